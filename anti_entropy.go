@@ -0,0 +1,275 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"net"
+	"sync/atomic"
+
+	metrics "github.com/hashicorp/go-metrics/compat"
+)
+
+/*
+Anti-entropy checks are a cheap way to spot divergence in between push/pull
+syncs. Nodes are spread over a fixed number of buckets by name, and each
+bucket is summarized by XOR-ing a hash of every node's name, incarnation and
+state, so the order of the nodes doesn't matter. The summary of all buckets is
+a two level Merkle tree that fits in a single packet.
+
+A member that gets a summary that differs from its own starts a delta
+push/pull with the sender, limited to the differing buckets.
+*/
+
+// summaryBuckets is the number of buckets in a state summary. It's part of
+// the protocol, so it must not change.
+const summaryBuckets = 64
+
+// stateSummary is sent to a random member by an anti-entropy check.
+type stateSummary struct {
+	Node    string
+	Root    uint64
+	Buckets []uint64
+}
+
+// summaryBucket returns the bucket a node belongs to.
+func summaryBucket(name string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	return int(h.Sum32() % summaryBuckets)
+}
+
+// bucketFilter returns a func that reports whether a node is in one of the
+// given buckets. With no buckets, every node is.
+func bucketFilter(buckets []int) func(name string) bool {
+	if len(buckets) == 0 {
+		return func(string) bool { return true }
+	}
+	set := make(map[int]struct{}, len(buckets))
+	for _, b := range buckets {
+		set[b] = struct{}{}
+	}
+	return func(name string) bool {
+		_, ok := set[summaryBucket(name)]
+		return ok
+	}
+}
+
+// summaryHash hashes the parts of a node's state the summary covers.
+func summaryHash(name string, incarnation uint32, state NodeStateType) uint64 {
+	var buf [5]byte
+	binary.BigEndian.PutUint32(buf[:4], incarnation)
+	buf[4] = byte(state)
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+	_, _ = h.Write(buf[:])
+	return h.Sum64()
+}
+
+// summaryRoot hashes the bucket summaries together.
+func summaryRoot(buckets []uint64) uint64 {
+	var buf [8]byte
+	h := fnv.New64a()
+	for _, b := range buckets {
+		binary.BigEndian.PutUint64(buf[:], b)
+		_, _ = h.Write(buf[:])
+	}
+	return h.Sum64()
+}
+
+// localSummary returns the summary of our node map.
+func (m *Memberlist) localSummary() stateSummary {
+	buckets := make([]uint64, summaryBuckets)
+
+	m.nodeLock.RLock()
+	for _, n := range m.nodes {
+		buckets[summaryBucket(n.Name)] ^= summaryHash(n.Name, n.Incarnation, n.State)
+	}
+	m.nodeLock.RUnlock()
+
+	return stateSummary{
+		Node:    m.config.Name,
+		Root:    summaryRoot(buckets),
+		Buckets: buckets,
+	}
+}
+
+// diffSummary returns the buckets where two summaries differ.
+func diffSummary(local, remote stateSummary) []int {
+	if local.Root == remote.Root || len(remote.Buckets) != len(local.Buckets) {
+		return nil
+	}
+
+	var diff []int
+	for i := range local.Buckets {
+		if local.Buckets[i] != remote.Buckets[i] {
+			diff = append(diff, i)
+		}
+	}
+	return diff
+}
+
+// antiEntropy is invoked every AntiEntropyInterval to send our state summary
+// to a random live member.
+func (m *Memberlist) antiEntropy() {
+	m.nodeLock.RLock()
+	nodes := kRandomNodes(1, m.nodes, func(n *nodeState) bool {
+		return n.Name == m.config.Name ||
+			n.State != StateAlive ||
+			!n.Supports(FeatureDeltaPushPull)
+	})
+	m.nodeLock.RUnlock()
+
+	if len(nodes) == 0 {
+		return
+	}
+	node := nodes[0]
+
+	summary := m.localSummary()
+	if err := m.encodeAndSendMsg(node.FullAddress(), stateSummaryMsg, &summary); err != nil {
+		m.logger.Printf("[ERR] memberlist: Failed to send state summary to %s: %s", node.Name, err)
+	}
+}
+
+// handleStateSummary compares a remote state summary with ours, and repairs
+// any differing buckets with the sender.
+func (m *Memberlist) handleStateSummary(buf []byte, from net.Addr) {
+	var remote stateSummary
+	if err := decode(buf, &remote); err != nil {
+		m.logger.Printf("[ERR] memberlist: Failed to decode state summary: %s %s", err, LogAddress(from))
+		return
+	}
+
+	diff := diffSummary(m.localSummary(), remote)
+	if len(diff) == 0 {
+		return
+	}
+	metrics.IncrCounterWithLabels([]string{"memberlist", "antientropy", "diverged"}, float32(len(diff)), m.metricLabels)
+
+	m.nodeLock.RLock()
+	state, ok := m.nodeMap[remote.Node]
+	var node Node
+	if ok {
+		ok = !state.DeadOrLeft()
+		node = state.Node
+	}
+	m.nodeLock.RUnlock()
+	if !ok {
+		return
+	}
+
+	// Only run one repair at a time, the next check will catch anything
+	// we skip here.
+	if !atomic.CompareAndSwapInt32(&m.repairing, 0, 1) {
+		return
+	}
+	go func() {
+		defer atomic.StoreInt32(&m.repairing, 0)
+		if err := m.deltaPushPull(node.StreamAddress(), diff); err != nil {
+			m.logger.Printf("[ERR] memberlist: Anti-entropy repair with %s failed: %s", node.Name, err)
+		}
+	}()
+}
+
+// gossipDigest is a tiny summary of a node map that's sent along with
+// gossip, see Config.DigestRepairThreshold.
+type gossipDigest struct {
+	Node  string
+	Count uint32
+	Hash  uint64
+}
+
+// localGossipDigest returns the digest of our node map. The hash is the XOR of
+// the same per-node hashes as in a state summary, which is kept up to date as
+// nodes change rather than worked out for every round of gossip.
+func (m *Memberlist) localGossipDigest() gossipDigest {
+	m.nodeLock.RLock()
+	defer m.nodeLock.RUnlock()
+
+	return gossipDigest{Node: m.config.Name, Count: uint32(len(m.nodes)), Hash: m.digestHash}
+}
+
+// flipDigest XORs a node's hash into the one in our gossip digest, which
+// adds the node if it isn't in yet and takes it out if it is. Call it before
+// and after changing a node's incarnation or state, and when adding or
+// removing a node. You must hold the node lock for writing.
+func (m *Memberlist) flipDigest(n *nodeState) {
+	m.digestHash ^= summaryHash(n.Name, n.Incarnation, n.State)
+}
+
+// encodeDigest returns our digest as a message to add to gossip, or nil if
+// we don't send them.
+func (m *Memberlist) encodeDigest() []byte {
+	if m.config.DigestRepairThreshold <= 0 {
+		return nil
+	}
+	d := m.localGossipDigest()
+	out, err := encode(gossipDigestMsg, &d, m.config.MsgpackUseNewTimeFormat)
+	if err != nil {
+		m.logger.Printf("[ERR] memberlist: Failed to encode gossip digest: %s", err)
+		return nil
+	}
+	return out.Bytes()
+}
+
+// handleGossipDigest compares a digest from gossip with ours, and does a
+// push/pull with the sender once enough of its digests in a row have
+// differed. A single difference is expected while broadcasts are still
+// spreading, so it takes a few to tell that something's been missed.
+func (m *Memberlist) handleGossipDigest(buf []byte, from net.Addr) {
+	var remote gossipDigest
+	if err := decode(buf, &remote); err != nil {
+		m.logger.Printf("[ERR] memberlist: Failed to decode gossip digest: %s %s", err, LogAddress(from))
+		return
+	}
+	threshold := m.config.DigestRepairThreshold
+	if threshold <= 0 {
+		return
+	}
+
+	local := m.localGossipDigest()
+	m.digestLock.Lock()
+	if local.Count == remote.Count && local.Hash == remote.Hash {
+		delete(m.digestMismatch, remote.Node)
+		m.digestLock.Unlock()
+		return
+	}
+	if m.digestMismatch == nil {
+		m.digestMismatch = make(map[string]int)
+	}
+	m.digestMismatch[remote.Node]++
+	if m.digestMismatch[remote.Node] < threshold {
+		m.digestLock.Unlock()
+		return
+	}
+	delete(m.digestMismatch, remote.Node)
+	m.digestLock.Unlock()
+	metrics.IncrCounterWithLabels([]string{"memberlist", "digest", "mismatch"}, 1, m.metricLabels)
+
+	m.nodeLock.RLock()
+	state, ok := m.nodeMap[remote.Node]
+	var node Node
+	if ok {
+		ok = !state.DeadOrLeft()
+		node = state.Node
+	}
+	m.nodeLock.RUnlock()
+	if !ok {
+		return
+	}
+
+	// Share the limit of one repair at a time with the anti-entropy
+	// checks, we'll be back here soon enough if this one's skipped.
+	if !atomic.CompareAndSwapInt32(&m.repairing, 0, 1) {
+		return
+	}
+	go func() {
+		defer atomic.StoreInt32(&m.repairing, 0)
+		if err := m.pushPullNode(node.StreamAddress(), false); err != nil {
+			m.logger.Printf("[ERR] memberlist: Digest repair with %s failed: %s", node.Name, err)
+		}
+	}()
+}
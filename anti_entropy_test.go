@@ -0,0 +1,277 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	iretry "github.com/hashicorp/memberlist/internal/retry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateSummary_Diff(t *testing.T) {
+	m := GetMemberlist(t, nil)
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	for _, a := range []alive{
+		{Node: "a", Addr: []byte{127, 0, 0, 1}, Incarnation: 1},
+		{Node: "b", Addr: []byte{127, 0, 0, 2}, Incarnation: 1},
+		{Node: "c", Addr: []byte{127, 0, 0, 3}, Incarnation: 1},
+	} {
+		a.Vsn = m.config.BuildVsnArray()
+		m.aliveNode(&a, nil, false)
+	}
+
+	before := m.localSummary()
+	require.Len(t, before.Buckets, summaryBuckets)
+	require.Empty(t, diffSummary(before, m.localSummary()))
+
+	// The summary doesn't depend on the order of the nodes.
+	m.nodeLock.Lock()
+	m.nodes[0], m.nodes[2] = m.nodes[2], m.nodes[0]
+	m.nodeLock.Unlock()
+	require.Equal(t, before, m.localSummary())
+
+	// A new incarnation only changes that node's bucket.
+	a := alive{Node: "b", Addr: []byte{127, 0, 0, 2}, Incarnation: 2, Vsn: m.config.BuildVsnArray()}
+	m.aliveNode(&a, nil, false)
+	require.Equal(t, []int{summaryBucket("b")}, diffSummary(m.localSummary(), before))
+
+	// So does the bucket-limited digest.
+	digest := m.localDigest([]int{summaryBucket("b")})
+	require.Len(t, digest, 1)
+	require.Equal(t, "b", digest[0].Name)
+}
+
+func TestMemberlist_DiffDigest_Buckets(t *testing.T) {
+	m := GetMemberlist(t, nil)
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	for _, a := range []alive{
+		{Node: "a", Addr: []byte{127, 0, 0, 1}, Incarnation: 1},
+		{Node: "b", Addr: []byte{127, 0, 0, 2}, Incarnation: 1},
+	} {
+		a.Vsn = m.config.BuildVsnArray()
+		m.aliveNode(&a, nil, false)
+	}
+	require.NotEqual(t, summaryBucket("a"), summaryBucket("b"))
+
+	// An empty digest of a's bucket shouldn't pull in b.
+	send, want := m.diffDigest(nil, []int{summaryBucket("a")})
+	require.Len(t, send, 1)
+	require.Equal(t, "a", send[0].Name)
+	require.Empty(t, want)
+}
+
+func TestMemberlist_AntiEntropyRepair(t *testing.T) {
+	c1 := testConfig(t)
+	c1.GossipInterval = 10 * time.Second
+	m1, err := Create(c1)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m1.Shutdown())
+	}()
+
+	c2 := testConfig(t)
+	c2.BindPort = m1.config.BindPort
+	c2.GossipInterval = 10 * time.Second
+	m2, err := Create(c2)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m2.Shutdown())
+	}()
+
+	_, err = m2.Join([]string{m1.config.Name + "/" + m1.config.BindAddr})
+	require.NoError(t, err)
+
+	// m1 learns about a node without gossiping it.
+	a := alive{Node: "x", Addr: []byte{127, 0, 0, 1}, Port: 7946, Incarnation: 1, Vsn: m1.config.BuildVsnArray()}
+	m1.aliveNode(&a, nil, false)
+	m1.broadcasts.Reset()
+
+	// m2 is the only member m1 can send its summary to, and it should repair
+	// the difference.
+	iretry.Run(t, func(r *iretry.R) {
+		m1.antiEntropy()
+		time.Sleep(10 * time.Millisecond)
+
+		m2.nodeLock.RLock()
+		x, ok := m2.nodeMap["x"]
+		alive := ok && x.State == StateAlive
+		m2.nodeLock.RUnlock()
+		if !alive {
+			r.Fatal("expected x to be alive")
+		}
+	})
+}
+
+func TestMemberlist_GossipDigestRepair(t *testing.T) {
+	c1 := testConfig(t)
+	c1.GossipInterval = 10 * time.Second
+	c1.DigestRepairThreshold = 3
+	m1, err := Create(c1)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m1.Shutdown())
+	}()
+
+	c2 := testConfig(t)
+	c2.BindPort = m1.config.BindPort
+	c2.GossipInterval = 10 * time.Second
+	c2.DigestRepairThreshold = 3
+	m2, err := Create(c2)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m2.Shutdown())
+	}()
+
+	_, err = m2.Join([]string{m1.config.Name + "/" + m1.config.BindAddr})
+	require.NoError(t, err)
+	require.Equal(t, m1.localGossipDigest().Hash, m2.localGossipDigest().Hash)
+
+	// m1 learns about a node without gossiping it.
+	a := alive{Node: "x", Addr: []byte{127, 0, 0, 1}, Port: 7946, Incarnation: 1, Vsn: m1.config.BuildVsnArray()}
+	m1.aliveNode(&a, nil, false)
+	m1.broadcasts.Reset()
+
+	hasX := func() bool {
+		m2.nodeLock.RLock()
+		defer m2.nodeLock.RUnlock()
+		x, ok := m2.nodeMap["x"]
+		return ok && x.State == StateAlive
+	}
+
+	// Below the threshold nothing happens.
+	m1.gossip()
+	m1.gossip()
+	time.Sleep(50 * time.Millisecond)
+	require.False(t, hasX())
+
+	iretry.Run(t, func(r *iretry.R) {
+		m1.gossip()
+		time.Sleep(10 * time.Millisecond)
+		if !hasX() {
+			r.Fatal("expected x to be alive")
+		}
+	})
+	require.Equal(t, m1.localGossipDigest().Hash, m2.localGossipDigest().Hash)
+}
+
+func TestMemberlist_GossipDigest_OnlyToSupportingNodes(t *testing.T) {
+	m := GetMemberlist(t, func(c *Config) {
+		c.GossipNodes = 3
+		c.DigestRepairThreshold = 1
+		c.EnableCompression = false
+	})
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+	require.NoError(t, m.setAlive())
+
+	// Stand-ins for a node that understands digests and one that doesn't.
+	listen := func() net.PacketConn {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = conn.Close() })
+		return conn
+	}
+	newConn, oldConn := listen(), listen()
+	vsn := m.config.BuildVsnArray()
+	oldVsn := []uint8{2, 8, 8, vsn[3], vsn[4], vsn[5]}
+	for name, node := range map[string]struct {
+		conn net.PacketConn
+		vsn  []uint8
+	}{"new": {newConn, vsn}, "old": {oldConn, oldVsn}} {
+		addr := node.conn.LocalAddr().(*net.UDPAddr)
+		a := alive{Node: name, Addr: addr.IP, Port: uint16(addr.Port), Incarnation: 1, Vsn: node.vsn}
+		m.aliveNode(&a, nil, false)
+	}
+
+	// received returns the types of the messages that arrive at conn.
+	received := func(conn net.PacketConn) map[messageType]bool {
+		types := make(map[messageType]bool)
+		buf := make([]byte, 65536)
+		for {
+			require.NoError(t, conn.SetReadDeadline(time.Now().Add(200*time.Millisecond)))
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				return types
+			}
+			msg := buf[:n]
+			if messageType(msg[0]) == hasCrcMsg {
+				msg = msg[5:]
+			}
+			parts := [][]byte{msg}
+			if messageType(msg[0]) == compoundMsg {
+				_, parts, err = decodeCompoundMessage(msg[1:])
+				require.NoError(t, err)
+			}
+			for _, part := range parts {
+				types[messageType(part[0])] = true
+			}
+		}
+	}
+
+	m.gossip()
+	require.True(t, received(newConn)[gossipDigestMsg])
+	old := received(oldConn)
+	require.NotEmpty(t, old)
+	require.False(t, old[gossipDigestMsg])
+}
+
+func TestMemberlist_GossipDigest_KeptUpToDate(t *testing.T) {
+	m := GetMemberlist(t, func(c *Config) {
+		c.GossipToTheDeadTime = 0
+	})
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+	require.NoError(t, m.setAlive())
+
+	// full works the hash out from scratch.
+	full := func() uint64 {
+		m.nodeLock.RLock()
+		defer m.nodeLock.RUnlock()
+		var h uint64
+		for _, n := range m.nodes {
+			h ^= summaryHash(n.Name, n.Incarnation, n.State)
+		}
+		return h
+	}
+	check := func() {
+		t.Helper()
+		require.Equal(t, full(), m.localGossipDigest().Hash)
+	}
+	check()
+
+	vsn := m.config.BuildVsnArray()
+	for i, name := range []string{"a", "b", "c"} {
+		a := alive{Node: name, Addr: []byte{127, 0, 0, byte(i + 2)}, Incarnation: 1, Vsn: vsn}
+		m.aliveNode(&a, nil, false)
+		check()
+	}
+	m.aliveNode(&alive{Node: "a", Addr: []byte{127, 0, 0, 2}, Incarnation: 2, Vsn: vsn}, nil, false)
+	check()
+	m.suspectNode(&suspect{Node: "b", Incarnation: 1, From: "a"})
+	check()
+	m.deadNode(&dead{Node: "c", Incarnation: 1, From: "a"})
+	check()
+	m.deadNode(&dead{Node: "b", Incarnation: 1, From: "b"})
+	check()
+
+	// Refuting bumps our own incarnation.
+	m.suspectNode(&suspect{Node: m.config.Name, Incarnation: 1, From: "a"})
+	check()
+
+	// Reaping takes the dead and left nodes out.
+	m.resetNodes()
+	require.Equal(t, uint32(2), m.localGossipDigest().Count)
+	check()
+}
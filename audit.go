@@ -0,0 +1,89 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// AuditHook is told about administrative actions, see Config.AuditHook. The
+// action is one of the Audit constants, the actor says who took it, the
+// target is what it was taken on, if anything, and the result is nil if it
+// succeeded.
+type AuditHook func(action, actor, target string, result error)
+
+// The actions reported to an AuditHook.
+const (
+	AuditJoin         = "join"
+	AuditLeave        = "leave"
+	AuditForceLeave   = "force-leave" // SetNodeState to StateDead
+	AuditForceSuspect = "force-suspect"
+	AuditForceAlive   = "force-alive"
+	AuditInstallKey   = "install-key" // Target is the KeyFingerprint
+	AuditUseKey       = "use-key"
+	AuditRemoveKey    = "remove-key"
+	AuditSetTuning    = "set-tuning"
+	AuditSetWeight    = "set-weight"
+	AuditMaintenance  = "maintenance"
+	AuditDrain        = "drain"
+	AuditUndrain      = "undrain"
+)
+
+// ActorLocal is the actor for actions the application takes by calling
+// Memberlist methods itself.
+const ActorLocal = "local"
+
+// Audit reports an administrative action to Config.AuditHook, if there is
+// one. Memberlist reports the calls made to it as ActorLocal, and those made
+// through an Admin as its actor; an admin API such as the control socket
+// also reports the other commands it takes, with its caller as the actor.
+func (m *Memberlist) Audit(action, actor, target string, result error) {
+	hook := m.config.AuditHook
+	if hook == nil {
+		return
+	}
+	m.guard("AuditHook", func() { hook(action, actor, target, result) })
+}
+
+// audit reports an action the application took.
+func (m *Memberlist) audit(action, target string, result error) {
+	m.Audit(action, ActorLocal, target, result)
+}
+
+// Admin takes administrative actions on a Memberlist for someone else, such
+// as the caller of an admin API, and reports them to the AuditHook with that
+// caller as the actor instead of ActorLocal.
+type Admin struct {
+	m     *Memberlist
+	actor string
+}
+
+// As returns an Admin that acts on the memberlist as the given actor.
+func (m *Memberlist) As(actor string) *Admin {
+	return &Admin{m: m, actor: actor}
+}
+
+// Join is Memberlist.Join, reported as the Admin's actor.
+func (a *Admin) Join(existing []string) (int, error) {
+	return a.m.joinAs(existing, a.actor)
+}
+
+// Leave is Memberlist.Leave, reported as the Admin's actor.
+func (a *Admin) Leave(timeout time.Duration) error {
+	return a.m.leaveAs(timeout, a.actor)
+}
+
+// SetNodeState is Memberlist.SetNodeState, reported as the Admin's actor.
+func (a *Admin) SetNodeState(node string, state NodeStateType, incarnation uint32) error {
+	return a.m.setNodeStateAs(node, state, incarnation, a.actor)
+}
+
+// KeyFingerprint returns a short identifier for an encryption key that's
+// safe to log, such as for the target of a key change.
+func KeyFingerprint(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,59 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type auditRecord struct {
+	action, actor, target string
+	failed                bool
+}
+
+// auditLog collects what an AuditHook is told.
+type auditLog struct {
+	lock    sync.Mutex
+	records []auditRecord
+}
+
+func (l *auditLog) hook(action, actor, target string, result error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.records = append(l.records, auditRecord{action, actor, target, result != nil})
+}
+
+func TestMemberlist_AuditHook(t *testing.T) {
+	var log auditLog
+	m := GetMemberlist(t, func(c *Config) {
+		c.AuditHook = log.hook
+	})
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+	require.NoError(t, m.setAlive())
+
+	require.NoError(t, m.SetWeight(42))
+	require.NoError(t, m.Drain())
+	require.NoError(t, m.SetMaintenance(time.Minute))
+	require.Error(t, m.SetNodeState("nope", StateDead, 1))
+	_, err := m.InstallKey([]byte("0123456789abcdef"), time.Second)
+	require.Error(t, err)
+	m.Audit(AuditForceLeave, "admin@example.com", "node-x", nil)
+	require.Error(t, m.As("admin@example.com").SetNodeState("nope", StateDead, 1))
+
+	require.Equal(t, []auditRecord{
+		{AuditSetWeight, ActorLocal, "42", false},
+		{AuditDrain, ActorLocal, "", false},
+		{AuditMaintenance, ActorLocal, "1m0s", false},
+		{AuditForceLeave, ActorLocal, "nope", true},
+		{AuditInstallKey, ActorLocal, KeyFingerprint([]byte("0123456789abcdef")), true},
+		{AuditForceLeave, "admin@example.com", "node-x", false},
+		{AuditForceLeave, "admin@example.com", "nope", true},
+	}, log.records)
+}
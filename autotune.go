@@ -0,0 +1,115 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"time"
+
+	metrics "github.com/hashicorp/go-metrics/compat"
+)
+
+const (
+	// autoTuneMinProbes is the fewest probes a loss rate is worked out
+	// from. Until there are this many, they carry over to the next pass.
+	autoTuneMinProbes = 10
+
+	// Loss rates above autoTuneLossHigh step the values up, and ones below
+	// autoTuneLossLow step them back down.
+	autoTuneLossHigh = 0.05
+	autoTuneLossLow  = 0.01
+)
+
+// lossCount counts probes of members that answered, and how many of those
+// only answered after the direct ping went unanswered.
+type lossCount struct {
+	probes int
+	lost   int
+}
+
+// AutoTuned holds the values auto-tuning has settled on. See
+// Config.AutoTuneInterval.
+type AutoTuned struct {
+	// RetransmitMult and ProbeTimeout are the values in effect.
+	RetransmitMult int
+	ProbeTimeout   time.Duration
+
+	// LossRate is the share of direct probes that were lost, as of the
+	// last time the values were looked at.
+	LossRate float64
+}
+
+// AutoTuned returns the values auto-tuning has settled on. Without
+// auto-tuning these are just the configured values.
+func (m *Memberlist) AutoTuned() AutoTuned {
+	m.autoTuneLock.Lock()
+	rate := m.autoTuneRate
+	m.autoTuneLock.Unlock()
+	return AutoTuned{
+		RetransmitMult: m.broadcasts.retransmitMult(),
+		ProbeTimeout:   m.probeTimeout(),
+		LossRate:       rate,
+	}
+}
+
+// probeTimeout returns how long to wait for an ack to a direct ping.
+func (m *Memberlist) probeTimeout() time.Duration {
+	return time.Duration(m.probeTimeoutNs.Load())
+}
+
+// recordProbeLoss counts a finished probe towards the loss rate. Probes of
+// members that never answered don't count, since that says more about the
+// member than the network.
+func (m *Memberlist) recordProbeLoss(t ProbeTrace) {
+	if m.config.AutoTuneInterval <= 0 || !t.Acked {
+		return
+	}
+
+	m.autoTuneLock.Lock()
+	defer m.autoTuneLock.Unlock()
+	m.autoTuneLoss.probes++
+	if t.Indirect > 0 || t.TCPFallback {
+		m.autoTuneLoss.lost++
+	}
+}
+
+// autoTune steps RetransmitMult and ProbeTimeout up or down according to
+// the loss rate since the last pass.
+func (m *Memberlist) autoTune() {
+	m.autoTuneLock.Lock()
+	count := m.autoTuneLoss
+	if count.probes < autoTuneMinProbes {
+		m.autoTuneLock.Unlock()
+		return
+	}
+	m.autoTuneLoss = lossCount{}
+	rate := float64(count.lost) / float64(count.probes)
+	m.autoTuneRate = rate
+	m.autoTuneLock.Unlock()
+
+	baseMult, baseTimeout := m.config.RetransmitMult, m.config.ProbeTimeout
+	maxMult := max(m.config.AutoTuneMaxMult, 1)
+	maxTimeout := min(baseTimeout*time.Duration(maxMult), m.Tuning().ProbeInterval/2)
+	step := baseTimeout / 4
+
+	mult, timeout := m.broadcasts.retransmitMult(), m.probeTimeout()
+	switch {
+	case rate > autoTuneLossHigh:
+		mult = min(mult+1, baseMult*maxMult)
+		timeout = min(timeout+step, max(maxTimeout, baseTimeout))
+	case rate < autoTuneLossLow:
+		mult = max(mult-1, baseMult)
+		timeout = max(timeout-step, baseTimeout)
+	}
+
+	if mult != m.broadcasts.retransmitMult() || timeout != m.probeTimeout() {
+		m.broadcasts.setRetransmitMult(mult)
+		m.probeTimeoutNs.Store(int64(timeout))
+		m.logger.Printf("[INFO] memberlist: Auto-tuned to retransmit mult %d, probe timeout %v, after losing %.1f%% of probes",
+			mult, timeout, rate*100)
+	}
+
+	metrics.SetGaugeWithLabels([]string{"memberlist", "autotune", "loss_rate"}, float32(rate), m.metricLabels)
+	metrics.SetGaugeWithLabels([]string{"memberlist", "autotune", "retransmit_mult"}, float32(mult), m.metricLabels)
+	metrics.SetGaugeWithLabels([]string{"memberlist", "autotune", "probe_timeout"}, float32(timeout.Milliseconds()), m.metricLabels)
+}
@@ -0,0 +1,65 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberlist_AutoTune(t *testing.T) {
+	m := GetMemberlist(t, func(c *Config) {
+		c.AutoTuneInterval = time.Hour
+		c.AutoTuneMaxMult = 2
+		c.RetransmitMult = 2
+		c.ProbeInterval = 10 * time.Second
+		c.ProbeTimeout = time.Second
+	})
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	probes := func(total, lost int) {
+		for i := 0; i < total; i++ {
+			m.recordProbeLoss(ProbeTrace{Acked: true, Indirect: boolInt(i < lost)})
+		}
+		// Members that never answer don't count.
+		m.recordProbeLoss(ProbeTrace{Indirect: 3})
+		m.autoTune()
+	}
+	require.Equal(t, AutoTuned{RetransmitMult: 2, ProbeTimeout: time.Second}, m.AutoTuned())
+
+	// Too few probes to go on.
+	probes(5, 5)
+	require.Equal(t, 2, m.AutoTuned().RetransmitMult)
+
+	// Those carry over, and losing a quarter steps things up.
+	probes(15, 0)
+	require.Equal(t, AutoTuned{RetransmitMult: 3, ProbeTimeout: 1250 * time.Millisecond, LossRate: 0.25}, m.AutoTuned())
+
+	// Only as far as the bounds.
+	for i := 0; i < 10; i++ {
+		probes(10, 5)
+	}
+	require.Equal(t, AutoTuned{RetransmitMult: 4, ProbeTimeout: 2 * time.Second, LossRate: 0.5}, m.AutoTuned())
+	require.Equal(t, 4, m.broadcasts.retransmitMult())
+
+	// Some loss holds steady, and none steps back down to the configured
+	// values.
+	probes(50, 1)
+	require.Equal(t, 4, m.AutoTuned().RetransmitMult)
+	for i := 0; i < 10; i++ {
+		probes(10, 0)
+	}
+	require.Equal(t, AutoTuned{RetransmitMult: 2, ProbeTimeout: time.Second}, m.AutoTuned())
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
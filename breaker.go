@@ -0,0 +1,76 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"net"
+	"time"
+
+	metrics "github.com/hashicorp/go-metrics/compat"
+)
+
+// peerBreaker counts consecutive send failures to a peer.
+type peerBreaker struct {
+	failures  int
+	openUntil time.Time
+}
+
+// sendResult records the outcome of sending to, or dialing, the named peer.
+// After SendFailureThreshold failures in a row the peer's breaker opens for
+// SendFailureCooldown, and it isn't picked for gossip or as an indirect probe
+// helper in that time. It's still probed directly, and after the cooldown
+// it's tried again; a further failure reopens the breaker straight away.
+func (m *Memberlist) sendResult(name string, err error) {
+	if name == "" || m.config.SendFailureThreshold <= 0 {
+		return
+	}
+
+	m.breakerLock.Lock()
+	defer m.breakerLock.Unlock()
+	if err == nil {
+		delete(m.breakers, name)
+		return
+	}
+
+	if m.breakers == nil {
+		m.breakers = make(map[string]*peerBreaker)
+	}
+	b, ok := m.breakers[name]
+	if !ok {
+		b = &peerBreaker{}
+		m.breakers[name] = b
+	}
+	b.failures++
+	if b.failures < m.config.SendFailureThreshold {
+		return
+	}
+	if !time.Now().Before(b.openUntil) {
+		m.logger.Printf("[WARN] memberlist: Not gossiping to %s for %v after %d failed sends",
+			name, m.config.SendFailureCooldown, b.failures)
+		metrics.IncrCounterWithLabels([]string{"memberlist", "breaker", "open"}, 1, m.metricLabels)
+	}
+	b.openUntil = time.Now().Add(m.config.SendFailureCooldown)
+}
+
+// breakerOpen returns true if we should avoid picking the named peer for
+// gossip or indirect probes.
+func (m *Memberlist) breakerOpen(name string) bool {
+	m.breakerLock.Lock()
+	defer m.breakerLock.Unlock()
+	b, ok := m.breakers[name]
+	return ok && time.Now().Before(b.openUntil)
+}
+
+// dialNode opens a stream to a node, recording the outcome for its breaker.
+func (m *Memberlist) dialNode(a Address, timeout time.Duration) (net.Conn, error) {
+	conn, err := m.dialStream(a, timeout)
+	if err == nil {
+		if err = m.authorizeStream(conn, a.Name); err != nil {
+			_ = conn.Close()
+			conn = nil
+		}
+	}
+	m.sendResult(a.Name, err)
+	return conn, err
+}
@@ -0,0 +1,114 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberlist_SendBreaker(t *testing.T) {
+	m := GetMemberlist(t, func(c *Config) {
+		c.SendFailureThreshold = 2
+		c.SendFailureCooldown = 50 * time.Millisecond
+	})
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	errSend := errors.New("unreachable")
+	m.sendResult("node", errSend)
+	require.False(t, m.breakerOpen("node"))
+	m.sendResult("node", errSend)
+	require.True(t, m.breakerOpen("node"))
+
+	// Half open after the cooldown, and a single failure reopens it.
+	time.Sleep(60 * time.Millisecond)
+	require.False(t, m.breakerOpen("node"))
+	m.sendResult("node", errSend)
+	require.True(t, m.breakerOpen("node"))
+
+	// A success closes it.
+	m.sendResult("node", nil)
+	require.False(t, m.breakerOpen("node"))
+	m.sendResult("node", errSend)
+	require.False(t, m.breakerOpen("node"))
+
+	// Unnamed addresses aren't tracked.
+	m.sendResult("", errSend)
+	m.sendResult("", errSend)
+	require.False(t, m.breakerOpen(""))
+}
+
+func TestMemberlist_SendBreaker_Disabled(t *testing.T) {
+	m := GetMemberlist(t, func(c *Config) {
+		c.SendFailureThreshold = 0
+	})
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	for i := 0; i < 5; i++ {
+		m.sendResult("node", errors.New("unreachable"))
+	}
+	require.False(t, m.breakerOpen("node"))
+}
+
+// sentToTransport records the names of the nodes packets are sent to.
+type sentToTransport struct {
+	NodeAwareTransport
+	lock sync.Mutex
+	sent map[string]int
+}
+
+func (t *sentToTransport) WriteToAddress(b []byte, addr Address) (time.Time, error) {
+	t.lock.Lock()
+	t.sent[addr.Name]++
+	t.lock.Unlock()
+	return time.Now(), nil
+}
+
+func TestMemberlist_SendBreaker_SkipsGossip(t *testing.T) {
+	var tr *sentToTransport
+	m := GetMemberlist(t, func(c *Config) {
+		c.GossipNodes = 3
+		c.RetransmitMult = 10
+		c.SendFailureThreshold = 1
+		c.SendFailureCooldown = time.Minute
+
+		nt, err := NewNetTransport(&NetTransportConfig{
+			BindAddrs: []string{c.BindAddr},
+			Logger:    c.Logger,
+		})
+		require.NoError(t, err)
+		tr = &sentToTransport{NodeAwareTransport: nt, sent: make(map[string]int)}
+		c.Transport = tr
+		c.BindPort = nt.GetAutoBindPort()
+	})
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	for i, name := range []string{"a", "b", "c"} {
+		a := alive{
+			Node:        name,
+			Addr:        net.IPv4(127, 0, 0, byte(10+i)),
+			Port:        7946,
+			Incarnation: 1,
+			Vsn:         m.config.BuildVsnArray(),
+		}
+		m.aliveNode(&a, nil, false)
+	}
+	m.sendResult("b", errors.New("unreachable"))
+
+	m.gossip()
+	tr.lock.Lock()
+	defer tr.lock.Unlock()
+	require.Equal(t, map[string]int{"a": 1, "c": 1}, tr.sent)
+}
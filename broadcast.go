@@ -3,6 +3,10 @@
 
 package memberlist
 
+import (
+	"bytes"
+)
+
 /*
 The broadcast mechanism works by maintaining a sorted list of messages to be
 sent out. When a message is to be broadcast, the retransmit count
@@ -13,8 +17,18 @@ a message hits the retransmit limit, it is removed from the queue.
 Additionally, older entries can be invalidated by new messages that
 are contradictory. For example, if we send "{suspect M1 inc: 1},
 then a following {alive M1 inc: 2} will invalidate that message
+
+A message identical to one that's already queued, such as the same rumor
+heard from several peers, doesn't replace it, so it isn't sent any more
+times than a single copy would be.
 */
 
+// duplicateBroadcast is implemented by named broadcasts that can tell they
+// carry the same message as the queued broadcast with the same name.
+type duplicateBroadcast interface {
+	duplicates(other Broadcast) bool
+}
+
 type memberlistBroadcast struct {
 	node   string
 	msg    []byte
@@ -32,11 +46,24 @@ func (b *memberlistBroadcast) Invalidates(other Broadcast) bool {
 	return b.node == mb.node
 }
 
+// duplicates returns true if other is the same message about the same node.
+// Broadcasts that notify when they're done are never treated as duplicates,
+// since the caller is waiting on their own transmits.
+func (b *memberlistBroadcast) duplicates(other Broadcast) bool {
+	mb, ok := other.(*memberlistBroadcast)
+	return ok && b.notify == nil && b.node == mb.node && bytes.Equal(b.msg, mb.msg)
+}
+
 // memberlist.NamedBroadcast optional interface
 func (b *memberlistBroadcast) Name() string {
 	return b.node
 }
 
+// memberlist.PrioritizedBroadcast optional interface
+func (b *memberlistBroadcast) Priority() BroadcastPriority {
+	return PrioritySystem
+}
+
 func (b *memberlistBroadcast) Message() []byte {
 	return b.msg
 }
@@ -48,6 +75,32 @@ func (b *memberlistBroadcast) Finished() {
 	}
 }
 
+// trackedBroadcast is a memberlistBroadcast that records whether it was sent
+// the full number of times before it left the queue.
+type trackedBroadcast struct {
+	memberlistBroadcast
+
+	// transmitted is written by the queue right before Finished is called,
+	// so it's safe to read once the notification has arrived.
+	transmitted bool
+}
+
+func (b *trackedBroadcast) markTransmitted() {
+	b.transmitted = true
+}
+
+// userBroadcast is a memberlistBroadcast of a user message, retransmitted
+// according to Config.UserRetransmitMult rather than the queue's multiplier.
+type userBroadcast struct {
+	memberlistBroadcast
+	mult int
+}
+
+// retransmitMulter optional interface
+func (b *userBroadcast) retransmitMult() int {
+	return b.mult
+}
+
 // encodeAndBroadcast encodes a message and enqueues it for broadcast. Fails
 // silently if there is an encoding error.
 func (m *Memberlist) encodeAndBroadcast(node string, msgType messageType, msg interface{}) {
@@ -74,6 +127,27 @@ func (m *Memberlist) queueBroadcast(node string, msg []byte, notify chan struct{
 	m.broadcasts.QueueBroadcast(b)
 }
 
+// queueUserBroadcast is like queueBroadcast, but for user messages, which
+// are retransmitted according to Config.UserRetransmitMult.
+func (m *Memberlist) queueUserBroadcast(name string, msg []byte) {
+	b := &userBroadcast{memberlistBroadcast: memberlistBroadcast{name, msg, nil}, mult: m.config.UserRetransmitMult}
+	m.broadcasts.QueueBroadcast(b)
+}
+
+// encodeTrackedBroadcast is like encodeBroadcastNotify, but returns the
+// queued broadcast so the caller can check whether it went out the full
+// number of times. It returns nil if there is an encoding error.
+func (m *Memberlist) encodeTrackedBroadcast(node string, msgType messageType, msg interface{}, notify chan struct{}) *trackedBroadcast {
+	buf, err := encode(msgType, msg, m.config.MsgpackUseNewTimeFormat)
+	if err != nil {
+		m.logger.Printf("[ERR] memberlist: Failed to encode message for broadcast: %s", err)
+		return nil
+	}
+	b := &trackedBroadcast{memberlistBroadcast: memberlistBroadcast{node, buf.Bytes(), notify}}
+	m.broadcasts.QueueBroadcast(b)
+	return b
+}
+
 // getBroadcasts is used to return a slice of broadcasts to send up to
 // a maximum byte size, while imposing a per-broadcast overhead. This is used
 // to fill a UDP packet with piggybacked data
@@ -82,7 +156,7 @@ func (m *Memberlist) getBroadcasts(overhead, limit int) [][]byte {
 	toSend := m.broadcasts.GetBroadcasts(overhead, limit)
 
 	// Check if the user has anything to broadcast
-	d := m.config.Delegate
+	d := m.delegate()
 	if d != nil {
 		// Determine the bytes used already
 		bytesUsed := 0
@@ -92,11 +166,28 @@ func (m *Memberlist) getBroadcasts(overhead, limit int) [][]byte {
 
 		// Check space remaining for user messages
 		avail := limit - bytesUsed
-		if avail > overhead+userMsgOverhead {
-			userMsgs := d.GetBroadcasts(overhead+userMsgOverhead, avail)
-
-			// Frame each user message
+		framing := userMsgOverhead
+		_, timed := d.(LamportDelegate)
+		if timed {
+			framing = timedUserMsgOverhead
+		}
+		if avail > overhead+framing {
+			var userMsgs [][]byte
+			m.guard("GetBroadcasts", func() { userMsgs = d.GetBroadcasts(overhead+framing, avail) })
+
+			// Frame each user message, stamping the batch if asked to
+			var ltime LamportTime
+			if timed && len(userMsgs) > 0 {
+				ltime = m.clock.Increment()
+			}
 			for _, msg := range userMsgs {
+				if !m.userMsgAllowed(len(msg)) {
+					continue
+				}
+				if timed {
+					toSend = append(toSend, stampUserMsg(msg, ltime))
+					continue
+				}
 				buf := make([]byte, 1, len(msg)+1)
 				buf[0] = byte(userMsg)
 				buf = append(buf, msg...)
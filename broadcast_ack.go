@@ -0,0 +1,271 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+
+	metrics "github.com/hashicorp/go-metrics/compat"
+)
+
+/*
+An acknowledged broadcast is gossiped like any other broadcast, except that
+every member that receives it for the first time also queues it for gossip
+itself, so it spreads epidemically rather than only from the sender. Each
+member sends a small ack straight back to the sender, and through
+Config.RelayFactor other members if relaying is enabled, so the sender can
+see how far it got.
+*/
+
+// maxSeenBroadcasts bounds how many acknowledged broadcasts a member
+// remembers having seen.
+const maxSeenBroadcasts = 4096
+
+// AckSummary describes how far a broadcast sent with BroadcastWithAck got.
+type AckSummary struct {
+	// Acked are the members that acknowledged the broadcast.
+	Acked []string
+
+	// Missing are the members that were alive when the summary was made but
+	// didn't acknowledge the broadcast.
+	Missing []string
+
+	// Elapsed is how long it took to get the acks, which is the full timeout
+	// unless every member acknowledged the broadcast before then.
+	Elapsed time.Duration
+}
+
+// pendingBroadcast tracks the acks for a broadcast we sent.
+type pendingBroadcast struct {
+	start time.Time
+	acked map[string]struct{}
+	timer *time.Timer
+	ch    chan AckSummary
+}
+
+// BroadcastWithAck gossips a user message to the whole cluster, and reports
+// which members acknowledged it within the timeout. Each member receives the
+// message once, through its delegate's NotifyMsg. The sender only gets it if
+// Config.DeliverOwnBroadcasts is set, and then before this returns. The
+// returned channel gets a single summary once the timeout passes, or sooner
+// if every live member has acknowledged the message, and is then closed.
+//
+// Acks are best effort, so a member missing from the summary may still have
+// received the message. Members running a version of memberlist without
+// support for acknowledged broadcasts drop the message.
+func (m *Memberlist) BroadcastWithAck(msg []byte, timeout time.Duration) (<-chan AckSummary, error) {
+	if m.hasShutdown() {
+		return nil, ErrAlreadyShutdown
+	}
+	if timeout <= 0 {
+		return nil, fmt.Errorf("timeout must be positive")
+	}
+	if err := m.checkUserMsgSize(len(msg)); err != nil {
+		return nil, err
+	}
+
+	b := ackedBroadcast{
+		ID:      m.broadcastID.Add(1),
+		Origin:  m.config.Name,
+		Timeout: timeout,
+		Payload: msg,
+	}
+	buf, err := encode(ackedUserMsg, &b, m.config.MsgpackUseNewTimeFormat)
+	if err != nil {
+		return nil, err
+	}
+	if budget := m.config.UDPBufferSize - compoundHeaderOverhead - compoundOverhead - m.packetOverhead(); buf.Len() > budget {
+		return nil, ErrMessageTooLarge{Limit: budget - (buf.Len() - len(msg)), Size: len(msg)}
+	}
+
+	ch := m.trackBroadcast(b.ID, timeout)
+	m.queueUserBroadcast(broadcastKey(b.Origin, b.ID), buf.Bytes())
+	if m.config.DeliverOwnBroadcasts {
+		// Straight to the delegate, not through the workers, so it has
+		// seen the message by the time we return.
+		m.deliverUser(userMessage{buf: msg})
+	}
+	return ch, nil
+}
+
+// trackBroadcast starts collecting acks for a broadcast we're about to send,
+// returning the channel its summary is sent on.
+func (m *Memberlist) trackBroadcast(id uint32, timeout time.Duration) <-chan AckSummary {
+	p := &pendingBroadcast{
+		start: time.Now(),
+		acked: make(map[string]struct{}),
+		ch:    make(chan AckSummary, 1),
+	}
+	m.broadcastLock.Lock()
+	if m.pendingBroadcasts == nil {
+		m.pendingBroadcasts = make(map[uint32]*pendingBroadcast)
+	}
+	m.pendingBroadcasts[id] = p
+	p.timer = time.AfterFunc(timeout, func() { m.finishBroadcast(id) })
+	m.broadcastLock.Unlock()
+	return p.ch
+}
+
+// broadcastKey names an acknowledged broadcast in the broadcast queue. Node
+// names can't contain a NUL, so it can't be taken for a message about a node.
+func broadcastKey(origin string, id uint32) string {
+	return "\x00ack/" + origin + "/" + strconv.FormatUint(uint64(id), 10)
+}
+
+// handleAckedUser delivers an acknowledged broadcast we haven't seen yet,
+// passes it on and acknowledges it.
+func (m *Memberlist) handleAckedUser(buf []byte, from net.Addr) {
+	var b ackedBroadcast
+	if err := decode(buf, &b); err != nil {
+		m.logger.Printf("[ERR] memberlist: Failed to decode acked broadcast: %s %s", err, LogAddress(from))
+		return
+	}
+	if b.Origin == m.config.Name || !m.firstSeen(b.Origin, b.ID, b.Timeout) {
+		return
+	}
+
+	// Pass it on as it came, since we can't change it anyway
+	out := make([]byte, 1, len(buf)+1)
+	out[0] = byte(ackedUserMsg)
+	out = append(out, buf...)
+	m.queueUserBroadcast(broadcastKey(b.Origin, b.ID), out)
+
+	m.notifyMsg(b.Payload)
+	m.sendBroadcastAck(b.Origin, b.ID)
+}
+
+// firstSeen records an acknowledged broadcast, and returns true if it's the
+// first time we've seen it. We remember it for as long as its sender waits
+// for acks.
+func (m *Memberlist) firstSeen(origin string, id uint32, timeout time.Duration) bool {
+	key := broadcastKey(origin, id)
+	now := time.Now()
+
+	m.broadcastLock.Lock()
+	defer m.broadcastLock.Unlock()
+
+	if m.seenBroadcasts == nil {
+		m.seenBroadcasts = make(map[string]time.Time)
+	}
+	if until, ok := m.seenBroadcasts[key]; ok && now.Before(until) {
+		return false
+	}
+	if len(m.seenBroadcasts) >= maxSeenBroadcasts {
+		for k, until := range m.seenBroadcasts {
+			if !now.Before(until) {
+				delete(m.seenBroadcasts, k)
+			}
+		}
+		if len(m.seenBroadcasts) >= maxSeenBroadcasts {
+			m.logger.Printf("[WARN] memberlist: Too many acked broadcasts, dropping one from %s", origin)
+			return false
+		}
+	}
+	m.seenBroadcasts[key] = now.Add(timeout)
+	return true
+}
+
+// sendBroadcastAck acknowledges a broadcast to the member that sent it.
+func (m *Memberlist) sendBroadcastAck(origin string, id uint32) {
+	m.nodeLock.RLock()
+	state, ok := m.nodeMap[origin]
+	var node Node
+	if ok {
+		node = state.Node
+	}
+	m.nodeLock.RUnlock()
+	if !ok || state.DeadOrLeft() {
+		return
+	}
+
+	out, err := encode(broadcastAckMsg, &broadcastAck{ID: id, Node: m.config.Name}, m.config.MsgpackUseNewTimeFormat)
+	if err != nil {
+		m.logger.Printf("[ERR] memberlist: Failed to encode broadcast ack: %s", err)
+		return
+	}
+	a := Address{Addr: node.Address(), Name: node.Name}
+	err = m.rawSendMsgPacket(a, &node, out.Bytes())
+	if m.config.RelayFactor > 0 {
+		if rerr := m.relayUserMsg(&node, out.Bytes()); rerr != nil && err != nil {
+			m.logger.Printf("[WARN] memberlist: Failed to send broadcast ack to %s: %s", origin, err)
+		}
+	} else if err != nil {
+		m.logger.Printf("[WARN] memberlist: Failed to send broadcast ack to %s: %s", origin, err)
+	}
+}
+
+// handleBroadcastAck records an ack for a broadcast we sent.
+func (m *Memberlist) handleBroadcastAck(buf []byte, from net.Addr) {
+	var ack broadcastAck
+	if err := decode(buf, &ack); err != nil {
+		m.logger.Printf("[ERR] memberlist: Failed to decode broadcast ack: %s %s", err, LogAddress(from))
+		return
+	}
+
+	m.broadcastLock.Lock()
+	p, ok := m.pendingBroadcasts[ack.ID]
+	if ok {
+		p.acked[ack.Node] = struct{}{}
+	}
+	m.broadcastLock.Unlock()
+	if !ok {
+		return
+	}
+	metrics.IncrCounterWithLabels([]string{"memberlist", "broadcast", "acked"}, 1, m.metricLabels)
+
+	if len(m.missingAcks(p)) == 0 {
+		m.finishBroadcast(ack.ID)
+	}
+}
+
+// missingAcks returns the live members that haven't acknowledged a
+// broadcast yet.
+func (m *Memberlist) missingAcks(p *pendingBroadcast) []string {
+	m.nodeLock.RLock()
+	defer m.nodeLock.RUnlock()
+
+	m.broadcastLock.Lock()
+	defer m.broadcastLock.Unlock()
+
+	var missing []string
+	for _, n := range m.nodes {
+		if n.Name == m.config.Name || n.State != StateAlive {
+			continue
+		}
+		if _, ok := p.acked[n.Name]; !ok {
+			missing = append(missing, n.Name)
+		}
+	}
+	return missing
+}
+
+// finishBroadcast sends the summary of a broadcast we sent, unless that's
+// already been done.
+func (m *Memberlist) finishBroadcast(id uint32) {
+	m.broadcastLock.Lock()
+	p, ok := m.pendingBroadcasts[id]
+	delete(m.pendingBroadcasts, id)
+	m.broadcastLock.Unlock()
+	if !ok {
+		return
+	}
+	p.timer.Stop()
+
+	summary := AckSummary{
+		Missing: m.missingAcks(p),
+		Elapsed: time.Since(p.start),
+	}
+	for name := range p.acked {
+		summary.Acked = append(summary.Acked, name)
+	}
+	sort.Strings(summary.Acked)
+	sort.Strings(summary.Missing)
+
+	p.ch <- summary
+	close(p.ch)
+}
@@ -0,0 +1,142 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberlist_BroadcastWithAck(t *testing.T) {
+	var (
+		ms []*Memberlist
+		ds []*MockDelegate
+	)
+	for i := 0; i < 3; i++ {
+		d := &MockDelegate{}
+		c := testConfig(t)
+		c.GossipInterval = 10 * time.Millisecond
+		c.Delegate = d
+		if i > 0 {
+			c.BindPort = ms[0].config.BindPort
+		}
+		m, err := Create(c)
+		require.NoError(t, err)
+		defer m.Shutdown()
+		if i > 0 {
+			_, err := m.Join([]string{ms[0].config.Name + "/" + ms[0].config.BindAddr})
+			require.NoError(t, err)
+		}
+		ms = append(ms, m)
+		ds = append(ds, d)
+	}
+
+	ch, err := ms[0].BroadcastWithAck([]byte("hello"), 5*time.Second)
+	require.NoError(t, err)
+
+	// Everyone acks, so the summary arrives before the timeout
+	var summary AckSummary
+	select {
+	case summary = <-ch:
+	case <-time.After(4 * time.Second):
+		t.Fatal("timed out waiting for the acks")
+	}
+	require.Equal(t, []string{ms[1].config.Name, ms[2].config.Name}, summary.Acked)
+	require.Empty(t, summary.Missing)
+	_, ok := <-ch
+	require.False(t, ok)
+
+	// Each member got the message once, and the sender didn't get it
+	for i, d := range ds {
+		if i == 0 {
+			require.Empty(t, d.getMessages())
+		} else {
+			require.Equal(t, [][]byte{[]byte("hello")}, d.getMessages())
+		}
+	}
+}
+
+func TestMemberlist_BroadcastWithAck_Missing(t *testing.T) {
+	m := GetMemberlist(t, nil)
+	defer m.Shutdown()
+	for _, name := range []string{"a", "b"} {
+		a := alive{Node: name, Addr: []byte{127, 0, 0, 1}, Port: 1, Incarnation: 1}
+		m.aliveNode(&a, nil, false)
+	}
+
+	ch, err := m.BroadcastWithAck([]byte("hello"), 50*time.Millisecond)
+	require.NoError(t, err)
+
+	buf, err := encode(broadcastAckMsg, &broadcastAck{ID: 1, Node: "a"}, false)
+	require.NoError(t, err)
+	m.handleBroadcastAck(buf.Bytes()[1:], nil)
+
+	summary := <-ch
+	require.Equal(t, []string{"a"}, summary.Acked)
+	require.Equal(t, []string{"b"}, summary.Missing)
+	require.GreaterOrEqual(t, summary.Elapsed, 50*time.Millisecond)
+
+	_, err = m.BroadcastWithAck([]byte("hello"), 0)
+	require.Error(t, err)
+}
+
+func TestMemberlist_HandleAckedUser(t *testing.T) {
+	d := &MockDelegate{}
+	m := GetMemberlist(t, func(c *Config) { c.Delegate = d })
+	defer m.Shutdown()
+
+	buf, err := encode(ackedUserMsg, &ackedBroadcast{ID: 7, Origin: "other", Timeout: time.Minute, Payload: []byte("hi")}, false)
+	require.NoError(t, err)
+	from := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1}
+
+	// Only the first copy is delivered and passed on
+	m.handleAckedUser(buf.Bytes()[1:], from)
+	m.handleAckedUser(buf.Bytes()[1:], from)
+	require.Equal(t, [][]byte{[]byte("hi")}, d.getMessages())
+	require.Equal(t, 1, m.broadcasts.NumQueued())
+	require.Equal(t, buf.Bytes(), m.broadcasts.GetBroadcasts(0, 1400)[0])
+}
+
+func TestMemberlist_BroadcastWithAck_UserRetransmitMult(t *testing.T) {
+	m := GetMemberlist(t, func(c *Config) { c.UserRetransmitMult = 7 })
+	defer m.Shutdown()
+
+	_, err := m.BroadcastWithAck([]byte("hello"), time.Minute)
+	require.NoError(t, err)
+	m.encodeAndBroadcast("node", deadMsg, &dead{Node: "node"})
+
+	var mults []int
+	for _, lb := range m.broadcasts.orderedView(false) {
+		mult := 0
+		if rm, ok := lb.b.(retransmitMulter); ok {
+			mult = rm.retransmitMult()
+		}
+		mults = append(mults, mult)
+	}
+	require.ElementsMatch(t, []int{7, 0}, mults)
+}
+
+func TestMemberlist_BroadcastWithAck_DeliverOwn(t *testing.T) {
+	d := &MockDelegate{}
+	m := GetMemberlist(t, func(c *Config) {
+		c.Delegate = d
+		c.NotifyMsgWorkers = 1
+		c.DeliverOwnBroadcasts = true
+	})
+	defer m.Shutdown()
+
+	// Delivered before the call returns, even with the workers in between
+	_, err := m.BroadcastWithAck([]byte("mine"), time.Second)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{[]byte("mine")}, d.getMessages())
+
+	// The copy that comes back through gossip isn't delivered again
+	buf, err := encode(ackedUserMsg, &ackedBroadcast{ID: 1, Origin: m.config.Name, Timeout: time.Second, Payload: []byte("mine")}, false)
+	require.NoError(t, err)
+	m.handleAckedUser(buf.Bytes()[1:], nil)
+	require.Len(t, d.getMessages(), 1)
+}
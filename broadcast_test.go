@@ -6,6 +6,8 @@ package memberlist
 import (
 	"reflect"
 	"testing"
+
+	"github.com/stretchr/testify/require"
 )
 
 func TestMemberlistBroadcast_Invalidates(t *testing.T) {
@@ -28,3 +30,53 @@ func TestMemberlistBroadcast_Message(t *testing.T) {
 		t.Fatalf("messages do not match")
 	}
 }
+
+func TestTrackedBroadcast(t *testing.T) {
+	q := &TransmitLimitedQueue{RetransmitMult: 1, NumNodes: func() int { return 1 }}
+
+	ch := make(chan struct{}, 1)
+	b := &trackedBroadcast{memberlistBroadcast: memberlistBroadcast{"test", []byte("test"), ch}}
+	q.QueueBroadcast(b)
+
+	// Invalidation finishes the broadcast without it going out.
+	q.QueueBroadcast(&memberlistBroadcast{"test", []byte("newer"), nil})
+	<-ch
+	require.False(t, b.transmitted)
+
+	b = &trackedBroadcast{memberlistBroadcast: memberlistBroadcast{"other", []byte("test"), ch}}
+	q.QueueBroadcast(b)
+	for q.NumQueued() > 0 {
+		q.GetBroadcasts(0, 1000)
+	}
+	<-ch
+	require.True(t, b.transmitted)
+}
+
+func TestMemberlistBroadcast_Duplicates(t *testing.T) {
+	q := &TransmitLimitedQueue{RetransmitMult: 2, NumNodes: func() int { return 10 }}
+	q.QueueBroadcast(&memberlistBroadcast{"test", []byte("suspect"), nil})
+	q.QueueBroadcast(&memberlistBroadcast{"other", []byte("suspect"), nil})
+	require.Len(t, q.GetBroadcasts(0, 100), 2)
+
+	// The same message again keeps its transmit count.
+	q.QueueBroadcast(&memberlistBroadcast{"test", []byte("suspect"), nil})
+	require.Equal(t, uint64(1), q.Stats().Deduped)
+	dump := q.orderedView(false)
+	require.Len(t, dump, 2)
+	for _, lb := range dump {
+		require.Equal(t, 1, lb.transmits)
+	}
+
+	// A different message about the node replaces it.
+	q.QueueBroadcast(&memberlistBroadcast{"test", []byte("dead"), nil})
+	require.Equal(t, uint64(1), q.Stats().Deduped)
+	dump = q.orderedView(false)
+	require.Equal(t, "test", dump[0].b.(*memberlistBroadcast).node)
+	require.Equal(t, 0, dump[0].transmits)
+
+	// So does the same message when the caller wants to be notified.
+	ch := make(chan struct{}, 1)
+	q.QueueBroadcast(&memberlistBroadcast{"test", []byte("dead"), ch})
+	require.Equal(t, uint64(1), q.Stats().Deduped)
+	require.Equal(t, 2, q.NumQueued())
+}
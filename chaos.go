@@ -0,0 +1,142 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	metrics "github.com/hashicorp/go-metrics/compat"
+)
+
+// ErrChaosDisabled is returned when a failure is injected without
+// Config.EnableChaos set.
+var ErrChaosDisabled = errors.New("memberlist: failure injection is not enabled")
+
+// Chaos injects failures into the local member, for running controlled
+// experiments on the failure detector in a staging cluster. Each experiment
+// runs for the period it's given and then ends by itself, so one that's
+// forgotten can't linger. Starting an experiment again replaces the one
+// that's running. It's safe for concurrent use.
+type Chaos struct {
+	m *Memberlist
+
+	lock          sync.Mutex
+	dropPercent   float64
+	dropUntil     time.Time
+	ackDelay      time.Duration
+	ackDelayUntil time.Time
+	muteUntil     time.Time
+}
+
+// Chaos returns the switches for injecting failures into this member. They
+// return ErrChaosDisabled unless Config.EnableChaos is set.
+func (m *Memberlist) Chaos() *Chaos {
+	return &m.chaos
+}
+
+// start checks an experiment can be started for the duration d.
+func (c *Chaos) start(d time.Duration) error {
+	if !c.m.config.EnableChaos {
+		return ErrChaosDisabled
+	}
+	if d <= 0 {
+		return fmt.Errorf("chaos duration must be positive")
+	}
+	return nil
+}
+
+// DropPackets drops the given percentage of the packets we send, chosen at
+// random, for the duration d. Streams aren't affected.
+func (c *Chaos) DropPackets(percent float64, d time.Duration) error {
+	if err := c.start(d); err != nil {
+		return err
+	}
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("drop percentage %v is out of range", percent)
+	}
+	c.lock.Lock()
+	c.dropPercent, c.dropUntil = percent, time.Now().Add(d)
+	c.lock.Unlock()
+	c.m.logger.Printf("[WARN] memberlist: Chaos: Dropping %v%% of outgoing packets for %s", percent, d)
+	return nil
+}
+
+// DelayAcks holds back every ack we send, including those relayed for an
+// indirect probe, by delay for the duration d.
+func (c *Chaos) DelayAcks(delay, d time.Duration) error {
+	if err := c.start(d); err != nil {
+		return err
+	}
+	if delay < 0 {
+		return fmt.Errorf("ack delay can't be negative")
+	}
+	c.lock.Lock()
+	c.ackDelay, c.ackDelayUntil = delay, time.Now().Add(d)
+	c.lock.Unlock()
+	c.m.logger.Printf("[WARN] memberlist: Chaos: Delaying acks by %s for %s", delay, d)
+	return nil
+}
+
+// MuteGossip stops us gossiping for the duration d, both in gossip rounds
+// and piggybacked on other messages. Broadcasts stay queued until it ends.
+func (c *Chaos) MuteGossip(d time.Duration) error {
+	if err := c.start(d); err != nil {
+		return err
+	}
+	c.lock.Lock()
+	c.muteUntil = time.Now().Add(d)
+	c.lock.Unlock()
+	c.m.logger.Printf("[WARN] memberlist: Chaos: Muting gossip for %s", d)
+	return nil
+}
+
+// Stop ends all the experiments early.
+func (c *Chaos) Stop() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.dropUntil, c.ackDelayUntil, c.muteUntil = time.Time{}, time.Time{}, time.Time{}
+}
+
+// dropPacket returns true if an outgoing packet should be dropped.
+func (c *Chaos) dropPacket() bool {
+	if !c.m.config.EnableChaos {
+		return false
+	}
+	c.lock.Lock()
+	active := time.Now().Before(c.dropUntil)
+	percent := c.dropPercent
+	c.lock.Unlock()
+	if !active || rand.Float64()*100 >= percent {
+		return false
+	}
+	metrics.IncrCounterWithLabels([]string{"memberlist", "chaos", "dropped"}, 1, c.m.metricLabels)
+	return true
+}
+
+// delayAck returns how long to hold back an ack, or zero.
+func (c *Chaos) delayAck() time.Duration {
+	if !c.m.config.EnableChaos {
+		return 0
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !time.Now().Before(c.ackDelayUntil) {
+		return 0
+	}
+	return c.ackDelay
+}
+
+// gossipMuted returns true if we shouldn't gossip.
+func (c *Chaos) gossipMuted() bool {
+	if !c.m.config.EnableChaos {
+		return false
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return time.Now().Before(c.muteUntil)
+}
@@ -0,0 +1,92 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChaos_Disabled(t *testing.T) {
+	m := GetMemberlist(t, nil)
+	defer m.Shutdown()
+
+	require.ErrorIs(t, m.Chaos().DropPackets(100, time.Minute), ErrChaosDisabled)
+	require.ErrorIs(t, m.Chaos().DelayAcks(time.Second, time.Minute), ErrChaosDisabled)
+	require.ErrorIs(t, m.Chaos().MuteGossip(time.Minute), ErrChaosDisabled)
+	require.False(t, m.chaos.dropPacket())
+}
+
+func TestChaos_Experiments(t *testing.T) {
+	m := GetMemberlist(t, func(c *Config) {
+		c.EnableChaos = true
+	})
+	defer m.Shutdown()
+	c := m.Chaos()
+
+	require.Error(t, c.DropPackets(101, time.Minute))
+	require.Error(t, c.DropPackets(50, 0))
+	require.Error(t, c.DelayAcks(-time.Second, time.Minute))
+
+	require.NoError(t, c.DropPackets(100, time.Minute))
+	require.NoError(t, c.DelayAcks(time.Second, time.Minute))
+	require.NoError(t, c.MuteGossip(time.Minute))
+	require.True(t, c.dropPacket())
+	require.Equal(t, time.Second, c.delayAck())
+	require.True(t, c.gossipMuted())
+
+	c.Stop()
+	require.False(t, c.dropPacket())
+	require.Zero(t, c.delayAck())
+	require.False(t, c.gossipMuted())
+
+	// Experiments end by themselves.
+	require.NoError(t, c.DropPackets(100, 20*time.Millisecond))
+	require.True(t, c.dropPacket())
+	time.Sleep(30 * time.Millisecond)
+	require.False(t, c.dropPacket())
+}
+
+func TestChaos_FailsProbes(t *testing.T) {
+	c1 := testConfig(t)
+	c1.EnableChaos = true
+	m1, err := Create(c1)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m1.Shutdown())
+	}()
+
+	c2 := testConfig(t)
+	c2.BindPort = m1.config.BindPort
+	c2.EnableChaos = true
+	m2, err := Create(c2)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m2.Shutdown())
+	}()
+
+	_, err = m2.Join([]string{m1.config.Name + "/" + m1.config.BindAddr})
+	require.NoError(t, err)
+	addr := &net.UDPAddr{IP: net.ParseIP(m2.config.BindAddr), Port: m2.config.BindPort}
+	_, _, err = m1.Ping(m2.config.Name, addr)
+	require.NoError(t, err)
+
+	// The ping doesn't get out.
+	require.NoError(t, m1.Chaos().DropPackets(100, time.Minute))
+	_, _, err = m1.Ping(m2.config.Name, addr)
+	require.Error(t, err)
+	m1.Chaos().Stop()
+
+	// The ack comes back too late.
+	require.NoError(t, m2.Chaos().DelayAcks(2*m1.config.ProbeTimeout, time.Minute))
+	_, _, err = m1.Ping(m2.config.Name, addr)
+	require.Error(t, err)
+	m2.Chaos().Stop()
+
+	_, _, err = m1.Ping(m2.config.Name, addr)
+	require.NoError(t, err)
+}
@@ -0,0 +1,121 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package chunk
+
+import (
+	"bytes"
+	"fmt"
+	"hash/crc32"
+	"sync"
+	"time"
+)
+
+// partial is a payload we've got some of the chunks for.
+type partial struct {
+	start    time.Time
+	crc      uint32
+	chunks   [][]byte
+	received int
+	size     int
+}
+
+// Assembler puts payloads back together from their chunks. It's safe for
+// concurrent use.
+type Assembler struct {
+	timeout time.Duration
+	maxSize int
+
+	lock    sync.Mutex
+	partial map[uint64]*partial
+	done    map[uint64]time.Time
+}
+
+// NewAssembler returns an Assembler that gives up on a payload if all its
+// chunks haven't arrived within the timeout. Payloads bigger than maxSize
+// are refused, so a peer can't make us buffer an unbounded amount; zero
+// means no limit.
+func NewAssembler(timeout time.Duration, maxSize int) *Assembler {
+	return &Assembler{
+		timeout: timeout,
+		maxSize: maxSize,
+		partial: make(map[uint64]*partial),
+		done:    make(map[uint64]time.Time),
+	}
+}
+
+// Add takes a chunk. Once it's got all the chunks for a payload it returns
+// the payload and true. Chunks for a payload that's already been returned
+// are ignored, since gossip can deliver the same message more than once.
+func (a *Assembler) Add(msg []byte) ([]byte, bool, error) {
+	h, data, err := parse(msg)
+	if err != nil {
+		return nil, false, err
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	now := time.Now()
+	a.expire(now)
+	if _, ok := a.done[h.id]; ok {
+		return nil, false, nil
+	}
+
+	p, ok := a.partial[h.id]
+	if !ok {
+		p = &partial{start: now, crc: h.crc, chunks: make([][]byte, h.count)}
+		a.partial[h.id] = p
+	}
+	if int(h.count) != len(p.chunks) || h.crc != p.crc {
+		delete(a.partial, h.id)
+		return nil, false, fmt.Errorf("chunk: chunk %d doesn't match the others for its payload", h.index)
+	}
+	if p.chunks[h.index] != nil {
+		return nil, false, nil
+	}
+
+	if a.maxSize > 0 && p.size+len(data) > a.maxSize {
+		delete(a.partial, h.id)
+		a.done[h.id] = now
+		return nil, false, fmt.Errorf("chunk: payload is larger than the limit of %d bytes", a.maxSize)
+	}
+	p.chunks[h.index] = append([]byte(nil), data...)
+	p.received++
+	p.size += len(data)
+	if p.received < len(p.chunks) {
+		return nil, false, nil
+	}
+
+	delete(a.partial, h.id)
+	a.done[h.id] = now
+	payload := bytes.Join(p.chunks, nil)
+	if crc32.ChecksumIEEE(payload) != p.crc {
+		return nil, false, ErrChecksum
+	}
+	return payload, true, nil
+}
+
+// Pending returns the number of payloads that are waiting on more chunks.
+func (a *Assembler) Pending() int {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.expire(time.Now())
+	return len(a.partial)
+}
+
+// expire drops payloads that have waited longer than the timeout, and
+// forgets about finished ones once their chunks can't still be around.
+func (a *Assembler) expire(now time.Time) {
+	for id, p := range a.partial {
+		if now.Sub(p.start) > a.timeout {
+			delete(a.partial, id)
+		}
+	}
+	for id, t := range a.done {
+		if now.Sub(t) > a.timeout {
+			delete(a.done, id)
+		}
+	}
+}
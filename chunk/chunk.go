@@ -0,0 +1,127 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+// Package chunk splits user payloads that are too big for a single
+// memberlist packet into chunks that can each be sent on their own, and puts
+// them back together on the receiving side.
+//
+// The sending side splits a payload with Split and sends the chunks however
+// it likes, or uses Broadcast, SendBestEffort or SendReliable to do it. The
+// receiving side hands everything it gets in NotifyMsg to an Assembler,
+// which returns the payload once all of its chunks are in:
+//
+//	func (d *delegate) NotifyMsg(msg []byte) {
+//	    if !chunk.IsChunk(msg) {
+//	        d.handle(msg)
+//	        return
+//	    }
+//	    payload, ok, err := d.assembler.Add(msg)
+//	    if err != nil || !ok {
+//	        return
+//	    }
+//	    d.handle(payload)
+//	}
+//
+// Chunks can arrive in any order, more than once, or not at all. A payload
+// that isn't complete within the Assembler's timeout is dropped, and each
+// reassembled payload is checked against a CRC-32 of the original.
+package chunk
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+)
+
+const (
+	// Magic is the first byte of every chunk. Applications that send
+	// chunks alongside other messages need to make sure their own
+	// messages never start with it.
+	Magic byte = 0xc7
+
+	// HeaderSize is the number of bytes of each chunk taken up by its
+	// header: the magic byte, a payload ID, the chunk's index, the number
+	// of chunks and the payload's checksum.
+	HeaderSize = 1 + 8 + 2 + 2 + 4
+
+	// MaxChunks is the most chunks a payload can be split into.
+	MaxChunks = 1<<16 - 1
+)
+
+// ErrChecksum is returned when a reassembled payload doesn't match the
+// checksum it was sent with.
+var ErrChecksum = errors.New("chunk: payload checksum mismatch")
+
+// header is the start of each chunk.
+type header struct {
+	id    uint64
+	index uint16
+	count uint16
+	crc   uint32
+}
+
+// IsChunk returns true if the message looks like a chunk made by Split.
+func IsChunk(msg []byte) bool {
+	return len(msg) >= HeaderSize && msg[0] == Magic
+}
+
+// Split breaks the payload up into chunks of at most size bytes each,
+// headers included, under a new random payload ID.
+func Split(payload []byte, size int) ([][]byte, error) {
+	if size <= HeaderSize {
+		return nil, fmt.Errorf("chunk: size %d leaves no room after the %d byte header", size, HeaderSize)
+	}
+	per := size - HeaderSize
+	count := max((len(payload)+per-1)/per, 1)
+	if count > MaxChunks {
+		return nil, fmt.Errorf("chunk: payload of %d bytes needs %d chunks, more than the %d allowed", len(payload), count, MaxChunks)
+	}
+
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, err
+	}
+	h := header{
+		id:    binary.BigEndian.Uint64(id[:]),
+		count: uint16(count),
+		crc:   crc32.ChecksumIEEE(payload),
+	}
+
+	chunks := make([][]byte, 0, count)
+	for i := 0; i < count; i++ {
+		data := payload[min(i*per, len(payload)):min((i+1)*per, len(payload))]
+		h.index = uint16(i)
+		chunk := make([]byte, HeaderSize, HeaderSize+len(data))
+		h.put(chunk)
+		chunks = append(chunks, append(chunk, data...))
+	}
+	return chunks, nil
+}
+
+// put writes the header to the start of buf, which must have room for it.
+func (h header) put(buf []byte) {
+	buf[0] = Magic
+	binary.BigEndian.PutUint64(buf[1:], h.id)
+	binary.BigEndian.PutUint16(buf[9:], h.index)
+	binary.BigEndian.PutUint16(buf[11:], h.count)
+	binary.BigEndian.PutUint32(buf[13:], h.crc)
+}
+
+// parse reads a chunk's header, returning its data too.
+func parse(msg []byte) (header, []byte, error) {
+	if !IsChunk(msg) {
+		return header{}, nil, errors.New("chunk: not a chunk")
+	}
+	h := header{
+		id:    binary.BigEndian.Uint64(msg[1:]),
+		index: binary.BigEndian.Uint16(msg[9:]),
+		count: binary.BigEndian.Uint16(msg[11:]),
+		crc:   binary.BigEndian.Uint32(msg[13:]),
+	}
+	if h.count == 0 || h.index >= h.count {
+		return header{}, nil, fmt.Errorf("chunk: index %d out of range for %d chunks", h.index, h.count)
+	}
+	return h, msg[HeaderSize:], nil
+}
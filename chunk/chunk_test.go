@@ -0,0 +1,163 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package chunk
+
+import (
+	"bytes"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/memberlist"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplit_Reassemble(t *testing.T) {
+	payload := make([]byte, 5000)
+	rand.Read(payload)
+
+	chunks, err := Split(payload, 1000)
+	require.NoError(t, err)
+	require.Len(t, chunks, 6)
+	for _, c := range chunks {
+		require.LessOrEqual(t, len(c), 1000)
+		require.True(t, IsChunk(c))
+	}
+
+	// Out of order, with a duplicate thrown in.
+	a := NewAssembler(time.Minute, 0)
+	rand.Shuffle(len(chunks), func(i, j int) { chunks[i], chunks[j] = chunks[j], chunks[i] })
+	for i, c := range chunks[:len(chunks)-1] {
+		_, ok, err := a.Add(c)
+		require.NoError(t, err)
+		require.False(t, ok, i)
+	}
+	_, ok, err := a.Add(chunks[0])
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Equal(t, 1, a.Pending())
+
+	got, ok, err := a.Add(chunks[len(chunks)-1])
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, payload, got)
+	require.Equal(t, 0, a.Pending())
+
+	// Late copies don't produce it again.
+	_, ok, err = a.Add(chunks[0])
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Equal(t, 0, a.Pending())
+
+	// Empty payloads are a single chunk.
+	chunks, err = Split(nil, 100)
+	require.NoError(t, err)
+	require.Len(t, chunks, 1)
+	got, ok, err = a.Add(chunks[0])
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Empty(t, got)
+
+	_, err = Split(payload, HeaderSize)
+	require.Error(t, err)
+}
+
+func TestAssembler_Errors(t *testing.T) {
+	chunks, err := Split(bytes.Repeat([]byte("x"), 100), 50)
+	require.NoError(t, err)
+
+	// A corrupted chunk fails the checksum.
+	a := NewAssembler(time.Minute, 0)
+	for _, c := range chunks[:len(chunks)-1] {
+		_, _, err := a.Add(c)
+		require.NoError(t, err)
+	}
+	bad := append([]byte(nil), chunks[len(chunks)-1]...)
+	bad[len(bad)-1] ^= 0xff
+	_, _, err = a.Add(bad)
+	require.ErrorIs(t, err, ErrChecksum)
+
+	// Payloads over the limit are refused.
+	a = NewAssembler(time.Minute, 60)
+	var errs int
+	for _, c := range chunks {
+		if _, _, err := a.Add(c); err != nil {
+			errs++
+		}
+	}
+	require.Equal(t, 1, errs)
+	require.Equal(t, 0, a.Pending())
+
+	// Incomplete payloads time out.
+	a = NewAssembler(10*time.Millisecond, 0)
+	_, _, err = a.Add(chunks[0])
+	require.NoError(t, err)
+	require.Equal(t, 1, a.Pending())
+	time.Sleep(20 * time.Millisecond)
+	require.Equal(t, 0, a.Pending())
+
+	_, _, err = a.Add([]byte("not a chunk"))
+	require.Error(t, err)
+}
+
+// delegate reassembles chunks it gets.
+type delegate struct {
+	a *Assembler
+
+	lock sync.Mutex
+	got  [][]byte
+}
+
+func (d *delegate) NodeMeta(int) []byte                 { return nil }
+func (d *delegate) GetBroadcasts(int, int) [][]byte     { return nil }
+func (d *delegate) LocalState(bool) []byte              { return nil }
+func (d *delegate) MergeRemoteState(buf []byte, _ bool) {}
+
+func (d *delegate) NotifyMsg(msg []byte) {
+	payload, ok, err := d.a.Add(msg)
+	if err != nil || !ok {
+		return
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.got = append(d.got, payload)
+}
+
+func (d *delegate) received() [][]byte {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.got
+}
+
+func TestSend(t *testing.T) {
+	create := func(name string, d memberlist.Delegate) *memberlist.Memberlist {
+		c := memberlist.DefaultLANConfig()
+		c.Name = name
+		c.BindAddr = "127.0.0.1"
+		c.BindPort = 0
+		c.Delegate = d
+		c.MaxUserMessageSize = 1000
+		m, err := memberlist.Create(c)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = m.Shutdown() })
+		return m
+	}
+	m1 := create("m1", nil)
+	d := &delegate{a: NewAssembler(time.Minute, 0)}
+	m2 := create("m2", d)
+
+	payload := make([]byte, 4000)
+	rand.Read(payload)
+	require.Error(t, m1.SendReliable(m2.LocalNode(), payload))
+
+	require.NoError(t, SendReliable(m1, m2.LocalNode(), payload, 1000))
+	require.NoError(t, SendBestEffort(m1, m2.LocalNode(), payload))
+	require.Eventually(t, func() bool {
+		return len(d.received()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	for _, got := range d.received() {
+		require.Equal(t, payload, got)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package chunk
+
+import (
+	"github.com/hashicorp/memberlist"
+)
+
+// broadcast is a single chunk in a TransmitLimitedQueue. Every chunk is
+// distinct, so none invalidates another.
+type broadcast []byte
+
+func (b broadcast) Invalidates(memberlist.Broadcast) bool { return false }
+func (b broadcast) Message() []byte                       { return b }
+func (b broadcast) Finished()                             {}
+func (b broadcast) UniqueBroadcast()                      {}
+
+// Broadcast splits the payload into chunks that fit in a packet for the
+// given memberlist and queues them all on q, for a Delegate to hand out from
+// GetBroadcasts.
+func Broadcast(m *memberlist.Memberlist, q *memberlist.TransmitLimitedQueue, payload []byte) error {
+	chunks, err := Split(payload, m.MaxPayloadSize())
+	if err != nil {
+		return err
+	}
+	for _, c := range chunks {
+		q.QueueBroadcast(broadcast(c))
+	}
+	return nil
+}
+
+// SendBestEffort splits the payload into chunks that fit in a packet and
+// sends each of them to the node with Memberlist.SendBestEffort. Any chunk
+// can be lost, which loses the whole payload.
+func SendBestEffort(m *memberlist.Memberlist, to *memberlist.Node, payload []byte) error {
+	chunks, err := Split(payload, m.MaxPayloadSize())
+	if err != nil {
+		return err
+	}
+	for _, c := range chunks {
+		if err := m.SendBestEffort(to, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SendReliable splits the payload into chunks of at most size bytes and
+// sends each of them to the node with Memberlist.SendReliable. This is
+// only needed when Config.MaxUserMessageSize is set, since reliable sends
+// aren't otherwise limited in size.
+func SendReliable(m *memberlist.Memberlist, to *memberlist.Node, payload []byte, size int) error {
+	chunks, err := Split(payload, size)
+	if err != nil {
+		return err
+	}
+	for _, c := range chunks {
+		if err := m.SendReliable(to, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
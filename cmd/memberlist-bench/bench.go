@@ -0,0 +1,425 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/memberlist"
+)
+
+// options are the parameters of a benchmark run.
+type options struct {
+	Nodes     int
+	Profile   string // "lan", "wan" or "local"
+	Transport string // "mock" or "loopback"
+	Loss      float64
+	Churn     int
+	Partition time.Duration
+	Steady    time.Duration
+	Timeout   time.Duration
+	Seed      int64
+	Verbose   bool
+}
+
+func (o *options) validate() error {
+	if o.Nodes < 2 {
+		return fmt.Errorf("need at least 2 nodes")
+	}
+	if o.Churn >= o.Nodes {
+		return fmt.Errorf("can't churn %d of %d nodes", o.Churn, o.Nodes)
+	}
+	if o.Loss < 0 || o.Loss >= 1 {
+		return fmt.Errorf("loss must be in [0, 1)")
+	}
+	switch o.Profile {
+	case "lan", "wan", "local":
+	default:
+		return fmt.Errorf("unknown profile %q", o.Profile)
+	}
+	switch o.Transport {
+	case "mock":
+	case "loopback":
+		if o.Loss > 0 || o.Partition > 0 {
+			return fmt.Errorf("the loopback transport can't lose packets or partition")
+		}
+	default:
+		return fmt.Errorf("unknown transport %q", o.Transport)
+	}
+	return nil
+}
+
+// node is a running member.
+type node struct {
+	name string
+	m    *memberlist.Memberlist
+	sent *atomic.Uint64
+}
+
+// cluster runs the nodes of a benchmark.
+type cluster struct {
+	opts options
+	net  *network // nil for the loopback transport
+	out  io.Writer
+
+	lock     sync.Mutex
+	live     []*node
+	all      []*node         // Every node ever started, to count bytes
+	expected map[string]bool // Nodes that are down on purpose
+
+	watching       atomic.Bool // Count false positives
+	falsePositives atomic.Int64
+	generation     int
+}
+
+// result is what a run measured.
+type result struct {
+	Join           time.Duration
+	JoinOK         bool
+	BytesPerSec    float64 // Per node, during the steady phase
+	FalsePositives int64
+	Detect         time.Duration // Churn failure detection
+	DetectOK       bool
+	Rejoin         time.Duration
+	RejoinOK       bool
+	Split          time.Duration // Partition detection
+	SplitOK        bool
+	Heal           time.Duration
+	HealOK         bool
+}
+
+func newCluster(opts options, out io.Writer) *cluster {
+	c := &cluster{opts: opts, out: out, expected: make(map[string]bool)}
+	if opts.Transport == "mock" {
+		c.net = newNetwork(opts.Loss, opts.Seed)
+	}
+	return c
+}
+
+// config returns the memberlist configuration for the chosen profile.
+func (c *cluster) config() *memberlist.Config {
+	switch c.opts.Profile {
+	case "wan":
+		return memberlist.DefaultWANConfig()
+	case "local":
+		return memberlist.DefaultLocalConfig()
+	default:
+		return memberlist.DefaultLANConfig()
+	}
+}
+
+// start starts a new node, with a name that's never been used.
+func (c *cluster) start(name string) (*node, error) {
+	conf := c.config()
+	conf.Name = name
+	conf.Events = &watcher{c: c}
+	conf.LogOutput = io.Discard
+	if c.opts.Verbose {
+		conf.LogOutput = os.Stderr
+	}
+
+	n := &node{name: name}
+	if c.net != nil {
+		t := c.net.newTransport(name)
+		conf.Transport = t
+		n.sent = &t.sent
+	} else {
+		nt, err := memberlist.NewNetTransport(&memberlist.NetTransportConfig{
+			BindAddrs: []string{"127.0.0.1"},
+			Logger:    log.New(conf.LogOutput, "", log.LstdFlags),
+		})
+		if err != nil {
+			return nil, err
+		}
+		n.sent = new(atomic.Uint64)
+		conf.Transport = &countingTransport{NodeAwareTransport: nt, sent: n.sent}
+	}
+
+	m, err := memberlist.Create(conf)
+	if err != nil {
+		return nil, err
+	}
+	n.m = m
+
+	c.lock.Lock()
+	c.live = append(c.live, n)
+	c.all = append(c.all, n)
+	c.lock.Unlock()
+	return n, nil
+}
+
+// kill shuts a node down without leaving, as if it crashed.
+func (c *cluster) kill(n *node) {
+	c.lock.Lock()
+	c.expected[n.name] = true
+	for i, l := range c.live {
+		if l == n {
+			c.live = append(c.live[:i], c.live[i+1:]...)
+			break
+		}
+	}
+	c.lock.Unlock()
+	_ = n.m.Shutdown()
+}
+
+func (c *cluster) shutdown() {
+	c.lock.Lock()
+	live := c.live
+	c.live = nil
+	c.lock.Unlock()
+	for _, n := range live {
+		_ = n.m.Shutdown()
+	}
+}
+
+func (c *cluster) liveNodes() []*node {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]*node(nil), c.live...)
+}
+
+// bytesSent returns the bytes sent by every node so far.
+func (c *cluster) bytesSent() uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	var sent uint64
+	for _, n := range c.all {
+		sent += n.sent.Load()
+	}
+	return sent
+}
+
+// sees returns true if every node in from sees every node in alive as alive,
+// and every node in gone as dead, left or forgotten.
+func sees(from []*node, alive, gone []string) bool {
+	for _, n := range from {
+		for _, name := range alive {
+			d, err := n.m.NodeDiagnostics(name)
+			if err != nil || d.State != memberlist.StateAlive {
+				return false
+			}
+		}
+		for _, name := range gone {
+			d, err := n.m.NodeDiagnostics(name)
+			if err == nil && (d.State == memberlist.StateAlive || d.State == memberlist.StateSuspect) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// waitFor polls cond until it's true or the timeout passes, and returns how
+// long that took.
+func (c *cluster) waitFor(cond func() bool) (time.Duration, bool) {
+	start := time.Now()
+	deadline := start.Add(c.opts.Timeout)
+	for {
+		if cond() {
+			return time.Since(start), true
+		}
+		if time.Now().After(deadline) {
+			return time.Since(start), false
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func names(nodes []*node) []string {
+	out := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		out = append(out, n.name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (c *cluster) logf(format string, args ...interface{}) {
+	fmt.Fprintf(c.out, format+"\n", args...)
+}
+
+// run runs the whole benchmark.
+func (c *cluster) run() (result, error) {
+	var res result
+	defer c.shutdown()
+	rng := rand.New(rand.NewSource(c.opts.Seed))
+
+	// Join
+	start := time.Now()
+	var seed string
+	for i := 0; i < c.opts.Nodes; i++ {
+		n, err := c.start(fmt.Sprintf("node-%d", i))
+		if err != nil {
+			return res, err
+		}
+		if i == 0 {
+			seed = n.m.LocalNode().Address()
+			continue
+		}
+		if _, err := n.m.Join([]string{seed}); err != nil {
+			return res, fmt.Errorf("%s failed to join: %v", n.name, err)
+		}
+	}
+	_, ok := c.waitFor(func() bool {
+		live := c.liveNodes()
+		return sees(live, names(live), nil)
+	})
+	res.Join, res.JoinOK = time.Since(start), ok
+	c.logf("join: %s", outcome(res.Join, ok, "%d nodes", c.opts.Nodes))
+	if !ok {
+		return res, nil
+	}
+
+	// Steady state
+	if c.opts.Steady > 0 {
+		before := c.bytesSent()
+		c.falsePositives.Store(0)
+		c.watching.Store(true)
+		time.Sleep(c.opts.Steady)
+		c.watching.Store(false)
+		sent := c.bytesSent() - before
+		res.BytesPerSec = float64(sent) / float64(c.opts.Nodes) / c.opts.Steady.Seconds()
+		res.FalsePositives = c.falsePositives.Load()
+		c.logf("steady: %.1f KB/s sent per node, %d false positives in %s", res.BytesPerSec/1024, res.FalsePositives, c.opts.Steady)
+	}
+
+	// Churn
+	if c.opts.Churn > 0 {
+		live := c.liveNodes()
+		rng.Shuffle(len(live)-1, func(i, j int) { live[i+1], live[j+1] = live[j+1], live[i+1] })
+		victims := live[1 : 1+c.opts.Churn]
+		for _, n := range victims {
+			c.kill(n)
+		}
+		res.Detect, res.DetectOK = c.waitFor(func() bool {
+			return sees(c.liveNodes(), nil, names(victims))
+		})
+		c.logf("failure detection: %s", outcome(res.Detect, res.DetectOK, "%d nodes", len(victims)))
+
+		c.generation++
+		start := time.Now()
+		for _, v := range victims {
+			n, err := c.start(fmt.Sprintf("%s-r%d", v.name, c.generation))
+			if err != nil {
+				return res, err
+			}
+			if _, err := n.m.Join([]string{seed}); err != nil {
+				return res, fmt.Errorf("%s failed to join: %v", n.name, err)
+			}
+		}
+		_, ok := c.waitFor(func() bool {
+			live := c.liveNodes()
+			return sees(live, names(live), nil)
+		})
+		res.Rejoin, res.RejoinOK = time.Since(start), ok
+		c.logf("rejoin: %s", outcome(res.Rejoin, ok, "%d nodes", len(victims)))
+	}
+
+	// Partition
+	if c.opts.Partition > 0 {
+		live := c.liveNodes()
+		half := len(live) / 2
+		left, right := live[:half], live[half:]
+		groups := make(map[string]int, len(live))
+		for _, n := range right {
+			groups[n.name] = 1
+		}
+		c.net.partition(groups)
+		start := time.Now()
+		res.Split, res.SplitOK = c.waitFor(func() bool {
+			return sees(left, names(left), names(right)) && sees(right, names(right), names(left))
+		})
+		c.logf("partition: %s", outcome(res.Split, res.SplitOK, "%d/%d nodes", len(left), len(right)))
+		if wait := c.opts.Partition - time.Since(start); wait > 0 {
+			time.Sleep(wait)
+		}
+
+		c.net.heal()
+		res.Heal, res.HealOK = c.waitFor(func() bool {
+			return sees(live, names(live), nil)
+		})
+		c.logf("heal: %s", outcome(res.Heal, res.HealOK, "%d nodes", len(live)))
+	}
+	return res, nil
+}
+
+// outcome describes how a phase went.
+func outcome(d time.Duration, ok bool, format string, args ...interface{}) string {
+	what := fmt.Sprintf(format, args...)
+	if !ok {
+		return fmt.Sprintf("%s did not converge within %s", what, d.Round(time.Millisecond))
+	}
+	return fmt.Sprintf("%s converged in %s", what, d.Round(time.Millisecond))
+}
+
+// watcher counts nodes declared dead that weren't taken down on purpose.
+type watcher struct {
+	c *cluster
+}
+
+func (w *watcher) NotifyJoin(*memberlist.Node)   {}
+func (w *watcher) NotifyUpdate(*memberlist.Node) {}
+
+func (w *watcher) NotifyLeave(n *memberlist.Node) {
+	if !w.c.watching.Load() || n.Reason == memberlist.ReasonLeft {
+		return
+	}
+	w.c.lock.Lock()
+	expected := w.c.expected[n.Name]
+	w.c.lock.Unlock()
+	if !expected {
+		w.c.falsePositives.Add(1)
+	}
+}
+
+// countingTransport counts the bytes a node sends over a real transport.
+type countingTransport struct {
+	memberlist.NodeAwareTransport
+	sent *atomic.Uint64
+}
+
+func (t *countingTransport) WriteTo(b []byte, addr string) (time.Time, error) {
+	t.sent.Add(uint64(len(b)))
+	return t.NodeAwareTransport.WriteTo(b, addr)
+}
+
+func (t *countingTransport) WriteToAddress(b []byte, a memberlist.Address) (time.Time, error) {
+	t.sent.Add(uint64(len(b)))
+	return t.NodeAwareTransport.WriteToAddress(b, a)
+}
+
+func (t *countingTransport) DialTimeout(addr string, timeout time.Duration) (net.Conn, error) {
+	return t.DialAddressTimeout(memberlist.Address{Addr: addr}, timeout)
+}
+
+func (t *countingTransport) DialAddressTimeout(a memberlist.Address, timeout time.Duration) (net.Conn, error) {
+	conn, err := t.NodeAwareTransport.DialAddressTimeout(a, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &countingConn{Conn: conn, sent: t.sent}, nil
+}
+
+// converged returns true if every phase that ran converged.
+func (r result) converged(opts options) bool {
+	if !r.JoinOK {
+		return false
+	}
+	if opts.Churn > 0 && (!r.DetectOK || !r.RejoinOK) {
+		return false
+	}
+	if opts.Partition > 0 && (!r.SplitOK || !r.HealOK) {
+		return false
+	}
+	return true
+}
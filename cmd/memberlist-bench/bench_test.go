@@ -0,0 +1,82 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/memberlist"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBench_Run(t *testing.T) {
+	opts := options{
+		Nodes:     3,
+		Profile:   "local",
+		Transport: "mock",
+		Steady:    1500 * time.Millisecond, // Longer than a probe interval, so something is sent
+		Timeout:   30 * time.Second,
+		Seed:      1,
+	}
+	require.NoError(t, opts.validate())
+
+	var out bytes.Buffer
+	res, err := newCluster(opts, &out).run()
+	require.NoError(t, err)
+	require.True(t, res.converged(opts), out.String())
+	require.Positive(t, res.BytesPerSec)
+	require.Zero(t, res.FalsePositives)
+	require.Contains(t, out.String(), "join: 3 nodes converged in")
+}
+
+func TestBench_Validate(t *testing.T) {
+	base := options{Nodes: 4, Profile: "lan", Transport: "mock"}
+	require.NoError(t, base.validate())
+
+	for name, change := range map[string]func(*options){
+		"too few nodes":      func(o *options) { o.Nodes = 1 },
+		"churn everything":   func(o *options) { o.Churn = 4 },
+		"bad loss":           func(o *options) { o.Loss = 1 },
+		"bad profile":        func(o *options) { o.Profile = "moon" },
+		"bad transport":      func(o *options) { o.Transport = "carrier-pigeon" },
+		"loopback partition": func(o *options) { o.Transport, o.Partition = "loopback", time.Second },
+	} {
+		t.Run(name, func(t *testing.T) {
+			o := base
+			change(&o)
+			require.Error(t, o.validate())
+		})
+	}
+}
+
+func TestNetwork_Partition(t *testing.T) {
+	n := newNetwork(0, 1)
+	a, b := n.newTransport("a"), n.newTransport("b")
+
+	send := func() bool {
+		_, err := a.WriteToAddress([]byte("hi"), memberlist.Address{Addr: b.addr.String()})
+		require.NoError(t, err)
+		select {
+		case <-b.PacketCh():
+			return true
+		default:
+			return false
+		}
+	}
+	require.True(t, send())
+	require.Equal(t, uint64(2), a.sent.Load())
+
+	n.partition(map[string]int{"a": 0, "b": 1})
+	require.False(t, send())
+	_, err := a.DialAddressTimeout(memberlist.Address{Addr: b.addr.String()}, time.Second)
+	require.Error(t, err)
+
+	n.heal()
+	require.True(t, send())
+
+	require.NoError(t, b.Shutdown())
+	require.False(t, send())
+}
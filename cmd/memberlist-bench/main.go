@@ -0,0 +1,59 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+// Command memberlist-bench runs a cluster of in-process memberlist nodes to
+// see how a configuration behaves before it goes to production. It measures,
+// in order:
+//
+//   - how long the nodes take to join and agree on the membership,
+//   - the bandwidth each node uses and the live nodes wrongly declared dead
+//     while the cluster is steady, with optional packet loss,
+//   - how long it takes to detect nodes that crash, and for replacements to
+//     join, and
+//   - how long it takes to detect a network partition, and to merge again
+//     once it heals.
+//
+// The nodes talk over a simulated network by default, which supports packet
+// loss and partitions, or over real loopback sockets with -transport
+// loopback.
+//
+// Usage:
+//
+//	memberlist-bench [-nodes 16] [-profile lan] [-loss 0.01] [-churn 2] [-partition 10s]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+func main() {
+	var opts options
+	flag.IntVar(&opts.Nodes, "nodes", 16, "number of nodes")
+	flag.StringVar(&opts.Profile, "profile", "lan", "configuration profile: lan, wan or local")
+	flag.StringVar(&opts.Transport, "transport", "mock", "transport: mock or loopback")
+	flag.Float64Var(&opts.Loss, "loss", 0, "fraction of packets lost, mock transport only")
+	flag.IntVar(&opts.Churn, "churn", 0, "number of nodes to crash and replace")
+	flag.DurationVar(&opts.Partition, "partition", 0, "how long to partition the cluster in two, mock transport only")
+	flag.DurationVar(&opts.Steady, "steady", 10*time.Second, "how long to measure the steady state for")
+	flag.DurationVar(&opts.Timeout, "timeout", time.Minute, "how long to wait for each phase to converge")
+	flag.Int64Var(&opts.Seed, "seed", time.Now().UnixNano(), "random seed")
+	flag.BoolVar(&opts.Verbose, "v", false, "show memberlist logs")
+	flag.Parse()
+
+	if err := opts.validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "memberlist-bench: %v\n", err)
+		os.Exit(2)
+	}
+
+	res, err := newCluster(opts, os.Stdout).run()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "memberlist-bench: %v\n", err)
+		os.Exit(1)
+	}
+	if !res.converged(opts) {
+		os.Exit(1)
+	}
+}
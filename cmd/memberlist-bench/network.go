@@ -0,0 +1,172 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/memberlist"
+)
+
+// network is an in-process network the nodes talk over. It can lose packets
+// and split the nodes into partitions.
+type network struct {
+	lock   sync.RWMutex
+	byAddr map[string]*transport
+	groups map[string]int // Partition of each node, by name; nil when whole
+	port   int
+
+	loss    float64
+	rngLock sync.Mutex
+	rng     *rand.Rand
+}
+
+func newNetwork(loss float64, seed int64) *network {
+	return &network{
+		byAddr: make(map[string]*transport),
+		loss:   loss,
+		rng:    rand.New(rand.NewSource(seed)),
+	}
+}
+
+// newTransport returns a transport for a node, with a new address.
+func (n *network) newTransport(name string) *transport {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.port++
+	t := &transport{
+		net:      n,
+		name:     name,
+		addr:     &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: n.port},
+		packetCh: make(chan *memberlist.Packet, 1024),
+		streamCh: make(chan net.Conn, 64),
+	}
+	n.byAddr[t.addr.String()] = t
+	return t
+}
+
+// partition splits the network, putting each node in the group given for
+// it. Nodes in different groups can't reach each other.
+func (n *network) partition(groups map[string]int) {
+	n.lock.Lock()
+	n.groups = groups
+	n.lock.Unlock()
+}
+
+// heal undoes a partition.
+func (n *network) heal() {
+	n.partition(nil)
+}
+
+// peer returns the transport at addr, if from can reach it.
+func (n *network) peer(from *transport, addr string) (*transport, bool) {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+	to, ok := n.byAddr[addr]
+	if !ok || to.down.Load() {
+		return nil, false
+	}
+	if n.groups != nil && n.groups[from.name] != n.groups[to.name] {
+		return nil, false
+	}
+	return to, true
+}
+
+// drop returns true if a packet should be lost.
+func (n *network) drop() bool {
+	if n.loss <= 0 {
+		return false
+	}
+	n.rngLock.Lock()
+	defer n.rngLock.Unlock()
+	return n.rng.Float64() < n.loss
+}
+
+// transport is a node's memberlist.Transport on a network. Packets to nodes
+// that are down or unreachable are silently lost, like UDP, and streams to
+// them fail to connect. It counts the bytes the node sends.
+type transport struct {
+	net  *network
+	name string
+	addr *net.UDPAddr
+	down atomic.Bool
+	sent atomic.Uint64
+
+	packetCh chan *memberlist.Packet
+	streamCh chan net.Conn
+}
+
+var _ memberlist.NodeAwareTransport = (*transport)(nil)
+
+func (t *transport) FinalAdvertiseAddr(string, int) (net.IP, int, error) {
+	return t.addr.IP, t.addr.Port, nil
+}
+
+func (t *transport) WriteTo(b []byte, addr string) (time.Time, error) {
+	return t.WriteToAddress(b, memberlist.Address{Addr: addr})
+}
+
+func (t *transport) WriteToAddress(b []byte, a memberlist.Address) (time.Time, error) {
+	now := time.Now()
+	t.sent.Add(uint64(len(b)))
+	to, ok := t.net.peer(t, a.Addr)
+	if !ok || t.net.drop() {
+		return now, nil
+	}
+	p := &memberlist.Packet{Buf: append([]byte(nil), b...), From: t.addr, Timestamp: now}
+	select {
+	case to.packetCh <- p:
+	default:
+		// The receiver is overwhelmed, so the packet is lost.
+	}
+	return now, nil
+}
+
+func (t *transport) PacketCh() <-chan *memberlist.Packet {
+	return t.packetCh
+}
+
+func (t *transport) DialTimeout(addr string, timeout time.Duration) (net.Conn, error) {
+	return t.DialAddressTimeout(memberlist.Address{Addr: addr}, timeout)
+}
+
+func (t *transport) DialAddressTimeout(a memberlist.Address, timeout time.Duration) (net.Conn, error) {
+	to, ok := t.net.peer(t, a.Addr)
+	if !ok {
+		return nil, fmt.Errorf("no route to %s", a.Addr)
+	}
+	local, remote := net.Pipe()
+	select {
+	case to.streamCh <- &countingConn{Conn: remote, sent: &to.sent}:
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("timed out connecting to %s", a.Addr)
+	}
+	return &countingConn{Conn: local, sent: &t.sent}, nil
+}
+
+func (t *transport) StreamCh() <-chan net.Conn {
+	return t.streamCh
+}
+
+func (t *transport) Shutdown() error {
+	t.down.Store(true)
+	return nil
+}
+
+// countingConn counts the bytes written to a stream.
+type countingConn struct {
+	net.Conn
+	sent *atomic.Uint64
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.sent.Add(uint64(n))
+	return n, err
+}
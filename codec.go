@@ -0,0 +1,482 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// WireCodec selects how memberlist encodes the messages it sends directly to
+// other members.
+type WireCodec uint8
+
+const (
+	// WireCodecMsgpack is the original msgpack encoding, understood by every
+	// protocol version.
+	WireCodecMsgpack WireCodec = iota
+
+	// WireCodecProtobuf encodes probe messages using the protocol buffer
+	// schema in proto/memberlist.proto, so implementations in other languages
+	// don't have to mirror Go's msgpack layouts. It's only used with members
+	// that understand protocol version 7 or greater; everything else still
+	// uses msgpack.
+	WireCodecProtobuf
+)
+
+// String returns the name of the codec.
+func (c WireCodec) String() string {
+	switch c {
+	case WireCodecMsgpack:
+		return "msgpack"
+	case WireCodecProtobuf:
+		return "protobuf"
+	default:
+		return "unknown"
+	}
+}
+
+// messageCodec encodes and decodes the body of a message.
+type messageCodec interface {
+	// encode returns the message, prefixed with its type byte.
+	encode(msgType messageType, in interface{}) (*bytes.Buffer, error)
+
+	// decode decodes the body of a message, without the type byte.
+	decode(buf []byte, out interface{}) error
+}
+
+// msgpackCodec is the default messageCodec.
+type msgpackCodec struct {
+	useNewTimeFormat bool
+}
+
+func (c msgpackCodec) encode(msgType messageType, in interface{}) (*bytes.Buffer, error) {
+	return encode(msgType, in, c.useNewTimeFormat)
+}
+
+func (c msgpackCodec) decode(buf []byte, out interface{}) error {
+	return decode(buf, out)
+}
+
+// protoMessage is implemented by the messages that have a protocol buffer
+// form.
+type protoMessage interface {
+	marshalProto() []byte
+	unmarshalProto(buf []byte) error
+}
+
+// errNotProtoMessage is returned when a message has no protocol buffer form.
+var errNotProtoMessage = errors.New("message has no protocol buffer form")
+
+// protobufCodec wraps messages in a protoMsg envelope:
+//
+//	[protoMsg; byte] [messageType; byte] [protocol buffer encoded message]
+//
+// Messages that have no protocol buffer form are sent using the fallback.
+type protobufCodec struct {
+	fallback msgpackCodec
+}
+
+func (c protobufCodec) encode(msgType messageType, in interface{}) (*bytes.Buffer, error) {
+	pm, ok := in.(protoMessage)
+	if !ok {
+		return c.fallback.encode(msgType, in)
+	}
+	buf := bytes.NewBuffer(nil)
+	buf.WriteByte(uint8(protoMsg))
+	buf.WriteByte(uint8(msgType))
+	buf.Write(pm.marshalProto())
+	return buf, nil
+}
+
+func (c protobufCodec) decode(buf []byte, out interface{}) error {
+	pm, ok := out.(protoMessage)
+	if !ok {
+		return errNotProtoMessage
+	}
+	return pm.unmarshalProto(buf)
+}
+
+// codecFor returns the codec to use for a message sent to the given address.
+func (m *Memberlist) codecFor(a Address) messageCodec {
+	fallback := msgpackCodec{useNewTimeFormat: m.config.MsgpackUseNewTimeFormat}
+	if m.config.WireCodec != WireCodecProtobuf || a.Name == "" {
+		return fallback
+	}
+
+	m.nodeLock.RLock()
+	node, ok := m.nodeMap[a.Name]
+	supported := ok && node.Supports(FeatureProtobuf)
+	m.nodeLock.RUnlock()
+	if !supported {
+		return fallback
+	}
+	return protobufCodec{fallback: fallback}
+}
+
+// newProtoMessage returns an empty message of the given type, if the type has
+// a protocol buffer form.
+func newProtoMessage(msgType messageType) protoMessage {
+	switch msgType {
+	case pingMsg:
+		return &ping{}
+	case indirectPingMsg:
+		return &indirectPingReq{}
+	case ackRespMsg:
+		return &ackResp{}
+	case nackRespMsg:
+		return &nackResp{}
+	case suspectMsg:
+		return &suspect{}
+	case aliveMsg:
+		return &alive{}
+	case deadMsg:
+		return &dead{}
+	default:
+		return nil
+	}
+}
+
+// handleProto unwraps a protoMsg envelope. The message is converted to its
+// msgpack form and handled like any other, so the rest of the stack only has
+// to deal with one encoding.
+func (m *Memberlist) handleProto(buf []byte, from net.Addr, timestamp time.Time) {
+	if len(buf) < 1 {
+		m.logger.Printf("[ERR] memberlist: missing protobuf message type byte %s", LogAddress(from))
+		return
+	}
+	msgType := messageType(buf[0])
+
+	msg := newProtoMessage(msgType)
+	if msg == nil {
+		m.logger.Printf("[ERR] memberlist: protobuf msg type (%d) not supported %s", msgType, LogAddress(from))
+		return
+	}
+	if err := (protobufCodec{}).decode(buf[1:], msg); err != nil {
+		m.logger.Printf("[ERR] memberlist: Failed to decode protobuf message: %s %s", err, LogAddress(from))
+		return
+	}
+
+	out, err := encode(msgType, msg, m.config.MsgpackUseNewTimeFormat)
+	if err != nil {
+		m.logger.Printf("[ERR] memberlist: Failed to convert protobuf message: %s %s", err, LogAddress(from))
+		return
+	}
+	m.handleCommand(out.Bytes(), from, timestamp)
+}
+
+// The helpers below implement the subset of the protocol buffer encoding the
+// schema uses. Zero values are omitted, as proto3 does.
+
+func pbAppendVarint(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func pbAppendBool(b []byte, num protowire.Number, v bool) []byte {
+	if !v {
+		return b
+	}
+	return pbAppendVarint(b, num, 1)
+}
+
+func pbAppendBytes(b []byte, num protowire.Number, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func pbAppendString(b []byte, num protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+// pbField is a decoded field. Only one of varint and bytes is set, depending
+// on the wire type.
+type pbField struct {
+	num    protowire.Number
+	varint uint64
+	bytes  []byte
+}
+
+// pbDecode calls f for each varint and length-delimited field in buf, and
+// skips the others.
+func pbDecode(buf []byte, f func(pbField) error) error {
+	for len(buf) > 0 {
+		num, typ, n := protowire.ConsumeTag(buf)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		buf = buf[n:]
+
+		field := pbField{num: num}
+		switch typ {
+		case protowire.VarintType:
+			field.varint, n = protowire.ConsumeVarint(buf)
+		case protowire.BytesType:
+			field.bytes, n = protowire.ConsumeBytes(buf)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, buf)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			buf = buf[n:]
+			continue
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		buf = buf[n:]
+
+		if err := f(field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pbCopy returns a copy of a bytes field, so decoded messages don't alias the
+// packet buffer.
+func pbCopy(b []byte) []byte {
+	if len(b) == 0 {
+		return nil
+	}
+	return append([]byte(nil), b...)
+}
+
+func (p *ping) marshalProto() []byte {
+	var b []byte
+	b = pbAppendVarint(b, 1, uint64(p.SeqNo))
+	b = pbAppendString(b, 2, p.Node)
+	b = pbAppendBytes(b, 3, p.SourceAddr)
+	b = pbAppendVarint(b, 4, uint64(p.SourcePort))
+	b = pbAppendString(b, 5, p.SourceNode)
+	return b
+}
+
+func (p *ping) unmarshalProto(buf []byte) error {
+	return pbDecode(buf, func(f pbField) error {
+		switch f.num {
+		case 1:
+			p.SeqNo = uint32(f.varint)
+		case 2:
+			p.Node = string(f.bytes)
+		case 3:
+			p.SourceAddr = pbCopy(f.bytes)
+		case 4:
+			p.SourcePort = uint16(f.varint)
+		case 5:
+			p.SourceNode = string(f.bytes)
+		}
+		return nil
+	})
+}
+
+func (ind *indirectPingReq) marshalProto() []byte {
+	var b []byte
+	b = pbAppendVarint(b, 1, uint64(ind.SeqNo))
+	b = pbAppendBytes(b, 2, ind.Target)
+	b = pbAppendVarint(b, 3, uint64(ind.Port))
+	b = pbAppendString(b, 4, ind.Node)
+	b = pbAppendBool(b, 5, ind.Nack)
+	b = pbAppendBytes(b, 6, ind.SourceAddr)
+	b = pbAppendVarint(b, 7, uint64(ind.SourcePort))
+	b = pbAppendString(b, 8, ind.SourceNode)
+	return b
+}
+
+func (ind *indirectPingReq) unmarshalProto(buf []byte) error {
+	return pbDecode(buf, func(f pbField) error {
+		switch f.num {
+		case 1:
+			ind.SeqNo = uint32(f.varint)
+		case 2:
+			ind.Target = pbCopy(f.bytes)
+		case 3:
+			ind.Port = uint16(f.varint)
+		case 4:
+			ind.Node = string(f.bytes)
+		case 5:
+			ind.Nack = f.varint != 0
+		case 6:
+			ind.SourceAddr = pbCopy(f.bytes)
+		case 7:
+			ind.SourcePort = uint16(f.varint)
+		case 8:
+			ind.SourceNode = string(f.bytes)
+		}
+		return nil
+	})
+}
+
+func (a *ackResp) marshalProto() []byte {
+	var b []byte
+	b = pbAppendVarint(b, 1, uint64(a.SeqNo))
+	b = pbAppendBytes(b, 2, a.Payload)
+	b = pbAppendVarint(b, 3, uint64(int64(a.Health)))
+	return b
+}
+
+func (a *ackResp) unmarshalProto(buf []byte) error {
+	return pbDecode(buf, func(f pbField) error {
+		switch f.num {
+		case 1:
+			a.SeqNo = uint32(f.varint)
+		case 2:
+			a.Payload = pbCopy(f.bytes)
+		case 3:
+			a.Health = int(int32(f.varint))
+		}
+		return nil
+	})
+}
+
+func (n *nackResp) marshalProto() []byte {
+	return pbAppendVarint(nil, 1, uint64(n.SeqNo))
+}
+
+func (n *nackResp) unmarshalProto(buf []byte) error {
+	return pbDecode(buf, func(f pbField) error {
+		if f.num == 1 {
+			n.SeqNo = uint32(f.varint)
+		}
+		return nil
+	})
+}
+
+func (s *suspect) marshalProto() []byte {
+	var b []byte
+	b = pbAppendVarint(b, 1, uint64(s.Incarnation))
+	b = pbAppendString(b, 2, s.Node)
+	b = pbAppendString(b, 3, s.From)
+	b = pbAppendVarint(b, 4, uint64(s.Reason))
+	return b
+}
+
+func (s *suspect) unmarshalProto(buf []byte) error {
+	return pbDecode(buf, func(f pbField) error {
+		switch f.num {
+		case 1:
+			s.Incarnation = uint32(f.varint)
+		case 2:
+			s.Node = string(f.bytes)
+		case 3:
+			s.From = string(f.bytes)
+		case 4:
+			if f.varint > 0xff {
+				return fmt.Errorf("invalid reason %d", f.varint)
+			}
+			s.Reason = StateChangeReason(f.varint)
+		}
+		return nil
+	})
+}
+
+func (a *alive) marshalProto() []byte {
+	var b []byte
+	b = pbAppendVarint(b, 1, uint64(a.Incarnation))
+	b = pbAppendString(b, 2, a.Node)
+	b = pbAppendBytes(b, 3, a.Addr)
+	b = pbAppendVarint(b, 4, uint64(a.Port))
+	b = pbAppendBytes(b, 5, a.Meta)
+	b = pbAppendBytes(b, 6, a.Vsn)
+	for _, addr := range a.Addrs {
+		b = protowire.AppendTag(b, 7, protowire.BytesType)
+		b = protowire.AppendString(b, addr)
+	}
+	b = pbAppendVarint(b, 8, uint64(a.Role))
+	b = pbAppendString(b, 9, a.Zone)
+	b = pbAppendString(b, 10, a.ID)
+	b = pbAppendVarint(b, 11, uint64(a.StreamPort))
+	b = pbAppendVarint(b, 12, uint64(a.Maintenance))
+	b = pbAppendVarint(b, 13, uint64(a.Weight))
+	b = pbAppendBool(b, 14, a.Draining)
+	b = pbAppendBool(b, 15, a.Complete)
+	return b
+}
+
+func (a *alive) unmarshalProto(buf []byte) error {
+	return pbDecode(buf, func(f pbField) error {
+		switch f.num {
+		case 1:
+			a.Incarnation = uint32(f.varint)
+		case 2:
+			a.Node = string(f.bytes)
+		case 3:
+			a.Addr = pbCopy(f.bytes)
+		case 4:
+			a.Port = uint16(f.varint)
+		case 5:
+			a.Meta = pbCopy(f.bytes)
+		case 6:
+			a.Vsn = pbCopy(f.bytes)
+		case 7:
+			a.Addrs = append(a.Addrs, string(f.bytes))
+		case 8:
+			if f.varint > 0xff {
+				return fmt.Errorf("invalid role %d", f.varint)
+			}
+			a.Role = NodeRole(f.varint)
+		case 9:
+			a.Zone = string(f.bytes)
+		case 10:
+			a.ID = string(f.bytes)
+		case 11:
+			a.StreamPort = uint16(f.varint)
+		case 12:
+			a.Maintenance = time.Duration(f.varint)
+		case 13:
+			if f.varint > 0xffff {
+				return fmt.Errorf("invalid weight %d", f.varint)
+			}
+			a.Weight = uint16(f.varint)
+		case 14:
+			a.Draining = f.varint != 0
+		case 15:
+			a.Complete = f.varint != 0
+		}
+		return nil
+	})
+}
+
+func (d *dead) marshalProto() []byte {
+	var b []byte
+	b = pbAppendVarint(b, 1, uint64(d.Incarnation))
+	b = pbAppendString(b, 2, d.Node)
+	b = pbAppendString(b, 3, d.From)
+	b = pbAppendVarint(b, 4, uint64(d.Reason))
+	return b
+}
+
+func (d *dead) unmarshalProto(buf []byte) error {
+	return pbDecode(buf, func(f pbField) error {
+		switch f.num {
+		case 1:
+			d.Incarnation = uint32(f.varint)
+		case 2:
+			d.Node = string(f.bytes)
+		case 3:
+			d.From = string(f.bytes)
+		case 4:
+			if f.varint > 0xff {
+				return fmt.Errorf("invalid reason %d", f.varint)
+			}
+			d.Reason = StateChangeReason(f.varint)
+		}
+		return nil
+	})
+}
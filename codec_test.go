@@ -0,0 +1,110 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProtobufCodec_RoundTrip(t *testing.T) {
+	cases := []struct {
+		name    string
+		msgType messageType
+		msg     protoMessage
+	}{
+		{"ping", pingMsg, &ping{SeqNo: 42, Node: "b", SourceAddr: []byte{127, 0, 0, 1}, SourcePort: 7946, SourceNode: "a"}},
+		{"indirect-ping", indirectPingMsg, &indirectPingReq{SeqNo: 7, Target: []byte{10, 0, 0, 1}, Port: 7946, Node: "c", Nack: true, SourceAddr: []byte{127, 0, 0, 1}, SourcePort: 7947, SourceNode: "a"}},
+		{"ack", ackRespMsg, &ackResp{SeqNo: 9, Payload: []byte("payload"), Health: 3}},
+		{"nack", nackRespMsg, &nackResp{SeqNo: 11}},
+		{"suspect", suspectMsg, &suspect{Incarnation: 2, Node: "b", From: "a", Reason: ReasonIndirectFailure}},
+		{"alive", aliveMsg, &alive{
+			Incarnation: 3, Node: "b", Addr: []byte{127, 0, 0, 1}, Port: 7946, Meta: []byte("meta"),
+			Vsn: []uint8{1, 7, 2, 0, 0, 0}, Addrs: []string{"10.0.0.1:7946", "10.0.0.2:7946"},
+			Role: Observer, Zone: "us-east-1a", ID: "4f9a3c2e-1d2b-4c5a-9e8f-7a6b5c4d3e2f", StreamPort: 7947, Maintenance: time.Minute, Weight: 250, Draining: true, Complete: true,
+		}},
+		{"dead", deadMsg, &dead{Incarnation: 4, Node: "b", From: "a", Reason: ReasonSuspicionExpired}},
+	}
+
+	codec := protobufCodec{}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			buf, err := codec.encode(c.msgType, c.msg)
+			require.NoError(t, err)
+			require.Equal(t, byte(protoMsg), buf.Bytes()[0])
+			require.Equal(t, byte(c.msgType), buf.Bytes()[1])
+
+			out := newProtoMessage(c.msgType)
+			require.NoError(t, codec.decode(buf.Bytes()[2:], out))
+			require.Equal(t, c.msg, out)
+		})
+	}
+}
+
+func TestProtobufCodec_Fallback(t *testing.T) {
+	codec := protobufCodec{}
+
+	// Messages without a protocol buffer form are sent as msgpack.
+	buf, err := codec.encode(relayMsg, &relay{Node: "b", Payload: []byte{byte(userMsg)}})
+	require.NoError(t, err)
+	require.Equal(t, byte(relayMsg), buf.Bytes()[0])
+
+	var r relay
+	require.NoError(t, decode(buf.Bytes()[1:], &r))
+	require.Equal(t, "b", r.Node)
+
+	require.Equal(t, errNotProtoMessage, codec.decode(nil, &r))
+}
+
+func TestMemberlist_CodecFor(t *testing.T) {
+	m, err := Create(testConfig(t))
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	a := Address{Addr: m.LocalNode().Address(), Name: m.config.Name}
+	require.IsType(t, msgpackCodec{}, m.codecFor(a))
+
+	m.config.WireCodec = WireCodecProtobuf
+	require.IsType(t, protobufCodec{}, m.codecFor(a))
+
+	// Unknown and older members get msgpack.
+	require.IsType(t, msgpackCodec{}, m.codecFor(Address{Addr: a.Addr, Name: "nope"}))
+	m.changeNode(m.config.Name, func(state *nodeState) {
+		state.PMax = 6
+	})
+	require.IsType(t, msgpackCodec{}, m.codecFor(a))
+}
+
+func TestMemberlist_PingProtobuf(t *testing.T) {
+	c1 := testConfig(t)
+	c1.WireCodec = WireCodecProtobuf
+	m1, err := Create(c1)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m1.Shutdown())
+	}()
+
+	c2 := testConfig(t)
+	c2.BindPort = m1.config.BindPort
+	c2.WireCodec = WireCodecProtobuf
+	m2, err := Create(c2)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m2.Shutdown())
+	}()
+
+	_, err = m2.Join([]string{m1.config.Name + "/" + m1.config.BindAddr})
+	require.NoError(t, err)
+
+	// Both the ping and the ack go out in the protobuf form.
+	addr := &net.UDPAddr{IP: net.ParseIP(m2.config.BindAddr), Port: m2.config.BindPort}
+	rtt, _, err := m1.Ping(m2.config.Name, addr)
+	require.NoError(t, err)
+	require.Greater(t, rtt, time.Duration(0))
+}
@@ -0,0 +1,74 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"net"
+	"strings"
+)
+
+// maxUnprunedStrings is how many interned strings are kept before the
+// ones no node uses any more are pruned, see pruneStrings.
+const maxUnprunedStrings = 32
+
+// intern returns the canonical copy of s, for strings many nodes carry the
+// same value of, like zones, so they share one allocation rather than each
+// keeping the one its alive message was decoded into. Strings that are
+// unique to a node aren't worth interning, the table entry costs more than
+// the string. The nodeLock must be held.
+func (m *Memberlist) intern(s string) string {
+	if s == "" {
+		return ""
+	}
+	if c, ok := m.strs[s]; ok {
+		return c
+	}
+	if m.strs == nil {
+		m.strs = make(map[string]string)
+	}
+	// Clone it, in case it points into a larger buffer it'd otherwise keep
+	// alive.
+	s = strings.Clone(s)
+	m.strs[s] = s
+	return s
+}
+
+// internName returns the copy of a node's name its state holds, if the node
+// is known, so the name from a message that's kept, like the key of a
+// suspicion timer, doesn't take another allocation. The nodeLock must be
+// held.
+func (m *Memberlist) internName(name string) string {
+	if state, ok := m.nodeMap[name]; ok {
+		return state.Name
+	}
+	return name
+}
+
+// pruneStrings drops the interned strings no node uses any more, so those
+// of reaped nodes don't pile up. The nodeLock must be held.
+func (m *Memberlist) pruneStrings() {
+	if len(m.strs) <= maxUnprunedStrings {
+		return
+	}
+	old := m.strs
+	m.strs = make(map[string]string, len(old))
+	for _, n := range m.nodes {
+		if c, ok := old[n.Zone]; ok {
+			m.strs[c] = c
+		}
+	}
+}
+
+// compactIP returns ip in its shortest form, 4 bytes for an IPv4 address
+// and 16 otherwise, in storage of its own. Decoded addresses are often the
+// 16 byte form of an IPv4 address, or point into the message they came in.
+func compactIP(ip []byte) net.IP {
+	if len(ip) == 0 {
+		return nil
+	}
+	if ip4 := net.IP(ip).To4(); ip4 != nil {
+		ip = ip4
+	}
+	return append(make(net.IP, 0, len(ip)), ip...)
+}
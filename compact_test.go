@@ -0,0 +1,148 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"fmt"
+	"net"
+	"runtime"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+// decodedAlive returns a as it'd arrive off the wire, with storage of its
+// own for each field.
+func decodedAlive(tb testing.TB, a alive) *alive {
+	buf, err := encode(aliveMsg, &a, false)
+	require.NoError(tb, err)
+	var out alive
+	require.NoError(tb, decode(buf.Bytes()[1:], &out))
+	return &out
+}
+
+func TestMemberlist_CompactNodes(t *testing.T) {
+	m := GetMemberlist(t, nil)
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	vsn := m.config.BuildVsnArray()
+	for _, name := range []string{"a", "b"} {
+		a := alive{
+			Node:        name,
+			Addr:        net.ParseIP("10.0.0.1"),
+			Port:        7946,
+			Incarnation: 1,
+			Vsn:         vsn,
+			Zone:        "us-east-1a",
+		}
+		m.aliveNode(decodedAlive(t, a), nil, false)
+	}
+
+	m.nodeLock.RLock()
+	a, b := m.nodeMap["a"], m.nodeMap["b"]
+	m.nodeLock.RUnlock()
+	require.Equal(t, unsafe.StringData(a.Zone), unsafe.StringData(b.Zone))
+	require.Len(t, a.Addr, net.IPv4len)
+	require.Equal(t, "10.0.0.1", a.Addr.String())
+
+	// The 16 byte form of the address it has isn't a change of address.
+	m.aliveNode(decodedAlive(t, alive{
+		Node:        "a",
+		Addr:        net.ParseIP("10.0.0.1").To16(),
+		Port:        7946,
+		Incarnation: 2,
+		Vsn:         vsn,
+		Zone:        "us-east-1b",
+		Complete:    true,
+	}), nil, false)
+	m.nodeLock.RLock()
+	require.Equal(t, uint32(2), a.Incarnation)
+	require.Equal(t, "us-east-1b", a.Zone)
+	m.nodeLock.RUnlock()
+}
+
+func TestMemberlist_PruneStrings(t *testing.T) {
+	m := GetMemberlist(t, nil)
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	vsn := m.config.BuildVsnArray()
+	for i := 0; i < 2*maxUnprunedStrings; i++ {
+		a := alive{
+			Node:        fmt.Sprintf("node-%d", i),
+			Addr:        []byte{127, 0, 0, 1},
+			Incarnation: 1,
+			Vsn:         vsn,
+			Zone:        fmt.Sprintf("zone-%d", i%maxUnprunedStrings),
+		}
+		m.aliveNode(&a, nil, false)
+	}
+
+	// Nothing to prune while it's small.
+	m.nodeLock.Lock()
+	m.pruneStrings()
+	require.Len(t, m.strs, maxUnprunedStrings)
+	m.nodes = m.nodes[:0]
+	m.nodeLock.Unlock()
+
+	for i := 0; i < 2*maxUnprunedStrings; i++ {
+		a := alive{Node: fmt.Sprintf("extra-%d", i), Addr: []byte{127, 0, 0, 1}, Incarnation: 1, Vsn: vsn, Zone: "extra"}
+		m.aliveNode(&a, nil, false)
+	}
+
+	m.nodeLock.Lock()
+	defer m.nodeLock.Unlock()
+	m.pruneStrings()
+	require.Equal(t, map[string]string{"extra": "extra"}, m.strs)
+}
+
+// BenchmarkMemberlist_NodeMemory reports the heap each member takes, once
+// its alive message has been applied and dropped.
+func BenchmarkMemberlist_NodeMemory(b *testing.B) {
+	const nodes = 10000
+	zones := []string{"us-east-1a", "us-east-1b", "us-east-1c"}
+
+	var ms runtime.MemStats
+	var total uint64
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		m := GetMemberlist(b, nil)
+		vsn := m.config.BuildVsnArray()
+		runtime.GC()
+		runtime.ReadMemStats(&ms)
+		before := ms.HeapAlloc
+		msgs := make([]*alive, nodes)
+		for j := range msgs {
+			msgs[j] = decodedAlive(b, alive{
+				Node:        fmt.Sprintf("node-%05d.example.internal", j),
+				Addr:        net.IPv4(10, byte(j>>16), byte(j>>8), byte(j)),
+				Port:        7946,
+				Meta:        []byte("role=web"),
+				Incarnation: 1,
+				Vsn:         vsn,
+				Zone:        zones[j%len(zones)],
+			})
+		}
+		b.StartTimer()
+
+		for _, a := range msgs {
+			m.aliveNode(a, nil, false)
+		}
+
+		b.StopTimer()
+		msgs = nil
+		m.broadcasts.Reset()
+		runtime.GC()
+		runtime.ReadMemStats(&ms)
+		total += ms.HeapAlloc - before
+		runtime.KeepAlive(m)
+		require.NoError(b, m.Shutdown())
+		b.StartTimer()
+	}
+	b.ReportMetric(float64(total)/float64(b.N*nodes), "B/node")
+}
@@ -0,0 +1,125 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"encoding/binary"
+	"sync"
+)
+
+// CompositeDelegate is a Delegate that fans the callbacks out to any number
+// of registered delegates, so independent parts of an application can each
+// hook into the gossip layer.
+//
+//   - NodeMeta returns the metadata of the first delegate that has any.
+//   - NotifyMsg is passed to every delegate, so each must ignore messages
+//     it doesn't recognize.
+//   - GetBroadcasts asks each delegate in turn, sharing the byte limit.
+//   - LocalState frames the state of each delegate, and MergeRemoteState
+//     hands each delegate its part of the remote state. Every member must
+//     register the same delegates in the same order.
+type CompositeDelegate struct {
+	mu        sync.RWMutex
+	delegates []Delegate
+}
+
+// NewCompositeDelegate returns a CompositeDelegate for the given delegates.
+func NewCompositeDelegate(delegates ...Delegate) *CompositeDelegate {
+	return &CompositeDelegate{delegates: delegates}
+}
+
+// Register adds a delegate after the existing ones.
+func (c *CompositeDelegate) Register(d Delegate) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.delegates = append(c.delegates, d)
+}
+
+// list returns a snapshot of the registered delegates.
+func (c *CompositeDelegate) list() []Delegate {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.delegates
+}
+
+func (c *CompositeDelegate) NodeMeta(limit int) []byte {
+	for _, d := range c.list() {
+		if meta := d.NodeMeta(limit); len(meta) > 0 {
+			return meta
+		}
+	}
+	return nil
+}
+
+func (c *CompositeDelegate) NotifyMsg(msg []byte) {
+	for _, d := range c.list() {
+		d.NotifyMsg(msg)
+	}
+}
+
+func (c *CompositeDelegate) GetBroadcasts(overhead, limit int) [][]byte {
+	var out [][]byte
+	for _, d := range c.list() {
+		if limit <= overhead {
+			break
+		}
+		msgs := d.GetBroadcasts(overhead, limit)
+		for _, msg := range msgs {
+			limit -= overhead + len(msg)
+		}
+		out = append(out, msgs...)
+	}
+	return out
+}
+
+func (c *CompositeDelegate) LocalState(join bool) []byte {
+	delegates := c.list()
+
+	// The state is the number of delegates, followed by the length and
+	// state of each.
+	buf := binary.AppendUvarint(nil, uint64(len(delegates)))
+	for _, d := range delegates {
+		state := d.LocalState(join)
+		buf = binary.AppendUvarint(buf, uint64(len(state)))
+		buf = append(buf, state...)
+	}
+	return buf
+}
+
+func (c *CompositeDelegate) MergeRemoteState(buf []byte, join bool) {
+	parts, ok := splitCompositeState(buf)
+	if !ok {
+		return
+	}
+	for i, d := range c.list() {
+		if i >= len(parts) {
+			break
+		}
+		if len(parts[i]) > 0 {
+			d.MergeRemoteState(parts[i], join)
+		}
+	}
+}
+
+// splitCompositeState splits the output of CompositeDelegate.LocalState into
+// the state of each delegate.
+func splitCompositeState(buf []byte) ([][]byte, bool) {
+	n, size := binary.Uvarint(buf)
+	if size <= 0 || n > uint64(len(buf)) {
+		return nil, false
+	}
+	buf = buf[size:]
+
+	parts := make([][]byte, 0, n)
+	for i := uint64(0); i < n; i++ {
+		l, size := binary.Uvarint(buf)
+		if size <= 0 || l > uint64(len(buf)-size) {
+			return nil, false
+		}
+		buf = buf[size:]
+		parts = append(parts, buf[:l])
+		buf = buf[l:]
+	}
+	return parts, true
+}
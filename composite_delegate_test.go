@@ -0,0 +1,69 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompositeDelegate(t *testing.T) {
+	d1, d2 := &MockDelegate{}, &MockDelegate{}
+	c := NewCompositeDelegate(d1)
+	c.Register(d2)
+
+	// Metadata comes from the first delegate that has any.
+	d2.setMeta([]byte("two"))
+	require.Equal(t, []byte("two"), c.NodeMeta(MetaMaxSize))
+	d1.setMeta([]byte("one"))
+	require.Equal(t, []byte("one"), c.NodeMeta(MetaMaxSize))
+
+	// Messages go to everyone.
+	c.NotifyMsg([]byte("hello"))
+	require.Equal(t, [][]byte{[]byte("hello")}, d1.getMessages())
+	require.Equal(t, [][]byte{[]byte("hello")}, d2.getMessages())
+
+	// Broadcasts share the limit.
+	d1.setBroadcasts([][]byte{[]byte("aaaa")})
+	d2.setBroadcasts([][]byte{[]byte("bbbb")})
+	require.Equal(t, [][]byte{[]byte("aaaa"), []byte("bbbb")}, c.GetBroadcasts(1, 10))
+
+	// Each delegate gets its own part of the remote state.
+	d1.setState([]byte("state one"))
+	d2.setState(nil)
+	state := c.LocalState(false)
+
+	r1, r2 := &MockDelegate{}, &MockDelegate{}
+	NewCompositeDelegate(r1, r2).MergeRemoteState(state, false)
+	require.Equal(t, []byte("state one"), r1.getRemoteState())
+	require.Empty(t, r2.getRemoteState())
+
+	// Garbage is ignored.
+	r3 := &MockDelegate{}
+	NewCompositeDelegate(r3).MergeRemoteState([]byte{5, 100}, false)
+	require.Empty(t, r3.getRemoteState())
+}
+
+func TestMemberlist_SetDelegate(t *testing.T) {
+	d1 := &MockDelegate{}
+	m := GetMemberlist(t, func(c *Config) {
+		c.Delegate = d1
+	})
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	m.handleUser([]byte("one"), nil)
+
+	d2 := &MockDelegate{}
+	m.SetDelegate(d2)
+	m.handleUser([]byte("two"), nil)
+
+	m.SetDelegate(nil)
+	m.handleUser([]byte("three"), nil)
+
+	require.Equal(t, [][]byte{[]byte("one")}, d1.getMessages())
+	require.Equal(t, [][]byte{[]byte("two")}, d2.getMessages())
+}
@@ -4,12 +4,14 @@
 package memberlist
 
 import (
+	"crypto/tls"
 	"fmt"
 	"io"
 	"log"
 	"net"
 	"os"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/hashicorp/go-metrics/compat"
@@ -43,11 +45,105 @@ type Config struct {
 	BindAddr string
 	BindPort int
 
+	// StreamBindPort optionally sets a separate port to listen for stream
+	// (TCP) connections on, leaving BindPort for packets (UDP). Zero uses
+	// BindPort for both. The stream port is advertised, so peers don't need
+	// to be configured the same way, though members running an older
+	// version still assume the two ports match.
+	StreamBindPort int
+
+	// BindInterface optionally names a network interface, such as "eth1",
+	// to bind to instead of BindAddr. All of its addresses are looked up at
+	// startup, and looked up again if binding to them fails, in case they
+	// were changing at the time.
+	BindInterface string
+
+	// SocketControl is an optional hook that is called on the TCP and UDP
+	// listener sockets after they're created and before they're bound, in
+	// the same way as net.ListenConfig.Control. It can be used to set
+	// options such as SO_REUSEPORT, IP_TOS for DSCP marking or
+	// SO_BINDTODEVICE. Returning an error fails Create. This only applies
+	// to the default transport.
+	SocketControl func(network, address string, c syscall.RawConn) error
+
+	// TLSConfig, if set, wraps the default transport's stream connections
+	// in TLS. The same config is used for both ends of a stream, so it
+	// needs Certificates, and for mutual TLS ClientAuth and ClientCAs too.
+	// Outgoing streams check the peer's certificate against the host in
+	// its address unless ServerName is set. Packets aren't covered by
+	// this; use the keyring to encrypt those.
+	TLSConfig *tls.Config
+
+	// Authorizer, if set, checks the identity in the peer's certificate
+	// on every stream, and streams that fail the check are closed. Streams
+	// have to be TLS for this, with mutual TLS for incoming ones, so it
+	// needs TLSConfig or a Transport whose streams are *tls.Conn. See
+	// SPIFFEAuthorizer and SANAuthorizer for ready made ones, and
+	// CertPinAuthorizer for pinning the certificate of each node.
+	Authorizer Authorizer
+
 	// Configuration related to what address to advertise to other
 	// cluster members. Used for nat traversal.
 	AdvertiseAddr string
 	AdvertisePort int
 
+	// AdvertiseStreamPort is the stream port to advertise, for when it
+	// differs from StreamBindPort, such as behind NAT. Zero advertises
+	// StreamBindPort.
+	AdvertiseStreamPort int
+
+	// AdvertiseInterface optionally names a network interface whose
+	// address is advertised when AdvertiseAddr isn't set, preferring a
+	// private address. This lets a multi-homed host bind to 0.0.0.0 and
+	// still advertise the right address, rather than the first private
+	// address found on any interface.
+	AdvertiseInterface string
+
+	// AdvertiseAddrFilter picks which interface addresses may be
+	// advertised when neither AdvertiseAddr nor AdvertiseInterface is set
+	// and BindAddr is unspecified, such as 0.0.0.0. Exactly one address
+	// must pass it, or Create fails. See DetectAdvertiseAddr. If it's nil,
+	// the transport picks a private address itself.
+	AdvertiseAddrFilter func(iface net.Interface, ip net.IP) bool
+
+	// AdvertiseAddrs is an optional list of additional addresses to
+	// advertise alongside AdvertiseAddr, such as an IPv6 address on a
+	// dual-stack host or a public address next to a private one. Entries
+	// are IPs, optionally with a port, which defaults to the advertised
+	// port. Peers try them when the primary address stops answering and
+	// remember whichever one works.
+	AdvertiseAddrs []string
+
+	// STUNServers is an optional list of STUN servers, as host or
+	// host:port with the port defaulting to 3478, that are asked for our
+	// public address and port when AdvertiseAddr isn't set. The answer is
+	// advertised in place of the transport's address, so nodes behind NAT
+	// at different sites can reach each other without configuring it by
+	// hand. The servers are tried in turn; if none answers at startup we
+	// fall back to the transport's address. Streams are assumed to come in
+	// on the same public port unless AdvertiseStreamPort is set, and the
+	// NAT has to keep the same mapping for every peer, which rules out
+	// symmetric NATs. The transport has to implement STUNTransport, which
+	// NetTransport does.
+	STUNServers []string
+
+	// STUNInterval is how often the STUN servers are asked again, so a
+	// changed mapping is advertised with a new alive message. Members take
+	// the new address once the old one has failed its probes and the node
+	// is declared dead, which they only allow early for a node with the
+	// same ID, so this needs NodeID, or StateDir to generate one. Zero only
+	// asks at startup.
+	STUNInterval time.Duration
+
+	// DialProxy is an optional proxy that outgoing stream connections, such
+	// as push/pull syncs and TCP fallback probes, are made through. This
+	// allows nodes to reach peers on networks that are only reachable via
+	// a bastion. It's given as a URL, either socks5://host:port or
+	// http://host:port for a proxy supporting the CONNECT method, with
+	// optional user:password credentials. This only applies when memberlist
+	// creates its own transport; UDP packets are always sent directly.
+	DialProxy string
+
 	// ProtocolVersion is the configured protocol version that we
 	// will _speak_. This must be between ProtocolVersionMin and
 	// ProtocolVersionMax.
@@ -60,6 +156,22 @@ type Config struct {
 	// the transport.
 	TCPTimeout time.Duration
 
+	// DialRaceDelay is how long a stream connection attempt to one of a
+	// node's addresses gets before an attempt to the next one starts
+	// alongside it, when the node advertises several. This is the
+	// "connection attempt delay" from RFC 8305, so the first address that
+	// works wins rather than each dead one costing a full TCPTimeout.
+	// Attempts alternate between IPv6 and IPv4 addresses. Zero turns this
+	// off and only the node's current address is dialed.
+	DialRaceDelay time.Duration
+
+	// ShutdownTimeout is how long Shutdown waits for push/pulls and other
+	// stream sessions already in flight to finish, once it has stopped
+	// accepting new stream connections, before it closes the sockets
+	// anyway. The default of zero doesn't wait, and closes them straight
+	// away.
+	ShutdownTimeout time.Duration
+
 	// IndirectChecks is the number of nodes that will be asked to perform
 	// an indirect probe of a node in the case a direct probe fails. Memberlist
 	// waits for an ack from any single indirect node, so increasing this
@@ -76,8 +188,20 @@ type Config struct {
 	// This allows the retransmits to scale properly with cluster size. The
 	// higher the multiplier, the more likely a failed broadcast is to converge
 	// at the expense of increased bandwidth.
+	//
+	// It applies to membership messages, and to user broadcasts too unless
+	// UserRetransmitMult is set.
 	RetransmitMult int
 
+	// UserRetransmitMult is like RetransmitMult, but for the user messages
+	// memberlist gossips itself, those sent with BroadcastWithAck, so the
+	// reliability of an application's own data can be tuned without changing
+	// how much membership traffic there is. If it's zero, RetransmitMult is
+	// used, including any adjustment auto-tuning makes to it. Delegates that
+	// queue their own broadcasts set the multiplier on their own
+	// TransmitLimitedQueue.
+	UserRetransmitMult int
+
 	// SuspicionMult is the multiplier for determining the time an
 	// inaccessible node is considered suspect before declaring it dead.
 	// The actual timeout is calculated using the formula:
@@ -118,6 +242,79 @@ type Config struct {
 	// usage.
 	PushPullInterval time.Duration
 
+	// DeltaPushPull makes periodic push/pull syncs first exchange a compact
+	// digest of each node's incarnation and state, and then transfer only
+	// the entries that differ. This saves a lot of bandwidth in large,
+	// stable clusters. It's only used with members that understand protocol
+	// version 6 or greater, and a failed delta sync falls back to a complete
+	// one. Join always does a complete sync.
+	DeltaPushPull bool
+
+	// JoinParallelism is how many of the seeds given to Join are contacted
+	// at once, so a few seeds that are down don't hold up the rest. One
+	// contacts them one after another.
+	JoinParallelism int
+
+	// JoinTimeout bounds how long Join takes overall. Once it runs out, Join
+	// returns with the seeds that have synced so far. Zero means no limit
+	// beyond the TCPTimeout of each attempt.
+	JoinTimeout time.Duration
+
+	// JoinMinSuccess makes Join return as soon as this many seeds have
+	// synced, without waiting for the rest. Zero waits for every seed.
+	JoinMinSuccess int
+
+	// WireCodec selects the encoding of the probe messages sent directly to
+	// other members. The default is msgpack. WireCodecProtobuf is only used
+	// with members that understand protocol version 7 or greater, so it's
+	// safe to enable in a mixed cluster.
+	WireCodec WireCodec
+
+	// DelegatePanicHandler, if set, makes memberlist recover from panics in
+	// any of the delegate callbacks instead of crashing. It's called with the
+	// name of the callback that panicked and the recovered value. A callback
+	// that can reject its input, such as NotifyAlive or NotifyMerge, counts
+	// as having rejected it.
+	DelegatePanicHandler func(callback string, recovered interface{})
+
+	// NotifyMsgWorkers, if greater than zero, runs the delegate's NotifyMsg
+	// on this many worker goroutines instead of the packet and stream
+	// handlers, so a slow callback can't stall them. NotifyMsgTimeout is how
+	// long a message waits for a free worker before it's dropped; zero waits
+	// indefinitely.
+	NotifyMsgWorkers int
+	NotifyMsgTimeout time.Duration
+
+	// DeliverOwnBroadcasts makes BroadcastWithAck hand the message to the
+	// local delegate's NotifyMsg before it returns, so the sender sees its
+	// own broadcasts in the same order as the rest of its state changes
+	// instead of never. By default the sender isn't told, since a delegate
+	// that already acts on what it broadcasts would otherwise do it twice.
+	DeliverOwnBroadcasts bool
+
+	// RequiredMembers is the number of live members, counting this one,
+	// that must be known before the channel returned by Memberlist.Ready is
+	// closed. Zero or one means ready as soon as Create returns.
+	RequiredMembers int
+
+	// AntiEntropyInterval is the interval between cheap divergence checks
+	// done in between push/pull syncs. Each check sends a small hash summary
+	// of our node states to a random member, and if its view differs it
+	// repairs just the differing part with a delta push/pull. Setting this
+	// to zero disables the checks. Members that don't understand protocol
+	// version 6 are never picked.
+	AntiEntropyInterval time.Duration
+
+	// DigestRepairThreshold adds a tiny digest of our node states, a count
+	// and a hash, to every gossip round. A member that gets this many
+	// digests in a row from the same sender that differ from its own does
+	// a push/pull with the sender straight away, rather than waiting for
+	// the next PushPullInterval. Gossip is sent every GossipInterval even
+	// when there are no broadcasts, so digests keep flowing. Zero disables
+	// it. Digests are only sent to members that understand protocol
+	// version 9, and not to a MulticastAddr.
+	DigestRepairThreshold int
+
 	// ProbeInterval and ProbeTimeout are used to configure probing
 	// behavior for memberlist.
 	//
@@ -131,6 +328,29 @@ type Config struct {
 	ProbeInterval time.Duration
 	ProbeTimeout  time.Duration
 
+	// AutoTuneInterval, if set, is how often memberlist looks at how many
+	// of its direct probes went unanswered by members that turned out to
+	// be fine, and adjusts RetransmitMult and ProbeTimeout to suit. When
+	// more than 5% were lost, both go up a step, to at most AutoTuneMaxMult
+	// times their configured values; when less than 1% were lost they come
+	// back down a step, no lower than configured. The values in effect are
+	// reported by Memberlist.AutoTuned and in metrics. By default, this is
+	// 0, meaning the configured values are always used.
+	AutoTuneInterval time.Duration
+
+	// AutoTuneMaxMult bounds how far auto-tuning raises RetransmitMult and
+	// ProbeTimeout, as a multiple of their configured values. ProbeTimeout
+	// is also kept under half of ProbeInterval.
+	AutoTuneMaxMult int
+
+	// ProbeJitterPercent delays every probe by a random amount of up to this
+	// percentage of ProbeInterval, so the probes of a large cluster don't
+	// synchronize into bursts. The delay is taken out of the interval, so
+	// the probe rate stays the same, and ProbeTimeout still governs how
+	// long to wait for acks. Values above 90 are treated as 90. By default,
+	// this is 0, meaning probes run right on the interval.
+	ProbeJitterPercent int
+
 	// DisableTcpPings will turn off the fallback TCP pings that are attempted
 	// if the direct UDP ping fails. These get pipelined along with the
 	// indirect UDP pings.
@@ -140,6 +360,62 @@ type Config struct {
 	// whether to perform TCP pings on a node-by-node basis.
 	DisableTcpPingsForNode func(nodeName string) bool
 
+	// DisableProbes turns off memberlist's own failure detection. No probes
+	// are sent, and liveness verdicts are instead fed in through
+	// Memberlist.SetNodeState, for example from an existing health-check
+	// system. This node still answers probes from other members.
+	DisableProbes bool
+
+	// FailureDetector optionally decides whether a node that failed a probe
+	// should be suspected, for example a PhiAccrualDetector. If this is nil
+	// a node is suspected as soon as a single probe fails.
+	FailureDetector FailureDetector
+
+	// Zone optionally names the zone or region this node is in, which is
+	// advertised to the other members.
+	Zone string
+
+	// CrossZoneFraction is the fraction of gossip targets and indirect
+	// probe helpers that are picked from zones other than Zone, rounded
+	// up. Mixing in helpers from other zones means a network event local
+	// to one zone, or a flaky link between zones, doesn't get a whole zone
+	// declared dead. This has no effect unless Zone is set. By default,
+	// this is 0, meaning targets are picked without regard to zones.
+	CrossZoneFraction float64
+
+	// Weight is this node's relative capacity, which is advertised to the
+	// other members for applications to spread load by, such as when
+	// placing nodes on a ring or fanning out queries. It can be changed
+	// later with SetWeight or a WeightDelegate. Zero means DefaultWeight.
+	Weight uint16
+
+	// WeightedSelection biases the picking of gossip targets and indirect
+	// probe helpers by the weight of each node, so weak nodes are picked
+	// less often than strong ones. By default nodes are picked uniformly.
+	WeightedSelection bool
+
+	// NodeID is an optional stable identity for this node, in the form of a
+	// UUID, that's advertised alongside the name. Members use it to tell a
+	// different node reusing the name apart from this one, and to let this
+	// one come back at a new address once it's been declared dead without
+	// waiting for DeadNodeReclaimTime. It's sent in the clear, so it isn't
+	// trusted to move a live node. If this is empty and StateDir is set, an
+	// ID is generated on the first start and kept in StateDir.
+	NodeID string
+
+	// StateDir is an optional directory where memberlist keeps state that
+	// should survive restarts. Currently that's the local incarnation
+	// number, so a node that comes back quickly starts past the incarnation
+	// it used before, instead of at zero, and wins the refutation race
+	// against stale messages declaring its previous life dead.
+	StateDir string
+
+	// Role is the part this node plays in the cluster. Set it to Observer
+	// for nodes, such as dashboards or CLIs, that want a live view of the
+	// membership without affecting failure detection. By default, this is
+	// FullMember.
+	Role NodeRole
+
 	// AwarenessMaxMultiplier will increase the probe interval if the node
 	// becomes aware that it might be degraded and not meeting the soft real
 	// time requirements to reliably probe other nodes.
@@ -161,10 +437,25 @@ type Config struct {
 	//
 	// GossipToTheDeadTime is the interval after which a node has died that
 	// we will still try to gossip to it. This gives it a chance to refute.
+	// Once it has passed for a node that left, an alive message from the
+	// same address or ID is taken as the node restarting, whatever its
+	// incarnation number.
 	GossipInterval      time.Duration
 	GossipNodes         int
 	GossipToTheDeadTime time.Duration
 
+	// MulticastGroup, if set, is a multicast address and port, such as
+	// "239.255.77.77:7947", that each gossip round is also sent to. It
+	// stands in for all but one of the GossipNodes unicast packets, which
+	// still goes out in case the group doesn't reach every member. Probes
+	// are always sent unicast. The default NetTransport joins the group on
+	// the system's default multicast interface; a custom Transport has to
+	// join it itself to receive anything. Packets go out with a TTL of one,
+	// so this is only useful on a LAN where every member can join the
+	// group, and it's meant for dense clusters where the unicast fanout
+	// adds up.
+	MulticastGroup string
+
 	// GossipVerifyIncoming controls whether to enforce encryption for incoming
 	// gossip. It is used for upshifting from unencrypted to encrypted gossip on
 	// a running cluster.
@@ -191,8 +482,22 @@ type Config struct {
 
 	// The keyring holds all of the encryption keys used internally. It is
 	// automatically initialized using the SecretKey and SecretKeys values.
+	// If memberlist creates it from SecretKey, Shutdown wipes it; a keyring
+	// passed in is left alone, so it can be shared, and it's up to its owner
+	// to call Wipe. SecretKey can be wiped once Create returns.
 	Keyring *Keyring
 
+	// FIPSMode restricts memberlist to FIPS approved cryptography. Packets
+	// are already encrypted with AES-GCM, so this is about TLSConfig:
+	// Create fails if it allows TLS versions, cipher suites or curves that
+	// aren't approved, and the approved ones are filled in for any it
+	// leaves to Go's defaults. This only covers the choices memberlist
+	// makes; the cryptography itself is only validated when Go is running
+	// in FIPS 140-3 mode, or built with GOEXPERIMENT=boringcrypto, and a
+	// warning is logged if it isn't. Building with the fips tag turns
+	// this on regardless.
+	FIPSMode bool
+
 	// Delegate and Events are delegates for receiving and providing
 	// data to memberlist via callback mechanisms. For Delegate, see
 	// the Delegate interface. For Events, see the EventDelegate interface.
@@ -210,6 +515,113 @@ type Config struct {
 	Merge                   MergeDelegate
 	Ping                    PingDelegate
 	Alive                   AliveDelegate
+	Partition               PartitionDelegate
+
+	// Tracer, if set, is told about every packet sent and received, and
+	// every probe and push/pull, for tracing. See the Tracer interface.
+	Tracer Tracer
+
+	// ProbeObserver, if set, is told the outcome of every attempt to reach
+	// a member we probe, direct, indirect or over TCP. See the
+	// ProbeObserver interface.
+	ProbeObserver ProbeObserver
+
+	// AuditHook, if set, is told about administrative actions that change
+	// the membership or how this node takes part in it: joining, leaving,
+	// forcing a node's state, changing keys across the cluster, and
+	// changing the tuning, weight, maintenance or draining of this node.
+	// It gives security teams an audit trail of who changed what. Calls to
+	// those Memberlist methods are reported as ActorLocal, and the control
+	// socket and gRPC admin API report the commands they take with their
+	// caller as the actor, so a command that calls one of the methods is
+	// reported twice. It's called synchronously, so it should be quick.
+	AuditHook AuditHook
+
+	// DebugRingSize, if positive, keeps that many of the most recent
+	// messages sent and received, with their decoded fields, so they can
+	// be written out with DebugDump. This costs an extra decode of every
+	// message, so it's meant for debugging rather than production.
+	DebugRingSize int
+
+	// EventJournalPath, if set, is a file that membership events are
+	// appended to as they happen, so the history of the membership can be
+	// replayed with ReplayEvents, such as when reviewing an incident. The
+	// events are recorded whether or not there's an event delegate.
+	//
+	// EventJournalMaxSize bounds how many bytes of events are kept on disk.
+	// The journal is rotated into a second file with a ".1" suffix once it
+	// reaches half of this, and the oldest events are dropped.
+	EventJournalPath    string
+	EventJournalMaxSize int64
+
+	// EnableChaos allows failures to be injected with Memberlist.Chaos, for
+	// testing how the cluster copes with them. It's off by default so that
+	// a stray call can't degrade a production member.
+	EnableChaos bool
+
+	// PartitionThreshold and PartitionWindow control when the Partition
+	// delegate is told about a possible partition: when more than this
+	// fraction of the members become suspect or dead within the window.
+	// A threshold of zero turns detection off.
+	PartitionThreshold float64
+	PartitionWindow    time.Duration
+
+	// ReconnectInterval is how often we try to reach a failed member or
+	// one of ReconnectSeeds with a push/pull while we know of fewer live
+	// members than we have in the past, so the two sides of a healed
+	// partition merge again. Members that left gracefully don't count.
+	// Failed members are tried for up to ReconnectTimeout after they're
+	// forgotten. Only the fastest of ReconnectSeeds is tried, see
+	// SeedStats. Setting ReconnectInterval to zero turns this off.
+	ReconnectInterval time.Duration
+	ReconnectTimeout  time.Duration
+	ReconnectSeeds    []string
+
+	// FlapHalfLife turns on damping of nodes that keep flapping between
+	// alive and suspect. Each time a node becomes suspect it gains a point
+	// of penalty, which halves every FlapHalfLife. Once the penalty reaches
+	// FlapThreshold the node is damped: its suspicion timeout is doubled
+	// for every point over the threshold, up to FlapMaxMultiplier, so it
+	// has longer to refute instead of churning between dead and alive. It
+	// stays damped until the penalty decays to half the threshold. Setting
+	// FlapHalfLife to zero turns this off.
+	FlapHalfLife      time.Duration
+	FlapThreshold     float64
+	FlapMaxMultiplier int
+
+	// HealthyMaxFlaps is the most recent flaps, as counted for damping, a
+	// live member can have and still be returned by HealthyMembers. Zero
+	// leaves out a member that has flapped at all recently. It has no
+	// effect unless FlapHalfLife is set.
+	HealthyMaxFlaps int
+
+	// SendFailureThreshold is the number of sends or dials to a node that
+	// must fail in a row before we stop picking it for gossip and as an
+	// indirect probe helper for SendFailureCooldown, rather than waste
+	// fanout on a peer we can't reach. It's still probed directly. Zero
+	// turns this off.
+	SendFailureThreshold int
+	SendFailureCooldown  time.Duration
+
+	// UnknownNodeHoldTime is how long we hold suspect and dead messages
+	// about nodes we don't know about yet, rather than drop them, in case
+	// they overtook the node's alive message. Hearing about an unknown
+	// node also starts a push/pull with the sender. Zero turns this off.
+	UnknownNodeHoldTime time.Duration
+
+	// MaxMaintenance is the longest a node can be in maintenance for, see
+	// SetMaintenance. Longer periods announced by other nodes are cut short
+	// to this, so a node can't turn off failure detection for itself for
+	// good. Zero turns maintenance mode off.
+	MaxMaintenance time.Duration
+
+	// TombstoneTimeout is how long we remember dead and left nodes after
+	// they're reaped from the member list. Until then, alive messages for
+	// them that aren't newer than when they died are ignored, and they're
+	// passed on in push/pulls, so a node that joins right after a departure
+	// doesn't bring the departed member back from a peer's stale state.
+	// Zero turns this off.
+	TombstoneTimeout time.Duration
 
 	// DNSConfigPath points to the system's DNS config file, usually located
 	// at /etc/resolv.conf. It can be overridden via config for easier testing.
@@ -231,6 +643,34 @@ type Config struct {
 	// while UDP messages are handled.
 	HandoffQueueDepth int
 
+	// HandoffDropPolicy controls what happens when a message arrives and its
+	// handoff queue already holds HandoffQueueDepth messages. See the
+	// HandoffDropPolicy constants. The default is DropNewest.
+	HandoffDropPolicy HandoffDropPolicy
+
+	// HandoffWorkers is the number of goroutines that process messages from
+	// the handoff queue. Membership messages (alive, suspect, dead) are
+	// always taken ahead of user messages, so a flood of user broadcasts
+	// can't delay failure detection traffic. Defaults to 1, which processes
+	// messages strictly one at a time.
+	HandoffWorkers int
+
+	// PacketWorkers is the number of goroutines that read packets from the
+	// transport, decrypting and unpacking them before they're handled or
+	// handed off. Defaults to 1; more can help when decryption is the
+	// bottleneck, at the cost of packets from one sender possibly being
+	// handled out of order.
+	PacketWorkers int
+
+	// StreamWorkers is the number of goroutines that handle incoming
+	// stream connections. When they're all busy, new connections wait in
+	// the transport, so a node that's hammered with connections doesn't
+	// start a goroutine for every one. A worker is held for as long as the
+	// connection is open, up to TCPTimeout, so connections that are opened
+	// and left idle can keep them all busy. The default of zero handles
+	// each connection on a goroutine of its own, with no limit.
+	StreamWorkers int
+
 	// Maximum number of bytes that memberlist will put in a packet (this
 	// will be for UDP packets by default with a NetTransport). A safe value
 	// for this is typically 1400 bytes (which is the default). However,
@@ -240,6 +680,26 @@ type Config struct {
 	// called PacketBufferSize now that we have generalized the transport.
 	UDPBufferSize int
 
+	// MTUProbeInterval, if set, is how often we find out how large a packet
+	// gets through to a random member, by pinging it with packets of
+	// different sizes up to UDPBufferSize. Gossip and piggybacked broadcasts
+	// sent to that member are then kept to that size, so paths with a
+	// smaller MTU than the rest, such as through a VPN or an overlay
+	// network, don't silently lose full-sized packets. With a NetTransport
+	// it also sets the don't fragment bit on outgoing packets where the
+	// platform allows it. Members reply to probes with packets of their own
+	// that carry broadcasts too, so it works best when set on every member.
+	// Zero turns probing off.
+	MTUProbeInterval time.Duration
+
+	// MaxUserMessageSize is the largest user message, in bytes, that
+	// SendBestEffort, SendToAddress, SendReliable and BroadcastWithAck
+	// accept, failing with ErrMessageTooLarge otherwise. Larger user
+	// messages from peers, and user broadcasts from the delegate, are
+	// dropped. Zero means no limit beyond what fits in a packet; see
+	// MaxPayloadSize.
+	MaxUserMessageSize int
+
 	// DeadNodeReclaimTime controls the time before a dead node's name can be
 	// reclaimed by one with a different address or port. By default, this is 0,
 	// meaning nodes cannot be reclaimed this way.
@@ -249,11 +709,44 @@ type Config struct {
 	// a message to that node.
 	RequireNodeNames bool
 
+	// RelayFactor is the number of other members that SendBestEffort also
+	// sends each message through, in addition to sending it directly. The
+	// relays forward the message to the target, which improves the odds of
+	// delivery when the direct path between two specific nodes is lossy or
+	// blocked. The target may get the same message more than once, so the
+	// delegate has to tolerate duplicates. By default, this is 0, which
+	// disables relaying.
+	RelayFactor int
+
+	// HolePunching lets members behind NAT reach each other directly. When
+	// we can only probe a member through others, we ask one of those to
+	// act as a rendezvous, and it has both sides send packets to each
+	// other at the same time so each NAT sees outgoing traffic to the
+	// other and lets its answers in. If that fails, packets between the
+	// two go through the rendezvous until punching is tried again, and a
+	// member with this set forwards any packet for others, not just user
+	// messages. Streams, such as push/pull, aren't relayed. It has to be
+	// set on every member, and is meant to be used with STUNServers so
+	// members advertise their public addresses.
+	HolePunching bool
+
 	// CIDRsAllowed If nil, allow any connection (default), otherwise specify all networks
 	// allowed to connect (you must specify IPv6/IPv4 separately)
 	// Using [] will block all connections.
 	CIDRsAllowed []net.IPNet
 
+	// VerifyPacketSource drops packets that don't come from the IP address
+	// of a member we know, whether alive or not, or one of the additional
+	// addresses it advertises, and counts them. Pings are let through, so
+	// a member that's just joined through someone else can probe us
+	// before we've heard of it, and so are the messages that set up a hole
+	// punch with HolePunching. It makes spoofing gossip harder on open
+	// networks, but members whose packets come from another address than
+	// the ones they advertise, such as behind NAT, can't be heard from
+	// except through streams. Acks to Ping for addresses that aren't
+	// members are dropped as well.
+	VerifyPacketSource bool
+
 	// MetricLabels is a map of optional labels to apply to all metrics emitted.
 	MetricLabels []metrics.Label
 
@@ -268,6 +761,39 @@ type Config struct {
 	MsgpackUseNewTimeFormat bool
 }
 
+// HandoffDropPolicy selects how the incoming message queue behaves when it
+// overflows.
+type HandoffDropPolicy int
+
+const (
+	// DropNewest discards the message that didn't fit, keeping everything
+	// that is already queued.
+	DropNewest HandoffDropPolicy = iota
+
+	// DropOldest evicts the oldest queued message to make room for the new
+	// one, favoring fresh information.
+	DropOldest
+
+	// Block stalls the packet listener until there is room in the queue. No
+	// messages are lost inside memberlist, but the transport will start to
+	// drop packets instead once its own buffers fill up.
+	Block
+)
+
+// String returns the policy name, as used in metric labels.
+func (p HandoffDropPolicy) String() string {
+	switch p {
+	case DropNewest:
+		return "drop-newest"
+	case DropOldest:
+		return "drop-oldest"
+	case Block:
+		return "block"
+	default:
+		return fmt.Sprintf("unknown-%d", int(p))
+	}
+}
+
 // ParseCIDRs return a possible empty list of all Network that have been parsed
 // In case of error, it returns succesfully parsed CIDRs and the last error found
 func ParseCIDRs(v []string) ([]net.IPNet, error) {
@@ -309,12 +835,15 @@ func DefaultLANConfig() *Config {
 		AdvertisePort:           7946,
 		ProtocolVersion:         ProtocolVersion2Compatible,
 		TCPTimeout:              10 * time.Second,       // Timeout after 10 seconds
+		DialRaceDelay:           250 * time.Millisecond, // RFC 8305 recommends 250ms
+		JoinParallelism:         4,                      // Contact 4 seeds at once
 		IndirectChecks:          3,                      // Use 3 nodes for the indirect ping
 		RetransmitMult:          4,                      // Retransmit a message 4 * log(N+1) nodes
 		SuspicionMult:           4,                      // Suspect a node for 4 * log(N+1) * Interval
 		SuspicionMaxTimeoutMult: 6,                      // For 10k nodes this will give a max timeout of 120 seconds
 		PushPullInterval:        30 * time.Second,       // Low frequency
 		ProbeTimeout:            500 * time.Millisecond, // Reasonable RTT time for LAN
+		AutoTuneMaxMult:         2,                      // Up to twice the configured values
 		ProbeInterval:           1 * time.Second,        // Failure check every second
 		DisableTcpPings:         false,                  // TCP pings are safe, even with mixed versions
 		AwarenessMaxMultiplier:  8,                      // Probe interval backs off to 8 seconds
@@ -333,10 +862,36 @@ func DefaultLANConfig() *Config {
 		DNSConfigPath: "/etc/resolv.conf",
 
 		HandoffQueueDepth: 1024,
+		HandoffWorkers:    1,
+		PacketWorkers:     1,
 		UDPBufferSize:     1400,
 		CIDRsAllowed:      nil, // same as allow all
 
 		QueueCheckInterval: 30 * time.Second,
+
+		PartitionThreshold: 0.3,              // A third of the cluster
+		PartitionWindow:    30 * time.Second, // Failing within 30 seconds
+
+		ReconnectInterval: 30 * time.Second, // Try to heal partitions every 30 seconds
+		ReconnectTimeout:  6 * time.Hour,    // For up to 6 hours
+
+		FlapHalfLife:      5 * time.Minute, // Forgive flaps over a few minutes
+		FlapThreshold:     3,               // Damp from the third recent flap
+		FlapMaxMultiplier: 8,               // Up to 8x the suspicion timeout
+		HealthyMaxFlaps:   1,               // Route around a node from its second recent flap
+
+		SendFailureThreshold: 3,                // Skip a peer after 3 failed sends
+		SendFailureCooldown:  10 * time.Second, // For 10 seconds
+
+		UnknownNodeHoldTime: 5 * time.Second, // Hold messages about unknown nodes for 5 seconds
+
+		MaxMaintenance: 30 * time.Minute, // Enough for a restart or a deploy
+
+		TombstoneTimeout: 5 * time.Minute, // Remember reaped nodes for 5 minutes
+
+		STUNInterval: 5 * time.Minute, // Catch NAT mapping changes within minutes
+
+		EventJournalMaxSize: 16 << 20, // 16 MiB
 	}
 }
 
@@ -0,0 +1,411 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigEnvPrefix is the prefix of the environment variables that override
+// settings read by LoadConfig. The rest of the name is the setting in upper
+// case, so bind_addr is overridden by MEMBERLIST_BIND_ADDR.
+const ConfigEnvPrefix = "MEMBERLIST_"
+
+// fileConfig is the form of the configuration read by LoadConfig. Every
+// field is optional; unset fields keep the value from the preset. Durations
+// are strings like "500ms" and lists in environment variables are comma
+// separated.
+type fileConfig struct {
+	Preset string `json:"preset" yaml:"preset"`
+
+	Name                *string `json:"name" yaml:"name"`
+	BindAddr            *string `json:"bind_addr" yaml:"bind_addr"`
+	BindPort            *int    `json:"bind_port" yaml:"bind_port"`
+	BindInterface       *string `json:"bind_interface" yaml:"bind_interface"`
+	StreamBindPort      *int    `json:"stream_bind_port" yaml:"stream_bind_port"`
+	AdvertiseAddr       *string `json:"advertise_addr" yaml:"advertise_addr"`
+	AdvertisePort       *int    `json:"advertise_port" yaml:"advertise_port"`
+	AdvertiseStreamPort *int    `json:"advertise_stream_port" yaml:"advertise_stream_port"`
+	AdvertiseInterface  *string `json:"advertise_interface" yaml:"advertise_interface"`
+	Label               *string `json:"label" yaml:"label"`
+	Zone                *string `json:"zone" yaml:"zone"`
+	NodeID              *string `json:"node_id" yaml:"node_id"`
+	StateDir            *string `json:"state_dir" yaml:"state_dir"`
+	MulticastGroup      *string `json:"multicast_group" yaml:"multicast_group"`
+
+	ProtocolVersion         *int     `json:"protocol_version" yaml:"protocol_version"`
+	TCPTimeout              *string  `json:"tcp_timeout" yaml:"tcp_timeout"`
+	DialRaceDelay           *string  `json:"dial_race_delay" yaml:"dial_race_delay"`
+	ShutdownTimeout         *string  `json:"shutdown_timeout" yaml:"shutdown_timeout"`
+	JoinParallelism         *int     `json:"join_parallelism" yaml:"join_parallelism"`
+	JoinTimeout             *string  `json:"join_timeout" yaml:"join_timeout"`
+	JoinMinSuccess          *int     `json:"join_min_success" yaml:"join_min_success"`
+	DigestRepairThreshold   *int     `json:"digest_repair_threshold" yaml:"digest_repair_threshold"`
+	MTUProbeInterval        *string  `json:"mtu_probe_interval" yaml:"mtu_probe_interval"`
+	VerifyPacketSource      *bool    `json:"verify_packet_source" yaml:"verify_packet_source"`
+	DeliverOwnBroadcasts    *bool    `json:"deliver_own_broadcasts" yaml:"deliver_own_broadcasts"`
+	EnableChaos             *bool    `json:"enable_chaos" yaml:"enable_chaos"`
+	IndirectChecks          *int     `json:"indirect_checks" yaml:"indirect_checks"`
+	RetransmitMult          *int     `json:"retransmit_mult" yaml:"retransmit_mult"`
+	UserRetransmitMult      *int     `json:"user_retransmit_mult" yaml:"user_retransmit_mult"`
+	SuspicionMult           *int     `json:"suspicion_mult" yaml:"suspicion_mult"`
+	SuspicionMaxTimeoutMult *int     `json:"suspicion_max_timeout_mult" yaml:"suspicion_max_timeout_mult"`
+	PushPullInterval        *string  `json:"push_pull_interval" yaml:"push_pull_interval"`
+	ProbeInterval           *string  `json:"probe_interval" yaml:"probe_interval"`
+	ProbeTimeout            *string  `json:"probe_timeout" yaml:"probe_timeout"`
+	AutoTuneInterval        *string  `json:"auto_tune_interval" yaml:"auto_tune_interval"`
+	AutoTuneMaxMult         *int     `json:"auto_tune_max_mult" yaml:"auto_tune_max_mult"`
+	DisableTcpPings         *bool    `json:"disable_tcp_pings" yaml:"disable_tcp_pings"`
+	AwarenessMaxMultiplier  *int     `json:"awareness_max_multiplier" yaml:"awareness_max_multiplier"`
+	GossipInterval          *string  `json:"gossip_interval" yaml:"gossip_interval"`
+	GossipNodes             *int     `json:"gossip_nodes" yaml:"gossip_nodes"`
+	GossipToTheDeadTime     *string  `json:"gossip_to_the_dead_time" yaml:"gossip_to_the_dead_time"`
+	GossipVerifyIncoming    *bool    `json:"gossip_verify_incoming" yaml:"gossip_verify_incoming"`
+	GossipVerifyOutgoing    *bool    `json:"gossip_verify_outgoing" yaml:"gossip_verify_outgoing"`
+	EnableCompression       *bool    `json:"enable_compression" yaml:"enable_compression"`
+	FIPSMode                *bool    `json:"fips_mode" yaml:"fips_mode"`
+	CrossZoneFraction       *float64 `json:"cross_zone_fraction" yaml:"cross_zone_fraction"`
+	Weight                  *int     `json:"weight" yaml:"weight"`
+	WeightedSelection       *bool    `json:"weighted_selection" yaml:"weighted_selection"`
+	DeadNodeReclaimTime     *string  `json:"dead_node_reclaim_time" yaml:"dead_node_reclaim_time"`
+	HandoffQueueDepth       *int     `json:"handoff_queue_depth" yaml:"handoff_queue_depth"`
+	PacketWorkers           *int     `json:"packet_workers" yaml:"packet_workers"`
+	StreamWorkers           *int     `json:"stream_workers" yaml:"stream_workers"`
+	UDPBufferSize           *int     `json:"udp_buffer_size" yaml:"udp_buffer_size"`
+	MaxUserMessageSize      *int     `json:"max_user_message_size" yaml:"max_user_message_size"`
+	RequiredMembers         *int     `json:"required_members" yaml:"required_members"`
+	PartitionThreshold      *float64 `json:"partition_threshold" yaml:"partition_threshold"`
+	PartitionWindow         *string  `json:"partition_window" yaml:"partition_window"`
+	ReconnectInterval       *string  `json:"reconnect_interval" yaml:"reconnect_interval"`
+	ReconnectTimeout        *string  `json:"reconnect_timeout" yaml:"reconnect_timeout"`
+	ReconnectSeeds          []string `json:"reconnect_seeds" yaml:"reconnect_seeds"`
+	STUNServers             []string `json:"stun_servers" yaml:"stun_servers"`
+	HolePunching            *bool    `json:"hole_punching" yaml:"hole_punching"`
+	STUNInterval            *string  `json:"stun_interval" yaml:"stun_interval"`
+	FlapHalfLife            *string  `json:"flap_half_life" yaml:"flap_half_life"`
+	FlapThreshold           *float64 `json:"flap_threshold" yaml:"flap_threshold"`
+	FlapMaxMultiplier       *int     `json:"flap_max_multiplier" yaml:"flap_max_multiplier"`
+	HealthyMaxFlaps         *int     `json:"healthy_max_flaps" yaml:"healthy_max_flaps"`
+	SendFailureThreshold    *int     `json:"send_failure_threshold" yaml:"send_failure_threshold"`
+	SendFailureCooldown     *string  `json:"send_failure_cooldown" yaml:"send_failure_cooldown"`
+	DebugRingSize           *int     `json:"debug_ring_size" yaml:"debug_ring_size"`
+	EventJournalPath        *string  `json:"event_journal_path" yaml:"event_journal_path"`
+	EventJournalMaxSize     *int64   `json:"event_journal_max_size" yaml:"event_journal_max_size"`
+	UnknownNodeHoldTime     *string  `json:"unknown_node_hold_time" yaml:"unknown_node_hold_time"`
+	MaxMaintenance          *string  `json:"max_maintenance" yaml:"max_maintenance"`
+	TombstoneTimeout        *string  `json:"tombstone_timeout" yaml:"tombstone_timeout"`
+	CIDRsAllowed            []string `json:"cidrs_allowed" yaml:"cidrs_allowed"`
+
+	// KeysFile is a JSON file holding a list of base64 encoded keys. The
+	// first key is the primary key.
+	KeysFile *string `json:"keys_file" yaml:"keys_file"`
+}
+
+// LoadConfig reads a configuration from a JSON or YAML file, picked by the
+// extension of path, and then applies any overrides from the environment
+// (see ConfigEnvPrefix). The "preset" setting picks the starting point: "lan"
+// (the default), "wan" or "local". If path is empty, only the environment is
+// used.
+func LoadConfig(path string) (*Config, error) {
+	var fc fileConfig
+	if path != "" {
+		buf, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		switch ext := strings.ToLower(filepath.Ext(path)); ext {
+		case ".json":
+			dec := json.NewDecoder(bytes.NewReader(buf))
+			dec.DisallowUnknownFields()
+			err = dec.Decode(&fc)
+		case ".yaml", ".yml":
+			dec := yaml.NewDecoder(bytes.NewReader(buf))
+			dec.KnownFields(true)
+			err = dec.Decode(&fc)
+		default:
+			return nil, fmt.Errorf("unsupported config file extension %q", ext)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+		}
+	}
+	if err := fc.applyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	return fc.config()
+}
+
+// applyEnv overrides fields with the environment variables named after
+// their JSON keys.
+func (fc *fileConfig) applyEnv(lookup func(string) (string, bool)) error {
+	v := reflect.ValueOf(fc).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key := t.Field(i).Tag.Get("json")
+		name := ConfigEnvPrefix + strings.ToUpper(key)
+		s, ok := lookup(name)
+		if !ok {
+			continue
+		}
+		if err := setFromString(v.Field(i), s); err != nil {
+			return fmt.Errorf("invalid %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// setFromString parses s into the field f.
+func setFromString(f reflect.Value, s string) error {
+	if f.Kind() == reflect.Slice {
+		var list []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		f.Set(reflect.ValueOf(list))
+		return nil
+	}
+
+	target := f
+	if f.Kind() == reflect.Ptr {
+		target = reflect.New(f.Type().Elem()).Elem()
+	}
+	switch target.Kind() {
+	case reflect.String:
+		target.SetString(s)
+	case reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		target.SetInt(int64(n))
+	case reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		target.SetInt(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		target.SetBool(b)
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		target.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %s", target.Type())
+	}
+	if f.Kind() == reflect.Ptr {
+		f.Set(target.Addr())
+	}
+	return nil
+}
+
+// config builds a Config from the preset and the fields that are set.
+func (fc *fileConfig) config() (*Config, error) {
+	var conf *Config
+	switch fc.Preset {
+	case "", "lan":
+		conf = DefaultLANConfig()
+	case "wan":
+		conf = DefaultWANConfig()
+	case "local":
+		conf = DefaultLocalConfig()
+	default:
+		return nil, fmt.Errorf("unknown preset %q", fc.Preset)
+	}
+
+	setString := func(dst *string, src *string) {
+		if src != nil {
+			*dst = *src
+		}
+	}
+	setInt := func(dst *int, src *int) {
+		if src != nil {
+			*dst = *src
+		}
+	}
+	setBool := func(dst *bool, src *bool) {
+		if src != nil {
+			*dst = *src
+		}
+	}
+	var err error
+	setDuration := func(name string, dst *time.Duration, src *string) {
+		if src == nil || err != nil {
+			return
+		}
+		var d time.Duration
+		if d, err = time.ParseDuration(*src); err != nil {
+			err = fmt.Errorf("invalid %s: %v", name, err)
+			return
+		}
+		*dst = d
+	}
+
+	setString(&conf.Name, fc.Name)
+	setString(&conf.BindAddr, fc.BindAddr)
+	setInt(&conf.BindPort, fc.BindPort)
+	setString(&conf.BindInterface, fc.BindInterface)
+	setInt(&conf.StreamBindPort, fc.StreamBindPort)
+	setString(&conf.AdvertiseAddr, fc.AdvertiseAddr)
+	setInt(&conf.AdvertisePort, fc.AdvertisePort)
+	setInt(&conf.AdvertiseStreamPort, fc.AdvertiseStreamPort)
+	setString(&conf.AdvertiseInterface, fc.AdvertiseInterface)
+	setString(&conf.Label, fc.Label)
+	setString(&conf.Zone, fc.Zone)
+	setString(&conf.NodeID, fc.NodeID)
+	setString(&conf.StateDir, fc.StateDir)
+	setString(&conf.MulticastGroup, fc.MulticastGroup)
+
+	if fc.ProtocolVersion != nil {
+		conf.ProtocolVersion = uint8(*fc.ProtocolVersion)
+	}
+	setDuration("tcp_timeout", &conf.TCPTimeout, fc.TCPTimeout)
+	setDuration("dial_race_delay", &conf.DialRaceDelay, fc.DialRaceDelay)
+	setDuration("shutdown_timeout", &conf.ShutdownTimeout, fc.ShutdownTimeout)
+	setInt(&conf.JoinParallelism, fc.JoinParallelism)
+	setDuration("join_timeout", &conf.JoinTimeout, fc.JoinTimeout)
+	setInt(&conf.JoinMinSuccess, fc.JoinMinSuccess)
+	setInt(&conf.DigestRepairThreshold, fc.DigestRepairThreshold)
+	setInt(&conf.IndirectChecks, fc.IndirectChecks)
+	setInt(&conf.RetransmitMult, fc.RetransmitMult)
+	setInt(&conf.UserRetransmitMult, fc.UserRetransmitMult)
+	setInt(&conf.SuspicionMult, fc.SuspicionMult)
+	setInt(&conf.SuspicionMaxTimeoutMult, fc.SuspicionMaxTimeoutMult)
+	setDuration("push_pull_interval", &conf.PushPullInterval, fc.PushPullInterval)
+	setDuration("probe_interval", &conf.ProbeInterval, fc.ProbeInterval)
+	setDuration("probe_timeout", &conf.ProbeTimeout, fc.ProbeTimeout)
+	setDuration("auto_tune_interval", &conf.AutoTuneInterval, fc.AutoTuneInterval)
+	setInt(&conf.AutoTuneMaxMult, fc.AutoTuneMaxMult)
+	setBool(&conf.DisableTcpPings, fc.DisableTcpPings)
+	setInt(&conf.AwarenessMaxMultiplier, fc.AwarenessMaxMultiplier)
+	setDuration("gossip_interval", &conf.GossipInterval, fc.GossipInterval)
+	setInt(&conf.GossipNodes, fc.GossipNodes)
+	setDuration("gossip_to_the_dead_time", &conf.GossipToTheDeadTime, fc.GossipToTheDeadTime)
+	setBool(&conf.GossipVerifyIncoming, fc.GossipVerifyIncoming)
+	setBool(&conf.GossipVerifyOutgoing, fc.GossipVerifyOutgoing)
+	setBool(&conf.EnableCompression, fc.EnableCompression)
+	setBool(&conf.FIPSMode, fc.FIPSMode)
+	setBool(&conf.VerifyPacketSource, fc.VerifyPacketSource)
+	setBool(&conf.DeliverOwnBroadcasts, fc.DeliverOwnBroadcasts)
+	setBool(&conf.EnableChaos, fc.EnableChaos)
+	if fc.CrossZoneFraction != nil {
+		conf.CrossZoneFraction = *fc.CrossZoneFraction
+	}
+	if fc.Weight != nil {
+		if *fc.Weight < 0 || *fc.Weight > math.MaxUint16 {
+			return nil, fmt.Errorf("invalid weight: %d is out of range", *fc.Weight)
+		}
+		conf.Weight = uint16(*fc.Weight)
+	}
+	setBool(&conf.WeightedSelection, fc.WeightedSelection)
+	setDuration("dead_node_reclaim_time", &conf.DeadNodeReclaimTime, fc.DeadNodeReclaimTime)
+	setInt(&conf.HandoffQueueDepth, fc.HandoffQueueDepth)
+	setInt(&conf.PacketWorkers, fc.PacketWorkers)
+	setInt(&conf.StreamWorkers, fc.StreamWorkers)
+	setInt(&conf.UDPBufferSize, fc.UDPBufferSize)
+	setInt(&conf.MaxUserMessageSize, fc.MaxUserMessageSize)
+	setInt(&conf.RequiredMembers, fc.RequiredMembers)
+	if fc.PartitionThreshold != nil {
+		conf.PartitionThreshold = *fc.PartitionThreshold
+	}
+	setDuration("partition_window", &conf.PartitionWindow, fc.PartitionWindow)
+	setDuration("reconnect_interval", &conf.ReconnectInterval, fc.ReconnectInterval)
+	setDuration("mtu_probe_interval", &conf.MTUProbeInterval, fc.MTUProbeInterval)
+	setDuration("reconnect_timeout", &conf.ReconnectTimeout, fc.ReconnectTimeout)
+	if fc.ReconnectSeeds != nil {
+		conf.ReconnectSeeds = fc.ReconnectSeeds
+	}
+	if fc.STUNServers != nil {
+		conf.STUNServers = fc.STUNServers
+	}
+	setDuration("stun_interval", &conf.STUNInterval, fc.STUNInterval)
+	setBool(&conf.HolePunching, fc.HolePunching)
+	setDuration("flap_half_life", &conf.FlapHalfLife, fc.FlapHalfLife)
+	if fc.FlapThreshold != nil {
+		conf.FlapThreshold = *fc.FlapThreshold
+	}
+	setInt(&conf.FlapMaxMultiplier, fc.FlapMaxMultiplier)
+	setInt(&conf.HealthyMaxFlaps, fc.HealthyMaxFlaps)
+	setInt(&conf.SendFailureThreshold, fc.SendFailureThreshold)
+	setDuration("send_failure_cooldown", &conf.SendFailureCooldown, fc.SendFailureCooldown)
+	setInt(&conf.DebugRingSize, fc.DebugRingSize)
+	setString(&conf.EventJournalPath, fc.EventJournalPath)
+	if fc.EventJournalMaxSize != nil {
+		conf.EventJournalMaxSize = *fc.EventJournalMaxSize
+	}
+	setDuration("unknown_node_hold_time", &conf.UnknownNodeHoldTime, fc.UnknownNodeHoldTime)
+	setDuration("max_maintenance", &conf.MaxMaintenance, fc.MaxMaintenance)
+	setDuration("tombstone_timeout", &conf.TombstoneTimeout, fc.TombstoneTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	if fc.CIDRsAllowed != nil {
+		nets, err := ParseCIDRs(fc.CIDRsAllowed)
+		if err != nil {
+			return nil, err
+		}
+		conf.CIDRsAllowed = nets
+	}
+
+	if fc.KeysFile != nil {
+		keyring, err := loadKeyring(*fc.KeysFile)
+		if err != nil {
+			return nil, err
+		}
+		conf.Keyring = keyring
+	}
+	return conf, nil
+}
+
+// loadKeyring reads a JSON list of base64 encoded keys into a keyring, with
+// the first key as the primary key.
+func loadKeyring(path string) (*Keyring, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var encoded []string
+	if err := json.Unmarshal(buf, &encoded); err != nil {
+		return nil, fmt.Errorf("failed to parse keys file %s: %v", path, err)
+	}
+	if len(encoded) == 0 {
+		return nil, fmt.Errorf("keys file %s has no keys", path)
+	}
+
+	keys := make([][]byte, 0, len(encoded))
+	for _, e := range encoded {
+		key, err := base64.StdEncoding.DecodeString(e)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode key in %s: %v", path, err)
+		}
+		keys = append(keys, key)
+	}
+	defer func() {
+		for _, key := range keys {
+			clear(key)
+		}
+	}()
+	return NewKeyring(keys, keys[0])
+}
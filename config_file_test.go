@@ -0,0 +1,90 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	key := []byte("0123456789abcdef")
+	keysFile := filepath.Join(dir, "keys.json")
+	require.NoError(t, os.WriteFile(keysFile,
+		[]byte(`["`+base64.StdEncoding.EncodeToString(key)+`"]`), 0600))
+
+	yamlFile := filepath.Join(dir, "memberlist.yaml")
+	require.NoError(t, os.WriteFile(yamlFile, []byte(`
+preset: wan
+name: node-a
+bind_addr: 10.0.0.1
+bind_port: 8301
+probe_interval: 2s
+gossip_verify_outgoing: false
+cidrs_allowed: ["10.0.0.0/8"]
+keys_file: `+keysFile+`
+`), 0600))
+
+	t.Setenv("MEMBERLIST_BIND_PORT", "9301")
+	t.Setenv("MEMBERLIST_GOSSIP_INTERVAL", "250ms")
+	t.Setenv("MEMBERLIST_EVENT_JOURNAL_MAX_SIZE", "1048576")
+
+	conf, err := LoadConfig(yamlFile)
+	require.NoError(t, err)
+	require.Equal(t, "node-a", conf.Name)
+	require.Equal(t, "10.0.0.1", conf.BindAddr)
+	require.Equal(t, 9301, conf.BindPort)
+	require.Equal(t, 2*time.Second, conf.ProbeInterval)
+	require.Equal(t, 250*time.Millisecond, conf.GossipInterval)
+	require.False(t, conf.GossipVerifyOutgoing)
+	require.Equal(t, int64(1<<20), conf.EventJournalMaxSize)
+	require.Len(t, conf.CIDRsAllowed, 1)
+	require.Equal(t, key, conf.Keyring.GetPrimaryKey())
+
+	// Everything else comes from the preset.
+	require.Equal(t, DefaultWANConfig().ProbeTimeout, conf.ProbeTimeout)
+
+	jsonFile := filepath.Join(dir, "memberlist.json")
+	require.NoError(t, os.WriteFile(jsonFile, []byte(`{"preset": "local", "retransmit_mult": 5}`), 0600))
+	conf, err = LoadConfig(jsonFile)
+	require.NoError(t, err)
+	require.Equal(t, 5, conf.RetransmitMult)
+	require.Equal(t, 9301, conf.BindPort)
+	require.Equal(t, DefaultLocalConfig().ProbeTimeout, conf.ProbeTimeout)
+}
+
+func TestLoadConfig_Errors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(body), 0600))
+		return path
+	}
+
+	cases := map[string]string{
+		"unknown field":  write("unknown.json", `{"nope": 1}`),
+		"bad duration":   write("duration.yaml", "probe_interval: soon\n"),
+		"unknown preset": write("preset.yaml", "preset: moon\n"),
+		"bad extension":  write("config.toml", ""),
+		"missing":        filepath.Join(dir, "missing.json"),
+	}
+	for name, path := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadConfig(path)
+			require.Error(t, err)
+		})
+	}
+
+	t.Run("bad env", func(t *testing.T) {
+		t.Setenv("MEMBERLIST_BIND_PORT", "eighty")
+		_, err := LoadConfig("")
+		require.ErrorContains(t, err, "MEMBERLIST_BIND_PORT")
+	})
+}
@@ -0,0 +1,213 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+// Package conformance holds golden wire encodings of memberlist's messages,
+// and checks a wire implementation against them.
+//
+// Each Vector describes one message: its type, how it's encoded, the lowest
+// protocol version that sends it, its fields, and its exact bytes on the
+// wire. An implementation under test provides a Codec, and Verify checks that
+// it encodes every vector's fields to exactly the golden bytes, and decodes
+// the golden bytes back to the same fields.
+//
+// Fields are named the way they're named on the wire: the msgpack map keys,
+// which are the field names of memberlist's Go structs, and the same names
+// for the protocol buffer forms as in proto/memberlist.proto. Framing
+// messages that aren't encoded by a codec, such as compound and label
+// messages, use the field names documented on their vectors.
+package conformance
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Codecs a Vector can be encoded with.
+const (
+	// Msgpack messages are the type byte followed by a msgpack map of the
+	// message's fields, with the keys in sorted order.
+	Msgpack = "msgpack"
+
+	// Protobuf messages are the proto type byte, the message type byte and
+	// the protocol buffer encoding of the message.
+	Protobuf = "protobuf"
+
+	// Raw messages are framing with a fixed binary layout, described on
+	// each vector.
+	Raw = "raw"
+)
+
+// Field is a named value in a message. Values are one of uint64 (unsigned
+// integers), int64 (signed integers and durations in nanoseconds), bool,
+// string, []byte, []string, []uint64, [][]byte, []Field for a nested
+// message, or [][]Field for a list of nested messages.
+type Field struct {
+	Name  string
+	Value interface{}
+}
+
+// Vector is the golden encoding of a single message.
+type Vector struct {
+	// Name identifies the vector, as "<message>/<codec>".
+	Name string
+
+	// Type is the message type byte.
+	Type uint8
+
+	// Codec is one of Msgpack, Protobuf or Raw.
+	Codec string
+
+	// MinVersion is the lowest protocol version a member must understand
+	// for memberlist to send it this message in this form. Message types
+	// that aren't tied to a protocol version have a MinVersion of 1, and
+	// are dropped by implementations that don't know them.
+	MinVersion uint8
+
+	// Fields are the contents of the message.
+	Fields []Field
+
+	// Wire is the encoded message, starting with its type byte.
+	Wire []byte
+
+	// DecodeOnly is set for messages that can't be encoded the same way
+	// twice, such as encrypted messages with a random nonce. Only decoding
+	// is checked for them.
+	DecodeOnly bool
+}
+
+// Codec is implemented by the wire implementation under test.
+type Codec interface {
+	// Encode encodes the vector's fields as a message of the vector's type
+	// and codec, including the leading type byte.
+	Encode(v Vector) ([]byte, error)
+
+	// Decode decodes the vector's wire bytes, and returns the message's
+	// fields. Fields that are left out of the encoding because they're
+	// empty may be left out of the result as well.
+	Decode(v Vector) ([]Field, error)
+}
+
+// Vectors returns all the golden vectors.
+func Vectors() []Vector {
+	out := make([]Vector, len(vectors))
+	copy(out, vectors)
+	return out
+}
+
+// VectorsFor returns the vectors a member speaking the given protocol
+// version can be sent.
+func VectorsFor(version uint8) []Vector {
+	var out []Vector
+	for _, v := range vectors {
+		if v.MinVersion <= version {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Verify checks a codec against every vector for the given protocol
+// version. It returns an error describing every mismatch, or nil if there
+// are none.
+func Verify(c Codec, version uint8) error {
+	var errs []error
+	for _, v := range VectorsFor(version) {
+		if err := VerifyVector(c, v); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// VerifyVector checks a codec against a single vector.
+func VerifyVector(c Codec, v Vector) error {
+	if !v.DecodeOnly {
+		buf, err := c.Encode(v)
+		if err != nil {
+			return fmt.Errorf("%s: encode failed: %v", v.Name, err)
+		}
+		if !bytes.Equal(buf, v.Wire) {
+			return fmt.Errorf("%s: encoded as %x, expected %x", v.Name, buf, v.Wire)
+		}
+	}
+
+	fields, err := c.Decode(v)
+	if err != nil {
+		return fmt.Errorf("%s: decode failed: %v", v.Name, err)
+	}
+	if err := compareFields(v.Fields, fields); err != nil {
+		return fmt.Errorf("%s: %v", v.Name, err)
+	}
+	return nil
+}
+
+// compareFields checks that got has the expected fields. Fields missing
+// from got must have an empty expected value.
+func compareFields(want, got []Field) error {
+	byName := make(map[string]interface{}, len(got))
+	for _, f := range got {
+		byName[f.Name] = f.Value
+	}
+	for _, f := range want {
+		g, ok := byName[f.Name]
+		if !ok {
+			if !isEmpty(f.Value) {
+				return fmt.Errorf("field %s is missing", f.Name)
+			}
+			continue
+		}
+		if err := compareValue(f.Name, f.Value, g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compareValue compares two field values, descending into nested messages.
+func compareValue(name string, want, got interface{}) error {
+	switch w := want.(type) {
+	case []Field:
+		g, ok := got.([]Field)
+		if !ok {
+			return fmt.Errorf("field %s is %T, expected a nested message", name, got)
+		}
+		if err := compareFields(w, g); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		return nil
+	case [][]Field:
+		g, ok := got.([][]Field)
+		if !ok || len(g) != len(w) {
+			return fmt.Errorf("field %s has %v, expected %d nested messages", name, got, len(w))
+		}
+		for i := range w {
+			if err := compareFields(w[i], g[i]); err != nil {
+				return fmt.Errorf("%s[%d]: %v", name, i, err)
+			}
+		}
+		return nil
+	}
+	if isEmpty(want) && isEmpty(got) {
+		return nil
+	}
+	if !reflect.DeepEqual(want, got) {
+		return fmt.Errorf("field %s is %#v, expected %#v", name, got, want)
+	}
+	return nil
+}
+
+// isEmpty returns true for the zero value and empty slices.
+func isEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice:
+		return rv.Len() == 0
+	default:
+		return rv.IsZero()
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package conformance
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hashicorp/go-msgpack/v2/codec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVectors_WellFormed(t *testing.T) {
+	names := make(map[string]struct{})
+	for _, v := range Vectors() {
+		_, dup := names[v.Name]
+		require.False(t, dup, "duplicate vector %s", v.Name)
+		names[v.Name] = struct{}{}
+
+		require.NotEmpty(t, v.Wire, v.Name)
+		require.NotEmpty(t, v.Fields, v.Name)
+		switch v.Codec {
+		case Protobuf:
+			require.Equal(t, []byte{17, v.Type}, v.Wire[:2], v.Name)
+		case Msgpack, Raw:
+			require.Equal(t, v.Type, v.Wire[0], v.Name)
+		default:
+			t.Fatalf("%s: unknown codec %q", v.Name, v.Codec)
+		}
+	}
+}
+
+func TestVectors_MsgpackKeys(t *testing.T) {
+	// Every msgpack vector is a map keyed by the vector's fields, holding at
+	// least the non-empty ones.
+	for _, v := range Vectors() {
+		if v.Codec != Msgpack {
+			continue
+		}
+		var out map[string]interface{}
+		dec := codec.NewDecoder(bytes.NewReader(v.Wire[1:]), &codec.MsgpackHandle{})
+		require.NoError(t, dec.Decode(&out), v.Name)
+
+		known := make(map[string]struct{})
+		for _, f := range v.Fields {
+			known[f.Name] = struct{}{}
+			if !isEmpty(f.Value) {
+				require.Contains(t, out, f.Name, v.Name)
+			}
+		}
+		for k := range out {
+			require.Contains(t, known, k, v.Name)
+		}
+	}
+}
+
+func TestVectorsFor(t *testing.T) {
+	for _, v := range VectorsFor(6) {
+		require.LessOrEqual(t, v.MinVersion, uint8(6))
+		require.NotEqual(t, Protobuf, v.Codec)
+	}
+	require.Len(t, VectorsFor(255), len(Vectors()))
+}
+
+// echoCodec returns the golden values as they are, to check Verify itself.
+type echoCodec struct {
+	wire func(v Vector) []byte
+}
+
+func (c echoCodec) Encode(v Vector) ([]byte, error) {
+	return c.wire(v), nil
+}
+
+func (c echoCodec) Decode(v Vector) ([]Field, error) {
+	return v.Fields, nil
+}
+
+func TestVerify(t *testing.T) {
+	require.NoError(t, Verify(echoCodec{wire: func(v Vector) []byte { return v.Wire }}, 255))
+
+	// Mismatches are all reported
+	err := Verify(echoCodec{wire: func(v Vector) []byte { return nil }}, 1)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "ping/msgpack: encoded as")
+	require.NotContains(t, err.Error(), "encrypt/raw")
+}
+
+func TestCompareFields(t *testing.T) {
+	want := []Field{{"A", uint64(1)}, {"B", ""}, {"C", []Field{{"D", "x"}}}}
+
+	// Empty fields can be left out
+	require.NoError(t, compareFields(want, []Field{{"A", uint64(1)}, {"C", []Field{{"D", "x"}}}}))
+
+	require.Error(t, compareFields(want, []Field{{"A", uint64(2)}, {"C", []Field{{"D", "x"}}}}))
+	require.Error(t, compareFields(want, []Field{{"A", uint64(1)}, {"C", []Field{{"D", "y"}}}}))
+	require.Error(t, compareFields(want, []Field{{"C", []Field{{"D", "x"}}}}))
+}
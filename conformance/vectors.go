@@ -0,0 +1,321 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package conformance
+
+import (
+	"encoding/hex"
+)
+
+// Message type bytes, as in memberlist's net.go.
+const (
+	typePing           = 0
+	typeIndirectPing   = 1
+	typeAck            = 2
+	typeSuspect        = 3
+	typeAlive          = 4
+	typeDead           = 5
+	typePushPull       = 6
+	typeCompound       = 7
+	typeUser           = 8
+	typeCompress       = 9
+	typeEncrypt        = 10
+	typeNack           = 11
+	typeCRC            = 12
+	typeError          = 13
+	typeRelay          = 14
+	typePushPullDigest = 15
+	typeStateSummary   = 16
+	typeTimedUser      = 18
+	typeAckedUser      = 19
+	typeBroadcastAck   = 20
+	typeLabel          = 244
+)
+
+// Node states, as in memberlist's state.go.
+const (
+	stateAlive   = 0
+	stateSuspect = 1
+)
+
+// Fields shared by several vectors.
+var (
+	pingFields = []Field{
+		{"SeqNo", uint64(42)},
+		{"Node", "b"},
+		{"SourceAddr", []byte{127, 0, 0, 1}},
+		{"SourcePort", uint64(7946)},
+		{"SourceNode", "a"},
+	}
+	indirectPingFields = []Field{
+		{"SeqNo", uint64(7)},
+		{"Target", []byte{10, 0, 0, 1}},
+		{"Port", uint64(7946)},
+		{"Node", "c"},
+		{"Nack", true},
+		{"SourceAddr", []byte{127, 0, 0, 1}},
+		{"SourcePort", uint64(7947)},
+		{"SourceNode", "a"},
+	}
+	ackFields = []Field{
+		{"SeqNo", uint64(9)},
+		{"Payload", []byte("payload")},
+		{"Health", int64(3)},
+	}
+	nackFields = []Field{
+		{"SeqNo", uint64(11)},
+	}
+	suspectFields = []Field{
+		{"Incarnation", uint64(2)},
+		{"Node", "b"},
+		{"From", "a"},
+	}
+	aliveFields = []Field{
+		{"Incarnation", uint64(3)},
+		{"Node", "b"},
+		{"Addr", []byte{127, 0, 0, 1}},
+		{"Port", uint64(7946)},
+		{"Meta", []byte("meta")},
+		{"Vsn", []byte{1, 8, 2, 0, 0, 0}},
+		{"Addrs", []string{"10.0.0.1:7946", "10.0.0.2:7946"}},
+		{"Role", uint64(1)},
+		{"Zone", "us-east-1a"},
+		{"ID", "4f9a3c2e-1d2b-4c5a-9e8f-7a6b5c4d3e2f"},
+		{"StreamPort", uint64(7947)},
+		{"Maintenance", int64(60000000000)},
+	}
+	deadFields = []Field{
+		{"Incarnation", uint64(4)},
+		{"Node", "b"},
+		{"From", "a"},
+	}
+)
+
+// vectors are the golden encodings. The wire bytes must never change for an
+// existing vector; a new encoding gets a new vector.
+var vectors = []Vector{
+	{
+		Name: "ping/msgpack", Type: typePing, Codec: Msgpack, MinVersion: 1,
+		Fields: pingFields,
+		Wire:   h("0085a44e6f6465a162a55365714e6f2aaa536f7572636541646472a47f000001aa536f757263654e6f6465a161aa536f75726365506f7274cd1f0a"),
+	},
+	{
+		Name: "ping/protobuf", Type: typePing, Codec: Protobuf, MinVersion: 7,
+		Fields: pingFields,
+		Wire:   h("1100082a1201621a047f000001208a3e2a0161"),
+	},
+	{
+		Name: "indirect-ping/msgpack", Type: typeIndirectPing, Codec: Msgpack, MinVersion: 4,
+		Fields: indirectPingFields,
+		Wire:   h("0188a44e61636bc3a44e6f6465a163a4506f7274cd1f0aa55365714e6f07aa536f7572636541646472a47f000001aa536f757263654e6f6465a161aa536f75726365506f7274cd1f0ba6546172676574a40a000001"),
+	},
+	{
+		Name: "indirect-ping/protobuf", Type: typeIndirectPing, Codec: Protobuf, MinVersion: 7,
+		Fields: indirectPingFields,
+		Wire:   h("1101080712040a000001188a3e220163280132047f000001388b3e420161"),
+	},
+	{
+		Name: "ack/msgpack", Type: typeAck, Codec: Msgpack, MinVersion: 1,
+		Fields: ackFields,
+		Wire:   h("0283a64865616c746803a75061796c6f6164a77061796c6f6164a55365714e6f09"),
+	},
+	{
+		Name: "ack/protobuf", Type: typeAck, Codec: Protobuf, MinVersion: 7,
+		Fields: ackFields,
+		Wire:   h("1102080912077061796c6f61641803"),
+	},
+	{
+		Name: "nack/msgpack", Type: typeNack, Codec: Msgpack, MinVersion: 4,
+		Fields: nackFields,
+		Wire:   h("0b81a55365714e6f0b"),
+	},
+	{
+		Name: "nack/protobuf", Type: typeNack, Codec: Protobuf, MinVersion: 7,
+		Fields: nackFields,
+		Wire:   h("110b080b"),
+	},
+	{
+		Name: "suspect/msgpack", Type: typeSuspect, Codec: Msgpack, MinVersion: 1,
+		Fields: suspectFields,
+		Wire:   h("0383a446726f6da161ab496e6361726e6174696f6e02a44e6f6465a162"),
+	},
+	{
+		Name: "suspect/protobuf", Type: typeSuspect, Codec: Protobuf, MinVersion: 7,
+		Fields: suspectFields,
+		Wire:   h("110308021201621a0161"),
+	},
+	{
+		Name: "alive/msgpack", Type: typeAlive, Codec: Msgpack, MinVersion: 1,
+		Fields: aliveFields,
+		Wire:   h("048ca441646472a47f000001a5416464727392ad31302e302e302e313a37393436ad31302e302e302e323a37393436a24944da002434663961336332652d316432622d346335612d396538662d376136623563346433653266ab496e6361726e6174696f6e03ab4d61696e74656e616e6365d30000000df8475800a44d657461a46d657461a44e6f6465a162a4506f7274cd1f0aa4526f6c6501aa53747265616d506f7274cd1f0ba356736ea6010802000000a45a6f6e65aa75732d656173742d3161"),
+	},
+	{
+		Name: "alive/protobuf", Type: typeAlive, Codec: Protobuf, MinVersion: 7,
+		Fields: aliveFields,
+		Wire:   h("110408031201621a047f000001208a3e2a046d65746132060108020000003a0d31302e302e302e313a373934363a0d31302e302e302e323a3739343640014a0a75732d656173742d3161522434663961336332652d316432622d346335612d396538662d376136623563346433653266588b3e6080b09dc2df01"),
+	},
+	{
+		Name: "dead/msgpack", Type: typeDead, Codec: Msgpack, MinVersion: 1,
+		Fields: deadFields,
+		Wire:   h("0583a446726f6da161ab496e6361726e6174696f6e04a44e6f6465a162"),
+	},
+	{
+		Name: "dead/protobuf", Type: typeDead, Codec: Protobuf, MinVersion: 7,
+		Fields: deadFields,
+		Wire:   h("110508041201621a0161"),
+	},
+	{
+		Name: "error/msgpack", Type: typeError, Codec: Msgpack, MinVersion: 1,
+		Fields: []Field{
+			{"Error", "too many pending push/pull requests"},
+		},
+		Wire: h("0d81a54572726f72da0023746f6f206d616e792070656e64696e6720707573682f70756c6c207265717565737473"),
+	},
+	{
+		Name: "relay/msgpack", Type: typeRelay, Codec: Msgpack, MinVersion: 1,
+		Fields: []Field{
+			{"Node", "b"},
+			{"Payload", []byte{typeUser, 'h', 'i'}},
+		},
+		Wire: h("0e82a44e6f6465a162a75061796c6f6164a3086869"),
+	},
+	{
+		// Buf is the LZW (LSB first, 8 bit literals) compression of the
+		// compound/raw vector's message.
+		Name: "compress/msgpack", Type: typeCompress, Codec: Msgpack, MinVersion: 1,
+		Fields: []Field{
+			{"Algo", uint64(0)},
+			{"Buf", []byte{0x00, 0x0f, 0x08, 0x00, 0x30, 0x80, 0x20, 0x02, 0x34, 0x69, 0x10, 0xe4, 0x79, 0x13, 0x10}},
+		},
+		Wire: h("0982a4416c676f00a3427566af000f080030802002346910e4791310"),
+	},
+	{
+		Name: "push-pull-digest/msgpack", Type: typePushPullDigest, Codec: Msgpack, MinVersion: 6,
+		Fields: []Field{
+			{"Digest", [][]Field{
+				{{"Name", "a"}, {"Incarnation", uint64(1)}, {"State", int64(stateAlive)}, {"Entries", uint64(0)}},
+				{{"Name", "b"}, {"Incarnation", uint64(5)}, {"State", int64(stateSuspect)}, {"Entries", uint64(0xcbf29ce484222325)}},
+			}},
+			{"Want", []string{"c"}},
+			{"UserState", []byte("state")},
+		},
+		Wire: h("0f83a64469676573749283ab496e6361726e6174696f6e01a44e616d65a161a553746174650084a7456e7472696573cfcbf29ce484222325ab496e6361726e6174696f6e05a44e616d65a162a5537461746501a9557365725374617465a57374617465a457616e7491a163"),
+	},
+	{
+		Name: "state-summary/msgpack", Type: typeStateSummary, Codec: Msgpack, MinVersion: 6,
+		Fields: []Field{
+			{"Node", "a"},
+			{"Root", uint64(0x1234567890abcdef)},
+			{"Buckets", []uint64{1, 2, 3}},
+		},
+		Wire: h("1083a74275636b65747393010203a44e6f6465a161a4526f6f74cf1234567890abcdef"),
+	},
+	{
+		Name: "acked-user/msgpack", Type: typeAckedUser, Codec: Msgpack, MinVersion: 1,
+		Fields: []Field{
+			{"ID", uint64(3)},
+			{"Origin", "a"},
+			{"Timeout", int64(5000000000)},
+			{"Payload", []byte("hello")},
+		},
+		Wire: h("1384a2494403a64f726967696ea161a75061796c6f6164a568656c6c6fa754696d656f7574d3000000012a05f200"),
+	},
+	{
+		Name: "broadcast-ack/msgpack", Type: typeBroadcastAck, Codec: Msgpack, MinVersion: 1,
+		Fields: []Field{
+			{"ID", uint64(3)},
+			{"Node", "b"},
+		},
+		Wire: h("1482a2494403a44e6f6465a162"),
+	},
+	{
+		// [type; byte] [payload]
+		Name: "user/raw", Type: typeUser, Codec: Raw, MinVersion: 1,
+		Fields: []Field{
+			{"Payload", []byte("hello")},
+		},
+		Wire: h("0868656c6c6f"),
+	},
+	{
+		// On a stream: [type; byte] [msgpack {UserMsgLen}] [payload]
+		Name: "user-stream/raw", Type: typeUser, Codec: Raw, MinVersion: 1,
+		Fields: []Field{
+			{"Payload", []byte("hello")},
+		},
+		Wire: h("0881aa557365724d73674c656e0568656c6c6f"),
+	},
+	{
+		// [type; byte] [LTime; uint64 big endian] [payload]
+		Name: "timed-user/raw", Type: typeTimedUser, Codec: Raw, MinVersion: 1,
+		Fields: []Field{
+			{"LTime", uint64(7)},
+			{"Payload", []byte("hello")},
+		},
+		Wire: h("12000000000000000768656c6c6f"),
+	},
+	{
+		// [type; byte] [count; byte] [length; uint16 big endian]... [part]...
+		Name: "compound/raw", Type: typeCompound, Codec: Raw, MinVersion: 1,
+		Fields: []Field{
+			{"Parts", [][]byte{{typeUser, 'h', 'i'}, {typeUser, 'y', 'o'}}},
+		},
+		Wire: h("07020003000308686908796f"),
+	},
+	{
+		// [type; byte] [CRC32 IEEE of payload; uint32 big endian] [payload]
+		Name: "crc/raw", Type: typeCRC, Codec: Raw, MinVersion: 5,
+		Fields: []Field{
+			{"Payload", []byte{typeUser, 'h', 'i'}},
+		},
+		Wire: h("0c6818b0f9086869"),
+	},
+	{
+		// [type; byte] [label length; byte] [label] [payload]
+		Name: "label/raw", Type: typeLabel, Codec: Raw, MinVersion: 1,
+		Fields: []Field{
+			{"Label", "cluster-a"},
+			{"Payload", []byte{typeUser, 'h', 'i'}},
+		},
+		Wire: h("f409636c75737465722d61086869"),
+	},
+	{
+		// On a stream: [type; byte] [length; uint32 big endian]
+		// [encryption version; byte] [nonce; 12 bytes] [AES-GCM ciphertext
+		// and tag]. The type and length bytes are the additional data.
+		Name: "encrypt/raw", Type: typeEncrypt, Codec: Raw, MinVersion: 1,
+		Fields: []Field{
+			{"Key", []byte("0123456789abcdef")},
+			{"Payload", []byte{typeUser, 'h', 'i'}},
+		},
+		Wire:       h("0a0000002001e833f6d48b218d016ec988117b21c016cfe78623c0cdee019b2c9bab83e090"),
+		DecodeOnly: true,
+	},
+	{
+		// On a stream: [type; byte] [msgpack header] [msgpack node
+		// state]... [user state]
+		Name: "push-pull/raw", Type: typePushPull, Codec: Raw, MinVersion: 1,
+		Fields: []Field{
+			{"Header", []Field{{"Nodes", int64(1)}, {"UserStateLen", int64(5)}, {"Join", true}}},
+			{"Nodes", [][]Field{{
+				{"Name", "a"},
+				{"Addr", []byte{127, 0, 0, 1}},
+				{"Port", uint64(7946)},
+				{"Meta", []byte("meta")},
+				{"Incarnation", uint64(1)},
+				{"State", int64(stateAlive)},
+				{"Vsn", []byte{1, 8, 2, 0, 0, 0}},
+			}}},
+			{"UserState", []byte("state")},
+		},
+		Wire: h("0683a44a6f696ec3a54e6f64657301ac5573657253746174654c656e0587a441646472a47f000001ab496e6361726e6174696f6e01a44d657461a46d657461a44e616d65a161a4506f7274cd1f0aa5537461746500a356736ea60108020000007374617465"),
+	},
+}
+
+// h decodes a hex string, for writing wire bytes compactly.
+func h(s string) []byte {
+	buf, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return buf
+}
@@ -0,0 +1,329 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-msgpack/v2/codec"
+	"github.com/hashicorp/memberlist/conformance"
+	"github.com/stretchr/testify/require"
+)
+
+// conformanceCodec checks memberlist's own wire encoding against the golden
+// vectors.
+type conformanceCodec struct{}
+
+// newWireMessage returns an empty message of a type encoded with a codec.
+func newWireMessage(t messageType) (interface{}, error) {
+	switch t {
+	case pingMsg:
+		return &ping{}, nil
+	case indirectPingMsg:
+		return &indirectPingReq{}, nil
+	case ackRespMsg:
+		return &ackResp{}, nil
+	case nackRespMsg:
+		return &nackResp{}, nil
+	case suspectMsg:
+		return &suspect{}, nil
+	case aliveMsg:
+		return &alive{}, nil
+	case deadMsg:
+		return &dead{}, nil
+	case errMsg:
+		return &errResp{}, nil
+	case relayMsg:
+		return &relay{}, nil
+	case compressMsg:
+		return &compress{}, nil
+	case pushPullDigestMsg:
+		return &pushPullDigest{}, nil
+	case stateSummaryMsg:
+		return &stateSummary{}, nil
+	case ackedUserMsg:
+		return &ackedBroadcast{}, nil
+	case broadcastAckMsg:
+		return &broadcastAck{}, nil
+	default:
+		return nil, fmt.Errorf("no message for type %v", t)
+	}
+}
+
+func (conformanceCodec) Encode(v conformance.Vector) ([]byte, error) {
+	t := messageType(v.Type)
+	switch v.Codec {
+	case conformance.Msgpack, conformance.Protobuf:
+		msg, err := newWireMessage(t)
+		if err != nil {
+			return nil, err
+		}
+		if err := fieldsToStruct(v.Fields, reflect.ValueOf(msg).Elem()); err != nil {
+			return nil, err
+		}
+		var c messageCodec = msgpackCodec{}
+		if v.Codec == conformance.Protobuf {
+			c = protobufCodec{}
+		}
+		buf, err := c.encode(t, msg)
+		if err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	f := fieldMap(v.Fields)
+	switch v.Name {
+	case "user/raw":
+		return append([]byte{byte(userMsg)}, f["Payload"].([]byte)...), nil
+	case "user-stream/raw":
+		payload := f["Payload"].([]byte)
+		buf, err := encode(userMsg, &userMsgHeader{UserMsgLen: len(payload)}, false)
+		if err != nil {
+			return nil, err
+		}
+		return append(buf.Bytes(), payload...), nil
+	case "timed-user/raw":
+		return stampUserMsg(f["Payload"].([]byte), LamportTime(f["LTime"].(uint64))), nil
+	case "compound/raw":
+		return makeCompoundMessage(f["Parts"].([][]byte)).Bytes(), nil
+	case "crc/raw":
+		payload := f["Payload"].([]byte)
+		buf := make([]byte, 5, 5+len(payload))
+		buf[0] = byte(hasCrcMsg)
+		binary.BigEndian.PutUint32(buf[1:], crc32.ChecksumIEEE(payload))
+		return append(buf, payload...), nil
+	case "label/raw":
+		return AddLabelHeaderToPacket(f["Payload"].([]byte), f["Label"].(string))
+	case "push-pull/raw":
+		var header pushPullHeader
+		if err := fieldsToStruct(f["Header"].([]conformance.Field), reflect.ValueOf(&header).Elem()); err != nil {
+			return nil, err
+		}
+		buf := bytes.NewBuffer([]byte{byte(pushPullMsg)})
+		enc := codec.NewEncoder(buf, &codec.MsgpackHandle{})
+		if err := enc.Encode(&header); err != nil {
+			return nil, err
+		}
+		for _, fields := range f["Nodes"].([][]conformance.Field) {
+			var n pushNodeState
+			if err := fieldsToStruct(fields, reflect.ValueOf(&n).Elem()); err != nil {
+				return nil, err
+			}
+			if err := enc.Encode(&n); err != nil {
+				return nil, err
+			}
+		}
+		buf.Write(f["UserState"].([]byte))
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unknown raw vector %s", v.Name)
+	}
+}
+
+func (conformanceCodec) Decode(v conformance.Vector) ([]conformance.Field, error) {
+	switch v.Codec {
+	case conformance.Msgpack:
+		msg, err := newWireMessage(messageType(v.Wire[0]))
+		if err != nil {
+			return nil, err
+		}
+		if err := decode(v.Wire[1:], msg); err != nil {
+			return nil, err
+		}
+		return structToFields(reflect.ValueOf(msg).Elem()), nil
+	case conformance.Protobuf:
+		if messageType(v.Wire[0]) != protoMsg {
+			return nil, fmt.Errorf("not a proto message")
+		}
+		msg := newProtoMessage(messageType(v.Wire[1]))
+		if msg == nil {
+			return nil, fmt.Errorf("no proto message for type %d", v.Wire[1])
+		}
+		if err := msg.unmarshalProto(v.Wire[2:]); err != nil {
+			return nil, err
+		}
+		return structToFields(reflect.ValueOf(msg).Elem()), nil
+	}
+
+	payload := func(b []byte) []conformance.Field {
+		return []conformance.Field{{Name: "Payload", Value: b}}
+	}
+	switch v.Name {
+	case "user/raw":
+		return payload(v.Wire[1:]), nil
+	case "user-stream/raw":
+		var header userMsgHeader
+		r := bytes.NewReader(v.Wire[1:])
+		if err := codec.NewDecoder(r, &codec.MsgpackHandle{}).Decode(&header); err != nil {
+			return nil, err
+		}
+		rest := v.Wire[len(v.Wire)-r.Len():]
+		if len(rest) != header.UserMsgLen {
+			return nil, fmt.Errorf("bad length %d", header.UserMsgLen)
+		}
+		return payload(rest), nil
+	case "timed-user/raw":
+		return append(payload(v.Wire[9:]), conformance.Field{Name: "LTime", Value: binary.BigEndian.Uint64(v.Wire[1:9])}), nil
+	case "compound/raw":
+		_, parts, err := decodeCompoundMessage(v.Wire[1:])
+		if err != nil {
+			return nil, err
+		}
+		return []conformance.Field{{Name: "Parts", Value: parts}}, nil
+	case "crc/raw":
+		if crc32.ChecksumIEEE(v.Wire[5:]) != binary.BigEndian.Uint32(v.Wire[1:5]) {
+			return nil, fmt.Errorf("bad checksum")
+		}
+		return payload(v.Wire[5:]), nil
+	case "label/raw":
+		buf, label, err := RemoveLabelHeaderFromPacket(v.Wire)
+		if err != nil {
+			return nil, err
+		}
+		return append(payload(buf), conformance.Field{Name: "Label", Value: label}), nil
+	case "encrypt/raw":
+		key := fieldMap(v.Fields)["Key"].([]byte)
+		plain, err := decryptPayload([][]byte{key}, v.Wire[5:], v.Wire[:5])
+		if err != nil {
+			return nil, err
+		}
+		return append(payload(plain), conformance.Field{Name: "Key", Value: key}), nil
+	case "push-pull/raw":
+		bufConn := bufio.NewReader(bytes.NewReader(v.Wire[1:]))
+		dec := codec.NewDecoder(bufConn, &codec.MsgpackHandle{})
+		var header pushPullHeader
+		if err := dec.Decode(&header); err != nil {
+			return nil, err
+		}
+		var nodes [][]conformance.Field
+		for i := 0; i < header.Nodes; i++ {
+			var n pushNodeState
+			if err := dec.Decode(&n); err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, structToFields(reflect.ValueOf(&n).Elem()))
+		}
+		userState := make([]byte, header.UserStateLen)
+		if _, err := io.ReadFull(bufConn, userState); err != nil {
+			return nil, err
+		}
+		return []conformance.Field{
+			{Name: "Header", Value: structToFields(reflect.ValueOf(&header).Elem())},
+			{Name: "Nodes", Value: nodes},
+			{Name: "UserState", Value: userState},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown raw vector %s", v.Name)
+	}
+}
+
+// fieldMap indexes fields by name.
+func fieldMap(fields []conformance.Field) map[string]interface{} {
+	out := make(map[string]interface{}, len(fields))
+	for _, f := range fields {
+		out[f.Name] = f.Value
+	}
+	return out
+}
+
+// fieldsToStruct sets the fields of a struct from conformance fields.
+func fieldsToStruct(fields []conformance.Field, out reflect.Value) error {
+	for _, f := range fields {
+		dst := out.FieldByName(f.Name)
+		if !dst.IsValid() {
+			return fmt.Errorf("%s has no field %s", out.Type(), f.Name)
+		}
+		if err := setField(dst, f.Value); err != nil {
+			return fmt.Errorf("%s: %v", f.Name, err)
+		}
+	}
+	return nil
+}
+
+func setField(dst reflect.Value, value interface{}) error {
+	switch v := value.(type) {
+	case uint64:
+		dst.SetUint(v)
+	case int64:
+		dst.SetInt(v)
+	case []uint64:
+		dst.Set(reflect.ValueOf(v))
+	case []conformance.Field:
+		return fieldsToStruct(v, dst)
+	case [][]conformance.Field:
+		dst.Set(reflect.MakeSlice(dst.Type(), len(v), len(v)))
+		for i := range v {
+			if err := fieldsToStruct(v[i], dst.Index(i)); err != nil {
+				return err
+			}
+		}
+	default:
+		dst.Set(reflect.ValueOf(value).Convert(dst.Type()))
+	}
+	return nil
+}
+
+// structToFields returns the fields of a struct as conformance fields,
+// leaving out empty fields that are tagged omitempty.
+func structToFields(v reflect.Value) []conformance.Field {
+	var fields []conformance.Field
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		if strings.Contains(sf.Tag.Get("codec"), "omitempty") && fv.IsZero() {
+			continue
+		}
+		fields = append(fields, conformance.Field{Name: sf.Name, Value: fieldValue(fv)})
+	}
+	return fields
+}
+
+func fieldValue(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.String:
+		return v.String()
+	case reflect.Struct:
+		return structToFields(v)
+	case reflect.Slice:
+		switch v.Type().Elem().Kind() {
+		case reflect.Uint8:
+			return v.Bytes()
+		case reflect.String:
+			return v.Interface().([]string)
+		case reflect.Uint64:
+			return v.Interface().([]uint64)
+		case reflect.Struct:
+			out := make([][]conformance.Field, v.Len())
+			for i := range out {
+				out[i] = structToFields(v.Index(i))
+			}
+			return out
+		}
+	}
+	return v.Interface()
+}
+
+func TestConformance(t *testing.T) {
+	for version := ProtocolVersionMin; version <= ProtocolVersionMax; version++ {
+		require.NoError(t, conformance.Verify(conformanceCodec{}, version), "version %d", version)
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// Client talks to a control socket. It's safe for concurrent use, though
+// commands are sent one at a time.
+type Client struct {
+	lock sync.Mutex
+	conn net.Conn
+	enc  *json.Encoder
+	dec  *json.Decoder
+}
+
+// Dial connects to the control socket at path.
+func Dial(path string) (*Client, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, enc: json.NewEncoder(conn), dec: json.NewDecoder(conn)}, nil
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Call sends a command with the given arguments, which may be nil, and
+// decodes its result into result, which may be nil too.
+func (c *Client) Call(command string, args, result interface{}) error {
+	req := Request{Command: command}
+	if args != nil {
+		buf, err := json.Marshal(args)
+		if err != nil {
+			return err
+		}
+		req.Args = buf
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if err := c.enc.Encode(&req); err != nil {
+		return err
+	}
+	var resp Response
+	if err := c.dec.Decode(&resp); err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+	if result != nil && len(resp.Result) > 0 {
+		return json.Unmarshal(resp.Result, result)
+	}
+	return nil
+}
+
+// Members lists the members the instance knows of.
+func (c *Client) Members() ([]Member, error) {
+	var members []Member
+	err := c.Call(CmdMembers, nil, &members)
+	return members, err
+}
+
+// Join joins the cluster through the given addresses, and returns the
+// number of nodes contacted.
+func (c *Client) Join(addrs []string) (int, error) {
+	var res JoinResult
+	err := c.Call(CmdJoin, JoinArgs{Addrs: addrs}, &res)
+	return res.Joined, err
+}
+
+// Leave makes the instance leave the cluster.
+func (c *Client) Leave(timeout time.Duration) error {
+	return c.Call(CmdLeave, LeaveArgs{Timeout: timeout}, nil)
+}
+
+// ForceLeave marks a node as dead.
+func (c *Client) ForceLeave(node string) error {
+	return c.Call(CmdForceLeave, ForceLeaveArgs{Node: node}, nil)
+}
+
+// ListKeys returns the encryption keys, primary first.
+func (c *Client) ListKeys() ([][]byte, error) {
+	var res KeysResult
+	err := c.Call(CmdListKeys, nil, &res)
+	return res.Keys, err
+}
+
+// InstallKey adds a key to the keyring.
+func (c *Client) InstallKey(key []byte) error {
+	return c.Call(CmdInstallKey, KeyArgs{Key: key}, nil)
+}
+
+// UseKey makes an installed key the primary key.
+func (c *Client) UseKey(key []byte) error {
+	return c.Call(CmdUseKey, KeyArgs{Key: key}, nil)
+}
+
+// RemoveKey removes a key from the keyring.
+func (c *Client) RemoveKey(key []byte) error {
+	return c.Call(CmdRemoveKey, KeyArgs{Key: key}, nil)
+}
+
+// Stats returns the instance's stats.
+func (c *Client) Stats() (Stats, error) {
+	var s Stats
+	err := c.Call(CmdStats, nil, &s)
+	return s, err
+}
@@ -0,0 +1,120 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+// Package control serves a local control socket for a running memberlist, so
+// tooling can manage an embedded instance without linking the application.
+//
+// The protocol is JSON over a UNIX socket. A client writes one Request object
+// at a time, and the server answers each with one Response object, in order,
+// on the same connection:
+//
+//	{"Command": "members"}
+//	{"Result": [{"Name": "node-a", "Addr": "10.0.0.1", ...}]}
+//
+//	{"Command": "join", "Args": {"Addrs": ["10.0.0.2"]}}
+//	{"Result": {"Joined": 1}}
+//
+// A failed command gets a Response with Error set and no Result. The commands
+// and their arguments are listed with the Cmd constants.
+package control
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Commands the control socket accepts.
+const (
+	// CmdMembers lists the members we know of, with no arguments. The
+	// result is a []Member.
+	CmdMembers = "members"
+
+	// CmdJoin joins the cluster through the addresses in JoinArgs. The
+	// result is a JoinResult.
+	CmdJoin = "join"
+
+	// CmdLeave broadcasts that we're leaving, waiting up to the timeout in
+	// LeaveArgs, and has no result. The memberlist isn't shut down.
+	CmdLeave = "leave"
+
+	// CmdForceLeave marks the node in ForceLeaveArgs as dead, and has no
+	// result. It's meant for nodes that are gone for good but never left.
+	CmdForceLeave = "force-leave"
+
+	// CmdListKeys lists the encryption keys, primary first, with no
+	// arguments. The result is a KeysResult.
+	CmdListKeys = "list-keys"
+
+	// CmdInstallKey, CmdUseKey and CmdRemoveKey add a key to the keyring,
+	// make a key the primary one, and remove a key, like the Keyring's
+	// AddKey, UseKey and RemoveKey. They take KeyArgs and have no result.
+	CmdInstallKey = "install-key"
+	CmdUseKey     = "use-key"
+	CmdRemoveKey  = "remove-key"
+
+	// CmdStats returns a Stats, with no arguments.
+	CmdStats = "stats"
+)
+
+// Request is a command sent to the control socket.
+type Request struct {
+	Command string
+	Args    json.RawMessage `json:",omitempty"`
+}
+
+// Response is the answer to a Request.
+type Response struct {
+	Error  string          `json:",omitempty"`
+	Result json.RawMessage `json:",omitempty"`
+}
+
+// Member is a member as listed by the Members command.
+type Member struct {
+	Name  string
+	Addr  string
+	Port  uint16
+	State string // One of "alive", "suspect", "dead" or "left"
+	Meta  []byte `json:",omitempty"`
+	Zone  string `json:",omitempty"`
+	ID    string `json:",omitempty"`
+}
+
+// JoinArgs are the arguments of the Join command.
+type JoinArgs struct {
+	Addrs []string
+}
+
+// JoinResult is the result of the Join command.
+type JoinResult struct {
+	Joined int // The number of nodes successfully contacted
+}
+
+// LeaveArgs are the arguments of the Leave command.
+type LeaveArgs struct {
+	Timeout time.Duration
+}
+
+// ForceLeaveArgs are the arguments of the ForceLeave command.
+type ForceLeaveArgs struct {
+	Node string
+}
+
+// KeyArgs are the arguments of the InstallKey, UseKey and RemoveKey
+// commands.
+type KeyArgs struct {
+	Key []byte
+}
+
+// KeysResult is the result of the ListKeys command.
+type KeysResult struct {
+	Keys [][]byte
+}
+
+// Stats is the result of the Stats command.
+type Stats struct {
+	Members       int   // Members we know of, in any state
+	Alive         int   // Members that are alive
+	Health        int   // Our health score, 0 is best
+	Protocol      uint8 // Protocol version we speak
+	ProtocolFloor uint8 // Highest protocol version all live members understand
+}
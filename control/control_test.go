@@ -0,0 +1,175 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package control
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/memberlist"
+	"github.com/stretchr/testify/require"
+)
+
+func newMemberlist(t *testing.T, name string, keyring *memberlist.Keyring) *memberlist.Memberlist {
+	c := memberlist.DefaultLANConfig()
+	c.Name = name
+	c.BindAddr = "127.0.0.1"
+	c.BindPort = 0
+	c.Keyring = keyring
+	m, err := memberlist.Create(c)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = m.Shutdown() })
+	return m
+}
+
+func TestControl(t *testing.T) {
+	key1 := bytes.Repeat([]byte{1}, 16)
+	key2 := bytes.Repeat([]byte{2}, 16)
+	keyring, err := memberlist.NewKeyring(nil, key1)
+	require.NoError(t, err)
+
+	m1 := newMemberlist(t, "a", keyring)
+	m2 := newMemberlist(t, "b", keyring)
+
+	path := filepath.Join(t.TempDir(), "control.sock")
+	s, err := Listen(m1, Config{Path: path, Keyring: keyring})
+	require.NoError(t, err)
+	defer s.Close()
+
+	c, err := Dial(path)
+	require.NoError(t, err)
+	defer c.Close()
+
+	joined, err := c.Join([]string{m2.LocalNode().Address()})
+	require.NoError(t, err)
+	require.Equal(t, 1, joined)
+
+	members, err := c.Members()
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+	for _, member := range members {
+		require.Equal(t, "alive", member.State)
+		require.Equal(t, "127.0.0.1", member.Addr)
+	}
+
+	stats, err := c.Stats()
+	require.NoError(t, err)
+	require.Equal(t, 2, stats.Members)
+	require.Equal(t, 2, stats.Alive)
+	require.Equal(t, uint8(memberlist.ProtocolVersionMax), stats.ProtocolFloor)
+
+	// Rotate the key
+	require.NoError(t, c.InstallKey(key2))
+	require.NoError(t, c.UseKey(key2))
+	require.NoError(t, c.RemoveKey(key1))
+	keys, err := c.ListKeys()
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{key2}, keys)
+	require.Error(t, c.RemoveKey(key2), "can't remove the primary key")
+
+	// Force b out once it's gone
+	require.NoError(t, m2.Shutdown())
+	require.NoError(t, c.ForceLeave("b"))
+	stats, err = c.Stats()
+	require.NoError(t, err)
+	require.Equal(t, 1, stats.Alive)
+
+	require.Error(t, c.ForceLeave("nope"))
+	require.EqualError(t, c.Call("bogus", nil, nil), `unknown command "bogus"`)
+
+	require.NoError(t, c.Leave(time.Second))
+}
+
+func TestControl_NoKeyring(t *testing.T) {
+	m := newMemberlist(t, "a", nil)
+
+	path := filepath.Join(t.TempDir(), "control.sock")
+	s, err := Listen(m, Config{Path: path})
+	require.NoError(t, err)
+
+	c, err := Dial(path)
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.ListKeys()
+	require.EqualError(t, err, "no keyring configured")
+
+	// The path can be listened on again once closed
+	require.NoError(t, s.Close())
+	s, err = Listen(m, Config{Path: path})
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+}
+
+func TestControl_SocketPermissions(t *testing.T) {
+	m := newMemberlist(t, "a", nil)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "control.sock")
+	s, err := Listen(m, Config{Path: path})
+	require.NoError(t, err)
+	require.Equal(t, path, s.Addr().String())
+
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	require.NoError(t, s.Close())
+	_, err = os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestControl_Audit(t *testing.T) {
+	var (
+		lock    sync.Mutex
+		actions []string
+	)
+	c := memberlist.DefaultLANConfig()
+	c.Name = "a"
+	c.BindAddr = "127.0.0.1"
+	c.BindPort = 0
+	c.AuditHook = func(action, actor, target string, result error) {
+		lock.Lock()
+		defer lock.Unlock()
+		actions = append(actions, fmt.Sprintf("%s %s %s %v", action, actor, target, result != nil))
+	}
+	m, err := memberlist.Create(c)
+	require.NoError(t, err)
+	defer m.Shutdown()
+
+	path := filepath.Join(t.TempDir(), "control.sock")
+	s, err := Listen(m, Config{Path: path})
+	require.NoError(t, err)
+	defer s.Close()
+
+	cl, err := Dial(path)
+	require.NoError(t, err)
+	defer cl.Close()
+
+	// Each command is reported once, as the control socket.
+	m2 := newMemberlist(t, "b", nil)
+	_, err = cl.Join([]string{m2.LocalNode().Address()})
+	require.NoError(t, err)
+	require.NoError(t, m2.Shutdown())
+	require.NoError(t, cl.ForceLeave("b"))
+	require.Error(t, cl.ForceLeave("nope"))
+	require.Error(t, cl.InstallKey(bytes.Repeat([]byte{1}, 16)))
+	_, err = cl.Members()
+	require.NoError(t, err)
+	lock.Lock()
+	defer lock.Unlock()
+	require.Equal(t, []string{
+		"join control-socket " + m2.LocalNode().Address() + " false",
+		"force-leave control-socket b false",
+		"force-leave control-socket nope true",
+		"install-key control-socket " + memberlist.KeyFingerprint(bytes.Repeat([]byte{1}, 16)) + " true",
+	}, actions)
+}
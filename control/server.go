@@ -0,0 +1,354 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/hashicorp/memberlist"
+)
+
+// Config configures a control socket Server.
+type Config struct {
+	// Path is where the UNIX socket is created. It's created with mode
+	// 0600, so only the owner can use it. A stale socket left at the path
+	// is replaced.
+	Path string
+
+	// Keyring is the keyring the memberlist was created with. The key
+	// commands fail if it's nil.
+	Keyring *memberlist.Keyring
+
+	// Logger is used to report errors serving connections. It defaults to
+	// logging to os.Stderr.
+	Logger *log.Logger
+}
+
+// Server serves the control socket for a memberlist.
+type Server struct {
+	m       *memberlist.Memberlist
+	keyring *memberlist.Keyring
+	logger  *log.Logger
+	path    string
+	ln      net.Listener
+
+	lock   sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// Listen creates the control socket and starts serving it.
+func Listen(m *memberlist.Memberlist, c Config) (*Server, error) {
+	if c.Path == "" {
+		return nil, fmt.Errorf("control socket path is required")
+	}
+	if fi, err := os.Lstat(c.Path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and isn't a socket", c.Path)
+		}
+		if err := os.Remove(c.Path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale control socket: %v", err)
+		}
+	}
+
+	ln, err := listenPrivate(c.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := c.Logger
+	if logger == nil {
+		logger = log.New(os.Stderr, "", log.LstdFlags)
+	}
+	s := &Server{
+		m:       m,
+		keyring: c.Keyring,
+		logger:  logger,
+		path:    c.Path,
+		ln:      ln,
+		conns:   make(map[net.Conn]struct{}),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Addr returns the address of the control socket.
+func (s *Server) Addr() net.Addr {
+	return &net.UnixAddr{Name: s.path, Net: "unix"}
+}
+
+// Close stops serving, closes any open connections and removes the socket.
+func (s *Server) Close() error {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return nil
+	}
+	s.closed = true
+	err := s.ln.Close()
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.lock.Unlock()
+
+	s.wg.Wait()
+	if rerr := os.Remove(s.path); rerr != nil && !os.IsNotExist(rerr) && err == nil {
+		err = rerr
+	}
+	return err
+}
+
+// listenPrivate creates a UNIX socket at path that only the owner can use.
+// It's created in a private directory next to path and moved into place once
+// its mode is set, so it's never reachable with the mode the umask gives it.
+func listenPrivate(path string) (*net.UnixListener, error) {
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".control-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create control socket: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tmp := filepath.Join(dir, "sock")
+	ln, err := net.ListenUnix("unix", &net.UnixAddr{Name: tmp, Net: "unix"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create control socket: %v", err)
+	}
+	// The socket won't be at tmp by the time we're closed, Close removes it.
+	ln.SetUnlinkOnClose(false)
+	if err := os.Chmod(tmp, 0600); err != nil {
+		_ = ln.Close()
+		return nil, fmt.Errorf("failed to set control socket permissions: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = ln.Close()
+		return nil, fmt.Errorf("failed to create control socket: %v", err)
+	}
+	return ln, nil
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			s.lock.Lock()
+			closed := s.closed
+			s.lock.Unlock()
+			if !closed {
+				s.logger.Printf("[ERR] memberlist: Control socket accept failed: %v", err)
+			}
+			return
+		}
+
+		s.lock.Lock()
+		if s.closed {
+			s.lock.Unlock()
+			_ = conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.lock.Unlock()
+		go s.handleConn(conn)
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	defer func() {
+		s.lock.Lock()
+		delete(s.conns, conn)
+		s.lock.Unlock()
+		_ = conn.Close()
+		s.wg.Done()
+	}()
+
+	dec := json.NewDecoder(conn)
+	enc := json.NewEncoder(conn)
+	for {
+		var req Request
+		if err := dec.Decode(&req); err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				s.logger.Printf("[ERR] memberlist: Failed to read control request: %v", err)
+			}
+			return
+		}
+
+		var resp Response
+		result, err := s.handle(req)
+		s.audit(req, err)
+		if err == nil && result != nil {
+			resp.Result, err = json.Marshal(result)
+		}
+		if err != nil {
+			resp.Error = err.Error()
+		}
+		if err := enc.Encode(&resp); err != nil {
+			s.logger.Printf("[ERR] memberlist: Failed to write control response: %v", err)
+			return
+		}
+	}
+}
+
+// handle runs a command and returns its result, or nil if it has none.
+func (s *Server) handle(req Request) (interface{}, error) {
+	switch req.Command {
+	case CmdMembers:
+		nodes := s.m.Members()
+		members := make([]Member, 0, len(nodes))
+		for _, n := range nodes {
+			members = append(members, Member{
+				Name:  n.Name,
+				Addr:  n.Addr.String(),
+				Port:  n.Port,
+				State: stateName(s.state(n.Name)),
+				Meta:  n.Meta,
+				Zone:  n.Zone,
+				ID:    n.ID,
+			})
+		}
+		return members, nil
+
+	case CmdJoin:
+		var args JoinArgs
+		if err := decodeArgs(req, &args); err != nil {
+			return nil, err
+		}
+		n, err := s.m.As(actor).Join(args.Addrs)
+		if n == 0 && err != nil {
+			return nil, err
+		}
+		return JoinResult{Joined: n}, nil
+
+	case CmdLeave:
+		var args LeaveArgs
+		if err := decodeArgs(req, &args); err != nil {
+			return nil, err
+		}
+		return nil, s.m.As(actor).Leave(args.Timeout)
+
+	case CmdForceLeave:
+		var args ForceLeaveArgs
+		if err := decodeArgs(req, &args); err != nil {
+			return nil, err
+		}
+		d, err := s.m.NodeDiagnostics(args.Node)
+		if err != nil {
+			// There's no SetNodeState call to report this.
+			s.m.Audit(memberlist.AuditForceLeave, actor, args.Node, err)
+			return nil, err
+		}
+		if d.State == memberlist.StateDead || d.State == memberlist.StateLeft {
+			return nil, nil
+		}
+		return nil, s.m.As(actor).SetNodeState(args.Node, memberlist.StateDead, d.Incarnation)
+
+	case CmdListKeys:
+		if s.keyring == nil {
+			return nil, errNoKeyring
+		}
+		return KeysResult{Keys: s.keyring.GetKeys()}, nil
+
+	case CmdInstallKey, CmdUseKey, CmdRemoveKey:
+		if s.keyring == nil {
+			return nil, errNoKeyring
+		}
+		var args KeyArgs
+		if err := decodeArgs(req, &args); err != nil {
+			return nil, err
+		}
+		defer clear(args.Key) // The keyring has its own copy
+		switch req.Command {
+		case CmdInstallKey:
+			return nil, s.keyring.AddKey(args.Key)
+		case CmdUseKey:
+			return nil, s.keyring.UseKey(args.Key)
+		default:
+			return nil, s.keyring.RemoveKey(args.Key)
+		}
+
+	case CmdStats:
+		nodes := s.m.Members()
+		return Stats{
+			Members:       len(nodes),
+			Alive:         s.m.NumMembers(),
+			Health:        s.m.GetHealthScore(),
+			Protocol:      s.m.ProtocolVersion(),
+			ProtocolFloor: s.m.ProtocolSummary().Floor,
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown command %q", req.Command)
+	}
+}
+
+var errNoKeyring = errors.New("no keyring configured")
+
+// actor is who we report as taking the commands to the memberlist's
+// AuditHook. The socket is only open to its owner.
+const actor = "control-socket"
+
+// audit reports a command that changes something to the memberlist's
+// AuditHook. Membership commands are reported by the memberlist itself, see
+// handle.
+func (s *Server) audit(req Request, err error) {
+	switch req.Command {
+	case CmdInstallKey, CmdUseKey, CmdRemoveKey:
+		var args KeyArgs
+		_ = decodeArgs(req, &args)
+		defer clear(args.Key)
+		action := memberlist.AuditRemoveKey
+		switch req.Command {
+		case CmdInstallKey:
+			action = memberlist.AuditInstallKey
+		case CmdUseKey:
+			action = memberlist.AuditUseKey
+		}
+		s.m.Audit(action, actor, memberlist.KeyFingerprint(args.Key), err)
+	}
+}
+
+// state returns the current state of a node. The nodes returned by Members
+// don't carry it.
+func (s *Server) state(name string) memberlist.NodeStateType {
+	d, err := s.m.NodeDiagnostics(name)
+	if err != nil {
+		return memberlist.StateDead
+	}
+	return d.State
+}
+
+func decodeArgs(req Request, args interface{}) error {
+	if len(req.Args) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(req.Args, args); err != nil {
+		return fmt.Errorf("invalid arguments for %s: %v", req.Command, err)
+	}
+	return nil
+}
+
+func stateName(s memberlist.NodeStateType) string {
+	switch s {
+	case memberlist.StateAlive:
+		return "alive"
+	case memberlist.StateSuspect:
+		return "suspect"
+	case memberlist.StateDead:
+		return "dead"
+	case memberlist.StateLeft:
+		return "left"
+	default:
+		return fmt.Sprintf("unknown(%d)", s)
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package crdt
+
+// GCounter is a grow-only counter. Each node increments its own count, and
+// the value is the sum of all of them. Merging keeps the highest count seen
+// for each node.
+type GCounter struct {
+	Counts map[string]uint64
+}
+
+// Inc adds delta to the given node's count.
+func (c *GCounter) Inc(node string, delta uint64) {
+	if c.Counts == nil {
+		c.Counts = make(map[string]uint64)
+	}
+	c.Counts[node] += delta
+}
+
+// Value returns the total count over all nodes.
+func (c *GCounter) Value() uint64 {
+	var total uint64
+	for _, n := range c.Counts {
+		total += n
+	}
+	return total
+}
+
+// Merge keeps the highest count for each node.
+func (c *GCounter) Merge(other *GCounter) {
+	for node, n := range other.Counts {
+		if n > c.Counts[node] {
+			if c.Counts == nil {
+				c.Counts = make(map[string]uint64)
+			}
+			c.Counts[node] = n
+		}
+	}
+}
+
+// gcounterWire has the same fields as GCounter without its methods, so
+// that the codec doesn't call back into MarshalBinary.
+type gcounterWire GCounter
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (c *GCounter) MarshalBinary() ([]byte, error) {
+	return encode((*gcounterWire)(c))
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (c *GCounter) UnmarshalBinary(buf []byte) error {
+	*c = GCounter{}
+	return decode(buf, (*gcounterWire)(c))
+}
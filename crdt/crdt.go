@@ -0,0 +1,57 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+// Package crdt provides conflict-free replicated data types for state that
+// is spread with memberlist, either in a Delegate's broadcasts or in its
+// push/pull state.
+//
+// Each type has a Merge method that combines another replica's state into
+// it. Merging is commutative, associative and idempotent, so replicas that
+// have seen the same updates end up with the same state no matter in which
+// order or how many times they were delivered. Each type can be serialized
+// with MarshalBinary and read back with UnmarshalBinary.
+//
+// A typical Delegate marshals its state in LocalState and merges what it
+// gets in MergeRemoteState:
+//
+//	func (d *delegate) LocalState(join bool) []byte {
+//	    d.lock.Lock()
+//	    defer d.lock.Unlock()
+//	    buf, _ := d.hits.MarshalBinary()
+//	    return buf
+//	}
+//
+//	func (d *delegate) MergeRemoteState(buf []byte, join bool) {
+//	    var remote crdt.GCounter
+//	    if err := remote.UnmarshalBinary(buf); err != nil {
+//	        return
+//	    }
+//	    d.lock.Lock()
+//	    defer d.lock.Unlock()
+//	    d.hits.Merge(&remote)
+//	}
+//
+// None of the types are safe for concurrent use.
+package crdt
+
+import (
+	"bytes"
+
+	"github.com/hashicorp/go-msgpack/v2/codec"
+)
+
+// encode serializes a value with msgpack, as memberlist does.
+func encode(in interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	hd := codec.MsgpackHandle{}
+	if err := codec.NewEncoder(&buf, &hd).Encode(in); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decode reverses encode.
+func decode(buf []byte, out interface{}) error {
+	hd := codec.MsgpackHandle{}
+	return codec.NewDecoder(bytes.NewReader(buf), &hd).Decode(out)
+}
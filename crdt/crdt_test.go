@@ -0,0 +1,108 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package crdt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLWWRegister_Merge(t *testing.T) {
+	var a, b LWWRegister
+	a.Set([]byte("one"), 1, "a")
+	b.Set([]byte("two"), 2, "b")
+
+	// Later write wins regardless of merge order
+	ab, ba := a, b
+	ab.Merge(&b)
+	ba.Merge(&a)
+	require.Equal(t, []byte("two"), ab.Value)
+	require.Equal(t, ab, ba)
+
+	// Stale sets are ignored
+	ab.Set([]byte("old"), 1, "z")
+	require.Equal(t, []byte("two"), ab.Value)
+
+	// Ties are broken by node name
+	var c, d LWWRegister
+	c.Set([]byte("c"), 5, "node-c")
+	d.Set([]byte("d"), 5, "node-d")
+	c.Merge(&d)
+	require.Equal(t, []byte("d"), c.Value)
+	d.Merge(&c)
+	require.Equal(t, c, d)
+}
+
+func TestGCounter_Merge(t *testing.T) {
+	var a, b GCounter
+	a.Inc("a", 3)
+	b.Inc("b", 2)
+	b.Inc("a", 1)
+
+	a.Merge(&b)
+	require.Equal(t, uint64(5), a.Value())
+
+	// Merging again changes nothing
+	a.Merge(&b)
+	require.Equal(t, uint64(5), a.Value())
+
+	b.Merge(&a)
+	require.Equal(t, a.Counts, b.Counts)
+}
+
+func TestORSet(t *testing.T) {
+	var a, b ORSet
+	a.Add("x", "a")
+	a.Add("y", "a")
+	b.Merge(&a)
+	require.Equal(t, []string{"x", "y"}, b.Elements())
+
+	// A concurrent add and remove keeps the element
+	b.Remove("x")
+	a.Add("x", "a")
+	require.False(t, b.Contains("x"))
+	a.Merge(&b)
+	b.Merge(&a)
+	require.True(t, a.Contains("x"))
+	require.Equal(t, a.Elements(), b.Elements())
+
+	// A remove of everything seen removes it everywhere
+	a.Remove("y")
+	b.Merge(&a)
+	require.Equal(t, []string{"x"}, b.Elements())
+}
+
+func TestCRDT_Marshal(t *testing.T) {
+	var r LWWRegister
+	r.Set([]byte("v"), 7, "n")
+	buf, err := r.MarshalBinary()
+	require.NoError(t, err)
+	var r2 LWWRegister
+	require.NoError(t, r2.UnmarshalBinary(buf))
+	require.Equal(t, r, r2)
+
+	var c GCounter
+	c.Inc("n", 4)
+	buf, err = c.MarshalBinary()
+	require.NoError(t, err)
+	var c2 GCounter
+	require.NoError(t, c2.UnmarshalBinary(buf))
+	require.Equal(t, uint64(4), c2.Value())
+
+	var s ORSet
+	s.Add("x", "n")
+	s.Add("y", "n")
+	s.Remove("y")
+	buf, err = s.MarshalBinary()
+	require.NoError(t, err)
+	var s2 ORSet
+	require.NoError(t, s2.UnmarshalBinary(buf))
+	require.Equal(t, []string{"x"}, s2.Elements())
+
+	// Adds after a round trip still get fresh tags
+	s2.Add("y", "n")
+	s.Merge(&s2)
+	require.Equal(t, []string{"x", "y"}, s.Elements())
+}
@@ -0,0 +1,63 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package crdt
+
+import (
+	"bytes"
+)
+
+// LWWRegister is a last-writer-wins register: it holds a single value, and
+// the write with the highest timestamp wins. Writes with the same timestamp
+// are ordered by the name of the node that made them, then by value, so
+// every replica picks the same one.
+//
+// Timestamps can be wall clock times, such as time.Now().UnixNano(), or a
+// logical clock. With wall clocks, clock skew between nodes decides which
+// of two close writes wins.
+type LWWRegister struct {
+	Value     []byte
+	Timestamp uint64
+	Node      string
+}
+
+// Set writes a value at the given timestamp on behalf of a node. It has no
+// effect if the register already holds a later write.
+func (r *LWWRegister) Set(value []byte, timestamp uint64, node string) {
+	r.Merge(&LWWRegister{Value: value, Timestamp: timestamp, Node: node})
+}
+
+// Merge keeps whichever of the two writes is later.
+func (r *LWWRegister) Merge(other *LWWRegister) {
+	if other.after(r) {
+		r.Value = append([]byte(nil), other.Value...)
+		r.Timestamp = other.Timestamp
+		r.Node = other.Node
+	}
+}
+
+// after returns true if r's write wins over other's.
+func (r *LWWRegister) after(other *LWWRegister) bool {
+	if r.Timestamp != other.Timestamp {
+		return r.Timestamp > other.Timestamp
+	}
+	if r.Node != other.Node {
+		return r.Node > other.Node
+	}
+	return bytes.Compare(r.Value, other.Value) > 0
+}
+
+// lWWRegisterWire has the same fields as LWWRegister without its methods, so
+// that the codec doesn't call back into MarshalBinary.
+type lWWRegisterWire LWWRegister
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (r *LWWRegister) MarshalBinary() ([]byte, error) {
+	return encode((*lWWRegisterWire)(r))
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (r *LWWRegister) UnmarshalBinary(buf []byte) error {
+	*r = LWWRegister{}
+	return decode(buf, (*lWWRegisterWire)(r))
+}
@@ -0,0 +1,117 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package crdt
+
+import (
+	"sort"
+	"strconv"
+)
+
+// ORSet is an observed-remove set of strings. Every add is tagged with a
+// unique tag, and a remove only removes the tags it has seen, so an add
+// that happens concurrently with a remove of the same element wins.
+//
+// Removed tags are kept as tombstones so merges don't bring them back,
+// which means the set grows with the number of adds over its lifetime.
+type ORSet struct {
+	// Adds maps each element to the tags it was added with.
+	Adds map[string]map[string]struct{}
+
+	// Removed holds the tags that have been removed.
+	Removed map[string]struct{}
+
+	// Clocks counts the adds made by each node, to make unique tags.
+	Clocks map[string]uint64
+}
+
+// Add adds an element on behalf of a node.
+func (s *ORSet) Add(elem, node string) {
+	if s.Clocks == nil {
+		s.Clocks = make(map[string]uint64)
+	}
+	s.Clocks[node]++
+	s.addTag(elem, node+"/"+strconv.FormatUint(s.Clocks[node], 10))
+}
+
+func (s *ORSet) addTag(elem, tag string) {
+	if s.Adds == nil {
+		s.Adds = make(map[string]map[string]struct{})
+	}
+	tags, ok := s.Adds[elem]
+	if !ok {
+		tags = make(map[string]struct{})
+		s.Adds[elem] = tags
+	}
+	tags[tag] = struct{}{}
+}
+
+// Remove removes an element, as far as this replica has seen it added.
+func (s *ORSet) Remove(elem string) {
+	for tag := range s.Adds[elem] {
+		if s.Removed == nil {
+			s.Removed = make(map[string]struct{})
+		}
+		s.Removed[tag] = struct{}{}
+	}
+}
+
+// Contains returns true if the element has an add that wasn't removed.
+func (s *ORSet) Contains(elem string) bool {
+	for tag := range s.Adds[elem] {
+		if _, ok := s.Removed[tag]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Elements returns the elements in the set, sorted.
+func (s *ORSet) Elements() []string {
+	var elems []string
+	for elem := range s.Adds {
+		if s.Contains(elem) {
+			elems = append(elems, elem)
+		}
+	}
+	sort.Strings(elems)
+	return elems
+}
+
+// Merge takes the union of both replicas' adds and removes.
+func (s *ORSet) Merge(other *ORSet) {
+	for elem, tags := range other.Adds {
+		for tag := range tags {
+			s.addTag(elem, tag)
+		}
+	}
+	for tag := range other.Removed {
+		if s.Removed == nil {
+			s.Removed = make(map[string]struct{})
+		}
+		s.Removed[tag] = struct{}{}
+	}
+	for node, n := range other.Clocks {
+		if n > s.Clocks[node] {
+			if s.Clocks == nil {
+				s.Clocks = make(map[string]uint64)
+			}
+			s.Clocks[node] = n
+		}
+	}
+}
+
+// orsetWire has the same fields as ORSet without its methods, so
+// that the codec doesn't call back into MarshalBinary.
+type orsetWire ORSet
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (s *ORSet) MarshalBinary() ([]byte, error) {
+	return encode((*orsetWire)(s))
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (s *ORSet) UnmarshalBinary(buf []byte) error {
+	*s = ORSet{}
+	return decode(buf, (*orsetWire)(s))
+}
@@ -0,0 +1,149 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// debugEntry is a message kept for DebugDump.
+type debugEntry struct {
+	time    time.Time
+	inbound bool
+	peer    string
+	msgType messageType
+	size    int
+	fields  interface{} // Decoded message, or nil if it couldn't be
+}
+
+// debugRing keeps the last Config.DebugRingSize messages.
+type debugRing struct {
+	lock    sync.Mutex
+	entries []debugEntry
+	next    int
+	full    bool
+}
+
+func (r *debugRing) add(e debugEntry) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.entries[r.next] = e
+	r.next++
+	if r.next == len(r.entries) {
+		r.next, r.full = 0, true
+	}
+}
+
+// snapshot returns the entries, oldest first.
+func (r *debugRing) snapshot() []debugEntry {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if !r.full {
+		return append([]debugEntry(nil), r.entries[:r.next]...)
+	}
+	out := make([]debugEntry, 0, len(r.entries))
+	out = append(out, r.entries[r.next:]...)
+	return append(out, r.entries[:r.next]...)
+}
+
+// newDebugMessage returns a value to decode a message of the given type
+// into, or nil if it isn't one we know how to decode.
+func newDebugMessage(msgType messageType) interface{} {
+	switch msgType {
+	case pingMsg:
+		return &ping{}
+	case indirectPingMsg:
+		return &indirectPingReq{}
+	case ackRespMsg:
+		return &ackResp{}
+	case nackRespMsg:
+		return &nackResp{}
+	case suspectMsg:
+		return &suspect{}
+	case aliveMsg:
+		return &alive{}
+	case deadMsg:
+		return &dead{}
+	case errMsg:
+		return &errResp{}
+	case pushPullDigestMsg:
+		return &pushPullDigest{}
+	case stateSummaryMsg:
+		return &stateSummary{}
+	case gossipDigestMsg:
+		return &gossipDigest{}
+	}
+	return nil
+}
+
+// debugPacket records a packet message, which starts with its type byte.
+// Compound messages are recorded as their parts.
+func (m *Memberlist) debugPacket(inbound bool, peer string, buf []byte) {
+	if m.debugRing == nil || len(buf) < 1 {
+		return
+	}
+	msgType := messageType(buf[0])
+	e := debugEntry{time: time.Now(), inbound: inbound, peer: peer, msgType: msgType, size: len(buf)}
+
+	switch msgType {
+	case compoundMsg:
+		_, parts, err := decodeCompoundMessage(buf[1:])
+		if err == nil {
+			for _, part := range parts {
+				m.debugPacket(inbound, peer, part)
+			}
+			return
+		}
+	case protoMsg:
+		if len(buf) > 1 {
+			e.msgType = messageType(buf[1])
+			if msg := newProtoMessage(e.msgType); msg != nil && (protobufCodec{}).decode(buf[2:], msg) == nil {
+				e.fields = msg
+			}
+		}
+	default:
+		if msg := newDebugMessage(msgType); msg != nil && decode(buf[1:], msg) == nil {
+			e.fields = msg
+		}
+	}
+	m.debugRing.add(e)
+}
+
+// debugMessage records an already decoded message.
+func (m *Memberlist) debugMessage(inbound bool, peer string, msgType messageType, fields interface{}) {
+	if m.debugRing == nil {
+		return
+	}
+	m.debugRing.add(debugEntry{time: time.Now(), inbound: inbound, peer: peer, msgType: msgType, fields: fields})
+}
+
+// DebugDump writes the messages kept since Config.DebugRingSize turned on
+// the debug ring, oldest first, one per line with their decoded fields.
+// It's meant for working out after the fact why something happened, such
+// as why a node was marked dead.
+func (m *Memberlist) DebugDump(w io.Writer) error {
+	if m.debugRing == nil {
+		return fmt.Errorf("debug ring is not enabled")
+	}
+	for _, e := range m.debugRing.snapshot() {
+		dir := "out"
+		if e.inbound {
+			dir = "in"
+		}
+		line := fmt.Sprintf("%s %-3s %s %s", e.time.Format(time.RFC3339Nano), dir, e.peer, e.msgType)
+		if e.size > 0 {
+			line += fmt.Sprintf(" (%d bytes)", e.size)
+		}
+		if e.fields != nil {
+			line += fmt.Sprintf(" %+v", e.fields)
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
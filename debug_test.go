@@ -0,0 +1,70 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	iretry "github.com/hashicorp/memberlist/internal/retry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugRing(t *testing.T) {
+	r := &debugRing{entries: make([]debugEntry, 3)}
+	require.Empty(t, r.snapshot())
+
+	for i := 0; i < 5; i++ {
+		r.add(debugEntry{size: i})
+	}
+	var sizes []int
+	for _, e := range r.snapshot() {
+		sizes = append(sizes, e.size)
+	}
+	require.Equal(t, []int{2, 3, 4}, sizes)
+}
+
+func TestMemberlist_DebugDump(t *testing.T) {
+	c1 := testConfig(t)
+	c1.DebugRingSize = 1000
+	m1, err := Create(c1)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m1.Shutdown())
+	}()
+
+	c2 := testConfig(t)
+	c2.BindPort = m1.config.BindPort
+	m2, err := Create(c2)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m2.Shutdown())
+	}()
+
+	_, err = m2.Join([]string{m1.config.Name + "/" + m1.config.BindAddr})
+	require.NoError(t, err)
+
+	iretry.Run(t, func(r *iretry.R) {
+		var buf bytes.Buffer
+		require.NoError(t, m1.DebugDump(&buf))
+		dump := buf.String()
+
+		// The join's push/pull, and probes both ways.
+		for _, want := range []string{
+			" in  " + m2.config.BindAddr,
+			" push-pull ",
+			" out " + m2.config.Name + " (",
+			" ping ",
+			" ack ",
+			"Node:" + m2.config.Name,
+		} {
+			if !strings.Contains(dump, want) {
+				r.Fatalf("missing %q in dump:\n%s", want, dump)
+			}
+		}
+	})
+
+	require.Error(t, m2.DebugDump(&bytes.Buffer{}))
+}
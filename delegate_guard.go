@@ -0,0 +1,96 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"errors"
+	"time"
+
+	metrics "github.com/hashicorp/go-metrics/compat"
+)
+
+// errDelegatePanic is used in place of the result of a delegate callback that
+// panicked, for callbacks that can reject what they're given.
+var errDelegatePanic = errors.New("delegate panicked")
+
+// guard runs a delegate callback. If Config.DelegatePanicHandler is set, a
+// panic in the callback is recovered and handed to it, and guard returns
+// false. Otherwise the panic isn't recovered, as before.
+func (m *Memberlist) guard(callback string, f func()) (ok bool) {
+	handler := m.config.DelegatePanicHandler
+	if handler == nil {
+		f()
+		return true
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			ok = false
+			m.logger.Printf("[ERR] memberlist: Delegate callback %s panicked: %v", callback, r)
+			metrics.IncrCounterWithLabels([]string{"memberlist", "delegate", "panic"}, 1, m.metricLabels)
+			handler(callback, r)
+		}
+	}()
+	f()
+	return true
+}
+
+// notifyMsg hands a user message to the delegate, either directly or through
+// the NotifyMsg workers if there are any.
+func (m *Memberlist) notifyMsg(msg []byte) {
+	m.notifyUser(userMessage{buf: msg})
+}
+
+// notifyUser is notifyMsg for messages that may carry a Lamport time.
+func (m *Memberlist) notifyUser(msg userMessage) {
+	if !m.userMsgAllowed(len(msg.buf)) {
+		return
+	}
+
+	if m.notifyCh == nil {
+		m.deliverUser(msg)
+		return
+	}
+
+	// The caller may reuse the buffer once we return.
+	msg.buf = append([]byte(nil), msg.buf...)
+
+	var timeoutCh <-chan time.Time
+	if m.config.NotifyMsgTimeout > 0 {
+		timer := time.NewTimer(m.config.NotifyMsgTimeout)
+		defer timer.Stop()
+		timeoutCh = timer.C
+	}
+	select {
+	case m.notifyCh <- msg:
+	case <-timeoutCh:
+		m.logger.Printf("[WARN] memberlist: Dropping user message, all NotifyMsg workers are busy")
+		metrics.IncrCounterWithLabels([]string{"memberlist", "delegate", "dropped"}, 1, m.metricLabels)
+	case <-m.shutdownCh:
+	}
+}
+
+// startNotifyWorkers starts the NotifyMsg workers, if configured.
+func (m *Memberlist) startNotifyWorkers() {
+	if m.config.NotifyMsgWorkers <= 0 {
+		return
+	}
+	m.notifyCh = make(chan userMessage, m.config.NotifyMsgWorkers)
+	for i := 0; i < m.config.NotifyMsgWorkers; i++ {
+		go m.notifyWorker()
+	}
+}
+
+// notifyWorker is a long running goroutine that delivers user messages to the
+// delegate until shutdown.
+func (m *Memberlist) notifyWorker() {
+	for {
+		select {
+		case msg := <-m.notifyCh:
+			m.deliverUser(msg)
+		case <-m.shutdownCh:
+			return
+		}
+	}
+}
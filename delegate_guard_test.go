@@ -0,0 +1,123 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	iretry "github.com/hashicorp/memberlist/internal/retry"
+	"github.com/stretchr/testify/require"
+)
+
+// panicDelegate panics in NotifyMsg.
+type panicDelegate struct {
+	MockDelegate
+}
+
+func (d *panicDelegate) NotifyMsg([]byte) {
+	panic("boom")
+}
+
+// panicAliveDelegate panics in NotifyAlive.
+type panicAliveDelegate struct{}
+
+func (panicAliveDelegate) NotifyAlive(*Node) error {
+	panic("boom")
+}
+
+// blockingDelegate blocks in NotifyMsg until released.
+type blockingDelegate struct {
+	MockDelegate
+	release chan struct{}
+}
+
+func (d *blockingDelegate) NotifyMsg(msg []byte) {
+	<-d.release
+	d.MockDelegate.NotifyMsg(msg)
+}
+
+func TestMemberlist_DelegatePanicHandler(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		recovery []string
+	)
+	m := GetMemberlist(t, func(c *Config) {
+		c.Delegate = &panicDelegate{}
+		c.Alive = panicAliveDelegate{}
+		c.DelegatePanicHandler = func(callback string, recovered interface{}) {
+			mu.Lock()
+			defer mu.Unlock()
+			recovery = append(recovery, callback)
+			require.Equal(t, "boom", recovered)
+		}
+	})
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	// The panic doesn't escape.
+	m.handleUser([]byte("hello"), nil)
+
+	// A panicking alive delegate rejects the node.
+	a := alive{Node: "test", Addr: []byte{127, 0, 0, 1}, Incarnation: 1, Vsn: m.config.BuildVsnArray()}
+	m.aliveNode(&a, nil, false)
+	m.nodeLock.RLock()
+	_, ok := m.nodeMap["test"]
+	m.nodeLock.RUnlock()
+	require.False(t, ok)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []string{"NotifyMsg", "NotifyAlive"}, recovery)
+}
+
+func TestMemberlist_Guard_NoHandler(t *testing.T) {
+	m := GetMemberlist(t, nil)
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	// Without a handler panics propagate as before.
+	require.PanicsWithValue(t, "boom", func() {
+		m.guard("NotifyMsg", func() { panic("boom") })
+	})
+	require.True(t, m.guard("NotifyMsg", func() {}))
+}
+
+func TestMemberlist_NotifyMsgWorkers(t *testing.T) {
+	d := &blockingDelegate{release: make(chan struct{})}
+	m := GetMemberlist(t, func(c *Config) {
+		c.Delegate = d
+		c.NotifyMsgWorkers = 1
+		c.NotifyMsgTimeout = 10 * time.Millisecond
+	})
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	// The first message occupies the worker, the second waits in the queue
+	// and the third times out, all without blocking the caller for long.
+	start := time.Now()
+	buf := []byte("one")
+	m.handleUser(buf, nil)
+	copy(buf, "xxx") // the worker has its own copy
+	iretry.Run(t, func(r *iretry.R) {
+		if len(m.notifyCh) != 0 {
+			r.Fatal("expected the worker to pick up the first message")
+		}
+	})
+	m.handleUser([]byte("two"), nil)
+	m.handleUser([]byte("three"), nil)
+	require.Less(t, time.Since(start), time.Second)
+
+	close(d.release)
+	iretry.Run(t, func(r *iretry.R) {
+		if len(d.getMessages()) != 2 {
+			r.Fatal("expected two messages")
+		}
+	})
+	require.Equal(t, [][]byte{[]byte("one"), []byte("two")}, d.getMessages())
+}
@@ -0,0 +1,258 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"time"
+
+	metrics "github.com/hashicorp/go-metrics/compat"
+	"github.com/hashicorp/go-msgpack/v2/codec"
+)
+
+/*
+A delta push/pull is a three step exchange over a single stream:
+
+ 1. The initiator sends a digest of its node map: the name, incarnation,
+    state and a hash of the keyed state of every node it knows about.
+ 2. The responder compares that with its own view, and replies with the
+    full state of every node where it has a different view, the names of
+    the nodes where the initiator's view is newer or unknown to it, and its
+    user state.
+ 3. The initiator sends the full state of those names and its user state as
+    a regular push/pull message.
+
+Both sides then merge what they received the same way as a complete sync.
+*/
+
+// nodeDigest is the compact form of a node's state used by delta push/pull.
+type nodeDigest struct {
+	Name        string
+	Incarnation uint32
+	State       NodeStateType
+	Entries     uint64 `codec:",omitempty"` // Hash of the node's keyed state
+}
+
+// pushPullDigest is exchanged in the first two steps of a delta push/pull.
+type pushPullDigest struct {
+	Digest    []nodeDigest    `codec:",omitempty"` // Initiator's view of the cluster
+	Buckets   []int           `codec:",omitempty"` // Summary buckets the digest is limited to
+	Nodes     []pushNodeState `codec:",omitempty"` // Responder's differing states
+	Want      []string        `codec:",omitempty"` // States the responder asks for
+	UserState []byte          `codec:",omitempty"` // Responder's delegate state
+
+	From string `codec:",omitempty"` // Sender's name
+	To   string `codec:",omitempty"` // Name the sender expects the receiver to have
+}
+
+// canDeltaPushPull returns true if the periodic push/pull with the given
+// node can use the delta protocol.
+func (m *Memberlist) canDeltaPushPull(a Address) bool {
+	if !m.config.DeltaPushPull || a.Name == "" {
+		return false
+	}
+
+	m.nodeLock.RLock()
+	defer m.nodeLock.RUnlock()
+
+	node, ok := m.nodeMap[a.Name]
+	return ok && node.Supports(FeatureDeltaPushPull)
+}
+
+// localDigest returns the digest of our node map, limited to the given
+// summary buckets if there are any.
+func (m *Memberlist) localDigest(buckets []int) []nodeDigest {
+	in := bucketFilter(buckets)
+
+	m.refreshLocalEntries()
+	m.nodeLock.RLock()
+	defer m.nodeLock.RUnlock()
+
+	digest := make([]nodeDigest, 0, len(m.nodes))
+	for _, n := range m.nodes {
+		if in(n.Name) {
+			digest = append(digest, nodeDigest{Name: n.Name, Incarnation: n.Incarnation, State: n.State, Entries: n.entriesHash()})
+		}
+	}
+	return digest
+}
+
+// diffDigest compares a remote digest with our node map. It returns the
+// states of the nodes we have a different view of, and the names of the nodes
+// the remote side has a newer view of or that we don't know about. If both
+// sides have the same incarnation but a different state or keyed state, the
+// node shows up in both so that the usual merge rules settle it. If the digest is limited to
+// some summary buckets, so is the comparison.
+func (m *Memberlist) diffDigest(digest []nodeDigest, buckets []int) ([]pushNodeState, []string) {
+	in := bucketFilter(buckets)
+
+	m.refreshLocalEntries()
+	m.nodeLock.RLock()
+	defer m.nodeLock.RUnlock()
+
+	var (
+		send []pushNodeState
+		want []string
+		seen = make(map[string]struct{}, len(digest))
+	)
+	for _, d := range digest {
+		seen[d.Name] = struct{}{}
+
+		n, ok := m.nodeMap[d.Name]
+		switch {
+		case !ok || incarnationAfter(d.Incarnation, n.Incarnation):
+			want = append(want, d.Name)
+		case incarnationAfter(n.Incarnation, d.Incarnation):
+			send = append(send, pushNodeStateOf(n))
+		case n.State != d.State || n.entriesHash() != d.Entries:
+			send = append(send, pushNodeStateOf(n))
+			want = append(want, d.Name)
+		}
+	}
+	for _, n := range m.nodes {
+		if _, ok := seen[n.Name]; !ok && in(n.Name) {
+			send = append(send, pushNodeStateOf(n))
+		}
+	}
+	return send, want
+}
+
+// deltaPushPull does a delta state exchange with a specific node. If buckets
+// is non-empty, only the nodes in those summary buckets are exchanged.
+func (m *Memberlist) deltaPushPull(a Address, buckets []int) (err error) {
+	trace := PushPullTrace{Addr: a.Addr, Node: a.Name, Delta: true, Start: time.Now()}
+	defer func() {
+		m.tracePushPull(trace, err)
+	}()
+
+	conn, err := m.dialNode(a, m.config.TCPTimeout)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	m.logger.Printf("[DEBUG] memberlist: Initiating delta push/pull sync with: %s %s", a.Name, conn.RemoteAddr())
+	metrics.IncrCounterWithLabels([]string{"memberlist", "tcp", "connect"}, 1, m.metricLabels)
+
+	// Send our digest
+	req := pushPullDigest{Digest: m.localDigest(buckets), Buckets: buckets, From: m.config.Name, To: a.Name}
+	if err := m.sendDigest(conn, &req, m.config.Label); err != nil {
+		return err
+	}
+
+	// Read the differences
+	if err := conn.SetDeadline(time.Now().Add(m.config.TCPTimeout)); err != nil {
+		m.logger.Printf("Err: Could not set the deadline: %s", err)
+	}
+	msgType, _, dec, err := m.readStream(conn, m.config.Label)
+	if err != nil {
+		return err
+	}
+	if msgType == errMsg {
+		var resp errResp
+		if err := dec.Decode(&resp); err != nil {
+			return err
+		}
+		return fmt.Errorf("remote error: %v", resp.Error)
+	}
+	if msgType != pushPullDigestMsg {
+		return fmt.Errorf("received invalid msgType (%d), expected pushPullDigestMsg (%d) %s", msgType, pushPullDigestMsg, LogConn(conn))
+	}
+	var resp pushPullDigest
+	if err := dec.Decode(&resp); err != nil {
+		return err
+	}
+	m.debugMessage(true, a.String(), pushPullDigestMsg, &resp)
+	if err := m.checkPeerName(a.Name, resp.From); err != nil {
+		return err
+	}
+
+	// Send what they asked for, along with our user state
+	if err := m.sendNodeStates(conn, false, m.config.Label, a.Name, namesSet(resp.Want)); err != nil {
+		return err
+	}
+
+	metrics.IncrCounterWithLabels([]string{"memberlist", "pushpull", "delta", "received"}, float32(len(resp.Nodes)), m.metricLabels)
+	return m.mergeRemoteState(false, resp.Nodes, resp.UserState)
+}
+
+// handleDeltaPushPull is the responder side of a delta push/pull.
+func (m *Memberlist) handleDeltaPushPull(conn net.Conn, dec *codec.Decoder, streamLabel string) error {
+	var req pushPullDigest
+	if err := dec.Decode(&req); err != nil {
+		return err
+	}
+	m.debugMessage(true, conn.RemoteAddr().String(), pushPullDigestMsg, &req)
+
+	if err := m.authorizePushPull(conn, req.From); err != nil {
+		return err
+	}
+
+	// If it was meant for someone else, just tell them who we are.
+	resp := pushPullDigest{From: m.config.Name, To: req.From}
+	if err := m.checkPeerName(req.To, m.config.Name); err != nil {
+		if serr := m.sendDigest(conn, &resp, streamLabel); serr != nil {
+			m.logger.Printf("[ERR] memberlist: Failed to send digest: %s %s", serr, LogConn(conn))
+		}
+		return err
+	}
+
+	// Reply with the differences, along with our user state
+	resp.Nodes, resp.Want = m.diffDigest(req.Digest, req.Buckets)
+	if d := m.delegate(); d != nil {
+		m.guard("LocalState", func() { resp.UserState = d.LocalState(false) })
+	}
+	if err := m.sendDigest(conn, &resp, streamLabel); err != nil {
+		return err
+	}
+
+	// Read the states we asked for
+	msgType, bufConn, dec, err := m.readStream(conn, streamLabel)
+	if err != nil {
+		return err
+	}
+	if msgType != pushPullMsg {
+		return fmt.Errorf("received invalid msgType (%d), expected pushPullMsg (%d)", msgType, pushPullMsg)
+	}
+	header, remoteNodes, userState, err := m.readRemoteState(bufConn, dec)
+	if err != nil {
+		return err
+	}
+	m.debugMessage(true, conn.RemoteAddr().String(), pushPullMsg, remoteNodes)
+
+	metrics.IncrCounterWithLabels([]string{"memberlist", "pushpull", "delta", "received"}, float32(len(remoteNodes)), m.metricLabels)
+	return m.mergeRemoteState(header.Join, remoteNodes, userState)
+}
+
+// sendDigest sends a delta push/pull message over a stream connection.
+func (m *Memberlist) sendDigest(conn net.Conn, msg *pushPullDigest, streamLabel string) error {
+	if err := conn.SetDeadline(time.Now().Add(m.config.TCPTimeout)); err != nil {
+		m.logger.Printf("Err: Could not set the deadline: %s", err)
+	}
+
+	bufConn := bytes.NewBuffer(nil)
+	if _, err := bufConn.Write([]byte{byte(pushPullDigestMsg)}); err != nil {
+		return err
+	}
+	hd := codec.MsgpackHandle{}
+	enc := codec.NewEncoder(bufConn, &hd)
+	if err := enc.Encode(msg); err != nil {
+		return err
+	}
+	m.debugMessage(false, conn.RemoteAddr().String(), pushPullDigestMsg, msg)
+	return m.rawSendMsgStream(conn, bufConn.Bytes(), streamLabel)
+}
+
+// namesSet turns a list of names into a set. The set is never nil, so an
+// empty list selects nothing.
+func namesSet(names []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		set[name] = struct{}{}
+	}
+	return set
+}
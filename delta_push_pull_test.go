@@ -0,0 +1,146 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	iretry "github.com/hashicorp/memberlist/internal/retry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberlist_DiffDigest(t *testing.T) {
+	m := GetMemberlist(t, nil)
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	for _, a := range []alive{
+		{Node: "same", Addr: []byte{127, 0, 0, 1}, Incarnation: 1},
+		{Node: "newer-here", Addr: []byte{127, 0, 0, 2}, Incarnation: 5},
+		{Node: "newer-there", Addr: []byte{127, 0, 0, 3}, Incarnation: 1},
+		{Node: "only-here", Addr: []byte{127, 0, 0, 4}, Incarnation: 1},
+		{Node: "other-state", Addr: []byte{127, 0, 0, 5}, Incarnation: 1},
+	} {
+		a.Vsn = m.config.BuildVsnArray()
+		m.aliveNode(&a, nil, false)
+	}
+
+	digest := m.localDigest(nil)
+	for i := range digest {
+		switch digest[i].Name {
+		case "newer-here":
+			digest[i].Incarnation = 4
+		case "newer-there":
+			digest[i].Incarnation = 2
+		case "other-state":
+			digest[i].State = StateSuspect
+		}
+	}
+	digest = append(digest, nodeDigest{Name: "only-there", Incarnation: 1})
+	for i := range digest {
+		if digest[i].Name == "only-here" {
+			digest = append(digest[:i], digest[i+1:]...)
+			break
+		}
+	}
+
+	send, want := m.diffDigest(digest, nil)
+
+	var sent []string
+	for _, n := range send {
+		sent = append(sent, n.Name)
+	}
+	sort.Strings(sent)
+	sort.Strings(want)
+	require.Equal(t, []string{"newer-here", "only-here", "other-state"}, sent)
+	require.Equal(t, []string{"newer-there", "only-there", "other-state"}, want)
+
+	// Nothing to exchange when the views agree.
+	send, want = m.diffDigest(m.localDigest(nil), nil)
+	require.Empty(t, send)
+	require.Empty(t, want)
+}
+
+func TestMemberlist_DeltaPushPull(t *testing.T) {
+	d1 := &MockDelegate{}
+	d1.setState([]byte("state one"))
+	c1 := testConfig(t)
+	c1.GossipInterval = 10 * time.Second
+	c1.DeltaPushPull = true
+	c1.Delegate = d1
+	m1, err := Create(c1)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m1.Shutdown())
+	}()
+
+	d2 := &MockDelegate{}
+	d2.setState([]byte("state two"))
+	c2 := testConfig(t)
+	c2.BindPort = m1.config.BindPort
+	c2.GossipInterval = 10 * time.Second
+	c2.DeltaPushPull = true
+	c2.Delegate = d2
+	m2, err := Create(c2)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m2.Shutdown())
+	}()
+
+	_, err = m2.Join([]string{m1.config.Name + "/" + m1.config.BindAddr})
+	require.NoError(t, err)
+
+	// Each side learns about a node the other doesn't know of, without
+	// gossiping it.
+	a := alive{Node: "x", Addr: []byte{127, 0, 0, 1}, Port: 7946, Incarnation: 1, Vsn: m1.config.BuildVsnArray()}
+	m1.aliveNode(&a, nil, false)
+	m1.broadcasts.Reset()
+	a = alive{Node: "y", Addr: []byte{127, 0, 0, 1}, Port: 7947, Incarnation: 1, Vsn: m2.config.BuildVsnArray()}
+	m2.aliveNode(&a, nil, false)
+	m2.broadcasts.Reset()
+
+	m2Addr := Address{Addr: m2.LocalNode().Address(), Name: m2.config.Name}
+	require.True(t, m1.canDeltaPushPull(m2Addr))
+	require.NoError(t, m1.deltaPushPull(m2Addr, nil))
+
+	require.Equal(t, StateAlive, m1.getNodeState("y"))
+	require.Equal(t, []byte("state two"), d1.getRemoteState())
+
+	// The responder merges after replying, so give it a moment.
+	iretry.Run(t, func(r *iretry.R) {
+		m2.nodeLock.RLock()
+		x, ok := m2.nodeMap["x"]
+		alive := ok && x.State == StateAlive
+		m2.nodeLock.RUnlock()
+		if !alive {
+			r.Fatal("expected x to be alive")
+		}
+		if string(d2.getRemoteState()) != "state one" {
+			r.Fatal("expected remote user state")
+		}
+	})
+}
+
+func TestMemberlist_DeltaPushPull_Disabled(t *testing.T) {
+	m, err := Create(testConfig(t))
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	a := Address{Addr: m.LocalNode().Address(), Name: m.config.Name}
+	require.False(t, m.canDeltaPushPull(a))
+
+	m.config.DeltaPushPull = true
+	require.True(t, m.canDeltaPushPull(a))
+
+	// Older members get a complete sync.
+	m.changeNode(m.config.Name, func(state *nodeState) {
+		state.PMax = 5
+	})
+	require.False(t, m.canDeltaPushPull(a))
+}
@@ -0,0 +1,217 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// probeHistorySize is how many probe results are kept for each node.
+const probeHistorySize = 16
+
+// rttSmoothing is the weight given to each new RTT sample in a node's
+// moving average, the same as TCP uses for its smoothed RTT.
+const rttSmoothing = 0.125
+
+// ProbeResult is the outcome of one of our probes of a node.
+type ProbeResult struct {
+	// Time is when the probe started.
+	Time time.Time
+
+	// Acked is true if the node answered, directly, through another member
+	// or over TCP.
+	Acked bool
+
+	// RTT is the round trip time of the direct ping. It's zero unless the
+	// node answered that ping.
+	RTT time.Duration
+
+	// Indirect is the number of other members asked to probe the node
+	// after the direct ping went unanswered.
+	Indirect int
+
+	// TCPFallback is true if the node only answered over TCP.
+	TCPFallback bool
+
+	// Awareness is our own health score at the time of the probe. A failed
+	// probe while it's high says more about us than about the node.
+	Awareness int
+}
+
+// NodeDiagnostics is what we know about how well we can reach a node.
+type NodeDiagnostics struct {
+	Node        Node
+	State       NodeStateType
+	Incarnation uint32
+	StateChange time.Time
+
+	// Health is the health score the node last reported in an ack.
+	Health int
+
+	// Probes are our most recent probes of the node, oldest first.
+	Probes []ProbeResult
+}
+
+// Acked returns the number of probes the node answered.
+func (d *NodeDiagnostics) Acked() int {
+	acked := 0
+	for _, p := range d.Probes {
+		if p.Acked {
+			acked++
+		}
+	}
+	return acked
+}
+
+// MeanRTT returns the mean round trip time of the answered direct pings, or
+// zero if there aren't any.
+func (d *NodeDiagnostics) MeanRTT() time.Duration {
+	var sum time.Duration
+	n := 0
+	for _, p := range d.Probes {
+		if p.RTT > 0 {
+			sum += p.RTT
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / time.Duration(n)
+}
+
+// NodeDiagnostics returns what we know about how well we can reach the
+// named node, including our recent probes of it.
+func (m *Memberlist) NodeDiagnostics(name string) (NodeDiagnostics, error) {
+	m.nodeLock.RLock()
+	defer m.nodeLock.RUnlock()
+
+	n, ok := m.nodeMap[name]
+	if !ok {
+		return NodeDiagnostics{}, fmt.Errorf("unknown node %q", name)
+	}
+	d := NodeDiagnostics{
+		Node:        n.Node,
+		State:       n.State,
+		Incarnation: n.Incarnation,
+		StateChange: n.StateChange,
+		Health:      n.health,
+	}
+	d.Node.State = n.State
+	if n.probes != nil {
+		d.Probes = n.probes.list()
+	}
+	return d, nil
+}
+
+// Reachability sums up our probes of a node, see Memberlist.Reachability.
+type Reachability struct {
+	Node  string
+	State NodeStateType
+
+	// LastAck is when the last probe the node answered was started, or the
+	// zero time if it hasn't answered one.
+	LastAck time.Time
+
+	// RTT is an exponentially weighted moving average of the round trip
+	// times of the direct pings the node answered.
+	RTT time.Duration
+
+	// FailureStreak is how many probes in a row the node hasn't answered.
+	FailureStreak int
+}
+
+// Reachability returns how well we can reach each of the other members we
+// know about, dead ones included, sorted by name. Members we haven't probed
+// yet are reported with zero values. Combined across the cluster this gives
+// a matrix of which members can reach which.
+func (m *Memberlist) Reachability() []Reachability {
+	m.nodeLock.RLock()
+	defer m.nodeLock.RUnlock()
+
+	out := make([]Reachability, 0, len(m.nodes))
+	for _, n := range m.nodes {
+		if n.Name == m.config.Name {
+			continue
+		}
+		r := Reachability{Node: n.Name, State: n.State}
+		if n.probes != nil {
+			r.LastAck = n.probes.lastAck
+			r.RTT = n.probes.rtt
+			r.FailureStreak = n.probes.streak
+		}
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Node < out[j].Node })
+	return out
+}
+
+// probeHistory is a ring of a node's most recent probe results, along with
+// totals kept over all of them.
+type probeHistory struct {
+	results [probeHistorySize]ProbeResult
+	next    int
+	full    bool
+
+	lastAck time.Time     // Start of the last probe that was acked
+	rtt     time.Duration // Moving average of the direct ping RTTs
+	streak  int           // Probes in a row that weren't acked
+}
+
+func (h *probeHistory) add(r ProbeResult) {
+	h.results[h.next] = r
+	h.next = (h.next + 1) % probeHistorySize
+	if h.next == 0 {
+		h.full = true
+	}
+
+	if !r.Acked {
+		h.streak++
+		return
+	}
+	h.streak = 0
+	h.lastAck = r.Time
+	switch {
+	case r.RTT <= 0:
+	case h.rtt == 0:
+		h.rtt = r.RTT
+	default:
+		h.rtt += time.Duration(rttSmoothing * float64(r.RTT-h.rtt))
+	}
+}
+
+// list returns the results, oldest first.
+func (h *probeHistory) list() []ProbeResult {
+	if !h.full {
+		return append([]ProbeResult(nil), h.results[:h.next]...)
+	}
+	out := make([]ProbeResult, 0, probeHistorySize)
+	out = append(out, h.results[h.next:]...)
+	return append(out, h.results[:h.next]...)
+}
+
+// recordProbe adds a finished probe to the node's history.
+func (m *Memberlist) recordProbe(t ProbeTrace) {
+	r := ProbeResult{
+		Time:        t.Start,
+		Acked:       t.Acked,
+		RTT:         t.RTT,
+		Indirect:    t.Indirect,
+		TCPFallback: t.TCPFallback,
+		Awareness:   m.awareness.GetHealthScore(),
+	}
+
+	m.nodeLock.Lock()
+	defer m.nodeLock.Unlock()
+	n, ok := m.nodeMap[t.Node]
+	if !ok {
+		return
+	}
+	if n.probes == nil {
+		n.probes = new(probeHistory)
+	}
+	n.probes.add(r)
+}
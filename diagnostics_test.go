@@ -0,0 +1,113 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberlist_NodeDiagnostics(t *testing.T) {
+	addr1 := getBindAddr()
+	addr2 := getBindAddr()
+	addr3 := getBindAddr()
+
+	rec := &probeRecorder{}
+	m1 := HostMemberlist(addr1.String(), t, func(c *Config) {
+		c.ProbeTimeout = 10 * time.Millisecond
+		c.ProbeInterval = 50 * time.Millisecond
+		c.ProbeObserver = rec
+	})
+	defer m1.Shutdown()
+
+	bindPort := m1.config.BindPort
+	m2 := HostMemberlist(addr2.String(), t, func(c *Config) {
+		c.BindPort = bindPort
+	})
+	defer m2.Shutdown()
+
+	m1.aliveNode(&alive{Node: addr1.String(), Addr: []byte(addr1), Port: uint16(bindPort), Incarnation: 1}, nil, true)
+	m1.aliveNode(&alive{Node: addr2.String(), Addr: []byte(addr2), Port: uint16(bindPort), Incarnation: 1}, nil, false)
+	m1.aliveNode(&alive{Node: addr3.String(), Addr: []byte(addr3), Port: uint16(bindPort), Incarnation: 1}, nil, false)
+
+	m1.probeNode(m1.nodeMap[addr2.String()])
+	m1.probeNode(m1.nodeMap[addr3.String()])
+
+	d, err := m1.NodeDiagnostics(addr2.String())
+	require.NoError(t, err)
+	d2 := d
+	require.Equal(t, StateAlive, d.State)
+	require.Len(t, d.Probes, 1)
+	require.True(t, d.Probes[0].Acked)
+	require.Positive(t, d.Probes[0].RTT)
+	require.Equal(t, 1, d.Acked())
+	require.Equal(t, d.Probes[0].RTT, d.MeanRTT())
+
+	// Nothing answers for the third node
+	d, err = m1.NodeDiagnostics(addr3.String())
+	require.NoError(t, err)
+	require.Len(t, d.Probes, 1)
+	require.False(t, d.Probes[0].Acked)
+	// Picking the helper is best effort, so it may have been skipped.
+	var helpers int
+	for _, o := range rec.get() {
+		if o.Node == addr3.String() && o.Method == ProbeIndirect {
+			helpers = len(o.Via)
+		}
+	}
+	require.LessOrEqual(t, helpers, 1)
+	require.Equal(t, helpers, d.Probes[0].Indirect)
+	require.Zero(t, d.MeanRTT())
+
+	_, err = m1.NodeDiagnostics("nope")
+	require.Error(t, err)
+
+	m1.probeNode(m1.nodeMap[addr3.String()])
+	reach := m1.Reachability()
+	require.Len(t, reach, 2)
+	require.Equal(t, addr2.String(), reach[0].Node)
+	require.Equal(t, d2.Probes[0].Time, reach[0].LastAck)
+	require.Equal(t, d2.Probes[0].RTT, reach[0].RTT)
+	require.Zero(t, reach[0].FailureStreak)
+	require.Equal(t, addr3.String(), reach[1].Node)
+	require.True(t, reach[1].LastAck.IsZero())
+	require.Equal(t, 2, reach[1].FailureStreak)
+}
+
+func TestProbeHistory_Wraps(t *testing.T) {
+	var h probeHistory
+	require.Empty(t, h.list())
+
+	base := time.Now()
+	for i := 0; i < probeHistorySize+3; i++ {
+		h.add(ProbeResult{Time: base.Add(time.Duration(i) * time.Second)})
+	}
+	list := h.list()
+	require.Len(t, list, probeHistorySize)
+	require.Equal(t, base.Add(3*time.Second), list[0].Time)
+	require.Equal(t, base.Add(time.Duration(probeHistorySize+2)*time.Second), list[len(list)-1].Time)
+}
+
+func TestProbeHistory_Reachability(t *testing.T) {
+	var h probeHistory
+	base := time.Now()
+	h.add(ProbeResult{Time: base, Acked: true, RTT: 80 * time.Millisecond})
+	require.Equal(t, 80*time.Millisecond, h.rtt)
+
+	// Acks over TCP or through others don't have an RTT.
+	h.add(ProbeResult{Time: base.Add(time.Second), Acked: true})
+	require.Equal(t, 80*time.Millisecond, h.rtt)
+	h.add(ProbeResult{Time: base.Add(2 * time.Second), Acked: true, RTT: 160 * time.Millisecond})
+	require.Equal(t, 90*time.Millisecond, h.rtt)
+
+	h.add(ProbeResult{Time: base.Add(3 * time.Second)})
+	h.add(ProbeResult{Time: base.Add(4 * time.Second)})
+	require.Equal(t, 2, h.streak)
+	require.Equal(t, base.Add(2*time.Second), h.lastAck)
+
+	h.add(ProbeResult{Time: base.Add(5 * time.Second), Acked: true})
+	require.Zero(t, h.streak)
+}
@@ -0,0 +1,187 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// dialStream opens a stream to a node. If the node advertises several
+// addresses and the caller is after its usual one, they're raced against
+// each other, and whichever connects first becomes the node's address from
+// then on.
+func (m *Memberlist) dialStream(a Address, timeout time.Duration) (net.Conn, error) {
+	packet, stream := m.streamCandidates(a)
+	if len(stream) < 2 {
+		return m.transport.DialAddressTimeout(a, timeout)
+	}
+
+	conn, i, err := m.dialRace(a.Name, stream, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if i != 0 {
+		m.setActiveAddr(a.Name, packet[i])
+	}
+	return conn, nil
+}
+
+// streamCandidates returns the addresses worth racing to reach a node over a
+// stream, current one first, along with the matching packet addresses. It
+// returns nothing if racing is turned off, the node isn't known, or the
+// caller asked for some other address than the node's current one.
+func (m *Memberlist) streamCandidates(a Address) (packet, stream []string) {
+	if m.config.DialRaceDelay <= 0 || a.Name == "" {
+		return nil, nil
+	}
+
+	m.nodeLock.RLock()
+	defer m.nodeLock.RUnlock()
+	state, ok := m.nodeMap[a.Name]
+	if !ok || len(state.Addrs) == 0 || state.StreamAddress().Addr != a.Addr {
+		return nil, nil
+	}
+
+	current := state.Address()
+	packet = []string{current}
+	for _, addr := range state.Addresses() {
+		if addr != current {
+			packet = append(packet, addr)
+		}
+	}
+	packet = interleaveFamilies(packet)
+
+	stream = make([]string, len(packet))
+	for i, addr := range packet {
+		stream[i] = addr
+		if state.StreamPort == 0 {
+			continue
+		}
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			stream[i] = joinHostPort(host, state.StreamPort)
+		}
+	}
+	return packet, stream
+}
+
+// dialResult is the outcome of one of the attempts in a dialRace.
+type dialResult struct {
+	index int
+	conn  net.Conn
+	err   error
+}
+
+// dialRace opens a stream to whichever of the addresses connects first, as
+// described in RFC 8305. Attempts start in order, each one DialRaceDelay
+// after the last or as soon as the last one fails, and all of them share
+// the timeout. The first connection wins and any others that come in after
+// it are closed. It returns the index of the address that won.
+func (m *Memberlist) dialRace(name string, addrs []string, timeout time.Duration) (net.Conn, int, error) {
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+
+	results := make(chan dialResult, len(addrs))
+	next, pending := 0, 0
+	start := func() {
+		i := next
+		next++
+		pending++
+
+		var remaining time.Duration
+		if !deadline.IsZero() {
+			// Don't let a zero or negative remainder mean no timeout.
+			remaining = max(time.Until(deadline), time.Millisecond)
+		}
+		go func() {
+			conn, err := m.transport.DialAddressTimeout(Address{Addr: addrs[i], Name: name}, remaining)
+			results <- dialResult{index: i, conn: conn, err: err}
+		}()
+	}
+
+	delay := time.NewTimer(m.config.DialRaceDelay)
+	defer delay.Stop()
+
+	var errs error
+	start()
+	for pending > 0 {
+		select {
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				go closeDialResults(results, pending)
+				return r.conn, r.index, nil
+			}
+			errs = multierror.Append(errs, fmt.Errorf("%s: %v", addrs[r.index], r.err))
+			if next < len(addrs) {
+				start()
+				delay.Reset(m.config.DialRaceDelay)
+			}
+
+		case <-delay.C:
+			if next < len(addrs) {
+				start()
+				delay.Reset(m.config.DialRaceDelay)
+			}
+		}
+	}
+	return nil, 0, errs
+}
+
+// closeDialResults closes the connections from attempts that lost a race.
+func closeDialResults(results <-chan dialResult, pending int) {
+	for ; pending > 0; pending-- {
+		if r := <-results; r.conn != nil {
+			_ = r.conn.Close()
+		}
+	}
+}
+
+// interleaveFamilies reorders host:port addresses so IPv6 and IPv4 ones
+// alternate, starting with the family of the first, as RFC 8305 suggests.
+// Addresses that aren't IPs keep their place in the IPv4 list.
+func interleaveFamilies(addrs []string) []string {
+	if len(addrs) < 2 {
+		return addrs
+	}
+
+	var v6, v4 []string
+	for _, addr := range addrs {
+		if isIPv6Addr(addr) {
+			v6 = append(v6, addr)
+		} else {
+			v4 = append(v4, addr)
+		}
+	}
+	first, second := v4, v6
+	if isIPv6Addr(addrs[0]) {
+		first, second = v6, v4
+	}
+
+	out := make([]string, 0, len(addrs))
+	for i := 0; i < len(first) || i < len(second); i++ {
+		if i < len(first) {
+			out = append(out, first[i])
+		}
+		if i < len(second) {
+			out = append(out, second[i])
+		}
+	}
+	return out
+}
+
+// isIPv6Addr returns true if the host:port address has an IPv6 host.
+func isIPv6Addr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.To4() == nil
+}
@@ -0,0 +1,114 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// blackholeTransport never connects to the given address, as though it
+// were silently dropping packets.
+type blackholeTransport struct {
+	NodeAwareTransport
+	dead string
+	done chan struct{}
+}
+
+func (t *blackholeTransport) DialAddressTimeout(a Address, timeout time.Duration) (net.Conn, error) {
+	if a.Addr != t.dead {
+		return t.NodeAwareTransport.DialAddressTimeout(a, timeout)
+	}
+	select {
+	case <-time.After(timeout):
+	case <-t.done:
+	}
+	return nil, errors.New("i/o timeout")
+}
+
+func TestMemberlist_DialRace(t *testing.T) {
+	const dead = "127.0.0.1:1"
+	tr := &blackholeTransport{dead: dead, done: make(chan struct{})}
+	m1 := GetMemberlist(t, func(c *Config) {
+		c.TCPTimeout = 5 * time.Second
+		c.DialRaceDelay = 20 * time.Millisecond
+
+		nt, err := NewNetTransport(&NetTransportConfig{
+			BindAddrs: []string{c.BindAddr},
+			Logger:    c.Logger,
+		})
+		require.NoError(t, err)
+		tr.NodeAwareTransport = nt
+		c.Transport = tr
+		c.BindPort = nt.GetAutoBindPort()
+	})
+	defer func() {
+		require.NoError(t, m1.Shutdown())
+	}()
+	defer close(tr.done)
+	require.NoError(t, m1.setAlive())
+	m2 := GetMemberlist(t, nil)
+	defer func() {
+		require.NoError(t, m2.Shutdown())
+	}()
+	require.NoError(t, m2.setAlive())
+
+	// The address we've been using for m2 has gone dark, but the primary
+	// one still works.
+	self := m2.LocalNode()
+	a := alive{
+		Node:        self.Name,
+		Addr:        self.Addr,
+		Port:        self.Port,
+		Addrs:       []string{dead},
+		Incarnation: 1,
+		Vsn:         m1.config.BuildVsnArray(),
+	}
+	m1.aliveNode(&a, nil, false)
+	m1.setActiveAddr(self.Name, dead)
+
+	start := time.Now()
+	require.NoError(t, m1.PushPullNode(self.Name))
+	require.Less(t, time.Since(start), m1.config.TCPTimeout)
+
+	// The winner is remembered.
+	m1.nodeLock.RLock()
+	addr := m1.nodeMap[self.Name].Address()
+	m1.nodeLock.RUnlock()
+	require.Equal(t, self.Address(), addr)
+
+	// With racing off only the current address is tried, which fails.
+	m1.setActiveAddr(self.Name, dead)
+	m1.config.DialRaceDelay = 0
+	m1.config.TCPTimeout = 50 * time.Millisecond
+	require.Error(t, m1.PushPullNode(self.Name))
+}
+
+func TestInterleaveFamilies(t *testing.T) {
+	addrs := []string{
+		"[fd00::1]:7946",
+		"[fd00::2]:7946",
+		"[fd00::3]:7946",
+		"10.0.0.1:7946",
+		"10.0.0.2:7946",
+	}
+	require.Equal(t, []string{
+		"[fd00::1]:7946",
+		"10.0.0.1:7946",
+		"[fd00::2]:7946",
+		"10.0.0.2:7946",
+		"[fd00::3]:7946",
+	}, interleaveFamilies(addrs))
+
+	// It starts with whatever family comes first.
+	require.Equal(t, []string{
+		"10.0.0.1:7946",
+		"[fd00::1]:7946",
+		"10.0.0.2:7946",
+	}, interleaveFamilies([]string{"10.0.0.1:7946", "10.0.0.2:7946", "[fd00::1]:7946"}))
+}
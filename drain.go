@@ -0,0 +1,131 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"fmt"
+	"time"
+)
+
+// DrainingTransport is an optional interface for a Transport that can stop
+// accepting new stream connections without tearing anything else down. If
+// the configured Transport implements it, Shutdown calls StopAccepting
+// first and gives the streams already in flight a chance to finish before
+// calling Shutdown on the transport.
+type DrainingTransport interface {
+	Transport
+
+	// StopAccepting closes the stream listeners. Packets must keep
+	// flowing, and streams that have already been accepted must keep
+	// working until Shutdown.
+	StopAccepting() error
+}
+
+// See DrainingTransport.
+func (t *shimNodeAwareTransport) StopAccepting() error {
+	if d, ok := t.Transport.(DrainingTransport); ok {
+		return d.StopAccepting()
+	}
+	return nil
+}
+
+// See DrainingTransport.
+func (t *labelWrappedTransport) StopAccepting() error {
+	if d, ok := t.NodeAwareTransport.(DrainingTransport); ok {
+		return d.StopAccepting()
+	}
+	return nil
+}
+
+// beginStream records a stream session, inbound or outbound, as in flight.
+// Every call must be matched by a call to endStream.
+func (m *Memberlist) beginStream() {
+	m.streamLock.Lock()
+	defer m.streamLock.Unlock()
+	m.streams++
+}
+
+// endStream records that a stream session has finished, letting a
+// draining Shutdown know once the last one is done.
+func (m *Memberlist) endStream() {
+	m.streamLock.Lock()
+	defer m.streamLock.Unlock()
+	m.streams--
+	if m.streams == 0 && m.drainedCh != nil {
+		close(m.drainedCh)
+		m.drainedCh = nil
+	}
+}
+
+// drainStreams stops the transport accepting new stream connections and
+// waits up to the timeout for the stream sessions in flight to finish. It
+// returns an error if the transport couldn't stop accepting or the timeout
+// ran out first.
+func (m *Memberlist) drainStreams(timeout time.Duration) error {
+	if d, ok := m.transport.(DrainingTransport); ok {
+		if err := d.StopAccepting(); err != nil {
+			return fmt.Errorf("failed to stop accepting streams: %v", err)
+		}
+	}
+
+	m.streamLock.Lock()
+	if m.streams == 0 {
+		m.streamLock.Unlock()
+		return nil
+	}
+	drainedCh := make(chan struct{})
+	m.drainedCh = drainedCh
+	m.streamLock.Unlock()
+
+	select {
+	case <-drainedCh:
+		return nil
+	case <-time.After(timeout):
+	}
+
+	m.streamLock.Lock()
+	defer m.streamLock.Unlock()
+	m.drainedCh = nil
+	if m.streams == 0 {
+		return nil
+	}
+	return fmt.Errorf("timed out after %s with %d stream(s) still in flight", timeout, m.streams)
+}
+
+// Drain marks the local node as draining and gossips it with a new alive
+// message, the same way as an UpdateNode, without waiting for it to go out.
+// Other members see Node.Draining set, and get a NotifyUpdate for it, so
+// anything routing work to the node can stop before it leaves. It has no
+// effect on the membership itself; the node stays alive until it leaves or
+// Undrain is called.
+func (m *Memberlist) Drain() error {
+	err := m.setDraining(true)
+	m.audit(AuditDrain, "", err)
+	return err
+}
+
+// Undrain takes back a Drain.
+func (m *Memberlist) Undrain() error {
+	err := m.setDraining(false)
+	m.audit(AuditUndrain, "", err)
+	return err
+}
+
+func (m *Memberlist) setDraining(draining bool) error {
+	if m.hasShutdown() {
+		return ErrAlreadyShutdown
+	}
+	if m.draining.Swap(draining) == draining {
+		return nil
+	}
+
+	m.nodeLock.RLock()
+	_, ok := m.nodeMap[m.config.Name]
+	m.nodeLock.RUnlock()
+	if !ok {
+		return nil
+	}
+	m.updateNode(nil)
+	return nil
+}
@@ -0,0 +1,163 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberlist_ShutdownDrainsStreams(t *testing.T) {
+	m := GetMemberlist(t, func(c *Config) {
+		c.TCPTimeout = 10 * time.Second
+		c.ShutdownTimeout = 5 * time.Second
+	})
+	require.NoError(t, m.setAlive())
+	addr := m.LocalNode().Address()
+
+	// A stream that's in flight when we shut down.
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.Eventually(t, func() bool {
+		m.streamLock.Lock()
+		defer m.streamLock.Unlock()
+		return m.streams == 1
+	}, time.Second, 10*time.Millisecond)
+
+	done := make(chan error, 1)
+	start := time.Now()
+	go func() {
+		done <- m.Shutdown()
+	}()
+
+	// New streams are refused while it drains.
+	require.Eventually(t, func() bool {
+		c, err := net.DialTimeout("tcp", addr, 100*time.Millisecond)
+		if err == nil {
+			c.Close()
+		}
+		return err != nil
+	}, time.Second, 10*time.Millisecond)
+	select {
+	case err := <-done:
+		t.Fatalf("shutdown shouldn't have finished yet: %v", err)
+	default:
+	}
+
+	// Once the stream finishes, so does the shutdown.
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, conn.Close())
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("shutdown should have finished")
+	}
+}
+
+func TestMemberlist_ShutdownDrainTimeout(t *testing.T) {
+	m := GetMemberlist(t, func(c *Config) {
+		c.TCPTimeout = 10 * time.Second
+		c.ShutdownTimeout = 100 * time.Millisecond
+	})
+	require.NoError(t, m.setAlive())
+
+	conn, err := net.Dial("tcp", m.LocalNode().Address())
+	require.NoError(t, err)
+	defer conn.Close()
+	require.Eventually(t, func() bool {
+		m.streamLock.Lock()
+		defer m.streamLock.Unlock()
+		return m.streams == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// The stalled stream doesn't hold up shutdown past the timeout.
+	start := time.Now()
+	err = m.Shutdown()
+	require.ErrorContains(t, err, "1 stream(s) still in flight")
+	require.Less(t, time.Since(start), 5*time.Second)
+	require.True(t, m.hasShutdown())
+
+	// It's only reported once.
+	require.NoError(t, m.Shutdown())
+}
+
+func TestMemberlist_ShutdownDoesntWaitByDefault(t *testing.T) {
+	m := GetMemberlist(t, func(c *Config) {
+		c.TCPTimeout = 10 * time.Second
+	})
+	require.Zero(t, m.config.ShutdownTimeout)
+	require.NoError(t, m.setAlive())
+
+	conn, err := net.Dial("tcp", m.LocalNode().Address())
+	require.NoError(t, err)
+	defer conn.Close()
+	require.Eventually(t, func() bool {
+		m.streamLock.Lock()
+		defer m.streamLock.Unlock()
+		return m.streams == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// The stream in flight is cut off, as it always has been.
+	start := time.Now()
+	require.NoError(t, m.Shutdown())
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestMemberlist_Drain(t *testing.T) {
+	m1 := GetMemberlist(t, nil)
+	defer func() {
+		require.NoError(t, m1.Shutdown())
+	}()
+	require.NoError(t, m1.setAlive())
+	m1.schedule()
+
+	events := make(chan NodeEvent, 16)
+	m2 := GetMemberlist(t, func(c *Config) {
+		c.Events = &ChannelEventDelegate{Ch: events}
+	})
+	defer func() {
+		require.NoError(t, m2.Shutdown())
+	}()
+	require.NoError(t, m2.setAlive())
+	m2.schedule()
+	_, err := m2.Join([]string{m1.config.Name + "/" + m1.LocalNode().Address()})
+	require.NoError(t, err)
+
+	drainingOf := func(m *Memberlist) bool {
+		m.nodeLock.RLock()
+		defer m.nodeLock.RUnlock()
+		return m.nodeMap[m1.config.Name].Draining
+	}
+	waitUpdate := func() {
+		for {
+			select {
+			case e := <-events:
+				if e.Event == NodeUpdate && e.Node.Name == m1.config.Name {
+					return
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for an update")
+			}
+		}
+	}
+
+	require.NoError(t, m1.Drain())
+	require.True(t, m1.LocalNode().Draining)
+	waitUpdate()
+	require.True(t, drainingOf(m2))
+	require.Len(t, m2.Members(), 2)
+	require.Len(t, m2.HealthyMembers(), 1)
+
+	require.NoError(t, m1.Undrain())
+	require.False(t, m1.LocalNode().Draining)
+	waitUpdate()
+	require.False(t, drainingOf(m2))
+	require.Len(t, m2.HealthyMembers(), 2)
+}
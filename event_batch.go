@@ -0,0 +1,106 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import "time"
+
+// handoffEventRound is how many queued messages a packet handler works
+// through before it ends a round of events and starts another, so a long
+// burst of gossip doesn't hold back the events it causes.
+const handoffEventRound = 64
+
+// EventBatchDelegate can optionally be implemented by an EventDelegate to be
+// told about the changes from each round of processing in a single call,
+// rather than one NotifyJoin, NotifyLeave or NotifyUpdate at a time. A round
+// is a burst of gossip drained from the incoming queue, the merge of a
+// push/pull, or a single change made any other way, such as a suspicion
+// timing out. An application that keeps an external system in step with the
+// cluster, such as DNS or a proxy, can then apply one update per round.
+//
+// When it's implemented the individual callbacks aren't called for joins,
+// leaves and updates, and a node coming back after it left is reported as a
+// NodeJoin rather than through NotifyRejoin. Rounds handled at the same time
+// by different goroutines share a batch.
+type EventBatchDelegate interface {
+	// NotifyBatch is invoked with the changes from a round, in the order
+	// they happened, so the same node can appear more than once. The nodes
+	// are copies the delegate can keep.
+	NotifyBatch(events []NodeEvent)
+}
+
+// batchDelegate returns the event delegate if it takes its events in
+// batches.
+func (m *Memberlist) batchDelegate() (EventBatchDelegate, bool) {
+	bd, ok := m.config.Events.(EventBatchDelegate)
+	return bd, ok
+}
+
+// notifyEvent records a change to a node in the journal and tells the event
+// delegate about it, or adds it to the batch for the current round. You must
+// hold the node lock.
+func (m *Memberlist) notifyEvent(typ NodeEventType, n *Node) {
+	m.journalEvent(typ, n)
+	if m.config.Events == nil {
+		return
+	}
+	if _, ok := m.batchDelegate(); !ok {
+		switch typ {
+		case NodeJoin:
+			m.guard("NotifyJoin", func() { m.config.Events.NotifyJoin(n) })
+		case NodeLeave:
+			m.guard("NotifyLeave", func() { m.config.Events.NotifyLeave(n) })
+		case NodeUpdate:
+			m.guard("NotifyUpdate", func() { m.config.Events.NotifyUpdate(n) })
+		}
+		return
+	}
+
+	node := *n
+	if len(m.eventBatch) == 0 {
+		m.eventBatchStart = time.Now()
+	}
+	m.eventBatch = append(m.eventBatch, NodeEvent{typ, &node})
+	if m.eventRounds == 0 {
+		m.flushEvents()
+	}
+}
+
+// beginEvents starts a round of processing whose events are batched. It must
+// be paired with endEvents.
+func (m *Memberlist) beginEvents() {
+	if _, ok := m.batchDelegate(); !ok {
+		return
+	}
+	m.nodeLock.Lock()
+	m.eventRounds++
+	m.nodeLock.Unlock()
+}
+
+// endEvents ends a round of processing, and hands the batch to the delegate
+// if no other round is under way. So that overlapping rounds can't hold the
+// batch back forever, it's also handed over once its first event is older
+// than a gossip interval.
+func (m *Memberlist) endEvents() {
+	if _, ok := m.batchDelegate(); !ok {
+		return
+	}
+	interval := m.Tuning().GossipInterval
+	m.nodeLock.Lock()
+	defer m.nodeLock.Unlock()
+	m.eventRounds--
+	if m.eventRounds == 0 || time.Since(m.eventBatchStart) >= interval {
+		m.flushEvents()
+	}
+}
+
+// flushEvents hands the batch to the delegate. You must hold the node lock.
+func (m *Memberlist) flushEvents() {
+	if len(m.eventBatch) == 0 {
+		return
+	}
+	batch := m.eventBatch
+	m.eventBatch = nil
+	bd, _ := m.batchDelegate()
+	m.guard("NotifyBatch", func() { bd.NotifyBatch(batch) })
+}
@@ -0,0 +1,77 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type batchEvents struct {
+	ChannelEventDelegate
+	batches chan []NodeEvent
+}
+
+func (b *batchEvents) NotifyBatch(events []NodeEvent) {
+	b.batches <- events
+}
+
+func TestMemberlist_EventBatch(t *testing.T) {
+	events := &batchEvents{
+		ChannelEventDelegate: ChannelEventDelegate{Ch: make(chan NodeEvent, 16)},
+		batches:              make(chan []NodeEvent, 16),
+	}
+	m := GetMemberlist(t, func(c *Config) {
+		c.Events = events
+	})
+	defer m.Shutdown()
+
+	// A push/pull merge is one round.
+	var remote []pushNodeState
+	for _, name := range []string{"a", "b", "c"} {
+		remote = append(remote, pushNodeState{
+			Name: name, Addr: []byte{127, 0, 0, 1}, Port: 7946,
+			Incarnation: 1, State: StateAlive, Vsn: m.config.BuildVsnArray(),
+		})
+	}
+	remote[2].Meta = []byte("meta")
+	m.mergeState(remote)
+	m.mergeState(remote[2:])
+
+	batch := <-events.batches
+	require.Len(t, batch, 3)
+	for i, e := range batch {
+		require.Equal(t, NodeJoin, e.Event)
+		require.Equal(t, remote[i].Name, e.Node.Name)
+	}
+	require.Equal(t, []byte("meta"), batch[2].Node.Meta)
+
+	// Nothing changed the second time, so there's no batch.
+	require.Empty(t, events.batches)
+
+	// A change outside a round is a batch of its own.
+	m.deadNode(&dead{Node: "b", From: "b", Incarnation: 1})
+	batch = <-events.batches
+	require.Len(t, batch, 1)
+	require.Equal(t, NodeLeave, batch[0].Event)
+	require.Equal(t, "b", batch[0].Node.Name)
+
+	// A round holds on to its events until it ends.
+	m.beginEvents()
+	m.aliveNode(&alive{Node: "c", Addr: []byte{127, 0, 0, 1}, Port: 7946, Incarnation: 2, Vsn: m.config.BuildVsnArray()}, nil, false)
+	m.aliveNode(&alive{Node: "b", Addr: []byte{127, 0, 0, 1}, Port: 7946, Incarnation: 2, Vsn: m.config.BuildVsnArray()}, nil, false)
+	require.Empty(t, events.batches)
+	m.endEvents()
+	batch = <-events.batches
+	require.Equal(t, []NodeEvent{
+		{NodeUpdate, batch[0].Node},
+		{NodeJoin, batch[1].Node},
+	}, batch)
+	require.Nil(t, batch[0].Node.Meta)
+	require.Equal(t, "b", batch[1].Node.Name)
+
+	// None of the individual callbacks were used.
+	require.Empty(t, events.Ch)
+}
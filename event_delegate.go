@@ -65,3 +65,13 @@ func (c *ChannelEventDelegate) NotifyUpdate(n *Node) {
 	node := *n
 	c.Ch <- NodeEvent{NodeUpdate, &node}
 }
+
+// FlapEventDelegate can optionally be implemented by an EventDelegate to
+// learn when a node is damped for flapping between alive and suspect, and
+// when it settles down again. See Config.FlapHalfLife.
+type FlapEventDelegate interface {
+	// NotifyFlapping is invoked when a node starts being damped, with
+	// damped set, and when it stops. The Node argument must not be
+	// modified.
+	NotifyFlapping(node *Node, damped bool)
+}
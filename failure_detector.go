@@ -0,0 +1,157 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// FailureDetector decides whether a node that failed a probe should be marked
+// as suspect. Once a node is suspect, the usual suspicion timeout decides
+// when it's confirmed dead. Leaving Config.FailureDetector unset suspects a
+// node as soon as a single probe fails.
+type FailureDetector interface {
+	// Heartbeat is called every time a probe of the node succeeds.
+	Heartbeat(node string, now time.Time)
+
+	// Suspect is called when a probe of the node fails, and returns true if
+	// the node should be marked as suspect.
+	Suspect(node string, now time.Time) bool
+
+	// Remove is called when a node is removed from the member list, so any
+	// state kept for it can be released.
+	Remove(node string)
+}
+
+// PhiAccrualDetector is a FailureDetector based on the phi accrual failure
+// detector by Hayashibara et al. Instead of a binary up/down verdict it
+// tracks the distribution of the intervals between successful probes of each
+// node, and computes phi, a continuous suspicion level, from how overdue the
+// node is. A failed probe only leads to suspicion once phi reaches the
+// threshold, so the sensitivity can be tuned smoothly to match the network.
+type PhiAccrualDetector struct {
+	// Threshold is the phi value at or above which a node is suspected. A
+	// threshold of 1 means a 10% chance of a mistake, 2 means 1%, 3 means
+	// 0.1% and so on.
+	Threshold float64
+
+	// MaxSampleSize is the number of intervals kept per node.
+	MaxSampleSize int
+
+	// MinStdDev is the lowest standard deviation used when computing phi.
+	// It keeps phi from climbing too fast when the intervals so far were
+	// very regular.
+	MinStdDev time.Duration
+
+	// AcceptablePause is added to the mean interval, allowing for a pause
+	// in heartbeats without raising phi, such as a garbage collection.
+	AcceptablePause time.Duration
+
+	mu      sync.Mutex
+	history map[string]*heartbeatHistory
+}
+
+// heartbeatHistory holds the recent heartbeat intervals for a single node.
+type heartbeatHistory struct {
+	last      time.Time
+	intervals []float64
+	next      int
+	sum       float64
+	sumSq     float64
+}
+
+// NewPhiAccrualDetector returns a phi accrual failure detector with the given
+// threshold and reasonable defaults for everything else.
+func NewPhiAccrualDetector(threshold float64) *PhiAccrualDetector {
+	return &PhiAccrualDetector{
+		Threshold:     threshold,
+		MaxSampleSize: 200,
+		MinStdDev:     100 * time.Millisecond,
+	}
+}
+
+// Heartbeat records a successful probe of the node. See FailureDetector.
+func (d *PhiAccrualDetector) Heartbeat(node string, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.history == nil {
+		d.history = make(map[string]*heartbeatHistory)
+	}
+	h, ok := d.history[node]
+	if !ok {
+		d.history[node] = &heartbeatHistory{last: now}
+		return
+	}
+
+	interval := float64(now.Sub(h.last))
+	h.last = now
+	if interval <= 0 {
+		return
+	}
+
+	max := d.MaxSampleSize
+	if max < 1 {
+		max = 1
+	}
+	if len(h.intervals) < max {
+		h.intervals = append(h.intervals, interval)
+	} else {
+		old := h.intervals[h.next]
+		h.sum -= old
+		h.sumSq -= old * old
+		h.intervals[h.next] = interval
+		h.next = (h.next + 1) % max
+	}
+	h.sum += interval
+	h.sumSq += interval * interval
+}
+
+// Suspect returns true if phi for the node has reached the threshold. Nodes
+// we don't have enough history for are always suspected, which matches the
+// behavior without a failure detector. See FailureDetector.
+func (d *PhiAccrualDetector) Suspect(node string, now time.Time) bool {
+	return d.Phi(node, now) >= d.Threshold
+}
+
+// Remove forgets everything about the node. See FailureDetector.
+func (d *PhiAccrualDetector) Remove(node string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.history, node)
+}
+
+// Phi returns the current suspicion level for the node. It returns +Inf for
+// nodes that haven't had at least two successful probes yet.
+func (d *PhiAccrualDetector) Phi(node string, now time.Time) float64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	h, ok := d.history[node]
+	if !ok || len(h.intervals) == 0 {
+		return math.Inf(1)
+	}
+
+	n := float64(len(h.intervals))
+	mean := h.sum / n
+	stdDev := math.Sqrt(math.Max(h.sumSq/n-mean*mean, 0))
+	stdDev = math.Max(stdDev, float64(d.MinStdDev))
+	mean += float64(d.AcceptablePause)
+
+	return phi(float64(now.Sub(h.last)), mean, stdDev)
+}
+
+// phi computes -log10 of the probability that a heartbeat arrives later than
+// elapsed, using a logistic approximation of the normal distribution's
+// cumulative distribution function.
+func phi(elapsed, mean, stdDev float64) float64 {
+	y := (elapsed - mean) / stdDev
+	e := math.Exp(-y * (1.5976 + 0.070566*y*y))
+	if elapsed > mean {
+		return -math.Log10(e / (1.0 + e))
+	}
+	return -math.Log10(1.0 - 1.0/(1.0+e))
+}
@@ -0,0 +1,66 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPhiAccrualDetector(t *testing.T) {
+	d := NewPhiAccrualDetector(8)
+
+	// Nothing known about the node means immediate suspicion.
+	now := time.Now()
+	require.True(t, math.IsInf(d.Phi("node", now), 1))
+	require.True(t, d.Suspect("node", now))
+
+	// Heartbeat once a second, with a little jitter.
+	for i := 0; i < 20; i++ {
+		now = now.Add(time.Second + time.Duration(i%3)*10*time.Millisecond)
+		d.Heartbeat("node", now)
+	}
+
+	// Right on time is fine.
+	require.Less(t, d.Phi("node", now.Add(time.Second)), 1.0)
+	require.False(t, d.Suspect("node", now.Add(time.Second)))
+
+	// Phi keeps growing the longer the node is overdue.
+	p1 := d.Phi("node", now.Add(1200*time.Millisecond))
+	p2 := d.Phi("node", now.Add(1500*time.Millisecond))
+	require.Greater(t, p2, p1)
+	require.True(t, d.Suspect("node", now.Add(3*time.Second)))
+
+	// An acceptable pause pushes suspicion out.
+	d.AcceptablePause = 5 * time.Second
+	require.False(t, d.Suspect("node", now.Add(3*time.Second)))
+
+	d.Remove("node")
+	require.True(t, math.IsInf(d.Phi("node", now), 1))
+}
+
+func TestPhiAccrualDetector_MaxSampleSize(t *testing.T) {
+	d := NewPhiAccrualDetector(8)
+	d.MaxSampleSize = 5
+
+	now := time.Now()
+	for i := 0; i < 20; i++ {
+		now = now.Add(10 * time.Second)
+		d.Heartbeat("node", now)
+	}
+
+	// Switch to a much faster rate, which should push the old samples out.
+	for i := 0; i < 5; i++ {
+		now = now.Add(time.Second)
+		d.Heartbeat("node", now)
+	}
+
+	h := d.history["node"]
+	require.Len(t, h.intervals, 5)
+	require.Equal(t, float64(5*time.Second), h.sum)
+	require.True(t, d.Suspect("node", now.Add(5*time.Second)))
+}
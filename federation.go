@@ -0,0 +1,252 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-msgpack/v2/codec"
+)
+
+// FederationConfig is used to configure a Federation.
+type FederationConfig struct {
+	// Site is the name of the local site, such as a datacenter, that the
+	// LAN cluster belongs to. It must be the same on all the gateways of a
+	// site and unique across sites.
+	Site string
+
+	// LAN is the local cluster whose members are summarized for the other
+	// sites.
+	LAN *Memberlist
+
+	// WAN is the configuration for the memberlist instance that gateways
+	// of all the sites form among themselves. Its Name must be unique
+	// across all gateways, and its Delegate must be left unset since the
+	// federation uses it to exchange summaries. Something like
+	// DefaultWANConfig is a good starting point. Summaries are exchanged
+	// during push/pull syncs, so PushPullInterval controls how quickly
+	// changes reach the other sites.
+	WAN *Config
+}
+
+// Federation links several LAN clusters, called sites, without stretching
+// a single cluster across the WAN. Designated gateway nodes in every site
+// create a Federation, which joins them into a separate WAN cluster. The
+// gateways periodically exchange a summary of their site's members over it,
+// so every gateway can answer questions about the membership of remote
+// sites, while failure detection within a site is never exposed to WAN
+// latency.
+type Federation struct {
+	site    string
+	gateway string
+	lan     *Memberlist
+	wan     *Memberlist
+
+	sitesLock sync.RWMutex
+	sites     map[string]*siteSummary
+}
+
+// siteSummary is the membership of a single site, as last reported by one
+// of its gateways.
+type siteSummary struct {
+	Site    string
+	Gateway string
+	Updated int64 // Unix nanoseconds, so we can keep the newest summary
+	Members []Node
+}
+
+// NewFederation creates the WAN memberlist for this gateway. Use Join to
+// connect it to the gateways of other sites.
+func NewFederation(conf *FederationConfig) (*Federation, error) {
+	if conf.Site == "" {
+		return nil, fmt.Errorf("a site name is required")
+	}
+	if conf.LAN == nil {
+		return nil, fmt.Errorf("a LAN memberlist is required")
+	}
+	if conf.WAN == nil {
+		return nil, fmt.Errorf("a WAN config is required")
+	}
+	if conf.WAN.Delegate != nil {
+		return nil, fmt.Errorf("the WAN config must not have a delegate")
+	}
+
+	wanConf := *conf.WAN
+	f := &Federation{
+		site:    conf.Site,
+		gateway: wanConf.Name,
+		lan:     conf.LAN,
+		sites:   make(map[string]*siteSummary),
+	}
+
+	wanConf.Delegate = &federationDelegate{f}
+	wan, err := Create(&wanConf)
+	if err != nil {
+		return nil, err
+	}
+	f.wan = wan
+	return f, nil
+}
+
+// Join joins the WAN cluster through the gateways of other sites. See
+// Memberlist.Join.
+func (f *Federation) Join(existing []string) (int, error) {
+	return f.wan.Join(existing)
+}
+
+// WAN returns the memberlist that the gateways use among themselves.
+func (f *Federation) WAN() *Memberlist {
+	return f.wan
+}
+
+// Sites returns the names of all the sites with at least one live gateway,
+// including the local one, in sorted order.
+func (f *Federation) Sites() []string {
+	seen := map[string]struct{}{f.site: {}}
+	for _, n := range f.wan.Members() {
+		if site := string(n.Meta); site != "" {
+			seen[site] = struct{}{}
+		}
+	}
+
+	sites := make([]string, 0, len(seen))
+	for site := range seen {
+		sites = append(sites, site)
+	}
+	sort.Strings(sites)
+	return sites
+}
+
+// SiteMembers returns the members of the given site. For the local site
+// this is the live view from the LAN memberlist, for remote ones it's the
+// latest summary received from their gateways. It returns nil for sites
+// without any live gateway.
+func (f *Federation) SiteMembers(site string) []*Node {
+	if site == f.site {
+		return f.lan.Members()
+	}
+
+	if !f.hasGateway(site) {
+		return nil
+	}
+
+	f.sitesLock.RLock()
+	defer f.sitesLock.RUnlock()
+
+	s, ok := f.sites[site]
+	if !ok {
+		return nil
+	}
+	nodes := make([]*Node, len(s.Members))
+	for i := range s.Members {
+		n := s.Members[i]
+		nodes[i] = &n
+	}
+	return nodes
+}
+
+// Leave leaves the WAN cluster. See Memberlist.Leave.
+func (f *Federation) Leave(timeout time.Duration) error {
+	return f.wan.Leave(timeout)
+}
+
+// Shutdown stops the WAN memberlist. The LAN one is left running.
+func (f *Federation) Shutdown() error {
+	return f.wan.Shutdown()
+}
+
+// hasGateway returns true if any live WAN member belongs to the site.
+func (f *Federation) hasGateway(site string) bool {
+	for _, n := range f.wan.Members() {
+		if string(n.Meta) == site {
+			return true
+		}
+	}
+	return false
+}
+
+// localSummary builds a summary of the local site from the LAN memberlist.
+func (f *Federation) localSummary() *siteSummary {
+	members := f.lan.Members()
+	s := &siteSummary{
+		Site:    f.site,
+		Gateway: f.gateway,
+		Updated: time.Now().UnixNano(),
+		Members: make([]Node, len(members)),
+	}
+	for i, n := range members {
+		s.Members[i] = *n
+	}
+	return s
+}
+
+// encodeSummaries returns our own summary along with all the remote ones we
+// know about, so they spread to gateways we aren't talking to directly.
+func (f *Federation) encodeSummaries() []byte {
+	summaries := []*siteSummary{f.localSummary()}
+
+	f.sitesLock.RLock()
+	for _, s := range f.sites {
+		summaries = append(summaries, s)
+	}
+	f.sitesLock.RUnlock()
+
+	var buf bytes.Buffer
+	hd := codec.MsgpackHandle{}
+	if err := codec.NewEncoder(&buf, &hd).Encode(summaries); err != nil {
+		f.lan.logger.Printf("[ERR] memberlist: Failed to encode site summaries: %v", err)
+		return nil
+	}
+	return buf.Bytes()
+}
+
+// mergeSummaries keeps the newest summary for every remote site.
+func (f *Federation) mergeSummaries(buf []byte) {
+	var summaries []*siteSummary
+	if err := decode(buf, &summaries); err != nil {
+		f.lan.logger.Printf("[ERR] memberlist: Failed to decode site summaries: %v", err)
+		return
+	}
+
+	f.sitesLock.Lock()
+	defer f.sitesLock.Unlock()
+	for _, s := range summaries {
+		if s == nil || s.Site == "" || s.Site == f.site {
+			continue
+		}
+		if old, ok := f.sites[s.Site]; ok && old.Updated >= s.Updated {
+			continue
+		}
+		f.sites[s.Site] = s
+	}
+}
+
+// federationDelegate hooks a Federation into its WAN memberlist.
+type federationDelegate struct {
+	f *Federation
+}
+
+func (d *federationDelegate) NodeMeta(limit int) []byte {
+	return []byte(d.f.site)
+}
+
+func (d *federationDelegate) NotifyMsg([]byte) {}
+
+func (d *federationDelegate) GetBroadcasts(overhead, limit int) [][]byte {
+	return nil
+}
+
+func (d *federationDelegate) LocalState(join bool) []byte {
+	return d.f.encodeSummaries()
+}
+
+func (d *federationDelegate) MergeRemoteState(buf []byte, join bool) {
+	if len(buf) > 0 {
+		d.f.mergeSummaries(buf)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFederation(t *testing.T) {
+	newSite := func(site string) *Federation {
+		lan, err := Create(testConfig(t))
+		require.NoError(t, err)
+		t.Cleanup(func() { lan.Shutdown() })
+
+		f, err := NewFederation(&FederationConfig{
+			Site: site,
+			LAN:  lan,
+			WAN:  testConfig(t),
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() { f.Shutdown() })
+		return f
+	}
+
+	fa := newSite("a")
+	fb := newSite("b")
+
+	wan := fa.WAN().LocalNode()
+	_, err := fb.Join([]string{wan.Name + "/" + wan.Address()})
+	require.NoError(t, err)
+
+	// The join's push/pull exchanges summaries in both directions.
+	waitForCondition(t, func() (bool, string) {
+		members := fa.SiteMembers("b")
+		return len(members) == 1, fmt.Sprintf("expected 1 member, got %d", len(members))
+	})
+	require.Equal(t, []string{"a", "b"}, fa.Sites())
+	require.Equal(t, []string{"a", "b"}, fb.Sites())
+	require.Equal(t, fb.lan.LocalNode().Name, fa.SiteMembers("b")[0].Name)
+	require.Equal(t, fa.lan.LocalNode().Name, fb.SiteMembers("a")[0].Name)
+
+	// The local site comes straight from the LAN.
+	require.Len(t, fa.SiteMembers("a"), 1)
+	require.Nil(t, fa.SiteMembers("c"))
+}
+
+func TestNewFederation_Validation(t *testing.T) {
+	lan, err := Create(testConfig(t))
+	require.NoError(t, err)
+	defer lan.Shutdown()
+
+	_, err = NewFederation(&FederationConfig{LAN: lan, WAN: testConfig(t)})
+	require.Error(t, err)
+
+	wan := testConfig(t)
+	wan.Delegate = &MockDelegate{}
+	_, err = NewFederation(&FederationConfig{Site: "a", LAN: lan, WAN: wan})
+	require.Error(t, err)
+}
@@ -0,0 +1,98 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"crypto/fips140"
+	"crypto/tls"
+	"fmt"
+	"slices"
+)
+
+/*
+Packets are encrypted with AES-GCM, which is FIPS approved, so FIPS mode has
+nothing to change about them: newGCM always goes through the approved
+interface, which makes its own nonces. What it does restrict is TLS for
+streams, where Go would otherwise allow suites and curves that aren't
+approved unless it's running in FIPS 140-3 mode itself.
+*/
+
+// The TLS settings FIPS mode allows, following SP 800-52r2.
+var (
+	fipsTLSVersions = []uint16{tls.VersionTLS12, tls.VersionTLS13}
+
+	// TLS 1.3 suites can't be configured, and are all AES-GCM in FIPS
+	// mode.
+	fipsCipherSuites = []uint16{
+		tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+		tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	}
+
+	fipsCurves = []tls.CurveID{
+		tls.CurveP256,
+		tls.CurveP384,
+		tls.CurveP521,
+	}
+)
+
+// fipsMode returns true if memberlist is restricted to FIPS approved
+// cryptography, either by the config or by building with the fips tag.
+func (c *Config) fipsMode() bool {
+	return c.FIPSMode || fipsBuild
+}
+
+// fipsModule returns true if Go's cryptography is a FIPS 140 validated
+// module, either the Go Cryptographic Module in FIPS 140-3 mode or
+// BoringCrypto.
+func fipsModule() bool {
+	return fips140.Enabled() || boringEnabled()
+}
+
+// checkFIPS returns an error if the config asks for cryptography FIPS mode
+// doesn't allow.
+func checkFIPS(c *Config) error {
+	tc := c.TLSConfig
+	if tc == nil {
+		return nil
+	}
+	if tc.MinVersion != 0 && !slices.Contains(fipsTLSVersions, tc.MinVersion) {
+		return fmt.Errorf("FIPS mode doesn't allow TLS version %s", tls.VersionName(tc.MinVersion))
+	}
+	if tc.MaxVersion != 0 && !slices.Contains(fipsTLSVersions, tc.MaxVersion) {
+		return fmt.Errorf("FIPS mode doesn't allow TLS version %s", tls.VersionName(tc.MaxVersion))
+	}
+	for _, suite := range tc.CipherSuites {
+		if !slices.Contains(fipsCipherSuites, suite) {
+			return fmt.Errorf("FIPS mode doesn't allow TLS cipher suite %s", tls.CipherSuiteName(suite))
+		}
+	}
+	for _, curve := range tc.CurvePreferences {
+		if !slices.Contains(fipsCurves, curve) {
+			return fmt.Errorf("FIPS mode doesn't allow TLS curve %s", curve)
+		}
+	}
+	return nil
+}
+
+// fipsTLSConfig returns a copy of a TLS config that checkFIPS passed, with
+// the approved versions, suites and curves filled in where it left them to
+// Go's defaults.
+func fipsTLSConfig(tc *tls.Config) *tls.Config {
+	if tc == nil {
+		return nil
+	}
+	tc = tc.Clone()
+	if tc.MinVersion == 0 {
+		tc.MinVersion = tls.VersionTLS12
+	}
+	if tc.CipherSuites == nil {
+		tc.CipherSuites = slices.Clone(fipsCipherSuites)
+	}
+	if tc.CurvePreferences == nil {
+		tc.CurvePreferences = slices.Clone(fipsCurves)
+	}
+	return tc
+}
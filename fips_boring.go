@@ -0,0 +1,16 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build boringcrypto
+
+package memberlist
+
+import (
+	"crypto/boring"
+)
+
+// boringEnabled returns true if Go's cryptography is BoringCrypto, which it
+// is when built with GOEXPERIMENT=boringcrypto on a platform it supports.
+func boringEnabled() bool {
+	return boring.Enabled()
+}
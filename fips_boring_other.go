@@ -0,0 +1,12 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build !boringcrypto
+
+package memberlist
+
+// boringEnabled returns true if Go's cryptography is BoringCrypto, which it
+// never is without GOEXPERIMENT=boringcrypto.
+func boringEnabled() bool {
+	return false
+}
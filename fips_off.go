@@ -0,0 +1,10 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build !fips
+
+package memberlist
+
+// fipsBuild turns on FIPS mode whatever Config.FIPSMode says, when building
+// with the fips tag.
+const fipsBuild = false
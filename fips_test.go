@@ -0,0 +1,77 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckFIPS(t *testing.T) {
+	cases := map[string]struct {
+		tls *tls.Config
+		err string
+	}{
+		"no TLS": {},
+		"defaults": {
+			tls: &tls.Config{},
+		},
+		"approved": {
+			tls: &tls.Config{
+				MinVersion:       tls.VersionTLS12,
+				MaxVersion:       tls.VersionTLS13,
+				CipherSuites:     []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384},
+				CurvePreferences: []tls.CurveID{tls.CurveP384},
+			},
+		},
+		"old version": {
+			tls: &tls.Config{MinVersion: tls.VersionTLS10},
+			err: "TLS version TLS 1.0",
+		},
+		"chacha20": {
+			tls: &tls.Config{CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256}},
+			err: "TLS cipher suite TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256",
+		},
+		"x25519": {
+			tls: &tls.Config{CurvePreferences: []tls.CurveID{tls.X25519}},
+			err: "TLS curve X25519",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := DefaultLANConfig()
+			c.TLSConfig = tc.tls
+			err := checkFIPS(c)
+			if tc.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestFIPSTLSConfig(t *testing.T) {
+	require.Nil(t, fipsTLSConfig(nil))
+
+	// Anything left to the defaults gets the approved settings, without
+	// touching the config passed in.
+	orig := &tls.Config{CurvePreferences: []tls.CurveID{tls.CurveP256}}
+	tc := fipsTLSConfig(orig)
+	require.Equal(t, uint16(tls.VersionTLS12), tc.MinVersion)
+	require.Equal(t, fipsCipherSuites, tc.CipherSuites)
+	require.Equal(t, []tls.CurveID{tls.CurveP256}, tc.CurvePreferences)
+	require.Zero(t, orig.MinVersion)
+	require.Nil(t, orig.CipherSuites)
+}
+
+func TestCreate_FIPSMode(t *testing.T) {
+	c := testConfig(t)
+	c.FIPSMode = true
+	c.TLSConfig = &tls.Config{MaxVersion: tls.VersionTLS11}
+	_, err := Create(c)
+	require.ErrorContains(t, err, "FIPS mode doesn't allow TLS version TLS 1.1")
+}
@@ -0,0 +1,106 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"math"
+	"time"
+
+	metrics "github.com/hashicorp/go-metrics/compat"
+)
+
+// flapState tracks how often a node has been flapping, in the manner of
+// route flap damping. Each time the node becomes suspect its penalty goes
+// up by one, and the penalty halves every FlapHalfLife.
+type flapState struct {
+	penalty float64
+	updated time.Time
+	damped  bool
+}
+
+// decay brings the penalty up to date.
+func (f *flapState) decay(now time.Time, halfLife time.Duration) {
+	if !f.updated.IsZero() {
+		elapsed := now.Sub(f.updated)
+		f.penalty *= math.Pow(0.5, float64(elapsed)/float64(halfLife))
+	}
+	f.updated = now
+}
+
+// flaps is the penalty rounded to a whole number of recent flaps, so a few
+// flaps in quick succession count in full despite a little decay.
+func (f *flapState) flaps() float64 {
+	return math.Round(f.penalty)
+}
+
+// recentFlaps is flaps as of now, without bringing the penalty up to date,
+// so it only needs nodeLock held for reading.
+func (f *flapState) recentFlaps(now time.Time, halfLife time.Duration) float64 {
+	g := *f
+	g.decay(now, halfLife)
+	return g.flaps()
+}
+
+// recordFlap adds to a node's flap penalty when it becomes suspect, and
+// damps it once the penalty reaches FlapThreshold. It must be called with
+// nodeLock held for writing.
+func (m *Memberlist) recordFlap(state *nodeState) {
+	if m.config.FlapHalfLife <= 0 {
+		return
+	}
+	state.flap.decay(time.Now(), m.config.FlapHalfLife)
+	state.flap.penalty++
+	if !state.flap.damped && state.flap.flaps() >= m.config.FlapThreshold {
+		state.flap.damped = true
+		m.logger.Printf("[WARN] memberlist: Damping flapping node %s", state.Name)
+		metrics.IncrCounterWithLabels([]string{"memberlist", "flap", "damped"}, 1, m.metricLabels)
+		m.notifyFlapping(state, true)
+	}
+}
+
+// flapMultiplier is how much to stretch a node's suspicion timeout. It
+// doubles for every point of penalty over FlapThreshold, up to
+// FlapMaxMultiplier, so a chronic flapper gets more time to refute before
+// it's declared dead and joins again. It must be called with nodeLock held.
+func (m *Memberlist) flapMultiplier(state *nodeState) time.Duration {
+	if !state.flap.damped {
+		return 1
+	}
+	over := state.flap.flaps() - m.config.FlapThreshold
+	mult := math.Pow(2, math.Max(over, 0)+1)
+	if max := float64(m.config.FlapMaxMultiplier); max >= 1 && mult > max {
+		mult = max
+	}
+	return time.Duration(mult)
+}
+
+// undampNodes lets nodes whose penalty has decayed to half the threshold
+// out of damping. It must be called with nodeLock held for writing.
+func (m *Memberlist) undampNodes() {
+	if m.config.FlapHalfLife <= 0 {
+		return
+	}
+	now := time.Now()
+	for _, state := range m.nodes {
+		if !state.flap.damped {
+			continue
+		}
+		state.flap.decay(now, m.config.FlapHalfLife)
+		if state.flap.penalty < m.config.FlapThreshold/2 {
+			state.flap.damped = false
+			m.logger.Printf("[INFO] memberlist: Node %s stopped flapping", state.Name)
+			m.notifyFlapping(state, false)
+		}
+	}
+}
+
+// notifyFlapping tells the event delegate about a change in damping, if it
+// wants to know.
+func (m *Memberlist) notifyFlapping(state *nodeState, damped bool) {
+	d, ok := m.config.Events.(FlapEventDelegate)
+	if !ok {
+		return
+	}
+	m.guard("NotifyFlapping", func() { d.NotifyFlapping(&state.Node, damped) })
+}
@@ -0,0 +1,72 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type flapEvents struct {
+	ChannelEventDelegate
+	flaps []bool
+}
+
+func (f *flapEvents) NotifyFlapping(node *Node, damped bool) {
+	f.flaps = append(f.flaps, damped)
+}
+
+func TestMemberlist_FlapDamping(t *testing.T) {
+	events := &flapEvents{ChannelEventDelegate: ChannelEventDelegate{Ch: make(chan NodeEvent, 100)}}
+	m := GetMemberlist(t, func(c *Config) {
+		c.Events = events
+		c.FlapHalfLife = time.Hour
+		c.FlapThreshold = 2
+		c.FlapMaxMultiplier = 4
+	})
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	inc := uint32(1)
+	a := alive{Node: "test", Addr: []byte{127, 0, 0, 1}, Incarnation: inc, Vsn: m.config.BuildVsnArray()}
+	m.aliveNode(&a, nil, false)
+
+	flap := func() {
+		m.suspectNode(&suspect{Node: "test", Incarnation: inc, From: m.config.Name})
+		inc++
+		a.Incarnation = inc
+		m.aliveNode(&a, nil, false)
+	}
+	multiplier := func() time.Duration {
+		m.nodeLock.RLock()
+		defer m.nodeLock.RUnlock()
+		return m.flapMultiplier(m.nodeMap["test"])
+	}
+
+	flap()
+	require.Equal(t, time.Duration(1), multiplier())
+	require.Empty(t, events.flaps)
+
+	// The second flap reaches the threshold, and every one after that
+	// doubles the hold-down up to the limit.
+	flap()
+	require.Equal(t, []bool{true}, events.flaps)
+	require.Equal(t, time.Duration(2), multiplier())
+	flap()
+	require.Equal(t, time.Duration(4), multiplier())
+	flap()
+	require.Equal(t, time.Duration(4), multiplier())
+	require.Equal(t, []bool{true}, events.flaps)
+
+	// Once the penalty decays the node is let go.
+	m.nodeLock.Lock()
+	m.nodeMap["test"].flap.updated = time.Now().Add(-3 * time.Hour)
+	m.nodeLock.Unlock()
+	m.resetNodes()
+	require.Equal(t, []bool{true, false}, events.flaps)
+	require.Equal(t, time.Duration(1), multiplier())
+}
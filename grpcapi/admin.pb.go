@@ -0,0 +1,848 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+// Admin service for a running memberlist, served by the grpcapi package. It
+// mirrors the commands of the control socket, and adds a stream of
+// membership events.
+//
+// Field numbers are part of the API. Only add new fields, never reuse or
+// renumber existing ones.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: admin.proto
+
+package grpcapi
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type MembershipEvent_Type int32
+
+const (
+	MembershipEvent_JOIN   MembershipEvent_Type = 0
+	MembershipEvent_LEAVE  MembershipEvent_Type = 1
+	MembershipEvent_UPDATE MembershipEvent_Type = 2
+)
+
+// Enum value maps for MembershipEvent_Type.
+var (
+	MembershipEvent_Type_name = map[int32]string{
+		0: "JOIN",
+		1: "LEAVE",
+		2: "UPDATE",
+	}
+	MembershipEvent_Type_value = map[string]int32{
+		"JOIN":   0,
+		"LEAVE":  1,
+		"UPDATE": 2,
+	}
+)
+
+func (x MembershipEvent_Type) Enum() *MembershipEvent_Type {
+	p := new(MembershipEvent_Type)
+	*p = x
+	return p
+}
+
+func (x MembershipEvent_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MembershipEvent_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_admin_proto_enumTypes[0].Descriptor()
+}
+
+func (MembershipEvent_Type) Type() protoreflect.EnumType {
+	return &file_admin_proto_enumTypes[0]
+}
+
+func (x MembershipEvent_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MembershipEvent_Type.Descriptor instead.
+func (MembershipEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{11, 0}
+}
+
+type Empty struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Empty) Reset() {
+	*x = Empty{}
+	mi := &file_admin_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Empty) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Empty) ProtoMessage() {}
+
+func (x *Empty) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Empty.ProtoReflect.Descriptor instead.
+func (*Empty) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{0}
+}
+
+type Member struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Addr  string                 `protobuf:"bytes,2,opt,name=addr,proto3" json:"addr,omitempty"`
+	Port  uint32                 `protobuf:"varint,3,opt,name=port,proto3" json:"port,omitempty"`
+	// alive = 0, suspect = 1, dead = 2, left = 3
+	State uint32 `protobuf:"varint,4,opt,name=state,proto3" json:"state,omitempty"`
+	Meta  []byte `protobuf:"bytes,5,opt,name=meta,proto3" json:"meta,omitempty"`
+	Zone  string `protobuf:"bytes,6,opt,name=zone,proto3" json:"zone,omitempty"`
+	Id    string `protobuf:"bytes,7,opt,name=id,proto3" json:"id,omitempty"`
+	// Why the node isn't alive, see memberlist.StateChangeReason.
+	Reason        uint32 `protobuf:"varint,8,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Member) Reset() {
+	*x = Member{}
+	mi := &file_admin_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Member) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Member) ProtoMessage() {}
+
+func (x *Member) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Member.ProtoReflect.Descriptor instead.
+func (*Member) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Member) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Member) GetAddr() string {
+	if x != nil {
+		return x.Addr
+	}
+	return ""
+}
+
+func (x *Member) GetPort() uint32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *Member) GetState() uint32 {
+	if x != nil {
+		return x.State
+	}
+	return 0
+}
+
+func (x *Member) GetMeta() []byte {
+	if x != nil {
+		return x.Meta
+	}
+	return nil
+}
+
+func (x *Member) GetZone() string {
+	if x != nil {
+		return x.Zone
+	}
+	return ""
+}
+
+func (x *Member) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Member) GetReason() uint32 {
+	if x != nil {
+		return x.Reason
+	}
+	return 0
+}
+
+type MembersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Members       []*Member              `protobuf:"bytes,1,rep,name=members,proto3" json:"members,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MembersResponse) Reset() {
+	*x = MembersResponse{}
+	mi := &file_admin_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MembersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MembersResponse) ProtoMessage() {}
+
+func (x *MembersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MembersResponse.ProtoReflect.Descriptor instead.
+func (*MembersResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *MembersResponse) GetMembers() []*Member {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+type JoinRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Addrs         []string               `protobuf:"bytes,1,rep,name=addrs,proto3" json:"addrs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JoinRequest) Reset() {
+	*x = JoinRequest{}
+	mi := &file_admin_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JoinRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JoinRequest) ProtoMessage() {}
+
+func (x *JoinRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JoinRequest.ProtoReflect.Descriptor instead.
+func (*JoinRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *JoinRequest) GetAddrs() []string {
+	if x != nil {
+		return x.Addrs
+	}
+	return nil
+}
+
+type JoinResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The number of nodes successfully contacted.
+	Joined        int32 `protobuf:"varint,1,opt,name=joined,proto3" json:"joined,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JoinResponse) Reset() {
+	*x = JoinResponse{}
+	mi := &file_admin_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JoinResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JoinResponse) ProtoMessage() {}
+
+func (x *JoinResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JoinResponse.ProtoReflect.Descriptor instead.
+func (*JoinResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *JoinResponse) GetJoined() int32 {
+	if x != nil {
+		return x.Joined
+	}
+	return 0
+}
+
+type LeaveRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// How long to wait for the leave to be broadcast, in nanoseconds.
+	Timeout       int64 `protobuf:"varint,1,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeaveRequest) Reset() {
+	*x = LeaveRequest{}
+	mi := &file_admin_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeaveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaveRequest) ProtoMessage() {}
+
+func (x *LeaveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaveRequest.ProtoReflect.Descriptor instead.
+func (*LeaveRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *LeaveRequest) GetTimeout() int64 {
+	if x != nil {
+		return x.Timeout
+	}
+	return 0
+}
+
+type ForceLeaveRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Node          string                 `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ForceLeaveRequest) Reset() {
+	*x = ForceLeaveRequest{}
+	mi := &file_admin_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ForceLeaveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForceLeaveRequest) ProtoMessage() {}
+
+func (x *ForceLeaveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForceLeaveRequest.ProtoReflect.Descriptor instead.
+func (*ForceLeaveRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ForceLeaveRequest) GetNode() string {
+	if x != nil {
+		return x.Node
+	}
+	return ""
+}
+
+type KeyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           []byte                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeyRequest) Reset() {
+	*x = KeyRequest{}
+	mi := &file_admin_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyRequest) ProtoMessage() {}
+
+func (x *KeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyRequest.ProtoReflect.Descriptor instead.
+func (*KeyRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *KeyRequest) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+type KeysResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Keys          [][]byte               `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeysResponse) Reset() {
+	*x = KeysResponse{}
+	mi := &file_admin_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeysResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeysResponse) ProtoMessage() {}
+
+func (x *KeysResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeysResponse.ProtoReflect.Descriptor instead.
+func (*KeysResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *KeysResponse) GetKeys() [][]byte {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+type StatsResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Members  int32                  `protobuf:"varint,1,opt,name=members,proto3" json:"members,omitempty"`
+	Alive    int32                  `protobuf:"varint,2,opt,name=alive,proto3" json:"alive,omitempty"`
+	Health   int32                  `protobuf:"varint,3,opt,name=health,proto3" json:"health,omitempty"`
+	Protocol uint32                 `protobuf:"varint,4,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	// Highest protocol version all live members understand.
+	ProtocolFloor uint32 `protobuf:"varint,5,opt,name=protocol_floor,json=protocolFloor,proto3" json:"protocol_floor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatsResponse) Reset() {
+	*x = StatsResponse{}
+	mi := &file_admin_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsResponse) ProtoMessage() {}
+
+func (x *StatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsResponse.ProtoReflect.Descriptor instead.
+func (*StatsResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *StatsResponse) GetMembers() int32 {
+	if x != nil {
+		return x.Members
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetAlive() int32 {
+	if x != nil {
+		return x.Alive
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetHealth() int32 {
+	if x != nil {
+		return x.Health
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetProtocol() uint32 {
+	if x != nil {
+		return x.Protocol
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetProtocolFloor() uint32 {
+	if x != nil {
+		return x.ProtocolFloor
+	}
+	return 0
+}
+
+type MembershipEventsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Start the stream with a join event for each member we know of.
+	Snapshot      bool `protobuf:"varint,1,opt,name=snapshot,proto3" json:"snapshot,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MembershipEventsRequest) Reset() {
+	*x = MembershipEventsRequest{}
+	mi := &file_admin_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MembershipEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MembershipEventsRequest) ProtoMessage() {}
+
+func (x *MembershipEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MembershipEventsRequest.ProtoReflect.Descriptor instead.
+func (*MembershipEventsRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *MembershipEventsRequest) GetSnapshot() bool {
+	if x != nil {
+		return x.Snapshot
+	}
+	return false
+}
+
+type MembershipEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          MembershipEvent_Type   `protobuf:"varint,1,opt,name=type,proto3,enum=memberlist.admin.v1.MembershipEvent_Type" json:"type,omitempty"`
+	Member        *Member                `protobuf:"bytes,2,opt,name=member,proto3" json:"member,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MembershipEvent) Reset() {
+	*x = MembershipEvent{}
+	mi := &file_admin_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MembershipEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MembershipEvent) ProtoMessage() {}
+
+func (x *MembershipEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MembershipEvent.ProtoReflect.Descriptor instead.
+func (*MembershipEvent) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *MembershipEvent) GetType() MembershipEvent_Type {
+	if x != nil {
+		return x.Type
+	}
+	return MembershipEvent_JOIN
+}
+
+func (x *MembershipEvent) GetMember() *Member {
+	if x != nil {
+		return x.Member
+	}
+	return nil
+}
+
+var File_admin_proto protoreflect.FileDescriptor
+
+const file_admin_proto_rawDesc = "" +
+	"\n" +
+	"\vadmin.proto\x12\x13memberlist.admin.v1\"\a\n" +
+	"\x05Empty\"\xaa\x01\n" +
+	"\x06Member\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04addr\x18\x02 \x01(\tR\x04addr\x12\x12\n" +
+	"\x04port\x18\x03 \x01(\rR\x04port\x12\x14\n" +
+	"\x05state\x18\x04 \x01(\rR\x05state\x12\x12\n" +
+	"\x04meta\x18\x05 \x01(\fR\x04meta\x12\x12\n" +
+	"\x04zone\x18\x06 \x01(\tR\x04zone\x12\x0e\n" +
+	"\x02id\x18\a \x01(\tR\x02id\x12\x16\n" +
+	"\x06reason\x18\b \x01(\rR\x06reason\"H\n" +
+	"\x0fMembersResponse\x125\n" +
+	"\amembers\x18\x01 \x03(\v2\x1b.memberlist.admin.v1.MemberR\amembers\"#\n" +
+	"\vJoinRequest\x12\x14\n" +
+	"\x05addrs\x18\x01 \x03(\tR\x05addrs\"&\n" +
+	"\fJoinResponse\x12\x16\n" +
+	"\x06joined\x18\x01 \x01(\x05R\x06joined\"(\n" +
+	"\fLeaveRequest\x12\x18\n" +
+	"\atimeout\x18\x01 \x01(\x03R\atimeout\"'\n" +
+	"\x11ForceLeaveRequest\x12\x12\n" +
+	"\x04node\x18\x01 \x01(\tR\x04node\"\x1e\n" +
+	"\n" +
+	"KeyRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\fR\x03key\"\"\n" +
+	"\fKeysResponse\x12\x12\n" +
+	"\x04keys\x18\x01 \x03(\fR\x04keys\"\x9a\x01\n" +
+	"\rStatsResponse\x12\x18\n" +
+	"\amembers\x18\x01 \x01(\x05R\amembers\x12\x14\n" +
+	"\x05alive\x18\x02 \x01(\x05R\x05alive\x12\x16\n" +
+	"\x06health\x18\x03 \x01(\x05R\x06health\x12\x1a\n" +
+	"\bprotocol\x18\x04 \x01(\rR\bprotocol\x12%\n" +
+	"\x0eprotocol_floor\x18\x05 \x01(\rR\rprotocolFloor\"5\n" +
+	"\x17MembershipEventsRequest\x12\x1a\n" +
+	"\bsnapshot\x18\x01 \x01(\bR\bsnapshot\"\xae\x01\n" +
+	"\x0fMembershipEvent\x12=\n" +
+	"\x04type\x18\x01 \x01(\x0e2).memberlist.admin.v1.MembershipEvent.TypeR\x04type\x123\n" +
+	"\x06member\x18\x02 \x01(\v2\x1b.memberlist.admin.v1.MemberR\x06member\"'\n" +
+	"\x04Type\x12\b\n" +
+	"\x04JOIN\x10\x00\x12\t\n" +
+	"\x05LEAVE\x10\x01\x12\n" +
+	"\n" +
+	"\x06UPDATE\x10\x022\x95\x06\n" +
+	"\x05Admin\x12K\n" +
+	"\aMembers\x12\x1a.memberlist.admin.v1.Empty\x1a$.memberlist.admin.v1.MembersResponse\x12K\n" +
+	"\x04Join\x12 .memberlist.admin.v1.JoinRequest\x1a!.memberlist.admin.v1.JoinResponse\x12F\n" +
+	"\x05Leave\x12!.memberlist.admin.v1.LeaveRequest\x1a\x1a.memberlist.admin.v1.Empty\x12P\n" +
+	"\n" +
+	"ForceLeave\x12&.memberlist.admin.v1.ForceLeaveRequest\x1a\x1a.memberlist.admin.v1.Empty\x12I\n" +
+	"\bListKeys\x12\x1a.memberlist.admin.v1.Empty\x1a!.memberlist.admin.v1.KeysResponse\x12I\n" +
+	"\n" +
+	"InstallKey\x12\x1f.memberlist.admin.v1.KeyRequest\x1a\x1a.memberlist.admin.v1.Empty\x12E\n" +
+	"\x06UseKey\x12\x1f.memberlist.admin.v1.KeyRequest\x1a\x1a.memberlist.admin.v1.Empty\x12H\n" +
+	"\tRemoveKey\x12\x1f.memberlist.admin.v1.KeyRequest\x1a\x1a.memberlist.admin.v1.Empty\x12G\n" +
+	"\x05Stats\x12\x1a.memberlist.admin.v1.Empty\x1a\".memberlist.admin.v1.StatsResponse\x12h\n" +
+	"\x10MembershipEvents\x12,.memberlist.admin.v1.MembershipEventsRequest\x1a$.memberlist.admin.v1.MembershipEvent0\x01B)Z'github.com/hashicorp/memberlist/grpcapib\x06proto3"
+
+var (
+	file_admin_proto_rawDescOnce sync.Once
+	file_admin_proto_rawDescData []byte
+)
+
+func file_admin_proto_rawDescGZIP() []byte {
+	file_admin_proto_rawDescOnce.Do(func() {
+		file_admin_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_admin_proto_rawDesc), len(file_admin_proto_rawDesc)))
+	})
+	return file_admin_proto_rawDescData
+}
+
+var file_admin_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_admin_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_admin_proto_goTypes = []any{
+	(MembershipEvent_Type)(0),       // 0: memberlist.admin.v1.MembershipEvent.Type
+	(*Empty)(nil),                   // 1: memberlist.admin.v1.Empty
+	(*Member)(nil),                  // 2: memberlist.admin.v1.Member
+	(*MembersResponse)(nil),         // 3: memberlist.admin.v1.MembersResponse
+	(*JoinRequest)(nil),             // 4: memberlist.admin.v1.JoinRequest
+	(*JoinResponse)(nil),            // 5: memberlist.admin.v1.JoinResponse
+	(*LeaveRequest)(nil),            // 6: memberlist.admin.v1.LeaveRequest
+	(*ForceLeaveRequest)(nil),       // 7: memberlist.admin.v1.ForceLeaveRequest
+	(*KeyRequest)(nil),              // 8: memberlist.admin.v1.KeyRequest
+	(*KeysResponse)(nil),            // 9: memberlist.admin.v1.KeysResponse
+	(*StatsResponse)(nil),           // 10: memberlist.admin.v1.StatsResponse
+	(*MembershipEventsRequest)(nil), // 11: memberlist.admin.v1.MembershipEventsRequest
+	(*MembershipEvent)(nil),         // 12: memberlist.admin.v1.MembershipEvent
+}
+var file_admin_proto_depIdxs = []int32{
+	2,  // 0: memberlist.admin.v1.MembersResponse.members:type_name -> memberlist.admin.v1.Member
+	0,  // 1: memberlist.admin.v1.MembershipEvent.type:type_name -> memberlist.admin.v1.MembershipEvent.Type
+	2,  // 2: memberlist.admin.v1.MembershipEvent.member:type_name -> memberlist.admin.v1.Member
+	1,  // 3: memberlist.admin.v1.Admin.Members:input_type -> memberlist.admin.v1.Empty
+	4,  // 4: memberlist.admin.v1.Admin.Join:input_type -> memberlist.admin.v1.JoinRequest
+	6,  // 5: memberlist.admin.v1.Admin.Leave:input_type -> memberlist.admin.v1.LeaveRequest
+	7,  // 6: memberlist.admin.v1.Admin.ForceLeave:input_type -> memberlist.admin.v1.ForceLeaveRequest
+	1,  // 7: memberlist.admin.v1.Admin.ListKeys:input_type -> memberlist.admin.v1.Empty
+	8,  // 8: memberlist.admin.v1.Admin.InstallKey:input_type -> memberlist.admin.v1.KeyRequest
+	8,  // 9: memberlist.admin.v1.Admin.UseKey:input_type -> memberlist.admin.v1.KeyRequest
+	8,  // 10: memberlist.admin.v1.Admin.RemoveKey:input_type -> memberlist.admin.v1.KeyRequest
+	1,  // 11: memberlist.admin.v1.Admin.Stats:input_type -> memberlist.admin.v1.Empty
+	11, // 12: memberlist.admin.v1.Admin.MembershipEvents:input_type -> memberlist.admin.v1.MembershipEventsRequest
+	3,  // 13: memberlist.admin.v1.Admin.Members:output_type -> memberlist.admin.v1.MembersResponse
+	5,  // 14: memberlist.admin.v1.Admin.Join:output_type -> memberlist.admin.v1.JoinResponse
+	1,  // 15: memberlist.admin.v1.Admin.Leave:output_type -> memberlist.admin.v1.Empty
+	1,  // 16: memberlist.admin.v1.Admin.ForceLeave:output_type -> memberlist.admin.v1.Empty
+	9,  // 17: memberlist.admin.v1.Admin.ListKeys:output_type -> memberlist.admin.v1.KeysResponse
+	1,  // 18: memberlist.admin.v1.Admin.InstallKey:output_type -> memberlist.admin.v1.Empty
+	1,  // 19: memberlist.admin.v1.Admin.UseKey:output_type -> memberlist.admin.v1.Empty
+	1,  // 20: memberlist.admin.v1.Admin.RemoveKey:output_type -> memberlist.admin.v1.Empty
+	10, // 21: memberlist.admin.v1.Admin.Stats:output_type -> memberlist.admin.v1.StatsResponse
+	12, // 22: memberlist.admin.v1.Admin.MembershipEvents:output_type -> memberlist.admin.v1.MembershipEvent
+	13, // [13:23] is the sub-list for method output_type
+	3,  // [3:13] is the sub-list for method input_type
+	3,  // [3:3] is the sub-list for extension type_name
+	3,  // [3:3] is the sub-list for extension extendee
+	0,  // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_admin_proto_init() }
+func file_admin_proto_init() {
+	if File_admin_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_admin_proto_rawDesc), len(file_admin_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_admin_proto_goTypes,
+		DependencyIndexes: file_admin_proto_depIdxs,
+		EnumInfos:         file_admin_proto_enumTypes,
+		MessageInfos:      file_admin_proto_msgTypes,
+	}.Build()
+	File_admin_proto = out.File
+	file_admin_proto_goTypes = nil
+	file_admin_proto_depIdxs = nil
+}
@@ -0,0 +1,82 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package grpcapi
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// Client calls the Admin service, wrapping the generated AdminClient with
+// plain Go arguments and results.
+type Client struct {
+	c AdminClient
+}
+
+// NewClient returns a Client using the given connection.
+func NewClient(cc grpc.ClientConnInterface) *Client {
+	return &Client{c: NewAdminClient(cc)}
+}
+
+// Members lists the members the instance knows of.
+func (c *Client) Members(ctx context.Context) ([]*Member, error) {
+	resp, err := c.c.Members(ctx, &Empty{})
+	return resp.GetMembers(), err
+}
+
+// Join joins the cluster through the given addresses, and returns the
+// number of nodes contacted.
+func (c *Client) Join(ctx context.Context, addrs []string) (int, error) {
+	resp, err := c.c.Join(ctx, &JoinRequest{Addrs: addrs})
+	return int(resp.GetJoined()), err
+}
+
+// Leave makes the instance leave the cluster.
+func (c *Client) Leave(ctx context.Context, timeout time.Duration) error {
+	_, err := c.c.Leave(ctx, &LeaveRequest{Timeout: int64(timeout)})
+	return err
+}
+
+// ForceLeave marks a node as dead.
+func (c *Client) ForceLeave(ctx context.Context, node string) error {
+	_, err := c.c.ForceLeave(ctx, &ForceLeaveRequest{Node: node})
+	return err
+}
+
+// ListKeys returns the encryption keys, primary first.
+func (c *Client) ListKeys(ctx context.Context) ([][]byte, error) {
+	resp, err := c.c.ListKeys(ctx, &Empty{})
+	return resp.GetKeys(), err
+}
+
+// InstallKey adds a key to the keyring.
+func (c *Client) InstallKey(ctx context.Context, key []byte) error {
+	_, err := c.c.InstallKey(ctx, &KeyRequest{Key: key})
+	return err
+}
+
+// UseKey makes an installed key the primary key.
+func (c *Client) UseKey(ctx context.Context, key []byte) error {
+	_, err := c.c.UseKey(ctx, &KeyRequest{Key: key})
+	return err
+}
+
+// RemoveKey removes a key from the keyring.
+func (c *Client) RemoveKey(ctx context.Context, key []byte) error {
+	_, err := c.c.RemoveKey(ctx, &KeyRequest{Key: key})
+	return err
+}
+
+// Stats returns the instance's stats.
+func (c *Client) Stats(ctx context.Context) (*StatsResponse, error) {
+	return c.c.Stats(ctx, &Empty{})
+}
+
+// MembershipEvents opens a stream of membership events, starting with the
+// current members if snapshot is set. The stream ends when ctx is done.
+func (c *Client) MembershipEvents(ctx context.Context, snapshot bool) (Admin_MembershipEventsClient, error) {
+	return c.c.MembershipEvents(ctx, &MembershipEventsRequest{Snapshot: snapshot})
+}
@@ -0,0 +1,100 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package grpcapi
+
+import (
+	"sync"
+
+	"github.com/hashicorp/memberlist"
+)
+
+// eventBuffer is how many events a stream can fall behind by before it's
+// ended.
+const eventBuffer = 128
+
+// Events is a memberlist.EventDelegate that feeds the MembershipEvents
+// streams. Set it as the memberlist's Config.Events, or call it from the
+// application's own delegate.
+type Events struct {
+	lock sync.Mutex
+	subs map[*subscriber]struct{}
+}
+
+// NewEvents returns an Events with no streams.
+func NewEvents() *Events {
+	return &Events{subs: make(map[*subscriber]struct{})}
+}
+
+// subscriber is a single stream's queue of events. Since the delegate must
+// not block, a stream that falls too far behind is dropped, and behind is
+// closed to tell it so.
+type subscriber struct {
+	ch     chan *MembershipEvent
+	behind chan struct{}
+}
+
+func (e *Events) NotifyJoin(n *memberlist.Node) {
+	e.publish(MembershipEvent_JOIN, n)
+}
+
+func (e *Events) NotifyLeave(n *memberlist.Node) {
+	e.publish(MembershipEvent_LEAVE, n)
+}
+
+func (e *Events) NotifyUpdate(n *memberlist.Node) {
+	e.publish(MembershipEvent_UPDATE, n)
+}
+
+func (e *Events) publish(t MembershipEvent_Type, n *memberlist.Node) {
+	ev := &MembershipEvent{Type: t, Member: memberOf(n)}
+	if t == MembershipEvent_LEAVE {
+		// The node passed to NotifyLeave doesn't carry its new state
+		ev.Member.State = uint32(memberlist.StateDead)
+		if n.Reason == memberlist.ReasonLeft {
+			ev.Member.State = uint32(memberlist.StateLeft)
+		}
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	for sub := range e.subs {
+		select {
+		case sub.ch <- ev:
+		default:
+			close(sub.behind)
+			delete(e.subs, sub)
+		}
+	}
+}
+
+func (e *Events) subscribe() *subscriber {
+	sub := &subscriber{
+		ch:     make(chan *MembershipEvent, eventBuffer),
+		behind: make(chan struct{}),
+	}
+	e.lock.Lock()
+	e.subs[sub] = struct{}{}
+	e.lock.Unlock()
+	return sub
+}
+
+func (e *Events) unsubscribe(sub *subscriber) {
+	e.lock.Lock()
+	delete(e.subs, sub)
+	e.lock.Unlock()
+}
+
+// memberOf converts a node to its message form.
+func memberOf(n *memberlist.Node) *Member {
+	return &Member{
+		Name:   n.Name,
+		Addr:   n.Addr.String(),
+		Port:   uint32(n.Port),
+		State:  uint32(n.State),
+		Meta:   n.Meta,
+		Zone:   n.Zone,
+		Id:     n.ID,
+		Reason: uint32(n.Reason),
+	}
+}
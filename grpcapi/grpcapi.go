@@ -0,0 +1,31 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+// Package grpcapi serves the Admin gRPC service described in admin.proto for
+// a running memberlist, so systems that aren't written in Go can observe and
+// manage cluster membership. It mirrors the commands of the control package,
+// and adds a stream of membership events.
+//
+// It's a separate module, so memberlist itself doesn't depend on gRPC.
+//
+// The messages and service stubs are generated from admin.proto, so any
+// gRPC client built from it can talk to the server:
+//
+//	events := grpcapi.NewEvents()
+//	conf.Events = events
+//	m, _ := memberlist.Create(conf)
+//
+//	s := grpc.NewServer()
+//	grpcapi.Register(s, grpcapi.NewServer(m, grpcapi.Config{Events: events}))
+package grpcapi
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative admin.proto
+
+import (
+	"google.golang.org/grpc"
+)
+
+// Register registers the Admin service with a gRPC server.
+func Register(r grpc.ServiceRegistrar, s *Server) {
+	RegisterAdminServer(r, s)
+}
@@ -0,0 +1,146 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/memberlist"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newMemberlist(t *testing.T, name string, keyring *memberlist.Keyring, events memberlist.EventDelegate) *memberlist.Memberlist {
+	c := memberlist.DefaultLANConfig()
+	c.Name = name
+	c.BindAddr = "127.0.0.1"
+	c.BindPort = 0
+	c.Keyring = keyring
+	c.Events = events
+	m, err := memberlist.Create(c)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = m.Shutdown() })
+	return m
+}
+
+// serve serves the Admin service for m over an in-memory connection, and
+// returns a client for it.
+func serve(t *testing.T, s *Server) *Client {
+	ln := bufconn.Listen(1 << 20)
+	g := grpc.NewServer()
+	Register(g, s)
+	go func() { _ = g.Serve(ln) }()
+	t.Cleanup(g.Stop)
+
+	cc, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cc.Close() })
+	return NewClient(cc)
+}
+
+func TestAdmin(t *testing.T) {
+	ctx := context.Background()
+	key1 := bytes.Repeat([]byte{1}, 16)
+	key2 := bytes.Repeat([]byte{2}, 16)
+	keyring, err := memberlist.NewKeyring(nil, key1)
+	require.NoError(t, err)
+
+	events := NewEvents()
+	m1 := newMemberlist(t, "a", keyring, events)
+	m2 := newMemberlist(t, "b", keyring, nil)
+	c := serve(t, NewServer(m1, Config{Keyring: keyring, Events: events}))
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.MembershipEvents(streamCtx, true)
+	require.NoError(t, err)
+	ev, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, MembershipEvent_JOIN, ev.Type)
+	require.Equal(t, "a", ev.Member.Name)
+
+	joined, err := c.Join(ctx, []string{m2.LocalNode().Address()})
+	require.NoError(t, err)
+	require.Equal(t, 1, joined)
+	ev, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, MembershipEvent_JOIN, ev.Type)
+	require.Equal(t, "b", ev.Member.Name)
+
+	members, err := c.Members(ctx)
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+	for _, member := range members {
+		require.Equal(t, uint32(memberlist.StateAlive), member.State)
+		require.Equal(t, "127.0.0.1", member.Addr)
+		require.NotZero(t, member.Port)
+	}
+
+	stats, err := c.Stats(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), stats.Members)
+	require.Equal(t, int32(2), stats.Alive)
+	require.Equal(t, uint32(memberlist.ProtocolVersionMax), stats.ProtocolFloor)
+
+	// Rotate the key
+	require.NoError(t, c.InstallKey(ctx, key2))
+	require.NoError(t, c.UseKey(ctx, key2))
+	require.NoError(t, c.RemoveKey(ctx, key1))
+	keys, err := c.ListKeys(ctx)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{key2}, keys)
+	require.Equal(t, codes.InvalidArgument, status.Code(c.RemoveKey(ctx, key2)))
+
+	// Force b out once it's gone
+	require.NoError(t, m2.Shutdown())
+	require.NoError(t, c.ForceLeave(ctx, "b"))
+	ev, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, MembershipEvent_LEAVE, ev.Type)
+	require.Equal(t, "b", ev.Member.Name)
+	require.Equal(t, uint32(memberlist.ReasonForced), ev.Member.Reason)
+	require.Equal(t, uint32(memberlist.StateDead), ev.Member.State)
+
+	require.Equal(t, codes.NotFound, status.Code(c.ForceLeave(ctx, "nope")))
+	require.NoError(t, c.Leave(ctx, time.Second))
+}
+
+func TestAdmin_NotConfigured(t *testing.T) {
+	ctx := context.Background()
+	m := newMemberlist(t, "a", nil, nil)
+	c := serve(t, NewServer(m, Config{}))
+
+	_, err := c.ListKeys(ctx)
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	stream, err := c.MembershipEvents(ctx, false)
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestEvents_DropsSlowStreams(t *testing.T) {
+	e := NewEvents()
+	sub := e.subscribe()
+	n := &memberlist.Node{Name: "a", Addr: net.IPv4(127, 0, 0, 1)}
+	for i := 0; i < eventBuffer+1; i++ {
+		e.NotifyUpdate(n)
+	}
+	select {
+	case <-sub.behind:
+	default:
+		t.Fatal("expected the stream to be dropped")
+	}
+	require.Empty(t, e.subs)
+}
@@ -0,0 +1,200 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package grpcapi
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/memberlist"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Config configures a Server.
+type Config struct {
+	// Keyring is the keyring the memberlist was created with. The key RPCs
+	// fail if it's nil.
+	Keyring *memberlist.Keyring
+
+	// Events is the delegate the memberlist sends its events to. The
+	// MembershipEvents RPC fails if it's nil.
+	Events *Events
+}
+
+// Server implements the Admin service for a memberlist.
+type Server struct {
+	UnimplementedAdminServer
+
+	m       *memberlist.Memberlist
+	keyring *memberlist.Keyring
+	events  *Events
+}
+
+// NewServer returns a Server for the given memberlist. Use Register to add
+// it to a gRPC server.
+func NewServer(m *memberlist.Memberlist, c Config) *Server {
+	return &Server{m: m, keyring: c.Keyring, events: c.Events}
+}
+
+// Members implements AdminServer.
+func (s *Server) Members(context.Context, *Empty) (*MembersResponse, error) {
+	var resp MembersResponse
+	for _, n := range s.m.Members() {
+		resp.Members = append(resp.Members, s.memberOf(n))
+	}
+	return &resp, nil
+}
+
+// Join implements AdminServer.
+func (s *Server) Join(ctx context.Context, req *JoinRequest) (*JoinResponse, error) {
+	n, err := s.m.As(actorOf(ctx)).Join(req.Addrs)
+	if n == 0 && err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return &JoinResponse{Joined: int32(n)}, nil
+}
+
+// Leave implements AdminServer.
+func (s *Server) Leave(ctx context.Context, req *LeaveRequest) (*Empty, error) {
+	if err := s.m.As(actorOf(ctx)).Leave(time.Duration(req.Timeout)); err != nil {
+		return nil, err
+	}
+	return &Empty{}, nil
+}
+
+// ForceLeave implements AdminServer.
+func (s *Server) ForceLeave(ctx context.Context, req *ForceLeaveRequest) (*Empty, error) {
+	actor := actorOf(ctx)
+	d, err := s.m.NodeDiagnostics(req.Node)
+	if err != nil {
+		// There's no SetNodeState call to report this.
+		s.m.Audit(memberlist.AuditForceLeave, actor, req.Node, err)
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if d.State == memberlist.StateDead || d.State == memberlist.StateLeft {
+		return &Empty{}, nil
+	}
+	if err := s.m.As(actor).SetNodeState(req.Node, memberlist.StateDead, d.Incarnation); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return &Empty{}, nil
+}
+
+// ListKeys implements AdminServer.
+func (s *Server) ListKeys(context.Context, *Empty) (*KeysResponse, error) {
+	if s.keyring == nil {
+		return nil, errNoKeyring
+	}
+	return &KeysResponse{Keys: s.keyring.GetKeys()}, nil
+}
+
+// InstallKey implements AdminServer.
+func (s *Server) InstallKey(ctx context.Context, req *KeyRequest) (*Empty, error) {
+	return s.keyOp(ctx, memberlist.AuditInstallKey, req, (*memberlist.Keyring).AddKey)
+}
+
+// UseKey implements AdminServer.
+func (s *Server) UseKey(ctx context.Context, req *KeyRequest) (*Empty, error) {
+	return s.keyOp(ctx, memberlist.AuditUseKey, req, (*memberlist.Keyring).UseKey)
+}
+
+// RemoveKey implements AdminServer.
+func (s *Server) RemoveKey(ctx context.Context, req *KeyRequest) (*Empty, error) {
+	return s.keyOp(ctx, memberlist.AuditRemoveKey, req, (*memberlist.Keyring).RemoveKey)
+}
+
+func (s *Server) keyOp(ctx context.Context, action string, req *KeyRequest, op func(*memberlist.Keyring, []byte) error) (_ *Empty, err error) {
+	defer clear(req.Key) // The keyring has its own copy
+	defer func() { s.m.Audit(action, actorOf(ctx), memberlist.KeyFingerprint(req.Key), err) }()
+	if s.keyring == nil {
+		return nil, errNoKeyring
+	}
+	if err := op(s.keyring, req.Key); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &Empty{}, nil
+}
+
+var errNoKeyring = status.Error(codes.FailedPrecondition, "no keyring configured")
+
+// actorOf returns who's calling, to report to the memberlist's AuditHook:
+// the identity in their client certificate if they have one, otherwise
+// their address.
+func actorOf(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "grpc"
+	}
+	if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.PeerCertificates) > 0 {
+		cert := info.State.PeerCertificates[0]
+		if len(cert.URIs) > 0 {
+			return "grpc:" + cert.URIs[0].String()
+		}
+		if cert.Subject.CommonName != "" {
+			return "grpc:" + cert.Subject.CommonName
+		}
+	}
+	if p.Addr != nil {
+		return "grpc:" + p.Addr.String()
+	}
+	return "grpc"
+}
+
+// Stats implements AdminServer.
+func (s *Server) Stats(context.Context, *Empty) (*StatsResponse, error) {
+	return &StatsResponse{
+		Members:       int32(len(s.m.Members())),
+		Alive:         int32(s.m.NumMembers()),
+		Health:        int32(s.m.GetHealthScore()),
+		Protocol:      uint32(s.m.ProtocolVersion()),
+		ProtocolFloor: uint32(s.m.ProtocolSummary().Floor),
+	}, nil
+}
+
+// memberOf converts a node returned by Members to its message form. Those
+// nodes don't carry their state, so it's looked up.
+func (s *Server) memberOf(n *memberlist.Node) *Member {
+	m := memberOf(n)
+	if d, err := s.m.NodeDiagnostics(n.Name); err == nil {
+		m.State = uint32(d.State)
+	}
+	return m
+}
+
+// MembershipEvents implements AdminServer. It streams events until the
+// client goes away. Events that happen while the snapshot is being sent may
+// show up twice.
+func (s *Server) MembershipEvents(req *MembershipEventsRequest, stream grpc.ServerStreamingServer[MembershipEvent]) error {
+	if s.events == nil {
+		return status.Error(codes.FailedPrecondition, "no event delegate configured")
+	}
+	sub := s.events.subscribe()
+	defer s.events.unsubscribe(sub)
+
+	if req.Snapshot {
+		for _, n := range s.m.Members() {
+			if err := stream.Send(&MembershipEvent{Type: MembershipEvent_JOIN, Member: s.memberOf(n)}); err != nil {
+				return err
+			}
+		}
+	}
+
+	ctx := stream.Context()
+	for {
+		select {
+		case ev := <-sub.ch:
+			if err := stream.Send(ev); err != nil {
+				return err
+			}
+		case <-sub.behind:
+			return status.Error(codes.ResourceExhausted, "event stream fell behind")
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"net"
+	"sync/atomic"
+	"time"
+
+	metrics "github.com/hashicorp/go-metrics/compat"
+)
+
+/*
+Two members behind NAT usually can't reach each other directly, since each
+NAT drops packets from addresses it hasn't seen outgoing traffic to, even
+though both can reach members with public addresses. Probing shows this up:
+direct pings to the peer go unanswered while indirect ones get through. When
+that happens the prober asks one of the members that reached the peer to act
+as a rendezvous, and the rendezvous tells both sides to ping each other right
+away. Each side's pings open its own NAT for the other's, so once both have
+sent, pings and acks start getting through.
+
+If no ack comes back, such as with a symmetric NAT, each side sends the other
+its packets through the rendezvous as relay messages instead, until
+holePunchRetry has passed and the next failed probe tries again.
+*/
+
+const (
+	// holePunchPings is how many pings each side sends when punching, and
+	// holePunchSpacing the time between them.
+	holePunchPings   = 3
+	holePunchSpacing = 50 * time.Millisecond
+
+	// holePunchRetry is how long to wait before trying to punch a hole to
+	// the same peer again, and how long to relay to it in the meantime.
+	holePunchRetry = 5 * time.Minute
+)
+
+// punchReq asks a member to be the rendezvous for a hole punch between the
+// sender and Peer.
+type punchReq struct {
+	Node string
+	Peer string
+}
+
+// punch is sent by a rendezvous to both sides, telling each to start sending
+// to the other.
+type punch struct {
+	Peer       string
+	Rendezvous string
+}
+
+// punchState is how we get packets to a peer we've punched, or tried to.
+type punchState struct {
+	retry time.Time // No punching before this
+	relay *Address  // Rendezvous to relay through until retry, if punching failed
+}
+
+// requestPunch asks one of the members that reached a peer for us to be the
+// rendezvous for a hole punch to it, unless we've tried recently.
+func (m *Memberlist) requestPunch(peer string, helpers []Node) {
+	if !m.config.HolePunching || len(helpers) == 0 {
+		return
+	}
+	now := time.Now()
+	m.punchLock.Lock()
+	if st, ok := m.punches[peer]; ok && now.Before(st.retry) {
+		m.punchLock.Unlock()
+		return
+	}
+	if m.punches == nil {
+		m.punches = make(map[string]*punchState)
+	}
+	m.punches[peer] = &punchState{retry: now.Add(holePunchRetry)}
+	m.punchLock.Unlock()
+
+	rendezvous := helpers[0]
+	req := punchReq{Node: m.config.Name, Peer: peer}
+	if err := m.encodeAndSendMsg(rendezvous.FullAddress(), punchReqMsg, &req); err != nil {
+		m.logger.Printf("[ERR] memberlist: Failed to ask %s to punch a hole to %s: %s", rendezvous.Name, peer, err)
+		return
+	}
+	m.logger.Printf("[DEBUG] memberlist: Asked %s to punch a hole to %s", rendezvous.Name, peer)
+}
+
+// handlePunchReq tells both sides of a hole punch to start sending to each
+// other.
+func (m *Memberlist) handlePunchReq(buf []byte, from net.Addr) {
+	if !m.config.HolePunching {
+		m.logger.Printf("[DEBUG] memberlist: Ignoring hole punch request, hole punching is disabled %s", LogAddress(from))
+		return
+	}
+	var req punchReq
+	if err := decode(buf, &req); err != nil {
+		m.logger.Printf("[ERR] memberlist: Failed to decode hole punch request: %s %s", err, LogAddress(from))
+		return
+	}
+
+	m.nodeLock.RLock()
+	a, aok := m.nodeMap[req.Node]
+	b, bok := m.nodeMap[req.Peer]
+	var nodes [2]Node
+	if aok && bok {
+		nodes = [2]Node{a.Node, b.Node}
+	}
+	m.nodeLock.RUnlock()
+	if !aok || !bok || a.DeadOrLeft() || b.DeadOrLeft() {
+		m.logger.Printf("[WARN] memberlist: Can't punch a hole between unknown or dead nodes %s and %s %s", req.Node, req.Peer, LogAddress(from))
+		return
+	}
+
+	// Send both as close together as we can.
+	for i, n := range nodes {
+		p := punch{Peer: nodes[1-i].Name, Rendezvous: m.config.Name}
+		if err := m.encodeAndSendMsg(n.FullAddress(), punchMsg, &p); err != nil {
+			m.logger.Printf("[ERR] memberlist: Failed to send hole punch to %s: %s", n.Name, err)
+		}
+	}
+}
+
+// handlePunch pings a peer to open our NAT for it, and falls back to relaying
+// through the rendezvous if we don't hear back.
+func (m *Memberlist) handlePunch(buf []byte, from net.Addr) {
+	if !m.config.HolePunching {
+		return
+	}
+	var p punch
+	if err := decode(buf, &p); err != nil {
+		m.logger.Printf("[ERR] memberlist: Failed to decode hole punch: %s %s", err, LogAddress(from))
+		return
+	}
+
+	m.nodeLock.RLock()
+	peerState, pok := m.nodeMap[p.Peer]
+	rvState, rok := m.nodeMap[p.Rendezvous]
+	var peer, rendezvous Node
+	if pok && rok {
+		peer, rendezvous = peerState.Node, rvState.Node
+	}
+	m.nodeLock.RUnlock()
+	if !pok || !rok || p.Peer == m.config.Name {
+		m.logger.Printf("[WARN] memberlist: Ignoring hole punch to unknown node %s %s", p.Peer, LogAddress(from))
+		return
+	}
+
+	// Send directly while we're punching, whatever we did before.
+	m.punchLock.Lock()
+	if m.punches == nil {
+		m.punches = make(map[string]*punchState)
+	}
+	m.punches[peer.Name] = &punchState{retry: time.Now().Add(holePunchRetry)}
+	m.punchLock.Unlock()
+
+	selfAddr, selfPort := m.getAdvertise()
+	ping := ping{
+		SeqNo:      m.nextSeqNo(),
+		Node:       peer.Name,
+		SourceAddr: selfAddr,
+		SourcePort: selfPort,
+		SourceNode: m.config.Name,
+	}
+	timeout := holePunchPings*holePunchSpacing + m.probeTimeout()
+	var acked atomic.Bool
+	m.setAckHandler(ping.SeqNo, func([]byte, time.Time) { acked.Store(true) }, timeout)
+	m.timers.AfterFunc(timeout, func() {
+		if acked.Load() {
+			metrics.IncrCounterWithLabels([]string{"memberlist", "holepunch", "punched"}, 1, m.metricLabels)
+			m.logger.Printf("[INFO] memberlist: Punched a hole to %s", peer.Name)
+			return
+		}
+		m.relayThrough(peer.Name, rendezvous.FullAddress())
+	})
+
+	go func() {
+		for i := 0; i < holePunchPings && !acked.Load(); i++ {
+			if i > 0 {
+				time.Sleep(holePunchSpacing)
+			}
+			if err := m.encodeAndSendMsg(peer.FullAddress(), pingMsg, &ping); err != nil {
+				m.logger.Printf("[ERR] memberlist: Failed to send hole punch ping to %s: %s", peer.Name, err)
+			}
+		}
+	}()
+}
+
+// relayThrough sends packets for a peer through a rendezvous until it's time
+// to punch again.
+func (m *Memberlist) relayThrough(peer string, rendezvous Address) {
+	m.punchLock.Lock()
+	defer m.punchLock.Unlock()
+	// Don't relay through a node we relay to ourselves, or to a node we
+	// relay through, or we'd go round in circles.
+	if st, ok := m.punches[rendezvous.Name]; ok && st.relay != nil {
+		return
+	}
+	for _, st := range m.punches {
+		if st.relay != nil && st.relay.Name == peer {
+			return
+		}
+	}
+	m.punches[peer] = &punchState{retry: time.Now().Add(holePunchRetry), relay: &rendezvous}
+	metrics.IncrCounterWithLabels([]string{"memberlist", "holepunch", "relayed"}, 1, m.metricLabels)
+	m.logger.Printf("[INFO] memberlist: Failed to punch a hole to %s, relaying through %s", peer, rendezvous.Name)
+}
+
+// punchRelay returns the rendezvous to send a peer's packets through, if
+// we're relaying to it.
+func (m *Memberlist) punchRelay(peer string) (Address, bool) {
+	if !m.config.HolePunching || peer == "" {
+		return Address{}, false
+	}
+	m.punchLock.Lock()
+	defer m.punchLock.Unlock()
+	st, ok := m.punches[peer]
+	if !ok || st.relay == nil {
+		return Address{}, false
+	}
+	if time.Now().After(st.retry) {
+		delete(m.punches, peer)
+		return Address{}, false
+	}
+	return *st.relay, true
+}
+
+// relayPacket sends a packet for a peer through a rendezvous.
+func (m *Memberlist) relayPacket(rendezvous Address, peer string, msg []byte) error {
+	out, err := encode(relayMsg, &relay{Node: peer, Payload: msg}, m.config.MsgpackUseNewTimeFormat)
+	if err != nil {
+		return err
+	}
+	return m.rawSendMsgPacket(rendezvous, nil, out.Bytes())
+}
+
+// relayable returns true if we forward relayed messages of the given type.
+func (m *Memberlist) relayable(t messageType) bool {
+	switch t {
+	case userMsg, broadcastAckMsg:
+		return true
+	case relayMsg:
+		return false
+	default:
+		return m.config.HolePunching
+	}
+}
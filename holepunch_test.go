@@ -0,0 +1,111 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// natTransport drops packets to blocked addresses, like a NAT that hasn't
+// seen us send to them.
+type natTransport struct {
+	*NetTransport
+
+	lock    sync.Mutex
+	blocked map[string]bool
+}
+
+func (t *natTransport) block(addr string, blocked bool) {
+	t.lock.Lock()
+	t.blocked[addr] = blocked
+	t.lock.Unlock()
+}
+
+func (t *natTransport) WriteToAddress(b []byte, addr Address) (time.Time, error) {
+	t.lock.Lock()
+	blocked := t.blocked[addr.Addr]
+	t.lock.Unlock()
+	if blocked {
+		return time.Now(), nil
+	}
+	return t.NetTransport.WriteToAddress(b, addr)
+}
+
+func TestMemberlist_HolePunching(t *testing.T) {
+	var (
+		members    []*Memberlist
+		transports []*natTransport
+		delegates  []*MockDelegate
+	)
+	for i := 0; i < 3; i++ {
+		c := testConfig(t)
+		nt, err := NewNetTransport(&NetTransportConfig{
+			BindAddrs: []string{c.BindAddr},
+			Logger:    c.Logger,
+		})
+		require.NoError(t, err)
+		tr := &natTransport{NetTransport: nt, blocked: make(map[string]bool)}
+		d := &MockDelegate{}
+		c.Transport = tr
+		c.BindPort = nt.GetAutoBindPort()
+		c.AdvertisePort = c.BindPort
+		c.Delegate = d
+		c.HolePunching = true
+		c.ProbeInterval = 100 * time.Millisecond
+		c.ProbeTimeout = 50 * time.Millisecond
+		m, err := Create(c)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, m.Shutdown())
+		}()
+		if i > 0 {
+			_, err := m.Join([]string{members[0].config.Name + "/" + members[0].LocalNode().Address()})
+			require.NoError(t, err)
+		}
+		members = append(members, m)
+		transports = append(transports, tr)
+		delegates = append(delegates, d)
+	}
+	m1, m2, m3 := members[0], members[1], members[2]
+	require.Eventually(t, func() bool {
+		return m1.NumMembers() == 3 && m2.NumMembers() == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Nothing's in the way, so the punch gets through.
+	m1.requestPunch(m2.config.Name, []Node{*m3.LocalNode()})
+	require.Never(t, func() bool {
+		_, ok := m1.punchRelay(m2.config.Name)
+		return ok
+	}, 500*time.Millisecond, 10*time.Millisecond)
+
+	// Once the two can't reach each other, probes only get through
+	// indirectly, so they fall back to relaying through the third.
+	transports[0].block(m2.LocalNode().Address(), true)
+	transports[1].block(m1.LocalNode().Address(), true)
+	m1.punchLock.Lock()
+	m1.punches = nil
+	m1.punchLock.Unlock()
+	require.Eventually(t, func() bool {
+		r1, ok1 := m1.punchRelay(m2.config.Name)
+		r2, ok2 := m2.punchRelay(m1.config.Name)
+		return ok1 && ok2 && r1.Name == m3.config.Name && r2.Name == m3.config.Name
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, m1.SendBestEffort(m2.LocalNode(), []byte("hello")))
+	require.Eventually(t, func() bool {
+		for _, msg := range delegates[1].getMessages() {
+			if string(msg) == "hello" {
+				return true
+			}
+		}
+		return false
+	}, 5*time.Second, 10*time.Millisecond)
+	m1.nodeLock.RLock()
+	defer m1.nodeLock.RUnlock()
+	require.Equal(t, StateAlive, m1.nodeMap[m2.config.Name].State)
+}
@@ -0,0 +1,271 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	metrics "github.com/hashicorp/go-metrics/compat"
+)
+
+// PeerIdentity is what the certificate the peer presented on a TLS stream
+// says about it.
+type PeerIdentity struct {
+	// Certificate is the peer's leaf certificate. It has already been
+	// verified by the TLS handshake.
+	Certificate *x509.Certificate
+
+	// SPIFFEID is the peer's SPIFFE ID, such as
+	// spiffe://example.org/ns/prod/node-1, or empty if the certificate
+	// doesn't carry exactly one valid one as its only URI.
+	SPIFFEID string
+}
+
+// SANs returns all the subject alternative names in the certificate, as
+// strings.
+func (p PeerIdentity) SANs() []string {
+	c := p.Certificate
+	if c == nil {
+		return nil
+	}
+	sans := make([]string, 0, len(c.URIs)+len(c.DNSNames)+len(c.EmailAddresses)+len(c.IPAddresses))
+	for _, u := range c.URIs {
+		sans = append(sans, u.String())
+	}
+	sans = append(sans, c.DNSNames...)
+	sans = append(sans, c.EmailAddresses...)
+	for _, ip := range c.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	return sans
+}
+
+// Fingerprint returns the SHA-256 fingerprint of the peer's certificate, in
+// lower case hex, for pinning the certificates nodes may use.
+func (p PeerIdentity) Fingerprint() string {
+	if p.Certificate == nil {
+		return ""
+	}
+	sum := sha256.Sum256(p.Certificate.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// Authorizer decides whether the peer on a TLS stream may speak for the
+// named node, returning an error if not.
+//
+// For streams we open, node is the name of the node we meant to reach, if
+// we know it. Incoming streams don't say which node they're from before
+// they're read, so node is empty, and the Authorizer should allow any
+// identity that belongs to a member of the cluster.
+type Authorizer func(id PeerIdentity, node string) error
+
+// SPIFFEAuthorizer returns an Authorizer that allows peers with a SPIFFE ID
+// in the given trust domain. The node a peer may speak for comes from
+// nodeFor, which is given the path of the ID, such as /ns/prod/node-1. If
+// nodeFor is nil, it's the last segment of the path, node-1 here.
+func SPIFFEAuthorizer(trustDomain string, nodeFor func(path string) string) Authorizer {
+	if nodeFor == nil {
+		nodeFor = func(path string) string {
+			return path[strings.LastIndex(path, "/")+1:]
+		}
+	}
+	return func(id PeerIdentity, node string) error {
+		if id.SPIFFEID == "" {
+			return errors.New("certificate has no SPIFFE ID")
+		}
+		u, err := url.Parse(id.SPIFFEID)
+		if err != nil {
+			return err
+		}
+		if u.Host != trustDomain {
+			return fmt.Errorf("SPIFFE ID %s isn't in trust domain %q", id.SPIFFEID, trustDomain)
+		}
+		if node != "" && nodeFor(u.Path) != node {
+			return fmt.Errorf("SPIFFE ID %s can't speak for node %q", id.SPIFFEID, node)
+		}
+		return nil
+	}
+}
+
+// SANAuthorizer returns an Authorizer that allows peers with a subject
+// alternative name the pattern matches in full. If the pattern has a
+// subexpression named "node", what it matches is the node the peer may
+// speak for, for example `^spiffe://example\.org/nodes/(?P<node>[^/]+)$`
+// or `^(?P<node>[^.]+)\.cluster\.internal$`.
+func SANAuthorizer(pattern *regexp.Regexp) Authorizer {
+	nodeIdx := pattern.SubexpIndex("node")
+	return func(id PeerIdentity, node string) error {
+		for _, san := range id.SANs() {
+			match := pattern.FindStringSubmatchIndex(san)
+			if match == nil || match[0] != 0 || match[1] != len(san) {
+				continue
+			}
+			if node == "" || nodeIdx < 0 {
+				return nil
+			}
+			if start := match[2*nodeIdx]; start >= 0 && san[start:match[2*nodeIdx+1]] == node {
+				return nil
+			}
+		}
+		if node != "" && nodeIdx >= 0 {
+			return fmt.Errorf("no subject alternative name allows node %q", node)
+		}
+		return errors.New("no subject alternative name is allowed")
+	}
+}
+
+// CertPinAuthorizer returns an Authorizer that only lets a peer speak for a
+// node with a certificate pinned for that node, so even with a shared CA, a
+// stolen or wrongly issued certificate can't pass for another node. The
+// pins map node names to certificate fingerprints, as
+// PeerIdentity.Fingerprint gives them; upper case and colons, as openssl
+// prints them, are fine. Incoming streams need a certificate pinned for any
+// node, and nodes with no pins can't be reached at all. The pins are copied,
+// so to change them at runtime, write an Authorizer that looks them up
+// instead.
+//
+// This only checks the certificate is pinned, so it's usually combined with
+// another Authorizer, such as with AllAuthorizers.
+func CertPinAuthorizer(pins map[string][]string) Authorizer {
+	byNode := make(map[string]map[string]struct{}, len(pins))
+	all := make(map[string]struct{})
+	for node, fingerprints := range pins {
+		set := make(map[string]struct{}, len(fingerprints))
+		for _, fp := range fingerprints {
+			fp = strings.ToLower(strings.ReplaceAll(fp, ":", ""))
+			set[fp] = struct{}{}
+			all[fp] = struct{}{}
+		}
+		byNode[node] = set
+	}
+	return func(id PeerIdentity, node string) error {
+		fp := id.Fingerprint()
+		pinned := all
+		if node != "" {
+			pinned = byNode[node]
+		}
+		if _, ok := pinned[fp]; ok {
+			return nil
+		}
+		if node != "" {
+			return fmt.Errorf("certificate %s isn't pinned for node %q", fp, node)
+		}
+		return fmt.Errorf("certificate %s isn't pinned for any node", fp)
+	}
+}
+
+// AllAuthorizers returns an Authorizer that allows a peer only if every one
+// of the given ones does.
+func AllAuthorizers(authorizers ...Authorizer) Authorizer {
+	return func(id PeerIdentity, node string) error {
+		for _, auth := range authorizers {
+			if err := auth(id, node); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// peerIdentityOf builds the identity for a peer's leaf certificate.
+func peerIdentityOf(c *x509.Certificate) PeerIdentity {
+	id := PeerIdentity{Certificate: c}
+
+	// An X509-SVID carries exactly one URI, its SPIFFE ID.
+	if len(c.URIs) == 1 && isSPIFFEID(c.URIs[0]) {
+		id.SPIFFEID = c.URIs[0].String()
+	}
+	return id
+}
+
+var (
+	spiffeTrustDomain = regexp.MustCompile(`^[a-z0-9._-]+$`)
+	spiffePathSegment = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+)
+
+// isSPIFFEID returns true if the URI is a well formed SPIFFE ID for a
+// workload, as the SPIFFE ID specification describes.
+func isSPIFFEID(u *url.URL) bool {
+	if u.Scheme != "spiffe" || u.User != nil || u.Port() != "" ||
+		u.RawQuery != "" || u.Fragment != "" || u.Opaque != "" {
+		return false
+	}
+	if !spiffeTrustDomain.MatchString(u.Host) || u.Path == "" {
+		return false
+	}
+	for _, seg := range strings.Split(u.Path[1:], "/") {
+		if seg == "." || seg == ".." || !spiffePathSegment.MatchString(seg) {
+			return false
+		}
+	}
+	return true
+}
+
+// tlsConn is implemented by streams that were set up over TLS.
+type tlsConn interface {
+	net.Conn
+	HandshakeContext(ctx context.Context) error
+	ConnectionState() tls.ConnectionState
+}
+
+// authorizePushPull checks the peer on an incoming push/pull against the
+// member it says it's from, which covers the state it pushes for itself. The
+// stream was only authorized as some member, so one that doesn't say who it's
+// from is refused.
+func (m *Memberlist) authorizePushPull(conn net.Conn, from string) error {
+	if m.config.Authorizer == nil {
+		return nil
+	}
+	if from == "" {
+		return errors.New("push/pull doesn't say who it's from")
+	}
+	return m.authorizeStream(conn, from)
+}
+
+// authorizeStream checks the peer on a stream with the configured
+// Authorizer, if there is one. The stream has to be TLS, with a verified
+// peer certificate, so there's an identity to check.
+func (m *Memberlist) authorizeStream(conn net.Conn, node string) error {
+	if m.config.Authorizer == nil {
+		return nil
+	}
+
+	// Look through the wrapper left by reading the label header.
+	for {
+		pc, ok := conn.(*peekedConn)
+		if !ok {
+			break
+		}
+		conn = pc.Conn
+	}
+	tc, ok := conn.(tlsConn)
+	if !ok {
+		return errors.New("stream isn't using TLS, so the peer has no identity")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), m.config.TCPTimeout)
+	defer cancel()
+	if err := tc.HandshakeContext(ctx); err != nil {
+		return fmt.Errorf("TLS handshake failed: %v", err)
+	}
+	certs := tc.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return errors.New("peer didn't present a certificate")
+	}
+
+	if err := m.config.Authorizer(peerIdentityOf(certs[0]), node); err != nil {
+		metrics.IncrCounterWithLabels([]string{"memberlist", "tcp", "unauthorized"}, 1, m.metricLabels)
+		return fmt.Errorf("peer isn't authorized: %v", err)
+	}
+	return nil
+}
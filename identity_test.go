@@ -0,0 +1,280 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testPKI is a throwaway CA for issuing node certificates in tests.
+type testPKI struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &testPKI{cert: cert, key: key, pool: pool}
+}
+
+// tlsConfig issues a certificate with the given URI and IP and returns a
+// mutual TLS config using it.
+func (p *testPKI) tlsConfig(t *testing.T, uri string, ip string) *tls.Config {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	u, err := url.Parse(uri)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		URIs:         []*url.URL{u},
+		IPAddresses:  []net.IP{net.ParseIP(ip)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, p.cert, &key.PublicKey, p.key)
+	require.NoError(t, err)
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		RootCAs:      p.pool,
+		ClientCAs:    p.pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+}
+
+func TestMemberlist_TLSAuthorizer(t *testing.T) {
+	pki := newTestPKI(t)
+	create := func(name, uri string) *Memberlist {
+		c := testConfig(t)
+		c.Name = name
+		c.TLSConfig = pki.tlsConfig(t, uri, c.BindAddr)
+		c.Authorizer = SPIFFEAuthorizer("example.org", nil)
+		m, err := Create(c)
+		require.NoError(t, err)
+		return m
+	}
+
+	m1 := create("node-1", "spiffe://example.org/nodes/node-1")
+	defer func() {
+		require.NoError(t, m1.Shutdown())
+	}()
+	m2 := create("node-2", "spiffe://example.org/nodes/node-2")
+	defer func() {
+		require.NoError(t, m2.Shutdown())
+	}()
+
+	seed := "node-1/" + m1.LocalNode().Address()
+	n, err := m2.Join([]string{seed})
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, 2, m2.NumMembers())
+
+	// A node from another trust domain is turned away by m1.
+	m3 := create("node-3", "spiffe://other.org/nodes/node-3")
+	defer func() {
+		require.NoError(t, m3.Shutdown())
+	}()
+	_, err = m3.Join([]string{seed})
+	require.Error(t, err)
+
+	// And m1's certificate doesn't let it pass for anyone else.
+	_, err = m2.Join([]string{"node-9/" + m1.LocalNode().Address()})
+	require.ErrorContains(t, err, `can't speak for node "node-9"`)
+}
+
+// fingerprintOf returns the fingerprint of the certificate in a TLS config.
+func fingerprintOf(t *testing.T, tc *tls.Config) string {
+	cert, err := x509.ParseCertificate(tc.Certificates[0].Certificate[0])
+	require.NoError(t, err)
+	return PeerIdentity{Certificate: cert}.Fingerprint()
+}
+
+func TestCertPinAuthorizer(t *testing.T) {
+	cert := &x509.Certificate{Raw: []byte("node-1")}
+	id := PeerIdentity{Certificate: cert}
+	var colons []string
+	for i := 0; i < len(id.Fingerprint()); i += 2 {
+		colons = append(colons, strings.ToUpper(id.Fingerprint()[i:i+2]))
+	}
+	auth := CertPinAuthorizer(map[string][]string{
+		"node-1": {strings.Join(colons, ":")},
+		"node-2": {"00"},
+	})
+
+	require.NoError(t, auth(id, "node-1"))
+	require.NoError(t, auth(id, ""))
+	require.ErrorContains(t, auth(id, "node-2"), `isn't pinned for node "node-2"`)
+	require.ErrorContains(t, auth(id, "node-3"), `isn't pinned for node "node-3"`)
+
+	other := PeerIdentity{Certificate: &x509.Certificate{Raw: []byte("node-9")}}
+	require.ErrorContains(t, auth(other, ""), "isn't pinned for any node")
+}
+
+func TestMemberlist_CertPinning(t *testing.T) {
+	pki := newTestPKI(t)
+	configs := make(map[string]*Config)
+	pins := make(map[string][]string)
+	for _, name := range []string{"node-1", "node-2", "impostor"} {
+		c := testConfig(t)
+		c.Name = name
+		if name == "impostor" {
+			// The CA issues a perfectly good certificate for node-1.
+			c.TLSConfig = pki.tlsConfig(t, "spiffe://example.org/nodes/node-1", c.BindAddr)
+		} else {
+			c.TLSConfig = pki.tlsConfig(t, "spiffe://example.org/nodes/"+name, c.BindAddr)
+			pins[name] = []string{fingerprintOf(t, c.TLSConfig)}
+		}
+		configs[name] = c
+	}
+	var members []*Memberlist
+	for _, name := range []string{"node-1", "node-2", "impostor"} {
+		c := configs[name]
+		c.Authorizer = AllAuthorizers(SPIFFEAuthorizer("example.org", nil), CertPinAuthorizer(pins))
+		m, err := Create(c)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, m.Shutdown())
+		}()
+		members = append(members, m)
+	}
+	m1, m2, impostor := members[0], members[1], members[2]
+
+	_, err := m2.Join([]string{"node-1/" + m1.LocalNode().Address()})
+	require.NoError(t, err)
+
+	// The impostor's certificate would pass the SPIFFE check for node-1,
+	// but it isn't the one pinned for it.
+	_, err = m2.Join([]string{"node-1/" + impostor.LocalNode().Address()})
+	require.ErrorContains(t, err, fmt.Sprintf(`certificate %s isn't pinned for node "node-1"`, fingerprintOf(t, configs["impostor"].TLSConfig)))
+	_, err = impostor.Join([]string{"node-2/" + m2.LocalNode().Address()})
+	require.Error(t, err)
+}
+
+func TestMemberlist_CertPinning_PushPullFrom(t *testing.T) {
+	pki := newTestPKI(t)
+	configs := make(map[string]*Config)
+	pins := make(map[string][]string)
+	for _, name := range []string{"node-1", "node-2"} {
+		c := testConfig(t)
+		c.Name = name
+		c.TLSConfig = pki.tlsConfig(t, "spiffe://example.org/nodes/"+name, c.BindAddr)
+		pins[name] = []string{fingerprintOf(t, c.TLSConfig)}
+		configs[name] = c
+	}
+	// The thief has node-1's certificate and key, but claims to be node-2.
+	c := testConfig(t)
+	c.Name = "node-2"
+	c.TLSConfig = configs["node-1"].TLSConfig.Clone()
+	configs["thief"] = c
+
+	var members []*Memberlist
+	for _, name := range []string{"node-1", "thief"} {
+		c := configs[name]
+		c.Authorizer = AllAuthorizers(SPIFFEAuthorizer("example.org", nil), CertPinAuthorizer(pins))
+		m, err := Create(c)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, m.Shutdown())
+		}()
+		members = append(members, m)
+	}
+	m1, thief := members[0], members[1]
+
+	// The certificate is pinned for node-1, so the stream itself is allowed,
+	// but not the push/pull from node-2.
+	_, err := thief.Join([]string{"node-1/" + m1.LocalNode().Address()})
+	require.Error(t, err)
+	require.Equal(t, 1, m1.NumMembers())
+
+	// Nor one that doesn't say who it's from.
+	anon := GetMemberlist(t, func(c *Config) {
+		c.Name = ""
+		c.TLSConfig = configs["node-1"].TLSConfig.Clone()
+		c.Authorizer = CertPinAuthorizer(pins)
+	})
+	defer func() {
+		require.NoError(t, anon.Shutdown())
+	}()
+	_, _, err = anon.sendAndReceiveState(Address{Addr: m1.LocalNode().Address(), Name: "node-1"}, false)
+	require.Error(t, err)
+}
+
+func TestMemberlist_AuthorizerNeedsTLS(t *testing.T) {
+	c := testConfig(t)
+	c.Authorizer = SPIFFEAuthorizer("example.org", nil)
+	_, err := Create(c)
+	require.ErrorContains(t, err, "TLSConfig")
+}
+
+func TestPeerIdentity_SPIFFEID(t *testing.T) {
+	cases := map[string]bool{
+		"spiffe://example.org/nodes/node-1": true,
+		"spiffe://example.org":              false,
+		"spiffe://example.org/":             false,
+		"spiffe://example.org/nodes/":       false,
+		"spiffe://example.org/nodes/../x":   false,
+		"spiffe://Example.org/nodes/node-1": false,
+		"spiffe://example.org:80/node":      false,
+		"spiffe://example.org/node?x=1":     false,
+		"https://example.org/nodes/node-1":  false,
+	}
+	for uri, valid := range cases {
+		u, err := url.Parse(uri)
+		require.NoError(t, err)
+		id := peerIdentityOf(&x509.Certificate{URIs: []*url.URL{u}})
+		if valid {
+			require.Equal(t, uri, id.SPIFFEID, uri)
+		} else {
+			require.Empty(t, id.SPIFFEID, uri)
+		}
+	}
+}
+
+func TestSANAuthorizer(t *testing.T) {
+	auth := SANAuthorizer(regexp.MustCompile(`(?P<node>[^.]+)\.cluster\.internal`))
+	id := PeerIdentity{Certificate: &x509.Certificate{
+		DNSNames: []string{"node-1.cluster.internal", "node-1.cluster.internal.evil.com"},
+	}}
+
+	require.NoError(t, auth(id, ""))
+	require.NoError(t, auth(id, "node-1"))
+	require.Error(t, auth(id, "node-2"))
+
+	// Partial matches don't count.
+	id.Certificate.DNSNames = []string{"node-1.cluster.internal.evil.com"}
+	require.Error(t, auth(id, ""))
+}
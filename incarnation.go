@@ -0,0 +1,118 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// incarnationFile is the name of the file under Config.StateDir that holds
+// the last incarnation number we used.
+const incarnationFile = "incarnation"
+
+// loadIncarnation returns the incarnation number saved in the given state
+// directory, creating the directory if needed. It returns zero if nothing
+// was saved yet.
+func loadIncarnation(dir string) (uint32, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return 0, fmt.Errorf("failed to create state dir: %v", err)
+	}
+
+	buf, err := os.ReadFile(filepath.Join(dir, incarnationFile))
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to read incarnation: %v", err)
+	}
+
+	inc, err := strconv.ParseUint(strings.TrimSpace(string(buf)), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse incarnation: %v", err)
+	}
+	return uint32(inc), nil
+}
+
+// saveIncarnation writes the incarnation number to the given state
+// directory. The file is replaced atomically so a crash never leaves a
+// partial write behind.
+func saveIncarnation(dir string, inc uint32) error {
+	path := filepath.Join(dir, incarnationFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.FormatUint(uint64(inc), 10)), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// persistIncarnation saves the incarnation number if we have a state
+// directory. Concurrent bumps can finish in any order, so we never save a
+// lower number than we already have.
+func (m *Memberlist) persistIncarnation(inc uint32) {
+	if m.config.StateDir == "" {
+		return
+	}
+
+	m.incarnationLock.Lock()
+	defer m.incarnationLock.Unlock()
+
+	if !incarnationAfter(inc, m.savedIncarnation) {
+		return
+	}
+	if err := saveIncarnation(m.config.StateDir, inc); err != nil {
+		m.logger.Printf("[ERR] memberlist: Failed to save incarnation: %v", err)
+		return
+	}
+	m.savedIncarnation = inc
+}
+
+// incarnationAfter returns true if incarnation a is later than b. The
+// comparison uses serial number arithmetic (RFC 1982), so it stays right when
+// a node's incarnation number wraps around, as long as the two are less than
+// 2^31 apart. Zero is never used once a number wraps, so it's kept as the
+// lowest incarnation, for nodes we haven't heard an incarnation for yet.
+func incarnationAfter(a, b uint32) bool {
+	switch {
+	case a == 0:
+		return false
+	case b == 0:
+		return true
+	default:
+		return int32(a-b) > 0
+	}
+}
+
+// wrapIncarnation returns the incarnation number to use in place of next when
+// bumping it from cur. If next wrapped around, it skips zero, unless a live
+// member has too old a protocol version to compare wrapped incarnations, in
+// which case it stays at the highest number and logs an error instead.
+// canWrap checks the members, and is only called when next wrapped.
+func (m *Memberlist) wrapIncarnation(cur, next uint32, canWrap func() bool) uint32 {
+	if next >= cur {
+		return next
+	}
+	if !canWrap() {
+		m.logger.Printf("[ERR] memberlist: Incarnation number can't wrap around until every member supports protocol version 8")
+		return math.MaxUint32
+	}
+	if next == 0 {
+		next = 1
+	}
+	return next
+}
+
+// canWrapIncarnation returns true if every live member understands wrapped
+// incarnation numbers. You must hold the node lock.
+func (m *Memberlist) canWrapIncarnation() bool {
+	for _, n := range m.nodes {
+		if n.State == StateAlive && !n.Supports(FeatureIncarnationWrap) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIncarnation_LoadSave(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "state")
+
+	inc, err := loadIncarnation(dir)
+	require.NoError(t, err)
+	require.Equal(t, uint32(0), inc)
+
+	require.NoError(t, saveIncarnation(dir, 42))
+	inc, err = loadIncarnation(dir)
+	require.NoError(t, err)
+	require.Equal(t, uint32(42), inc)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, incarnationFile), []byte("junk"), 0600))
+	_, err = loadIncarnation(dir)
+	require.Error(t, err)
+}
+
+func TestMemberlist_StateDir(t *testing.T) {
+	dir := t.TempDir()
+
+	c := testConfig(t)
+	c.StateDir = dir
+	m, err := Create(c)
+	require.NoError(t, err)
+	first := atomic.LoadUint32(&m.incarnation)
+	require.NoError(t, m.Shutdown())
+
+	// A restart picks up past the previous incarnation.
+	c = testConfig(t)
+	c.StateDir = dir
+	m, err = Create(c)
+	require.NoError(t, err)
+	defer m.Shutdown()
+	require.Greater(t, atomic.LoadUint32(&m.incarnation), first)
+
+	inc, err := loadIncarnation(dir)
+	require.NoError(t, err)
+	require.Equal(t, atomic.LoadUint32(&m.incarnation), inc)
+}
+
+func TestIncarnationAfter(t *testing.T) {
+	require.True(t, incarnationAfter(2, 1))
+	require.False(t, incarnationAfter(1, 2))
+	require.False(t, incarnationAfter(1, 1))
+
+	// Zero is older than anything
+	require.True(t, incarnationAfter(1, 0))
+	require.True(t, incarnationAfter(math.MaxUint32, 0))
+	require.False(t, incarnationAfter(0, math.MaxUint32))
+
+	// Wrapped numbers are later than the ones just before the wrap
+	require.True(t, incarnationAfter(1, math.MaxUint32))
+	require.True(t, incarnationAfter(5, math.MaxUint32-5))
+	require.False(t, incarnationAfter(math.MaxUint32, 1))
+}
+
+func TestMemberList_IncarnationWrap(t *testing.T) {
+	m := GetMemberlist(t, nil)
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+	a := alive{Node: "old", Addr: []byte{127, 0, 0, 1}, Port: 1, Incarnation: 1, Vsn: []uint8{1, 7, 7, 0, 0, 0}}
+	m.aliveNode(&a, nil, false)
+
+	// A member that can't compare wrapped numbers holds us at the top
+	atomic.StoreUint32(&m.incarnation, math.MaxUint32-1)
+	require.Equal(t, uint32(math.MaxUint32), m.nextIncarnation())
+	require.Equal(t, uint32(math.MaxUint32), m.nextIncarnation())
+
+	// Once it's gone, we wrap and skip zero
+	m.deadNode(&dead{Node: "old", Incarnation: 1, From: "old"})
+	require.Equal(t, uint32(1), m.nextIncarnation())
+
+	// And others take the wrapped number as the later one
+	a = alive{Node: "new", Addr: []byte{127, 0, 0, 2}, Port: 1, Incarnation: math.MaxUint32, Vsn: m.config.BuildVsnArray()}
+	m.aliveNode(&a, nil, false)
+	m.changeNode("new", func(state *nodeState) {
+		state.StateChange = state.StateChange.Add(-time.Hour)
+	})
+	m.suspectNode(&suspect{Node: "new", Incarnation: math.MaxUint32})
+	require.Equal(t, StateSuspect, m.getNodeState("new"))
+	a.Incarnation = 1
+	m.aliveNode(&a, nil, false)
+	require.Equal(t, StateAlive, m.getNodeState("new"))
+}
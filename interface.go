@@ -0,0 +1,145 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"fmt"
+	"net"
+)
+
+// interfaceAddrs returns the usable unicast addresses of the named network
+// interface, IPv4 first. Link-local addresses are skipped since they can't be
+// used without a zone.
+func interfaceAddrs(name string) ([]net.IP, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get addresses of interface %q: %v", name, err)
+	}
+
+	var v4, v6 []net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		ip := ipNet.IP
+		if ip.IsLinkLocalUnicast() || ip.IsMulticast() || ip.IsUnspecified() {
+			continue
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			v4 = append(v4, ip4)
+		} else {
+			v6 = append(v6, ip)
+		}
+	}
+	ips := append(v4, v6...)
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("interface %q has no usable addresses", name)
+	}
+	return ips, nil
+}
+
+// interfaceBindAddrs returns the addresses of the named interface as strings
+// for NetTransportConfig.BindAddrs.
+func interfaceBindAddrs(name string) ([]string, error) {
+	ips, err := interfaceAddrs(name)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		out = append(out, ip.String())
+	}
+	return out, nil
+}
+
+// interfaceAdvertiseAddr picks the address of the named interface to
+// advertise, preferring a private one.
+func interfaceAdvertiseAddr(name string) (string, error) {
+	ips, err := interfaceAddrs(name)
+	if err != nil {
+		return "", err
+	}
+	for _, ip := range ips {
+		if ip.IsPrivate() {
+			return ip.String(), nil
+		}
+	}
+	return ips[0].String(), nil
+}
+
+// DetectAdvertiseAddr picks the address to advertise for a node bound to an
+// unspecified address such as 0.0.0.0. It looks at the addresses on every
+// interface that's up, keeping those the filter accepts, and returns the one
+// that's left, preferring IPv4 if there are both. If several are left it
+// returns an error listing them, since guessing wrong leaves the node
+// unreachable; set Config.AdvertiseAddr, Config.AdvertiseInterface or a
+// stricter filter to settle it. A nil filter accepts private addresses on
+// interfaces other than loopback. Link-local addresses are always skipped.
+func DetectAdvertiseAddr(filter func(iface net.Interface, ip net.IP) bool) (net.IP, error) {
+	if filter == nil {
+		filter = func(iface net.Interface, ip net.IP) bool {
+			return iface.Flags&net.FlagLoopback == 0 && ip.IsPrivate()
+		}
+	}
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get interfaces: %v", err)
+	}
+	var ips []net.IP
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			ip := ipNet.IP
+			if ip.IsLinkLocalUnicast() || ip.IsMulticast() || ip.IsUnspecified() {
+				continue
+			}
+			if filter(iface, ip) {
+				ips = append(ips, ip)
+			}
+		}
+	}
+	return chooseAdvertiseAddr(ips)
+}
+
+// chooseAdvertiseAddr returns the only address in ips, or the only IPv4 one
+// if there are IPv6 addresses too.
+func chooseAdvertiseAddr(ips []net.IP) (net.IP, error) {
+	var v4, v6 []net.IP
+	for _, ip := range ips {
+		if ip4 := ip.To4(); ip4 != nil {
+			v4 = append(v4, ip4)
+		} else {
+			v6 = append(v6, ip)
+		}
+	}
+	candidates := v4
+	if len(candidates) == 0 {
+		candidates = v6
+	}
+
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("no suitable address found to advertise, set an advertise address")
+	case 1:
+		return candidates[0], nil
+	default:
+		return nil, fmt.Errorf("multiple addresses found to advertise %v, set an advertise address or interface to pick one", candidates)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// loopbackInterface returns the name of the loopback interface.
+func loopbackInterface(t *testing.T) string {
+	ifaces, err := net.Interfaces()
+	require.NoError(t, err)
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 && iface.Flags&net.FlagUp != 0 {
+			return iface.Name
+		}
+	}
+	t.Skip("no loopback interface")
+	return ""
+}
+
+func TestInterfaceAddrs(t *testing.T) {
+	lo := loopbackInterface(t)
+
+	ips, err := interfaceAddrs(lo)
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1", ips[0].String())
+
+	addr, err := interfaceAdvertiseAddr(lo)
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1", addr)
+
+	_, err = interfaceAddrs("does-not-exist0")
+	require.Error(t, err)
+}
+
+func TestMemberlist_BindInterface(t *testing.T) {
+	lo := loopbackInterface(t)
+
+	c := testConfig(t)
+	c.BindAddr = "192.0.2.1" // ignored
+	c.BindInterface = lo
+	m, err := Create(c)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+	require.Equal(t, "127.0.0.1", m.config.BindAddr)
+	require.Equal(t, net.ParseIP("127.0.0.1").To4(), m.LocalNode().Addr.To4())
+
+	c = testConfig(t)
+	c.BindInterface = "does-not-exist0"
+	_, err = Create(c)
+	require.Error(t, err)
+}
+
+func TestMemberlist_AdvertiseInterface(t *testing.T) {
+	lo := loopbackInterface(t)
+
+	c := testConfig(t)
+	c.AdvertiseInterface = lo
+	m, err := Create(c)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+	require.Equal(t, "127.0.0.1", m.LocalNode().Addr.String())
+	require.Equal(t, m.config.BindPort, int(m.LocalNode().Port))
+}
+
+func TestChooseAdvertiseAddr(t *testing.T) {
+	v4a, v4b := net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")
+	v6 := net.ParseIP("fd00::1")
+
+	ip, err := chooseAdvertiseAddr([]net.IP{v6, v4a})
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.1", ip.String())
+
+	ip, err = chooseAdvertiseAddr([]net.IP{v6})
+	require.NoError(t, err)
+	require.Equal(t, "fd00::1", ip.String())
+
+	_, err = chooseAdvertiseAddr([]net.IP{v4a, v4b, v6})
+	require.ErrorContains(t, err, "multiple addresses")
+	require.ErrorContains(t, err, "10.0.0.2")
+
+	_, err = chooseAdvertiseAddr(nil)
+	require.ErrorContains(t, err, "no suitable address")
+}
+
+func TestMemberlist_AdvertiseAddrFilter(t *testing.T) {
+	loopbackInterface(t)
+	loopback := func(iface net.Interface, ip net.IP) bool {
+		return ip.IsLoopback()
+	}
+
+	ip, err := DetectAdvertiseAddr(loopback)
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1", ip.String())
+
+	c := testConfig(t)
+	c.BindAddr = "0.0.0.0"
+	c.AdvertiseAddrFilter = loopback
+	m, err := Create(c)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+	require.Equal(t, "127.0.0.1", m.LocalNode().Addr.String())
+
+	c = testConfig(t)
+	c.BindAddr = "0.0.0.0"
+	c.AdvertiseAddrFilter = func(net.Interface, net.IP) bool { return false }
+	_, err = Create(c)
+	require.ErrorContains(t, err, "no suitable address")
+}
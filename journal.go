@@ -0,0 +1,222 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrJournalDisabled is returned by ReplayEvents when Config.EventJournalPath
+// isn't set.
+var ErrJournalDisabled = errors.New("memberlist: event journal is disabled")
+
+// Event is a membership event as it was recorded in the event journal.
+type Event struct {
+	Time time.Time
+	Type NodeEventType
+	Node Node
+}
+
+/*
+The journal is a file of events, one JSON object per line, that's appended to
+as events happen. To keep it within Config.EventJournalMaxSize it's rotated
+once it reaches half of that: the old file is kept with a ".1" suffix, and the
+one before it is dropped, so there's always at least half the limit's worth of
+history. Lines are written as they come without syncing, so a crash may lose
+the last few or leave a partial line behind, which replay skips.
+*/
+
+// eventJournal appends events to a size-bounded file.
+type eventJournal struct {
+	lock    sync.Mutex
+	path    string
+	maxSize int64
+	f       *os.File
+	size    int64
+
+	// Events are queued for journalWriter, so they can be recorded under
+	// the node lock without waiting on the disk.
+	pendingLock sync.Mutex
+	pending     []Event
+	wake        chan struct{}
+	done        chan struct{} // Closed once journalWriter has written the last of them
+}
+
+// openEventJournal opens the journal at the given path, creating it if
+// needed.
+func openEventJournal(path string, maxSize int64) (*eventJournal, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event journal: %v", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to open event journal: %v", err)
+	}
+	return &eventJournal{
+		path:    path,
+		maxSize: maxSize,
+		f:       f,
+		size:    info.Size(),
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// queue adds an event to be written by journalWriter.
+func (j *eventJournal) queue(e Event) {
+	j.pendingLock.Lock()
+	j.pending = append(j.pending, e)
+	j.pendingLock.Unlock()
+	select {
+	case j.wake <- struct{}{}:
+	default:
+	}
+}
+
+// takePending returns the queued events and empties the queue.
+func (j *eventJournal) takePending() []Event {
+	j.pendingLock.Lock()
+	defer j.pendingLock.Unlock()
+	events := j.pending
+	j.pending = nil
+	return events
+}
+
+// append writes an event to the journal, rotating it first if it's full.
+func (j *eventJournal) append(e Event) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	if j.f == nil {
+		return os.ErrClosed
+	}
+	if j.size > 0 && j.size+int64(len(line)) > j.maxSize/2 {
+		if err := j.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := j.f.Write(line)
+	j.size += int64(n)
+	return err
+}
+
+// rotate moves the journal aside and starts a new one. You must hold the
+// lock.
+func (j *eventJournal) rotate() error {
+	if err := j.f.Close(); err != nil {
+		return err
+	}
+	j.f = nil
+	if err := os.Rename(j.path, j.path+".1"); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(j.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	j.f, j.size = f, 0
+	return nil
+}
+
+// replay calls fn for each event recorded at or after since, oldest first.
+// The journal is read up front, so fn can take as long as it likes.
+func (j *eventJournal) replay(since time.Time, fn func(Event)) error {
+	var bufs [2][]byte
+	j.lock.Lock()
+	for i, path := range []string{j.path + ".1", j.path} {
+		buf, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			j.lock.Unlock()
+			return fmt.Errorf("failed to read event journal: %v", err)
+		}
+		bufs[i] = buf
+	}
+	j.lock.Unlock()
+
+	for _, buf := range bufs {
+		scanner := bufio.NewScanner(bytes.NewReader(buf))
+		scanner.Buffer(nil, len(buf)+1)
+		for scanner.Scan() {
+			var e Event
+			if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+				continue
+			}
+			if !e.Time.Before(since) {
+				fn(e)
+			}
+		}
+	}
+	return nil
+}
+
+// close closes the journal.
+func (j *eventJournal) close() error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	if j.f == nil {
+		return nil
+	}
+	err := j.f.Close()
+	j.f = nil
+	return err
+}
+
+// journalEvent records an event in the journal, if we keep one. It's written
+// in the background by journalWriter.
+func (m *Memberlist) journalEvent(typ NodeEventType, n *Node) {
+	if m.journal == nil {
+		return
+	}
+	m.journal.queue(Event{Time: time.Now(), Type: typ, Node: *n})
+}
+
+// journalWriter is a long running goroutine that writes the queued events to
+// the journal. At shutdown it writes what's left before it returns.
+func (m *Memberlist) journalWriter() {
+	j := m.journal
+	defer close(j.done)
+	for {
+		var stop bool
+		select {
+		case <-j.wake:
+		case <-m.shutdownCh:
+			stop = true
+		}
+		for _, e := range j.takePending() {
+			if err := j.append(e); err != nil {
+				m.logger.Printf("[ERR] memberlist: Failed to write to event journal: %v", err)
+			}
+		}
+		if stop {
+			return
+		}
+	}
+}
+
+// ReplayEvents calls fn for each membership event recorded in the journal at
+// or after since, oldest first, so the history of the membership can be
+// reconstructed, such as after an incident. Only as much history as fits in
+// Config.EventJournalMaxSize is kept, and events are written in the
+// background, so the very latest may not be there yet. It returns ErrJournalDisabled if
+// there's no journal.
+func (m *Memberlist) ReplayEvents(since time.Time, fn func(Event)) error {
+	if m.journal == nil {
+		return ErrJournalDisabled
+	}
+	return m.journal.replay(since, fn)
+}
@@ -0,0 +1,117 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventJournal_Rotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events")
+	j, err := openEventJournal(path, 8192)
+	require.NoError(t, err)
+
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		require.NoError(t, j.append(Event{Time: start.Add(time.Duration(i) * time.Second), Type: NodeJoin, Node: Node{Name: fmt.Sprintf("node-%d", i)}}))
+	}
+	require.NoError(t, j.close())
+
+	// Only the newest events are kept, within the limit.
+	var size int64
+	for _, p := range []string{path, path + ".1"} {
+		info, err := os.Stat(p)
+		require.NoError(t, err)
+		size += info.Size()
+	}
+	require.LessOrEqual(t, size, int64(8192))
+
+	// A partial line from a crash is skipped.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"Time":"2`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	j, err = openEventJournal(path, 8192)
+	require.NoError(t, err)
+	defer j.close()
+	var names []string
+	require.NoError(t, j.replay(start.Add(95*time.Second), func(e Event) {
+		names = append(names, e.Node.Name)
+	}))
+	require.Equal(t, []string{"node-95", "node-96", "node-97", "node-98", "node-99"}, names)
+}
+
+func TestMemberlist_ReplayEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events")
+	m1 := GetMemberlist(t, func(c *Config) {
+		c.EventJournalPath = path
+	})
+	defer func() {
+		require.NoError(t, m1.Shutdown())
+	}()
+	require.NoError(t, m1.setAlive())
+	m1.schedule()
+
+	var none Memberlist
+	require.ErrorIs(t, none.ReplayEvents(time.Time{}, func(Event) {}), ErrJournalDisabled)
+
+	m2 := GetMemberlist(t, nil)
+	require.NoError(t, m2.setAlive())
+	m2.schedule()
+	_, err := m2.Join([]string{m1.config.Name + "/" + m1.LocalNode().Address()})
+	require.NoError(t, err)
+	require.NoError(t, m2.Leave(time.Second))
+	require.NoError(t, m2.Shutdown())
+
+	type seen struct {
+		typ  NodeEventType
+		name string
+	}
+	want := []seen{
+		{NodeJoin, m1.config.Name},
+		{NodeJoin, m2.config.Name},
+		{NodeLeave, m2.config.Name},
+	}
+	var got []seen
+	require.Eventually(t, func() bool {
+		got = nil
+		require.NoError(t, m1.ReplayEvents(time.Time{}, func(e Event) {
+			got = append(got, seen{e.Type, e.Node.Name})
+		}))
+		return len(got) == len(want)
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, want, got)
+}
+
+func TestMemberlist_JournalWrittenAtShutdown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events")
+	m := GetMemberlist(t, func(c *Config) {
+		c.EventJournalPath = path
+	})
+
+	// Events are queued while we hold the node lock, and written after.
+	m.nodeLock.Lock()
+	for i := 0; i < 3; i++ {
+		m.journalEvent(NodeJoin, &Node{Name: fmt.Sprintf("node-%d", i)})
+	}
+	m.nodeLock.Unlock()
+	require.NoError(t, m.Shutdown())
+
+	j, err := openEventJournal(path, m.config.EventJournalMaxSize)
+	require.NoError(t, err)
+	defer j.close()
+	var names []string
+	require.NoError(t, j.replay(time.Time{}, func(e Event) {
+		names = append(names, e.Node.Name)
+	}))
+	require.Equal(t, []string{"node-0", "node-1", "node-2"}, names)
+}
@@ -0,0 +1,51 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"sync"
+)
+
+/*
+Keys live in slots carved out of pages that are locked into memory where the
+platform allows, so they're never written out to swap, and a slot is wiped as
+soon as its key is removed from a keyring. Pages are never given back: a
+packet being decrypted on another goroutine may still hold a key that's just
+been removed, and it's better for that to fail to decrypt with a wiped key
+than to fault on unmapped memory. For the same reason wiped slots aren't
+reused: a slice that's still held must keep reading as zeros rather than start
+aliasing a later key. A slot is small and keys don't change often, so the
+memory this holds on to stays small.
+*/
+
+// keySlotSize is room for the largest key, for AES-256.
+const keySlotSize = 32
+
+// keyMem is the pool of unused key slots, shared by every keyring.
+var keyMem struct {
+	sync.Mutex
+	free [][]byte
+}
+
+// allocKey copies a key into a locked slot. The key passed in isn't touched,
+// so it's up to the caller to wipe it if it's done with it.
+func allocKey(key []byte) []byte {
+	keyMem.Lock()
+	defer keyMem.Unlock()
+
+	if len(keyMem.free) == 0 {
+		page := allocLocked()
+		for i := 0; i+keySlotSize <= len(page); i += keySlotSize {
+			keyMem.free = append(keyMem.free, page[i:i+keySlotSize:i+keySlotSize])
+		}
+	}
+	slot := keyMem.free[len(keyMem.free)-1]
+	keyMem.free = keyMem.free[:len(keyMem.free)-1]
+	return slot[:copy(slot, key)]
+}
+
+// freeKey wipes a key from allocKey. Its slot isn't used again.
+func freeKey(key []byte) {
+	clear(key[:keySlotSize])
+}
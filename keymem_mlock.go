@@ -0,0 +1,23 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build linux || darwin
+
+package memberlist
+
+import (
+	"syscall"
+)
+
+// allocLocked returns a page of memory for keys, locked so it can't be
+// swapped out. If the page can't be locked, such as when RLIMIT_MEMLOCK is
+// used up, it's used anyway; keys are still wiped when they're removed.
+func allocLocked() []byte {
+	size := syscall.Getpagesize()
+	page, err := syscall.Mmap(-1, 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		return make([]byte, size)
+	}
+	_ = syscall.Mlock(page)
+	return page
+}
@@ -13,7 +13,9 @@ type Keyring struct {
 	// Keys stores the key data used during encryption and decryption. It is
 	// ordered in such a way where the first key (index 0) is the primary key,
 	// which is used for encrypting messages, and is the first key tried during
-	// message decryption.
+	// message decryption. Each key is in a slot from allocKey, and is wiped
+	// with freeKey once it's removed. The slice is replaced rather than
+	// changed in place, so what GetKeys returned stays as it was.
 	keys [][]byte
 
 	// The keyring lock is used while performing IO operations on the keyring.
@@ -76,6 +78,10 @@ func ValidateKey(key []byte) error {
 // it available for use in decryption. If the key already exists on the ring,
 // this function will just return noop.
 //
+// The keyring keeps its own copy of the key, in memory that's locked so it
+// isn't swapped out where the platform supports it, so the caller can wipe
+// theirs once it's been added.
+//
 // key should be either 16, 24, or 32 bytes to select AES-128,
 // AES-192, or AES-256.
 func (k *Keyring) AddKey(key []byte) error {
@@ -83,6 +89,9 @@ func (k *Keyring) AddKey(key []byte) error {
 		return err
 	}
 
+	k.l.Lock()
+	defer k.l.Unlock()
+
 	// No-op if key is already installed
 	for _, installedKey := range k.keys {
 		if bytes.Equal(installedKey, key) {
@@ -90,59 +99,69 @@ func (k *Keyring) AddKey(key []byte) error {
 		}
 	}
 
-	keys := append(k.keys, key)
-	primaryKey := k.GetPrimaryKey()
-	if primaryKey == nil {
-		primaryKey = key
-	}
-	k.installKeys(keys, primaryKey)
+	keys := make([][]byte, 0, len(k.keys)+1)
+	keys = append(keys, k.keys...)
+	k.keys = append(keys, allocKey(key))
 	return nil
 }
 
 // UseKey changes the key used to encrypt messages. This is the only key used to
 // encrypt messages, so peers should know this key before this method is called.
 func (k *Keyring) UseKey(key []byte) error {
-	for _, installedKey := range k.keys {
+	k.l.Lock()
+	defer k.l.Unlock()
+
+	for i, installedKey := range k.keys {
 		if bytes.Equal(key, installedKey) {
-			k.installKeys(k.keys, key)
+			keys := make([][]byte, 0, len(k.keys))
+			keys = append(keys, installedKey)
+			keys = append(keys, k.keys[:i]...)
+			k.keys = append(keys, k.keys[i+1:]...)
 			return nil
 		}
 	}
 	return fmt.Errorf("requested key is not in the keyring")
 }
 
-// RemoveKey drops a key from the keyring. This will return an error if the key
-// requested for removal is currently at position 0 (primary key).
+// RemoveKey drops a key from the keyring and wipes it from memory. This will
+// return an error if the key requested for removal is currently at position 0
+// (primary key).
 func (k *Keyring) RemoveKey(key []byte) error {
-	if bytes.Equal(key, k.keys[0]) {
+	k.l.Lock()
+	defer k.l.Unlock()
+
+	if len(k.keys) > 0 && bytes.Equal(key, k.keys[0]) {
 		return fmt.Errorf("removing the primary key is not allowed")
 	}
 	for i, installedKey := range k.keys {
 		if bytes.Equal(key, installedKey) {
-			keys := append(k.keys[:i], k.keys[i+1:]...)
-			k.installKeys(keys, k.keys[0])
+			keys := make([][]byte, 0, len(k.keys)-1)
+			keys = append(keys, k.keys[:i]...)
+			k.keys = append(keys, k.keys[i+1:]...)
+			freeKey(installedKey)
+			break
 		}
 	}
 	return nil
 }
 
-// installKeys will take out a lock on the keyring, and replace the keys with a
-// new set of keys. The key indicated by primaryKey will be installed as the new
-// primary key.
-func (k *Keyring) installKeys(keys [][]byte, primaryKey []byte) {
+// Wipe removes every key from the keyring, the primary key included, and
+// wipes them from memory, which disables encryption. Memberlist only wipes
+// a keyring it built itself from Config.SecretKey when it's shut down; one
+// passed in as Config.Keyring is left for its owner to wipe.
+func (k *Keyring) Wipe() {
 	k.l.Lock()
 	defer k.l.Unlock()
 
-	newKeys := [][]byte{primaryKey}
-	for _, key := range keys {
-		if !bytes.Equal(key, primaryKey) {
-			newKeys = append(newKeys, key)
-		}
+	for _, key := range k.keys {
+		freeKey(key)
 	}
-	k.keys = newKeys
+	k.keys = make([][]byte, 0)
 }
 
-// GetKeys returns the current set of keys on the ring.
+// GetKeys returns the current set of keys on the ring. The keys are the
+// keyring's own and must not be modified, and once a key has been removed
+// the slice holding it reads as zeros.
 func (k *Keyring) GetKeys() [][]byte {
 	k.l.Lock()
 	defer k.l.Unlock()
@@ -0,0 +1,199 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"time"
+
+	metrics "github.com/hashicorp/go-metrics/compat"
+)
+
+/*
+Rotating the encryption key of a running cluster takes three steps, each of
+which has to reach every member before the next one starts: install the new
+key everywhere so it can be decrypted, use it everywhere so it's what's sent,
+then remove the old one. InstallKey, UseKey and RemoveKey each make one of
+those changes locally and gossip it the same way as BroadcastWithAck, so one
+call on any member makes it cluster-wide, and the summary says which members
+confirmed having made it. A member only acknowledges a change once it's
+applied it, so it's safe to go on to the next step once nobody's missing.
+
+The keys themselves are gossiped, so these need encryption to be enforced
+both ways: a change is only sent if GossipVerifyOutgoing is set, and only
+acted on if GossipVerifyIncoming is, which means it arrived encrypted with a
+key we already trust.
+*/
+
+// keyringOp is a change to a keyring.
+type keyringOp uint8
+
+const (
+	keyringInstall keyringOp = iota
+	keyringUse
+	keyringRemove
+)
+
+func (op keyringOp) String() string {
+	switch op {
+	case keyringInstall:
+		return "install"
+	case keyringUse:
+		return "use"
+	case keyringRemove:
+		return "remove"
+	default:
+		return fmt.Sprintf("keyring-op(%d)", uint8(op))
+	}
+}
+
+// auditAction returns the action to report to the AuditHook.
+func (op keyringOp) auditAction() string {
+	switch op {
+	case keyringInstall:
+		return AuditInstallKey
+	case keyringUse:
+		return AuditUseKey
+	default:
+		return AuditRemoveKey
+	}
+}
+
+// apply makes the change to a keyring.
+func (op keyringOp) apply(k *Keyring, key []byte) error {
+	switch op {
+	case keyringInstall:
+		return k.AddKey(key)
+	case keyringUse:
+		return k.UseKey(key)
+	case keyringRemove:
+		return k.RemoveKey(key)
+	default:
+		return fmt.Errorf("unknown keyring operation %d", uint8(op))
+	}
+}
+
+// InstallKey adds a key to the keyring of every member, so they can decrypt
+// messages encrypted with it. The returned channel gets a summary of which
+// members confirmed it, as with BroadcastWithAck.
+func (m *Memberlist) InstallKey(key []byte, timeout time.Duration) (<-chan AckSummary, error) {
+	return m.changeKeyring(keyringInstall, key, timeout)
+}
+
+// UseKey makes a key that every member has installed the one they all
+// encrypt with. The returned channel gets a summary of which members
+// confirmed it, as with BroadcastWithAck.
+func (m *Memberlist) UseKey(key []byte, timeout time.Duration) (<-chan AckSummary, error) {
+	return m.changeKeyring(keyringUse, key, timeout)
+}
+
+// RemoveKey removes a key that's no longer used from the keyring of every
+// member. The returned channel gets a summary of which members confirmed
+// it, as with BroadcastWithAck.
+func (m *Memberlist) RemoveKey(key []byte, timeout time.Duration) (<-chan AckSummary, error) {
+	return m.changeKeyring(keyringRemove, key, timeout)
+}
+
+// changeKeyring makes a change to our keyring, and gossips it to the rest of
+// the cluster.
+func (m *Memberlist) changeKeyring(op keyringOp, key []byte, timeout time.Duration) (_ <-chan AckSummary, err error) {
+	defer func() { m.audit(op.auditAction(), KeyFingerprint(key), err) }()
+	if m.hasShutdown() {
+		return nil, ErrAlreadyShutdown
+	}
+	if timeout <= 0 {
+		return nil, fmt.Errorf("timeout must be positive")
+	}
+	if !m.config.EncryptionEnabled() || !m.config.GossipVerifyOutgoing {
+		return nil, fmt.Errorf("keys can only be changed across the cluster when outgoing gossip is encrypted")
+	}
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+
+	// If it doesn't work here there's little point in trying elsewhere.
+	if err := op.apply(m.config.Keyring, key); err != nil {
+		return nil, err
+	}
+
+	c := keyringChange{
+		ID:      m.broadcastID.Add(1),
+		Origin:  m.config.Name,
+		Timeout: timeout,
+		Op:      op,
+		Key:     key,
+	}
+	buf, err := encode(keyringMsg, &c, m.config.MsgpackUseNewTimeFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := m.trackBroadcast(c.ID, timeout)
+	m.queueKeyringBroadcast(broadcastKey(c.Origin, c.ID), buf.Bytes())
+	m.logger.Printf("[INFO] memberlist: Gossiping keyring change: %s", op)
+	return ch, nil
+}
+
+// handleKeyring applies a keyring change we haven't seen yet, passes it on
+// and acknowledges it. It's only passed on if our outgoing gossip is
+// encrypted, as the key would otherwise go out in the clear.
+func (m *Memberlist) handleKeyring(buf []byte, from net.Addr) {
+	if !m.config.EncryptionEnabled() || !m.config.GossipVerifyIncoming {
+		m.logger.Printf("[WARN] memberlist: Ignoring keyring change, incoming gossip isn't verified %s", LogAddress(from))
+		return
+	}
+
+	var c keyringChange
+	if err := decode(buf, &c); err != nil {
+		m.logger.Printf("[ERR] memberlist: Failed to decode keyring change: %s %s", err, LogAddress(from))
+		return
+	}
+	defer clear(c.Key) // The keyring has its own copy
+	if c.Origin == m.config.Name || !m.firstSeen(c.Origin, c.ID, c.Timeout) {
+		return
+	}
+
+	// Pass it on as it came, whether or not it works here
+	if m.config.GossipVerifyOutgoing {
+		out := make([]byte, 1, len(buf)+1)
+		out[0] = byte(keyringMsg)
+		out = append(out, buf...)
+		m.queueKeyringBroadcast(broadcastKey(c.Origin, c.ID), out)
+	} else {
+		m.logger.Printf("[WARN] memberlist: Not passing on keyring change from %s, outgoing gossip isn't encrypted", c.Origin)
+	}
+
+	if err := c.Op.apply(m.config.Keyring, c.Key); err != nil {
+		m.logger.Printf("[ERR] memberlist: Failed to %s key from %s: %s", c.Op, c.Origin, err)
+		return
+	}
+	metrics.IncrCounterWithLabels([]string{"memberlist", "keyring", c.Op.String()}, 1, m.metricLabels)
+	m.logger.Printf("[INFO] memberlist: Applied keyring change from %s: %s", c.Origin, c.Op)
+	m.sendBroadcastAck(c.Origin, c.ID)
+}
+
+// keyringBroadcast is a keyring change in the broadcast queue. Its message
+// holds the raw key, so it's wiped as soon as the queue is done with it,
+// whether it went out the full number of times or was invalidated. The queue
+// hands out the message for its last transmit right before it's finished, so
+// it hands out copies.
+type keyringBroadcast struct {
+	memberlistBroadcast
+}
+
+func (b *keyringBroadcast) Message() []byte {
+	return bytes.Clone(b.msg)
+}
+
+func (b *keyringBroadcast) Finished() {
+	clear(b.msg)
+	b.memberlistBroadcast.Finished()
+}
+
+// queueKeyringBroadcast queues a keyring change, see keyringBroadcast.
+func (m *Memberlist) queueKeyringBroadcast(name string, msg []byte) {
+	m.broadcasts.QueueBroadcast(&keyringBroadcast{memberlistBroadcast{name, msg, nil}})
+}
@@ -0,0 +1,159 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberlist_KeyRotation(t *testing.T) {
+	oldKey := bytes.Repeat([]byte{1}, 16)
+	newKey := bytes.Repeat([]byte{2}, 16)
+
+	var ms []*Memberlist
+	for i := 0; i < 3; i++ {
+		keyring, err := NewKeyring(nil, oldKey)
+		require.NoError(t, err)
+		c := testConfig(t)
+		c.GossipInterval = 10 * time.Millisecond
+		c.Keyring = keyring
+		if i > 0 {
+			c.BindPort = ms[0].config.BindPort
+		}
+		m, err := Create(c)
+		require.NoError(t, err)
+		defer m.Shutdown()
+		if i > 0 {
+			_, err := m.Join([]string{ms[0].config.Name + "/" + ms[0].config.BindAddr})
+			require.NoError(t, err)
+		}
+		ms = append(ms, m)
+	}
+
+	// Everyone has to know everyone before acks can be counted.
+	for _, m := range ms {
+		require.Eventually(t, func() bool {
+			return m.NumMembers() == 3
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+
+	// Every change is made from ms[1], which the others confirm.
+	confirmed := func(ch <-chan AckSummary, err error) {
+		t.Helper()
+		require.NoError(t, err)
+		select {
+		case summary := <-ch:
+			require.ElementsMatch(t, []string{ms[0].config.Name, ms[2].config.Name}, summary.Acked)
+			require.Empty(t, summary.Missing)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the acks")
+		}
+	}
+
+	// Rotate, checking everyone has made each step.
+	confirmed(ms[1].InstallKey(newKey, 5*time.Second))
+	for _, m := range ms {
+		require.Len(t, m.config.Keyring.GetKeys(), 2)
+		require.Equal(t, oldKey, m.config.Keyring.GetPrimaryKey())
+	}
+	confirmed(ms[1].UseKey(newKey, 5*time.Second))
+	for _, m := range ms {
+		require.Equal(t, newKey, m.config.Keyring.GetPrimaryKey())
+	}
+	confirmed(ms[1].RemoveKey(oldKey, 5*time.Second))
+	for _, m := range ms {
+		require.Equal(t, [][]byte{newKey}, m.config.Keyring.GetKeys())
+	}
+
+	// A change that doesn't work locally isn't sent.
+	_, err := ms[1].RemoveKey(newKey, time.Second)
+	require.Error(t, err)
+	_, err = ms[1].InstallKey([]byte("short"), time.Second)
+	require.Error(t, err)
+}
+
+func TestMemberlist_KeyRotation_RequiresEncryption(t *testing.T) {
+	m := GetMemberlist(t, nil)
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	_, err := m.InstallKey(bytes.Repeat([]byte{1}, 16), time.Second)
+	require.ErrorContains(t, err, "encrypted")
+}
+
+func TestMemberlist_HandleKeyring_OutgoingUnverified(t *testing.T) {
+	oldKey := bytes.Repeat([]byte{1}, 16)
+	newKey := bytes.Repeat([]byte{2}, 16)
+
+	keyring, err := NewKeyring(nil, oldKey)
+	require.NoError(t, err)
+	m := GetMemberlist(t, func(c *Config) {
+		c.Keyring = keyring
+		c.GossipVerifyOutgoing = false
+	})
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	change := keyringChange{ID: 1, Origin: "other", Timeout: time.Minute, Op: keyringInstall, Key: newKey}
+	buf, err := encode(keyringMsg, &change, false)
+	require.NoError(t, err)
+	m.handleKeyring(buf.Bytes()[1:], &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1})
+
+	// It's applied here, but the key isn't gossiped in the clear.
+	require.Len(t, m.config.Keyring.GetKeys(), 2)
+	for _, msg := range m.broadcasts.GetBroadcasts(0, 64*1024) {
+		require.NotEqual(t, keyringMsg, messageType(msg[0]))
+		require.False(t, bytes.Contains(msg, newKey))
+	}
+}
+
+func TestMemberlist_KeyRotation_WipesBroadcast(t *testing.T) {
+	oldKey := bytes.Repeat([]byte{1}, 16)
+	newKey := bytes.Repeat([]byte{2}, 16)
+
+	keyring, err := NewKeyring(nil, oldKey)
+	require.NoError(t, err)
+	m := GetMemberlist(t, func(c *Config) {
+		c.Keyring = keyring
+	})
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	_, err = m.InstallKey(newKey, time.Minute)
+	require.NoError(t, err)
+	var queued *keyringBroadcast
+	for _, lb := range m.broadcasts.orderedView(false) {
+		if b, ok := lb.b.(*keyringBroadcast); ok {
+			queued = b
+		}
+	}
+	require.NotNil(t, queued)
+
+	// Every transmit, the last included, carries the key.
+	for sent := 0; m.broadcasts.NumQueued() > 0; sent++ {
+		require.Less(t, sent, 100)
+		for _, msg := range m.broadcasts.GetBroadcasts(0, 64*1024) {
+			if messageType(msg[0]) == keyringMsg {
+				require.True(t, bytes.Contains(msg, newKey))
+			}
+		}
+	}
+
+	// Once it's out of the queue, it's wiped.
+	require.Equal(t, make([]byte, len(queued.msg)), queued.msg)
+
+	// So is one that's invalidated before it goes out.
+	msg := bytes.Clone(newKey)
+	m.queueKeyringBroadcast("change", msg)
+	m.queueBroadcast("change", []byte{byte(aliveMsg)}, nil)
+	require.Equal(t, make([]byte, len(newKey)), msg)
+}
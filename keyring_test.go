@@ -155,3 +155,48 @@ func TestKeyRing_MultiKeyEncryptDecrypt(t *testing.T) {
 		t.Fatalf("Expected no keys to decrypt message")
 	}
 }
+
+func TestKeyring_Wipe(t *testing.T) {
+	// The keyring keeps its own copy of keys
+	key := append([]byte(nil), TestKeys[2]...)
+	keyring, err := NewKeyring([][]byte{key}, TestKeys[0])
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	clear(key)
+	keys := keyring.GetKeys()
+	if !bytes.Equal(keys[1], TestKeys[2]) {
+		t.Fatalf("Unexpected key: %v", keys[1])
+	}
+
+	// Removing a key wipes it
+	if err := keyring.RemoveKey(TestKeys[2]); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !bytes.Equal(keys[1], make([]byte, len(TestKeys[2]))) {
+		t.Fatalf("Expected removed key to be wiped: %v", keys[1])
+	}
+	if !bytes.Equal(keys[0], TestKeys[0]) {
+		t.Fatalf("Unexpected primary key: %v", keys[0])
+	}
+
+	// The wiped slot isn't handed out again.
+	if err := keyring.AddKey(TestKeys[1]); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !bytes.Equal(keys[1], make([]byte, len(TestKeys[2]))) {
+		t.Fatalf("Expected removed key to stay wiped: %v", keys[1])
+	}
+
+	// Wiping the keyring wipes the rest, and disables encryption
+	keyring.Wipe()
+	if !bytes.Equal(keys[0], make([]byte, len(TestKeys[0]))) {
+		t.Fatalf("Expected primary key to be wiped: %v", keys[0])
+	}
+	if len(keyring.GetKeys()) != 0 {
+		t.Fatalf("Expected 0 keys but have %d", len(keyring.GetKeys()))
+	}
+	if !bytes.Equal(TestKeys[0], []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}) {
+		t.Fatalf("Expected the caller's key to be left alone: %v", TestKeys[0])
+	}
+}
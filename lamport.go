@@ -0,0 +1,112 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"encoding/binary"
+	"net"
+	"sync/atomic"
+)
+
+// LamportTime is the value of a LamportClock.
+type LamportTime uint64
+
+// LamportClock is a thread safe implementation of a Lamport clock. It gives
+// events a logical time such that if one event could have caused another, the
+// first has the lower time. The zero value is ready to use.
+type LamportClock struct {
+	counter atomic.Uint64
+}
+
+// Time returns the current value of the clock.
+func (l *LamportClock) Time() LamportTime {
+	return LamportTime(l.counter.Load())
+}
+
+// Increment advances the clock and returns the new value, for stamping a
+// local event.
+func (l *LamportClock) Increment() LamportTime {
+	return LamportTime(l.counter.Add(1))
+}
+
+// Witness moves the clock forward past a time seen from another node, so
+// that our later events are ordered after it.
+func (l *LamportClock) Witness(v LamportTime) {
+	for {
+		cur := l.counter.Load()
+		if uint64(v) < cur {
+			return
+		}
+		if l.counter.CompareAndSwap(cur, uint64(v)+1) {
+			return
+		}
+	}
+}
+
+// LamportDelegate is an optional interface a Delegate can implement to have
+// its broadcasts stamped with memberlist's Lamport clock.
+//
+// When the delegate implements it, every batch of user broadcasts returned by
+// GetBroadcasts is stamped with a new time from Memberlist.Clock, and the
+// receiving members witness that time before handing the message to
+// NotifyTimedMsg. A message that was retransmitted may be delivered more than
+// once, with a later time each time.
+//
+// Stamped broadcasts use a message type that older versions of memberlist
+// don't understand and will drop, so only implement this once every member
+// has been upgraded.
+type LamportDelegate interface {
+	// NotifyTimedMsg is called in place of NotifyMsg when a stamped user
+	// message is received. The same care should be taken as with NotifyMsg.
+	NotifyTimedMsg(msg []byte, ltime LamportTime)
+}
+
+// timedUserMsgOverhead is the framing added to a stamped user message.
+const timedUserMsgOverhead = userMsgOverhead + 8
+
+// userMessage is a user message on its way to the delegate.
+type userMessage struct {
+	buf   []byte
+	ltime LamportTime
+	timed bool
+}
+
+// Clock returns the Lamport clock used to stamp user broadcasts. It can also
+// be used to stamp an application's own events.
+func (m *Memberlist) Clock() *LamportClock {
+	return &m.clock
+}
+
+// stampUserMsg frames a user broadcast along with a Lamport time.
+func stampUserMsg(msg []byte, ltime LamportTime) []byte {
+	buf := make([]byte, timedUserMsgOverhead, len(msg)+timedUserMsgOverhead)
+	buf[0] = byte(timedUserMsg)
+	binary.BigEndian.PutUint64(buf[1:], uint64(ltime))
+	return append(buf, msg...)
+}
+
+// handleTimedUser witnesses the time on a stamped user message and passes it
+// on to the delegate.
+func (m *Memberlist) handleTimedUser(buf []byte, from net.Addr) {
+	if len(buf) < timedUserMsgOverhead-userMsgOverhead {
+		m.logger.Printf("[ERR] memberlist: Truncated timed user message %s", LogAddress(from))
+		return
+	}
+	ltime := LamportTime(binary.BigEndian.Uint64(buf))
+	m.clock.Witness(ltime)
+	m.notifyUser(userMessage{buf: buf[8:], ltime: ltime, timed: true})
+}
+
+// deliverUser hands a user message to the delegate.
+func (m *Memberlist) deliverUser(msg userMessage) {
+	d := m.delegate()
+	if d == nil {
+		return
+	}
+	if ld, ok := d.(LamportDelegate); ok && msg.timed {
+		m.guard("NotifyTimedMsg", func() { ld.NotifyTimedMsg(msg.buf, msg.ltime) })
+		return
+	}
+	m.guard("NotifyMsg", func() { d.NotifyMsg(msg.buf) })
+}
@@ -0,0 +1,86 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLamportClock(t *testing.T) {
+	var l LamportClock
+	require.Equal(t, LamportTime(0), l.Time())
+	require.Equal(t, LamportTime(1), l.Increment())
+
+	// Witnessing a later time moves past it
+	l.Witness(41)
+	require.Equal(t, LamportTime(42), l.Time())
+
+	// Witnessing an earlier time does nothing
+	l.Witness(10)
+	require.Equal(t, LamportTime(42), l.Time())
+}
+
+type timedDelegate struct {
+	MockDelegate
+	lock  sync.Mutex
+	times []LamportTime
+}
+
+func (d *timedDelegate) NotifyTimedMsg(msg []byte, ltime LamportTime) {
+	d.NotifyMsg(msg)
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.times = append(d.times, ltime)
+}
+
+func TestMemberlist_TimedBroadcasts(t *testing.T) {
+	d1 := &timedDelegate{}
+	m1 := GetMemberlist(t, func(c *Config) { c.Delegate = d1 })
+	defer m1.Shutdown()
+
+	d2 := &timedDelegate{}
+	m2 := GetMemberlist(t, func(c *Config) { c.Delegate = d2 })
+	defer m2.Shutdown()
+	m2.Clock().Witness(99)
+
+	// The batch is stamped with a single new time
+	d1.setBroadcasts([][]byte{[]byte("one"), []byte("two")})
+	out := m1.getBroadcasts(compoundOverhead, 1400)
+	require.Len(t, out, 2)
+	for _, buf := range out {
+		require.Equal(t, timedUserMsg, messageType(buf[0]))
+		m2.handleTimedUser(buf[1:], nil)
+	}
+	require.Equal(t, [][]byte{[]byte("one"), []byte("two")}, d2.getMessages())
+	require.Equal(t, []LamportTime{1, 1}, d2.times)
+	require.Equal(t, LamportTime(100), m2.Clock().Time())
+
+	// A reply from the receiver is ordered after what it saw, and the
+	// original sender witnesses that
+	d2.setBroadcasts([][]byte{[]byte("reply")})
+	out = m2.getBroadcasts(compoundOverhead, 1400)
+	require.Len(t, out, 1)
+	m1.handleTimedUser(out[0][1:], nil)
+	require.Equal(t, []LamportTime{101}, d1.times)
+	require.Equal(t, LamportTime(102), m1.Clock().Time())
+
+	// Truncated messages are dropped
+	m1.handleTimedUser([]byte{1, 2}, nil)
+	require.Len(t, d1.getMessages(), 1)
+}
+
+func TestMemberlist_UntimedBroadcasts(t *testing.T) {
+	d := &MockDelegate{}
+	m := GetMemberlist(t, func(c *Config) { c.Delegate = d })
+	defer m.Shutdown()
+
+	// Delegates that don't ask for it get plain user messages
+	d.setBroadcasts([][]byte{[]byte("one")})
+	out := m.getBroadcasts(compoundOverhead, 1400)
+	require.Equal(t, [][]byte{append([]byte{byte(userMsg)}, "one"...)}, out)
+	require.Equal(t, LamportTime(0), m.Clock().Time())
+}
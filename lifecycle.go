@@ -0,0 +1,67 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrAlreadyShutdown is returned by operations that need the network after
+// Shutdown has been called.
+var ErrAlreadyShutdown = errors.New("memberlist: already shut down")
+
+// Lifecycle is where a Memberlist is in its life, see Memberlist.State. It
+// only ever moves forward.
+type Lifecycle int32
+
+const (
+	// LifecycleCreated is a Memberlist that's listening but hasn't marked
+	// itself alive yet. Create returns once it's past this.
+	LifecycleCreated Lifecycle = iota
+
+	// LifecycleAlive is a Memberlist taking part in the cluster.
+	LifecycleAlive
+
+	// LifecycleLeaving is a Memberlist that's broadcasting its departure
+	// from inside Leave.
+	LifecycleLeaving
+
+	// LifecycleLeft is a Memberlist that has left the cluster, but still
+	// has its sockets open until Shutdown.
+	LifecycleLeft
+
+	// LifecycleShutdown is a Memberlist that has been shut down. It can't
+	// be used again.
+	LifecycleShutdown
+)
+
+func (l Lifecycle) String() string {
+	switch l {
+	case LifecycleCreated:
+		return "created"
+	case LifecycleAlive:
+		return "alive"
+	case LifecycleLeaving:
+		return "leaving"
+	case LifecycleLeft:
+		return "left"
+	case LifecycleShutdown:
+		return "shutdown"
+	default:
+		return fmt.Sprintf("Lifecycle(%d)", int32(l))
+	}
+}
+
+// State returns where this Memberlist is in its lifecycle.
+func (m *Memberlist) State() Lifecycle {
+	return Lifecycle(m.lifecycle.Load())
+}
+
+// advanceLifecycle moves the lifecycle from one state to the next, if it's
+// still in the first. It returns false if it wasn't, say because Shutdown
+// got there first.
+func (m *Memberlist) advanceLifecycle(from, to Lifecycle) bool {
+	return m.lifecycle.CompareAndSwap(int32(from), int32(to))
+}
@@ -0,0 +1,71 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberlist_Lifecycle(t *testing.T) {
+	m := GetMemberlist(t, nil)
+	require.Equal(t, LifecycleCreated, m.State())
+
+	require.NoError(t, m.setAlive())
+	require.Equal(t, LifecycleAlive, m.State())
+
+	require.NoError(t, m.Leave(time.Second))
+	require.Equal(t, LifecycleLeft, m.State())
+	require.NoError(t, m.Leave(time.Second))
+	require.Equal(t, LifecycleLeft, m.State())
+
+	require.NoError(t, m.Shutdown())
+	require.Equal(t, LifecycleShutdown, m.State())
+	require.NoError(t, m.Shutdown())
+
+	require.ErrorIs(t, m.Leave(time.Second), ErrAlreadyShutdown)
+	_, err := m.Join([]string{"127.0.0.1"})
+	require.ErrorIs(t, err, ErrAlreadyShutdown)
+	require.ErrorIs(t, m.UpdateNode(time.Second), ErrAlreadyShutdown)
+	require.ErrorIs(t, m.GossipNow(), ErrAlreadyShutdown)
+	require.Equal(t, LifecycleShutdown, m.State())
+	require.Equal(t, "shutdown", m.State().String())
+}
+
+func TestMemberlist_ShutdownDuringLeave(t *testing.T) {
+	m1 := GetMemberlist(t, nil)
+	require.NoError(t, m1.setAlive())
+	m1.schedule()
+	m2 := GetMemberlist(t, nil)
+	require.NoError(t, m2.setAlive())
+	m2.schedule()
+	defer func() {
+		require.NoError(t, m2.Shutdown())
+	}()
+	_, err := m2.Join([]string{m1.config.Name + "/" + m1.LocalNode().Address()})
+	require.NoError(t, err)
+
+	// Keep the leave broadcast from ever going out, so Leave is stuck
+	// waiting on it when we shut down.
+	require.NoError(t, m1.Pause())
+
+	left := make(chan error, 1)
+	go func() {
+		left <- m1.Leave(0)
+	}()
+	require.Eventually(t, func() bool {
+		return m1.State() == LifecycleLeaving
+	}, time.Second, time.Millisecond)
+
+	require.NoError(t, m1.Shutdown())
+	select {
+	case err := <-left:
+		require.ErrorIs(t, err, ErrAlreadyShutdown)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("leave should have given up")
+	}
+	require.Equal(t, LifecycleShutdown, m1.State())
+}
@@ -0,0 +1,95 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"fmt"
+	"time"
+)
+
+// SetMaintenance puts the local node in maintenance for the given duration,
+// before a planned restart or deploy. The other members stop probing the node
+// and ignore suspicions about it until the duration runs out, so it isn't
+// declared dead while it's briefly unreachable. The duration can't be longer
+// than Config.MaxMaintenance. Zero ends maintenance early.
+//
+// The change is gossiped with a new alive message, the same way as an
+// UpdateNode, but SetMaintenance doesn't wait for it to go out.
+func (m *Memberlist) SetMaintenance(d time.Duration) (err error) {
+	defer func() { m.audit(AuditMaintenance, d.String(), err) }()
+	if m.hasShutdown() {
+		return ErrAlreadyShutdown
+	}
+	if d < 0 {
+		return fmt.Errorf("maintenance duration can't be negative")
+	}
+	if d > m.config.MaxMaintenance {
+		return fmt.Errorf("maintenance duration %v is longer than the maximum of %v", d, m.config.MaxMaintenance)
+	}
+
+	m.nodeLock.RLock()
+	state, ok := m.nodeMap[m.config.Name]
+	var a alive
+	if ok {
+		a = alive{
+			Node:        state.Name,
+			Addr:        state.Addr,
+			Port:        state.Port,
+			Meta:        state.Meta,
+			Vsn:         m.config.BuildVsnArray(),
+			Addrs:       state.Addrs,
+			Role:        state.Role,
+			Zone:        state.Zone,
+			ID:          state.ID,
+			StreamPort:  state.StreamPort,
+			Maintenance: d,
+			Weight:      state.Weight,
+			Draining:    state.Draining,
+			Complete:    true,
+		}
+	}
+	m.nodeLock.RUnlock()
+	if !ok {
+		return fmt.Errorf("local node isn't set up yet")
+	}
+
+	a.Incarnation = m.nextIncarnation()
+	m.aliveNode(&a, nil, true)
+	return nil
+}
+
+// InMaintenance returns true if the named member is in maintenance, see
+// SetMaintenance.
+func (m *Memberlist) InMaintenance(node string) bool {
+	m.nodeLock.RLock()
+	defer m.nodeLock.RUnlock()
+
+	state, ok := m.nodeMap[node]
+	return ok && state.inMaintenance(time.Now())
+}
+
+// maintenanceUntil returns when a maintenance period announced now ends,
+// limited to Config.MaxMaintenance.
+func (m *Memberlist) maintenanceUntil(d time.Duration) time.Time {
+	if d > m.config.MaxMaintenance {
+		d = m.config.MaxMaintenance
+	}
+	if d <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(d)
+}
+
+// inMaintenance returns true if the node is in maintenance at the given time.
+func (n *nodeState) inMaintenance(now time.Time) bool {
+	return now.Before(n.maintenanceUntil)
+}
+
+// maintenanceLeft returns how much longer the node is in maintenance for.
+func (n *nodeState) maintenanceLeft() time.Duration {
+	if d := time.Until(n.maintenanceUntil); d > 0 {
+		return d
+	}
+	return 0
+}
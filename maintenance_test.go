@@ -0,0 +1,85 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"testing"
+	"time"
+
+	iretry "github.com/hashicorp/memberlist/internal/retry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberList_MaintenanceSuppressesSuspicion(t *testing.T) {
+	m := GetMemberlist(t, func(c *Config) {
+		c.MaxMaintenance = time.Minute
+	})
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	a := alive{Node: "test", Addr: []byte{127, 0, 0, 1}, Incarnation: 1, Vsn: m.config.BuildVsnArray(), Maintenance: time.Hour, Complete: true}
+	m.aliveNode(&a, nil, false)
+	m.changeNode("test", func(state *nodeState) {
+		state.StateChange = state.StateChange.Add(-time.Hour)
+	})
+	require.True(t, m.InMaintenance("test"))
+
+	// The announced period is cut to the maximum
+	m.nodeLock.RLock()
+	left := m.nodeMap["test"].maintenanceLeft()
+	m.nodeLock.RUnlock()
+	require.LessOrEqual(t, left, time.Minute)
+	require.Greater(t, left, 50*time.Second)
+
+	// Suspicions are ignored while it lasts
+	m.suspectNode(&suspect{Node: "test", Incarnation: 1})
+	require.Equal(t, StateAlive, m.getNodeState("test"))
+
+	// Ending maintenance makes the node suspectable again
+	a.Incarnation, a.Maintenance = 2, 0
+	m.aliveNode(&a, nil, false)
+	require.False(t, m.InMaintenance("test"))
+	m.suspectNode(&suspect{Node: "test", Incarnation: 2})
+	require.Equal(t, StateSuspect, m.getNodeState("test"))
+}
+
+func TestMemberlist_SetMaintenance(t *testing.T) {
+	c1 := testConfig(t)
+	m1, err := Create(c1)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m1.Shutdown())
+	}()
+
+	c2 := testConfig(t)
+	c2.BindPort = m1.config.BindPort
+	m2, err := Create(c2)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m2.Shutdown())
+	}()
+	_, err = m2.Join([]string{m1.config.Name + "/" + m1.config.BindAddr})
+	require.NoError(t, err)
+
+	require.Error(t, m1.SetMaintenance(-time.Second))
+	require.Error(t, m1.SetMaintenance(m1.config.MaxMaintenance+time.Second))
+
+	// The other member hears about it
+	require.NoError(t, m1.SetMaintenance(time.Minute))
+	require.True(t, m1.InMaintenance(m1.config.Name))
+	iretry.Run(t, func(r *iretry.R) {
+		if !m2.InMaintenance(m1.config.Name) {
+			r.Fatal("expected the node to be in maintenance")
+		}
+	})
+
+	// And about it ending early
+	require.NoError(t, m1.SetMaintenance(0))
+	iretry.Run(t, func(r *iretry.R) {
+		if m2.InMaintenance(m1.config.Name) {
+			r.Fatal("expected maintenance to be over")
+		}
+	})
+}
@@ -43,33 +43,58 @@ type Memberlist struct {
 	incarnation uint32 // Local incarnation number
 	numNodes    uint32 // Number of known nodes (estimate)
 	pushPullReq uint32 // Number of push/pull requests
+	repairing   int32  // Set while an anti-entropy repair is running
 
 	advertiseLock sync.RWMutex
 	advertiseAddr net.IP
 	advertisePort uint16
 
 	config         *Config
-	shutdown       int32 // Used as an atomic boolean value
+	ownKeyring     *Keyring     // Keyring we built from SecretKey, wiped at Shutdown
+	delegateLock   sync.RWMutex // Protects config.Delegate
+	shutdown       int32        // Used as an atomic boolean value
 	shutdownCh     chan struct{}
 	leave          int32 // Used as an atomic boolean value
 	leaveBroadcast chan struct{}
+	leaveMsg       *trackedBroadcast // Our leave broadcast, under nodeLock
 
-	shutdownLock sync.Mutex // Serializes calls to Shutdown
-	leaveLock    sync.Mutex // Serializes calls to Leave
+	shutdownLock sync.Mutex   // Serializes calls to Shutdown
+	leaveLock    sync.Mutex   // Serializes calls to Leave
+	lifecycle    atomic.Int32 // A Lifecycle, see State
+
+	streamLock sync.Mutex
+	streams    int           // Stream sessions in flight, under streamLock
+	drainedCh  chan struct{} // Closed once streams drops to zero, if Shutdown is waiting
 
 	transport NodeAwareTransport
 
+	notifyCh chan userMessage // User messages for the NotifyMsg workers, if any
+	clock    LamportClock     // Stamps user broadcasts for a LamportDelegate
+
 	handoffCh            chan struct{}
+	handoffSpaceCh       chan struct{}
 	highPriorityMsgQueue *list.List
 	lowPriorityMsgQueue  *list.List
 	msgQueueLock         sync.Mutex
-
-	nodeLock   sync.RWMutex
-	nodes      []*nodeState          // Known nodes
-	nodeMap    map[string]*nodeState // Maps Node.Name -> NodeState
-	nodeTimers map[string]*suspicion // Maps Node.Name -> suspicion timer
-	awareness  *awareness
-
+	highPriorityDropped  uint64 // Accessed atomically
+	lowPriorityDropped   uint64 // Accessed atomically
+
+	nodeLock    sync.RWMutex
+	nodes       []*nodeState          // Known nodes
+	nodeMap     map[string]*nodeState // Maps Node.Name -> NodeState
+	strs        map[string]string     // Interned node strings, see intern, under nodeLock
+	nodeTimers  map[string]*suspicion // Maps Node.Name -> suspicion timer
+	membersCh   chan struct{}         // Closed and replaced when a node comes or goes, under nodeLock
+	readyCh     chan struct{}         // Closed once RequiredMembers are known, under nodeLock
+	failures    map[string]time.Time  // Recent failures for partition detection, under nodeLock
+	partitioned bool                  // Set while a possible partition is reported, under nodeLock
+	highWater   int                   // Most live members known at once, less those that left, under nodeLock
+	lostNodes   map[string]lostNode   // Forgotten dead nodes to reconnect to, under nodeLock
+	tombstones  map[string]tombstone  // Recently reaped dead and left nodes, under nodeLock
+	digestHash  uint64                // Hash in our gossip digest, see flipDigest, under nodeLock
+	awareness   *awareness
+
+	tuningLock sync.RWMutex // Protects the intervals in config, see Tuning
 	tickerLock sync.Mutex
 	tickers    []*time.Ticker
 	stopTick   chan struct{}
@@ -78,6 +103,65 @@ type Memberlist struct {
 	ackLock     sync.Mutex
 	ackHandlers map[uint32]*ackHandler
 
+	incarnationLock  sync.Mutex // Serializes saving the incarnation
+	savedIncarnation uint32     // Last incarnation saved to the state dir
+
+	breakerLock sync.Mutex
+	breakers    map[string]*peerBreaker // Send failures by node name
+
+	autoTuneLock   sync.Mutex
+	autoTuneLoss   lossCount    // Probe outcomes since the last auto-tuning pass
+	autoTuneRate   float64      // Loss rate seen by the last auto-tuning pass
+	probeTimeoutNs atomic.Int64 // ProbeTimeout in effect, see AutoTuned
+
+	weight   atomic.Uint32 // Local weight, see SetWeight
+	draining atomic.Bool   // Local node is draining, see Drain
+
+	stunLock sync.Mutex
+	stunIP   net.IP // Public address found with STUN, if any
+	stunPort int
+
+	punchLock sync.Mutex
+	punches   map[string]*punchState // Hole punching by peer name
+
+	observerLock sync.Mutex
+	observerSeen map[string]time.Time // Last time each observer probed us
+
+	debugRing *debugRing    // Recent messages for DebugDump, if enabled
+	journal   *eventJournal // Event journal, if enabled
+	chaos     Chaos         // Failure injection, if enabled
+
+	membersVersion atomic.Uint64                   // Bumped under nodeLock when Members changes
+	membersSnap    atomic.Pointer[membersSnapshot] // Last Members snapshot
+	sourcesSnap    atomic.Pointer[sourceSet]       // Last set of member addresses, see VerifyPacketSource
+
+	broadcastLock     sync.Mutex
+	broadcastID       atomic.Uint32                // Last BroadcastWithAck ID
+	pendingBroadcasts map[uint32]*pendingBroadcast // Our broadcasts waiting on acks
+	seenBroadcasts    map[string]time.Time         // Acked broadcasts seen, until they expire
+
+	metaLock  sync.Mutex
+	metaTimer stoppableTimer // Gossips the local metadata again when it expires, under metaLock
+
+	mtuLock sync.Mutex
+	pathMTU map[string]int // Largest packet that gets through to a node, by name, see probeMTU
+
+	seedLock sync.Mutex
+	seeds    map[string]*SeedStats // Push/pulls with seeds, by seed, see SeedStats
+
+	digestLock     sync.Mutex
+	digestMismatch map[string]int // Digests in a row that differed from ours, by sender
+
+	unknownLock sync.Mutex
+	unknown     map[string][]unknownMsg // Messages about nodes we don't know yet
+
+	eventBatch      []NodeEvent // Events waiting for NotifyBatch, under nodeLock
+	eventBatchStart time.Time   // When the first of them happened, under nodeLock
+	eventRounds     int         // Rounds of processing under way, under nodeLock
+
+	// timers drives the ack reapers and suspicion timeouts.
+	timers *timerWheel
+
 	broadcasts *TransmitLimitedQueue
 
 	logger *log.Logger
@@ -106,6 +190,7 @@ func newMemberlist(conf *Config) (*Memberlist, error) {
 			conf.ProtocolVersion, ProtocolVersionMin, ProtocolVersionMax)
 	}
 
+	var ownKeyring *Keyring
 	if len(conf.SecretKey) > 0 {
 		if conf.Keyring == nil {
 			keyring, err := NewKeyring(nil, conf.SecretKey)
@@ -113,6 +198,7 @@ func newMemberlist(conf *Config) (*Memberlist, error) {
 				return nil, err
 			}
 			conf.Keyring = keyring
+			ownKeyring = keyring
 		} else {
 			if err := conf.Keyring.AddKey(conf.SecretKey); err != nil {
 				return nil, err
@@ -137,21 +223,86 @@ func newMemberlist(conf *Config) (*Memberlist, error) {
 		logger = log.New(logDest, "", log.LstdFlags)
 	}
 
+	// Pick up where we left off if we're keeping state across restarts.
+	var incarnation uint32
+	if conf.StateDir != "" {
+		inc, err := loadIncarnation(conf.StateDir)
+		if err != nil {
+			return nil, err
+		}
+		incarnation = inc
+
+		if conf.NodeID == "" {
+			id, err := loadNodeID(conf.StateDir)
+			if err != nil {
+				return nil, err
+			}
+			conf.NodeID = id
+		}
+	}
+	if conf.NodeID != "" && !isValidNodeID(conf.NodeID) {
+		return nil, fmt.Errorf("node ID %q is not a valid UUID", conf.NodeID)
+	}
+
+	if conf.MulticastGroup != "" {
+		if _, err := resolveMulticastGroup(conf.MulticastGroup); err != nil {
+			return nil, err
+		}
+	}
+
+	if conf.Authorizer != nil && conf.TLSConfig == nil && conf.Transport == nil {
+		return nil, fmt.Errorf("an Authorizer needs TLS streams, but there's no TLSConfig")
+	}
+
+	if conf.fipsMode() {
+		if err := checkFIPS(conf); err != nil {
+			return nil, err
+		}
+		conf.TLSConfig = fipsTLSConfig(conf.TLSConfig)
+		if !fipsModule() {
+			logger.Printf("[WARN] memberlist: FIPS mode is on, but Go's cryptography isn't a FIPS 140 validated module")
+		}
+	}
+
 	// Set up a network transport by default if a custom one wasn't given
 	// by the config.
 	transport := conf.Transport
 	if transport == nil {
 		nc := &NetTransportConfig{
-			BindAddrs:    []string{conf.BindAddr},
-			BindPort:     conf.BindPort,
-			Logger:       logger,
-			MetricLabels: conf.MetricLabels,
+			BindAddrs:      []string{conf.BindAddr},
+			BindPort:       conf.BindPort,
+			StreamBindPort: conf.StreamBindPort,
+			Logger:         logger,
+			MetricLabels:   conf.MetricLabels,
+			DialProxy:      conf.DialProxy,
+			SocketControl:  conf.SocketControl,
+			TLSConfig:      conf.TLSConfig,
+			DontFragment:   conf.MTUProbeInterval > 0,
+			MulticastGroup: conf.MulticastGroup,
+		}
+
+		// Look up the interface addresses on every try, since failing
+		// to bind may mean they've just changed.
+		resolveInterface := func() error {
+			if conf.BindInterface == "" {
+				return nil
+			}
+			addrs, err := interfaceBindAddrs(conf.BindInterface)
+			if err != nil {
+				return err
+			}
+			nc.BindAddrs = addrs
+			conf.BindAddr = addrs[0]
+			return nil
 		}
 
 		// See comment below for details about the retry in here.
 		makeNetRetry := func(limit int) (*NetTransport, error) {
 			var err error
 			for try := 0; try < limit; try++ {
+				if err = resolveInterface(); err != nil {
+					continue
+				}
 				var nt *NetTransport
 				if nt, err = NewNetTransport(nc); err == nil {
 					return nt, nil
@@ -174,6 +325,8 @@ func newMemberlist(conf *Config) (*Memberlist, error) {
 		limit := 1
 		if conf.BindPort == 0 {
 			limit = 10
+		} else if conf.BindInterface != "" {
+			limit = 3
 		}
 
 		nt, err := makeNetRetry(limit)
@@ -189,12 +342,35 @@ func newMemberlist(conf *Config) (*Memberlist, error) {
 		transport = nt
 	}
 
+	if conf.AdvertiseAddr == "" && conf.AdvertiseInterface != "" {
+		addr, err := interfaceAdvertiseAddr(conf.AdvertiseInterface)
+		if err != nil {
+			_ = transport.Shutdown()
+			return nil, fmt.Errorf("could not pick an advertise address: %v", err)
+		}
+		conf.AdvertiseAddr = addr
+	}
+	if conf.AdvertiseAddr == "" && conf.AdvertiseAddrFilter != nil {
+		if bind := net.ParseIP(conf.BindAddr); bind != nil && bind.IsUnspecified() {
+			addr, err := DetectAdvertiseAddr(conf.AdvertiseAddrFilter)
+			if err != nil {
+				_ = transport.Shutdown()
+				return nil, fmt.Errorf("could not pick an advertise address: %v", err)
+			}
+			conf.AdvertiseAddr = addr.String()
+		}
+	}
+
 	nodeAwareTransport, ok := transport.(NodeAwareTransport)
 	if !ok {
 		logger.Printf("[DEBUG] memberlist: configured Transport is not a NodeAwareTransport and some features may not work as desired")
 		nodeAwareTransport = &shimNodeAwareTransport{transport}
 	}
 
+	if _, err := parseAdvertiseAddrs(conf.AdvertiseAddrs, conf.AdvertisePort); err != nil {
+		return nil, err
+	}
+
 	if len(conf.Label) > LabelMaxSize {
 		return nil, fmt.Errorf("could not use %q as a label: too long", conf.Label)
 	}
@@ -207,17 +383,24 @@ func newMemberlist(conf *Config) (*Memberlist, error) {
 	}
 
 	m := &Memberlist{
+		incarnation:          incarnation,
+		savedIncarnation:     incarnation,
 		config:               conf,
+		ownKeyring:           ownKeyring,
 		shutdownCh:           make(chan struct{}),
+		membersCh:            make(chan struct{}),
+		readyCh:              make(chan struct{}),
 		leaveBroadcast:       make(chan struct{}, 1),
 		transport:            nodeAwareTransport,
 		handoffCh:            make(chan struct{}, 1),
+		handoffSpaceCh:       make(chan struct{}, 1),
 		highPriorityMsgQueue: list.New(),
 		lowPriorityMsgQueue:  list.New(),
 		nodeMap:              make(map[string]*nodeState),
 		nodeTimers:           make(map[string]*suspicion),
 		awareness:            newAwareness(conf.AwarenessMaxMultiplier, conf.MetricLabels),
 		ackHandlers:          make(map[uint32]*ackHandler),
+		timers:               newTimerWheel(timerWheelTick, timerWheelSlots),
 		broadcasts:           &TransmitLimitedQueue{RetransmitMult: conf.RetransmitMult},
 		logger:               logger,
 		metricLabels:         conf.MetricLabels,
@@ -225,18 +408,56 @@ func newMemberlist(conf *Config) (*Memberlist, error) {
 	m.broadcasts.NumNodes = func() int {
 		return m.estNumNodes()
 	}
+	m.chaos.m = m
+	m.membersVersion.Store(1) // So zero is always older
+	m.probeTimeoutNs.Store(int64(conf.ProbeTimeout))
+	m.weight.Store(uint32(conf.Weight))
+	if conf.DebugRingSize > 0 {
+		m.debugRing = &debugRing{entries: make([]debugEntry, conf.DebugRingSize)}
+	}
+	if conf.EventJournalPath != "" {
+		j, err := openEventJournal(conf.EventJournalPath, conf.EventJournalMaxSize)
+		if err != nil {
+			m.timers.Stop()
+			return nil, err
+		}
+		m.journal = j
+	}
 
 	// Get the final advertise address from the transport, which may need
 	// to see which address we bound to. We'll refresh this each time we
 	// send out an alive message.
+	m.initPublicAddr()
 	if _, _, err := m.refreshAdvertise(); err != nil {
+		m.timers.Stop()
+		if m.journal != nil {
+			_ = m.journal.close()
+		}
 		return nil, err
 	}
 
-	go m.streamListen()
-	go m.packetListen()
-	go m.packetHandler()
+	m.startNotifyWorkers()
+	if conf.StreamWorkers > 0 {
+		for i := 0; i < conf.StreamWorkers; i++ {
+			go m.streamWorker()
+		}
+	} else {
+		go m.streamListen()
+	}
+	for i := 0; i < max(conf.PacketWorkers, 1); i++ {
+		go m.packetListen()
+	}
+	workers := conf.HandoffWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		go m.packetHandler()
+	}
 	go m.checkBroadcastQueueDepth()
+	if m.journal != nil {
+		go m.journalWriter()
+	}
 	return m, nil
 }
 
@@ -264,33 +485,83 @@ func Create(conf *Config) (*Memberlist, error) {
 // remote nodes to become aware of the existence of this node, effectively
 // joining the cluster.
 //
+// Up to Config.JoinParallelism hosts are contacted at once. Join returns
+// once they've all been tried, Config.JoinMinSuccess of them have synced,
+// or Config.JoinTimeout runs out, whichever comes first. Attempts still in
+// flight at that point finish in the background. Hosts we've synced with
+// before are contacted fastest first, see SeedStats.
+//
 // This returns the number of hosts successfully contacted and an error if
 // none could be reached. If an error is returned, the node did not successfully
 // join the cluster.
 func (m *Memberlist) Join(existing []string) (int, error) {
-	numSuccess := 0
-	var errs error
-	for _, exist := range existing {
-		addrs, err := m.resolveAddr(exist)
-		if err != nil {
-			err = fmt.Errorf("failed to resolve %s: %v", exist, err)
-			errs = multierror.Append(errs, err)
-			m.logger.Printf("[WARN] memberlist: %v", err)
-			continue
+	return m.joinAs(existing, ActorLocal)
+}
+
+// joinAs is Join, reporting the given actor to the AuditHook.
+func (m *Memberlist) joinAs(existing []string, actor string) (n int, err error) {
+	defer func() { m.Audit(AuditJoin, actor, strings.Join(existing, ","), err) }()
+	if m.hasShutdown() {
+		return 0, ErrAlreadyShutdown
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	seeds := make(chan string)
+	go func() {
+		defer close(seeds)
+		for _, exist := range m.preferSeeds(existing) {
+			select {
+			case seeds <- exist:
+			case <-stopCh:
+				return
+			}
 		}
+	}()
+
+	results := make(chan error)
+	var wg sync.WaitGroup
+	for i := 0; i < max(m.config.JoinParallelism, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for exist := range seeds {
+				m.joinSeed(exist, results, stopCh)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var timeoutCh <-chan time.Time
+	if m.config.JoinTimeout > 0 {
+		timeoutCh = time.After(m.config.JoinTimeout)
+	}
 
-		for _, addr := range addrs {
-			hp := joinHostPort(addr.ip.String(), addr.port)
-			a := Address{Addr: hp, Name: addr.nodeName}
-			if err := m.pushPullNode(a, true); err != nil {
-				err = fmt.Errorf("failed to join %s: %v", a.Addr, err)
+	numSuccess := 0
+	var errs error
+WAIT:
+	for {
+		select {
+		case err, ok := <-results:
+			if !ok {
+				break WAIT
+			}
+			if err != nil {
 				errs = multierror.Append(errs, err)
-				m.logger.Printf("[DEBUG] memberlist: %v", err)
 				continue
 			}
 			numSuccess++
+			if want := m.config.JoinMinSuccess; want > 0 && numSuccess >= want {
+				break WAIT
+			}
+		case <-timeoutCh:
+			errs = multierror.Append(errs, fmt.Errorf("timed out joining after %v", m.config.JoinTimeout))
+			break WAIT
 		}
-
 	}
 	if numSuccess > 0 {
 		errs = nil
@@ -298,6 +569,148 @@ func (m *Memberlist) Join(existing []string) (int, error) {
 	return numSuccess, errs
 }
 
+// joinSeed resolves one of the hosts given to Join and does a push/pull with
+// each of its addresses in turn, sending the outcome of each to results
+// until Join stops listening.
+func (m *Memberlist) joinSeed(exist string, results chan<- error, stopCh <-chan struct{}) {
+	report := func(err error) bool {
+		select {
+		case results <- err:
+			return true
+		case <-stopCh:
+			return false
+		}
+	}
+
+	addrs, err := m.resolveAddr(exist)
+	if err != nil {
+		err = fmt.Errorf("failed to resolve %s: %v", exist, err)
+		m.logger.Printf("[WARN] memberlist: %v", err)
+		m.recordSeed(exist, 0, err)
+		report(err)
+		return
+	}
+
+	for _, addr := range addrs {
+		select {
+		case <-stopCh:
+			return
+		default:
+		}
+
+		hp := joinHostPort(addr.ip.String(), addr.port)
+		a := Address{Addr: hp, Name: addr.nodeName}
+		start := time.Now()
+		err := m.pushPullNode(a, true)
+		m.recordSeed(exist, time.Since(start), err)
+		if err != nil {
+			err = fmt.Errorf("failed to join %s: %w", a.Addr, err)
+			m.logger.Printf("[DEBUG] memberlist: %v", err)
+			if !report(err) {
+				return
+			}
+			continue
+		}
+		if !report(nil) {
+			return
+		}
+	}
+}
+
+// GossipNow sends any queued broadcasts to Config.GossipNodes random members
+// right away, rather than waiting for the next gossip interval. It's useful
+// after queueing a broadcast that should go out as soon as possible. The
+// regular gossip carries on as usual.
+func (m *Memberlist) GossipNow() error {
+	if m.hasShutdown() {
+		return ErrAlreadyShutdown
+	}
+	m.gossip()
+	return nil
+}
+
+// PushPullNode does a complete state exchange with a single node right away,
+// rather than waiting for the next push/pull interval. The node can be given
+// by the name of a known member, or by address in any form Join accepts.
+func (m *Memberlist) PushPullNode(node string) error {
+	if m.hasShutdown() {
+		return ErrAlreadyShutdown
+	}
+
+	m.nodeLock.RLock()
+	state, ok := m.nodeMap[node]
+	var a Address
+	if ok {
+		a = state.StreamAddress()
+	}
+	m.nodeLock.RUnlock()
+	if ok {
+		return m.pushPullNode(a, false)
+	}
+
+	addrs, err := m.resolveAddr(node)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %v", node, err)
+	}
+	if len(addrs) > 1 && m.config.DialRaceDelay > 0 {
+		return m.pushPullRace(node, addrs)
+	}
+	var errs error
+	for _, addr := range addrs {
+		a := Address{Addr: joinHostPort(addr.ip.String(), addr.port), Name: addr.nodeName}
+		err := m.pushPullNode(a, false)
+		if err == nil {
+			return nil
+		}
+		errs = multierror.Append(errs, fmt.Errorf("failed to push/pull with %s: %v", a.Addr, err))
+	}
+	if errs == nil {
+		return fmt.Errorf("no addresses found for %s", node)
+	}
+	return errs
+}
+
+// pushPullRace does a full push/pull with whichever of the addresses a name
+// resolved to connects first, rather than trying them one at a time.
+func (m *Memberlist) pushPullRace(node string, addrs []ipPort) error {
+	m.beginStream()
+	defer m.endStream()
+
+	name := addrs[0].nodeName
+	if name == "" && m.config.RequireNodeNames {
+		return errNodeNamesAreRequired
+	}
+
+	hostPorts := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		hostPorts = append(hostPorts, joinHostPort(addr.ip.String(), addr.port))
+	}
+	hostPorts = interleaveFamilies(hostPorts)
+
+	conn, i, err := m.dialRace(name, hostPorts, m.config.TCPTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to push/pull with %s: %v", node, err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	if err := m.authorizeStream(conn, name); err != nil {
+		return fmt.Errorf("failed to push/pull with %s: %v", hostPorts[i], err)
+	}
+
+	a := Address{Addr: hostPorts[i], Name: name}
+	trace := PushPullTrace{Addr: a.Addr, Node: a.Name, Start: time.Now()}
+	remote, userState, err := m.exchangeState(conn, a, false)
+	if err == nil {
+		err = m.mergeRemoteState(false, remote, userState)
+	}
+	m.tracePushPull(trace, err)
+	if err != nil {
+		return fmt.Errorf("failed to push/pull with %s: %v", a.Addr, err)
+	}
+	return nil
+}
+
 // ipPort holds information about a node we want to try to join.
 type ipPort struct {
 	ip       net.IP
@@ -380,7 +793,7 @@ func (m *Memberlist) resolveAddr(hostStr string) ([]ipPort, error) {
 	}
 
 	// This captures the supplied port, or the default one.
-	hostStr = ensurePort(hostStr, m.config.BindPort)
+	hostStr = ensurePort(hostStr, m.defaultStreamPort())
 	host, sport, err := net.SplitHostPort(hostStr)
 	if err != nil {
 		return nil, err
@@ -452,24 +865,24 @@ func (m *Memberlist) setAlive() error {
 		m.logger.Printf("[WARN] memberlist: Binding to public address without encryption!")
 	}
 
-	// Set any metadata from the delegate.
-	var meta []byte
-	if m.config.Delegate != nil {
-		meta = m.config.Delegate.NodeMeta(MetaMaxSize)
-		if len(meta) > MetaMaxSize {
-			panic("Node meta data provided is longer than the limit")
-		}
-	}
-
 	a := alive{
 		Incarnation: m.nextIncarnation(),
 		Node:        m.config.Name,
 		Addr:        addr,
 		Port:        uint16(port),
-		Meta:        meta,
+		Meta:        m.localMeta(),
 		Vsn:         m.config.BuildVsnArray(),
+		Addrs:       m.advertiseAddrs(port),
+		Role:        m.config.Role,
+		Zone:        m.config.Zone,
+		Weight:      m.localWeight(),
+		Draining:    m.draining.Load(),
+		Complete:    true,
+		ID:          m.config.NodeID,
+		StreamPort:  m.advertiseStreamPort(port),
 	}
 	m.aliveNode(&a, nil, true)
+	m.advanceLifecycle(LifecycleCreated, LifecycleAlive)
 
 	return nil
 }
@@ -488,6 +901,12 @@ func (m *Memberlist) setAdvertise(addr net.IP, port int) {
 }
 
 func (m *Memberlist) refreshAdvertise() (net.IP, int, error) {
+	if m.useSTUN() {
+		if addr, port, ok := m.stunAdvertise(); ok {
+			m.setAdvertise(addr, port)
+			return addr, port, nil
+		}
+	}
 	addr, port, err := m.transport.FinalAdvertiseAddr(
 		m.config.AdvertiseAddr, m.config.AdvertisePort)
 	if err != nil {
@@ -497,6 +916,53 @@ func (m *Memberlist) refreshAdvertise() (net.IP, int, error) {
 	return addr, port, nil
 }
 
+// advertiseStreamPort returns the stream port to advertise alongside the
+// given packet port, or zero if they're the same.
+func (m *Memberlist) advertiseStreamPort(port int) uint16 {
+	streamPort := m.config.AdvertiseStreamPort
+	if streamPort == 0 {
+		streamPort = m.config.StreamBindPort
+	}
+	if streamPort == port {
+		return 0
+	}
+	return uint16(streamPort)
+}
+
+// defaultStreamPort is the port we assume peers accept streams on when
+// joining an address without one.
+func (m *Memberlist) defaultStreamPort() int {
+	if m.config.StreamBindPort != 0 {
+		return m.config.StreamBindPort
+	}
+	return m.config.BindPort
+}
+
+// advertiseAddrs returns the configured additional advertise addresses in
+// host:port form, using the given port where none was specified.
+func (m *Memberlist) advertiseAddrs(port int) []string {
+	addrs, _ := parseAdvertiseAddrs(m.config.AdvertiseAddrs, port)
+	return addrs
+}
+
+// parseAdvertiseAddrs validates a list of additional advertise addresses and
+// returns them in host:port form.
+func parseAdvertiseAddrs(in []string, defaultPort int) ([]string, error) {
+	var out []string
+	for _, raw := range in {
+		s := ensurePort(strings.TrimSpace(raw), defaultPort)
+		host, _, err := net.SplitHostPort(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid advertise address %q: %v", raw, err)
+		}
+		if net.ParseIP(host) == nil {
+			return nil, fmt.Errorf("invalid advertise address %q: not an IP address", raw)
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
 // LocalNode is used to return the local Node
 func (m *Memberlist) LocalNode() *Node {
 	m.nodeLock.RLock()
@@ -511,18 +977,38 @@ func (m *Memberlist) LocalNode() *Node {
 // broadcasted to a member of the cluster, if any exist or until a specified
 // timeout is reached.
 func (m *Memberlist) UpdateNode(timeout time.Duration) error {
-	// Get the node meta data
-	var meta []byte
-	if m.config.Delegate != nil {
-		meta = m.config.Delegate.NodeMeta(MetaMaxSize)
-		if len(meta) > MetaMaxSize {
-			panic("Node meta data provided is longer than the limit")
+	if m.hasShutdown() {
+		return ErrAlreadyShutdown
+	}
+
+	notifyCh := make(chan struct{})
+	m.updateNode(notifyCh)
+
+	// Wait for the broadcast or a timeout
+	if m.anyAlive() {
+		var timeoutCh <-chan time.Time
+		if timeout > 0 {
+			timeoutCh = time.After(timeout)
+		}
+		select {
+		case <-notifyCh:
+		case <-timeoutCh:
+			return fmt.Errorf("timeout waiting for update broadcast")
 		}
 	}
+	return nil
+}
+
+// updateNode gossips a new alive message for the local node with fresh
+// metadata from the delegate. The notify channel, if any, is closed once
+// the message has gone out.
+func (m *Memberlist) updateNode(notifyCh chan struct{}) {
+	meta := m.localMeta()
 
 	// Get the existing node
 	m.nodeLock.RLock()
 	state := m.nodeMap[m.config.Name]
+	maintenance := state.maintenanceLeft()
 	m.nodeLock.RUnlock()
 
 	// Format a new alive message
@@ -533,23 +1019,49 @@ func (m *Memberlist) UpdateNode(timeout time.Duration) error {
 		Port:        state.Port,
 		Meta:        meta,
 		Vsn:         m.config.BuildVsnArray(),
+		Addrs:       state.Addrs,
+		Role:        state.Role,
+		Zone:        state.Zone,
+		ID:          state.ID,
+		StreamPort:  state.StreamPort,
+		Maintenance: maintenance,
+		Weight:      m.localWeight(),
+		Draining:    m.draining.Load(),
+		Complete:    true,
 	}
-	notifyCh := make(chan struct{})
 	m.aliveNode(&a, notifyCh, true)
+}
 
-	// Wait for the broadcast or a timeout
-	if m.anyAlive() {
-		var timeoutCh <-chan time.Time
-		if timeout > 0 {
-			timeoutCh = time.After(timeout)
-		}
-		select {
-		case <-notifyCh:
-		case <-timeoutCh:
-			return fmt.Errorf("timeout waiting for update broadcast")
-		}
+// localMeta gets the local node's metadata from the delegate, if any, and
+// arranges for it to be gossiped again when it expires.
+func (m *Memberlist) localMeta() []byte {
+	d := m.delegate()
+	if d == nil {
+		return nil
 	}
-	return nil
+	var meta []byte
+	m.guard("NodeMeta", func() { meta = d.NodeMeta(MetaMaxSize) })
+	if len(meta) > MetaMaxSize {
+		panic("Node meta data provided is longer than the limit")
+	}
+	m.armMetaExpiry(d)
+	return meta
+}
+
+// SetDelegate replaces the delegate at runtime. It may be nil to stop calling
+// a delegate. Callbacks already in progress finish on the old delegate. The
+// local node's metadata isn't refreshed; call UpdateNode if it changed.
+func (m *Memberlist) SetDelegate(d Delegate) {
+	m.delegateLock.Lock()
+	defer m.delegateLock.Unlock()
+	m.config.Delegate = d
+}
+
+// delegate returns the current delegate, if any.
+func (m *Memberlist) delegate() Delegate {
+	m.delegateLock.RLock()
+	defer m.delegateLock.RUnlock()
+	return m.config.Delegate
 }
 
 // Deprecated: SendTo is deprecated in favor of SendBestEffort, which requires a node to
@@ -560,6 +1072,10 @@ func (m *Memberlist) SendTo(to net.Addr, msg []byte) error {
 }
 
 func (m *Memberlist) SendToAddress(a Address, msg []byte) error {
+	if err := m.checkUserMsgSize(len(msg)); err != nil {
+		return err
+	}
+
 	// Encode as a user message
 	buf := make([]byte, 1, len(msg)+1)
 	buf[0] = byte(userMsg)
@@ -582,8 +1098,14 @@ func (m *Memberlist) SendToTCP(to *Node, msg []byte) error {
 // SendBestEffort uses the unreliable packet-oriented interface of the transport
 // to target a user message at the given node (this does not use the gossip
 // mechanism). The maximum size of the message depends on the configured
-// UDPBufferSize for this memberlist instance.
+// UDPBufferSize for this memberlist instance, see MaxPayloadSize. If
+// Config.RelayFactor is set, the message is also relayed through that many
+// other members.
 func (m *Memberlist) SendBestEffort(to *Node, msg []byte) error {
+	if err := m.checkUserMsgSize(len(msg)); err != nil {
+		return err
+	}
+
 	// Encode as a user message
 	buf := make([]byte, 1, len(msg)+1)
 	buf[0] = byte(userMsg)
@@ -591,32 +1113,74 @@ func (m *Memberlist) SendBestEffort(to *Node, msg []byte) error {
 
 	// Send the message
 	a := Address{Addr: to.Address(), Name: to.Name}
-	return m.rawSendMsgPacket(a, to, buf)
+	err := m.rawSendMsgPacket(a, to, buf)
+
+	if m.config.RelayFactor > 0 {
+		if rerr := m.relayUserMsg(to, buf); rerr != nil && err != nil {
+			return err
+		}
+		// The message counts as sent if either the direct send or one of
+		// the relays took it.
+		return nil
+	}
+	return err
+}
+
+// relayUserMsg sends an encoded user message to Config.RelayFactor random
+// members, asking them to forward it to the given node. It returns an error
+// if the message couldn't be handed to any of them.
+func (m *Memberlist) relayUserMsg(to *Node, buf []byte) error {
+	m.nodeLock.RLock()
+	relays := kRandomNodes(m.config.RelayFactor, m.nodes, func(n *nodeState) bool {
+		return n.Name == m.config.Name ||
+			n.Name == to.Name ||
+			n.State != StateAlive
+	})
+	m.nodeLock.RUnlock()
+
+	if len(relays) == 0 {
+		return fmt.Errorf("no members available to relay through")
+	}
+
+	out, err := encode(relayMsg, &relay{Node: to.Name, Payload: buf}, m.config.MsgpackUseNewTimeFormat)
+	if err != nil {
+		return err
+	}
+
+	var sent int
+	for i := range relays {
+		n := &relays[i]
+		a := Address{Addr: n.Address(), Name: n.Name}
+		if err := m.rawSendMsgPacket(a, n, out.Bytes()); err != nil {
+			m.logger.Printf("[WARN] memberlist: Failed to relay message through %s: %s", n.Name, err)
+			continue
+		}
+		sent++
+	}
+	if sent == 0 {
+		return fmt.Errorf("failed to send message to any relay")
+	}
+	return nil
 }
 
 // SendReliable uses the reliable stream-oriented interface of the transport to
 // target a user message at the given node (this does not use the gossip
-// mechanism). Delivery is guaranteed if no error is returned, and there is no
-// limit on the size of the message.
+// mechanism). Delivery is guaranteed if no error is returned, and the size of
+// the message is only limited by Config.MaxUserMessageSize.
 func (m *Memberlist) SendReliable(to *Node, msg []byte) error {
-	return m.sendUserMsg(to.FullAddress(), msg)
+	if err := m.checkUserMsgSize(len(msg)); err != nil {
+		return err
+	}
+	return m.sendUserMsg(to.StreamAddress(), msg)
 }
 
 // Members returns a list of all known live nodes. The node structures
 // returned must not be modified. If you wish to modify a Node, make a
-// copy first.
+// copy first. They're a snapshot, so they don't change along with the
+// members; see MembersIfChanged for a cheap way to tell when they have.
 func (m *Memberlist) Members() []*Node {
-	m.nodeLock.RLock()
-	defer m.nodeLock.RUnlock()
-
-	nodes := make([]*Node, 0, len(m.nodes))
-	for _, n := range m.nodes {
-		if !n.DeadOrLeft() {
-			nodes = append(nodes, &n.Node)
-		}
-	}
-
-	return nodes
+	nodes := m.members().nodes
+	return append(make([]*Node, 0, len(nodes)), nodes...)
 }
 
 // NumMembers returns the number of alive nodes currently known. Between
@@ -627,13 +1191,7 @@ func (m *Memberlist) NumMembers() (alive int) {
 	m.nodeLock.RLock()
 	defer m.nodeLock.RUnlock()
 
-	for _, n := range m.nodes {
-		if !n.DeadOrLeft() {
-			alive++
-		}
-	}
-
-	return
+	return m.numAlive()
 }
 
 // Leave will broadcast a leave message but will not shutdown the background
@@ -644,18 +1202,29 @@ func (m *Memberlist) NumMembers() (alive int) {
 // a member of the cluster, if any exist or until a specified timeout
 // is reached.
 //
-// This method is safe to call multiple times, but must not be called
-// after the cluster is already shut down.
+// This method is safe to call multiple times; calls after the first return
+// straight away. It returns ErrAlreadyShutdown if called after Shutdown, or
+// if Shutdown is called while it's waiting on the broadcast.
 func (m *Memberlist) Leave(timeout time.Duration) error {
+	return m.leaveAs(timeout, ActorLocal)
+}
+
+// leaveAs is Leave, reporting the given actor to the AuditHook.
+func (m *Memberlist) leaveAs(timeout time.Duration, actor string) (err error) {
+	defer func() { m.Audit(AuditLeave, actor, "", err) }()
 	m.leaveLock.Lock()
 	defer m.leaveLock.Unlock()
 
 	if m.hasShutdown() {
-		panic("leave after shutdown")
+		return ErrAlreadyShutdown
 	}
 
 	if !m.hasLeft() {
 		atomic.StoreInt32(&m.leave, 1)
+		if !m.advanceLifecycle(LifecycleAlive, LifecycleLeaving) {
+			m.advanceLifecycle(LifecycleCreated, LifecycleLeaving)
+		}
+		defer m.advanceLifecycle(LifecycleLeaving, LifecycleLeft)
 
 		m.nodeLock.Lock()
 		state, ok := m.nodeMap[m.config.Name]
@@ -676,7 +1245,9 @@ func (m *Memberlist) Leave(timeout time.Duration) error {
 		}
 		m.deadNode(&d)
 
-		// Block until the broadcast goes out
+		// Block until the broadcast has been sent out the full number of
+		// times, which is what gives us confidence that it reached the rest
+		// of the cluster.
 		if m.anyAlive() {
 			var timeoutCh <-chan time.Time
 			if timeout > 0 {
@@ -686,6 +1257,15 @@ func (m *Memberlist) Leave(timeout time.Duration) error {
 			case <-m.leaveBroadcast:
 			case <-timeoutCh:
 				return fmt.Errorf("timeout waiting for leave broadcast")
+			case <-m.shutdownCh:
+				return ErrAlreadyShutdown
+			}
+
+			m.nodeLock.RLock()
+			b := m.leaveMsg
+			m.nodeLock.RUnlock()
+			if b == nil || !b.transmitted {
+				return fmt.Errorf("leave broadcast was dropped before it was fully propagated")
 			}
 		}
 	}
@@ -727,6 +1307,12 @@ func (m *Memberlist) ProtocolVersion() uint8 {
 // to detect this node's shutdown using probing. If you wish to more
 // gracefully exit the cluster, call Leave prior to shutting down.
 //
+// If Config.ShutdownTimeout is set, new stream connections stop being
+// accepted straight away, and push/pulls and other stream sessions already
+// in flight get up to that long to finish before the sockets are closed.
+// Any errors along the way are returned together, but shutdown carries on
+// regardless.
+//
 // This method is safe to call multiple times.
 func (m *Memberlist) Shutdown() error {
 	m.shutdownLock.Lock()
@@ -736,18 +1322,38 @@ func (m *Memberlist) Shutdown() error {
 		return nil
 	}
 
+	var errs error
+	if m.config.ShutdownTimeout > 0 {
+		if err := m.drainStreams(m.config.ShutdownTimeout); err != nil {
+			m.logger.Printf("[WARN] memberlist: Failed to drain streams: %v", err)
+			errs = multierror.Append(errs, err)
+		}
+	}
+
 	// Shut down the transport first, which should block until it's
 	// completely torn down. If we kill the memberlist-side handlers
 	// those I/O handlers might get stuck.
 	if err := m.transport.Shutdown(); err != nil {
 		m.logger.Printf("[ERR] Failed to shutdown transport: %v", err)
+		errs = multierror.Append(errs, fmt.Errorf("failed to shutdown transport: %v", err))
 	}
 
 	// Now tear down everything else.
 	atomic.StoreInt32(&m.shutdown, 1)
+	m.lifecycle.Store(int32(LifecycleShutdown))
 	close(m.shutdownCh)
 	m.deschedule()
-	return nil
+	m.timers.Stop()
+	if m.ownKeyring != nil {
+		m.ownKeyring.Wipe()
+	}
+	if m.journal != nil {
+		<-m.journal.done
+		if err := m.journal.close(); err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("failed to close event journal: %v", err))
+		}
+	}
+	return errs
 }
 
 func (m *Memberlist) hasShutdown() bool {
@@ -783,13 +1389,23 @@ func (m *Memberlist) changeNode(addr string, f func(*nodeState)) {
 }
 
 // checkBroadcastQueueDepth periodically checks the size of the broadcast queue
-// to see if it is too large
+// to see if it is too large, and reports how messages are leaving it. The
+// queue is never pruned, so there are no drops to report here; queues owned
+// by a delegate report theirs through Stats.
 func (m *Memberlist) checkBroadcastQueueDepth() {
+	var last QueueStats
 	for {
 		select {
 		case <-time.After(m.config.QueueCheckInterval):
-			numq := m.broadcasts.NumQueued()
-			metrics.AddSampleWithLabels([]string{"memberlist", "queue", "broadcasts"}, float32(numq), m.metricLabels)
+			stats := m.broadcasts.Stats()
+			metrics.AddSampleWithLabels([]string{"memberlist", "queue", "broadcasts"}, float32(stats.Queued), m.metricLabels)
+			if deduped := stats.Deduped - last.Deduped; deduped > 0 {
+				metrics.IncrCounterWithLabels([]string{"memberlist", "queue", "deduped"}, float32(deduped), m.metricLabels)
+			}
+			if stats.Retired > last.Retired {
+				metrics.AddSampleWithLabels([]string{"memberlist", "queue", "transmits"}, float32(stats.AvgTransmits), m.metricLabels)
+			}
+			last = stats
 		case <-m.shutdownCh:
 			return
 		}
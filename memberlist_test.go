@@ -319,6 +319,37 @@ func TestCreate_keyringAndSecretKey(t *testing.T) {
 	}
 }
 
+func TestMemberlist_Shutdown_WipesKeyring(t *testing.T) {
+	c := testConfig(t)
+	c.SecretKey = []byte{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1}
+	m, err := Create(c)
+	require.NoError(t, err)
+	key := c.Keyring.GetPrimaryKey()
+
+	require.NoError(t, m.Shutdown())
+	require.Equal(t, make([]byte, 16), key)
+	require.Empty(t, c.Keyring.GetKeys())
+}
+
+func TestMemberlist_Shutdown_KeepsSharedKeyring(t *testing.T) {
+	keyring, err := NewKeyring(nil, []byte{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1})
+	require.NoError(t, err)
+	var members []*Memberlist
+	for i := 0; i < 2; i++ {
+		c := testConfig(t)
+		c.Keyring = keyring
+		m, err := Create(c)
+		require.NoError(t, err)
+		members = append(members, m)
+	}
+
+	// The keyring is the caller's, so the other memberlist keeps using it.
+	require.NoError(t, members[0].Shutdown())
+	require.True(t, members[1].config.EncryptionEnabled())
+	require.Len(t, keyring.GetKeys(), 1)
+	require.NoError(t, members[1].Shutdown())
+}
+
 func TestCreate_invalidLoggerSettings(t *testing.T) {
 	c := DefaultLANConfig()
 	c.BindAddr = getBindAddr().String()
@@ -1665,6 +1696,51 @@ func TestMemberlist_Join_DeadNode(t *testing.T) {
 	}
 }
 
+func TestMemberlist_Join_Parallel(t *testing.T) {
+	// Seeds that accept connections but never answer, so each attempt
+	// takes the full TCPTimeout.
+	var stalled []string
+	for i := 0; i < 3; i++ {
+		list, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer list.Close()
+		stalled = append(stalled, "fake/"+list.Addr().String())
+	}
+
+	m1 := GetMemberlist(t, nil)
+	defer func() {
+		require.NoError(t, m1.Shutdown())
+	}()
+	require.NoError(t, m1.setAlive())
+	good := m1.config.Name + "/" + m1.LocalNode().Address()
+
+	m2 := GetMemberlist(t, func(c *Config) {
+		c.TCPTimeout = 2 * time.Second
+		c.ShutdownTimeout = 0
+		c.JoinParallelism = 4
+		c.JoinMinSuccess = 1
+	})
+	defer func() {
+		require.NoError(t, m2.Shutdown())
+	}()
+	require.NoError(t, m2.setAlive())
+
+	// The good seed comes last but isn't held up by the others.
+	start := time.Now()
+	num, err := m2.Join(append(stalled, good))
+	require.NoError(t, err)
+	require.Equal(t, 1, num)
+	require.Less(t, time.Since(start), time.Second)
+
+	// The overall deadline cuts the stalled seeds short.
+	m2.config.JoinTimeout = 100 * time.Millisecond
+	start = time.Now()
+	num, err = m2.Join(stalled)
+	require.ErrorContains(t, err, "timed out joining")
+	require.Zero(t, num)
+	require.Less(t, time.Since(start), time.Second)
+}
+
 // Tests that nodes running different versions of the protocol can successfully
 // discover each other and add themselves to their respective member lists.
 func TestMemberlist_Join_Protocol_Compatibility(t *testing.T) {
@@ -2420,3 +2496,249 @@ func runStep(t *testing.T, name string, fn func(t *testing.T)) {
 		t.FailNow()
 	}
 }
+
+func TestParseAdvertiseAddrs(t *testing.T) {
+	addrs, err := parseAdvertiseAddrs([]string{"10.0.0.1", "::1", "10.0.0.2:9000"}, 7946)
+	require.NoError(t, err)
+	require.Equal(t, []string{"10.0.0.1:7946", "[::1]:7946", "10.0.0.2:9000"}, addrs)
+
+	_, err = parseAdvertiseAddrs([]string{"example.com"}, 7946)
+	require.Error(t, err)
+}
+
+func TestMemberlist_SendBestEffort_Relay(t *testing.T) {
+	newConfig := func() (*Config, *MockDelegate) {
+		d := &MockDelegate{}
+		c := testConfig(t)
+		c.Delegate = d
+		return c, d
+	}
+
+	c1, _ := newConfig()
+	c1.RelayFactor = 1
+	m1, err := Create(c1)
+	require.NoError(t, err)
+	defer m1.Shutdown()
+
+	bindPort := m1.config.BindPort
+
+	c2, d2 := newConfig()
+	c2.BindPort = bindPort
+	m2, err := Create(c2)
+	require.NoError(t, err)
+	defer m2.Shutdown()
+
+	c3, d3 := newConfig()
+	c3.BindPort = bindPort
+	m3, err := Create(c3)
+	require.NoError(t, err)
+	defer m3.Shutdown()
+
+	_, err = m2.Join([]string{m1.config.Name + "/" + m1.config.BindAddr})
+	require.NoError(t, err)
+	_, err = m3.Join([]string{m1.config.Name + "/" + m1.config.BindAddr})
+	require.NoError(t, err)
+
+	waitForCondition(t, func() (bool, string) {
+		return m1.NumMembers() == 3 && m2.NumMembers() == 3,
+			fmt.Sprintf("expected 3 members, got %d and %d", m1.NumMembers(), m2.NumMembers())
+	})
+
+	var target Node
+	for _, n := range m1.Members() {
+		if n.Name == m3.config.Name {
+			target = *n
+		}
+	}
+	require.Equal(t, m3.config.Name, target.Name)
+
+	// Point the direct send somewhere nothing is listening, so the message
+	// can only arrive by way of m2. Relays are picked at random, so keep
+	// trying in case m2 got missed.
+	target.Addr = net.ParseIP(getBindAddr().String())
+	waitForCondition(t, func() (bool, string) {
+		require.NoError(t, m1.SendBestEffort(&target, []byte("hello")))
+		msgs := d3.getMessages()
+		return len(msgs) > 0, "expected a relayed message"
+	})
+	for _, msg := range d3.getMessages() {
+		require.Equal(t, []byte("hello"), msg)
+	}
+	require.Empty(t, d2.getMessages())
+}
+
+func TestMemberlist_HandleRelay_UnknownNode(t *testing.T) {
+	d := &MockDelegate{}
+	c := testConfig(t)
+	c.Delegate = d
+	m, err := Create(c)
+	require.NoError(t, err)
+	defer m.Shutdown()
+
+	payload := append([]byte{byte(userMsg)}, "hello"...)
+	out, err := encode(relayMsg, &relay{Node: "nope", Payload: payload}, false)
+	require.NoError(t, err)
+	m.handleCommand(out.Bytes(), &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1234}, time.Now())
+
+	// A relay addressed to ourselves is delivered locally.
+	out, err = encode(relayMsg, &relay{Node: c.Name, Payload: payload}, false)
+	require.NoError(t, err)
+	m.handleCommand(out.Bytes(), &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1234}, time.Now())
+
+	waitForCondition(t, func() (bool, string) {
+		msgs := d.getMessages()
+		return len(msgs) == 1, fmt.Sprintf("expected 1 message, got %d", len(msgs))
+	})
+}
+
+func TestMemberlist_Leave_Dropped(t *testing.T) {
+	newConfig := func() *Config {
+		c := testConfig(t)
+		c.ProbeInterval = time.Hour
+		c.GossipInterval = 0
+		c.PushPullInterval = 0
+		return c
+	}
+
+	m1, err := Create(newConfig())
+	require.NoError(t, err)
+	defer m1.Shutdown()
+
+	c2 := newConfig()
+	c2.BindPort = m1.config.BindPort
+	m2, err := Create(c2)
+	require.NoError(t, err)
+	defer m2.Shutdown()
+
+	_, err = m2.Join([]string{m1.config.Name + "/" + m1.config.BindAddr})
+	require.NoError(t, err)
+
+	// Nothing gets gossiped, so the leave broadcast sits in the queue
+	// until we throw it away.
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		m1.broadcasts.Reset()
+	}()
+	err = m1.Leave(5 * time.Second)
+	require.ErrorContains(t, err, "dropped")
+}
+
+func TestMemberlist_StreamBindPort(t *testing.T) {
+	c1 := testConfig(t)
+	ln, err := net.Listen("tcp", net.JoinHostPort(c1.BindAddr, "0"))
+	require.NoError(t, err)
+	streamPort := ln.Addr().(*net.TCPAddr).Port
+	require.NoError(t, ln.Close())
+
+	c1.StreamBindPort = streamPort
+	d1 := &MockDelegate{}
+	c1.Delegate = d1
+	m1, err := Create(c1)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m1.Shutdown())
+	}()
+	require.NotEqual(t, streamPort, m1.config.BindPort)
+	require.Equal(t, uint16(streamPort), m1.LocalNode().StreamPort)
+
+	// The second node uses one port for both, and joins via the stream
+	// port of the first.
+	c2 := testConfig(t)
+	c2.BindPort = m1.config.BindPort
+	m2, err := Create(c2)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m2.Shutdown())
+	}()
+	require.Zero(t, m2.LocalNode().StreamPort)
+
+	num, err := m2.Join([]string{m1.config.Name + "/" + joinHostPort(c1.BindAddr, uint16(streamPort))})
+	require.NoError(t, err)
+	require.Equal(t, 1, num)
+
+	var n1 *Node
+	for _, n := range m2.Members() {
+		if n.Name == m1.config.Name {
+			n1 = n
+		}
+	}
+	require.NotNil(t, n1)
+	require.Equal(t, uint16(streamPort), n1.StreamPort)
+	require.Equal(t, joinHostPort(c1.BindAddr, uint16(streamPort)), n1.StreamAddress().Addr)
+	require.Equal(t, joinHostPort(c1.BindAddr, uint16(m1.config.BindPort)), n1.FullAddress().Addr)
+
+	// Streams go to the stream port, packets to the other.
+	require.NoError(t, m2.SendReliable(n1, []byte("stream")))
+	require.NoError(t, m2.SendBestEffort(n1, []byte("packet")))
+	iretry.Run(t, func(r *iretry.R) {
+		if len(d1.getMessages()) != 2 {
+			r.Fatal("expected both messages")
+		}
+	})
+	require.ElementsMatch(t, [][]byte{[]byte("stream"), []byte("packet")}, d1.getMessages())
+}
+
+func TestMemberlist_GossipNow(t *testing.T) {
+	d1 := &MockDelegate{}
+	c1 := testConfig(t)
+	c1.GossipInterval = time.Hour
+	c1.Delegate = d1
+	m1, err := Create(c1)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m1.Shutdown())
+	}()
+
+	d2 := &MockDelegate{}
+	c2 := testConfig(t)
+	c2.BindPort = m1.config.BindPort
+	c2.Delegate = d2
+	m2, err := Create(c2)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m2.Shutdown())
+	}()
+
+	_, err = m2.Join([]string{m1.config.Name + "/" + m1.config.BindAddr})
+	require.NoError(t, err)
+
+	d1.setBroadcasts([][]byte{[]byte("now")})
+	require.NoError(t, m1.GossipNow())
+	iretry.Run(t, func(r *iretry.R) {
+		for _, msg := range d2.getMessages() {
+			if string(msg) == "now" {
+				return
+			}
+		}
+		r.Fatal("broadcast not received")
+	})
+
+	require.NoError(t, m1.Shutdown())
+	require.Error(t, m1.GossipNow())
+}
+
+func TestMemberlist_PushPullNode(t *testing.T) {
+	m1, err := Create(testConfig(t))
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m1.Shutdown())
+	}()
+
+	c2 := testConfig(t)
+	c2.BindPort = m1.config.BindPort
+	m2, err := Create(c2)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m2.Shutdown())
+	}()
+
+	// By address, before m2 knows about m1.
+	require.NoError(t, m2.PushPullNode(m1.config.Name+"/"+m1.config.BindAddr))
+	require.Equal(t, 2, m2.NumMembers())
+	require.Equal(t, 2, m1.NumMembers())
+
+	// By name, once it does.
+	require.NoError(t, m2.PushPullNode(m1.config.Name))
+
+	require.Error(t, m2.PushPullNode("nope/127.0.0.1:1"))
+}
@@ -0,0 +1,136 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// membersSnapshot is an immutable copy of the live members, taken at a
+// given membership version. They're sorted by name, so that a member joining
+// or leaving only moves the ones after it along by one.
+type membersSnapshot struct {
+	version uint64
+	nodes   []*Node
+}
+
+// bumpMembers records that something visible through Members has changed,
+// so the next read takes a new snapshot. It must be called with nodeLock
+// held for writing.
+func (m *Memberlist) bumpMembers() {
+	m.membersVersion.Add(1)
+}
+
+// members returns a snapshot of the live members, taking a new one only if
+// the membership changed since the last.
+func (m *Memberlist) members() *membersSnapshot {
+	if s := m.membersSnap.Load(); s != nil && s.version == m.membersVersion.Load() {
+		return s
+	}
+
+	m.nodeLock.RLock()
+	defer m.nodeLock.RUnlock()
+
+	// The version can't change while we hold the lock, so anyone else
+	// storing a snapshot right now stores the same one.
+	version := m.membersVersion.Load()
+	if s := m.membersSnap.Load(); s != nil && s.version == version {
+		return s
+	}
+	nodes := make([]*Node, 0, len(m.nodes))
+	for _, n := range m.nodes {
+		if !n.DeadOrLeft() {
+			node := n.Node
+			nodes = append(nodes, &node)
+		}
+	}
+	slices.SortFunc(nodes, func(a, b *Node) int { return strings.Compare(a.Name, b.Name) })
+	s := &membersSnapshot{version: version, nodes: nodes}
+	m.membersSnap.Store(s)
+	return s
+}
+
+// MembersVersion returns the current membership version. It goes up
+// whenever a member joins, leaves, fails, or changes anything Members
+// reports, such as its metadata.
+func (m *Memberlist) MembersVersion() uint64 {
+	return m.membersVersion.Load()
+}
+
+// MembersIfChanged returns the live members along with the membership
+// version they were read at, if the version is newer than sinceVersion.
+// Otherwise it returns false without copying anything, which makes it a
+// cheap way for frequent readers to check for changes. Pass zero to always
+// get the members. The returned slice and nodes are shared with other
+// callers and must not be modified.
+func (m *Memberlist) MembersIfChanged(sinceVersion uint64) ([]*Node, uint64, bool) {
+	if version := m.membersVersion.Load(); version <= sinceVersion {
+		return nil, version, false
+	}
+	s := m.members()
+	return s.nodes, s.version, true
+}
+
+// MembersPage returns up to limit of the live members, sorted by name,
+// starting at offset, along with how many there are in all and the
+// membership version they were read at. A limit of zero or less returns all
+// of them from offset on. Only the page is copied, so reading a huge
+// membership a page at a time doesn't copy it all on every call. The
+// returned slice and nodes are shared with other callers and must not be
+// modified.
+//
+// Pages read at the same version are from the same snapshot, so together
+// they hold every member exactly once. If the version changes between pages,
+// members may have shifted by as many places as have joined or left before
+// them; start again from the first page for an exact list.
+func (m *Memberlist) MembersPage(offset, limit int) (page []*Node, total int, version uint64) {
+	s := m.members()
+	total = len(s.nodes)
+	start := min(max(offset, 0), total)
+	end := total
+	if limit > 0 {
+		end = min(start+limit, total)
+	}
+	return s.nodes[start:end:end], total, s.version
+}
+
+// IterateMembers calls yield for each live member, sorted by name, until it
+// returns false. It doesn't copy the members, and it can be used with range,
+// as in "for node := range m.IterateMembers". The members are those of one
+// snapshot, so they don't change during the iteration, whatever happens to
+// the membership in the meantime, and yield may call back into Memberlist.
+// The nodes are shared with other callers and must not be modified.
+func (m *Memberlist) IterateMembers(yield func(*Node) bool) {
+	for _, node := range m.members().nodes {
+		if !yield(node) {
+			return
+		}
+	}
+}
+
+// HealthyMembers returns the live members that aren't likely to be declared
+// dead soon, for picking where to route requests. It leaves out members
+// that are suspect, draining, in maintenance, damped for flapping or that have
+// flapped more than Config.HealthyMaxFlaps times recently. Unlike Members,
+// the result isn't cached, and it can't be watched with MembersVersion.
+func (m *Memberlist) HealthyMembers() []*Node {
+	m.nodeLock.RLock()
+	defer m.nodeLock.RUnlock()
+
+	now := time.Now()
+	nodes := make([]*Node, 0, len(m.nodes))
+	for _, n := range m.nodes {
+		if n.State != StateAlive || n.Draining || n.inMaintenance(now) || n.flap.damped {
+			continue
+		}
+		if m.config.FlapHalfLife > 0 && n.flap.recentFlaps(now, m.config.FlapHalfLife) > float64(m.config.HealthyMaxFlaps) {
+			continue
+		}
+		node := n.Node
+		nodes = append(nodes, &node)
+	}
+	return nodes
+}
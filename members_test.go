@@ -0,0 +1,169 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberlist_MembersIfChanged(t *testing.T) {
+	m := GetMemberlist(t, nil)
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	nodes, v1, ok := m.MembersIfChanged(0)
+	require.True(t, ok)
+	require.Empty(t, nodes)
+
+	a := alive{Node: "test", Addr: []byte{127, 0, 0, 1}, Incarnation: 1, Vsn: m.config.BuildVsnArray()}
+	m.aliveNode(&a, nil, false)
+
+	nodes, v2, ok := m.MembersIfChanged(v1)
+	require.True(t, ok)
+	require.Greater(t, v2, v1)
+	require.Len(t, nodes, 1)
+	require.Equal(t, v2, m.MembersVersion())
+
+	// Nothing changed, so nothing is copied.
+	nodes, v3, ok := m.MembersIfChanged(v2)
+	require.False(t, ok)
+	require.Nil(t, nodes)
+	require.Equal(t, v2, v3)
+
+	// Reads share a snapshot until something changes.
+	first := m.Members()
+	second := m.Members()
+	require.Same(t, first[0], second[0])
+
+	// Which doesn't change under the reader.
+	a.Incarnation = 2
+	a.Meta = []byte("new")
+	m.aliveNode(&a, nil, false)
+	require.Empty(t, first[0].Meta)
+	nodes, v4, ok := m.MembersIfChanged(v2)
+	require.True(t, ok)
+	require.Greater(t, v4, v2)
+	require.Equal(t, []byte("new"), nodes[0].Meta)
+
+	m.changeNode("test", func(state *nodeState) {
+		state.StateChange = state.StateChange.Add(-time.Hour)
+	})
+	s := suspect{Node: "test", Incarnation: 2}
+	m.suspectNode(&s)
+	_, v5, ok := m.MembersIfChanged(v4)
+	require.True(t, ok)
+	require.Greater(t, v5, v4)
+
+	d := dead{Node: "test", Incarnation: 2}
+	m.deadNode(&d)
+	require.Empty(t, m.Members())
+}
+
+func TestMemberlist_HealthyMembers(t *testing.T) {
+	m := GetMemberlist(t, nil)
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	for _, name := range []string{"ok", "suspect", "maintenance", "flappy", "once"} {
+		a := alive{Node: name, Addr: []byte{127, 0, 0, 1}, Incarnation: 1, Vsn: m.config.BuildVsnArray()}
+		if name == "maintenance" {
+			a.Maintenance = time.Minute
+		}
+		m.aliveNode(&a, nil, false)
+	}
+	m.suspectNode(&suspect{Node: "suspect", Incarnation: 1, From: "other"})
+	m.nodeLock.Lock()
+	m.nodeMap["flappy"].flap = flapState{penalty: 2, updated: time.Now()}
+	m.nodeMap["once"].flap = flapState{penalty: 1, updated: time.Now()}
+	m.nodeLock.Unlock()
+
+	var names []string
+	for _, n := range m.HealthyMembers() {
+		names = append(names, n.Name)
+	}
+	require.ElementsMatch(t, []string{"ok", "once"}, names)
+	require.Len(t, m.Members(), 5)
+}
+
+func TestMemberlist_MembersPage(t *testing.T) {
+	m := GetMemberlist(t, nil)
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	for _, name := range []string{"e", "b", "d", "a", "c"} {
+		a := alive{Node: name, Addr: []byte{127, 0, 0, 1}, Incarnation: 1, Vsn: m.config.BuildVsnArray()}
+		m.aliveNode(&a, nil, false)
+	}
+	names := func(nodes []*Node) []string {
+		var out []string
+		for _, n := range nodes {
+			out = append(out, n.Name)
+		}
+		return out
+	}
+
+	page, total, v1 := m.MembersPage(0, 2)
+	require.Equal(t, []string{"a", "b"}, names(page))
+	require.Equal(t, 5, total)
+	require.Equal(t, m.MembersVersion(), v1)
+
+	// Pages are shared with the snapshot, but can't be appended into it.
+	page = append(page, &Node{Name: "x"})
+	page, _, v2 := m.MembersPage(2, 2)
+	require.Equal(t, []string{"c", "d"}, names(page))
+	require.Equal(t, v1, v2)
+
+	page, _, _ = m.MembersPage(4, 2)
+	require.Equal(t, []string{"e"}, names(page))
+	page, _, _ = m.MembersPage(10, 2)
+	require.Empty(t, page)
+	page, _, _ = m.MembersPage(-1, 0)
+	require.Equal(t, []string{"a", "b", "c", "d", "e"}, names(page))
+
+	// A member joining moves the ones after it along by one.
+	a := alive{Node: "bb", Addr: []byte{127, 0, 0, 1}, Incarnation: 1, Vsn: m.config.BuildVsnArray()}
+	m.aliveNode(&a, nil, false)
+	page, total, v3 := m.MembersPage(2, 2)
+	require.Equal(t, []string{"bb", "c"}, names(page))
+	require.Equal(t, 6, total)
+	require.Greater(t, v3, v2)
+}
+
+func TestMemberlist_IterateMembers(t *testing.T) {
+	m := GetMemberlist(t, nil)
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	for _, name := range []string{"c", "a", "b"} {
+		a := alive{Node: name, Addr: []byte{127, 0, 0, 1}, Incarnation: 1, Vsn: m.config.BuildVsnArray()}
+		m.aliveNode(&a, nil, false)
+	}
+
+	// The iteration sees one snapshot, whatever happens meanwhile.
+	var names []string
+	for n := range m.IterateMembers {
+		names = append(names, n.Name)
+		if n.Name == "a" {
+			m.deadNode(&dead{Node: "b", Incarnation: 1})
+			a := alive{Node: "d", Addr: []byte{127, 0, 0, 1}, Incarnation: 1, Vsn: m.config.BuildVsnArray()}
+			m.aliveNode(&a, nil, false)
+		}
+	}
+	require.Equal(t, []string{"a", "b", "c"}, names)
+
+	// And stops when asked to.
+	names = nil
+	m.IterateMembers(func(n *Node) bool {
+		names = append(names, n.Name)
+		return false
+	})
+	require.Equal(t, []string{"a"}, names)
+}
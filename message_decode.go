@@ -0,0 +1,458 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// The messages sent on every probe and every gossip round, pings, acks and
+// alives, are decoded by hand rather than by the reflection based codec,
+// reading their fields straight out of the packet. Everything else is rare
+// enough to go through decode.
+
+// fastDecoder is implemented by the messages that have a hand written
+// decoder for their msgpack form.
+type fastDecoder interface {
+	// decodeMsgpack decodes buf into the message. It leaves the message
+	// untouched if it returns an error.
+	decodeMsgpack(buf []byte) error
+}
+
+// decodeMessage decodes the body of a message, without the type byte, using
+// its hand written decoder if it has one. Anything that decoder doesn't
+// understand is left to the generic codec, so it's never stricter.
+func decodeMessage(buf []byte, out interface{}) error {
+	if fd, ok := out.(fastDecoder); ok {
+		if err := fd.decodeMsgpack(buf); err == nil {
+			return nil
+		}
+	}
+	return decode(buf, out)
+}
+
+// maxSkipDepth bounds how deeply nested a field a hand written decoder
+// skips over before giving up.
+const maxSkipDepth = 8
+
+// errMsgpackShort is returned when a message ends part way through a value.
+var errMsgpackShort = errors.New("msgpack: message is truncated")
+
+// msgpackReader reads msgpack values in place. The byte slices it returns
+// point into the buffer it reads, so they must be copied if they're kept
+// after the buffer may be reused.
+type msgpackReader struct {
+	buf []byte
+}
+
+func (r *msgpackReader) take(n int) ([]byte, error) {
+	if n < 0 || n > len(r.buf) {
+		return nil, errMsgpackShort
+	}
+	b := r.buf[:n:n]
+	r.buf = r.buf[n:]
+	return b, nil
+}
+
+func (r *msgpackReader) readByte() (byte, error) {
+	if len(r.buf) == 0 {
+		return 0, errMsgpackShort
+	}
+	c := r.buf[0]
+	r.buf = r.buf[1:]
+	return c, nil
+}
+
+// readLen reads an n byte big endian length.
+func (r *msgpackReader) readLen(n int) (int, error) {
+	b, err := r.take(n)
+	if err != nil {
+		return 0, err
+	}
+	switch n {
+	case 1:
+		return int(b[0]), nil
+	case 2:
+		return int(binary.BigEndian.Uint16(b)), nil
+	default:
+		return int(binary.BigEndian.Uint32(b)), nil
+	}
+}
+
+// readMapLen reads the header of a map, returning its number of entries.
+func (r *msgpackReader) readMapLen() (int, error) {
+	c, err := r.readByte()
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case c >= 0x80 && c <= 0x8f:
+		return int(c & 0x0f), nil
+	case c == 0xde:
+		return r.readLen(2)
+	case c == 0xdf:
+		return r.readLen(4)
+	}
+	return 0, fmt.Errorf("msgpack: expected a map, got %#x", c)
+}
+
+// readArrayLen reads the header of an array, returning its number of
+// elements. A nil is an empty array.
+func (r *msgpackReader) readArrayLen() (int, error) {
+	c, err := r.readByte()
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case c >= 0x90 && c <= 0x9f:
+		return int(c & 0x0f), nil
+	case c == 0xdc:
+		return r.readLen(2)
+	case c == 0xdd:
+		return r.readLen(4)
+	case c == 0xc0:
+		return 0, nil
+	}
+	return 0, fmt.Errorf("msgpack: expected an array, got %#x", c)
+}
+
+// readBytes reads a string or binary value, returning it in place. A nil is
+// a nil slice.
+func (r *msgpackReader) readBytes() ([]byte, error) {
+	c, err := r.readByte()
+	if err != nil {
+		return nil, err
+	}
+	var n int
+	switch {
+	case c >= 0xa0 && c <= 0xbf:
+		n = int(c & 0x1f)
+	case c == 0xd9 || c == 0xc4:
+		n, err = r.readLen(1)
+	case c == 0xda || c == 0xc5:
+		n, err = r.readLen(2)
+	case c == 0xdb || c == 0xc6:
+		n, err = r.readLen(4)
+	case c == 0xc0:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("msgpack: expected a string, got %#x", c)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.take(n)
+}
+
+// readCopy is like readBytes, but returns a copy of the value.
+func (r *msgpackReader) readCopy() ([]byte, error) {
+	b, err := r.readBytes()
+	if b == nil || err != nil {
+		return nil, err
+	}
+	return append([]byte{}, b...), nil
+}
+
+// readString reads a string or binary value into a string of its own.
+func (r *msgpackReader) readString() (string, error) {
+	b, err := r.readBytes()
+	return string(b), err
+}
+
+// readStrings reads an array of strings.
+func (r *msgpackReader) readStrings() ([]string, error) {
+	n, err := r.readArrayLen()
+	if err != nil || n == 0 {
+		return nil, err
+	}
+	if n > len(r.buf) {
+		return nil, errMsgpackShort
+	}
+	ss := make([]string, n)
+	for i := range ss {
+		if ss[i], err = r.readString(); err != nil {
+			return nil, err
+		}
+	}
+	return ss, nil
+}
+
+// readInt reads an integer of any width, checking it's within [min, max].
+// A nil is zero.
+func (r *msgpackReader) readInt(min, max int64) (int64, error) {
+	c, err := r.readByte()
+	if err != nil {
+		return 0, err
+	}
+	var v int64
+	switch {
+	case c <= 0x7f:
+		v = int64(c)
+	case c >= 0xe0:
+		v = int64(int8(c))
+	case c == 0xc0:
+		v = 0
+	case c >= 0xcc && c <= 0xd3:
+		size := 1 << ((c - 0xcc) & 3)
+		b, err := r.take(size)
+		if err != nil {
+			return 0, err
+		}
+		var u uint64
+		for _, x := range b {
+			u = u<<8 | uint64(x)
+		}
+		if c <= 0xcf {
+			// Unsigned
+			if u > math.MaxInt64 {
+				return 0, fmt.Errorf("msgpack: integer %d overflows", u)
+			}
+			v = int64(u)
+		} else {
+			// Signed, sign extend it
+			shift := 64 - 8*size
+			v = int64(u<<shift) >> shift
+		}
+	default:
+		return 0, fmt.Errorf("msgpack: expected an integer, got %#x", c)
+	}
+	if v < min || v > max {
+		return 0, fmt.Errorf("msgpack: integer %d overflows", v)
+	}
+	return v, nil
+}
+
+func (r *msgpackReader) readUint16() (uint16, error) {
+	v, err := r.readInt(0, math.MaxUint16)
+	return uint16(v), err
+}
+
+func (r *msgpackReader) readUint32() (uint32, error) {
+	v, err := r.readInt(0, math.MaxUint32)
+	return uint32(v), err
+}
+
+// readBool reads a boolean. A nil is false.
+func (r *msgpackReader) readBool() (bool, error) {
+	c, err := r.readByte()
+	if err != nil {
+		return false, err
+	}
+	switch c {
+	case 0xc2, 0xc0:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	}
+	return false, fmt.Errorf("msgpack: expected a bool, got %#x", c)
+}
+
+// skip skips over a value of any type, such as a field from a newer version
+// this one doesn't know.
+func (r *msgpackReader) skip(depth int) error {
+	if depth > maxSkipDepth {
+		return errors.New("msgpack: value is nested too deeply")
+	}
+	c, err := r.readByte()
+	if err != nil {
+		return err
+	}
+	var n, elems int
+	switch {
+	case c <= 0x7f || c >= 0xe0 || c == 0xc0 || c == 0xc2 || c == 0xc3:
+	case c >= 0x80 && c <= 0x8f:
+		elems = 2 * int(c&0x0f)
+	case c >= 0x90 && c <= 0x9f:
+		elems = int(c & 0x0f)
+	case c >= 0xa0 && c <= 0xbf:
+		n = int(c & 0x1f)
+	case c == 0xc4 || c == 0xd9:
+		n, err = r.readLen(1)
+	case c == 0xc5 || c == 0xda:
+		n, err = r.readLen(2)
+	case c == 0xc6 || c == 0xdb:
+		n, err = r.readLen(4)
+	case c == 0xc7 || c == 0xc8 || c == 0xc9:
+		// ext, with a type byte after the length
+		n, err = r.readLen(1 << (c - 0xc7))
+		n++
+	case c == 0xca:
+		n = 4
+	case c == 0xcb:
+		n = 8
+	case c >= 0xcc && c <= 0xd3:
+		n = 1 << ((c - 0xcc) & 3)
+	case c >= 0xd4 && c <= 0xd8:
+		// fixext, with a type byte before the data
+		n = 1 + 1<<(c-0xd4)
+	case c == 0xdc:
+		elems, err = r.readLen(2)
+	case c == 0xdd:
+		elems, err = r.readLen(4)
+	case c == 0xde:
+		elems, err = r.readLen(2)
+		elems *= 2
+	case c == 0xdf:
+		elems, err = r.readLen(4)
+		elems *= 2
+	default:
+		return fmt.Errorf("msgpack: unknown type %#x", c)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := r.take(n); err != nil {
+		return err
+	}
+	if elems > len(r.buf) {
+		return errMsgpackShort
+	}
+	for i := 0; i < elems; i++ {
+		if err := r.skip(depth + 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readFields reads a struct encoded as a map of field names to values,
+// calling field for each, which should read the value or return false to
+// have it skipped.
+func (r *msgpackReader) readFields(field func(name []byte) (bool, error)) error {
+	n, err := r.readMapLen()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		name, err := r.readBytes()
+		if err != nil {
+			return err
+		}
+		ok, err := field(name)
+		if err != nil {
+			return fmt.Errorf("msgpack: field %s: %w", name, err)
+		}
+		if !ok {
+			if err := r.skip(0); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// decodeMsgpack implements fastDecoder. Pad and SourceAddr point into buf.
+func (p *ping) decodeMsgpack(buf []byte) error {
+	var v ping
+	r := msgpackReader{buf: buf}
+	err := r.readFields(func(name []byte) (ok bool, err error) {
+		switch string(name) {
+		case "SeqNo":
+			v.SeqNo, err = r.readUint32()
+		case "Node":
+			v.Node, err = r.readString()
+		case "SourceAddr":
+			v.SourceAddr, err = r.readBytes()
+		case "SourcePort":
+			v.SourcePort, err = r.readUint16()
+		case "SourceNode":
+			v.SourceNode, err = r.readString()
+		case "Pad":
+			v.Pad, err = r.readBytes()
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+	if err != nil {
+		return err
+	}
+	*p = v
+	return nil
+}
+
+// decodeMsgpack implements fastDecoder. The payload is copied, as it's
+// handed on to the ping delegate, which may keep it.
+func (a *ackResp) decodeMsgpack(buf []byte) error {
+	var v ackResp
+	r := msgpackReader{buf: buf}
+	err := r.readFields(func(name []byte) (ok bool, err error) {
+		switch string(name) {
+		case "SeqNo":
+			v.SeqNo, err = r.readUint32()
+		case "Payload":
+			v.Payload, err = r.readCopy()
+		case "Health":
+			var h int64
+			h, err = r.readInt(math.MinInt, math.MaxInt)
+			v.Health = int(h)
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
+
+// decodeMsgpack implements fastDecoder. Vsn points into buf, while Addr and
+// Meta are copied, as they're kept in the node's state and handed on to
+// delegates.
+func (a *alive) decodeMsgpack(buf []byte) error {
+	var v alive
+	r := msgpackReader{buf: buf}
+	err := r.readFields(func(name []byte) (ok bool, err error) {
+		switch string(name) {
+		case "Incarnation":
+			v.Incarnation, err = r.readUint32()
+		case "Node":
+			v.Node, err = r.readString()
+		case "Addr":
+			v.Addr, err = r.readCopy()
+		case "Port":
+			v.Port, err = r.readUint16()
+		case "Meta":
+			v.Meta, err = r.readCopy()
+		case "Vsn":
+			v.Vsn, err = r.readBytes()
+		case "Addrs":
+			v.Addrs, err = r.readStrings()
+		case "Role":
+			var role int64
+			role, err = r.readInt(0, math.MaxUint8)
+			v.Role = NodeRole(role)
+		case "Zone":
+			v.Zone, err = r.readString()
+		case "ID":
+			v.ID, err = r.readString()
+		case "StreamPort":
+			v.StreamPort, err = r.readUint16()
+		case "Maintenance":
+			var d int64
+			d, err = r.readInt(math.MinInt64, math.MaxInt64)
+			v.Maintenance = time.Duration(d)
+		case "Weight":
+			v.Weight, err = r.readUint16()
+		case "Draining":
+			v.Draining, err = r.readBool()
+		case "Complete":
+			v.Complete, err = r.readBool()
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
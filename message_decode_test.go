@@ -0,0 +1,155 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fastDecodeCases are messages for the hand written decoders, with every
+// field set and with none.
+var fastDecodeCases = []struct {
+	name string
+	msg  fastDecoder
+	new  func() fastDecoder
+}{
+	{"ping", &ping{}, func() fastDecoder { return &ping{} }},
+	{"ping full", &ping{
+		SeqNo:      42,
+		Node:       "node-1",
+		SourceAddr: net.ParseIP("10.0.0.2"),
+		SourcePort: 7946,
+		SourceNode: "node-2",
+		Pad:        make([]byte, 300),
+	}, func() fastDecoder { return &ping{} }},
+	{"ack", &ackResp{}, func() fastDecoder { return &ackResp{} }},
+	{"ack full", &ackResp{SeqNo: 1 << 31, Payload: []byte("payload"), Health: -3}, func() fastDecoder { return &ackResp{} }},
+	{"alive", &alive{}, func() fastDecoder { return &alive{} }},
+	{"alive full", &alive{
+		Incarnation: 1<<32 - 1,
+		Node:        "node-1",
+		Addr:        net.ParseIP("::1"),
+		Port:        65535,
+		Meta:        make([]byte, 512),
+		Vsn:         []uint8{1, 5, 5, 2, 5, 4},
+		Addrs:       []string{"10.1.0.1:7946", "[::1]:7946"},
+		Role:        3,
+		Zone:        "us-east-1a",
+		ID:          "2f7d7f5c-2fa0-4c6c-9a3e-6c1b5d1c6b7e",
+		StreamPort:  7947,
+		Maintenance: -time.Minute,
+		Weight:      200,
+		Draining:    true,
+		Complete:    true,
+	}, func() fastDecoder { return &alive{} }},
+}
+
+func TestDecodeMessage_MatchesCodec(t *testing.T) {
+	for _, tc := range fastDecodeCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf, err := encode(pingMsg, tc.msg, false)
+			require.NoError(t, err)
+			body := buf.Bytes()[1:]
+
+			want, got := tc.new(), tc.new()
+			require.NoError(t, decode(body, want))
+			require.NoError(t, got.decodeMsgpack(body))
+			require.Equal(t, want, got)
+
+			// It gives up on anything cut short, rather than reading past
+			// the end.
+			for i := 0; i < len(body); i++ {
+				require.Error(t, tc.new().decodeMsgpack(body[:i]), "truncated at %d", i)
+			}
+		})
+	}
+}
+
+func TestDecodeMessage_SkipsUnknownFields(t *testing.T) {
+	in := map[string]interface{}{
+		"Future":   map[string]interface{}{"a": []interface{}{1, "two", 3.5, nil}},
+		"SeqNo":    uint64(7),
+		"Payload":  []byte("hi"),
+		"Float":    1.25,
+		"Negative": int64(-1 << 40),
+		"Health":   int8(-2),
+	}
+	buf, err := encode(ackRespMsg, in, false)
+	require.NoError(t, err)
+
+	var ack ackResp
+	require.NoError(t, ack.decodeMsgpack(buf.Bytes()[1:]))
+	require.Equal(t, ackResp{SeqNo: 7, Payload: []byte("hi"), Health: -2}, ack)
+}
+
+func TestDecodeMessage_Overflow(t *testing.T) {
+	for _, in := range []map[string]interface{}{
+		{"Port": 1 << 16},
+		{"Port": -1},
+		{"Incarnation": uint64(1 << 32)},
+		{"Role": 256},
+	} {
+		buf, err := encode(aliveMsg, in, false)
+		require.NoError(t, err)
+		a := alive{Node: "untouched"}
+		require.Error(t, a.decodeMsgpack(buf.Bytes()[1:]), "%v", in)
+		require.Equal(t, alive{Node: "untouched"}, a)
+	}
+}
+
+func TestDecodeMessage_Fallback(t *testing.T) {
+	// The codec takes a nil as an empty message, which the hand written
+	// decoder leaves to it.
+	var p ping
+	require.Error(t, p.decodeMsgpack([]byte{0xc0}))
+	require.NoError(t, decodeMessage([]byte{0xc0}, &p))
+
+	// Messages without a hand written decoder go straight to the codec.
+	buf, err := encode(deadMsg, &dead{Node: "node-1", Incarnation: 3}, false)
+	require.NoError(t, err)
+	var d dead
+	require.NoError(t, decodeMessage(buf.Bytes()[1:], &d))
+	require.Equal(t, dead{Node: "node-1", Incarnation: 3}, d)
+}
+
+func TestDecodeMessage_CopiesKeptFields(t *testing.T) {
+	buf, err := encode(aliveMsg, &alive{Node: "node-1", Addr: []byte{127, 0, 0, 1}, Meta: []byte("meta")}, false)
+	require.NoError(t, err)
+	body := buf.Bytes()[1:]
+
+	var a alive
+	require.NoError(t, a.decodeMsgpack(body))
+	clear(body)
+	require.Equal(t, []byte{127, 0, 0, 1}, a.Addr)
+	require.Equal(t, []byte("meta"), a.Meta)
+}
+
+func BenchmarkDecodeMessage(b *testing.B) {
+	for _, tc := range fastDecodeCases {
+		buf, err := encode(pingMsg, tc.msg, false)
+		require.NoError(b, err)
+		body := buf.Bytes()[1:]
+
+		b.Run(tc.name+"/codec", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := decode(body, tc.new()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(tc.name+"/fast", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := tc.new().decodeMsgpack(body); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
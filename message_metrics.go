@@ -0,0 +1,64 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	metrics "github.com/hashicorp/go-metrics/compat"
+)
+
+/*
+Every message sent and received is counted by type, as
+memberlist.messages.sent and memberlist.messages.received, with a "type"
+label holding the message type's name, such as "ping", "alive" or
+"push-pull", and a "transport" label that's "packet" or "stream". Their sizes
+are sampled the same way, as memberlist.messages.sent_bytes and
+memberlist.messages.received_bytes, before compression and encryption, so
+gossip traffic can be planned for without packet captures. The messages
+inside a compound message are counted one by one, and the size of a stream
+isn't known up front when it's received, so only its count is kept.
+*/
+
+// countMessage counts a message sent or received by type, and samples its
+// size if it's known.
+func (m *Memberlist) countMessage(sent bool, stream bool, msgType messageType, size int) {
+	dir, transport := "received", "packet"
+	if sent {
+		dir = "sent"
+	}
+	if stream {
+		transport = "stream"
+	}
+	labels := make([]metrics.Label, 0, len(m.metricLabels)+2)
+	labels = append(labels, m.metricLabels...)
+	labels = append(labels,
+		metrics.Label{Name: "type", Value: msgType.String()},
+		metrics.Label{Name: "transport", Value: transport})
+	metrics.IncrCounterWithLabels([]string{"memberlist", "messages", dir}, 1, labels)
+	if size > 0 {
+		metrics.AddSampleWithLabels([]string{"memberlist", "messages", dir + "_bytes"}, float32(size), labels)
+	}
+}
+
+// countSentPacket counts the messages in a packet we're about to send.
+func (m *Memberlist) countSentPacket(msg []byte) {
+	if len(msg) == 0 {
+		return
+	}
+	switch messageType(msg[0]) {
+	case compoundMsg:
+		_, parts, err := decodeCompoundMessage(msg[1:])
+		if err != nil {
+			return
+		}
+		for _, part := range parts {
+			m.countSentPacket(part)
+		}
+	case protoMsg:
+		if len(msg) > 1 {
+			m.countMessage(true, false, messageType(msg[1]), len(msg))
+		}
+	default:
+		m.countMessage(true, false, messageType(msg[0]), len(msg))
+	}
+}
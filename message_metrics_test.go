@@ -0,0 +1,49 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberlist_MessageMetrics(t *testing.T) {
+	sink := registerInMemorySink(t)
+
+	m1 := GetMemberlist(t, nil)
+	defer func() {
+		require.NoError(t, m1.Shutdown())
+	}()
+	require.NoError(t, m1.setAlive())
+
+	m2 := GetMemberlist(t, nil)
+	defer func() {
+		require.NoError(t, m2.Shutdown())
+	}()
+	require.NoError(t, m2.setAlive())
+	_, err := m2.Join([]string{m1.config.Name + "/" + m1.LocalNode().Address()})
+	require.NoError(t, err)
+
+	addr := &net.UDPAddr{IP: m1.LocalNode().Addr, Port: int(m1.LocalNode().Port)}
+	_, _, err = m2.Ping(m1.config.Name, addr)
+	require.NoError(t, err)
+
+	interval := getIntervalMetrics(t, sink)
+	interval.RLock()
+	defer interval.RUnlock()
+	for _, name := range []string{
+		"consul.usage.test.memberlist.messages.sent;type=ping;transport=packet",
+		"consul.usage.test.memberlist.messages.received;type=ping;transport=packet",
+		"consul.usage.test.memberlist.messages.sent;type=ack;transport=packet",
+		"consul.usage.test.memberlist.messages.received;type=ack;transport=packet",
+		"consul.usage.test.memberlist.messages.sent;type=push-pull;transport=stream",
+		"consul.usage.test.memberlist.messages.received;type=push-pull;transport=stream",
+	} {
+		require.Contains(t, interval.Counters, name)
+	}
+	require.Contains(t, interval.Samples, "consul.usage.test.memberlist.messages.sent_bytes;type=ping;transport=packet")
+	require.Contains(t, interval.Samples, "consul.usage.test.memberlist.messages.received_bytes;type=ack;transport=packet")
+}
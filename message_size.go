@@ -0,0 +1,75 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"fmt"
+
+	metrics "github.com/hashicorp/go-metrics/compat"
+)
+
+// ErrMessageTooLarge is returned when a user message is bigger than can be
+// sent. Limit is the largest size that would have been accepted.
+type ErrMessageTooLarge struct {
+	Limit int
+	Size  int
+}
+
+func (e ErrMessageTooLarge) Error() string {
+	return fmt.Sprintf("message of %d bytes is larger than the limit of %d bytes", e.Size, e.Limit)
+}
+
+// MaxPayloadSize returns the largest user message that fits in a single
+// packet right now, whether it's returned from Delegate.GetBroadcasts or
+// sent with SendBestEffort. It takes the label, encryption and Lamport time
+// stamps into account, and is capped at Config.MaxUserMessageSize. Bigger
+// messages need SendReliable, or splitting up by the application.
+func (m *Memberlist) MaxPayloadSize() int {
+	size := m.config.UDPBufferSize - compoundHeaderOverhead - compoundOverhead - m.packetOverhead()
+	if _, timed := m.delegate().(LamportDelegate); timed {
+		size -= timedUserMsgOverhead
+	} else {
+		size -= userMsgOverhead
+	}
+	if limit := m.config.MaxUserMessageSize; limit > 0 && limit < size {
+		size = limit
+	}
+	return max(size, 0)
+}
+
+// packetOverhead returns how much of a packet goes to what rawSendMsgPacket
+// wraps the payload in, with the settings in effect right now: the label,
+// the checksum, and encryption with the cipher our protocol version uses if
+// outgoing gossip is encrypted. Those can change at runtime, such as when
+// upshifting to encryption, so budgets have to ask each time rather than
+// work it out once. Compression is only used when it makes the payload
+// smaller, so it needs no room.
+func (m *Memberlist) packetOverhead() int {
+	overhead := labelOverhead(m.config.Label) + crcOverhead
+	if m.config.EncryptionEnabled() && m.config.GossipVerifyOutgoing {
+		overhead += encryptOverhead(m.encryptionVersion())
+	}
+	return overhead
+}
+
+// checkUserMsgSize returns ErrMessageTooLarge if a user message is bigger
+// than Config.MaxUserMessageSize.
+func (m *Memberlist) checkUserMsgSize(size int) error {
+	if limit := m.config.MaxUserMessageSize; limit > 0 && size > limit {
+		return ErrMessageTooLarge{Limit: limit, Size: size}
+	}
+	return nil
+}
+
+// userMsgAllowed returns true if a user message we've been handed isn't
+// over Config.MaxUserMessageSize, logging it if it is.
+func (m *Memberlist) userMsgAllowed(size int) bool {
+	err := m.checkUserMsgSize(size)
+	if err == nil {
+		return true
+	}
+	m.logger.Printf("[WARN] memberlist: Dropping user message: %s", err)
+	metrics.IncrCounterWithLabels([]string{"memberlist", "msg", "too_large"}, 1, m.metricLabels)
+	return false
+}
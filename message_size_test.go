@@ -0,0 +1,143 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberlist_MaxUserMessageSize(t *testing.T) {
+	m := GetMemberlist(t, func(c *Config) {
+		c.MaxUserMessageSize = 100
+	})
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+	require.NoError(t, m.setAlive())
+	self := m.LocalNode()
+
+	var tooLarge ErrMessageTooLarge
+	err := m.SendBestEffort(self, make([]byte, 101))
+	require.True(t, errors.As(err, &tooLarge))
+	require.Equal(t, ErrMessageTooLarge{Limit: 100, Size: 101}, tooLarge)
+
+	require.ErrorAs(t, m.SendReliable(self, make([]byte, 101)), &tooLarge)
+	require.ErrorAs(t, m.SendToAddress(self.FullAddress(), make([]byte, 101)), &tooLarge)
+	_, err = m.BroadcastWithAck(make([]byte, 101), time.Second)
+	require.ErrorAs(t, err, &tooLarge)
+
+	require.NoError(t, m.SendBestEffort(self, make([]byte, 100)))
+	require.Equal(t, 100, m.MaxPayloadSize())
+}
+
+func TestMemberlist_MaxUserMessageSize_Receive(t *testing.T) {
+	d := &MockDelegate{}
+	m := GetMemberlist(t, func(c *Config) {
+		c.MaxUserMessageSize = 10
+		c.Delegate = d
+	})
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	m.handleUser(make([]byte, 11), nil)
+	m.handleUser(make([]byte, 10), nil)
+	require.Len(t, d.getMessages(), 1)
+}
+
+// limitDelegate records the space it's offered for broadcasts.
+type limitDelegate struct {
+	MockDelegate
+	overhead, limit int
+}
+
+func (d *limitDelegate) GetBroadcasts(overhead, limit int) [][]byte {
+	d.overhead, d.limit = overhead, limit
+	return nil
+}
+
+func TestMemberlist_MaxPayloadSize(t *testing.T) {
+	d := &limitDelegate{}
+	m := GetMemberlist(t, func(c *Config) {
+		c.Delegate = d
+		c.Label = "cluster-a"
+	})
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	// It's all the room a user broadcast gets in an otherwise empty packet.
+	m.getBroadcasts(compoundOverhead, m.config.UDPBufferSize-compoundHeaderOverhead-m.packetOverhead())
+	require.Equal(t, d.limit-d.overhead, m.MaxPayloadSize())
+
+	// Unless the configured limit is lower.
+	m.config.MaxUserMessageSize = 100
+	require.Equal(t, 100, m.MaxPayloadSize())
+}
+
+// sizeTransport keeps track of the largest packet written.
+type sizeTransport struct {
+	*NetTransport
+
+	lock    sync.Mutex
+	largest int
+}
+
+func (t *sizeTransport) WriteToAddress(b []byte, addr Address) (time.Time, error) {
+	t.lock.Lock()
+	t.largest = max(t.largest, len(b))
+	t.lock.Unlock()
+	return t.NetTransport.WriteToAddress(b, addr)
+}
+
+func TestMemberlist_PacketOverhead(t *testing.T) {
+	c := testConfig(t)
+	nt, err := NewNetTransport(&NetTransportConfig{
+		BindAddrs: []string{c.BindAddr},
+		Logger:    c.Logger,
+	})
+	require.NoError(t, err)
+	st := &sizeTransport{NetTransport: nt}
+	c.Transport = st
+	c.BindPort = nt.GetAutoBindPort()
+	c.AdvertisePort = c.BindPort
+	c.Label = "cluster-a"
+	c.SecretKey = make([]byte, 16)
+	c.EnableCompression = false
+	m, err := Create(c)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	overhead := labelOverhead(c.Label) + crcOverhead + encryptOverhead(m.encryptionVersion())
+	require.Equal(t, overhead, m.packetOverhead())
+
+	a := alive{Node: "peer", Addr: []byte{127, 0, 0, 1}, Port: 1, Incarnation: 1, Vsn: m.config.BuildVsnArray()}
+	m.aliveNode(&a, nil, false)
+	// Sizes from 1 to 100 bytes fill the packet right up to the budget.
+	for i := 0; i < 100; i++ {
+		msg := append([]byte{byte(userMsg)}, make([]byte, i)...)
+		m.queueBroadcast(fmt.Sprintf("b%d", i), msg, nil)
+	}
+
+	// Picking the peer to gossip to is random, even with just the one.
+	require.Eventually(t, func() bool {
+		m.gossip()
+		st.lock.Lock()
+		defer st.lock.Unlock()
+		return st.largest > 0
+	}, time.Second, time.Millisecond)
+
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	require.Greater(t, st.largest, m.config.UDPBufferSize-10)
+	require.LessOrEqual(t, st.largest, m.config.UDPBufferSize)
+}
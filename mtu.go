@@ -0,0 +1,169 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"crypto/rand"
+	"time"
+)
+
+const (
+	// mtuFloor is the smallest packet we probe with, which is the 576 byte
+	// datagram every IPv4 host must accept, less the largest IP header and
+	// the UDP header. Anything smaller than this isn't worth adapting to.
+	mtuFloor = 508
+
+	// mtuStep is how close the probes get to the exact limit.
+	mtuStep = 16
+)
+
+// mtuShortfall returns how much smaller than UDPBufferSize packets to a node
+// have to be, which is zero unless a probe found a smaller limit.
+func (m *Memberlist) mtuShortfall(name string) int {
+	m.mtuLock.Lock()
+	defer m.mtuLock.Unlock()
+
+	if mtu, ok := m.pathMTU[name]; ok && mtu < m.config.UDPBufferSize {
+		return m.config.UDPBufferSize - mtu
+	}
+	return 0
+}
+
+// probeMTU is invoked every MTUProbeInterval to find out how large a packet
+// gets through to a random live member.
+func (m *Memberlist) probeMTU() {
+	m.nodeLock.RLock()
+	nodes := kRandomNodes(1, m.nodes, func(n *nodeState) bool {
+		return n.Name == m.config.Name || n.State != StateAlive
+	})
+	m.nodeLock.RUnlock()
+	if len(nodes) == 0 {
+		return
+	}
+	node := nodes[0]
+
+	mtu, ok := m.findMTU(&node)
+	if !ok {
+		return
+	}
+
+	m.nodeLock.RLock()
+	m.mtuLock.Lock()
+	if m.pathMTU == nil {
+		m.pathMTU = make(map[string]int)
+	}
+	old, known := m.pathMTU[node.Name]
+	m.pathMTU[node.Name] = mtu
+
+	// Forget about members we no longer know
+	for name := range m.pathMTU {
+		if _, ok := m.nodeMap[name]; !ok {
+			delete(m.pathMTU, name)
+		}
+	}
+	m.mtuLock.Unlock()
+	m.nodeLock.RUnlock()
+
+	if mtu < m.config.UDPBufferSize && (!known || old != mtu) {
+		m.logger.Printf("[INFO] memberlist: Packets to %s are limited to %d bytes", node.Name, mtu)
+	} else if known && old < m.config.UDPBufferSize && mtu == m.config.UDPBufferSize {
+		m.logger.Printf("[INFO] memberlist: Packets to %s are no longer limited", node.Name)
+	}
+}
+
+// findMTU searches for the largest packet between mtuFloor and UDPBufferSize
+// that gets through to a node. It returns false if not even the smallest
+// does, since the node is then having trouble that has nothing to do with
+// packet sizes.
+func (m *Memberlist) findMTU(node *Node) (int, bool) {
+	lo, hi := mtuFloor, m.config.UDPBufferSize
+	if hi <= lo || m.sendMTUProbes(node, hi) {
+		return hi, true
+	}
+	if !m.sendMTUProbes(node, lo) {
+		return 0, false
+	}
+	for hi-lo > mtuStep {
+		mid := (lo + hi) / 2
+		if m.sendMTUProbes(node, mid) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo, true
+}
+
+// sendMTUProbes tries a packet size twice before giving up on it, since a
+// packet can be lost for reasons other than its size.
+func (m *Memberlist) sendMTUProbes(node *Node, size int) bool {
+	for i := 0; i < 2 && !m.hasShutdown(); i++ {
+		if m.sendMTUProbe(node, size) {
+			return true
+		}
+	}
+	return false
+}
+
+// sendMTUProbe pings a node with a packet of the given size, as it goes on
+// the wire, and returns true if it was acked.
+func (m *Memberlist) sendMTUProbe(node *Node, size int) bool {
+	selfAddr, selfPort := m.getAdvertise()
+	p := ping{
+		SeqNo:      m.nextSeqNo(),
+		Node:       node.Name,
+		SourceAddr: selfAddr,
+		SourcePort: selfPort,
+		SourceNode: m.config.Name,
+	}
+
+	// Work out what gets added to the message on its way out
+	overhead := labelOverhead(m.config.Label)
+	if node.Supports(FeatureChecksum) {
+		overhead += 5
+	}
+	if m.config.EncryptionEnabled() && m.config.GossipVerifyOutgoing {
+		overhead += encryptOverhead(m.encryptionVersion())
+	}
+
+	// Pad it out with random bytes so it can't be compressed. The size of
+	// the padding's own header depends on its length, so the first try
+	// tells us how far off we are.
+	p.Pad = make([]byte, size-overhead)
+	buf, err := encode(pingMsg, &p, m.config.MsgpackUseNewTimeFormat)
+	if err != nil {
+		m.logger.Printf("[ERR] memberlist: Failed to encode MTU probe: %s", err)
+		return false
+	}
+	if excess := buf.Len() + overhead - size; excess > 0 && excess < len(p.Pad) {
+		p.Pad = p.Pad[:len(p.Pad)-excess]
+	}
+	if _, err := rand.Read(p.Pad); err != nil {
+		m.logger.Printf("[ERR] memberlist: Failed to pad MTU probe: %s", err)
+		return false
+	}
+	if buf, err = encode(pingMsg, &p, m.config.MsgpackUseNewTimeFormat); err != nil {
+		m.logger.Printf("[ERR] memberlist: Failed to encode MTU probe: %s", err)
+		return false
+	}
+
+	timeout := m.probeTimeout()
+	ackCh := make(chan ackMessage, 1)
+	m.setProbeChannels(p.SeqNo, ackCh, nil, timeout)
+	a := Address{Addr: node.Address(), Name: node.Name}
+	if err := m.rawSendMsgPacket(a, node, buf.Bytes()); err != nil {
+		// The platform may refuse to send a packet larger than the
+		// local interface's MTU outright.
+		return false
+	}
+
+	select {
+	case v := <-ackCh:
+		return v.Complete
+	case <-time.After(timeout):
+		return false
+	case <-m.shutdownCh:
+		return false
+	}
+}
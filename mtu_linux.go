@@ -0,0 +1,32 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build linux
+
+package memberlist
+
+import (
+	"net"
+	"syscall"
+)
+
+// setDontFragment sets the don't fragment bit on packets sent from a UDP
+// listener. In probe mode the kernel doesn't lower its own idea of the path
+// MTU from ICMP errors either, leaving that to our probes.
+func setDontFragment(c *net.UDPConn) error {
+	raw, err := c.SyscallConn()
+	if err != nil {
+		return err
+	}
+	level, opt, val := syscall.IPPROTO_IP, syscall.IP_MTU_DISCOVER, syscall.IP_PMTUDISC_PROBE
+	if addr, ok := c.LocalAddr().(*net.UDPAddr); ok && addr.IP.To4() == nil {
+		level, opt, val = syscall.IPPROTO_IPV6, syscall.IPV6_MTU_DISCOVER, syscall.IPV6_PMTUDISC_PROBE
+	}
+	var serr error
+	if err := raw.Control(func(fd uintptr) {
+		serr = syscall.SetsockoptInt(int(fd), level, opt, val)
+	}); err != nil {
+		return err
+	}
+	return serr
+}
@@ -0,0 +1,18 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build !linux
+
+package memberlist
+
+import (
+	"net"
+)
+
+// setDontFragment sets the don't fragment bit on packets sent from a UDP
+// listener. It isn't supported on this platform, so packets may still be
+// fragmented, and probes find the largest packet that gets through either
+// way.
+func setDontFragment(*net.UDPConn) error {
+	return nil
+}
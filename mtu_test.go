@@ -0,0 +1,69 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// mtuTransport drops packets larger than its limit, like a path with a
+// small MTU and the don't fragment bit set.
+type mtuTransport struct {
+	*NetTransport
+	limit int
+}
+
+func (t *mtuTransport) WriteToAddress(b []byte, addr Address) (time.Time, error) {
+	if len(b) > t.limit {
+		return time.Now(), nil
+	}
+	return t.NetTransport.WriteToAddress(b, addr)
+}
+
+func TestMemberlist_ProbeMTU(t *testing.T) {
+	c1 := testConfig(t)
+	nt, err := NewNetTransport(&NetTransportConfig{
+		BindAddrs: []string{c1.BindAddr},
+		Logger:    c1.Logger,
+	})
+	require.NoError(t, err)
+	c1.Transport = &mtuTransport{NetTransport: nt, limit: 900}
+	c1.BindPort = nt.GetAutoBindPort()
+	c1.AdvertisePort = c1.BindPort
+	m1, err := Create(c1)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m1.Shutdown())
+	}()
+
+	c2 := testConfig(t)
+	c2.BindPort = m1.config.BindPort
+	m2, err := Create(c2)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m2.Shutdown())
+	}()
+
+	_, err = m2.Join([]string{m1.config.Name + "/" + m1.config.BindAddr})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return m1.NumMembers() == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Zero(t, m1.mtuShortfall(m2.config.Name))
+
+	m1.probeMTU()
+	m1.mtuLock.Lock()
+	mtu := m1.pathMTU[m2.config.Name]
+	m1.mtuLock.Unlock()
+	require.LessOrEqual(t, mtu, 900)
+	require.Greater(t, mtu, 900-mtuStep)
+	require.Equal(t, m1.config.UDPBufferSize-mtu, m1.mtuShortfall(m2.config.Name))
+
+	// Nothing's lost the other way.
+	m2.probeMTU()
+	require.Zero(t, m2.mtuShortfall(m1.config.Name))
+}
@@ -0,0 +1,29 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"fmt"
+	"net"
+)
+
+// resolveMulticastGroup parses Config.MulticastGroup.
+func resolveMulticastGroup(group string) (*net.UDPAddr, error) {
+	addr, err := net.ResolveUDPAddr("udp", group)
+	if err != nil {
+		return nil, fmt.Errorf("invalid multicast group %q: %v", group, err)
+	}
+	if !addr.IP.IsMulticast() || addr.Port == 0 {
+		return nil, fmt.Errorf("invalid multicast group %q: must be a multicast address and port", group)
+	}
+	return addr, nil
+}
+
+// multicastAddress is where gossip to Config.MulticastGroup is sent.
+func (m *Memberlist) multicastAddress() (Address, bool) {
+	if m.config.MulticastGroup == "" {
+		return Address{}, false
+	}
+	return Address{Addr: m.config.MulticastGroup}, true
+}
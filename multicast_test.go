@@ -0,0 +1,111 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveMulticastGroup(t *testing.T) {
+	addr, err := resolveMulticastGroup("239.255.77.77:7947")
+	require.NoError(t, err)
+	require.Equal(t, 7947, addr.Port)
+
+	_, err = resolveMulticastGroup("[ff02::1]:7947")
+	require.NoError(t, err)
+
+	for _, bad := range []string{"", "239.255.77.77", "10.0.0.1:7947", "239.255.77.77:0", "nope:7947"} {
+		_, err := resolveMulticastGroup(bad)
+		require.Error(t, err, bad)
+	}
+}
+
+// recordingTransport keeps track of where packets are sent, and drops the
+// ones for the multicast group.
+type recordingTransport struct {
+	*NetTransport
+	group string
+
+	lock sync.Mutex
+	sent []string
+}
+
+func (t *recordingTransport) WriteToAddress(b []byte, addr Address) (time.Time, error) {
+	t.lock.Lock()
+	t.sent = append(t.sent, addr.Addr)
+	t.lock.Unlock()
+	if addr.Addr == t.group {
+		return time.Now(), nil
+	}
+	return t.NetTransport.WriteToAddress(b, addr)
+}
+
+func TestMemberlist_MulticastGossip(t *testing.T) {
+	const group = "239.255.77.77:7947"
+	c := testConfig(t)
+	nt, err := NewNetTransport(&NetTransportConfig{
+		BindAddrs: []string{c.BindAddr},
+		Logger:    c.Logger,
+	})
+	require.NoError(t, err)
+	rt := &recordingTransport{NetTransport: nt, group: group}
+	c.Transport = rt
+	c.BindPort = nt.GetAutoBindPort()
+	c.AdvertisePort = c.BindPort
+	c.MulticastGroup = group
+	c.GossipNodes = 3
+	m, err := Create(c)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	for i, name := range []string{"a", "b", "c"} {
+		a := alive{Node: name, Addr: []byte{127, 0, 0, 1}, Port: uint16(60001 + i), Incarnation: 1, Vsn: m.config.BuildVsnArray()}
+		m.aliveNode(&a, nil, false)
+	}
+	rt.lock.Lock()
+	rt.sent = nil
+	rt.lock.Unlock()
+
+	m.gossip()
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+	require.Len(t, rt.sent, 2)
+	require.Equal(t, group, rt.sent[0])
+	require.NotEqual(t, group, rt.sent[1])
+}
+
+func TestNetTransport_Multicast(t *testing.T) {
+	const group = "239.255.77.78:17948"
+	c := testConfig(t)
+	nt, err := NewNetTransport(&NetTransportConfig{
+		BindAddrs:      []string{c.BindAddr},
+		MulticastGroup: group,
+		Logger:         c.Logger,
+	})
+	if err != nil {
+		t.Skipf("can't join a multicast group here: %v", err)
+	}
+	defer nt.Shutdown()
+
+	conn, err := net.Dial("udp", group)
+	require.NoError(t, err)
+	defer conn.Close()
+	if _, err := conn.Write([]byte("hello")); err != nil {
+		t.Skipf("can't send to a multicast group here: %v", err)
+	}
+
+	select {
+	case p := <-nt.PacketCh():
+		require.Equal(t, []byte("hello"), p.Buf)
+	case <-time.After(time.Second):
+		t.Skip("multicast isn't looped back here")
+	}
+}
@@ -6,7 +6,9 @@ package memberlist
 import (
 	"bufio"
 	"bytes"
+	"container/list"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"hash/crc32"
 	"io"
@@ -36,9 +38,22 @@ const (
 	// nacks from another memberlist who understands version 4 or
 	// greater, and likewise nacks will be sent to memberlists who
 	// understand version 4 or greater.
+	//
+	// Version 6 added support for delta push/pull, which is only attempted
+	// with memberlists who understand version 6 or greater.
+	//
+	// Version 7 added the protocol buffer wire codec, which is only used
+	// with memberlists who understand version 7 or greater.
+	//
+	// Version 8 compares incarnation numbers with wrap-around arithmetic.
+	// A node's incarnation number only wraps once every live member
+	// understands version 8 or greater.
+	//
+	// Version 9 added gossip digests, which are only sent to memberlists
+	// who understand version 9 or greater.
 	ProtocolVersion2Compatible = 2
 
-	ProtocolVersionMax = 5
+	ProtocolVersionMax = 9
 )
 
 // messageType is an integer ID of a type of message that can be received
@@ -64,8 +79,55 @@ const (
 	nackRespMsg
 	hasCrcMsg
 	errMsg
+	relayMsg
+	pushPullDigestMsg
+	stateSummaryMsg
+	protoMsg
+	timedUserMsg
+	ackedUserMsg
+	broadcastAckMsg
+	gossipDigestMsg
+	keyringMsg
+	punchReqMsg
+	punchMsg
 )
 
+var messageTypeNames = map[messageType]string{
+	pingMsg:           "ping",
+	indirectPingMsg:   "indirect-ping",
+	ackRespMsg:        "ack",
+	suspectMsg:        "suspect",
+	aliveMsg:          "alive",
+	deadMsg:           "dead",
+	pushPullMsg:       "push-pull",
+	compoundMsg:       "compound",
+	userMsg:           "user",
+	compressMsg:       "compress",
+	encryptMsg:        "encrypt",
+	nackRespMsg:       "nack",
+	hasCrcMsg:         "crc",
+	errMsg:            "error",
+	relayMsg:          "relay",
+	pushPullDigestMsg: "push-pull-digest",
+	stateSummaryMsg:   "state-summary",
+	protoMsg:          "proto",
+	timedUserMsg:      "timed-user",
+	ackedUserMsg:      "acked-user",
+	broadcastAckMsg:   "broadcast-ack",
+	gossipDigestMsg:   "gossip-digest",
+	keyringMsg:        "keyring",
+	punchReqMsg:       "punch-req",
+	punchMsg:          "punch",
+	hasLabelMsg:       "label",
+}
+
+func (t messageType) String() string {
+	if name, ok := messageTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", uint8(t))
+}
+
 const (
 	// hasLabelMsg has a deliberately high value so that you can disambiguate
 	// it from the encryptionVersion header which is either 0/1 right now and
@@ -80,10 +142,13 @@ const (
 	lzwAlgo compressionType = iota
 )
 
+var errTooManyPushPulls = errors.New("too many pending push/pull requests")
+
 const (
 	MetaMaxSize            = 512 // Maximum size for node meta data
 	compoundHeaderOverhead = 2   // Assumed header overhead
 	compoundOverhead       = 2   // Assumed overhead per entry in compoundHeader
+	crcOverhead            = 5   // Type byte and CRC-32 of a checksummed packet
 	userMsgOverhead        = 1
 	blockingWarning        = 10 * time.Millisecond // Warn if a UDP packet takes this long to process
 	maxPushStateBytes      = 20 * 1024 * 1024
@@ -102,6 +167,8 @@ type ping struct {
 	SourceAddr []byte `codec:",omitempty"` // Source address, used for a direct reply
 	SourcePort uint16 `codec:",omitempty"` // Source port, used for a direct reply
 	SourceNode string `codec:",omitempty"` // Source name, used for a direct reply
+
+	Pad []byte `codec:",omitempty"` // Fills an MTU probe out to the size being tried
 }
 
 // indirect ping sent to an indirect node
@@ -126,6 +193,10 @@ type indirectPingReq struct {
 type ackResp struct {
 	SeqNo   uint32
 	Payload []byte
+
+	// Health is the responder's own health score, a hint that it may be
+	// too overloaded to answer probes in time.
+	Health int `codec:",omitempty"`
 }
 
 // nack response is sent for an indirect ping when the pinger doesn't hear from
@@ -144,7 +215,8 @@ type errResp struct {
 type suspect struct {
 	Incarnation uint32
 	Node        string
-	From        string // Include who is suspecting
+	From        string            // Include who is suspecting
+	Reason      StateChangeReason `codec:",omitempty"` // Why the node is suspected
 }
 
 // alive is broadcast when we know a node is alive.
@@ -159,6 +231,37 @@ type alive struct {
 	// The versions of the protocol/delegate that are being spoken, order:
 	// pmin, pmax, pcur, dmin, dmax, dcur
 	Vsn []uint8
+
+	// Addrs holds any additional addresses the node is reachable at, in
+	// host:port form.
+	Addrs []string `codec:",omitempty"`
+
+	// Role is the node's role in the cluster.
+	Role NodeRole `codec:",omitempty"`
+
+	// Zone is the zone or region the node is in.
+	Zone string `codec:",omitempty"`
+
+	// ID is the node's stable identity, if it has one.
+	ID string `codec:",omitempty"`
+
+	// StreamPort is the port the node accepts stream connections on, if
+	// it's different from Port.
+	StreamPort uint16 `codec:",omitempty"`
+
+	// Maintenance is how much longer the node is in maintenance for.
+	Maintenance time.Duration `codec:",omitempty"`
+
+	// Weight is the node's relative capacity.
+	Weight uint16 `codec:",omitempty"`
+
+	// Draining is set while the node is draining.
+	Draining bool `codec:",omitempty"`
+
+	// Complete is set by members that know every field above. Older members
+	// drop the fields they don't know, this one included, when they relay
+	// the message, so without it the fields from Addrs on can't be trusted.
+	Complete bool `codec:",omitempty"`
 }
 
 // dead is broadcast when we confirm a node is dead
@@ -166,7 +269,8 @@ type alive struct {
 type dead struct {
 	Incarnation uint32
 	Node        string
-	From        string // Include who is suspecting
+	From        string            // Include who is suspecting
+	Reason      StateChangeReason `codec:",omitempty"` // Why the node is dead
 }
 
 // pushPullHeader is used to inform the
@@ -175,6 +279,9 @@ type pushPullHeader struct {
 	Nodes        int
 	UserStateLen int  // Encodes the byte lengh of user state
 	Join         bool // Is this a join request or a anti-entropy run
+
+	From string `codec:",omitempty"` // Sender's name
+	To   string `codec:",omitempty"` // Name the sender expects the receiver to have
 }
 
 // userMsgHeader is used to encapsulate a userMsg
@@ -191,7 +298,52 @@ type pushNodeState struct {
 	Meta        []byte
 	Incarnation uint32
 	State       NodeStateType
-	Vsn         []uint8 // Protocol versions
+	Vsn         []uint8       // Protocol versions
+	Addrs       []string      `codec:",omitempty"` // Additional addresses
+	Role        NodeRole      `codec:",omitempty"` // Role in the cluster
+	Zone        string        `codec:",omitempty"` // Zone or region
+	ID          string        `codec:",omitempty"` // Stable node identity
+	StreamPort  uint16        `codec:",omitempty"` // Stream port, if not Port
+	Maintenance time.Duration `codec:",omitempty"` // Maintenance time left
+	Weight      uint16        `codec:",omitempty"` // Relative capacity
+	Draining    bool          `codec:",omitempty"` // Draining, see Drain
+	Complete    bool          `codec:",omitempty"` // Fields from Addrs on are set, see alive
+
+	TombstoneAge time.Duration     `codec:",omitempty"` // How long ago a reaped node was reaped
+	Reason       StateChangeReason `codec:",omitempty"` // Why the node isn't alive
+
+	Entries []NodeEntry `codec:",omitempty"` // Keyed state, see NodeEntriesDelegate
+}
+
+// ackedBroadcast is a user message gossiped with BroadcastWithAck
+type ackedBroadcast struct {
+	ID      uint32
+	Origin  string
+	Timeout time.Duration // How long the origin waits for acks
+	Payload []byte
+}
+
+// keyringChange is a change to the keyring gossiped by InstallKey, UseKey
+// or RemoveKey, and acknowledged like an ackedBroadcast
+type keyringChange struct {
+	ID      uint32
+	Origin  string
+	Timeout time.Duration // How long the origin waits for acks
+	Op      keyringOp
+	Key     []byte
+}
+
+// broadcastAck acknowledges an ackedBroadcast to its origin
+type broadcastAck struct {
+	ID   uint32
+	Node string
+}
+
+// relay is sent to another member, asking it to forward a user message to
+// the named node
+type relay struct {
+	Node    string
+	Payload []byte
 }
 
 // compress is used to wrap an underlying payload
@@ -232,8 +384,26 @@ func (m *Memberlist) streamListen() {
 	}
 }
 
+// streamWorker is a long running goroutine that handles incoming stream
+// connections one at a time. Config.StreamWorkers of these share the
+// transport's stream channel.
+func (m *Memberlist) streamWorker() {
+	for {
+		select {
+		case conn := <-m.transport.StreamCh():
+			m.handleConn(conn)
+
+		case <-m.shutdownCh:
+			return
+		}
+	}
+}
+
 // handleConn handles a single incoming stream connection from the transport.
 func (m *Memberlist) handleConn(conn net.Conn) {
+	m.beginStream()
+	defer m.endStream()
+
 	m.logger.Printf("[DEBUG] memberlist: Stream connection %s", LogConn(conn))
 
 	metrics.IncrCounterWithLabels([]string{"memberlist", "tcp", "accept"}, 1, m.metricLabels)
@@ -242,6 +412,12 @@ func (m *Memberlist) handleConn(conn net.Conn) {
 		m.logger.Printf("Err: Could not set the deadline: %s", err)
 	}
 
+	if err := m.authorizeStream(conn, ""); err != nil {
+		m.logger.Printf("[ERR] memberlist: Rejected stream: %s %s", err, LogConn(conn))
+		_ = conn.Close()
+		return
+	}
+
 	var (
 		streamLabel string
 		err         error
@@ -302,31 +478,13 @@ func (m *Memberlist) handleConn(conn net.Conn) {
 			m.logger.Printf("[ERR] memberlist: Failed to receive user message: %s %s", err, LogConn(conn))
 		}
 	case pushPullMsg:
-		// Increment counter of pending push/pulls
-		numConcurrent := atomic.AddUint32(&m.pushPullReq, 1)
-		defer atomic.AddUint32(&m.pushPullReq, ^uint32(0))
-
-		// Check if we have too many open push/pull requests
-		if numConcurrent >= maxPushPullRequests {
-			m.logger.Printf("[ERR] memberlist: Too many pending push/pull requests")
-			return
-		}
-
-		join, remoteNodes, userState, err := m.readRemoteState(bufConn, dec)
-		if err != nil {
-			m.logger.Printf("[ERR] memberlist: Failed to read remote state: %s %s", err, LogConn(conn))
-			return
-		}
-
-		if err := m.sendLocalState(conn, join, streamLabel); err != nil {
-			m.logger.Printf("[ERR] memberlist: Failed to push local state: %s %s", err, LogConn(conn))
-			return
-		}
-
-		if err := m.mergeRemoteState(join, remoteNodes, userState); err != nil {
-			m.logger.Printf("[ERR] memberlist: Failed push/pull merge: %s %s", err, LogConn(conn))
-			return
-		}
+		trace := PushPullTrace{Inbound: true, Addr: conn.RemoteAddr().String(), Start: time.Now()}
+		err := m.handlePushPull(conn, bufConn, dec, streamLabel, &trace)
+		m.tracePushPull(trace, err)
+	case pushPullDigestMsg:
+		trace := PushPullTrace{Inbound: true, Addr: conn.RemoteAddr().String(), Delta: true, Start: time.Now()}
+		err := m.handleDigestPushPull(conn, dec, streamLabel)
+		m.tracePushPull(trace, err)
 	case pingMsg:
 		var p ping
 		if err := dec.Decode(&p); err != nil {
@@ -339,7 +497,7 @@ func (m *Memberlist) handleConn(conn net.Conn) {
 			return
 		}
 
-		ack := ackResp{p.SeqNo, nil}
+		ack := ackResp{SeqNo: p.SeqNo, Health: m.awareness.GetHealthScore()}
 		out, err := encode(ackRespMsg, &ack, m.config.MsgpackUseNewTimeFormat)
 		if err != nil {
 			m.logger.Printf("[ERR] memberlist: Failed to encode ack: %s", err)
@@ -356,6 +514,71 @@ func (m *Memberlist) handleConn(conn net.Conn) {
 	}
 }
 
+// handlePushPull answers a full push/pull started by a peer.
+func (m *Memberlist) handlePushPull(conn net.Conn, bufConn io.Reader, dec *codec.Decoder, streamLabel string, trace *PushPullTrace) error {
+	// Increment counter of pending push/pulls
+	numConcurrent := atomic.AddUint32(&m.pushPullReq, 1)
+	defer atomic.AddUint32(&m.pushPullReq, ^uint32(0))
+
+	// Check if we have too many open push/pull requests
+	if numConcurrent >= maxPushPullRequests {
+		m.logger.Printf("[ERR] memberlist: Too many pending push/pull requests")
+		return errTooManyPushPulls
+	}
+
+	header, remoteNodes, userState, err := m.readRemoteState(bufConn, dec)
+	if err != nil {
+		m.logger.Printf("[ERR] memberlist: Failed to read remote state: %s %s", err, LogConn(conn))
+		return err
+	}
+	join := header.Join
+	trace.Join = join
+	trace.Node = header.From
+	m.debugMessage(true, conn.RemoteAddr().String(), pushPullMsg, remoteNodes)
+
+	if err := m.authorizePushPull(conn, header.From); err != nil {
+		m.logger.Printf("[ERR] memberlist: Refusing push/pull from %q: %s %s", header.From, err, LogConn(conn))
+		return err
+	}
+
+	// If it was meant for someone else, just tell them who we are.
+	if err := m.checkPeerName(header.To, m.config.Name); err != nil {
+		m.logger.Printf("[ERR] memberlist: Refusing push/pull: %s %s", err, LogConn(conn))
+		if serr := m.sendNodeStates(conn, join, streamLabel, header.From, map[string]struct{}{}); serr != nil {
+			m.logger.Printf("[ERR] memberlist: Failed to push local state: %s %s", serr, LogConn(conn))
+		}
+		return err
+	}
+
+	if err := m.sendLocalState(conn, join, streamLabel, header.From); err != nil {
+		m.logger.Printf("[ERR] memberlist: Failed to push local state: %s %s", err, LogConn(conn))
+		return err
+	}
+
+	if err := m.mergeRemoteState(join, remoteNodes, userState); err != nil {
+		m.logger.Printf("[ERR] memberlist: Failed push/pull merge: %s %s", err, LogConn(conn))
+		return err
+	}
+	return nil
+}
+
+// handleDigestPushPull answers a delta push/pull started by a peer.
+func (m *Memberlist) handleDigestPushPull(conn net.Conn, dec *codec.Decoder, streamLabel string) error {
+	numConcurrent := atomic.AddUint32(&m.pushPullReq, 1)
+	defer atomic.AddUint32(&m.pushPullReq, ^uint32(0))
+
+	if numConcurrent >= maxPushPullRequests {
+		m.logger.Printf("[ERR] memberlist: Too many pending push/pull requests")
+		return errTooManyPushPulls
+	}
+
+	if err := m.handleDeltaPushPull(conn, dec, streamLabel); err != nil {
+		m.logger.Printf("[ERR] memberlist: Failed delta push/pull: %s %s", err, LogConn(conn))
+		return err
+	}
+	return nil
+}
+
 // packetListen is a long running goroutine that pulls packets out of the
 // transport and hands them off for processing.
 func (m *Memberlist) packetListen() {
@@ -374,6 +597,7 @@ func (m *Memberlist) ingestPacket(buf []byte, from net.Addr, timestamp time.Time
 	var (
 		packetLabel string
 		err         error
+		size        = len(buf)
 	)
 	buf, packetLabel, err = RemoveLabelHeaderFromPacket(buf)
 	if err != nil {
@@ -422,10 +646,13 @@ func (m *Memberlist) ingestPacket(buf []byte, from net.Addr, timestamp time.Time
 			m.logger.Printf("[WARN] memberlist: Got invalid checksum for UDP packet: %x, %x", crc, expected)
 			return
 		}
-		m.handleCommand(buf[5:], from, timestamp)
-	} else {
-		m.handleCommand(buf, from, timestamp)
+		buf = buf[5:]
+	}
+
+	if len(buf) > 0 {
+		m.tracePacket(true, from.String(), "", messageType(buf[0]), size, nil)
 	}
+	m.handleCommand(buf, from, timestamp)
 }
 
 func (m *Memberlist) handleCommand(buf []byte, from net.Addr, timestamp time.Time) {
@@ -435,14 +662,27 @@ func (m *Memberlist) handleCommand(buf []byte, from net.Addr, timestamp time.Tim
 	}
 	// Decode the message type
 	msgType := messageType(buf[0])
+	switch msgType {
+	case compoundMsg, compressMsg, protoMsg:
+		// These are recorded once they're unpacked
+	default:
+		m.debugPacket(true, from.String(), buf)
+		m.countMessage(false, false, msgType, len(buf))
+	}
 	buf = buf[1:]
 
+	if !m.sourceAllowed(msgType, from) {
+		return
+	}
+
 	// Switch on the msgType
 	switch msgType {
 	case compoundMsg:
 		m.handleCompound(buf, from, timestamp)
 	case compressMsg:
 		m.handleCompressed(buf, from, timestamp)
+	case protoMsg:
+		m.handleProto(buf, from, timestamp)
 
 	case pingMsg:
 		m.handlePing(buf, from)
@@ -452,86 +692,204 @@ func (m *Memberlist) handleCommand(buf []byte, from net.Addr, timestamp time.Tim
 		m.handleAck(buf, from, timestamp)
 	case nackRespMsg:
 		m.handleNack(buf, from)
+	case relayMsg:
+		m.handleRelay(buf, from)
+	case broadcastAckMsg:
+		m.handleBroadcastAck(buf, from)
+	case stateSummaryMsg:
+		m.handleStateSummary(buf, from)
+	case gossipDigestMsg:
+		m.handleGossipDigest(buf, from)
+	case keyringMsg:
+		m.handleKeyring(buf, from)
+	case punchReqMsg:
+		m.handlePunchReq(buf, from)
+	case punchMsg:
+		m.handlePunch(buf, from)
+
+	case suspectMsg, aliveMsg, deadMsg, userMsg, timedUserMsg, ackedUserMsg:
+		m.handoffMessage(msgType, buf, from)
 
-	case suspectMsg:
-		fallthrough
-	case aliveMsg:
-		fallthrough
-	case deadMsg:
-		fallthrough
-	case userMsg:
-		// Determine the message queue, prioritize alive
-		queue := m.lowPriorityMsgQueue
-		if msgType == aliveMsg {
-			queue = m.highPriorityMsgQueue
-		}
+	default:
+		m.logger.Printf("[ERR] memberlist: msg type (%d) not supported %s", msgType, LogAddress(from))
+	}
+}
 
-		// Check for overflow and append if not full
-		m.msgQueueLock.Lock()
-		if queue.Len() >= m.config.HandoffQueueDepth {
+// isHighPriority returns true for the message types that are queued ahead of
+// user messages in the handoff queue.
+func isHighPriority(msgType messageType) bool {
+	switch msgType {
+	case aliveMsg, suspectMsg, deadMsg:
+		return true
+	default:
+		return false
+	}
+}
+
+// handoffMessage queues a message for processing by the packet handlers. If
+// the relevant queue is full then Config.HandoffDropPolicy decides what
+// happens.
+func (m *Memberlist) handoffMessage(msgType messageType, buf []byte, from net.Addr) {
+	// Determine the message queue, prioritize membership messages
+	queue, dropped, priority := m.lowPriorityMsgQueue, &m.lowPriorityDropped, "low"
+	if isHighPriority(msgType) {
+		queue, dropped, priority = m.highPriorityMsgQueue, &m.highPriorityDropped, "high"
+	}
+	policy := m.config.HandoffDropPolicy
+
+	m.msgQueueLock.Lock()
+	if policy == Block && !m.waitHandoffSpace(queue) {
+		m.msgQueueLock.Unlock()
+		return
+	}
+
+	// Check for overflow and apply the policy if full
+	if queue.Len() >= m.config.HandoffQueueDepth {
+		switch policy {
+		case DropOldest:
+			if el := queue.Front(); el != nil {
+				queue.Remove(el)
+			}
+		default:
+			m.msgQueueLock.Unlock()
+			m.recordHandoffDrop(dropped, priority, policy)
 			m.logger.Printf("[WARN] memberlist: handler queue full, dropping message (%d) %s", msgType, LogAddress(from))
-		} else {
-			queue.PushBack(msgHandoff{msgType, buf, from})
+			return
 		}
+		queue.PushBack(msgHandoff{msgType, buf, from})
+		m.msgQueueLock.Unlock()
+		m.recordHandoffDrop(dropped, priority, policy)
+		m.logger.Printf("[WARN] memberlist: handler queue full, dropping oldest message to make room for (%d) %s", msgType, LogAddress(from))
+	} else {
+		queue.PushBack(msgHandoff{msgType, buf, from})
 		m.msgQueueLock.Unlock()
+	}
+
+	m.notifyHandoff()
+}
+
+// waitHandoffSpace blocks until the given queue has room or memberlist shuts
+// down, in which case it returns false. You must hold msgQueueLock, which is
+// released while waiting and held again on return.
+func (m *Memberlist) waitHandoffSpace(queue *list.List) bool {
+	if queue.Len() < m.config.HandoffQueueDepth {
+		return true
+	}
+	defer metrics.MeasureSinceWithLabels([]string{"memberlist", "queue", "blocked"}, time.Now(), m.metricLabels)
 
-		// Notify of pending message
+	for queue.Len() >= m.config.HandoffQueueDepth {
+		m.msgQueueLock.Unlock()
 		select {
-		case m.handoffCh <- struct{}{}:
-		default:
+		case <-m.handoffSpaceCh:
+		case <-m.shutdownCh:
+			m.msgQueueLock.Lock()
+			return false
 		}
+		m.msgQueueLock.Lock()
+	}
+	return true
+}
+
+// recordHandoffDrop accounts for a message lost to a full handoff queue.
+func (m *Memberlist) recordHandoffDrop(dropped *uint64, priority string, policy HandoffDropPolicy) {
+	atomic.AddUint64(dropped, 1)
+	metrics.IncrCounterWithLabels([]string{"memberlist", "queue", "dropped"}, 1,
+		append(m.metricLabels,
+			metrics.Label{Name: "priority", Value: priority},
+			metrics.Label{Name: "policy", Value: policy.String()}))
+}
 
+// notifyHandoff wakes up a packet handler, if one isn't already pending.
+func (m *Memberlist) notifyHandoff() {
+	select {
+	case m.handoffCh <- struct{}{}:
 	default:
-		m.logger.Printf("[ERR] memberlist: msg type (%d) not supported %s", msgType, LogAddress(from))
 	}
 }
 
-// getNextMessage returns the next message to process in priority order, using LIFO
-func (m *Memberlist) getNextMessage() (msgHandoff, bool) {
+// getNextMessage returns the next message to process in priority order, using
+// LIFO, along with whether there are more messages left to process.
+func (m *Memberlist) getNextMessage() (msgHandoff, bool, bool) {
 	m.msgQueueLock.Lock()
 	defer m.msgQueueLock.Unlock()
 
+	var msg msgHandoff
 	if el := m.highPriorityMsgQueue.Back(); el != nil {
 		m.highPriorityMsgQueue.Remove(el)
-		msg := el.Value.(msgHandoff)
-		return msg, true
+		msg = el.Value.(msgHandoff)
 	} else if el := m.lowPriorityMsgQueue.Back(); el != nil {
 		m.lowPriorityMsgQueue.Remove(el)
-		msg := el.Value.(msgHandoff)
-		return msg, true
+		msg = el.Value.(msgHandoff)
+	} else {
+		return msgHandoff{}, false, false
+	}
+	more := m.highPriorityMsgQueue.Len()+m.lowPriorityMsgQueue.Len() > 0
+
+	// Wake up a listener that may be blocked waiting for space.
+	select {
+	case m.handoffSpaceCh <- struct{}{}:
+	default:
+	}
+	return msg, true, more
+}
+
+// HandoffStats describes the state of the queue of incoming messages waiting
+// to be processed.
+type HandoffStats struct {
+	// HighPriorityQueued and LowPriorityQueued are the number of messages
+	// currently waiting in each queue. Membership messages are high priority
+	// and user messages are low priority.
+	HighPriorityQueued int
+	LowPriorityQueued  int
+
+	// HighPriorityDropped and LowPriorityDropped are the total number of
+	// messages dropped because the queue was full.
+	HighPriorityDropped uint64
+	LowPriorityDropped  uint64
+}
+
+// HandoffStats returns a snapshot of the incoming message queue.
+func (m *Memberlist) HandoffStats() HandoffStats {
+	m.msgQueueLock.Lock()
+	high, low := m.highPriorityMsgQueue.Len(), m.lowPriorityMsgQueue.Len()
+	m.msgQueueLock.Unlock()
+
+	return HandoffStats{
+		HighPriorityQueued:  high,
+		LowPriorityQueued:   low,
+		HighPriorityDropped: atomic.LoadUint64(&m.highPriorityDropped),
+		LowPriorityDropped:  atomic.LoadUint64(&m.lowPriorityDropped),
 	}
-	return msgHandoff{}, false
 }
 
 // packetHandler is a long running goroutine that processes messages received
 // over the packet interface, but is decoupled from the listener to avoid
 // blocking the listener which may cause ping/ack messages to be delayed.
+// Several of these may run concurrently, see Config.HandoffWorkers.
 func (m *Memberlist) packetHandler() {
 	for {
 		select {
 		case <-m.handoffCh:
-			for {
-				msg, ok := m.getNextMessage()
+			m.beginEvents()
+			for n := 1; ; n++ {
+				msg, ok, more := m.getNextMessage()
 				if !ok {
 					break
 				}
-				msgType := msg.msgType
-				buf := msg.buf
-				from := msg.from
-
-				switch msgType {
-				case suspectMsg:
-					m.handleSuspect(buf, from)
-				case aliveMsg:
-					m.handleAlive(buf, from)
-				case deadMsg:
-					m.handleDead(buf, from)
-				case userMsg:
-					m.handleUser(buf, from)
-				default:
-					m.logger.Printf("[ERR] memberlist: Message type (%d) not supported %s (packet handler)", msgType, LogAddress(from))
+
+				// Let another worker share the load while we are busy
+				// with this message.
+				if more {
+					m.notifyHandoff()
+				}
+
+				m.handleHandoff(msg)
+				if n%handoffEventRound == 0 {
+					m.endEvents()
+					m.beginEvents()
 				}
 			}
+			m.endEvents()
 
 		case <-m.shutdownCh:
 			return
@@ -539,6 +897,26 @@ func (m *Memberlist) packetHandler() {
 	}
 }
 
+// handleHandoff dispatches a single message taken from the handoff queue.
+func (m *Memberlist) handleHandoff(msg msgHandoff) {
+	switch msg.msgType {
+	case suspectMsg:
+		m.handleSuspect(msg.buf, msg.from)
+	case aliveMsg:
+		m.handleAlive(msg.buf, msg.from)
+	case deadMsg:
+		m.handleDead(msg.buf, msg.from)
+	case userMsg:
+		m.handleUser(msg.buf, msg.from)
+	case timedUserMsg:
+		m.handleTimedUser(msg.buf, msg.from)
+	case ackedUserMsg:
+		m.handleAckedUser(msg.buf, msg.from)
+	default:
+		m.logger.Printf("[ERR] memberlist: Message type (%d) not supported %s (packet handler)", msg.msgType, LogAddress(msg.from))
+	}
+}
+
 func (m *Memberlist) handleCompound(buf []byte, from net.Addr, timestamp time.Time) {
 	// Decode the parts
 	trunc, parts, err := decodeCompoundMessage(buf)
@@ -560,7 +938,7 @@ func (m *Memberlist) handleCompound(buf []byte, from net.Addr, timestamp time.Ti
 
 func (m *Memberlist) handlePing(buf []byte, from net.Addr) {
 	var p ping
-	if err := decode(buf, &p); err != nil {
+	if err := decodeMessage(buf, &p); err != nil {
 		m.logger.Printf("[ERR] memberlist: Failed to decode ping request: %s %s", err, LogAddress(from))
 		return
 	}
@@ -569,10 +947,14 @@ func (m *Memberlist) handlePing(buf []byte, from net.Addr) {
 		m.logger.Printf("[WARN] memberlist: Got ping for unexpected node '%s' %s", p.Node, LogAddress(from))
 		return
 	}
+	if p.SourceNode != "" {
+		m.observerHeard(p.SourceNode)
+	}
 	var ack ackResp
 	ack.SeqNo = p.SeqNo
+	ack.Health = m.awareness.GetHealthScore()
 	if m.config.Ping != nil {
-		ack.Payload = m.config.Ping.AckPayload()
+		m.guard("AckPayload", func() { ack.Payload = m.config.Ping.AckPayload() })
 	}
 
 	addr := ""
@@ -628,11 +1010,11 @@ func (m *Memberlist) handleIndirectPing(buf []byte, from net.Addr) {
 
 	// Setup a response handler to relay the ack
 	cancelCh := make(chan struct{})
-	respHandler := func(payload []byte, timestamp time.Time) {
+	respHandler := func(resp ackResp, timestamp time.Time) {
 		// Try to prevent the nack if we've caught it in time.
 		close(cancelCh)
 
-		ack := ackResp{ind.SeqNo, nil}
+		ack := ackResp{SeqNo: ind.SeqNo, Health: resp.Health}
 		a := Address{
 			Addr: indAddr,
 			Name: ind.SourceNode,
@@ -641,7 +1023,7 @@ func (m *Memberlist) handleIndirectPing(buf []byte, from net.Addr) {
 			m.logger.Printf("[ERR] memberlist: Failed to forward ack: %s %s", err, LogStringAddress(indAddr))
 		}
 	}
-	m.setAckHandler(localSeqNo, respHandler, m.config.ProbeTimeout)
+	m.setAckRespHandler(localSeqNo, respHandler, m.probeTimeout())
 
 	// Send the ping.
 	addr := joinHostPort(net.IP(ind.Target).String(), ind.Port)
@@ -653,29 +1035,31 @@ func (m *Memberlist) handleIndirectPing(buf []byte, from net.Addr) {
 		m.logger.Printf("[ERR] memberlist: Failed to send indirect ping: %s %s", err, LogStringAddress(indAddr))
 	}
 
-	// Setup a timer to fire off a nack if no ack is seen in time.
+	// Setup a timer to fire off a nack if no ack is seen in time. This goes
+	// on the timer wheel, so a flood of indirect pings doesn't leave a
+	// goroutine waiting for each one.
 	if ind.Nack {
-		go func() {
+		m.timers.AfterFunc(m.probeTimeout(), func() {
 			select {
 			case <-cancelCh:
 				return
-			case <-time.After(m.config.ProbeTimeout):
-				nack := nackResp{ind.SeqNo}
-				a := Address{
-					Addr: indAddr,
-					Name: ind.SourceNode,
-				}
-				if err := m.encodeAndSendMsg(a, nackRespMsg, &nack); err != nil {
-					m.logger.Printf("[ERR] memberlist: Failed to send nack: %s %s", err, LogStringAddress(indAddr))
-				}
+			default:
+			}
+			nack := nackResp{ind.SeqNo}
+			a := Address{
+				Addr: indAddr,
+				Name: ind.SourceNode,
+			}
+			if err := m.encodeAndSendMsg(a, nackRespMsg, &nack); err != nil {
+				m.logger.Printf("[ERR] memberlist: Failed to send nack: %s %s", err, LogStringAddress(indAddr))
 			}
-		}()
+		})
 	}
 }
 
 func (m *Memberlist) handleAck(buf []byte, from net.Addr, timestamp time.Time) {
 	var ack ackResp
-	if err := decode(buf, &ack); err != nil {
+	if err := decodeMessage(buf, &ack); err != nil {
 		m.logger.Printf("[ERR] memberlist: Failed to decode ack response: %s %s", err, LogAddress(from))
 		return
 	}
@@ -697,6 +1081,9 @@ func (m *Memberlist) handleSuspect(buf []byte, from net.Addr) {
 		m.logger.Printf("[ERR] memberlist: Failed to decode suspect message: %s %s", err, LogAddress(from))
 		return
 	}
+	if !m.knownNode(sus.Node) && m.holdUnknown(sus.Node, from, func() { m.suspectNode(&sus) }) {
+		return
+	}
 	m.suspectNode(&sus)
 }
 
@@ -728,7 +1115,7 @@ func (m *Memberlist) handleAlive(buf []byte, from net.Addr) {
 		return
 	}
 	var live alive
-	if err := decode(buf, &live); err != nil {
+	if err := decodeMessage(buf, &live); err != nil {
 		m.logger.Printf("[ERR] memberlist: Failed to decode alive message: %s %s", err, LogAddress(from))
 		return
 	}
@@ -757,14 +1144,55 @@ func (m *Memberlist) handleDead(buf []byte, from net.Addr) {
 		m.logger.Printf("[ERR] memberlist: Failed to decode dead message: %s %s", err, LogAddress(from))
 		return
 	}
+	if !m.knownNode(d.Node) && m.holdUnknown(d.Node, from, func() { m.deadNode(&d) }) {
+		return
+	}
 	m.deadNode(&d)
 }
 
 // handleUser is used to notify channels of incoming user data
 func (m *Memberlist) handleUser(buf []byte, from net.Addr) {
-	d := m.config.Delegate
-	if d != nil {
-		d.NotifyMsg(buf)
+	m.notifyMsg(buf)
+}
+
+// handleRelay forwards a user message on behalf of another member. We only
+// forward to members we know about, so we can't be used to reflect traffic
+// at arbitrary addresses.
+func (m *Memberlist) handleRelay(buf []byte, from net.Addr) {
+	var r relay
+	if err := decode(buf, &r); err != nil {
+		m.logger.Printf("[ERR] memberlist: Failed to decode relay request: %s %s", err, LogAddress(from))
+		return
+	}
+
+	// Make sure it's really a user message or broadcast ack we're forwarding,
+	// or anything but another relay if we relay for hole punching.
+	if len(r.Payload) < 1 || !m.relayable(messageType(r.Payload[0])) {
+		m.logger.Printf("[WARN] memberlist: Refusing to relay non-user message to %s %s", r.Node, LogAddress(from))
+		return
+	}
+
+	// Deliver it ourselves if it's meant for us.
+	if r.Node == m.config.Name {
+		m.handleCommand(r.Payload, from, time.Now())
+		return
+	}
+
+	m.nodeLock.RLock()
+	state, ok := m.nodeMap[r.Node]
+	var node Node
+	if ok {
+		node = state.Node
+	}
+	m.nodeLock.RUnlock()
+	if !ok || state.DeadOrLeft() {
+		m.logger.Printf("[WARN] memberlist: Can't relay message to unknown or dead node %s %s", r.Node, LogAddress(from))
+		return
+	}
+
+	a := Address{Addr: node.Address(), Name: node.Name}
+	if err := m.rawSendMsgPacket(a, &node, r.Payload); err != nil {
+		m.logger.Printf("[ERR] memberlist: Failed to relay message to %s: %s", r.Node, err)
 	}
 }
 
@@ -783,10 +1211,20 @@ func (m *Memberlist) handleCompressed(buf []byte, from net.Addr, timestamp time.
 
 // encodeAndSendMsg is used to combine the encoding and sending steps
 func (m *Memberlist) encodeAndSendMsg(a Address, msgType messageType, msg interface{}) error {
-	out, err := encode(msgType, msg, m.config.MsgpackUseNewTimeFormat)
+	out, err := m.codecFor(a).encode(msgType, msg)
 	if err != nil {
 		return err
 	}
+	if msgType == ackRespMsg {
+		if delay := m.chaos.delayAck(); delay > 0 {
+			time.AfterFunc(delay, func() {
+				if err := m.sendMsg(a, out.Bytes()); err != nil {
+					m.logger.Printf("[ERR] memberlist: Failed to send delayed ack: %s %s", err, LogStringAddress(a.Addr))
+				}
+			})
+			return nil
+		}
+	}
 	if err := m.sendMsg(a, out.Bytes()); err != nil {
 		return err
 	}
@@ -797,11 +1235,12 @@ func (m *Memberlist) encodeAndSendMsg(a Address, msgType messageType, msg interf
 // opportunistically create a compoundMsg and piggy back other broadcasts.
 func (m *Memberlist) sendMsg(a Address, msg []byte) error {
 	// Check if we can piggy back any messages
-	bytesAvail := m.config.UDPBufferSize - len(msg) - compoundHeaderOverhead - labelOverhead(m.config.Label)
-	if m.config.EncryptionEnabled() && m.config.GossipVerifyOutgoing {
-		bytesAvail -= encryptOverhead(m.encryptionVersion())
+	bytesAvail := m.config.UDPBufferSize - len(msg) - compoundHeaderOverhead - m.packetOverhead()
+	bytesAvail -= m.mtuShortfall(a.Name)
+	var extra [][]byte
+	if !m.chaos.gossipMuted() {
+		extra = m.getBroadcasts(compoundOverhead, bytesAvail)
 	}
-	extra := m.getBroadcasts(compoundOverhead, bytesAvail)
 
 	// Fast path if nothing to piggypack
 	if len(extra) == 0 {
@@ -823,9 +1262,21 @@ func (m *Memberlist) sendMsg(a Address, msg []byte) error {
 // rawSendMsgPacket is used to send message via packet to another host without
 // modification, other than compression or encryption if enabled.
 func (m *Memberlist) rawSendMsgPacket(a Address, node *Node, msg []byte) error {
-	if a.Name == "" && m.config.RequireNodeNames {
+	if a.Name == "" && m.config.RequireNodeNames && a.Addr != m.config.MulticastGroup {
 		return errNodeNamesAreRequired
 	}
+	if rendezvous, ok := m.punchRelay(a.Name); ok {
+		return m.relayPacket(rendezvous, a.Name, msg)
+	}
+	if m.chaos.dropPacket() {
+		return nil
+	}
+	var msgType messageType
+	if len(msg) > 0 {
+		msgType = messageType(msg[0])
+	}
+	m.debugPacket(false, a.String(), msg)
+	m.countSentPacket(msg)
 
 	// Check if we have compression enabled
 	if m.config.EnableCompression {
@@ -858,7 +1309,7 @@ func (m *Memberlist) rawSendMsgPacket(a Address, node *Node, msg []byte) error {
 
 	// Add a CRC to the end of the payload if the recipient understands
 	// ProtocolVersion >= 5
-	if node != nil && node.PMax >= 5 {
+	if node != nil && node.Supports(FeatureChecksum) {
 		crc := crc32.ChecksumIEEE(msg)
 		header := make([]byte, 5, 5+len(msg))
 		header[0] = byte(hasCrcMsg)
@@ -884,12 +1335,18 @@ func (m *Memberlist) rawSendMsgPacket(a Address, node *Node, msg []byte) error {
 
 	metrics.IncrCounterWithLabels([]string{"memberlist", "udp", "sent"}, float32(len(msg)), m.metricLabels)
 	_, err := m.transport.WriteToAddress(msg, a)
+	m.sendResult(a.Name, err)
+	m.tracePacket(false, a.Addr, a.Name, msgType, len(msg), err)
 	return err
 }
 
 // rawSendMsgStream is used to stream a message to another host without
 // modification, other than applying compression and encryption if enabled.
 func (m *Memberlist) rawSendMsgStream(conn net.Conn, sendBuf []byte, streamLabel string) error {
+	if len(sendBuf) > 0 {
+		m.countMessage(true, true, messageType(sendBuf[0]), len(sendBuf))
+	}
+
 	// Check if compression is enabled
 	if m.config.EnableCompression {
 		compBuf, err := compressPayload(sendBuf, m.config.MsgpackUseNewTimeFormat)
@@ -928,7 +1385,7 @@ func (m *Memberlist) sendUserMsg(a Address, sendBuf []byte) error {
 		return errNodeNamesAreRequired
 	}
 
-	conn, err := m.transport.DialAddressTimeout(a, m.config.TCPTimeout)
+	conn, err := m.dialNode(a, m.config.TCPTimeout)
 	if err != nil {
 		return err
 	}
@@ -964,18 +1421,23 @@ func (m *Memberlist) sendAndReceiveState(a Address, join bool) ([]pushNodeState,
 	}
 
 	// Attempt to connect
-	conn, err := m.transport.DialAddressTimeout(a, m.config.TCPTimeout)
+	conn, err := m.dialNode(a, m.config.TCPTimeout)
 	if err != nil {
 		return nil, nil, err
 	}
 	defer func() {
 		_ = conn.Close()
 	}()
+	return m.exchangeState(conn, a, join)
+}
+
+// exchangeState does a push/pull with a remote host over an open stream.
+func (m *Memberlist) exchangeState(conn net.Conn, a Address, join bool) ([]pushNodeState, []byte, error) {
 	m.logger.Printf("[DEBUG] memberlist: Initiating push/pull sync with: %s %s", a.Name, conn.RemoteAddr())
 	metrics.IncrCounterWithLabels([]string{"memberlist", "tcp", "connect"}, 1, m.metricLabels)
 
 	// Send our state
-	if err := m.sendLocalState(conn, join, m.config.Label); err != nil {
+	if err := m.sendLocalState(conn, join, m.config.Label, a.Name); err != nil {
 		return nil, nil, err
 	}
 
@@ -1002,41 +1464,26 @@ func (m *Memberlist) sendAndReceiveState(a Address, join bool) ([]pushNodeState,
 	}
 
 	// Read remote state
-	_, remoteNodes, userState, err := m.readRemoteState(bufConn, dec)
-	return remoteNodes, userState, err
-}
-
-// sendLocalState is invoked to send our local state over a stream connection.
-func (m *Memberlist) sendLocalState(conn net.Conn, join bool, streamLabel string) error {
-	// Setup a deadline
-	if err := conn.SetDeadline(time.Now().Add(m.config.TCPTimeout)); err != nil {
-		m.logger.Printf("Err: Could not set the deadline: %s", err)
+	header, remoteNodes, userState, err := m.readRemoteState(bufConn, dec)
+	if err != nil {
+		return nil, nil, err
 	}
-
-	// Prepare the local node state
-	m.nodeLock.RLock()
-	localNodes := make([]pushNodeState, len(m.nodes))
-	for idx, n := range m.nodes {
-		localNodes[idx].Name = n.Name
-		localNodes[idx].Addr = n.Addr
-		localNodes[idx].Port = n.Port
-		localNodes[idx].Incarnation = n.Incarnation
-		localNodes[idx].State = n.State
-		localNodes[idx].Meta = n.Meta
-		localNodes[idx].Vsn = []uint8{
-			n.PMin, n.PMax, n.PCur,
-			n.DMin, n.DMax, n.DCur,
-		}
+	m.debugMessage(true, a.String(), pushPullMsg, remoteNodes)
+	if err := m.checkPeerName(a.Name, header.From); err != nil {
+		return nil, nil, err
 	}
-	m.nodeLock.RUnlock()
+	return remoteNodes, userState, nil
+}
 
+// reportNodeStateCounts sets the gauges for the number of nodes in each state.
+func (m *Memberlist) reportNodeStateCounts(nodes []pushNodeState) {
 	nodeStateCounts := make(map[string]int)
 	nodeStateCounts[StateAlive.metricsString()] = 0
 	nodeStateCounts[StateLeft.metricsString()] = 0
 	nodeStateCounts[StateDead.metricsString()] = 0
 	nodeStateCounts[StateSuspect.metricsString()] = 0
 
-	for _, n := range localNodes {
+	for _, n := range nodes {
 		nodeStateCounts[n.State.metricsString()]++
 	}
 
@@ -1045,18 +1492,93 @@ func (m *Memberlist) sendLocalState(conn net.Conn, join bool, streamLabel string
 			float32(cnt),
 			append(m.metricLabels, metrics.Label{Name: "node_state", Value: nodeState}))
 	}
+}
+
+// sendLocalState is invoked to send our local state over a stream connection
+// to the named node, if we know its name.
+func (m *Memberlist) sendLocalState(conn net.Conn, join bool, streamLabel, to string) error {
+	return m.sendNodeStates(conn, join, streamLabel, to, nil)
+}
+
+// pushNodeStateOf converts a node to the form used for push/pull.
+func pushNodeStateOf(n *nodeState) pushNodeState {
+	return pushNodeState{
+		Name:        n.Name,
+		Addr:        n.Addr,
+		Port:        n.Port,
+		Incarnation: n.Incarnation,
+		State:       n.State,
+		Meta:        n.Meta,
+		Addrs:       n.Addrs,
+		Role:        n.Role,
+		Zone:        n.Zone,
+		ID:          n.ID,
+		StreamPort:  n.StreamPort,
+		Maintenance: n.maintenanceLeft(),
+		Weight:      n.Weight,
+		Draining:    n.Draining,
+		Complete:    true,
+		Reason:      n.Reason,
+		Vsn: []uint8{
+			n.PMin, n.PMax, n.PCur,
+			n.DMin, n.DMax, n.DCur,
+		},
+		Entries: n.entryList(),
+	}
+}
+
+// sendNodeStates sends our state over a stream connection as a push/pull
+// message. If only is non-nil, just the named nodes are included.
+func (m *Memberlist) sendNodeStates(conn net.Conn, join bool, streamLabel, to string, only map[string]struct{}) error {
+	// Setup a deadline
+	if err := conn.SetDeadline(time.Now().Add(m.config.TCPTimeout)); err != nil {
+		m.logger.Printf("Err: Could not set the deadline: %s", err)
+	}
+
+	// Prepare the local node state
+	m.refreshLocalEntries()
+	m.nodeLock.RLock()
+	localNodes := make([]pushNodeState, 0, len(m.nodes))
+	for _, n := range m.nodes {
+		if only != nil {
+			if _, ok := only[n.Name]; !ok {
+				continue
+			}
+		}
+		localNodes = append(localNodes, pushNodeStateOf(n))
+	}
+	var buried []pushNodeState
+	if only == nil {
+		buried = m.tombstoneStates()
+	}
+	m.nodeLock.RUnlock()
+
+	// A partial list would skew the per-state gauges.
+	if only == nil {
+		m.reportNodeStateCounts(localNodes)
+	}
+
+	// Send our tombstones along as dead and left nodes, so a node that
+	// joined after they were reaped doesn't take them for new members.
+	localNodes = append(localNodes, buried...)
 
 	// Get the delegate state
 	var userData []byte
-	if m.config.Delegate != nil {
-		userData = m.config.Delegate.LocalState(join)
+	if d := m.delegate(); d != nil {
+		m.guard("LocalState", func() { userData = d.LocalState(join) })
 	}
 
 	// Create a bytes buffer writer
 	bufConn := bytes.NewBuffer(nil)
 
 	// Send our node state
-	header := pushPullHeader{Nodes: len(localNodes), UserStateLen: len(userData), Join: join}
+	header := pushPullHeader{
+		Nodes:        len(localNodes),
+		UserStateLen: len(userData),
+		Join:         join,
+		From:         m.config.Name,
+		To:           to,
+	}
 	hd := codec.MsgpackHandle{}
 	enc := codec.NewEncoder(bufConn, &hd)
 
@@ -1081,6 +1603,7 @@ func (m *Memberlist) sendLocalState(conn net.Conn, join bool, streamLabel string
 		}
 	}
 
+	m.debugMessage(false, conn.RemoteAddr().String(), pushPullMsg, localNodes)
 	moreBytes := binary.BigEndian.Uint32(bufConn.Bytes()[1:5])
 	metrics.SetGaugeWithLabels([]string{"memberlist", "size", "local"}, float32(moreBytes), m.metricLabels)
 
@@ -1223,15 +1746,16 @@ func (m *Memberlist) readStream(conn net.Conn, streamLabel string) (messageType,
 		dec = codec.NewDecoder(bufConn, &hd)
 	}
 
+	m.countMessage(false, true, msgType, 0)
 	return msgType, bufConn, dec, nil
 }
 
 // readRemoteState is used to read the remote state from a connection
-func (m *Memberlist) readRemoteState(bufConn io.Reader, dec *codec.Decoder) (bool, []pushNodeState, []byte, error) {
+func (m *Memberlist) readRemoteState(bufConn io.Reader, dec *codec.Decoder) (pushPullHeader, []pushNodeState, []byte, error) {
 	// Read the push/pull header
 	var header pushPullHeader
 	if err := dec.Decode(&header); err != nil {
-		return false, nil, nil, err
+		return header, nil, nil, err
 	}
 
 	// Allocate space for the transfer
@@ -1240,7 +1764,7 @@ func (m *Memberlist) readRemoteState(bufConn io.Reader, dec *codec.Decoder) (boo
 	// Try to decode all the states
 	for i := 0; i < header.Nodes; i++ {
 		if err := dec.Decode(&remoteNodes[i]); err != nil {
-			return false, nil, nil, err
+			return header, nil, nil, err
 		}
 	}
 
@@ -1255,7 +1779,7 @@ func (m *Memberlist) readRemoteState(bufConn io.Reader, dec *codec.Decoder) (boo
 				bytes, header.UserStateLen)
 		}
 		if err != nil {
-			return false, nil, nil, err
+			return header, nil, nil, err
 		}
 	}
 
@@ -1267,7 +1791,7 @@ func (m *Memberlist) readRemoteState(bufConn io.Reader, dec *codec.Decoder) (boo
 		}
 	}
 
-	return header.Join, remoteNodes, userBuf, nil
+	return header, remoteNodes, userBuf, nil
 }
 
 // mergeRemoteState is used to merge the remote state with our local state
@@ -1281,20 +1805,31 @@ func (m *Memberlist) mergeRemoteState(join bool, remoteNodes []pushNodeState, us
 		nodes := make([]*Node, len(remoteNodes))
 		for idx, n := range remoteNodes {
 			nodes[idx] = &Node{
-				Name:  n.Name,
-				Addr:  n.Addr,
-				Port:  n.Port,
-				Meta:  n.Meta,
-				State: n.State,
-				PMin:  n.Vsn[0],
-				PMax:  n.Vsn[1],
-				PCur:  n.Vsn[2],
-				DMin:  n.Vsn[3],
-				DMax:  n.Vsn[4],
-				DCur:  n.Vsn[5],
+				Name:       n.Name,
+				Addr:       n.Addr,
+				Port:       n.Port,
+				Meta:       n.Meta,
+				Addrs:      n.Addrs,
+				Role:       n.Role,
+				Zone:       n.Zone,
+				Weight:     n.Weight,
+				Draining:   n.Draining,
+				ID:         n.ID,
+				StreamPort: n.StreamPort,
+				State:      n.State,
+				PMin:       n.Vsn[0],
+				PMax:       n.Vsn[1],
+				PCur:       n.Vsn[2],
+				DMin:       n.Vsn[3],
+				DMax:       n.Vsn[4],
+				DCur:       n.Vsn[5],
 			}
 		}
-		if err := m.config.Merge.NotifyMerge(nodes); err != nil {
+		var err error
+		if !m.guard("NotifyMerge", func() { err = m.config.Merge.NotifyMerge(nodes) }) {
+			err = errDelegatePanic
+		}
+		if err != nil {
 			return err
 		}
 	}
@@ -1303,8 +1838,8 @@ func (m *Memberlist) mergeRemoteState(join bool, remoteNodes []pushNodeState, us
 	m.mergeState(remoteNodes)
 
 	// Invoke the delegate for user state
-	if userBuf != nil && m.config.Delegate != nil {
-		m.config.Delegate.MergeRemoteState(userBuf, join)
+	if d := m.delegate(); userBuf != nil && d != nil {
+		m.guard("MergeRemoteState", func() { d.MergeRemoteState(userBuf, join) })
 	}
 	return nil
 }
@@ -1317,6 +1852,11 @@ func (m *Memberlist) readUserMsg(bufConn io.Reader, dec *codec.Decoder) error {
 		return err
 	}
 
+	// Don't allocate for a message we'd only drop.
+	if err := m.checkUserMsgSize(header.UserMsgLen); err != nil {
+		return err
+	}
+
 	// Read the user message into a buffer
 	var userBuf []byte
 	if header.UserMsgLen > 0 {
@@ -1331,10 +1871,7 @@ func (m *Memberlist) readUserMsg(bufConn io.Reader, dec *codec.Decoder) error {
 			return err
 		}
 
-		d := m.config.Delegate
-		if d != nil {
-			d.NotifyMsg(userBuf)
-		}
+		m.notifyMsg(userBuf)
 	}
 
 	return nil
@@ -1349,7 +1886,7 @@ func (m *Memberlist) sendPingAndWaitForAck(a Address, ping ping, deadline time.T
 		return false, errNodeNamesAreRequired
 	}
 
-	conn, err := m.transport.DialAddressTimeout(a, time.Until(deadline))
+	conn, err := m.dialNode(a, time.Until(deadline))
 	if err != nil {
 		// If the node is actually dead we expect this to fail, so we
 		// shouldn't spam the logs with it. After this point, errors
@@ -5,6 +5,7 @@ package memberlist
 
 import (
 	"bytes"
+	"container/list"
 	"encoding/binary"
 	"fmt"
 	"io"
@@ -18,6 +19,8 @@ import (
 
 	"github.com/hashicorp/go-msgpack/v2/codec"
 	"github.com/stretchr/testify/require"
+
+	iretry "github.com/hashicorp/memberlist/internal/retry"
 )
 
 // As a regression we left this test very low-level and network-ey, even after
@@ -121,6 +124,9 @@ func TestHandlePing(t *testing.T) {
 
 	udpAddr := udp.LocalAddr().(*net.UDPAddr)
 
+	// Make the responder report a degraded health score.
+	m.awareness.ApplyDelta(2)
+
 	// Encode a ping
 	ping := ping{
 		SeqNo:      42,
@@ -170,6 +176,9 @@ func TestHandlePing(t *testing.T) {
 	if ack.SeqNo != 42 {
 		t.Fatalf("bad sequence no")
 	}
+	if ack.Health != 2 {
+		t.Fatalf("bad health: %d", ack.Health)
+	}
 
 	doneCh <- struct{}{}
 }
@@ -370,7 +379,7 @@ func TestTCPPing(t *testing.T) {
 			return
 		}
 
-		ack := ackResp{pingIn.SeqNo, nil}
+		ack := ackResp{SeqNo: pingIn.SeqNo}
 		out, err := encode(ackRespMsg, &ack, m.config.MsgpackUseNewTimeFormat)
 		if err != nil {
 			pingErrCh <- fmt.Errorf("failed to encode ack: %s", err)
@@ -420,7 +429,7 @@ func TestTCPPing(t *testing.T) {
 			return
 		}
 
-		ack := ackResp{pingIn.SeqNo + 1, nil}
+		ack := ackResp{SeqNo: pingIn.SeqNo + 1}
 		out, err := encode(ackRespMsg, &ack, m.config.MsgpackUseNewTimeFormat)
 		if err != nil {
 			pingErrCh <- fmt.Errorf("failed to encode ack: %s", err)
@@ -1067,3 +1076,220 @@ func (c *errorReadNetConn) Close() error {
 	close(c.closed)
 	return nil
 }
+
+func TestHandoffQueue_Priority(t *testing.T) {
+	var buf bytes.Buffer
+	m := Memberlist{
+		config:               &Config{HandoffQueueDepth: 2},
+		logger:               log.New(&buf, "", 0),
+		handoffCh:            make(chan struct{}, 1),
+		highPriorityMsgQueue: list.New(),
+		lowPriorityMsgQueue:  list.New(),
+	}
+	from := &net.UDPAddr{Port: 12345}
+
+	// Fill up the user queue so the third one gets dropped.
+	for i := 0; i < 3; i++ {
+		m.handoffMessage(userMsg, []byte{byte(i)}, from)
+	}
+	m.handoffMessage(suspectMsg, nil, from)
+	m.handoffMessage(deadMsg, nil, from)
+	m.handoffMessage(aliveMsg, nil, from)
+
+	stats := m.HandoffStats()
+	require.Equal(t, HandoffStats{
+		HighPriorityQueued:  2,
+		LowPriorityQueued:   2,
+		HighPriorityDropped: 1,
+		LowPriorityDropped:  1,
+	}, stats)
+	require.Contains(t, buf.String(), "handler queue full")
+
+	// Membership messages come out first, then the user ones.
+	var types []messageType
+	for {
+		msg, ok, _ := m.getNextMessage()
+		if !ok {
+			break
+		}
+		types = append(types, msg.msgType)
+	}
+	require.Equal(t, []messageType{deadMsg, suspectMsg, userMsg, userMsg}, types)
+}
+
+func TestHandoffQueue_Workers(t *testing.T) {
+	d := &MockDelegate{}
+	m := GetMemberlist(t, func(c *Config) {
+		c.HandoffWorkers = 4
+		c.Delegate = d
+	})
+	defer func() {
+		if err := m.Shutdown(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	from := &net.UDPAddr{Port: 12345}
+	for i := 0; i < 100; i++ {
+		m.handoffMessage(userMsg, []byte{byte(i)}, from)
+	}
+
+	iretry.Run(t, func(r *iretry.R) {
+		if n := len(d.getMessages()); n != 100 {
+			r.Fatalf("expected 100 messages, got %d", n)
+		}
+	})
+}
+
+func TestHandoffQueue_DropPolicy(t *testing.T) {
+	newMemberlist := func(policy HandoffDropPolicy) *Memberlist {
+		return &Memberlist{
+			config:               &Config{HandoffQueueDepth: 2, HandoffDropPolicy: policy},
+			logger:               log.New(io.Discard, "", 0),
+			shutdownCh:           make(chan struct{}),
+			handoffCh:            make(chan struct{}, 1),
+			handoffSpaceCh:       make(chan struct{}, 1),
+			highPriorityMsgQueue: list.New(),
+			lowPriorityMsgQueue:  list.New(),
+		}
+	}
+	drain := func(m *Memberlist) []byte {
+		var out []byte
+		for {
+			msg, ok, _ := m.getNextMessage()
+			if !ok {
+				return out
+			}
+			out = append(out, msg.buf[0])
+		}
+	}
+	from := &net.UDPAddr{Port: 12345}
+
+	t.Run("drop newest", func(t *testing.T) {
+		m := newMemberlist(DropNewest)
+		for i := 0; i < 3; i++ {
+			m.handoffMessage(userMsg, []byte{byte(i)}, from)
+		}
+		require.Equal(t, uint64(1), m.HandoffStats().LowPriorityDropped)
+		require.Equal(t, []byte{1, 0}, drain(m))
+	})
+
+	t.Run("drop oldest", func(t *testing.T) {
+		m := newMemberlist(DropOldest)
+		for i := 0; i < 3; i++ {
+			m.handoffMessage(userMsg, []byte{byte(i)}, from)
+		}
+		require.Equal(t, uint64(1), m.HandoffStats().LowPriorityDropped)
+		require.Equal(t, []byte{2, 1}, drain(m))
+	})
+
+	t.Run("block", func(t *testing.T) {
+		m := newMemberlist(Block)
+		for i := 0; i < 2; i++ {
+			m.handoffMessage(userMsg, []byte{byte(i)}, from)
+		}
+
+		doneCh := make(chan struct{})
+		go func() {
+			defer close(doneCh)
+			m.handoffMessage(userMsg, []byte{2}, from)
+		}()
+		select {
+		case <-doneCh:
+			t.Fatalf("should be blocked")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		_, ok, _ := m.getNextMessage()
+		require.True(t, ok)
+		select {
+		case <-doneCh:
+		case <-time.After(time.Second):
+			t.Fatalf("should have unblocked")
+		}
+		require.Equal(t, uint64(0), m.HandoffStats().LowPriorityDropped)
+		require.Equal(t, []byte{2, 0}, drain(m))
+	})
+
+	t.Run("block until shutdown", func(t *testing.T) {
+		m := newMemberlist(Block)
+		for i := 0; i < 2; i++ {
+			m.handoffMessage(userMsg, []byte{byte(i)}, from)
+		}
+
+		doneCh := make(chan struct{})
+		go func() {
+			defer close(doneCh)
+			m.handoffMessage(userMsg, []byte{2}, from)
+		}()
+		close(m.shutdownCh)
+		select {
+		case <-doneCh:
+		case <-time.After(time.Second):
+			t.Fatalf("should have unblocked")
+		}
+	})
+}
+
+func TestStreamWorkers(t *testing.T) {
+	m1 := GetMemberlist(t, func(c *Config) {
+		c.StreamWorkers = 1
+	})
+	defer func() {
+		require.NoError(t, m1.Shutdown())
+	}()
+	require.NoError(t, m1.setAlive())
+	m2 := GetMemberlist(t, nil)
+	defer func() {
+		require.NoError(t, m2.Shutdown())
+	}()
+	require.NoError(t, m2.setAlive())
+
+	// Tie up the only worker with a stream that says nothing.
+	stalled, err := net.Dial("tcp", m1.LocalNode().Address())
+	require.NoError(t, err)
+	defer stalled.Close()
+
+	joined := make(chan error, 1)
+	go func() {
+		_, err := m2.Join([]string{m1.config.Name + "/" + m1.LocalNode().Address()})
+		joined <- err
+	}()
+	select {
+	case err := <-joined:
+		t.Fatalf("join shouldn't have been handled yet: %v", err)
+	case <-time.After(250 * time.Millisecond):
+	}
+
+	// Once it's free, the join goes through.
+	require.NoError(t, stalled.Close())
+	select {
+	case err := <-joined:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("join should have been handled")
+	}
+}
+
+func TestStreamWorkers_DefaultUnlimited(t *testing.T) {
+	m1 := GetMemberlist(t, nil)
+	defer func() {
+		require.NoError(t, m1.Shutdown())
+	}()
+	require.Zero(t, m1.config.StreamWorkers)
+	require.NoError(t, m1.setAlive())
+	m2 := GetMemberlist(t, nil)
+	defer func() {
+		require.NoError(t, m2.Shutdown())
+	}()
+	require.NoError(t, m2.setAlive())
+
+	// Streams that say nothing don't hold up anyone else's.
+	for i := 0; i < 64; i++ {
+		stalled, err := net.Dial("tcp", m1.LocalNode().Address())
+		require.NoError(t, err)
+		defer stalled.Close()
+	}
+	_, err := m2.Join([]string{m1.config.Name + "/" + m1.LocalNode().Address()})
+	require.NoError(t, err)
+}
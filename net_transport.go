@@ -5,15 +5,19 @@ package memberlist
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"log"
 	"net"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"time"
 
 	metrics "github.com/hashicorp/go-metrics/compat"
+	"github.com/hashicorp/go-multierror"
 	sockaddr "github.com/hashicorp/go-sockaddr"
 )
 
@@ -36,12 +40,38 @@ type NetTransportConfig struct {
 	// BindPort is the port to listen on, for each address above.
 	BindPort int
 
+	// StreamBindPort, if set, is the port to listen for TCP connections
+	// on instead of BindPort.
+	StreamBindPort int
+
 	// Logger is a logger for operator messages.
 	Logger *log.Logger
 
 	// MetricLabels is a map of optional labels to apply to all metrics
 	// emitted by this transport.
 	MetricLabels []metrics.Label
+
+	// DialProxy is an optional proxy URL that outgoing stream connections
+	// are made through. See Config.DialProxy for the supported forms.
+	DialProxy string
+
+	// SocketControl is an optional hook that is called on the TCP and UDP
+	// listener sockets before they're bound. See Config.SocketControl.
+	SocketControl func(network, address string, c syscall.RawConn) error
+
+	// DontFragment sets the don't fragment bit on outgoing packets where the
+	// platform allows it, so a packet too large for the path is dropped
+	// rather than fragmented. It's set when Config.MTUProbeInterval is, so
+	// the probes find the largest packet that gets through unfragmented.
+	DontFragment bool
+
+	// TLSConfig, if set, wraps stream connections in TLS. See
+	// Config.TLSConfig.
+	TLSConfig *tls.Config
+
+	// MulticastGroup, if set, is a multicast address and port to also
+	// receive packets on. See Config.MulticastGroup.
+	MulticastGroup string
 }
 
 // NetTransport is a Transport implementation that uses connectionless UDP for
@@ -54,12 +84,20 @@ type NetTransport struct {
 	wg           sync.WaitGroup
 	tcpListeners []*net.TCPListener
 	udpListeners []*net.UDPConn
+	mcastLn      *net.UDPConn
+	proxy        proxyDialer
 	shutdown     int32
+	stopAccept   int32
+
+	stunLock    sync.Mutex
+	stunWaiters map[stunTxID]chan []byte // Pending PublicAddr calls
 
 	metricLabels []metrics.Label
 }
 
 var _ NodeAwareTransport = (*NetTransport)(nil)
+var _ DrainingTransport = (*NetTransport)(nil)
+var _ STUNTransport = (*NetTransport)(nil)
 
 // NewNetTransport returns a net transport with the given configuration. On
 // success all the network listeners will be created and listening.
@@ -72,11 +110,19 @@ func NewNetTransport(config *NetTransportConfig) (*NetTransport, error) {
 
 	// Build out the new transport.
 	var ok bool
+	var pd proxyDialer
+	if config.DialProxy != "" {
+		var err error
+		if pd, err = newProxyDialer(config.DialProxy); err != nil {
+			return nil, err
+		}
+	}
 	t := NetTransport{
 		config:       config,
 		packetCh:     make(chan *Packet),
 		streamCh:     make(chan net.Conn),
 		logger:       config.Logger,
+		proxy:        pd,
 		metricLabels: config.MetricLabels,
 	}
 
@@ -88,33 +134,70 @@ func NewNetTransport(config *NetTransportConfig) (*NetTransport, error) {
 	}()
 
 	// Build all the TCP and UDP listeners.
+	lc := net.ListenConfig{Control: config.SocketControl}
 	port := config.BindPort
 	for _, addr := range config.BindAddrs {
 		ip := net.ParseIP(addr)
 
-		tcpAddr := &net.TCPAddr{IP: ip, Port: port}
-		tcpLn, err := net.ListenTCP("tcp", tcpAddr)
+		tcpPort := port
+		if config.StreamBindPort != 0 {
+			tcpPort = config.StreamBindPort
+		}
+		tcpAddr := &net.TCPAddr{IP: ip, Port: tcpPort}
+		ln, err := lc.Listen(context.Background(), "tcp", tcpAddr.String())
 		if err != nil {
-			return nil, fmt.Errorf("failed to start TCP listener on %q port %d: %v", addr, port, err)
+			return nil, fmt.Errorf("failed to start TCP listener on %q port %d: %v", addr, tcpPort, err)
 		}
+		tcpLn := ln.(*net.TCPListener)
 		t.tcpListeners = append(t.tcpListeners, tcpLn)
 
 		// If the config port given was zero, use the first TCP listener
 		// to pick an available port and then apply that to everything
-		// else.
-		if port == 0 {
+		// else. With a separate stream port, the first UDP listener
+		// picks it instead.
+		if port == 0 && config.StreamBindPort == 0 {
 			port = tcpLn.Addr().(*net.TCPAddr).Port
 		}
 
 		udpAddr := &net.UDPAddr{IP: ip, Port: port}
-		udpLn, err := net.ListenUDP("udp", udpAddr)
+		pc, err := lc.ListenPacket(context.Background(), "udp", udpAddr.String())
 		if err != nil {
 			return nil, fmt.Errorf("failed to start UDP listener on %q port %d: %v", addr, port, err)
 		}
+		udpLn := pc.(*net.UDPConn)
+		if err := prepareUDPConn(udpLn); err != nil {
+			t.logger.Printf("[WARN] memberlist: Failed to configure UDP listener on %q: %v", addr, err)
+		}
+		if config.DontFragment {
+			if err := setDontFragment(udpLn); err != nil {
+				t.logger.Printf("[WARN] memberlist: Failed to set don't fragment on UDP listener on %q: %v", addr, err)
+			}
+		}
 		if err := setUDPRecvBuf(udpLn); err != nil {
 			return nil, fmt.Errorf("failed to resize UDP buffer: %v", err)
 		}
+		if size, err := udpRecvBuf(udpLn); err == nil && size < udpRecvBufSize {
+			t.logger.Printf("[DEBUG] memberlist: UDP receive buffer on %q is %d bytes, wanted %d",
+				addr, size, udpRecvBufSize)
+		}
 		t.udpListeners = append(t.udpListeners, udpLn)
+		if port == 0 {
+			port = udpLn.LocalAddr().(*net.UDPAddr).Port
+		}
+	}
+
+	if config.MulticastGroup != "" {
+		group, err := resolveMulticastGroup(config.MulticastGroup)
+		if err != nil {
+			return nil, err
+		}
+		t.mcastLn, err = net.ListenMulticastUDP("udp", nil, group)
+		if err != nil {
+			return nil, fmt.Errorf("failed to join multicast group %s: %v", group, err)
+		}
+		if err := setUDPRecvBuf(t.mcastLn); err != nil {
+			return nil, fmt.Errorf("failed to resize UDP buffer: %v", err)
+		}
 	}
 
 	// Fire them up now that we've been able to create them all.
@@ -123,6 +206,10 @@ func NewNetTransport(config *NetTransportConfig) (*NetTransport, error) {
 		go t.tcpListen(t.tcpListeners[i])
 		go t.udpListen(t.udpListeners[i])
 	}
+	if t.mcastLn != nil {
+		t.wg.Add(1)
+		go t.udpListen(t.mcastLn)
+	}
 
 	ok = true
 	return &t, nil
@@ -131,8 +218,14 @@ func NewNetTransport(config *NetTransportConfig) (*NetTransport, error) {
 // GetAutoBindPort returns the bind port that was automatically given by the
 // kernel, if a bind port of 0 was given.
 func (t *NetTransport) GetAutoBindPort() int {
-	// We made sure there's at least one TCP listener, and that one's
+	// We made sure there's at least one UDP listener, and that one's
 	// port was applied to all the others for the dynamic bind case.
+	return t.udpListeners[0].LocalAddr().(*net.UDPAddr).Port
+}
+
+// GetStreamPort returns the port the TCP listeners are bound to, which is
+// the same as GetAutoBindPort unless a separate stream port was configured.
+func (t *NetTransport) GetStreamPort() int {
 	return t.tcpListeners[0].Addr().(*net.TCPAddr).Port
 }
 
@@ -153,16 +246,20 @@ func (t *NetTransport) FinalAdvertiseAddr(ip string, port int) (net.IP, int, err
 		}
 		advertisePort = port
 	} else {
-		if t.config.BindAddrs[0] == "0.0.0.0" {
+		if bind := net.ParseIP(t.config.BindAddrs[0]); bind != nil && bind.IsUnspecified() {
 			// Otherwise, if we're not bound to a specific IP, let's
 			// use a suitable private IP address.
 			var err error
 			ip, err = sockaddr.GetPrivateIP()
-			if err != nil {
-				return nil, 0, fmt.Errorf("failed to get interface addresses: %v", err)
-			}
-			if ip == "" {
-				return nil, 0, fmt.Errorf("no private IP address found, and explicit IP not provided")
+			if err != nil || ip == "" {
+				// The sockaddr lookup leans on routing tables, which it
+				// can't always read outside Linux, so fall back to
+				// walking the interfaces ourselves.
+				detected, err := DetectAdvertiseAddr(nil)
+				if err != nil {
+					return nil, 0, err
+				}
+				ip = detected.String()
 			}
 
 			advertiseAddr = net.ParseIP(ip)
@@ -250,8 +347,54 @@ func (t *NetTransport) DialTimeout(addr string, timeout time.Duration) (net.Conn
 func (t *NetTransport) DialAddressTimeout(a Address, timeout time.Duration) (net.Conn, error) {
 	addr := a.Addr
 
+	if t.proxy != nil {
+		ctx := context.Background()
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		conn, err := t.proxy.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return nil, err
+		}
+		return t.tlsClient(ctx, conn, addr)
+	}
+
 	dialer := net.Dialer{Timeout: timeout}
-	return dialer.Dial("tcp", addr)
+	conn, err := dialer.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return t.tlsClient(ctx, conn, addr)
+}
+
+// tlsClient wraps an outgoing stream in TLS, if it's configured, and does
+// the handshake.
+func (t *NetTransport) tlsClient(ctx context.Context, conn net.Conn, addr string) (net.Conn, error) {
+	cfg := t.config.TLSConfig
+	if cfg == nil {
+		return conn, nil
+	}
+	if cfg.ServerName == "" && !cfg.InsecureSkipVerify {
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			cfg = cfg.Clone()
+			cfg.ServerName = host
+		}
+	}
+
+	tc := tls.Client(conn, cfg)
+	if err := tc.HandshakeContext(ctx); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return tc, nil
 }
 
 // See Transport.
@@ -265,18 +408,37 @@ func (t *NetTransport) IngestStream(conn net.Conn) error {
 	return nil
 }
 
+// See DrainingTransport.
+func (t *NetTransport) StopAccepting() error {
+	if !atomic.CompareAndSwapInt32(&t.stopAccept, 0, 1) {
+		return nil
+	}
+
+	var errs error
+	for _, ln := range t.tcpListeners {
+		if err := ln.Close(); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return errs
+}
+
 // See Transport.
 func (t *NetTransport) Shutdown() error {
 	// This will avoid log spam about errors when we shut down.
 	atomic.StoreInt32(&t.shutdown, 1)
 
-	// Rip through all the connections and shut them down.
+	// Rip through all the connections and shut them down. The TCP
+	// listeners may already be closed by StopAccepting.
 	for _, conn := range t.tcpListeners {
 		_ = conn.Close()
 	}
 	for _, conn := range t.udpListeners {
 		_ = conn.Close()
 	}
+	if t.mcastLn != nil {
+		_ = t.mcastLn.Close()
+	}
 
 	// Block until all the listener threads have died.
 	t.wg.Wait()
@@ -303,6 +465,9 @@ func (t *NetTransport) tcpListen(tcpLn *net.TCPListener) {
 			if s := atomic.LoadInt32(&t.shutdown); s == 1 {
 				break
 			}
+			if s := atomic.LoadInt32(&t.stopAccept); s == 1 {
+				break
+			}
 
 			if loopDelay == 0 {
 				loopDelay = baseDelay
@@ -321,6 +486,12 @@ func (t *NetTransport) tcpListen(tcpLn *net.TCPListener) {
 		// No error, reset loop delay
 		loopDelay = 0
 
+		// The handshake is left to whoever reads the stream first, so a
+		// slow peer can't hold up accepting others.
+		if t.config.TLSConfig != nil {
+			t.streamCh <- tls.Server(conn, t.config.TLSConfig)
+			continue
+		}
 		t.streamCh <- conn
 	}
 }
@@ -340,6 +511,12 @@ func (t *NetTransport) udpListen(udpLn *net.UDPConn) {
 				break
 			}
 
+			// Some platforms report failures from earlier sends on
+			// the next read, which says nothing about this listener.
+			if udpReadErrIgnorable(err) {
+				continue
+			}
+
 			t.logger.Printf("[ERR] memberlist: Error reading UDP packet: %v", err)
 			continue
 		}
@@ -352,6 +529,11 @@ func (t *NetTransport) udpListen(udpLn *net.UDPConn) {
 			continue
 		}
 
+		// Answers to our own STUN requests aren't for memberlist.
+		if t.stunResponse(buf[:n]) {
+			continue
+		}
+
 		// Ingest the packet.
 		metrics.IncrCounterWithLabels([]string{"memberlist", "udp", "received"}, float32(n), t.metricLabels)
 		t.packetCh <- &Packet{
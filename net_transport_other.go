@@ -0,0 +1,27 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build !unix && !windows
+
+package memberlist
+
+import (
+	"net"
+)
+
+// prepareUDPConn applies any platform specific settings to a UDP listener.
+func prepareUDPConn(*net.UDPConn) error {
+	return nil
+}
+
+// udpReadErrIgnorable returns true if an error reading from a UDP listener
+// doesn't mean anything is wrong with the listener.
+func udpReadErrIgnorable(error) bool {
+	return false
+}
+
+// udpRecvBuf returns the effective size of a UDP listener's receive buffer.
+// It's unknown on this platform, so the requested size is assumed.
+func udpRecvBuf(*net.UDPConn) (int, error) {
+	return udpRecvBufSize, nil
+}
@@ -0,0 +1,52 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build unix
+
+package memberlist
+
+import (
+	"net"
+	"runtime"
+	"syscall"
+)
+
+// prepareUDPConn applies any platform specific settings to a UDP listener.
+// There are none needed on Unix systems.
+func prepareUDPConn(*net.UDPConn) error {
+	return nil
+}
+
+// udpReadErrIgnorable returns true if an error reading from a UDP listener
+// doesn't mean anything is wrong with the listener. Unconnected UDP sockets
+// don't report errors from earlier sends on Unix systems.
+func udpReadErrIgnorable(error) bool {
+	return false
+}
+
+// udpRecvBuf returns the effective size of a UDP listener's receive buffer.
+// Linux silently caps the size at net.core.rmem_max rather than failing, so
+// this is how we find out what we got.
+func udpRecvBuf(c *net.UDPConn) (int, error) {
+	raw, err := c.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	var size int
+	var serr error
+	if err := raw.Control(func(fd uintptr) {
+		size, serr = syscall.GetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_RCVBUF)
+	}); err != nil {
+		return 0, err
+	}
+	if serr != nil {
+		return 0, serr
+	}
+
+	// Linux doubles the requested size to leave room for bookkeeping, and
+	// reports the doubled value.
+	if runtime.GOOS == "linux" {
+		size /= 2
+	}
+	return size, nil
+}
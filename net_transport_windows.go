@@ -0,0 +1,70 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build windows
+
+package memberlist
+
+import (
+	"errors"
+	"net"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	// sioUDPConnReset is SIO_UDP_CONNRESET, which controls whether an ICMP
+	// port unreachable message makes the next read on a UDP socket fail.
+	sioUDPConnReset = syscall.IOC_IN | syscall.IOC_VENDOR | 12
+
+	// Errors Windows reports on a UDP read when an earlier send got an
+	// ICMP port unreachable or TTL expired message back.
+	wsaeconnreset syscall.Errno = 10054
+	wsaenetreset  syscall.Errno = 10052
+)
+
+// prepareUDPConn applies any platform specific settings to a UDP listener.
+// By default a packet to a member that has gone away makes the next read on
+// the listener fail with WSAECONNRESET, which would drop whatever packet was
+// actually waiting, so that's turned off.
+func prepareUDPConn(c *net.UDPConn) error {
+	raw, err := c.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var serr error
+	if err := raw.Control(func(fd uintptr) {
+		flag := uint32(0)
+		var ret uint32
+		serr = syscall.WSAIoctl(syscall.Handle(fd), sioUDPConnReset,
+			(*byte)(unsafe.Pointer(&flag)), uint32(unsafe.Sizeof(flag)),
+			nil, 0, &ret, nil, 0)
+	}); err != nil {
+		return err
+	}
+	return serr
+}
+
+// udpReadErrIgnorable returns true if an error reading from a UDP listener
+// doesn't mean anything is wrong with the listener. This covers the errors
+// left over from earlier sends, in case prepareUDPConn couldn't turn them
+// off.
+func udpReadErrIgnorable(err error) bool {
+	return errors.Is(err, wsaeconnreset) || errors.Is(err, wsaenetreset)
+}
+
+// udpRecvBuf returns the effective size of a UDP listener's receive buffer.
+func udpRecvBuf(c *net.UDPConn) (int, error) {
+	raw, err := c.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	var size int
+	var serr error
+	if err := raw.Control(func(fd uintptr) {
+		size, serr = syscall.GetsockoptInt(syscall.Handle(fd), syscall.SOL_SOCKET, syscall.SO_RCVBUF)
+	}); err != nil {
+		return 0, err
+	}
+	return size, serr
+}
@@ -0,0 +1,157 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+// NodeEntry is one keyed value in a node's state.
+type NodeEntry struct {
+	Key   string
+	Value []byte
+
+	// Version orders the values of a key. The entry with the highest
+	// version wins, so it must go up whenever the value changes.
+	Version uint64
+}
+
+// NodeEntriesDelegate can optionally be implemented by a Delegate that keeps
+// a small set of keyed values per node. Unlike LocalState, which is a
+// single blob the application has to merge as a whole, the entries are
+// kept per node and key by memberlist and exchanged during push/pull, and
+// each one is merged on its own, the highest version winning. Keys can't be
+// removed, but their value can be emptied with a newer version.
+type NodeEntriesDelegate interface {
+	// LocalNodeEntries returns this node's entries. It's called before each
+	// push/pull.
+	LocalNodeEntries() []NodeEntry
+
+	// NotifyNodeEntries is invoked with the entries of another node that
+	// are newer than the ones we had for it.
+	NotifyNodeEntries(node string, entries []NodeEntry)
+}
+
+// NodeEntries returns the entries we know of for the named node, sorted by
+// key, or nil if we have none.
+func (m *Memberlist) NodeEntries(node string) []NodeEntry {
+	m.nodeLock.RLock()
+	defer m.nodeLock.RUnlock()
+	n, ok := m.nodeMap[node]
+	if !ok {
+		return nil
+	}
+	return n.entryList()
+}
+
+// entryList returns a node's entries sorted by key. You must hold the node
+// lock.
+func (n *nodeState) entryList() []NodeEntry {
+	if len(n.entries) == 0 {
+		return nil
+	}
+	entries := make([]NodeEntry, 0, len(n.entries))
+	for _, e := range n.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries
+}
+
+// entriesHash returns a hash of the keys and versions of a node's entries,
+// so delta push/pull can tell whether two views of them differ. It's zero
+// if there are none. You must hold the node lock.
+func (n *nodeState) entriesHash() uint64 {
+	if len(n.entries) == 0 {
+		return 0
+	}
+	var sum uint64
+	for _, e := range n.entries {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(e.Key))
+		_, _ = h.Write([]byte(strconv.FormatUint(e.Version, 10)))
+		sum += h.Sum64()
+	}
+	return sum
+}
+
+// mergeEntries merges entries into a node's, keeping the highest version of
+// each key. It returns the entries that were newer. You must hold the node
+// lock for writing.
+func (n *nodeState) mergeEntries(entries []NodeEntry) []NodeEntry {
+	var newer []NodeEntry
+	for _, e := range entries {
+		if cur, ok := n.entries[e.Key]; ok && cur.Version >= e.Version {
+			continue
+		}
+		if n.entries == nil {
+			n.entries = make(map[string]NodeEntry)
+		}
+		n.entries[e.Key] = e
+		newer = append(newer, e)
+	}
+	return newer
+}
+
+// nodeEntriesDelegate returns the delegate if it implements
+// NodeEntriesDelegate.
+func (m *Memberlist) nodeEntriesDelegate() NodeEntriesDelegate {
+	d, _ := m.delegate().(NodeEntriesDelegate)
+	return d
+}
+
+// refreshLocalEntries asks the delegate for our entries, so the next
+// push/pull carries the latest ones.
+func (m *Memberlist) refreshLocalEntries() {
+	d := m.nodeEntriesDelegate()
+	if d == nil {
+		return
+	}
+	var entries []NodeEntry
+	if !m.guard("LocalNodeEntries", func() { entries = d.LocalNodeEntries() }) {
+		return
+	}
+
+	m.nodeLock.Lock()
+	defer m.nodeLock.Unlock()
+	if n, ok := m.nodeMap[m.config.Name]; ok {
+		n.mergeEntries(entries)
+	}
+}
+
+// mergeRemoteEntries merges the entries carried by remote node states and
+// tells the delegate about the ones that were newer. Our own entries are
+// only ever set by our delegate.
+func (m *Memberlist) mergeRemoteEntries(remote []pushNodeState) {
+	type update struct {
+		node    string
+		entries []NodeEntry
+	}
+	var updates []update
+
+	m.nodeLock.Lock()
+	for _, r := range remote {
+		if len(r.Entries) == 0 || r.Name == m.config.Name {
+			continue
+		}
+		n, ok := m.nodeMap[r.Name]
+		if !ok {
+			continue
+		}
+		if newer := n.mergeEntries(r.Entries); len(newer) > 0 {
+			updates = append(updates, update{r.Name, newer})
+		}
+	}
+	m.nodeLock.Unlock()
+
+	d := m.nodeEntriesDelegate()
+	if d == nil {
+		return
+	}
+	for _, u := range updates {
+		m.guard("NotifyNodeEntries", func() { d.NotifyNodeEntries(u.node, u.entries) })
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type nodeEntriesMockDelegate struct {
+	MockDelegate
+
+	mu       sync.Mutex
+	local    []NodeEntry
+	notified map[string][]NodeEntry
+}
+
+func (d *nodeEntriesMockDelegate) setLocal(entries ...NodeEntry) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.local = entries
+}
+
+func (d *nodeEntriesMockDelegate) getNotified(node string) []NodeEntry {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.notified[node]
+}
+
+func (d *nodeEntriesMockDelegate) LocalNodeEntries() []NodeEntry {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.local
+}
+
+func (d *nodeEntriesMockDelegate) NotifyNodeEntries(node string, entries []NodeEntry) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.notified == nil {
+		d.notified = make(map[string][]NodeEntry)
+	}
+	d.notified[node] = append(d.notified[node], entries...)
+}
+
+func TestNodeState_mergeEntries(t *testing.T) {
+	var n nodeState
+	require.Zero(t, n.entriesHash())
+
+	newer := n.mergeEntries([]NodeEntry{{Key: "a", Value: []byte("1"), Version: 2}, {Key: "b", Version: 1}})
+	require.Len(t, newer, 2)
+	h := n.entriesHash()
+	require.NotZero(t, h)
+
+	// Older and equal versions lose.
+	newer = n.mergeEntries([]NodeEntry{{Key: "a", Value: []byte("0"), Version: 1}, {Key: "b", Value: []byte("x"), Version: 1}})
+	require.Empty(t, newer)
+	require.Equal(t, h, n.entriesHash())
+
+	newer = n.mergeEntries([]NodeEntry{{Key: "a", Value: []byte("3"), Version: 3}})
+	require.Equal(t, []NodeEntry{{Key: "a", Value: []byte("3"), Version: 3}}, newer)
+	require.NotEqual(t, h, n.entriesHash())
+	require.Equal(t, []NodeEntry{{Key: "a", Value: []byte("3"), Version: 3}, {Key: "b", Version: 1}}, n.entryList())
+}
+
+func testNodeEntriesExchange(t *testing.T, delta bool) {
+	d1 := &nodeEntriesMockDelegate{}
+	d1.setLocal(NodeEntry{Key: "role", Value: []byte("db"), Version: 1})
+	c1 := testConfig(t)
+	c1.Delegate = d1
+	c1.DeltaPushPull = delta
+	m1, err := Create(c1)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m1.Shutdown())
+	}()
+
+	d2 := &nodeEntriesMockDelegate{}
+	d2.setLocal(NodeEntry{Key: "role", Value: []byte("web"), Version: 1})
+	c2 := testConfig(t)
+	c2.BindPort = m1.config.BindPort
+	c2.Delegate = d2
+	c2.DeltaPushPull = delta
+	m2, err := Create(c2)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m2.Shutdown())
+	}()
+
+	_, err = m2.Join([]string{m1.config.Name + "/" + m1.config.BindAddr})
+	require.NoError(t, err)
+
+	// The join exchanges both nodes' entries.
+	require.Equal(t, []NodeEntry{{Key: "role", Value: []byte("db"), Version: 1}}, m2.NodeEntries(m1.config.Name))
+	require.Equal(t, []NodeEntry{{Key: "role", Value: []byte("web"), Version: 1}}, m1.NodeEntries(m2.config.Name))
+	require.Equal(t, m2.NodeEntries(m1.config.Name), d2.getNotified(m1.config.Name))
+
+	// Newer entries replace older ones key by key.
+	d1.setLocal(
+		NodeEntry{Key: "role", Value: []byte("primary"), Version: 2},
+		NodeEntry{Key: "zone", Value: []byte("a"), Version: 1},
+	)
+	require.NoError(t, m2.PushPullNode(m1.config.Name))
+	require.Equal(t, []NodeEntry{
+		{Key: "role", Value: []byte("primary"), Version: 2},
+		{Key: "zone", Value: []byte("a"), Version: 1},
+	}, m2.NodeEntries(m1.config.Name))
+	require.Len(t, d2.getNotified(m1.config.Name), 3)
+
+	// Other members can't change our own entries.
+	require.Equal(t, []NodeEntry{{Key: "role", Value: []byte("web"), Version: 1}}, m2.NodeEntries(m2.config.Name))
+}
+
+func TestMemberlist_NodeEntries(t *testing.T) {
+	testNodeEntriesExchange(t, false)
+}
+
+func TestMemberlist_NodeEntries_Delta(t *testing.T) {
+	testNodeEntriesExchange(t, true)
+}
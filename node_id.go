@@ -0,0 +1,67 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// nodeIDFile is the name of the file under Config.StateDir that holds the
+// generated node ID.
+const nodeIDFile = "node-id"
+
+// generateNodeID returns a random (version 4) UUID.
+func generateNodeID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// isValidNodeID returns true if the ID is a UUID in its canonical textual
+// form.
+func isValidNodeID(id string) bool {
+	if len(id) != 36 {
+		return false
+	}
+	for i, c := range id {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		}
+	}
+	_, err := hex.DecodeString(strings.ReplaceAll(id, "-", ""))
+	return err == nil
+}
+
+// loadNodeID returns the node ID kept in the given state directory, which
+// must exist. If there isn't one yet, a new one is generated and saved.
+func loadNodeID(dir string) (string, error) {
+	path := filepath.Join(dir, nodeIDFile)
+	buf, err := os.ReadFile(path)
+	if err == nil {
+		return strings.TrimSpace(string(buf)), nil
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read node ID: %v", err)
+	}
+
+	id, err := generateNodeID()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate node ID: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(id), 0600); err != nil {
+		return "", fmt.Errorf("failed to save node ID: %v", err)
+	}
+	return id, nil
+}
@@ -0,0 +1,49 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeID_Generate(t *testing.T) {
+	id, err := generateNodeID()
+	require.NoError(t, err)
+	require.True(t, isValidNodeID(id))
+	require.Equal(t, byte('4'), id[14])
+
+	require.False(t, isValidNodeID(""))
+	require.False(t, isValidNodeID("not-a-uuid"))
+	require.False(t, isValidNodeID("5c8c3f0e-0f5e-4c1a-9a36-2f3e7bb4e3aZ"))
+	require.False(t, isValidNodeID("5c8c3f0e00f5e-4c1a-9a36-2f3e7bb4e3a1"))
+}
+
+func TestNodeID_Load(t *testing.T) {
+	dir := t.TempDir()
+
+	id, err := loadNodeID(dir)
+	require.NoError(t, err)
+	require.True(t, isValidNodeID(id))
+
+	// It sticks across loads.
+	again, err := loadNodeID(dir)
+	require.NoError(t, err)
+	require.Equal(t, id, again)
+}
+
+func TestMemberlist_NodeID(t *testing.T) {
+	c := testConfig(t)
+	c.NodeID = "bogus"
+	_, err := Create(c)
+	require.Error(t, err)
+
+	c = testConfig(t)
+	c.StateDir = t.TempDir()
+	m, err := Create(c)
+	require.NoError(t, err)
+	defer m.Shutdown()
+	require.True(t, isValidNodeID(m.LocalNode().ID))
+}
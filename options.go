@@ -0,0 +1,171 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+)
+
+// Option configures a Memberlist created with New.
+type Option func(*Config) error
+
+// ConfigError is returned by New when an option, or a combination of
+// options, is invalid.
+type ConfigError struct {
+	// Option is the name of the offending option, such as "WithEncryption".
+	Option string
+	Err    error
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("memberlist: invalid %s: %v", e.Option, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// New creates a Memberlist from DefaultLANConfig and the given options, and
+// starts it like Create. All options are checked before anything is bound,
+// and any problem is returned as a *ConfigError.
+func New(opts ...Option) (*Memberlist, error) {
+	conf := DefaultLANConfig()
+	if err := applyOptions(conf, opts...); err != nil {
+		return nil, err
+	}
+	return Create(conf)
+}
+
+// applyOptions applies the options to conf in order and validates the result.
+func applyOptions(conf *Config, opts ...Option) error {
+	for _, opt := range opts {
+		if err := opt(conf); err != nil {
+			return err
+		}
+	}
+
+	if conf.Name == "" {
+		return &ConfigError{Option: "WithName", Err: errors.New("a node name is required")}
+	}
+	if conf.Logger != nil && conf.LogOutput != nil {
+		return &ConfigError{Option: "WithLogger", Err: errors.New("cannot be combined with Config.LogOutput")}
+	}
+	if conf.ProtocolVersion < ProtocolVersionMin || conf.ProtocolVersion > ProtocolVersionMax {
+		return &ConfigError{Option: "WithConfig", Err: fmt.Errorf("protocol version %d must be in range [%d, %d]",
+			conf.ProtocolVersion, ProtocolVersionMin, ProtocolVersionMax)}
+	}
+	return nil
+}
+
+// WithConfig starts from a copy of the given configuration instead of
+// DefaultLANConfig. It replaces anything set by earlier options, so it
+// should come first.
+func WithConfig(c *Config) Option {
+	return func(conf *Config) error {
+		if c == nil {
+			return &ConfigError{Option: "WithConfig", Err: errors.New("config is nil")}
+		}
+		*conf = *c
+		return nil
+	}
+}
+
+// WithName sets the name of this node, which must be unique in the cluster.
+func WithName(name string) Option {
+	return func(conf *Config) error {
+		conf.Name = name
+		return nil
+	}
+}
+
+// WithBindAddr sets the IP address to listen on.
+func WithBindAddr(addr string) Option {
+	return func(conf *Config) error {
+		if net.ParseIP(addr) == nil {
+			return &ConfigError{Option: "WithBindAddr", Err: fmt.Errorf("%q is not an IP address", addr)}
+		}
+		conf.BindAddr = addr
+		return nil
+	}
+}
+
+// WithBindPort sets the port to listen on. Zero picks a free port.
+func WithBindPort(port int) Option {
+	return func(conf *Config) error {
+		if port < 0 || port > 65535 {
+			return &ConfigError{Option: "WithBindPort", Err: fmt.Errorf("port %d is out of range", port)}
+		}
+		conf.BindPort = port
+		return nil
+	}
+}
+
+// WithAdvertiseAddr sets the address and port advertised to other members,
+// for when they differ from the bind address, such as behind NAT.
+func WithAdvertiseAddr(addr string, port int) Option {
+	return func(conf *Config) error {
+		if net.ParseIP(addr) == nil {
+			return &ConfigError{Option: "WithAdvertiseAddr", Err: fmt.Errorf("%q is not an IP address", addr)}
+		}
+		if port < 0 || port > 65535 {
+			return &ConfigError{Option: "WithAdvertiseAddr", Err: fmt.Errorf("port %d is out of range", port)}
+		}
+		conf.AdvertiseAddr = addr
+		conf.AdvertisePort = port
+		return nil
+	}
+}
+
+// WithEncryption enables encryption with the given primary key, which must
+// be 16, 24 or 32 bytes long.
+func WithEncryption(key []byte) Option {
+	return func(conf *Config) error {
+		if err := ValidateKey(key); err != nil {
+			return &ConfigError{Option: "WithEncryption", Err: err}
+		}
+		conf.SecretKey = key
+		return nil
+	}
+}
+
+// WithLabel sets the label used to keep clusters on the same network apart.
+func WithLabel(label string) Option {
+	return func(conf *Config) error {
+		if len(label) > LabelMaxSize {
+			return &ConfigError{Option: "WithLabel", Err: fmt.Errorf("label is longer than %d bytes", LabelMaxSize)}
+		}
+		conf.Label = label
+		return nil
+	}
+}
+
+// WithDelegate sets the Delegate.
+func WithDelegate(d Delegate) Option {
+	return func(conf *Config) error {
+		conf.Delegate = d
+		return nil
+	}
+}
+
+// WithEvents sets the EventDelegate.
+func WithEvents(d EventDelegate) Option {
+	return func(conf *Config) error {
+		conf.Events = d
+		return nil
+	}
+}
+
+// WithLogger sets the logger.
+func WithLogger(l *log.Logger) Option {
+	return func(conf *Config) error {
+		if l == nil {
+			return &ConfigError{Option: "WithLogger", Err: errors.New("logger is nil")}
+		}
+		conf.Logger = l
+		return nil
+	}
+}
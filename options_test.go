@@ -0,0 +1,72 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	c := testConfig(t)
+	d := &MockDelegate{}
+	d.setMeta([]byte("meta"))
+
+	m, err := New(
+		WithConfig(c),
+		WithName("node-a"),
+		WithBindPort(0),
+		WithEncryption(make([]byte, 16)),
+		WithLabel("blue"),
+		WithDelegate(d),
+	)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	require.Equal(t, "node-a", m.LocalNode().Name)
+	require.Equal(t, []byte("meta"), m.LocalNode().Meta)
+	require.True(t, m.config.EncryptionEnabled())
+	require.Equal(t, "blue", m.config.Label)
+
+	// The configuration passed in isn't modified.
+	require.Equal(t, c.BindAddr, c.Name)
+	require.Nil(t, c.Keyring)
+}
+
+func TestNew_InvalidOptions(t *testing.T) {
+	var logs bytes.Buffer
+	cases := []struct {
+		name   string
+		opts   []Option
+		option string
+	}{
+		{"bad key", []Option{WithEncryption([]byte("short"))}, "WithEncryption"},
+		{"bad bind addr", []Option{WithBindAddr("nope")}, "WithBindAddr"},
+		{"bad bind port", []Option{WithBindPort(70000)}, "WithBindPort"},
+		{"bad advertise port", []Option{WithAdvertiseAddr("127.0.0.1", -1)}, "WithAdvertiseAddr"},
+		{"long label", []Option{WithLabel(string(make([]byte, LabelMaxSize+1)))}, "WithLabel"},
+		{"no name", []Option{WithName("")}, "WithName"},
+		{"nil config", []Option{WithConfig(nil)}, "WithConfig"},
+		{"logger and output", []Option{
+			WithConfig(&Config{Name: "a", ProtocolVersion: ProtocolVersionMax, LogOutput: &logs}),
+			WithLogger(log.New(&logs, "", 0)),
+		}, "WithLogger"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			m, err := New(c.opts...)
+			require.Nil(t, m)
+
+			var cerr *ConfigError
+			require.True(t, errors.As(err, &cerr), "expected a ConfigError, got %v", err)
+			require.Equal(t, c.option, cerr.Option)
+		})
+	}
+}
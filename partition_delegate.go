@@ -0,0 +1,115 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"time"
+
+	metrics "github.com/hashicorp/go-metrics/compat"
+)
+
+// PartitionDelegate is used to inform a client that a large part of the
+// cluster failed within a short window. That usually means this node has
+// been cut off from the rest, or the cluster has split, rather than that
+// many nodes failed independently, so an application may want to enter a
+// degraded or minority mode instead of failing over.
+type PartitionDelegate interface {
+	// NotifyPossiblePartition is invoked when the fraction of members that
+	// failed within Config.PartitionWindow goes over
+	// Config.PartitionThreshold. It's invoked again only after the
+	// fraction has dropped back below the threshold. It's called on its
+	// own goroutine.
+	NotifyPossiblePartition(stats PartitionStats)
+}
+
+// PartitionStats describes the failures that led to a possible partition.
+type PartitionStats struct {
+	// Members is the number of members, counting this one, that were live
+	// at the start of the window.
+	Members int
+
+	// Failed is the number of those that became suspect or dead within the
+	// window and haven't recovered.
+	Failed int
+
+	// Reachable is the fraction of Members that are still live.
+	Reachable float64
+
+	// Window is the window the failures happened in.
+	Window time.Duration
+}
+
+// minPartitionFailures is the fewest failures that are treated as a possible
+// partition, so that a single failure in a tiny cluster doesn't count.
+const minPartitionFailures = 2
+
+// recordFailure notes that a node has become suspect or dead, and checks
+// whether that looks like a partition. It must be called with nodeLock held
+// for writing.
+func (m *Memberlist) recordFailure(name string) {
+	if m.config.Partition == nil || m.config.PartitionThreshold <= 0 {
+		return
+	}
+	if m.failures == nil {
+		m.failures = make(map[string]time.Time)
+	}
+	if _, ok := m.failures[name]; !ok {
+		m.failures[name] = time.Now()
+	}
+	m.checkPartition()
+}
+
+// recordRecovery forgets a failure once the node is alive again. It must be
+// called with nodeLock held for writing.
+func (m *Memberlist) recordRecovery(name string) {
+	if _, ok := m.failures[name]; !ok {
+		return
+	}
+	delete(m.failures, name)
+	m.checkPartition()
+}
+
+// checkPartition works out the fraction of recent failures and notifies the
+// partition delegate when it first crosses the threshold. It must be called
+// with nodeLock held for writing.
+func (m *Memberlist) checkPartition() {
+	window := m.config.PartitionWindow
+	now := time.Now()
+	for name, at := range m.failures {
+		if now.Sub(at) > window {
+			delete(m.failures, name)
+		}
+	}
+
+	stats := PartitionStats{Window: window}
+	for _, n := range m.nodes {
+		if _, failed := m.failures[n.Name]; failed {
+			stats.Members++
+			stats.Failed++
+		} else if !n.DeadOrLeft() {
+			stats.Members++
+		}
+	}
+	if stats.Members == 0 {
+		return
+	}
+	stats.Reachable = float64(stats.Members-stats.Failed) / float64(stats.Members)
+
+	over := stats.Failed >= minPartitionFailures &&
+		float64(stats.Failed)/float64(stats.Members) > m.config.PartitionThreshold
+	if !over {
+		m.partitioned = false
+		return
+	}
+	if m.partitioned {
+		return
+	}
+	m.partitioned = true
+
+	m.logger.Printf("[WARN] memberlist: Possible partition, %d of %d members failed within %v",
+		stats.Failed, stats.Members, window)
+	metrics.IncrCounterWithLabels([]string{"memberlist", "partition", "suspected"}, 1, m.metricLabels)
+	d := m.config.Partition
+	go m.guard("NotifyPossiblePartition", func() { d.NotifyPossiblePartition(stats) })
+}
@@ -0,0 +1,81 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type mockPartitionDelegate struct {
+	ch chan PartitionStats
+}
+
+func (d *mockPartitionDelegate) NotifyPossiblePartition(stats PartitionStats) {
+	d.ch <- stats
+}
+
+func TestMemberlist_PossiblePartition(t *testing.T) {
+	d := &mockPartitionDelegate{ch: make(chan PartitionStats, 10)}
+	m := GetMemberlist(t, func(c *Config) {
+		c.Partition = d
+		c.PartitionThreshold = 0.3
+		c.PartitionWindow = time.Minute
+	})
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	for i := 0; i < 6; i++ {
+		a := alive{Node: fmt.Sprintf("node-%d", i), Addr: []byte{127, 0, 0, byte(i + 1)}, Incarnation: 1, Vsn: m.config.BuildVsnArray()}
+		m.aliveNode(&a, nil, false)
+	}
+	suspect := func(i int, inc uint32) {
+		s := suspect{Node: fmt.Sprintf("node-%d", i), Incarnation: inc, From: m.config.Name}
+		m.suspectNode(&s)
+	}
+	expectNone := func() {
+		select {
+		case stats := <-d.ch:
+			t.Fatalf("unexpected notification: %+v", stats)
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+	expectOne := func() PartitionStats {
+		select {
+		case stats := <-d.ch:
+			return stats
+		case <-time.After(time.Second):
+			t.Fatal("expected a notification")
+		}
+		return PartitionStats{}
+	}
+
+	// A single failure isn't a partition.
+	suspect(0, 1)
+	expectNone()
+
+	// Two out of six is over the threshold.
+	suspect(1, 1)
+	stats := expectOne()
+	require.Equal(t, PartitionStats{Members: 6, Failed: 2, Reachable: 4.0 / 6, Window: time.Minute}, stats)
+
+	// More failures don't notify again.
+	m.deadNode(&dead{Node: "node-2", Incarnation: 1, From: m.config.Name})
+	expectNone()
+
+	// Once nodes recover and fail again, we notify again.
+	for i := 0; i < 3; i++ {
+		a := alive{Node: fmt.Sprintf("node-%d", i), Addr: []byte{127, 0, 0, byte(i + 1)}, Incarnation: 2, Vsn: m.config.BuildVsnArray()}
+		m.aliveNode(&a, nil, false)
+	}
+	expectNone()
+	suspect(3, 1)
+	suspect(4, 1)
+	stats = expectOne()
+	require.Equal(t, 2, stats.Failed)
+}
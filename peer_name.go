@@ -0,0 +1,35 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"fmt"
+
+	metrics "github.com/hashicorp/go-metrics/compat"
+)
+
+// ErrNodeNameMismatch is returned by a push/pull, including the ones Join
+// does, when the node at the other end of the stream isn't the one we meant
+// to reach. That usually means the address was misrouted, or it came from a
+// stale DNS record and now belongs to another node.
+type ErrNodeNameMismatch struct {
+	Expected string // Name we meant to reach
+	Actual   string // Name of the node we reached
+}
+
+func (e ErrNodeNameMismatch) Error() string {
+	return fmt.Sprintf("expected to reach node %q but reached %q", e.Expected, e.Actual)
+}
+
+// checkPeerName checks the name a peer claims against the one we expect it to
+// have. Either may be empty, if we didn't know the name to expect, or the
+// peer runs a version of memberlist that doesn't send it, and then there's
+// nothing to check.
+func (m *Memberlist) checkPeerName(expected, actual string) error {
+	if expected == "" || actual == "" || expected == actual {
+		return nil
+	}
+	metrics.IncrCounterWithLabels([]string{"memberlist", "pushpull", "name_mismatch"}, 1, m.metricLabels)
+	return ErrNodeNameMismatch{Expected: expected, Actual: actual}
+}
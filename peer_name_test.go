@@ -0,0 +1,52 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberlist_PushPull_NameMismatch(t *testing.T) {
+	m1 := GetMemberlist(t, func(c *Config) {
+		c.DeltaPushPull = true
+	})
+	defer func() {
+		require.NoError(t, m1.Shutdown())
+	}()
+	require.NoError(t, m1.setAlive())
+	m1.schedule()
+
+	m2 := GetMemberlist(t, func(c *Config) {
+		c.DeltaPushPull = true
+	})
+	defer func() {
+		require.NoError(t, m2.Shutdown())
+	}()
+	require.NoError(t, m2.setAlive())
+	m2.schedule()
+
+	addr := net.JoinHostPort(m1.config.BindAddr, strconv.Itoa(m1.config.BindPort))
+	_, err := m2.Join([]string{"someone-else/" + addr})
+	var mismatch ErrNodeNameMismatch
+	require.True(t, errors.As(err, &mismatch), "%v", err)
+	require.Equal(t, ErrNodeNameMismatch{Expected: "someone-else", Actual: m1.config.Name}, mismatch)
+
+	// Neither side took the other's state.
+	require.Equal(t, 1, m1.NumMembers())
+	require.Equal(t, 1, m2.NumMembers())
+
+	// The same goes for a delta push/pull.
+	err = m2.deltaPushPull(Address{Addr: addr, Name: "someone-else"}, nil)
+	require.True(t, errors.As(err, &mismatch), "%v", err)
+
+	// With the right name it works.
+	_, err = m2.Join([]string{m1.config.Name + "/" + addr})
+	require.NoError(t, err)
+	require.Equal(t, 2, m2.NumMembers())
+}
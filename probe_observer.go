@@ -0,0 +1,77 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import "time"
+
+// ProbeObserver is told the outcome of each way memberlist tries to reach a
+// member it probes, so other systems can build latency maps or export
+// per-link SLIs. Unlike PingDelegate it hears about failures, indirect
+// pings and TCP fallbacks too. NotifyProbe is invoked on the goroutine that
+// made the attempt, so it must be fast and must not block.
+type ProbeObserver interface {
+	NotifyProbe(o ProbeOutcome)
+}
+
+// ProbeMethod is a way of probing a member.
+type ProbeMethod uint8
+
+const (
+	// ProbeDirect is a UDP ping sent straight to the member, at its
+	// primary address or any other it advertises.
+	ProbeDirect ProbeMethod = iota
+
+	// ProbeIndirect is a ping other members were asked to make on our
+	// behalf, after a direct ping went unanswered.
+	ProbeIndirect
+
+	// ProbeTCP is the ping sent straight to the member over TCP, after a
+	// direct ping went unanswered.
+	ProbeTCP
+)
+
+// String returns the name of the method.
+func (pm ProbeMethod) String() string {
+	switch pm {
+	case ProbeDirect:
+		return "direct"
+	case ProbeIndirect:
+		return "indirect"
+	case ProbeTCP:
+		return "tcp"
+	default:
+		return "unknown"
+	}
+}
+
+// ProbeOutcome describes the outcome of one attempt to reach a member.
+type ProbeOutcome struct {
+	// Node is the member probed, and Addr the address the attempt went
+	// to.
+	Node string
+	Addr string
+
+	// Method is how the member was probed. For indirect probes, Via are
+	// the members that were asked to ping it.
+	Method ProbeMethod
+	Via    []string
+
+	// Start is when the attempt was made.
+	Start time.Time
+
+	// Success is true if the member answered, and RTT is then how long
+	// it took.
+	Success bool
+	RTT     time.Duration
+}
+
+// observeProbe reports a probe attempt to the probe observer, if there is
+// one.
+func (m *Memberlist) observeProbe(o ProbeOutcome) {
+	po := m.config.ProbeObserver
+	if po == nil {
+		return
+	}
+	m.guard("NotifyProbe", func() { po.NotifyProbe(o) })
+}
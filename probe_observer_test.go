@@ -0,0 +1,130 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// probeRecorder is a ProbeObserver that keeps everything it's told.
+type probeRecorder struct {
+	lock     sync.Mutex
+	outcomes []ProbeOutcome
+}
+
+func (r *probeRecorder) NotifyProbe(o ProbeOutcome) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.outcomes = append(r.outcomes, o)
+}
+
+func (r *probeRecorder) reset() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.outcomes = nil
+}
+
+func (r *probeRecorder) get() []ProbeOutcome {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]ProbeOutcome(nil), r.outcomes...)
+}
+
+func TestMemberlist_ProbeObserver_Direct(t *testing.T) {
+	addr1 := getBindAddr()
+	addr2 := getBindAddr()
+
+	obs := &probeRecorder{}
+	m1 := HostMemberlist(addr1.String(), t, func(c *Config) {
+		c.ProbeTimeout = 100 * time.Millisecond
+		c.ProbeInterval = 200 * time.Millisecond
+		c.ProbeObserver = obs
+	})
+	defer m1.Shutdown()
+
+	bindPort := m1.config.BindPort
+	m2 := HostMemberlist(addr2.String(), t, func(c *Config) {
+		c.BindPort = bindPort
+	})
+	defer m2.Shutdown()
+
+	a1 := alive{Node: addr1.String(), Addr: addr1, Port: uint16(bindPort), Incarnation: 1}
+	m1.aliveNode(&a1, nil, true)
+	a2 := alive{Node: addr2.String(), Addr: addr2, Port: uint16(bindPort), Incarnation: 1}
+	m1.aliveNode(&a2, nil, false)
+
+	start := time.Now()
+	m1.probeNode(m1.nodeMap[addr2.String()])
+
+	outcomes := obs.get()
+	require.Len(t, outcomes, 1)
+	o := outcomes[0]
+	require.Equal(t, addr2.String(), o.Node)
+	require.Equal(t, joinHostPort(addr2.String(), uint16(bindPort)), o.Addr)
+	require.Equal(t, ProbeDirect, o.Method)
+	require.True(t, o.Success)
+	require.Positive(t, o.RTT)
+	require.Less(t, o.RTT, time.Since(start))
+	require.False(t, o.Start.Before(start))
+}
+
+func TestMemberlist_ProbeObserver_Failed(t *testing.T) {
+	addr1 := getBindAddr()
+	addr2 := getBindAddr()
+	addr3 := getBindAddr()
+
+	obs := &probeRecorder{}
+	m1 := HostMemberlist(addr1.String(), t, func(c *Config) {
+		c.ProbeTimeout = 10 * time.Millisecond
+		c.ProbeInterval = 200 * time.Millisecond
+		c.ProbeObserver = obs
+	})
+	defer m1.Shutdown()
+
+	bindPort := m1.config.BindPort
+	m3 := HostMemberlist(addr3.String(), t, func(c *Config) {
+		c.BindPort = bindPort
+	})
+	defer m3.Shutdown()
+
+	// Nothing is listening at addr2, which can be pinged over TCP too.
+	vsn := m1.config.BuildVsnArray()
+	a1 := alive{Node: addr1.String(), Addr: addr1, Port: uint16(bindPort), Incarnation: 1, Vsn: vsn}
+	m1.aliveNode(&a1, nil, true)
+	a2 := alive{Node: addr2.String(), Addr: addr2, Port: uint16(bindPort), Incarnation: 1, Vsn: vsn}
+	m1.aliveNode(&a2, nil, false)
+	a3 := alive{Node: addr3.String(), Addr: addr3, Port: uint16(bindPort), Incarnation: 1, Vsn: vsn}
+	m1.aliveNode(&a3, nil, false)
+
+	// The members asked to relay are picked at random, so m3 can be missed.
+	var outcomes []ProbeOutcome
+	for i := 0; i < 10 && len(outcomes) < 3; i++ {
+		obs.reset()
+		m1.probeNode(m1.nodeMap[addr2.String()])
+		outcomes = obs.get()
+	}
+	methods := make([]ProbeMethod, 0, len(outcomes))
+	for _, o := range outcomes {
+		require.Equal(t, addr2.String(), o.Node)
+		require.False(t, o.Success, "%v", o.Method)
+		require.Zero(t, o.RTT)
+		if o.Method == ProbeIndirect {
+			require.Equal(t, []string{addr3.String()}, o.Via)
+		}
+		methods = append(methods, o.Method)
+	}
+	require.ElementsMatch(t, []ProbeMethod{ProbeDirect, ProbeIndirect, ProbeTCP}, methods)
+	require.Equal(t, ProbeDirect, methods[0])
+}
+
+func TestProbeMethod_String(t *testing.T) {
+	require.Equal(t, "direct", ProbeDirect.String())
+	require.Equal(t, "indirect", ProbeIndirect.String())
+	require.Equal(t, "tcp", ProbeTCP.String())
+	require.Equal(t, "unknown", ProbeMethod(9).String())
+}
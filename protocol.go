@@ -0,0 +1,152 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ProtocolFeature is a part of the protocol that's only used with members
+// that understand the protocol version that added it. During a rolling
+// upgrade, each feature is used with the members that support it, and the
+// rest are spoken to the old way.
+type ProtocolFeature uint8
+
+const (
+	// FeatureTCPPing is the fallback ping over a stream connection.
+	FeatureTCPPing ProtocolFeature = iota
+
+	// FeatureNack is the nack sent back for a failed indirect ping.
+	FeatureNack
+
+	// FeatureChecksum is the CRC added to packets.
+	FeatureChecksum
+
+	// FeatureDeltaPushPull is delta push/pull and anti-entropy summaries.
+	FeatureDeltaPushPull
+
+	// FeatureProtobuf is the protocol buffer wire codec.
+	FeatureProtobuf
+
+	// FeatureIncarnationWrap is comparing incarnation numbers with
+	// wrap-around arithmetic.
+	FeatureIncarnationWrap
+
+	// FeatureGossipDigest is the digest added to gossip, see
+	// Config.DigestRepairThreshold.
+	FeatureGossipDigest
+)
+
+// protocolFeatures lists every feature, and the protocol version that
+// added it.
+var protocolFeatures = []struct {
+	feature ProtocolFeature
+	name    string
+	version uint8
+}{
+	{FeatureTCPPing, "tcp-ping", 3},
+	{FeatureNack, "nack", 4},
+	{FeatureChecksum, "checksum", 5},
+	{FeatureDeltaPushPull, "delta-push-pull", 6},
+	{FeatureProtobuf, "protobuf", 7},
+	{FeatureIncarnationWrap, "incarnation-wrap", 8},
+	{FeatureGossipDigest, "gossip-digest", 9},
+}
+
+// Version returns the protocol version that added the feature.
+func (f ProtocolFeature) Version() uint8 {
+	if int(f) < len(protocolFeatures) {
+		return protocolFeatures[f].version
+	}
+	return 0
+}
+
+// String returns the name of the feature.
+func (f ProtocolFeature) String() string {
+	if int(f) < len(protocolFeatures) {
+		return protocolFeatures[f].name
+	}
+	return fmt.Sprintf("unknown(%d)", uint8(f))
+}
+
+// Supports returns true if the node understands a protocol feature.
+func (n *Node) Supports(f ProtocolFeature) bool {
+	v := f.Version()
+	return v != 0 && n.PMax >= v
+}
+
+// ProtocolSummary describes the protocol versions spoken across the live
+// members, to follow a rolling upgrade.
+type ProtocolSummary struct {
+	// Current is the protocol version we speak.
+	Current uint8
+
+	// Floor is the highest protocol version every live member understands,
+	// which is the lowest maximum version any of them advertises. Features
+	// up to this version are used with every member.
+	Floor uint8
+
+	// Ceiling is the lowest protocol version every live member understands,
+	// which is the highest minimum version any of them advertises.
+	Ceiling uint8
+
+	// Features are the features every live member supports.
+	Features []ProtocolFeature
+
+	// Versions counts the live members by the maximum protocol version they
+	// understand.
+	Versions map[uint8]int
+
+	// Behind are the live members that don't understand our maximum
+	// protocol version, sorted by name.
+	Behind []string
+}
+
+// ProtocolSummary returns the protocol versions spoken across the live
+// members, including us.
+func (m *Memberlist) ProtocolSummary() ProtocolSummary {
+	s := ProtocolSummary{
+		Current:  m.ProtocolVersion(),
+		Floor:    ProtocolVersionMax,
+		Ceiling:  ProtocolVersionMin,
+		Versions: make(map[uint8]int),
+	}
+
+	m.nodeLock.RLock()
+	for _, n := range m.nodes {
+		if n.State != StateAlive {
+			continue
+		}
+		if n.PMax < s.Floor {
+			s.Floor = n.PMax
+		}
+		if n.PMin > s.Ceiling {
+			s.Ceiling = n.PMin
+		}
+		s.Versions[n.PMax]++
+		if n.PMax < ProtocolVersionMax {
+			s.Behind = append(s.Behind, n.Name)
+		}
+	}
+	m.nodeLock.RUnlock()
+
+	for _, f := range protocolFeatures {
+		if f.version <= s.Floor {
+			s.Features = append(s.Features, f.feature)
+		}
+	}
+	sort.Strings(s.Behind)
+	return s
+}
+
+// canSpeak returns an error if a node's advertised protocol range doesn't
+// overlap with ours, so we couldn't talk to it.
+func canSpeak(pmin, pmax uint8) error {
+	if pmin > ProtocolVersionMax || pmax < ProtocolVersionMin {
+		return fmt.Errorf("protocol versions [%d, %d] don't overlap with ours [%d, %d]",
+			pmin, pmax, ProtocolVersionMin, ProtocolVersionMax)
+	}
+	return nil
+}
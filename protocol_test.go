@@ -0,0 +1,60 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProtocolFeature_Supports(t *testing.T) {
+	n := &Node{PMin: 1, PMax: 5}
+	require.True(t, n.Supports(FeatureTCPPing))
+	require.True(t, n.Supports(FeatureChecksum))
+	require.False(t, n.Supports(FeatureDeltaPushPull))
+	require.False(t, n.Supports(FeatureIncarnationWrap))
+	require.False(t, n.Supports(ProtocolFeature(200)))
+
+	require.Equal(t, uint8(7), FeatureProtobuf.Version())
+	require.Equal(t, "protobuf", FeatureProtobuf.String())
+	require.Equal(t, "unknown(200)", ProtocolFeature(200).String())
+	require.Equal(t, uint8(8), FeatureIncarnationWrap.Version())
+	require.Equal(t, uint8(ProtocolVersionMax), FeatureGossipDigest.Version())
+}
+
+func TestMemberlist_ProtocolSummary(t *testing.T) {
+	m := GetMemberlist(t, nil)
+	defer m.Shutdown()
+
+	vsn := m.config.BuildVsnArray()
+	old := []uint8{2, 5, 5, vsn[3], vsn[4], vsn[5]}
+	m.aliveNode(&alive{Node: "new", Addr: []byte{127, 0, 0, 1}, Incarnation: 1, Vsn: vsn}, nil, false)
+	m.aliveNode(&alive{Node: "old", Addr: []byte{127, 0, 0, 2}, Incarnation: 1, Vsn: old}, nil, false)
+
+	s := m.ProtocolSummary()
+	require.Equal(t, m.ProtocolVersion(), s.Current)
+	require.Equal(t, uint8(5), s.Floor)
+	require.Equal(t, uint8(2), s.Ceiling)
+	require.Equal(t, []ProtocolFeature{FeatureTCPPing, FeatureNack, FeatureChecksum}, s.Features)
+	require.Equal(t, map[uint8]int{5: 1, ProtocolVersionMax: 1}, s.Versions)
+	require.Equal(t, []string{"old"}, s.Behind)
+
+	// Once the old node is gone the whole feature set is in use
+	m.deadNode(&dead{Node: "old", From: "new", Incarnation: 1})
+	s = m.ProtocolSummary()
+	require.Equal(t, uint8(ProtocolVersionMax), s.Floor)
+	require.Len(t, s.Features, len(protocolFeatures))
+	require.Empty(t, s.Behind)
+}
+
+func TestMemberlist_AliveNode_IncompatibleProtocol(t *testing.T) {
+	m := GetMemberlist(t, nil)
+	defer m.Shutdown()
+
+	vsn := m.config.BuildVsnArray()
+	future := []uint8{ProtocolVersionMax + 1, ProtocolVersionMax + 2, ProtocolVersionMax + 1, vsn[3], vsn[4], vsn[5]}
+	m.aliveNode(&alive{Node: "future", Addr: []byte{127, 0, 0, 1}, Incarnation: 1, Vsn: future}, nil, false)
+	require.False(t, m.knownNode("future"))
+}
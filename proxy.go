@@ -0,0 +1,112 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// proxyDialer opens stream connections through a proxy.
+type proxyDialer interface {
+	DialContext(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+// newProxyDialer returns a dialer for the given proxy URL. Supported schemes
+// are socks5 (and socks5h) and http, which uses the CONNECT method. Both
+// accept credentials in the user info part of the URL.
+func newProxyDialer(rawURL string) (proxyDialer, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dial proxy %q: %v", rawURL, err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid dial proxy %q: missing host", rawURL)
+	}
+
+	switch u.Scheme {
+	case "socks5", "socks5h":
+		d, err := proxy.FromURL(u, &net.Dialer{})
+		if err != nil {
+			return nil, fmt.Errorf("invalid dial proxy %q: %v", rawURL, err)
+		}
+		cd, ok := d.(proxy.ContextDialer)
+		if !ok {
+			return nil, fmt.Errorf("invalid dial proxy %q: dialer doesn't support contexts", rawURL)
+		}
+		return cd, nil
+
+	case "http":
+		return &httpConnectDialer{addr: u.Host, user: u.User}, nil
+
+	default:
+		return nil, fmt.Errorf("invalid dial proxy %q: unsupported scheme %q", rawURL, u.Scheme)
+	}
+}
+
+// httpConnectDialer tunnels connections through an HTTP proxy using the
+// CONNECT method.
+type httpConnectDialer struct {
+	addr string
+	user *url.Userinfo
+}
+
+// DialContext connects to the proxy and asks it to open a tunnel to addr.
+func (d *httpConnectDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, d.addr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make sure the handshake honors the context deadline.
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if d.user != nil {
+		pass, _ := d.user.Password()
+		creds := base64.StdEncoding.EncodeToString([]byte(d.user.Username() + ":" + pass))
+		req.Header.Set("Proxy-Authorization", "Basic "+creds)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to send CONNECT to proxy %s: %v", d.addr, err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read CONNECT response from proxy %s: %v", d.addr, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s refused CONNECT to %s: %s", d.addr, addr, resp.Status)
+	}
+
+	_ = conn.SetDeadline(time.Time{})
+
+	// Hang on to anything the proxy sent past the response.
+	if n := br.Buffered(); n > 0 {
+		peeked, _ := br.Peek(n)
+		return &peekedConn{Peeked: peeked, Conn: conn}, nil
+	}
+	return conn, nil
+}
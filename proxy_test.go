@@ -0,0 +1,118 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// startConnectProxy runs a minimal HTTP CONNECT proxy and returns its address
+// along with a channel that receives the Proxy-Authorization header of every
+// request.
+func startConnectProxy(t *testing.T) (string, <-chan string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	authCh := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				req, err := http.ReadRequest(bufio.NewReader(conn))
+				if err != nil || req.Method != http.MethodConnect {
+					return
+				}
+				authCh <- req.Header.Get("Proxy-Authorization")
+
+				target, err := net.Dial("tcp", req.Host)
+				if err != nil {
+					io.WriteString(conn, "HTTP/1.1 502 Bad Gateway\r\n\r\n")
+					return
+				}
+				defer target.Close()
+				io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+				go io.Copy(target, conn)
+				io.Copy(conn, target)
+			}(conn)
+		}
+	}()
+	return ln.Addr().String(), authCh
+}
+
+func TestNetTransport_DialProxy_HTTPConnect(t *testing.T) {
+	proxyAddr, authCh := startConnectProxy(t)
+
+	// An echo server standing in for a remote node.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.Copy(conn, conn)
+	}()
+
+	trans, err := NewNetTransport(&NetTransportConfig{
+		BindAddrs: []string{"127.0.0.1"},
+		DialProxy: "http://user:secret@" + proxyAddr,
+	})
+	require.NoError(t, err)
+	defer trans.Shutdown()
+
+	conn, err := trans.DialTimeout(ln.Addr().String(), time.Second)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.Equal(t, "Basic dXNlcjpzZWNyZXQ=", <-authCh)
+
+	_, err = conn.Write([]byte("hello"))
+	require.NoError(t, err)
+	buf := make([]byte, 5)
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(buf))
+}
+
+func TestNetTransport_DialProxy_Refused(t *testing.T) {
+	proxyAddr, _ := startConnectProxy(t)
+
+	// Grab a port that nothing listens on.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	d, err := newProxyDialer("http://" + proxyAddr)
+	require.NoError(t, err)
+
+	trans := &NetTransport{proxy: d}
+	_, err = trans.DialTimeout(addr, time.Second)
+	require.ErrorContains(t, err, "502")
+}
+
+func TestNewProxyDialer(t *testing.T) {
+	_, err := newProxyDialer("socks5://127.0.0.1:1080")
+	require.NoError(t, err)
+
+	_, err = newProxyDialer("ftp://127.0.0.1:21")
+	require.ErrorContains(t, err, "unsupported scheme")
+
+	_, err = newProxyDialer("127.0.0.1:1080")
+	require.Error(t, err)
+}
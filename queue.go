@@ -12,8 +12,9 @@ import (
 
 // TransmitLimitedQueue is used to queue messages to broadcast to
 // the cluster (via gossip) but limits the number of transmits per
-// message. It also prioritizes messages with lower transmit counts
-// (hence newer messages).
+// message. It prioritizes messages of a higher BroadcastPriority, and
+// within a priority class messages with lower transmit counts (hence
+// newer messages).
 type TransmitLimitedQueue struct {
 	// NumNodes returns the number of nodes in the cluster. This is
 	// used to determine the retransmit count, which is calculated
@@ -24,16 +25,50 @@ type TransmitLimitedQueue struct {
 	// number of retransmissions attempted.
 	RetransmitMult int
 
+	// OnEvict is an optional callback invoked when Prune drops a broadcast
+	// before it reached its transmit limit. It's called with the queue lock
+	// held, so it must not call back into the queue.
+	OnEvict func(b Broadcast)
+
 	mu    sync.Mutex
 	tq    *btree.BTree // stores *limitedBroadcast as btree.Item
 	tm    map[string]*limitedBroadcast
 	idGen int64
+
+	// Counters backing Stats.
+	dropped   uint64
+	retired   uint64
+	transmits uint64
+	deduped   uint64
+}
+
+// QueueStats is a snapshot of a TransmitLimitedQueue's telemetry.
+type QueueStats struct {
+	// Queued is the number of messages currently queued.
+	Queued int
+
+	// Dropped is the number of messages Prune evicted before they reached
+	// their transmit limit.
+	Dropped uint64
+
+	// Retired is the number of messages that have left the queue for any
+	// reason: transmit limit reached, invalidated, or evicted.
+	Retired uint64
+
+	// AvgTransmits is the average number of times a retired message was
+	// sent before it left the queue.
+	AvgTransmits float64
+
+	// Deduped is the number of messages that weren't queued because the
+	// same message was already queued.
+	Deduped uint64
 }
 
 type limitedBroadcast struct {
-	transmits int   // btree-key[0]: Number of transmissions attempted.
-	msgLen    int64 // btree-key[1]: copied from len(b.Message())
-	id        int64 // btree-key[2]: unique incrementing id stamped at submission time
+	priority  BroadcastPriority // btree-key[0]: copied from b.Priority() if set
+	transmits int               // btree-key[1]: Number of transmissions attempted.
+	msgLen    int64             // btree-key[2]: copied from len(b.Message())
+	id        int64             // btree-key[3]: unique incrementing id stamped at submission time
 	b         Broadcast
 
 	name string // set if Broadcast is a NamedBroadcast
@@ -46,11 +81,17 @@ type limitedBroadcast struct {
 // hold one of either a or b in the tree).
 //
 // default ordering is
+// - [priority=system, ..., priority=normal]
 // - [transmits=0, ..., transmits=inf]
 // - [transmits=0:len=999, ..., transmits=0:len=2, ...]
 // - [transmits=0:len=999,id=999, ..., transmits=0:len=999:id=1, ...]
 func (b *limitedBroadcast) Less(than btree.Item) bool {
 	o := than.(*limitedBroadcast)
+	if b.priority > o.priority {
+		return true
+	} else if b.priority < o.priority {
+		return false
+	}
 	if b.transmits < o.transmits {
 		return true
 	} else if b.transmits > o.transmits {
@@ -92,13 +133,14 @@ func (q *TransmitLimitedQueue) walkReadOnlyLocked(reverse bool, f func(*limitedB
 	iter := func(item btree.Item) bool {
 		cur := item.(*limitedBroadcast)
 
+		prevPriority := cur.priority
 		prevTransmits := cur.transmits
 		prevMsgLen := cur.msgLen
 		prevID := cur.id
 
 		keepGoing := f(cur)
 
-		if prevTransmits != cur.transmits || prevMsgLen != cur.msgLen || prevID != cur.id {
+		if prevPriority != cur.priority || prevTransmits != cur.transmits || prevMsgLen != cur.msgLen || prevID != cur.id {
 			panic("edited queue while walking read only")
 		}
 
@@ -128,6 +170,20 @@ type Broadcast interface {
 	Finished()
 }
 
+// transmitTracker is implemented by broadcasts that want to know whether they
+// were sent the full number of times, as opposed to being invalidated or
+// dropped. The queue calls markTransmitted right before Finished.
+type transmitTracker interface {
+	markTransmitted()
+}
+
+// retransmitMulter is implemented by broadcasts that are retransmitted
+// according to a multiplier of their own, rather than the queue's
+// RetransmitMult. A multiplier of zero means the queue's.
+type retransmitMulter interface {
+	retransmitMult() int
+}
+
 // NamedBroadcast is an optional extension of the Broadcast interface that
 // gives each message a unique string name, and that is used to optimize
 //
@@ -163,6 +219,47 @@ type UniqueBroadcast interface {
 	UniqueBroadcast()
 }
 
+// BroadcastPriority is the class of a broadcast in a TransmitLimitedQueue.
+// Higher classes are always sent before lower ones, so when there isn't room
+// for everything in a packet the lower classes wait.
+type BroadcastPriority int
+
+const (
+	// PriorityNormal is the default for broadcasts that don't implement
+	// PrioritizedBroadcast, such as bulk application data.
+	PriorityNormal BroadcastPriority = iota
+
+	// PriorityHigh is for application messages that should go out ahead of
+	// the normal traffic.
+	PriorityHigh
+
+	// PrioritySystem is used for memberlist's own alive, suspect and dead
+	// messages.
+	PrioritySystem
+)
+
+// String returns the name of the priority class.
+func (p BroadcastPriority) String() string {
+	switch p {
+	case PriorityNormal:
+		return "normal"
+	case PriorityHigh:
+		return "high"
+	case PrioritySystem:
+		return "system"
+	default:
+		return "unknown"
+	}
+}
+
+// PrioritizedBroadcast is an optional extension of the Broadcast interface
+// that puts the message in a priority class other than PriorityNormal.
+type PrioritizedBroadcast interface {
+	Broadcast
+	// Priority returns the class of this broadcast message.
+	Priority() BroadcastPriority
+}
+
 // QueueBroadcast is used to enqueue a broadcast
 func (q *TransmitLimitedQueue) QueueBroadcast(b Broadcast) {
 	q.queueBroadcast(b, 0)
@@ -202,6 +299,14 @@ func (q *TransmitLimitedQueue) queueBroadcast(b Broadcast, initialTransmits int)
 		id:        id,
 		b:         b,
 	}
+	if pb, ok := b.(PrioritizedBroadcast); ok {
+		lb.priority = pb.Priority()
+		if lb.priority < PriorityNormal {
+			lb.priority = PriorityNormal
+		} else if lb.priority > PrioritySystem {
+			lb.priority = PrioritySystem
+		}
+	}
 	unique := false
 	if nb, ok := b.(NamedBroadcast); ok {
 		lb.name = nb.Name()
@@ -212,8 +317,16 @@ func (q *TransmitLimitedQueue) queueBroadcast(b Broadcast, initialTransmits int)
 	// Check if this message invalidates another.
 	if lb.name != "" {
 		if old, ok := q.tm[lb.name]; ok {
+			// If it's the same message, keep the queued one so we don't
+			// start its transmits over.
+			if d, ok := b.(duplicateBroadcast); ok && d.duplicates(old.b) {
+				q.deduped++
+				b.Finished()
+				return
+			}
 			old.b.Finished()
 			q.deleteItem(old)
+			q.retire(old)
 		}
 	} else if !unique {
 		// Slow path, hopefully nothing hot hits this.
@@ -237,6 +350,7 @@ func (q *TransmitLimitedQueue) queueBroadcast(b Broadcast, initialTransmits int)
 		})
 		for _, cur := range remove {
 			q.deleteItem(cur)
+			q.retire(cur)
 		}
 	}
 
@@ -259,6 +373,13 @@ func (q *TransmitLimitedQueue) deleteItem(cur *limitedBroadcast) {
 	}
 }
 
+// retire records that the given item left the queue after being sent
+// cur.transmits times. You must already hold the mutex.
+func (q *TransmitLimitedQueue) retire(cur *limitedBroadcast) {
+	q.retired++
+	q.transmits += uint64(cur.transmits)
+}
+
 // addItem adds the given item into the overall datastructure. You must already
 // hold the mutex.
 func (q *TransmitLimitedQueue) addItem(cur *limitedBroadcast) {
@@ -269,21 +390,41 @@ func (q *TransmitLimitedQueue) addItem(cur *limitedBroadcast) {
 }
 
 // getTransmitRange returns a pair of min/max values for transmit values
-// represented by the current queue contents. Both values represent actual
-// transmit values on the interval [0, len). You must already hold the mutex.
-func (q *TransmitLimitedQueue) getTransmitRange() (minTransmit, maxTransmit int) {
+// represented by the current queue contents of the given priority class. Both
+// values represent actual transmit values on the interval [0, len), and ok is
+// false if the class is empty. You must already hold the mutex.
+func (q *TransmitLimitedQueue) getTransmitRange(priority BroadcastPriority) (minTransmit, maxTransmit int, ok bool) {
 	if q.lenLocked() == 0 {
-		return 0, 0
-	}
-	minItem, maxItem := q.tq.Min(), q.tq.Max()
-	if minItem == nil || maxItem == nil {
-		return 0, 0
+		return 0, 0, false
 	}
 
-	min := minItem.(*limitedBroadcast).transmits
-	max := maxItem.(*limitedBroadcast).transmits
+	// The first item of the class sorts right after this pivot, and the
+	// last one right before the pivot of the next lower class.
+	first := &limitedBroadcast{
+		priority:  priority,
+		transmits: math.MinInt,
+	}
+	var minItem, maxItem *limitedBroadcast
+	q.tq.AscendGreaterOrEqual(first, func(item btree.Item) bool {
+		minItem = item.(*limitedBroadcast)
+		return false
+	})
+	if minItem == nil || minItem.priority != priority {
+		return 0, 0, false
+	}
+	last := &limitedBroadcast{
+		priority:  priority - 1,
+		transmits: math.MinInt,
+	}
+	q.tq.DescendLessOrEqual(last, func(item btree.Item) bool {
+		maxItem = item.(*limitedBroadcast)
+		return false
+	})
+	if maxItem == nil || maxItem.priority != priority {
+		return 0, 0, false
+	}
 
-	return min, max
+	return minItem.transmits, maxItem.transmits, true
 }
 
 // GetBroadcasts is used to get a number of broadcasts, up to a byte limit
@@ -297,7 +438,8 @@ func (q *TransmitLimitedQueue) GetBroadcasts(overhead, limit int) [][]byte {
 		return nil
 	}
 
-	transmitLimit := retransmitLimit(q.RetransmitMult, q.NumNodes())
+	numNodes := q.NumNodes()
+	transmitLimit := retransmitLimit(q.RetransmitMult, numNodes)
 
 	var (
 		bytesUsed int
@@ -305,66 +447,84 @@ func (q *TransmitLimitedQueue) GetBroadcasts(overhead, limit int) [][]byte {
 		reinsert  []*limitedBroadcast
 	)
 
-	// Visit fresher items first, but only look at stuff that will fit.
-	// We'll go tier by tier, grabbing the largest items first.
-	minTr, maxTr := q.getTransmitRange()
-	for transmits := minTr; transmits <= maxTr; /*do not advance automatically*/ {
-		free := int64(limit - bytesUsed - overhead)
-		if free <= 0 {
-			break // bail out early
+	// Drain the higher priority classes first. Within a class, visit
+	// fresher items first, but only look at stuff that will fit. We'll go
+	// tier by tier, grabbing the largest items first.
+drain:
+	for priority := PrioritySystem; priority >= PriorityNormal; priority-- {
+		minTr, maxTr, ok := q.getTransmitRange(priority)
+		if !ok {
+			continue
 		}
+		for transmits := minTr; transmits <= maxTr; /*do not advance automatically*/ {
+			free := int64(limit - bytesUsed - overhead)
+			if free <= 0 {
+				break drain // bail out early
+			}
 
-		// Search for the least element on a given tier (by transmit count) as
-		// defined in the limitedBroadcast.Less function that will fit into our
-		// remaining space.
-		greaterOrEqual := &limitedBroadcast{
-			transmits: transmits,
-			msgLen:    free,
-			id:        math.MaxInt64,
-		}
-		lessThan := &limitedBroadcast{
-			transmits: transmits + 1,
-			msgLen:    math.MaxInt64,
-			id:        math.MaxInt64,
-		}
-		var keep *limitedBroadcast
-		q.tq.AscendRange(greaterOrEqual, lessThan, func(item btree.Item) bool {
-			cur := item.(*limitedBroadcast)
-			// Check if this is within our limits
-			if int64(len(cur.b.Message())) > free {
-				// If this happens it's a bug in the datastructure or
-				// surrounding use doing something like having len(Message())
-				// change over time. There's enough going on here that it's
-				// probably sane to just skip it and move on for now.
-				return true
+			// Search for the least element on a given tier (by transmit count) as
+			// defined in the limitedBroadcast.Less function that will fit into our
+			// remaining space.
+			greaterOrEqual := &limitedBroadcast{
+				priority:  priority,
+				transmits: transmits,
+				msgLen:    free,
+				id:        math.MaxInt64,
 			}
-			keep = cur
-			return false
-		})
-		if keep == nil {
-			// No more items of an appropriate size in the tier.
-			transmits++
-			continue
-		}
+			lessThan := &limitedBroadcast{
+				priority:  priority,
+				transmits: transmits + 1,
+				msgLen:    math.MaxInt64,
+				id:        math.MaxInt64,
+			}
+			var keep *limitedBroadcast
+			q.tq.AscendRange(greaterOrEqual, lessThan, func(item btree.Item) bool {
+				cur := item.(*limitedBroadcast)
+				// Check if this is within our limits
+				if int64(len(cur.b.Message())) > free {
+					// If this happens it's a bug in the datastructure or
+					// surrounding use doing something like having len(Message())
+					// change over time. There's enough going on here that it's
+					// probably sane to just skip it and move on for now.
+					return true
+				}
+				keep = cur
+				return false
+			})
+			if keep == nil {
+				// No more items of an appropriate size in the tier.
+				transmits++
+				continue
+			}
+
+			msg := keep.b.Message()
 
-		msg := keep.b.Message()
-
-		// Add to slice to send
-		bytesUsed += overhead + len(msg)
-		toSend = append(toSend, msg)
-
-		// Check if we should stop transmission
-		q.deleteItem(keep)
-		if keep.transmits+1 >= transmitLimit {
-			keep.b.Finished()
-		} else {
-			// We need to bump this item down to another transmit tier, but
-			// because it would be in the same direction that we're walking the
-			// tiers, we will have to delay the reinsertion until we are
-			// finished our search. Otherwise we'll possibly re-add the message
-			// when we ascend to the next tier.
-			keep.transmits++
-			reinsert = append(reinsert, keep)
+			// Add to slice to send
+			bytesUsed += overhead + len(msg)
+			toSend = append(toSend, msg)
+
+			// Check if we should stop transmission
+			q.deleteItem(keep)
+			limit := transmitLimit
+			if rm, ok := keep.b.(retransmitMulter); ok && rm.retransmitMult() > 0 {
+				limit = retransmitLimit(rm.retransmitMult(), numNodes)
+			}
+			if keep.transmits+1 >= limit {
+				if t, ok := keep.b.(transmitTracker); ok {
+					t.markTransmitted()
+				}
+				keep.b.Finished()
+				keep.transmits++
+				q.retire(keep)
+			} else {
+				// We need to bump this item down to another transmit tier, but
+				// because it would be in the same direction that we're walking the
+				// tiers, we will have to delay the reinsertion until we are
+				// finished our search. Otherwise we'll possibly re-add the message
+				// when we ascend to the next tier.
+				keep.transmits++
+				reinsert = append(reinsert, keep)
+			}
 		}
 	}
 
@@ -375,6 +535,20 @@ func (q *TransmitLimitedQueue) GetBroadcasts(overhead, limit int) [][]byte {
 	return toSend
 }
 
+// setRetransmitMult changes RetransmitMult while the queue is in use.
+func (q *TransmitLimitedQueue) setRetransmitMult(mult int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.RetransmitMult = mult
+}
+
+// retransmitMult returns RetransmitMult while the queue is in use.
+func (q *TransmitLimitedQueue) retransmitMult() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.RetransmitMult
+}
+
 // NumQueued returns the number of queued messages
 func (q *TransmitLimitedQueue) NumQueued() int {
 	q.mu.Lock()
@@ -382,6 +556,23 @@ func (q *TransmitLimitedQueue) NumQueued() int {
 	return q.lenLocked()
 }
 
+// Stats returns a snapshot of the queue's telemetry.
+func (q *TransmitLimitedQueue) Stats() QueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats := QueueStats{
+		Queued:  q.lenLocked(),
+		Dropped: q.dropped,
+		Retired: q.retired,
+		Deduped: q.deduped,
+	}
+	if q.retired > 0 {
+		stats.AvgTransmits = float64(q.transmits) / float64(q.retired)
+	}
+	return stats
+}
+
 // lenLocked returns the length of the overall queue datastructure. You must
 // hold the mutex.
 func (q *TransmitLimitedQueue) lenLocked() int {
@@ -421,5 +612,10 @@ func (q *TransmitLimitedQueue) Prune(maxRetain int) {
 		cur := item.(*limitedBroadcast)
 		cur.b.Finished()
 		q.deleteItem(cur)
+		q.retire(cur)
+		q.dropped++
+		if q.OnEvict != nil {
+			q.OnEvict(cur.b)
+		}
 	}
 }
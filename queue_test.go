@@ -26,6 +26,11 @@ func TestLimitedBroadcastLess(t *testing.T) {
 			&limitedBroadcast{transmits: 0, msgLen: 12, id: 100},
 			&limitedBroadcast{transmits: 0, msgLen: 10, id: 100},
 		},
+		{
+			"diff-priority",
+			&limitedBroadcast{priority: PrioritySystem, transmits: 5, msgLen: 10, id: 100},
+			&limitedBroadcast{priority: PriorityNormal, transmits: 0, msgLen: 10, id: 100},
+		},
 		{
 			"same-transmits--same-len--diff-id",
 			&limitedBroadcast{transmits: 0, msgLen: 12, id: 100},
@@ -78,7 +83,7 @@ func TestTransmitLimited_Queue(t *testing.T) {
 	}
 
 	// Should invalidate previous message
-	q.QueueBroadcast(&memberlistBroadcast{"test", nil, nil})
+	q.QueueBroadcast(&memberlistBroadcast{"test", []byte("newer"), nil})
 
 	if q.NumQueued() != 3 {
 		t.Fatalf("bad len")
@@ -151,6 +156,75 @@ func TestTransmitLimited_GetBroadcasts_Limit(t *testing.T) {
 	require.Equal(t, int64(0), q.idGen, "id generator resets on empty")
 }
 
+func TestTransmitLimited_GetBroadcasts_RetransmitMult(t *testing.T) {
+	q := &TransmitLimitedQueue{RetransmitMult: 1, NumNodes: func() int { return 10 }}
+	require.Equal(t, 2, retransmitLimit(1, 10), "sanity check transmit limits")
+	require.Equal(t, 6, retransmitLimit(3, 10), "sanity check transmit limits")
+
+	q.QueueBroadcast(&memberlistBroadcast{"member", []byte("member"), nil})
+	q.QueueBroadcast(&userBroadcast{memberlistBroadcast: memberlistBroadcast{"user", []byte("user"), nil}, mult: 3})
+	q.QueueBroadcast(&userBroadcast{memberlistBroadcast: memberlistBroadcast{"default", []byte("default"), nil}})
+
+	sent := make(map[string]int)
+	for i := 0; i < 10; i++ {
+		for _, msg := range q.GetBroadcasts(0, 1000) {
+			sent[string(msg)]++
+		}
+	}
+	require.Equal(t, map[string]int{"member": 2, "user": 6, "default": 2}, sent)
+}
+
+// priorityBroadcast is a user broadcast in a given priority class.
+type priorityBroadcast struct {
+	msg      string
+	priority BroadcastPriority
+}
+
+func (b *priorityBroadcast) Invalidates(other Broadcast) bool { return false }
+func (b *priorityBroadcast) Message() []byte                  { return []byte(b.msg) }
+func (b *priorityBroadcast) Finished()                        {}
+func (b *priorityBroadcast) Priority() BroadcastPriority      { return b.priority }
+func (b *priorityBroadcast) UniqueBroadcast()                 {}
+
+func TestTransmitLimited_GetBroadcasts_Priority(t *testing.T) {
+	q := &TransmitLimitedQueue{RetransmitMult: 3, NumNodes: func() int { return 10 }}
+
+	// The bulk data is queued first and is larger than the 18 byte
+	// messages, so it would win without the priority classes.
+	q.QueueBroadcast(&priorityBroadcast{"bulk application data!", PriorityNormal})
+	q.QueueBroadcast(&priorityBroadcast{"1. this is a test.", PriorityHigh})
+	q.QueueBroadcast(&memberlistBroadcast{"test", []byte("2. this is a test."), nil})
+
+	// Room for two messages only.
+	msgs := q.GetBroadcasts(2, 40)
+	require.Equal(t, []string{"'2. this is a test.'", "'1. this is a test.'"}, prettyPrintMessages(msgs))
+
+	// A system message that has been sent more often still goes ahead of
+	// a fresh normal one.
+	msgs = q.GetBroadcasts(2, 20)
+	require.Equal(t, []string{"'2. this is a test.'"}, prettyPrintMessages(msgs))
+
+	// Once there's room the normal class gets its turn.
+	msgs = q.GetBroadcasts(2, 100)
+	require.Equal(t, []string{"'2. this is a test.'", "'1. this is a test.'", "'bulk application data!'"}, prettyPrintMessages(msgs))
+}
+
+func TestTransmitLimited_Prune_Priority(t *testing.T) {
+	q := &TransmitLimitedQueue{RetransmitMult: 1, NumNodes: func() int { return 10 }}
+
+	q.QueueBroadcast(&memberlistBroadcast{"test", []byte("1. this is a test."), nil})
+	q.QueueBroadcast(&priorityBroadcast{"2. this is a test.", PriorityNormal})
+	q.QueueBroadcast(&priorityBroadcast{"3. this is a test.", PriorityHigh})
+
+	// The normal class is pruned first even though it's older.
+	q.Prune(2)
+
+	dump := q.orderedView(false)
+	require.Len(t, dump, 2)
+	require.Equal(t, PrioritySystem, dump[0].priority)
+	require.Equal(t, PriorityHigh, dump[1].priority)
+}
+
 func prettyPrintMessages(msgs [][]byte) []string {
 	var out []string
 	for _, msg := range msgs {
@@ -198,6 +272,37 @@ func TestTransmitLimited_Prune(t *testing.T) {
 	}
 }
 
+func TestTransmitLimited_Stats(t *testing.T) {
+	var evicted []string
+	q := &TransmitLimitedQueue{
+		RetransmitMult: 1,
+		NumNodes:       func() int { return 10 },
+		OnEvict: func(b Broadcast) {
+			evicted = append(evicted, b.(*memberlistBroadcast).node)
+		},
+	}
+
+	// 18 bytes per message
+	q.QueueBroadcast(&memberlistBroadcast{"test", []byte("1. this is a test."), nil})
+	q.QueueBroadcast(&memberlistBroadcast{"foo", []byte("2. this is a test."), nil})
+	q.QueueBroadcast(&memberlistBroadcast{"bar", []byte("3. this is a test."), nil})
+	require.Equal(t, QueueStats{Queued: 3}, q.Stats())
+
+	// Invalidating a message retires it without counting it as dropped.
+	q.QueueBroadcast(&memberlistBroadcast{"bar", []byte("4. this is a test."), nil})
+	require.Equal(t, QueueStats{Queued: 3, Retired: 1}, q.Stats())
+
+	// Send each message once, then drop the oldest two.
+	require.Len(t, q.GetBroadcasts(2, 100), 3)
+	q.Prune(1)
+	require.ElementsMatch(t, []string{"test", "foo"}, evicted)
+	require.Equal(t, QueueStats{Queued: 1, Dropped: 2, Retired: 3, AvgTransmits: 2.0 / 3.0}, q.Stats())
+
+	// The last one reaches its transmit limit.
+	require.Len(t, q.GetBroadcasts(2, 100), 1)
+	require.Equal(t, QueueStats{Queued: 0, Dropped: 2, Retired: 4, AvgTransmits: 1.0}, q.Stats())
+}
+
 func TestTransmitLimited_ordering(t *testing.T) {
 	q := &TransmitLimitedQueue{RetransmitMult: 1, NumNodes: func() int { return 10 }}
 
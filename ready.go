@@ -0,0 +1,72 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"context"
+	"fmt"
+)
+
+// Ready returns a channel that is closed once at least
+// Config.RequiredMembers live members, counting this one, have been known
+// at the same time. It stays closed if members leave afterwards, so it can
+// gate starting up things like a hash ring after a cold start.
+func (m *Memberlist) Ready() <-chan struct{} {
+	return m.readyCh
+}
+
+// WaitForMembers blocks until at least n live members, counting this one,
+// are known, the context is done, or memberlist is shut down.
+func (m *Memberlist) WaitForMembers(ctx context.Context, n int) error {
+	for {
+		m.nodeLock.RLock()
+		alive := m.numAlive()
+		changed := m.membersCh
+		m.nodeLock.RUnlock()
+		if alive >= n {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-m.shutdownCh:
+			return fmt.Errorf("memberlist shut down while waiting for %d members, have %d", n, alive)
+		}
+	}
+}
+
+// membersChanged wakes anyone waiting on the number of members. It must be
+// called with nodeLock held for writing whenever a node becomes alive or
+// dies.
+func (m *Memberlist) membersChanged() {
+	m.bumpMembers()
+	close(m.membersCh)
+	m.membersCh = make(chan struct{})
+
+	alive := m.numAlive()
+	if alive > m.highWater {
+		m.highWater = alive
+	}
+	select {
+	case <-m.readyCh:
+	default:
+		if alive >= m.config.RequiredMembers {
+			close(m.readyCh)
+		}
+	}
+}
+
+// numAlive returns the number of members that are neither dead nor left. It
+// must be called with nodeLock held.
+func (m *Memberlist) numAlive() int {
+	alive := 0
+	for _, n := range m.nodes {
+		if !n.DeadOrLeft() {
+			alive++
+		}
+	}
+	return alive
+}
@@ -0,0 +1,84 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberlist_Ready(t *testing.T) {
+	c1 := testConfig(t)
+	c1.RequiredMembers = 2
+	m1, err := Create(c1)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m1.Shutdown())
+	}()
+
+	select {
+	case <-m1.Ready():
+		t.Fatal("should not be ready with one member")
+	default:
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, m1.WaitForMembers(ctx, 2), context.DeadlineExceeded)
+
+	// Without a requirement a node is ready right away.
+	c2 := testConfig(t)
+	c2.BindPort = m1.config.BindPort
+	m2, err := Create(c2)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m2.Shutdown())
+	}()
+	select {
+	case <-m2.Ready():
+	default:
+		t.Fatal("should be ready")
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- m1.WaitForMembers(context.Background(), 2)
+	}()
+
+	_, err = m2.Join([]string{m1.config.Name + "/" + m1.config.BindAddr})
+	require.NoError(t, err)
+
+	select {
+	case err := <-errCh:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for members")
+	}
+	select {
+	case <-m1.Ready():
+	case <-time.After(5 * time.Second):
+		t.Fatal("should be ready")
+	}
+}
+
+func TestMemberlist_WaitForMembers_Shutdown(t *testing.T) {
+	m, err := Create(testConfig(t))
+	require.NoError(t, err)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- m.WaitForMembers(context.Background(), 2)
+	}()
+	require.NoError(t, m.Shutdown())
+
+	select {
+	case err := <-errCh:
+		require.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("should have returned on shutdown")
+	}
+}
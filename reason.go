@@ -0,0 +1,56 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import "fmt"
+
+// StateChangeReason says why a node was marked suspect, dead or left. It's
+// gossiped along with the state change, so every member sees the reason
+// given by the node that started it. Nodes running older versions don't
+// send one, which shows up as ReasonUnknown.
+type StateChangeReason uint8
+
+const (
+	// ReasonUnknown is used for alive nodes, and for state changes whose
+	// reason wasn't given.
+	ReasonUnknown StateChangeReason = iota
+
+	// ReasonProbeTimeout means a probe of the node got no ack, and there
+	// were no other members to ask for an indirect probe.
+	ReasonProbeTimeout
+
+	// ReasonIndirectFailure means a probe of the node got no ack, and
+	// neither did the indirect probes other members made for us.
+	ReasonIndirectFailure
+
+	// ReasonSuspicionExpired means the node was suspect and didn't refute
+	// it in time.
+	ReasonSuspicionExpired
+
+	// ReasonForced means the state was set through SetNodeState.
+	ReasonForced
+
+	// ReasonLeft means the node left the cluster voluntarily.
+	ReasonLeft
+)
+
+// String returns a short description of the reason, for logs.
+func (r StateChangeReason) String() string {
+	switch r {
+	case ReasonUnknown:
+		return "unknown"
+	case ReasonProbeTimeout:
+		return "probe timeout"
+	case ReasonIndirectFailure:
+		return "indirect probes failed"
+	case ReasonSuspicionExpired:
+		return "suspicion expired"
+	case ReasonForced:
+		return "forced"
+	case ReasonLeft:
+		return "left"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(r))
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberList_StateChangeReason(t *testing.T) {
+	ch := make(chan NodeEvent, 4)
+	m := GetMemberlist(t, func(c *Config) {
+		c.Events = &ChannelEventDelegate{ch}
+	})
+	defer m.Shutdown()
+
+	vsn := m.config.BuildVsnArray()
+	for _, name := range []string{"a", "b"} {
+		m.aliveNode(&alive{Node: name, Addr: []byte{127, 0, 0, 1}, Incarnation: 1, Vsn: vsn}, nil, false)
+		m.changeNode(name, func(s *nodeState) { s.StateChange = s.StateChange.Add(-time.Hour) })
+	}
+	<-ch
+	<-ch
+
+	m.suspectNode(&suspect{Node: "a", Incarnation: 1, From: "b", Reason: ReasonIndirectFailure})
+	require.Equal(t, ReasonIndirectFailure, m.nodeMap["a"].Reason)
+
+	// Death keeps the reason it was declared with
+	m.deadNode(&dead{Node: "a", Incarnation: 1, From: "b", Reason: ReasonSuspicionExpired})
+	e := <-ch
+	require.Equal(t, NodeLeave, e.Event)
+	require.Equal(t, ReasonSuspicionExpired, e.Node.Reason)
+
+	// Leaving is always voluntary
+	m.deadNode(&dead{Node: "b", Incarnation: 1, From: "b"})
+	e = <-ch
+	require.Equal(t, ReasonLeft, e.Node.Reason)
+
+	// Coming back clears it
+	m.aliveNode(&alive{Node: "a", Addr: []byte{127, 0, 0, 1}, Incarnation: 2, Vsn: vsn}, nil, false)
+	require.Equal(t, ReasonUnknown, m.nodeMap["a"].Reason)
+}
+
+func TestMemberList_SetNodeState_Reason(t *testing.T) {
+	m := GetMemberlist(t, nil)
+	defer m.Shutdown()
+
+	m.aliveNode(&alive{Node: "a", Addr: []byte{127, 0, 0, 1}, Incarnation: 1, Vsn: m.config.BuildVsnArray()}, nil, false)
+	m.changeNode("a", func(s *nodeState) { s.StateChange = s.StateChange.Add(-time.Hour) })
+
+	require.NoError(t, m.SetNodeState("a", StateDead, 1))
+	require.Equal(t, ReasonForced, m.nodeMap["a"].Reason)
+}
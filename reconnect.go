@@ -0,0 +1,96 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"math/rand"
+	"time"
+
+	metrics "github.com/hashicorp/go-metrics/compat"
+)
+
+// lostNode is a member that failed and has since been forgotten, which the
+// reconnector still tries to reach in case it's on the other side of a
+// partition.
+type lostNode struct {
+	addr Address
+	at   time.Time
+}
+
+// reconnect is invoked every ReconnectInterval. When we know of fewer live
+// members than we have in the past, it tries a push/pull with a failed
+// member or a seed, so that the two sides of a healed partition merge again
+// without waiting for a chance contact.
+func (m *Memberlist) reconnect() {
+	m.nodeLock.Lock()
+	if m.numAlive() >= m.highWater {
+		m.nodeLock.Unlock()
+		return
+	}
+
+	type candidate struct {
+		addr Address
+		seed string // Seed the address is from, if it's from one
+	}
+	var candidates []candidate
+	for _, n := range m.nodes {
+		if n.State == StateDead {
+			candidates = append(candidates, candidate{addr: n.StreamAddress()})
+		}
+	}
+	for name, lost := range m.lostNodes {
+		if time.Since(lost.at) > m.config.ReconnectTimeout {
+			delete(m.lostNodes, name)
+			continue
+		}
+		candidates = append(candidates, candidate{addr: lost.addr})
+	}
+	m.nodeLock.Unlock()
+
+	// Only the fastest seed that resolves is a candidate.
+	for _, seed := range m.preferSeeds(m.config.ReconnectSeeds) {
+		addrs, err := m.resolveAddr(seed)
+		if err != nil {
+			m.logger.Printf("[DEBUG] memberlist: Failed to resolve reconnect seed %s: %v", seed, err)
+			m.recordSeed(seed, 0, err)
+			continue
+		}
+		for _, addr := range addrs {
+			candidates = append(candidates, candidate{
+				addr: Address{
+					Addr: joinHostPort(addr.ip.String(), addr.port),
+					Name: addr.nodeName,
+				},
+				seed: seed,
+			})
+		}
+		break
+	}
+	if len(candidates) == 0 {
+		return
+	}
+
+	c := candidates[rand.Intn(len(candidates))]
+	metrics.IncrCounterWithLabels([]string{"memberlist", "reconnect"}, 1, m.metricLabels)
+	start := time.Now()
+	err := m.pushPullNode(c.addr, false)
+	if c.seed != "" {
+		m.recordSeed(c.seed, time.Since(start), err)
+	}
+	if err != nil {
+		m.logger.Printf("[DEBUG] memberlist: Failed to reconnect to %s: %v", c.addr.String(), err)
+	}
+}
+
+// rememberLost keeps the address of a dead node that's being forgotten, for
+// the reconnector. It must be called with nodeLock held for writing.
+func (m *Memberlist) rememberLost(n *nodeState) {
+	if m.config.ReconnectInterval <= 0 || n.State != StateDead {
+		return
+	}
+	if m.lostNodes == nil {
+		m.lostNodes = make(map[string]lostNode)
+	}
+	m.lostNodes[n.Name] = lostNode{addr: n.StreamAddress(), at: time.Now()}
+}
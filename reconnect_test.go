@@ -0,0 +1,80 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"testing"
+	"time"
+
+	iretry "github.com/hashicorp/memberlist/internal/retry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberlist_Reconnect(t *testing.T) {
+	c1 := testConfig(t)
+	c1.GossipToTheDeadTime = 0
+	m1, err := Create(c1)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m1.Shutdown())
+	}()
+
+	c2 := testConfig(t)
+	c2.BindPort = m1.config.BindPort
+	m2, err := Create(c2)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m2.Shutdown())
+	}()
+
+	_, err = m2.Join([]string{m1.config.Name + "/" + m1.config.BindAddr})
+	require.NoError(t, err)
+	require.Equal(t, 2, m1.NumMembers())
+
+	// Pretend m1 lost m2 in a partition, and has since forgotten it.
+	m1.nodeLock.RLock()
+	inc := m1.nodeMap[m2.config.Name].Incarnation
+	m1.nodeLock.RUnlock()
+	m1.deadNode(&dead{Node: m2.config.Name, Incarnation: inc, From: m1.config.Name})
+	require.Equal(t, 1, m1.NumMembers())
+	m1.resetNodes()
+
+	m1.nodeLock.RLock()
+	_, known := m1.nodeMap[m2.config.Name]
+	_, lost := m1.lostNodes[m2.config.Name]
+	highWater := m1.highWater
+	m1.nodeLock.RUnlock()
+	require.False(t, known)
+	require.True(t, lost)
+	require.Equal(t, 2, highWater)
+
+	// The reconnector brings it back.
+	m1.reconnect()
+	iretry.Run(t, func(r *iretry.R) {
+		if m1.NumMembers() != 2 {
+			r.Fatal("expected m2 to be back")
+		}
+	})
+	m1.nodeLock.RLock()
+	_, lost = m1.lostNodes[m2.config.Name]
+	m1.nodeLock.RUnlock()
+	require.False(t, lost)
+}
+
+func TestMemberlist_Reconnect_Left(t *testing.T) {
+	m := GetMemberlist(t, func(c *Config) {
+		c.ReconnectTimeout = time.Hour
+	})
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	a := alive{Node: "test", Addr: []byte{127, 0, 0, 1}, Incarnation: 1, Vsn: m.config.BuildVsnArray()}
+	m.aliveNode(&a, nil, false)
+	require.Equal(t, 1, m.highWater)
+
+	// A graceful leave lowers the mark, so there's nothing to reconnect.
+	m.deadNode(&dead{Node: "test", Incarnation: 1, From: "test"})
+	require.Equal(t, 0, m.highWater)
+}
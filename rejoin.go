@@ -0,0 +1,45 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	metrics "github.com/hashicorp/go-metrics/compat"
+)
+
+// RejoinEventDelegate can optionally be implemented by an EventDelegate to
+// tell a node that left coming back, such as after a planned restart, apart
+// from one joining for the first time or recovering from a failure. Without
+// it a rejoin is reported with NotifyJoin.
+type RejoinEventDelegate interface {
+	// NotifyRejoin is invoked instead of NotifyJoin when a node that left
+	// the cluster is detected to have joined it again under the same
+	// name. The Node argument must not be modified.
+	NotifyRejoin(*Node)
+}
+
+// rejoin is called when we hear that we left the cluster, though we haven't
+// left it in this run, which means it's remembering a leave from before we
+// restarted. It's not an accusation the way a dead message from another node
+// is, so rather than refute it we quietly take up an incarnation past the
+// leave and tell everyone we're back. You must hold the node lock.
+func (m *Memberlist) rejoin(me *nodeState, leftInc uint32) {
+	m.reannounce(me, leftInc)
+	metrics.IncrCounterWithLabels([]string{"memberlist", "rejoin"}, 1, m.metricLabels)
+	m.logger.Printf("[INFO] memberlist: Rejoining after a previous leave at incarnation %d", leftInc)
+}
+
+// notifyRejoin tells the event delegate that a node that left is back. You
+// must hold the node lock.
+func (m *Memberlist) notifyRejoin(n *Node) {
+	if _, ok := m.batchDelegate(); ok {
+		m.notifyEvent(NodeJoin, n)
+		return
+	}
+	if rd, ok := m.config.Events.(RejoinEventDelegate); ok {
+		m.journalEvent(NodeJoin, n)
+		m.guard("NotifyRejoin", func() { rd.NotifyRejoin(n) })
+		return
+	}
+	m.notifyEvent(NodeJoin, n)
+}
@@ -0,0 +1,119 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type rejoinEvents struct {
+	ChannelEventDelegate
+	rejoins chan string
+}
+
+func (r *rejoinEvents) NotifyRejoin(n *Node) {
+	r.rejoins <- n.Name
+}
+
+func TestMemberlist_Rejoin(t *testing.T) {
+	events := &rejoinEvents{
+		ChannelEventDelegate: ChannelEventDelegate{Ch: make(chan NodeEvent, 16)},
+		rejoins:              make(chan string, 16),
+	}
+	c1 := testConfig(t)
+	c1.Events = events
+	m1, err := Create(c1)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m1.Shutdown())
+	}()
+
+	c2 := testConfig(t)
+	c2.BindPort = m1.config.BindPort
+	m2, err := Create(c2)
+	require.NoError(t, err)
+	_, err = m2.Join([]string{m1.config.Name + "/" + m1.config.BindAddr})
+	require.NoError(t, err)
+	require.NoError(t, m2.Leave(time.Second))
+	require.NoError(t, m2.Shutdown())
+
+	stateOf := func(name string) NodeStateType {
+		m1.nodeLock.RLock()
+		defer m1.nodeLock.RUnlock()
+		return m1.nodeMap[name].State
+	}
+	require.Eventually(t, func() bool {
+		return stateOf(c2.Name) == StateLeft
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Restart it under the same name and address.
+	c3 := testConfig(t)
+	c3.Name = c2.Name
+	c3.BindAddr = c2.BindAddr
+	c3.BindPort = m1.config.BindPort
+	c3.Logger = c2.Logger
+	m3, err := Create(c3)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m3.Shutdown())
+	}()
+	_, err = m3.Join([]string{m1.config.Name + "/" + m1.config.BindAddr})
+	require.NoError(t, err)
+
+	select {
+	case name := <-events.rejoins:
+		require.Equal(t, c2.Name, name)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a rejoin event")
+	}
+	require.Equal(t, StateAlive, stateOf(c2.Name))
+
+	// Coming back isn't something to refute.
+	require.Zero(t, m3.GetHealthScore())
+}
+
+func TestMemberlist_Rejoin_LowerIncarnation(t *testing.T) {
+	events := &rejoinEvents{
+		ChannelEventDelegate: ChannelEventDelegate{Ch: make(chan NodeEvent, 16)},
+		rejoins:              make(chan string, 16),
+	}
+	m := GetMemberlist(t, func(c *Config) {
+		c.Events = events
+	})
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	vsn := m.config.BuildVsnArray()
+	a := alive{Node: "test", Addr: []byte{127, 0, 0, 1}, Port: 7946, Incarnation: 5, Vsn: vsn}
+	m.aliveNode(&a, nil, false)
+	m.deadNode(&dead{Node: "test", From: "test", Incarnation: 5})
+	require.Equal(t, StateLeft, m.getNodeState("test"))
+
+	// A stale copy from while the leave is still spreading is ignored.
+	restart := alive{Node: "test", Addr: []byte{127, 0, 0, 1}, Port: 7946, Incarnation: 1, Vsn: vsn}
+	m.aliveNode(&restart, nil, false)
+	require.Equal(t, StateLeft, m.getNodeState("test"))
+
+	// After that, the node restarting at incarnation 1 is taken back
+	// without it having to hear of the leave and refute it.
+	m.changeNode("test", func(state *nodeState) {
+		state.StateChange = state.StateChange.Add(-m.config.GossipToTheDeadTime - time.Second)
+	})
+	m.aliveNode(&restart, nil, false)
+	require.Equal(t, StateAlive, m.getNodeState("test"))
+	m.nodeLock.RLock()
+	require.Equal(t, uint32(1), m.nodeMap["test"].Incarnation)
+	m.nodeLock.RUnlock()
+
+	select {
+	case name := <-events.rejoins:
+		require.Equal(t, "test", name)
+	default:
+		t.Fatal("expected a rejoin event")
+	}
+}
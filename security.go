@@ -9,7 +9,6 @@ import (
 	"crypto/cipher"
 	"crypto/rand"
 	"fmt"
-	"io"
 )
 
 /*
@@ -83,81 +82,96 @@ func encryptedLength(vsn encryptionVersion, inp int) int {
 	return versionSize + nonceSize + inp + padding + tagSize
 }
 
-// encryptPayload is used to encrypt a message with a given key.
-// We make use of AES-128 in GCM mode. New byte buffer is the version,
-// nonce, ciphertext and tag
-func encryptPayload(vsn encryptionVersion, key []byte, msg []byte, data []byte, dst *bytes.Buffer) error {
+// newGCM returns AES-GCM with the given key, which makes a random nonce for
+// each message it seals and puts it before the ciphertext, and takes it from
+// there when opening. This is the FIPS approved way to use GCM, where the
+// nonce comes from inside the module.
+func newGCM(key []byte) (cipher.AEAD, error) {
 	// Get the AES block cipher
 	aesBlock, err := aes.NewCipher(key)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if gcm, err := cipher.NewGCMWithRandomNonce(aesBlock); err == nil {
+		return gcm, nil
 	}
 
-	// Get the GCM cipher mode
+	// BoringCrypto has its own AES, which only comes with plain GCM, and
+	// is validated as it is.
 	gcm, err := cipher.NewGCM(aesBlock)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return randomNonceGCM{gcm}, nil
+}
 
-	// Grow the buffer to make room for everything
-	offset := dst.Len()
-	dst.Grow(encryptedLength(vsn, len(msg)))
+// randomNonceGCM makes the nonces for a plain GCM the same way as
+// cipher.NewGCMWithRandomNonce does.
+type randomNonceGCM struct {
+	cipher.AEAD
+}
 
-	// Write the encryption version
-	dst.WriteByte(byte(vsn))
+func (g randomNonceGCM) NonceSize() int {
+	return 0
+}
 
-	// Add a random nonce
-	_, err = io.CopyN(dst, rand.Reader, nonceSize)
-	if err != nil {
-		return err
+func (g randomNonceGCM) Overhead() int {
+	return nonceSize + g.AEAD.Overhead()
+}
+
+func (g randomNonceGCM) Seal(dst, _, plaintext, data []byte) []byte {
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		panic(err)
 	}
-	afterNonce := dst.Len()
+	dst = append(dst, nonce...)
+	return g.AEAD.Seal(dst, nonce, plaintext, data)
+}
 
-	// Ensure we are correctly padded (only version 0)
-	if vsn == 0 {
-		_, _ = io.Copy(dst, bytes.NewReader(msg))
-		pkcs7encode(dst, offset+versionSize+nonceSize, aes.BlockSize)
+func (g randomNonceGCM) Open(dst, _, ciphertext, data []byte) ([]byte, error) {
+	if len(ciphertext) < nonceSize {
+		return nil, fmt.Errorf("ciphertext is too short")
 	}
+	return g.AEAD.Open(dst, ciphertext[:nonceSize], ciphertext[nonceSize:], data)
+}
 
-	// Encrypt message using GCM
-	slice := dst.Bytes()[offset:]
-	nonce := slice[versionSize : versionSize+nonceSize]
+// encryptPayload is used to encrypt a message with a given key.
+// We make use of AES-128 in GCM mode. New byte buffer is the version,
+// nonce, ciphertext and tag
+func encryptPayload(vsn encryptionVersion, key []byte, msg []byte, data []byte, dst *bytes.Buffer) error {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
 
 	// Message source depends on the encryption version.
 	// Version 0 uses padding, version 1 does not
-	var src []byte
+	src := msg
 	if vsn == 0 {
-		src = slice[versionSize+nonceSize:]
-	} else {
-		src = msg
+		var padded bytes.Buffer
+		padded.Grow(len(msg) + blockSize)
+		padded.Write(msg)
+		pkcs7encode(&padded, 0, aes.BlockSize)
+		src = padded.Bytes()
 	}
-	out := gcm.Seal(nil, nonce, src, data)
 
-	// Truncate the plaintext, and write the cipher text
-	dst.Truncate(afterNonce)
-	dst.Write(out)
+	// Write the encryption version, then the nonce, cipher text and tag
+	dst.Grow(encryptedLength(vsn, len(msg)))
+	dst.WriteByte(byte(vsn))
+	dst.Write(gcm.Seal(dst.AvailableBuffer(), nil, src, data))
 	return nil
 }
 
 // decryptMessage performs the actual decryption of ciphertext. This is in its
 // own function to allow it to be called on all keys easily.
 func decryptMessage(key, msg []byte, data []byte) ([]byte, error) {
-	// Get the AES block cipher
-	aesBlock, err := aes.NewCipher(key)
-	if err != nil {
-		return nil, err
-	}
-
-	// Get the GCM cipher mode
-	gcm, err := cipher.NewGCM(aesBlock)
+	gcm, err := newGCM(key)
 	if err != nil {
 		return nil, err
 	}
 
-	// Decrypt the message
-	nonce := msg[versionSize : versionSize+nonceSize]
-	ciphertext := msg[versionSize+nonceSize:]
-	plain, err := gcm.Open(nil, nonce, ciphertext, data)
+	// Decrypt the message, which starts with the nonce
+	plain, err := gcm.Open(nil, nil, msg[versionSize:], data)
 	if err != nil {
 		return nil, err
 	}
@@ -5,6 +5,8 @@ package memberlist
 
 import (
 	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
 	"reflect"
 	"testing"
 )
@@ -71,3 +73,39 @@ func encryptDecryptVersioned(vsn encryptionVersion, t *testing.T) {
 		t.Fatalf("encrypt/decrypt failed! %d '%s' '%s'", cmp, msg, plaintext)
 	}
 }
+
+func TestRandomNonceGCM(t *testing.T) {
+	// The plain GCM used with BoringCrypto has to be interchangeable with
+	// the approved one.
+	key := []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+	plaintext := []byte("this is a plain text message")
+	extra := []byte("random data")
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	plain, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	boring := randomNonceGCM{plain}
+	approved, err := newGCM(key)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if boring.Overhead() != approved.Overhead() {
+		t.Fatalf("overhead mismatch: %d %d", boring.Overhead(), approved.Overhead())
+	}
+
+	for _, pair := range [][2]cipher.AEAD{{boring, approved}, {approved, boring}} {
+		out := pair[0].Seal(nil, nil, plaintext, extra)
+		msg, err := pair[1].Open(nil, nil, out, extra)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !bytes.Equal(msg, plaintext) {
+			t.Fatalf("encrypt/decrypt failed! '%s' '%s'", msg, plaintext)
+		}
+	}
+}
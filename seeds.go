@@ -0,0 +1,137 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	metrics "github.com/hashicorp/go-metrics/compat"
+)
+
+/*
+Seeds are often spread across sites, and a node joined over a WAN gains
+nothing from syncing with the farthest one when a nearer one would do. So we
+time every push/pull with a seed, connecting included, and prefer the
+fastest: Join starts with them, so that with JoinMinSuccess it's done as soon
+as the nearest seeds have synced, and the reconnector only tries the fastest
+of ReconnectSeeds. Seeds we haven't timed yet come after the ones we have,
+and seeds whose last attempt failed come last, so a seed that's down doesn't
+stay first just because it used to be fast.
+*/
+
+// SeedStats is how push/pulls with a seed have gone, for one of the hosts
+// given to Join or in Config.ReconnectSeeds.
+type SeedStats struct {
+	Seed string
+
+	// Attempts and Failures count the push/pulls with the seed's
+	// addresses, and FailureStreak how many in a row have failed.
+	Attempts      int
+	Failures      int
+	FailureStreak int
+
+	// Latency is an exponentially weighted moving average of how long the
+	// successful push/pulls took, connecting included.
+	Latency time.Duration
+
+	// LastSuccess is when the last successful push/pull finished, and
+	// LastError the error from the last failed one.
+	LastSuccess time.Time
+	LastError   string
+}
+
+// recordSeed records how a push/pull with one of a seed's addresses went.
+func (m *Memberlist) recordSeed(seed string, took time.Duration, err error) {
+	m.seedLock.Lock()
+	defer m.seedLock.Unlock()
+
+	if m.seeds == nil {
+		m.seeds = make(map[string]*SeedStats)
+	}
+	s, ok := m.seeds[seed]
+	if !ok {
+		s = &SeedStats{Seed: seed}
+		m.seeds[seed] = s
+	}
+	s.Attempts++
+	if err != nil {
+		s.Failures++
+		s.FailureStreak++
+		s.LastError = err.Error()
+		return
+	}
+	s.FailureStreak = 0
+	s.LastSuccess = time.Now()
+	if s.Latency == 0 {
+		s.Latency = took
+	} else {
+		s.Latency += time.Duration(rttSmoothing * float64(took-s.Latency))
+	}
+
+	labels := make([]metrics.Label, 0, len(m.metricLabels)+1)
+	labels = append(labels, m.metricLabels...)
+	labels = append(labels, metrics.Label{Name: "seed", Value: seed})
+	metrics.AddSampleWithLabels([]string{"memberlist", "seed", "latency"}, float32(took)/float32(time.Millisecond), labels)
+}
+
+// preferSeeds returns the seeds in the order we'd rather sync with them:
+// timed ones fastest first, then ones we haven't timed in the order given,
+// then ones whose last attempt failed, those that have failed the fewest
+// times in a row first.
+func (m *Memberlist) preferSeeds(seeds []string) []string {
+	m.seedLock.Lock()
+	defer m.seedLock.Unlock()
+
+	out := slices.Clone(seeds)
+	m.sortSeeds(out)
+	return out
+}
+
+// sortSeeds sorts seeds in the order preferSeeds returns them. You must hold
+// seedLock.
+func (m *Memberlist) sortSeeds(seeds []string) {
+	rank := func(seed string) (int, time.Duration, int) {
+		s, ok := m.seeds[seed]
+		switch {
+		case !ok || s.Latency == 0 && s.FailureStreak == 0:
+			return 1, 0, 0
+		case s.FailureStreak > 0:
+			return 2, 0, s.FailureStreak
+		default:
+			return 0, s.Latency, 0
+		}
+	}
+	slices.SortStableFunc(seeds, func(a, b string) int {
+		ca, la, fa := rank(a)
+		cb, lb, fb := rank(b)
+		if ca != cb {
+			return ca - cb
+		}
+		if la != lb {
+			return cmp.Compare(la, lb)
+		}
+		return fa - fb
+	})
+}
+
+// SeedStats returns how push/pulls with each seed we've tried have gone, in
+// the order we prefer them.
+func (m *Memberlist) SeedStats() []SeedStats {
+	m.seedLock.Lock()
+	defer m.seedLock.Unlock()
+
+	names := make([]string, 0, len(m.seeds))
+	for name := range m.seeds {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	m.sortSeeds(names)
+	out := make([]SeedStats, 0, len(names))
+	for _, name := range names {
+		out = append(out, *m.seeds[name])
+	}
+	return out
+}
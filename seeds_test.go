@@ -0,0 +1,79 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberlist_PreferSeeds(t *testing.T) {
+	m := &Memberlist{}
+	m.recordSeed("far", 80*time.Millisecond, nil)
+	m.recordSeed("near", 5*time.Millisecond, nil)
+	m.recordSeed("mid", 20*time.Millisecond, nil)
+	m.recordSeed("down", 0, errors.New("connection refused"))
+	m.recordSeed("down", 0, errors.New("connection refused"))
+	m.recordSeed("flaky", 1*time.Millisecond, nil)
+	m.recordSeed("flaky", 0, errors.New("timeout"))
+
+	seeds := []string{"new-1", "down", "far", "flaky", "mid", "new-2", "near"}
+	require.Equal(t, []string{"near", "mid", "far", "new-1", "new-2", "flaky", "down"}, m.preferSeeds(seeds))
+	require.Equal(t, []string{"new-1", "down", "far", "flaky", "mid", "new-2", "near"}, seeds)
+
+	// The moving average keeps one slow sync from reordering them.
+	m.recordSeed("near", 50*time.Millisecond, nil)
+	require.Equal(t, []string{"near", "mid", "far"}, m.preferSeeds([]string{"far", "mid", "near"}))
+
+	stats := m.SeedStats()
+	require.Len(t, stats, 5)
+	require.Equal(t, "near", stats[0].Seed)
+	require.Equal(t, 2, stats[0].Attempts)
+	require.Equal(t, 5*time.Millisecond+time.Duration(rttSmoothing*float64(45*time.Millisecond)), stats[0].Latency)
+	require.Equal(t, "down", stats[4].Seed)
+	require.Equal(t, 2, stats[4].Failures)
+	require.Equal(t, 2, stats[4].FailureStreak)
+	require.Equal(t, "connection refused", stats[4].LastError)
+}
+
+func TestMemberlist_Join_RecordsSeeds(t *testing.T) {
+	m1 := GetMemberlist(t, nil)
+	defer func() {
+		require.NoError(t, m1.Shutdown())
+	}()
+	require.NoError(t, m1.setAlive())
+	m1.schedule()
+
+	// Nothing's listening on a port we've just closed.
+	l, err := net.Listen("tcp", net.JoinHostPort(m1.config.BindAddr, "0"))
+	require.NoError(t, err)
+	dead := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	m2 := GetMemberlist(t, func(c *Config) {
+		c.TCPTimeout = 100 * time.Millisecond
+	})
+	defer func() {
+		require.NoError(t, m2.Shutdown())
+	}()
+	require.NoError(t, m2.setAlive())
+	m2.schedule()
+
+	live := m1.config.Name + "/" + m1.LocalNode().Address()
+	n, err := m2.Join([]string{dead, live})
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	stats := m2.SeedStats()
+	require.Len(t, stats, 2)
+	require.Equal(t, live, stats[0].Seed)
+	require.Positive(t, stats[0].Latency)
+	require.Equal(t, dead, stats[1].Seed)
+	require.Equal(t, 1, stats[1].FailureStreak)
+	require.Equal(t, []string{live, dead}, m2.preferSeeds([]string{dead, live}))
+}
@@ -0,0 +1,82 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"net"
+
+	metrics "github.com/hashicorp/go-metrics/compat"
+)
+
+// sourceSet is the IP addresses of the members we know, taken at a given
+// membership version.
+type sourceSet struct {
+	version uint64
+	ips     map[string]struct{}
+}
+
+// sources returns the addresses of the members we know, taking a new set
+// only if the membership changed since the last.
+func (m *Memberlist) sources() *sourceSet {
+	if s := m.sourcesSnap.Load(); s != nil && s.version == m.membersVersion.Load() {
+		return s
+	}
+
+	m.nodeLock.RLock()
+	defer m.nodeLock.RUnlock()
+
+	s := &sourceSet{
+		version: m.membersVersion.Load(),
+		ips:     make(map[string]struct{}, len(m.nodes)),
+	}
+	for _, n := range m.nodes {
+		s.ips[n.Addr.String()] = struct{}{}
+		for _, addr := range n.Addrs {
+			if host, _, err := net.SplitHostPort(addr); err == nil {
+				if ip := net.ParseIP(host); ip != nil {
+					s.ips[ip.String()] = struct{}{}
+				}
+			}
+		}
+	}
+	m.sourcesSnap.Store(s)
+	return s
+}
+
+// knownSource returns true if a packet came from the address of a member we
+// know, or from somewhere that isn't an IP address and can't be checked.
+func (m *Memberlist) knownSource(from net.Addr) bool {
+	host, _, err := net.SplitHostPort(from.String())
+	if err != nil {
+		return true
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return true
+	}
+	_, ok := m.sources().ips[ip.String()]
+	return ok
+}
+
+// sourceAllowed returns false if a packet message should be dropped because
+// of where it came from, see Config.VerifyPacketSource. The messages that
+// wrap others are let through to have their contents checked. So are hole
+// punch messages, which come from behind NAT, so from an address that isn't
+// the one the member advertises, and which are only acted on for members
+// we know.
+func (m *Memberlist) sourceAllowed(msgType messageType, from net.Addr) bool {
+	if !m.config.VerifyPacketSource {
+		return true
+	}
+	switch msgType {
+	case compoundMsg, compressMsg, protoMsg, pingMsg, punchReqMsg, punchMsg:
+		return true
+	}
+	if m.knownSource(from) {
+		return true
+	}
+	metrics.IncrCounterWithLabels([]string{"memberlist", "packet", "unknown_source"}, 1, m.metricLabels)
+	m.logger.Printf("[DEBUG] memberlist: Dropping %s message from unknown source %s", msgType, LogAddress(from))
+	return false
+}
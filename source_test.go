@@ -0,0 +1,77 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberlist_VerifyPacketSource(t *testing.T) {
+	m := GetMemberlist(t, func(c *Config) {
+		c.VerifyPacketSource = true
+	})
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	from := func(ip string) net.Addr {
+		return &net.UDPAddr{IP: net.ParseIP(ip), Port: 7946}
+	}
+	require.False(t, m.sourceAllowed(aliveMsg, from("10.0.0.1")))
+	require.True(t, m.sourceAllowed(pingMsg, from("10.0.0.1")))
+	require.True(t, m.sourceAllowed(compoundMsg, from("10.0.0.1")))
+
+	a := alive{
+		Node:        "a",
+		Addr:        net.ParseIP("10.0.0.1").To4(),
+		Port:        7946,
+		Addrs:       []string{"[fd00::1]:7946"},
+		Incarnation: 1,
+		Vsn:         m.config.BuildVsnArray(),
+	}
+	m.aliveNode(&a, nil, false)
+	require.True(t, m.sourceAllowed(aliveMsg, from("10.0.0.1")))
+	require.True(t, m.sourceAllowed(aliveMsg, from("fd00::1")))
+	require.False(t, m.sourceAllowed(aliveMsg, from("10.0.0.2")))
+
+	// A dead member is still known, so it can refute.
+	m.deadNode(&dead{Node: "a", Incarnation: 1, From: m.config.Name})
+	require.True(t, m.sourceAllowed(suspectMsg, from("10.0.0.1")))
+
+	// Anything goes when it's off.
+	m.config.VerifyPacketSource = false
+	require.True(t, m.sourceAllowed(aliveMsg, from("10.0.0.2")))
+}
+
+func TestMemberlist_VerifyPacketSource_HolePunching(t *testing.T) {
+	m := GetMemberlist(t, func(c *Config) {
+		c.VerifyPacketSource = true
+		c.HolePunching = true
+	})
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	for i, name := range []string{"peer", "rendezvous"} {
+		a := alive{Node: name, Addr: []byte{10, 0, 0, byte(i + 1)}, Port: 7946, Incarnation: 1, Vsn: m.config.BuildVsnArray()}
+		m.aliveNode(&a, nil, false)
+	}
+
+	// Punch messages that come through NAT arrive from an address no member
+	// advertises.
+	nat := &net.UDPAddr{IP: net.ParseIP("192.0.2.1"), Port: 40000}
+	require.True(t, m.sourceAllowed(punchReqMsg, nat))
+	buf, err := encode(punchMsg, &punch{Peer: "peer", Rendezvous: "rendezvous"}, false)
+	require.NoError(t, err)
+	m.handleCommand(buf.Bytes(), nat, time.Now())
+
+	m.punchLock.Lock()
+	_, ok := m.punches["peer"]
+	m.punchLock.Unlock()
+	require.True(t, ok, "expected the punch to be handled")
+}
@@ -40,6 +40,34 @@ const (
 	StateLeft
 )
 
+// NodeRole describes how a node takes part in the cluster.
+type NodeRole uint8
+
+const (
+	// FullMember nodes take part in failure detection and gossip.
+	FullMember NodeRole = iota
+
+	// Observer nodes get the full membership and broadcasts, but are never
+	// probed, used as indirect probe helpers, or picked as gossip targets,
+	// and never raise suspicion of others. They stay informed by probing
+	// members, which piggyback broadcasts on their acks, and through
+	// push/pull syncs. Members notice an observer is gone when it stops
+	// probing them, so an observer shouldn't disable probes.
+	Observer
+)
+
+// String returns the name of the role.
+func (r NodeRole) String() string {
+	switch r {
+	case FullMember:
+		return "member"
+	case Observer:
+		return "observer"
+	default:
+		return fmt.Sprintf("unknown-%d", r)
+	}
+}
+
 // Node represents a node in the cluster.
 type Node struct {
 	Name  string
@@ -53,11 +81,50 @@ type Node struct {
 	DMin  uint8         // Min protocol version for the delegate to understand
 	DMax  uint8         // Max protocol version for the delegate to understand
 	DCur  uint8         // Current version delegate is speaking
+
+	// Addrs are any additional addresses the node advertises, in host:port
+	// form. Addr and Port always hold the primary address.
+	Addrs []string
+
+	// Role is the node's role in the cluster.
+	Role NodeRole
+
+	// Zone is the zone or region the node is in, if it advertises one.
+	Zone string
+
+	// Weight is the node's relative capacity, if it advertises one, for
+	// applications to spread load by. See Config.Weight.
+	Weight uint16
+
+	// Draining is true if the node is draining, so work should be routed
+	// elsewhere ahead of it leaving. See Memberlist.Drain.
+	Draining bool
+
+	// StreamPort is the port the node accepts stream connections on, if
+	// it's different from Port.
+	StreamPort uint16
+
+	// ID is the node's stable identity, a UUID, if it has one. Unlike the
+	// name it never changes, so it tells a renamed node apart from a
+	// different node that took over its name.
+	ID string
+
+	// Reason is why the node was last marked suspect, dead or left. It's
+	// ReasonUnknown while the node is alive.
+	Reason StateChangeReason
+
+	// activeAddr is the address, out of the advertised ones, that we last
+	// found to be reachable. It's empty when that's the primary address.
+	activeAddr string
 }
 
 // Address returns the host:port form of a node's address, suitable for use
-// with a transport.
+// with a transport. For nodes that advertise several addresses this is the
+// one that was last found to be reachable.
 func (n *Node) Address() string {
+	if n.activeAddr != "" {
+		return n.activeAddr
+	}
 	return joinHostPort(n.Addr.String(), n.Port)
 }
 
@@ -65,11 +132,36 @@ func (n *Node) Address() string {
 // suitable for use with a transport.
 func (n *Node) FullAddress() Address {
 	return Address{
-		Addr: joinHostPort(n.Addr.String(), n.Port),
+		Addr: n.Address(),
 		Name: n.Name,
 	}
 }
 
+// StreamAddress is like FullAddress, but for stream connections, which
+// go to StreamPort if the node has one.
+func (n *Node) StreamAddress() Address {
+	a := n.FullAddress()
+	if n.StreamPort != 0 {
+		if host, _, err := net.SplitHostPort(a.Addr); err == nil {
+			a.Addr = joinHostPort(host, n.StreamPort)
+		}
+	}
+	return a
+}
+
+// Addresses returns all the addresses a node advertises in host:port form,
+// starting with the primary one.
+func (n *Node) Addresses() []string {
+	addrs := make([]string, 0, 1+len(n.Addrs))
+	addrs = append(addrs, joinHostPort(n.Addr.String(), n.Port))
+	for _, addr := range n.Addrs {
+		if addr != addrs[0] {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
 // String returns the node name
 func (n *Node) String() string {
 	return n.Name
@@ -81,6 +173,13 @@ type nodeState struct {
 	Incarnation uint32        // Last known incarnation number
 	State       NodeStateType // Current state
 	StateChange time.Time     // Time last state change happened
+
+	health  int                  // Health score the node last reported in an ack
+	flap    flapState            // Flap damping, see recordFlap
+	entries map[string]NodeEntry // Keyed state, see NodeEntriesDelegate
+
+	maintenanceUntil time.Time     // When the node's maintenance ends, see SetMaintenance
+	probes           *probeHistory // Our recent probes of the node, see NodeDiagnostics
 }
 
 // Address returns the host:port form of a node's address, suitable for use
@@ -101,9 +200,9 @@ func (n *nodeState) DeadOrLeft() bool {
 
 // ackHandler is used to register handlers for incoming acks and nacks.
 type ackHandler struct {
-	ackFn  func([]byte, time.Time)
+	ackFn  func(ackResp, time.Time)
 	nackFn func()
-	timer  *time.Timer
+	timer  stoppableTimer
 }
 
 // NoPingResponseError is used to indicate a 'ping' packet was
@@ -123,40 +222,77 @@ func (m *Memberlist) schedule() {
 	m.tickerLock.Lock()
 	defer m.tickerLock.Unlock()
 
-	// If we already have tickers, then don't do anything, since we're
-	// scheduled
-	if len(m.tickers) > 0 {
+	// If we already have a stop channel, then don't do anything, since
+	// we're scheduled
+	if m.stopTick != nil {
 		return
 	}
 
 	// Create the stop tick channel, a blocking channel. We close this
 	// when we should stop the tickers.
 	stopCh := make(chan struct{})
+	tuning := m.Tuning()
 
 	// Create a new probeTicker
-	if m.config.ProbeInterval > 0 {
-		t := time.NewTicker(m.config.ProbeInterval)
-		go m.triggerFunc(m.config.ProbeInterval, t.C, stopCh, m.probe)
+	if tuning.ProbeInterval > 0 && !m.config.DisableProbes {
+		t := time.NewTicker(tuning.ProbeInterval)
+		probe := m.probe
+		if m.config.ProbeJitterPercent > 0 {
+			probe = func() { m.jitteredProbe(stopCh) }
+		}
+		go m.triggerFunc(tuning.ProbeInterval, t.C, stopCh, probe)
 		m.tickers = append(m.tickers, t)
 	}
 
 	// Create a push pull ticker if needed
-	if m.config.PushPullInterval > 0 {
-		go m.pushPullTrigger(stopCh)
+	if tuning.PushPullInterval > 0 {
+		go m.pushPullTrigger(tuning.PushPullInterval, stopCh)
 	}
 
-	// Create a gossip ticker if needed
-	if m.config.GossipInterval > 0 && m.config.GossipNodes > 0 {
-		t := time.NewTicker(m.config.GossipInterval)
-		go m.triggerFunc(m.config.GossipInterval, t.C, stopCh, m.gossip)
+	// Create an anti-entropy ticker if needed
+	if m.config.AntiEntropyInterval > 0 {
+		t := time.NewTicker(m.config.AntiEntropyInterval)
+		go m.triggerFunc(m.config.AntiEntropyInterval, t.C, stopCh, m.antiEntropy)
 		m.tickers = append(m.tickers, t)
 	}
 
-	// If we made any tickers, then record the stopTick channel for
-	// later.
-	if len(m.tickers) > 0 {
-		m.stopTick = stopCh
+	// Create an MTU probe ticker if needed
+	if m.config.MTUProbeInterval > 0 {
+		t := time.NewTicker(m.config.MTUProbeInterval)
+		go m.triggerFunc(m.config.MTUProbeInterval, t.C, stopCh, m.probeMTU)
+		m.tickers = append(m.tickers, t)
+	}
+
+	// Create a reconnect ticker if needed
+	if m.useSTUN() && m.config.STUNInterval > 0 {
+		t := time.NewTicker(m.config.STUNInterval)
+		go m.triggerFunc(m.config.STUNInterval, t.C, stopCh, m.checkPublicAddr)
+		m.tickers = append(m.tickers, t)
+	}
+
+	if m.config.ReconnectInterval > 0 {
+		t := time.NewTicker(m.config.ReconnectInterval)
+		go m.triggerFunc(m.config.ReconnectInterval, t.C, stopCh, m.reconnect)
+		m.tickers = append(m.tickers, t)
+	}
+
+	// Create an auto-tuning ticker if needed
+	if m.config.AutoTuneInterval > 0 {
+		t := time.NewTicker(m.config.AutoTuneInterval)
+		go m.triggerFunc(m.config.AutoTuneInterval, t.C, stopCh, m.autoTune)
+		m.tickers = append(m.tickers, t)
+	}
+
+	// Create a gossip ticker if needed
+	if tuning.GossipInterval > 0 && m.config.GossipNodes > 0 {
+		t := time.NewTicker(tuning.GossipInterval)
+		go m.triggerFunc(tuning.GossipInterval, t.C, stopCh, m.gossip)
+		m.tickers = append(m.tickers, t)
 	}
+
+	// Record the stopTick channel for later. The push/pull trigger doesn't
+	// have a ticker, so this is how we know we're scheduled.
+	m.stopTick = stopCh
 }
 
 // triggerFunc is used to trigger a function call each time a
@@ -179,12 +315,39 @@ func (m *Memberlist) triggerFunc(stagger time.Duration, C <-chan time.Time, stop
 	}
 }
 
+// jitteredProbe delays the probe by a random fraction of the probe interval,
+// bounded by Config.ProbeJitterPercent, so probes across a large cluster
+// don't line up into bursts. The delay comes out of the interval, it doesn't
+// add to it, and the ack timeouts are unaffected.
+func (m *Memberlist) jitteredProbe(stop <-chan struct{}) {
+	select {
+	case <-time.After(probeJitter(m.Tuning().ProbeInterval, m.config.ProbeJitterPercent)):
+		m.probe()
+	case <-stop:
+	}
+}
+
+// probeJitter returns a random delay of up to percent of the interval. The
+// percentage is capped below 100 so a probe always fits in its interval.
+func probeJitter(interval time.Duration, percent int) time.Duration {
+	if percent <= 0 || interval <= 0 {
+		return 0
+	}
+	if percent > 90 {
+		percent = 90
+	}
+	max := interval * time.Duration(percent) / 100
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(uint64(rand.Int63()) % uint64(max))
+}
+
 // pushPullTrigger is used to periodically trigger a push/pull until
 // a stop tick arrives. We don't use triggerFunc since the push/pull
 // timer is dynamically scaled based on cluster size to avoid network
 // saturation
-func (m *Memberlist) pushPullTrigger(stop <-chan struct{}) {
-	interval := m.config.PushPullInterval
+func (m *Memberlist) pushPullTrigger(interval time.Duration, stop <-chan struct{}) {
 
 	// Use a random stagger to avoid syncronizing
 	randStagger := time.Duration(uint64(rand.Int63()) % uint64(interval))
@@ -212,8 +375,8 @@ func (m *Memberlist) deschedule() {
 	m.tickerLock.Lock()
 	defer m.tickerLock.Unlock()
 
-	// If we have no tickers, then we aren't scheduled.
-	if len(m.tickers) == 0 {
+	// If we have no stop channel, then we aren't scheduled.
+	if m.stopTick == nil {
 		return
 	}
 
@@ -226,6 +389,7 @@ func (m *Memberlist) deschedule() {
 		t.Stop()
 	}
 	m.tickers = nil
+	m.stopTick = nil
 }
 
 // Tick is used to perform a single round of failure detection and gossip
@@ -258,12 +422,19 @@ START:
 		skip = true
 	} else if node.DeadOrLeft() {
 		skip = true
+	} else if node.Role == Observer {
+		skip = true
+	} else if node.inMaintenance(time.Now()) {
+		skip = true
 	}
 
 	// Potentially skip
 	m.nodeLock.RUnlock()
 	m.probeIndex++
 	if skip {
+		if node.Role == Observer && node.State == StateAlive {
+			m.checkObserver(&node)
+		}
 		numCheck++
 		goto START
 	}
@@ -308,8 +479,9 @@ func (m *Memberlist) probeNode(node *nodeState) {
 	// We use our health awareness to scale the overall probe interval, so we
 	// slow down if we detect problems. The ticker that calls us can handle
 	// us running over the base interval, and will skip missed ticks.
-	probeInterval := m.awareness.ScaleTimeout(m.config.ProbeInterval)
-	if probeInterval > m.config.ProbeInterval {
+	baseInterval := m.Tuning().ProbeInterval
+	probeInterval := m.awareness.ScaleTimeout(baseInterval)
+	if probeInterval > baseInterval {
 		metrics.IncrCounterWithLabels([]string{"memberlist", "degraded", "probe"}, 1, m.metricLabels)
 	}
 
@@ -344,10 +516,19 @@ func (m *Memberlist) probeNode(node *nodeState) {
 	defer func() {
 		m.awareness.ApplyDelta(awarenessDelta)
 	}()
+
+	// Report the probe once it's done.
+	trace := ProbeTrace{Node: node.Name, Addr: addr, Start: sent}
+	defer func() {
+		m.recordProbe(trace)
+		m.recordProbeLoss(trace)
+		m.traceProbe(trace)
+	}()
 	if node.State == StateAlive {
 		if err := m.encodeAndSendMsg(node.FullAddress(), pingMsg, &ping); err != nil {
 			m.logger.Printf("[ERR] memberlist: Failed to send UDP ping: %s", err)
 			if failedRemote(err) {
+				m.observeProbe(ProbeOutcome{Node: node.Name, Addr: addr, Method: ProbeDirect, Start: sent})
 				goto HANDLE_REMOTE_FAILURE
 			} else {
 				return
@@ -361,7 +542,7 @@ func (m *Memberlist) probeNode(node *nodeState) {
 		} else {
 			msgs = append(msgs, buf.Bytes())
 		}
-		s := suspect{Incarnation: node.Incarnation, Node: node.Name, From: m.config.Name}
+		s := suspect{Incarnation: node.Incarnation, Node: node.Name, From: m.config.Name, Reason: node.Reason}
 		if buf, err := encode(suspectMsg, &s, m.config.MsgpackUseNewTimeFormat); err != nil {
 			m.logger.Printf("[ERR] memberlist: Failed to encode suspect message: %s", err)
 			return
@@ -373,6 +554,7 @@ func (m *Memberlist) probeNode(node *nodeState) {
 		if err := m.rawSendMsgPacket(node.FullAddress(), &node.Node, compound.Bytes()); err != nil {
 			m.logger.Printf("[ERR] memberlist: Failed to send UDP compound ping and suspect message to %s: %s", addr, err)
 			if failedRemote(err) {
+				m.observeProbe(ProbeOutcome{Node: node.Name, Addr: addr, Method: ProbeDirect, Start: sent})
 				goto HANDLE_REMOTE_FAILURE
 			} else {
 				return
@@ -391,10 +573,13 @@ func (m *Memberlist) probeNode(node *nodeState) {
 	select {
 	case v := <-ackCh:
 		if v.Complete {
+			rtt := v.Timestamp.Sub(sent)
 			if m.config.Ping != nil {
-				rtt := v.Timestamp.Sub(sent)
-				m.config.Ping.NotifyPingComplete(&node.Node, rtt, v.Payload)
+				m.guard("NotifyPingComplete", func() { m.config.Ping.NotifyPingComplete(&node.Node, rtt, v.Payload) })
 			}
+			m.probeSucceeded(node.Name, v.Health)
+			trace.Acked, trace.RTT = true, rtt
+			m.observeProbe(ProbeOutcome{Node: node.Name, Addr: addr, Method: ProbeDirect, Start: sent, Success: true, RTT: rtt})
 			return
 		}
 
@@ -403,7 +588,8 @@ func (m *Memberlist) probeNode(node *nodeState) {
 		if !v.Complete {
 			ackCh <- v
 		}
-	case <-time.After(m.config.ProbeTimeout):
+		m.observeProbe(ProbeOutcome{Node: node.Name, Addr: addr, Method: ProbeDirect, Start: sent})
+	case <-time.After(m.probeTimeout()):
 		// Note that we don't scale this timeout based on awareness and
 		// the health score. That's because we don't really expect waiting
 		// longer to help get UDP through. Since health does extend the
@@ -411,15 +597,22 @@ func (m *Memberlist) probeNode(node *nodeState) {
 		// is more active in dealing with lost packets, and it gives more
 		// time to wait for indirect acks/nacks.
 		m.logger.Printf("[DEBUG] memberlist: Failed UDP ping: %s (timeout reached)", node.Name)
+		m.observeProbe(ProbeOutcome{Node: node.Name, Addr: addr, Method: ProbeDirect, Start: sent})
 	}
 
 HANDLE_REMOTE_FAILURE:
+	// If the node advertises other addresses, try those directly as well.
+	altSent := time.Now()
+	alternates := m.probeAlternateAddrs(node, ackCh, probeInterval)
+
 	// Get some random live nodes.
 	m.nodeLock.RLock()
-	kNodes := kRandomNodes(m.config.IndirectChecks, m.nodes, func(n *nodeState) bool {
+	kNodes := m.kRandomZoneNodes(m.config.IndirectChecks, func(n *nodeState) bool {
 		return n.Name == m.config.Name ||
 			n.Name == node.Name ||
-			n.State != StateAlive
+			n.State != StateAlive ||
+			n.Role == Observer ||
+			m.breakerOpen(n.Name)
 	})
 	m.nodeLock.RUnlock()
 
@@ -435,10 +628,14 @@ HANDLE_REMOTE_FAILURE:
 		SourcePort: selfPort,
 		SourceNode: m.config.Name,
 	}
+	trace.Indirect = len(kNodes)
+	indSent := time.Now()
+	via := make([]string, 0, len(kNodes))
 	for _, peer := range kNodes {
+		via = append(via, peer.Name)
 		// We only expect nack to be sent from peers who understand
 		// version 4 of the protocol.
-		if ind.Nack = peer.PMax >= 4; ind.Nack {
+		if ind.Nack = peer.Supports(FeatureNack); ind.Nack {
 			expectedNacks++
 		}
 
@@ -461,10 +658,17 @@ HANDLE_REMOTE_FAILURE:
 
 	disableTcpPings := m.config.DisableTcpPings ||
 		(m.config.DisableTcpPingsForNode != nil && m.config.DisableTcpPingsForNode(node.Name))
-	if (!disableTcpPings) && (node.PMax >= 3) {
+	if (!disableTcpPings) && node.Supports(FeatureTCPPing) {
 		go func() {
 			defer close(fallbackCh)
-			didContact, err := m.sendPingAndWaitForAck(node.FullAddress(), ping, deadline)
+			a := node.StreamAddress()
+			tcpSent := time.Now()
+			didContact, err := m.sendPingAndWaitForAck(a, ping, deadline)
+			r := ProbeOutcome{Node: node.Name, Addr: a.Addr, Method: ProbeTCP, Start: tcpSent, Success: err == nil && didContact}
+			if r.Success {
+				r.RTT = time.Since(tcpSent)
+			}
+			m.observeProbe(r)
 			if err != nil {
 				var to string
 				if ne, ok := err.(net.Error); ok && ne.Timeout() {
@@ -485,8 +689,24 @@ HANDLE_REMOTE_FAILURE:
 	// out first to allow the normal UDP-based acks to come in.
 	v := <-ackCh
 	if v.Complete {
+		m.probeSucceeded(node.Name, v.Health)
+		trace.Acked = true
+		// A late answer to the direct ping can't be told apart from one
+		// relayed by another member, so it counts as indirect.
+		if v.Addr != "" {
+			m.observeProbe(ProbeOutcome{Node: node.Name, Addr: v.Addr, Method: ProbeDirect, Start: altSent, Success: true, RTT: v.Timestamp.Sub(altSent)})
+		} else {
+			m.observeProbe(ProbeOutcome{Node: node.Name, Addr: addr, Method: ProbeIndirect, Via: via, Start: indSent, Success: true, RTT: v.Timestamp.Sub(indSent)})
+		}
+		m.requestPunch(node.Name, kNodes)
 		return
 	}
+	for _, alt := range alternates {
+		m.observeProbe(ProbeOutcome{Node: node.Name, Addr: alt, Method: ProbeDirect, Start: altSent})
+	}
+	if len(kNodes) > 0 {
+		m.observeProbe(ProbeOutcome{Node: node.Name, Addr: addr, Method: ProbeIndirect, Via: via, Start: indSent})
+	}
 
 	// Finally, poll the fallback channel. The timeouts are set such that
 	// the channel will have something or be closed without having to wait
@@ -494,6 +714,8 @@ HANDLE_REMOTE_FAILURE:
 	for didContact := range fallbackCh {
 		if didContact {
 			m.logger.Printf("[WARN] memberlist: Was able to connect to %s over TCP but UDP probes failed, network may be misconfigured", node.Name)
+			m.probeSucceeded(node.Name, 0)
+			trace.Acked, trace.TCPFallback = true, true
 			return
 		}
 	}
@@ -514,12 +736,192 @@ HANDLE_REMOTE_FAILURE:
 		awarenessDelta += 1
 	}
 
+	// Observers never raise suspicion, they only probe to stay informed.
+	if m.config.Role == Observer {
+		return
+	}
+
+	// Give the failure detector a chance to hold off on suspicion.
+	if fd := m.config.FailureDetector; fd != nil && !fd.Suspect(node.Name, time.Now()) {
+		m.logger.Printf("[DEBUG] memberlist: No acks received from %s, but not suspecting it yet", node.Name)
+		return
+	}
+
 	// No acks received from target, suspect it as failed.
 	m.logger.Printf("[INFO] memberlist: Suspect %s has failed, no acks received", node.Name)
-	s := suspect{Incarnation: node.Incarnation, Node: node.Name, From: m.config.Name}
+	reason := ReasonProbeTimeout
+	if trace.Indirect > 0 {
+		reason = ReasonIndirectFailure
+	}
+	s := suspect{Incarnation: node.Incarnation, Node: node.Name, From: m.config.Name, Reason: reason}
 	m.suspectNode(&s)
 }
 
+// kRandomZoneNodes is like kRandomNodes, but when we're in a zone it makes
+// sure that Config.CrossZoneFraction of the selected nodes, rounded up, are
+// from other zones, as long as there are enough of those. The rest may be
+// from any zone. You must hold the node lock.
+func (m *Memberlist) kRandomZoneNodes(k int, exclude func(*nodeState) bool) []Node {
+	zone, frac := m.config.Zone, m.config.CrossZoneFraction
+	if zone == "" || frac <= 0 || k <= 0 {
+		return m.kRandomNodes(k, exclude)
+	}
+
+	cross := int(math.Ceil(float64(k) * math.Min(frac, 1)))
+	kNodes := m.kRandomNodes(cross, func(n *nodeState) bool {
+		return exclude(n) || n.Zone == "" || n.Zone == zone
+	})
+	if len(kNodes) == k {
+		return kNodes
+	}
+
+	picked := make(map[string]struct{}, len(kNodes))
+	for _, n := range kNodes {
+		picked[n.Name] = struct{}{}
+	}
+	rest := m.kRandomNodes(k-len(kNodes), func(n *nodeState) bool {
+		if _, ok := picked[n.Name]; ok {
+			return true
+		}
+		return exclude(n)
+	})
+	return append(kNodes, rest...)
+}
+
+// observerHeard records that an observer node has just probed us.
+func (m *Memberlist) observerHeard(name string) {
+	m.nodeLock.RLock()
+	state, ok := m.nodeMap[name]
+	isObserver := ok && state.Role == Observer
+	m.nodeLock.RUnlock()
+	if !isObserver {
+		return
+	}
+
+	m.observerLock.Lock()
+	defer m.observerLock.Unlock()
+	if m.observerSeen == nil {
+		m.observerSeen = make(map[string]time.Time)
+	}
+	m.observerSeen[name] = time.Now()
+}
+
+// forgetObserver drops what we know about an observer.
+func (m *Memberlist) forgetObserver(name string) {
+	m.observerLock.Lock()
+	defer m.observerLock.Unlock()
+	delete(m.observerSeen, name)
+}
+
+// checkObserver suspects an observer that hasn't probed us for a while. We
+// never probe observers, so this is how the ones that vanish without leaving
+// are eventually removed. Observers probe one member per interval, so the
+// timeout scales with the cluster size.
+func (m *Memberlist) checkObserver(node *nodeState) {
+	m.observerLock.Lock()
+	last, ok := m.observerSeen[node.Name]
+	m.observerLock.Unlock()
+	if !ok || last.Before(node.StateChange) {
+		last = node.StateChange
+	}
+
+	timeout := time.Duration(3*m.estNumNodes()) * m.Tuning().ProbeInterval
+	if time.Since(last) < timeout {
+		return
+	}
+
+	m.logger.Printf("[INFO] memberlist: Suspect observer %s has failed, not heard from in %s", node.Name, timeout)
+	s := suspect{Incarnation: node.Incarnation, Node: node.Name, From: m.config.Name, Reason: ReasonProbeTimeout}
+	m.suspectNode(&s)
+}
+
+// scaleByHealth stretches a timeout according to a node's health score,
+// which is capped below max like our own awareness score.
+func scaleByHealth(timeout time.Duration, health, max int) time.Duration {
+	if health <= 0 {
+		return timeout
+	}
+	if max > 0 && health > max-1 {
+		health = max - 1
+	}
+	return timeout * time.Duration(health+1)
+}
+
+// probeSucceeded lets the failure detector know the node answered a probe,
+// and records the health score the node reported in its ack.
+func (m *Memberlist) probeSucceeded(name string, health int) {
+	if fd := m.config.FailureDetector; fd != nil {
+		fd.Heartbeat(name, time.Now())
+	}
+
+	m.nodeLock.Lock()
+	if state, ok := m.nodeMap[name]; ok {
+		state.health = health
+	}
+	m.nodeLock.Unlock()
+}
+
+// probeAlternateAddrs sends a ping to every address the node advertises other
+// than the one we've been using. Whichever answers first becomes the address
+// we use for the node from then on, and counts as a successful probe. It
+// returns the addresses it pinged.
+func (m *Memberlist) probeAlternateAddrs(node *nodeState, ackCh chan ackMessage, timeout time.Duration) (tried []string) {
+	current := node.Address()
+	for _, addr := range node.Addresses() {
+		if addr == current {
+			continue
+		}
+
+		selfAddr, selfPort := m.getAdvertise()
+		ping := ping{
+			SeqNo:      m.nextSeqNo(),
+			Node:       node.Name,
+			SourceAddr: selfAddr,
+			SourcePort: selfPort,
+			SourceNode: m.config.Name,
+		}
+
+		addr := addr
+		m.setAckRespHandler(ping.SeqNo, func(ack ackResp, timestamp time.Time) {
+			m.setActiveAddr(node.Name, addr)
+			select {
+			case ackCh <- ackMessage{true, ack.Payload, timestamp, ack.Health, addr}:
+			default:
+			}
+		}, timeout)
+
+		a := Address{Addr: addr, Name: node.Name}
+		if err := m.encodeAndSendMsg(a, pingMsg, &ping); err != nil {
+			m.logger.Printf("[ERR] memberlist: Failed to send UDP ping to alternate address %s: %s", addr, err)
+		}
+		tried = append(tried, addr)
+	}
+	return tried
+}
+
+// setActiveAddr remembers which of the node's advertised addresses works.
+func (m *Memberlist) setActiveAddr(name, addr string) {
+	m.nodeLock.Lock()
+	defer m.nodeLock.Unlock()
+
+	state, ok := m.nodeMap[name]
+	if !ok || state.Address() == addr {
+		return
+	}
+
+	addrs := state.Addresses()
+	switch {
+	case addr == addrs[0]:
+		state.activeAddr = ""
+	case containsString(addrs, addr):
+		state.activeAddr = addr
+	default:
+		return
+	}
+	m.bumpMembers()
+	m.logger.Printf("[INFO] memberlist: Switched to address %s for node %s", addr, name)
+}
+
 // Ping initiates a ping to the node with the specified name.
 func (m *Memberlist) Ping(node string, addr net.Addr) (time.Duration, []byte, error) {
 	// Prepare a ping message and setup an ack handler.
@@ -532,7 +934,7 @@ func (m *Memberlist) Ping(node string, addr net.Addr) (time.Duration, []byte, er
 		SourceNode: m.config.Name,
 	}
 	ackCh := make(chan ackMessage, m.config.IndirectChecks+1)
-	m.setProbeChannels(ping.SeqNo, ackCh, nil, m.config.ProbeInterval)
+	m.setProbeChannels(ping.SeqNo, ackCh, nil, m.Tuning().ProbeInterval)
 
 	a := Address{Addr: addr.String(), Name: node}
 
@@ -552,7 +954,7 @@ func (m *Memberlist) Ping(node string, addr net.Addr) (time.Duration, []byte, er
 		if v.Complete {
 			return v.Timestamp.Sub(sent), v.Payload, nil
 		}
-	case <-time.After(m.config.ProbeTimeout):
+	case <-time.After(m.probeTimeout()):
 		// Timeout, return an error below.
 	}
 
@@ -560,6 +962,76 @@ func (m *Memberlist) Ping(node string, addr net.Addr) (time.Duration, []byte, er
 	return 0, nil, NoPingResponseError{ping.Node}
 }
 
+// SetNodeState applies a liveness verdict from an external failure detector
+// to the named node, which is then gossiped like any other state change. It's
+// meant to be used together with Config.DisableProbes. The usual incarnation
+// rules apply, so suspect and dead verdicts must carry at least the node's
+// current incarnation, and bringing a node back to alive takes a higher one.
+// Only StateAlive, StateSuspect and StateDead are accepted.
+func (m *Memberlist) SetNodeState(node string, state NodeStateType, incarnation uint32) error {
+	return m.setNodeStateAs(node, state, incarnation, ActorLocal)
+}
+
+// setNodeStateAs is SetNodeState, reporting the given actor to the AuditHook.
+func (m *Memberlist) setNodeStateAs(node string, state NodeStateType, incarnation uint32, actor string) (err error) {
+	defer func() {
+		switch state {
+		case StateAlive:
+			m.Audit(AuditForceAlive, actor, node, err)
+		case StateSuspect:
+			m.Audit(AuditForceSuspect, actor, node, err)
+		case StateDead:
+			m.Audit(AuditForceLeave, actor, node, err)
+		}
+	}()
+	if node == m.config.Name {
+		return fmt.Errorf("cannot set the state of the local node")
+	}
+
+	m.nodeLock.RLock()
+	ns, ok := m.nodeMap[node]
+	var n Node
+	if ok {
+		n = ns.Node
+	}
+	m.nodeLock.RUnlock()
+	if !ok {
+		return fmt.Errorf("unknown node %q", node)
+	}
+
+	switch state {
+	case StateAlive:
+		a := alive{
+			Incarnation: incarnation,
+			Node:        n.Name,
+			Addr:        n.Addr,
+			Port:        n.Port,
+			Meta:        n.Meta,
+			Vsn: []uint8{
+				n.PMin, n.PMax, n.PCur,
+				n.DMin, n.DMax, n.DCur,
+			},
+			Addrs:      n.Addrs,
+			Role:       n.Role,
+			Zone:       n.Zone,
+			Weight:     n.Weight,
+			Draining:   n.Draining,
+			ID:         n.ID,
+			StreamPort: n.StreamPort,
+		}
+		m.aliveNode(&a, nil, false)
+	case StateSuspect:
+		s := suspect{Incarnation: incarnation, Node: node, From: m.config.Name, Reason: ReasonForced}
+		m.suspectNode(&s)
+	case StateDead:
+		d := dead{Incarnation: incarnation, Node: node, From: m.config.Name, Reason: ReasonForced}
+		m.deadNode(&d)
+	default:
+		return fmt.Errorf("unsupported node state %s", state.metricsString())
+	}
+	return nil
+}
+
 // resetNodes is used when the tick wraps around. It will reap the
 // dead nodes and shuffle the node list.
 func (m *Memberlist) resetNodes() {
@@ -570,17 +1042,30 @@ func (m *Memberlist) resetNodes() {
 	deadIdx := moveDeadNodes(m.nodes, m.config.GossipToTheDeadTime)
 
 	// Deregister the dead nodes
+	fd := m.config.FailureDetector
 	for i := deadIdx; i < len(m.nodes); i++ {
+		if fd != nil {
+			fd.Remove(m.nodes[i].Name)
+		}
+		m.forgetObserver(m.nodes[i].Name)
+		m.rememberLost(m.nodes[i])
+		m.bury(pushNodeStateOf(m.nodes[i]), 0)
+		m.flipDigest(m.nodes[i])
 		delete(m.nodeMap, m.nodes[i].Name)
 		m.nodes[i] = nil
 	}
 
 	// Trim the nodes to exclude the dead nodes
 	m.nodes = m.nodes[0:deadIdx]
+	m.pruneTombstones()
+	m.pruneStrings()
 
 	// Update numNodes after we've trimmed the dead nodes
 	atomic.StoreUint32(&m.numNodes, uint32(deadIdx))
 
+	// Let any nodes that have settled down out of damping
+	m.undampNodes()
+
 	// Shuffle live nodes
 	shuffleNodes(m.nodes)
 }
@@ -589,11 +1074,14 @@ func (m *Memberlist) resetNodes() {
 // messages to a few random nodes.
 func (m *Memberlist) gossip() {
 	defer metrics.MeasureSinceWithLabels([]string{"memberlist", "gossip"}, time.Now(), m.metricLabels)
+	if m.chaos.gossipMuted() {
+		return
+	}
 
 	// Get some random live, suspect, or recently dead nodes
 	m.nodeLock.RLock()
-	kNodes := kRandomNodes(m.config.GossipNodes, m.nodes, func(n *nodeState) bool {
-		if n.Name == m.config.Name {
+	kNodes := m.kRandomZoneNodes(m.config.GossipNodes, func(n *nodeState) bool {
+		if n.Name == m.config.Name || n.Role == Observer || m.breakerOpen(n.Name) {
 			return true
 		}
 
@@ -611,32 +1099,60 @@ func (m *Memberlist) gossip() {
 	m.nodeLock.RUnlock()
 
 	// Compute the bytes available
-	bytesAvail := m.config.UDPBufferSize - compoundHeaderOverhead - labelOverhead(m.config.Label)
-	if m.config.EncryptionEnabled() {
-		bytesAvail -= encryptOverhead(m.encryptionVersion())
-	}
+	bytesAvail := m.config.UDPBufferSize - compoundHeaderOverhead - m.packetOverhead()
 
-	for _, node := range kNodes {
-		// Get any pending broadcasts
+	// Our digest, if we're sending one, goes to the nodes that understand
+	// it.
+	digest := m.encodeDigest()
+
+	// One packet to the multicast group stands in for all but one of the
+	// unicast ones. We can't tell who's listening to it, so it doesn't get
+	// the digest.
+	if group, ok := m.multicastAddress(); ok {
 		msgs := m.getBroadcasts(compoundOverhead, bytesAvail)
 		if len(msgs) == 0 {
 			return
 		}
+		m.sendGossip(group, nil, msgs)
+		kNodes = kNodes[:min(len(kNodes), 1)]
+	}
 
-		addr := node.Address()
-		if len(msgs) == 1 {
-			// Send single message as is
-			if err := m.rawSendMsgPacket(node.FullAddress(), &node, msgs[0]); err != nil {
-				m.logger.Printf("[ERR] memberlist: Failed to send gossip to %s: %s", addr, err)
-			}
-		} else {
-			// Otherwise create and send one or more compound messages
-			compounds := makeCompoundMessages(msgs)
-			for _, compound := range compounds {
-				if err := m.rawSendMsgPacket(node.FullAddress(), &node, compound.Bytes()); err != nil {
-					m.logger.Printf("[ERR] memberlist: Failed to send gossip to %s: %s", addr, err)
-				}
-			}
+	for _, node := range kNodes {
+		// Leave room for the digest, if the node gets one
+		avail := bytesAvail - m.mtuShortfall(node.Name)
+		sendDigest := digest != nil && node.Supports(FeatureGossipDigest)
+		if sendDigest {
+			avail -= compoundOverhead + len(digest)
+		}
+
+		// Get any pending broadcasts that fit the path to the node
+		msgs := m.getBroadcasts(compoundOverhead, avail)
+		if sendDigest {
+			msgs = append(msgs, digest)
+		}
+		if len(msgs) == 0 {
+			continue
+		}
+		m.sendGossip(node.FullAddress(), &node, msgs)
+	}
+}
+
+// sendGossip sends a round of gossip messages to an address, in as few
+// packets as they fit in. The node is the one at the address, if any.
+func (m *Memberlist) sendGossip(a Address, node *Node, msgs [][]byte) {
+	if len(msgs) == 1 {
+		// Send single message as is
+		if err := m.rawSendMsgPacket(a, node, msgs[0]); err != nil {
+			m.logger.Printf("[ERR] memberlist: Failed to send gossip to %s: %s", a.Addr, err)
+		}
+		return
+	}
+
+	// Otherwise create and send one or more compound messages
+	compounds := makeCompoundMessages(msgs)
+	for _, compound := range compounds {
+		if err := m.rawSendMsgPacket(a, node, compound.Bytes()); err != nil {
+			m.logger.Printf("[ERR] memberlist: Failed to send gossip to %s: %s", a.Addr, err)
 		}
 	}
 }
@@ -661,15 +1177,34 @@ func (m *Memberlist) pushPull() {
 	node := nodes[0]
 
 	// Attempt a push pull
-	if err := m.pushPullNode(node.FullAddress(), false); err != nil {
+	if err := m.pushPullNode(node.StreamAddress(), false); err != nil {
 		m.logger.Printf("[ERR] memberlist: Push/Pull with %s failed: %s", node.Name, err)
 	}
 }
 
 // pushPullNode does a complete state exchange with a specific node.
 func (m *Memberlist) pushPullNode(a Address, join bool) error {
+	m.beginStream()
+	defer m.endStream()
+
 	defer metrics.MeasureSinceWithLabels([]string{"memberlist", "pushPullNode"}, time.Now(), m.metricLabels)
 
+	if !join && m.canDeltaPushPull(a) {
+		err := m.deltaPushPull(a, nil)
+		if err == nil {
+			return nil
+		}
+		m.logger.Printf("[WARN] memberlist: Delta push/pull with %s failed, falling back to a full sync: %s", a.Name, err)
+	}
+
+	trace := PushPullTrace{Addr: a.Addr, Node: a.Name, Join: join, Start: time.Now()}
+	err := m.fullPushPull(a, join)
+	m.tracePushPull(trace, err)
+	return err
+}
+
+// fullPushPull does a complete state exchange with a specific node.
+func (m *Memberlist) fullPushPull(a Address, join bool) error {
 	// Attempt to send and receive with the node
 	remote, userState, err := m.sendAndReceiveState(a, join)
 	if err != nil {
@@ -801,19 +1336,43 @@ func (m *Memberlist) verifyProtocol(remote []pushNodeState) error {
 	return nil
 }
 
-// nextSeqNo returns a usable sequence number in a thread safe way
+// nextSeqNo returns a usable sequence number in a thread safe way. Sequence
+// numbers are only ever matched for equality, so it's fine for them to wrap.
 func (m *Memberlist) nextSeqNo() uint32 {
 	return atomic.AddUint32(&m.sequenceNum, 1)
 }
 
 // nextIncarnation returns the next incarnation number in a thread safe way
 func (m *Memberlist) nextIncarnation() uint32 {
-	return atomic.AddUint32(&m.incarnation, 1)
+	return m.skipIncarnation(1)
 }
 
-// skipIncarnation adds the positive offset to the incarnation number.
+// skipIncarnation adds the positive offset to the incarnation number. See
+// wrapIncarnation for what happens when it overflows.
 func (m *Memberlist) skipIncarnation(offset uint32) uint32 {
-	return atomic.AddUint32(&m.incarnation, offset)
+	return m.bumpIncarnation(offset, func() bool {
+		m.nodeLock.RLock()
+		defer m.nodeLock.RUnlock()
+		return m.canWrapIncarnation()
+	})
+}
+
+// skipIncarnationLocked is skipIncarnation for when you hold the node lock.
+func (m *Memberlist) skipIncarnationLocked(offset uint32) uint32 {
+	return m.bumpIncarnation(offset, m.canWrapIncarnation)
+}
+
+// bumpIncarnation adds the offset to the incarnation number, with canWrap
+// checking the members if it overflows.
+func (m *Memberlist) bumpIncarnation(offset uint32, canWrap func() bool) uint32 {
+	for {
+		cur := atomic.LoadUint32(&m.incarnation)
+		inc := m.wrapIncarnation(cur, cur+offset, canWrap)
+		if atomic.CompareAndSwapUint32(&m.incarnation, cur, inc) {
+			m.persistIncarnation(inc)
+			return inc
+		}
+	}
 }
 
 // estNumNodes is used to get the current estimate of the number of nodes
@@ -825,6 +1384,8 @@ type ackMessage struct {
 	Complete  bool
 	Payload   []byte
 	Timestamp time.Time
+	Health    int    // Health score the responder reported
+	Addr      string // Alternate address the ack came from, see probeAlternateAddrs
 }
 
 // setProbeChannels is used to attach the ackCh to receive a message when an ack
@@ -833,9 +1394,9 @@ type ackMessage struct {
 // passed to the nackCh, which can be nil if not needed.
 func (m *Memberlist) setProbeChannels(seqNo uint32, ackCh chan ackMessage, nackCh chan struct{}, timeout time.Duration) {
 	// Create handler functions for acks and nacks
-	ackFn := func(payload []byte, timestamp time.Time) {
+	ackFn := func(ack ackResp, timestamp time.Time) {
 		select {
-		case ackCh <- ackMessage{true, payload, timestamp}:
+		case ackCh <- ackMessage{true, ack.Payload, timestamp, ack.Health, ""}:
 		default:
 		}
 	}
@@ -853,12 +1414,12 @@ func (m *Memberlist) setProbeChannels(seqNo uint32, ackCh chan ackMessage, nackC
 	m.ackLock.Unlock()
 
 	// Setup a reaping routing
-	ah.timer = time.AfterFunc(timeout, func() {
+	ah.timer = m.timers.AfterFunc(timeout, func() {
 		m.ackLock.Lock()
 		delete(m.ackHandlers, seqNo)
 		m.ackLock.Unlock()
 		select {
-		case ackCh <- ackMessage{false, nil, time.Now()}:
+		case ackCh <- ackMessage{false, nil, time.Now(), 0, ""}:
 		default:
 		}
 	})
@@ -869,6 +1430,14 @@ func (m *Memberlist) setProbeChannels(seqNo uint32, ackCh chan ackMessage, nackC
 // deleted. This is used for indirect pings so does not configure a function
 // for nacks.
 func (m *Memberlist) setAckHandler(seqNo uint32, ackFn func([]byte, time.Time), timeout time.Duration) {
+	m.setAckRespHandler(seqNo, func(ack ackResp, timestamp time.Time) {
+		ackFn(ack.Payload, timestamp)
+	}, timeout)
+}
+
+// setAckRespHandler is like setAckHandler, but hands the whole ack to the
+// handler.
+func (m *Memberlist) setAckRespHandler(seqNo uint32, ackFn func(ackResp, time.Time), timeout time.Duration) {
 	// Add the handler
 	ah := &ackHandler{ackFn, nil, nil}
 	m.ackLock.Lock()
@@ -876,7 +1445,7 @@ func (m *Memberlist) setAckHandler(seqNo uint32, ackFn func([]byte, time.Time),
 	m.ackLock.Unlock()
 
 	// Setup a reaping routing
-	ah.timer = time.AfterFunc(timeout, func() {
+	ah.timer = m.timers.AfterFunc(timeout, func() {
 		m.ackLock.Lock()
 		delete(m.ackHandlers, seqNo)
 		m.ackLock.Unlock()
@@ -893,7 +1462,7 @@ func (m *Memberlist) invokeAckHandler(ack ackResp, timestamp time.Time) {
 		return
 	}
 	ah.timer.Stop()
-	ah.ackFn(ack.Payload, timestamp)
+	ah.ackFn(ack, timestamp)
 }
 
 // Invokes nack handler if any is associated.
@@ -913,15 +1482,23 @@ func (m *Memberlist) invokeNackHandler(nack nackResp) {
 // This alters the node state that's passed in so this MUST be called while the
 // nodeLock is held.
 func (m *Memberlist) refute(me *nodeState, accusedInc uint32) {
+	// Decrease our health because we are being asked to refute a problem.
+	m.awareness.ApplyDelta(1)
+
+	m.reannounce(me, accusedInc)
+}
+
+// reannounce broadcasts an alive message for the local node with an
+// incarnation number that beats the given one. You must hold the node lock.
+func (m *Memberlist) reannounce(me *nodeState, accusedInc uint32) {
 	// Make sure the incarnation number beats the accusation.
-	inc := m.nextIncarnation()
-	if accusedInc >= inc {
-		inc = m.skipIncarnation(accusedInc - inc + 1)
+	inc := m.skipIncarnationLocked(1)
+	if !incarnationAfter(inc, accusedInc) {
+		inc = m.skipIncarnationLocked(accusedInc - inc + 1)
 	}
+	m.flipDigest(me)
 	me.Incarnation = inc
-
-	// Decrease our health because we are being asked to refute a problem.
-	m.awareness.ApplyDelta(1)
+	m.flipDigest(me)
 
 	// Format and broadcast an alive message.
 	a := alive{
@@ -934,10 +1511,31 @@ func (m *Memberlist) refute(me *nodeState, accusedInc uint32) {
 			me.PMin, me.PMax, me.PCur,
 			me.DMin, me.DMax, me.DCur,
 		},
+		Addrs:       me.Addrs,
+		Role:        me.Role,
+		Zone:        me.Zone,
+		ID:          me.ID,
+		StreamPort:  me.StreamPort,
+		Maintenance: me.maintenanceLeft(),
+		Weight:      me.Weight,
+		Draining:    me.Draining,
+		Complete:    true,
 	}
 	m.encodeAndBroadcast(me.Addr.String(), aliveMsg, a)
 }
 
+// keepFields sets the fields older members don't know from the node's
+// current state, except for the ID.
+func (a *alive) keepFields(n *nodeState) {
+	a.Addrs = n.Addrs
+	a.Role = n.Role
+	a.Zone = n.Zone
+	a.StreamPort = n.StreamPort
+	a.Maintenance = n.maintenanceLeft()
+	a.Weight = n.Weight
+	a.Draining = n.Draining
+}
+
 // aliveNode is invoked by the network layer when we get a message about a
 // live node.
 func (m *Memberlist) aliveNode(a *alive, notify chan struct{}, bootstrap bool) {
@@ -953,6 +1551,16 @@ func (m *Memberlist) aliveNode(a *alive, notify chan struct{}, bootstrap bool) {
 		return
 	}
 
+	// An older member relaying this message, or pushing its copy of the
+	// node, drops the fields it doesn't know. Keep the ones we have rather
+	// than resetting them. The ID is kept separately below, as a message
+	// mustn't gain the node's ID by passing through an older member.
+	if ok && !a.Complete {
+		kept := *a
+		kept.keepFields(state)
+		a = &kept
+	}
+
 	if len(a.Vsn) >= 3 {
 		pMin := a.Vsn[0]
 		pMax := a.Vsn[1]
@@ -961,6 +1569,10 @@ func (m *Memberlist) aliveNode(a *alive, notify chan struct{}, bootstrap bool) {
 			m.logger.Printf("[WARN] memberlist: Ignoring an alive message for '%s' (%v:%d) because protocol version(s) are wrong: %d <= %d <= %d should be >0", a.Node, net.IP(a.Addr), a.Port, pMin, pCur, pMax)
 			return
 		}
+		if err := canSpeak(pMin, pMax); err != nil {
+			m.logger.Printf("[WARN] memberlist: Ignoring an alive message for '%s' (%v:%d): %v", a.Node, net.IP(a.Addr), a.Port, err)
+			return
+		}
 	}
 
 	// Invoke the Alive delegate if any. This can be used to filter out
@@ -974,18 +1586,29 @@ func (m *Memberlist) aliveNode(a *alive, notify chan struct{}, bootstrap bool) {
 			return
 		}
 		node := &Node{
-			Name: a.Node,
-			Addr: a.Addr,
-			Port: a.Port,
-			Meta: a.Meta,
-			PMin: a.Vsn[0],
-			PMax: a.Vsn[1],
-			PCur: a.Vsn[2],
-			DMin: a.Vsn[3],
-			DMax: a.Vsn[4],
-			DCur: a.Vsn[5],
-		}
-		if err := m.config.Alive.NotifyAlive(node); err != nil {
+			Name:       a.Node,
+			Addr:       a.Addr,
+			Port:       a.Port,
+			Meta:       a.Meta,
+			Addrs:      a.Addrs,
+			Role:       a.Role,
+			Zone:       a.Zone,
+			Weight:     a.Weight,
+			Draining:   a.Draining,
+			ID:         a.ID,
+			StreamPort: a.StreamPort,
+			PMin:       a.Vsn[0],
+			PMax:       a.Vsn[1],
+			PCur:       a.Vsn[2],
+			DMin:       a.Vsn[3],
+			DMax:       a.Vsn[4],
+			DCur:       a.Vsn[5],
+		}
+		var err error
+		if !m.guard("NotifyAlive", func() { err = m.config.Alive.NotifyAlive(node) }) {
+			err = errDelegatePanic
+		}
+		if err != nil {
 			m.logger.Printf("[WARN] memberlist: ignoring alive message for '%s': %s",
 				a.Node, err)
 			return
@@ -996,6 +1619,10 @@ func (m *Memberlist) aliveNode(a *alive, notify chan struct{}, bootstrap bool) {
 	// store this node in our node map.
 	var updatesNode bool
 	if !ok {
+		if m.buried(a.Node, a.Incarnation) {
+			m.logger.Printf("[DEBUG] memberlist: Ignoring stale alive message for reaped node %s", a.Node)
+			return
+		}
 		errCon := m.config.IPAllowed(a.Addr)
 		if errCon != nil {
 			m.logger.Printf("[WARN] memberlist: Rejected node %s (%v): %s", a.Node, net.IP(a.Addr), errCon)
@@ -1003,10 +1630,17 @@ func (m *Memberlist) aliveNode(a *alive, notify chan struct{}, bootstrap bool) {
 		}
 		state = &nodeState{
 			Node: Node{
-				Name: a.Node,
-				Addr: a.Addr,
-				Port: a.Port,
-				Meta: a.Meta,
+				Name:       a.Node,
+				Addr:       compactIP(a.Addr),
+				Port:       a.Port,
+				Meta:       a.Meta,
+				Addrs:      a.Addrs,
+				Role:       a.Role,
+				Zone:       m.intern(a.Zone),
+				Weight:     a.Weight,
+				Draining:   a.Draining,
+				ID:         a.ID,
+				StreamPort: a.StreamPort,
 			},
 			State: StateDead,
 		}
@@ -1032,19 +1666,50 @@ func (m *Memberlist) aliveNode(a *alive, notify chan struct{}, bootstrap bool) {
 		// Add at the end and swap with the node at the offset
 		m.nodes = append(m.nodes, state)
 		m.nodes[offset], m.nodes[n] = m.nodes[n], m.nodes[offset]
+		m.flipDigest(state)
 
 		// Update numNodes after we've added a new node
 		atomic.AddUint32(&m.numNodes, 1)
+
+		// Apply anything we heard about it before it was added
+		m.releaseUnknown(a.Node)
 	} else {
-		// Check if this address is different than the existing node unless the old node is dead.
-		if !bytes.Equal([]byte(state.Addr), a.Addr) || state.Port != a.Port {
-			errCon := m.config.IPAllowed(a.Addr)
-			if errCon != nil {
+		// When both sides carry a stable ID we can tell another node that
+		// took over the name apart from this one. The ID is gossiped in the
+		// clear, so a matching one doesn't prove anything about a live node.
+		sameID := state.ID != "" && state.ID == a.ID
+		otherID := state.ID != "" && a.ID != "" && state.ID != a.ID
+
+		moved := !state.Addr.Equal(a.Addr) || state.Port != a.Port
+
+		// A node that left and restarted starts over with an incarnation
+		// number that can be lower than the one it left with. Once the
+		// leave has had as long to spread as we gossip to the dead, an
+		// alive message from the same ID or address is the node back
+		// rather than a stale copy, so take it whatever its incarnation
+		// instead of waiting for the node to hear of the leave and rejoin.
+		if state.State == StateLeft && (sameID || !moved) &&
+			time.Since(state.StateChange) > m.config.GossipToTheDeadTime {
+			updatesNode = true
+		}
+
+		if moved {
+			if errCon := m.config.IPAllowed(a.Addr); errCon != nil {
 				m.logger.Printf("[WARN] memberlist: Rejected IP update from %v to %v for node %s: %s", a.Node, state.Addr, net.IP(a.Addr), errCon)
 				return
 			}
-			// If DeadNodeReclaimTime is configured, check if enough time has elapsed since the node died.
-			canReclaim := (m.config.DeadNodeReclaimTime > 0 &&
+		}
+
+		// We can always move ourselves, such as when STUN finds a new
+		// public address.
+		moveSelf := bootstrap && a.Node == m.config.Name
+
+		// Check if this address is different than the existing node unless the old node is dead.
+		if !moveSelf && (otherID || moved) {
+			// If DeadNodeReclaimTime is configured, check if enough time has
+			// elapsed since the node died. A dead node with the same ID is
+			// coming back at a new address, so it can right away.
+			canReclaim := sameID || (m.config.DeadNodeReclaimTime > 0 &&
 				time.Since(state.StateChange) > m.config.DeadNodeReclaimTime)
 
 			// Allow the address to be updated if a dead node is being replaced.
@@ -1053,8 +1718,13 @@ func (m *Memberlist) aliveNode(a *alive, notify chan struct{}, bootstrap bool) {
 					state.Name, state.Addr, state.Port, net.IP(a.Addr), a.Port)
 				updatesNode = true
 			} else {
-				m.logger.Printf("[ERR] memberlist: Conflicting address for %s. Mine: %v:%d Theirs: %v:%d Old state: %v",
-					state.Name, state.Addr, state.Port, net.IP(a.Addr), a.Port, state.State)
+				if otherID {
+					m.logger.Printf("[ERR] memberlist: Conflicting ID for %s. Mine: %s Theirs: %s Old state: %v",
+						state.Name, state.ID, a.ID, state.State)
+				} else {
+					m.logger.Printf("[ERR] memberlist: Conflicting address for %s. Mine: %v:%d Theirs: %v:%d Old state: %v",
+						state.Name, state.Addr, state.Port, net.IP(a.Addr), a.Port, state.State)
+				}
 
 				// Inform the conflict delegate if provided
 				if m.config.Conflict != nil {
@@ -1063,8 +1733,9 @@ func (m *Memberlist) aliveNode(a *alive, notify chan struct{}, bootstrap bool) {
 						Addr: a.Addr,
 						Port: a.Port,
 						Meta: a.Meta,
+						ID:   a.ID,
 					}
-					m.config.Conflict.NotifyConflict(&state.Node, &other)
+					m.guard("NotifyConflict", func() { m.config.Conflict.NotifyConflict(&state.Node, &other) })
 				}
 				return
 			}
@@ -1073,12 +1744,12 @@ func (m *Memberlist) aliveNode(a *alive, notify chan struct{}, bootstrap bool) {
 
 	// Bail if the incarnation number is older, and this is not about us
 	isLocalNode := state.Name == m.config.Name
-	if a.Incarnation <= state.Incarnation && !isLocalNode && !updatesNode {
+	if !incarnationAfter(a.Incarnation, state.Incarnation) && !isLocalNode && !updatesNode {
 		return
 	}
 
 	// Bail if strictly less and this is about us
-	if a.Incarnation < state.Incarnation && isLocalNode {
+	if incarnationAfter(state.Incarnation, a.Incarnation) && isLocalNode {
 		return
 	}
 
@@ -1088,6 +1759,7 @@ func (m *Memberlist) aliveNode(a *alive, notify chan struct{}, bootstrap bool) {
 	// Store the old state and meta data
 	oldState := state.State
 	oldMeta := state.Meta
+	oldDraining := state.Draining
 
 	// If this is us we need to refute, otherwise re-broadcast
 	if !bootstrap && isLocalNode {
@@ -1129,29 +1801,54 @@ func (m *Memberlist) aliveNode(a *alive, notify chan struct{}, bootstrap bool) {
 		}
 
 		// Update the state and incarnation number
+		m.flipDigest(state)
 		state.Incarnation = a.Incarnation
 		state.Meta = a.Meta
-		state.Addr = a.Addr
+		if !state.Addr.Equal(a.Addr) {
+			state.Addr = compactIP(a.Addr)
+		}
 		state.Port = a.Port
+		if !equalStrings(state.Addrs, a.Addrs) {
+			state.Addrs = a.Addrs
+			state.activeAddr = ""
+		}
+		state.Role = a.Role
+		state.Zone = m.intern(a.Zone)
+		state.Weight = a.Weight
+		state.Draining = a.Draining
+		if a.ID != "" {
+			// Messages relayed by older members don't carry the ID.
+			state.ID = a.ID
+		}
+		state.StreamPort = a.StreamPort
+		state.maintenanceUntil = m.maintenanceUntil(a.Maintenance)
+		m.bumpMembers()
 		if state.State != StateAlive {
 			state.State = StateAlive
+			state.Reason = ReasonUnknown
 			state.StateChange = time.Now()
+			m.membersChanged()
+			m.recordRecovery(state.Name)
+			delete(m.lostNodes, state.Name)
 		}
+		m.flipDigest(state)
 	}
 
 	// Update metrics
 	metrics.IncrCounterWithLabels([]string{"memberlist", "msg", "alive"}, 1, m.metricLabels)
 
-	// Notify the delegate of any relevant updates
-	if m.config.Events != nil {
-		if oldState == StateDead || oldState == StateLeft {
-			// if Dead/Left -> Alive, notify of join
-			m.config.Events.NotifyJoin(&state.Node)
+	// Notify the delegate and journal of any relevant updates
+	if oldState == StateLeft {
+		// if Left -> Alive, notify of a rejoin
+		m.notifyRejoin(&state.Node)
 
-		} else if !bytes.Equal(oldMeta, state.Meta) {
-			// if Meta changed, trigger an update notification
-			m.config.Events.NotifyUpdate(&state.Node)
-		}
+	} else if oldState == StateDead {
+		// if Dead -> Alive, notify of join
+		m.notifyEvent(NodeJoin, &state.Node)
+
+	} else if !bytes.Equal(oldMeta, state.Meta) || oldDraining != state.Draining {
+		// if Meta or draining changed, trigger an update notification
+		m.notifyEvent(NodeUpdate, &state.Node)
 	}
 }
 
@@ -1168,7 +1865,7 @@ func (m *Memberlist) suspectNode(s *suspect) {
 	}
 
 	// Ignore old incarnation numbers
-	if s.Incarnation < state.Incarnation {
+	if incarnationAfter(state.Incarnation, s.Incarnation) {
 		return
 	}
 
@@ -1177,7 +1874,7 @@ func (m *Memberlist) suspectNode(s *suspect) {
 	// independent confirmations to flow even when a node probes a node
 	// that's already suspect.
 	if timer, ok := m.nodeTimers[s.Node]; ok {
-		if timer.Confirm(s.From) {
+		if timer.Confirm(m.internName(s.From)) {
 			m.encodeAndBroadcast(s.Node, suspectMsg, s)
 		}
 		return
@@ -1188,6 +1885,12 @@ func (m *Memberlist) suspectNode(s *suspect) {
 		return
 	}
 
+	// Don't suspect nodes that said they're in maintenance
+	if state.Name != m.config.Name && state.inMaintenance(time.Now()) {
+		m.logger.Printf("[DEBUG] memberlist: Ignoring suspect message for %s, which is in maintenance (from: %s)", s.Node, s.From)
+		return
+	}
+
 	// If this is us we need to refute, otherwise re-broadcast
 	if state.Name == m.config.Name {
 		m.refute(state, s.Incarnation)
@@ -1201,10 +1904,17 @@ func (m *Memberlist) suspectNode(s *suspect) {
 	metrics.IncrCounterWithLabels([]string{"memberlist", "msg", "suspect"}, 1, m.metricLabels)
 
 	// Update the state
+	m.flipDigest(state)
 	state.Incarnation = s.Incarnation
 	state.State = StateSuspect
+	m.flipDigest(state)
+	state.Reason = s.Reason
+	m.logger.Printf("[DEBUG] memberlist: Marking %s as suspect (reason: %s, from: %s)", state.Name, state.Reason, s.From)
+	m.bumpMembers()
 	changeTime := time.Now()
 	state.StateChange = changeTime
+	m.recordFailure(state.Name)
+	m.recordFlap(state)
 
 	// Setup a suspicion timer. Given that we don't have any known phase
 	// relationship with our peers, we set up k such that we hit the nominal
@@ -1220,8 +1930,12 @@ func (m *Memberlist) suspectNode(s *suspect) {
 		k = 0
 	}
 
-	// Compute the timeouts based on the size of the cluster.
-	min := suspicionTimeout(m.config.SuspicionMult, n, m.config.ProbeInterval)
+	// Compute the timeouts based on the size of the cluster. A node that
+	// told us it's struggling gets a longer grace period, the same way we
+	// scale our own probe timeouts with our health.
+	min := suspicionTimeout(m.config.SuspicionMult, n, m.Tuning().ProbeInterval)
+	min = scaleByHealth(min, state.health, m.config.AwarenessMaxMultiplier)
+	min *= m.flapMultiplier(state)
 	max := time.Duration(m.config.SuspicionMaxTimeoutMult) * min
 	fn := func(numConfirmations int) {
 		var d *dead
@@ -1230,7 +1944,7 @@ func (m *Memberlist) suspectNode(s *suspect) {
 		state, ok := m.nodeMap[s.Node]
 		timeout := ok && state.State == StateSuspect && state.StateChange.Equal(changeTime)
 		if timeout {
-			d = &dead{Incarnation: state.Incarnation, Node: state.Name, From: m.config.Name}
+			d = &dead{Incarnation: state.Incarnation, Node: state.Name, From: m.config.Name, Reason: ReasonSuspicionExpired}
 		}
 		m.nodeLock.Unlock()
 
@@ -1245,7 +1959,7 @@ func (m *Memberlist) suspectNode(s *suspect) {
 			m.deadNode(d)
 		}
 	}
-	m.nodeTimers[s.Node] = newSuspicion(s.From, k, min, max, fn)
+	m.nodeTimers[state.Name] = newSuspicion(m.timers, m.internName(s.From), k, min, max, fn)
 }
 
 // deadNode is invoked by the network layer when we get a message
@@ -1261,7 +1975,7 @@ func (m *Memberlist) deadNode(d *dead) {
 	}
 
 	// Ignore old incarnation numbers
-	if d.Incarnation < state.Incarnation {
+	if incarnationAfter(state.Incarnation, d.Incarnation) {
 		return
 	}
 
@@ -1275,7 +1989,12 @@ func (m *Memberlist) deadNode(d *dead) {
 
 	// Check if this is us
 	if state.Name == m.config.Name {
-		// If we are not leaving we need to refute
+		// If we are not leaving we need to refute, unless this is the
+		// cluster remembering a leave from before we restarted.
+		if !m.hasLeft() && d.Node == d.From {
+			m.rejoin(state, d.Incarnation)
+			return
+		}
 		if !m.hasLeft() {
 			m.refute(state, d.Incarnation)
 			m.logger.Printf("[WARN] memberlist: Refuting a dead message (from: %s)", d.From)
@@ -1283,7 +2002,7 @@ func (m *Memberlist) deadNode(d *dead) {
 		}
 
 		// If we are leaving, we broadcast and wait
-		m.encodeBroadcastNotify(d.Node, deadMsg, d, m.leaveBroadcast)
+		m.leaveMsg = m.encodeTrackedBroadcast(d.Node, deadMsg, d, m.leaveBroadcast)
 	} else {
 		m.encodeAndBroadcast(d.Node, deadMsg, d)
 	}
@@ -1292,26 +2011,40 @@ func (m *Memberlist) deadNode(d *dead) {
 	metrics.IncrCounterWithLabels([]string{"memberlist", "msg", "dead"}, 1, m.metricLabels)
 
 	// Update the state
+	m.flipDigest(state)
 	state.Incarnation = d.Incarnation
 
 	// If the dead message was send by the node itself, mark it is left
 	// instead of dead.
 	if d.Node == d.From {
 		state.State = StateLeft
+		state.Reason = ReasonLeft
+		if m.highWater > 0 {
+			m.highWater-- // Leaving isn't a loss to reconnect
+		}
 	} else {
 		state.State = StateDead
+		state.Reason = d.Reason
+		m.recordFailure(state.Name)
 	}
+	m.flipDigest(state)
 	state.StateChange = time.Now()
+	if state.Name != m.config.Name {
+		m.logger.Printf("[INFO] memberlist: Marking %s as %s (reason: %s, from: %s)", state.Name, state.State.metricsString(), state.Reason, d.From)
+	}
+	m.membersChanged()
 
 	// Notify of death
-	if m.config.Events != nil {
-		m.config.Events.NotifyLeave(&state.Node)
-	}
+	m.notifyEvent(NodeLeave, &state.Node)
 }
 
 // mergeState is invoked by the network layer when we get a Push/Pull
 // state transfer
 func (m *Memberlist) mergeState(remote []pushNodeState) {
+	m.beginEvents()
+	defer m.endEvents()
+
+	m.buryRemote(remote)
 	for _, r := range remote {
 		switch r.State {
 		case StateAlive:
@@ -1322,19 +2055,29 @@ func (m *Memberlist) mergeState(remote []pushNodeState) {
 				Port:        r.Port,
 				Meta:        r.Meta,
 				Vsn:         r.Vsn,
+				Addrs:       r.Addrs,
+				Role:        r.Role,
+				Zone:        r.Zone,
+				ID:          r.ID,
+				StreamPort:  r.StreamPort,
+				Maintenance: r.Maintenance,
+				Weight:      r.Weight,
+				Draining:    r.Draining,
+				Complete:    r.Complete,
 			}
 			m.aliveNode(&a, nil, false)
 
 		case StateLeft:
-			d := dead{Incarnation: r.Incarnation, Node: r.Name, From: r.Name}
+			d := dead{Incarnation: r.Incarnation, Node: r.Name, From: r.Name, Reason: ReasonLeft}
 			m.deadNode(&d)
 		case StateDead:
 			// If the remote node believes a node is dead, we prefer to
 			// suspect that node instead of declaring it dead instantly
 			fallthrough
 		case StateSuspect:
-			s := suspect{Incarnation: r.Incarnation, Node: r.Name, From: m.config.Name}
+			s := suspect{Incarnation: r.Incarnation, Node: r.Name, From: m.config.Name, Reason: r.Reason}
 			m.suspectNode(&s)
 		}
 	}
+	m.mergeRemoteEntries(remote)
 }
@@ -1075,6 +1075,44 @@ func TestMemberList_ProbeNode(t *testing.T) {
 	}
 }
 
+func TestMemberList_ProbeNode_AlternateAddr(t *testing.T) {
+	addr1 := getBindAddr()
+	addr2 := getBindAddr()
+	addr3 := getBindAddr()
+	ip1 := []byte(addr1)
+	ip3 := []byte(addr3)
+
+	m1 := HostMemberlist(addr1.String(), t, func(c *Config) {
+		c.ProbeTimeout = 10 * time.Millisecond
+		c.ProbeInterval = 200 * time.Millisecond
+	})
+	defer m1.Shutdown()
+
+	bindPort := m1.config.BindPort
+
+	m2 := HostMemberlist(addr2.String(), t, func(c *Config) {
+		c.BindPort = bindPort
+	})
+	defer m2.Shutdown()
+
+	// Nothing listens on the primary address, only on the alternate one.
+	alt := joinHostPort(addr2.String(), uint16(bindPort))
+	a1 := alive{Node: addr1.String(), Addr: ip1, Port: uint16(bindPort), Incarnation: 1}
+	m1.aliveNode(&a1, nil, true)
+	a2 := alive{Node: addr2.String(), Addr: ip3, Port: uint16(bindPort), Incarnation: 1, Addrs: []string{alt}}
+	m1.aliveNode(&a2, nil, false)
+
+	n := m1.nodeMap[addr2.String()]
+	m1.probeNode(n)
+
+	require.Equal(t, StateAlive, n.State)
+
+	m1.nodeLock.RLock()
+	got := n.Address()
+	m1.nodeLock.RUnlock()
+	require.Equal(t, alt, got)
+}
+
 func TestMemberList_Ping(t *testing.T) {
 	addr1 := getBindAddr()
 	addr2 := getBindAddr()
@@ -1276,7 +1314,7 @@ func TestMemberList_invokeAckHandler(t *testing.T) {
 	m.setAckHandler(0, f, 10*time.Millisecond)
 
 	// Should set b
-	m.invokeAckHandler(ackResp{0, nil}, time.Now())
+	m.invokeAckHandler(ackResp{SeqNo: 0}, time.Now())
 	if !b {
 		t.Fatalf("b not set")
 	}
@@ -1287,7 +1325,7 @@ func TestMemberList_invokeAckHandler(t *testing.T) {
 func TestMemberList_invokeAckHandler_Channel_Ack(t *testing.T) {
 	m := &Memberlist{ackHandlers: make(map[uint32]*ackHandler)}
 
-	ack := ackResp{0, []byte{0, 0, 0}}
+	ack := ackResp{SeqNo: 0, Payload: []byte{0, 0, 0}}
 
 	// Does nothing
 	m.invokeAckHandler(ack, time.Now())
@@ -1348,7 +1386,7 @@ func TestMemberList_invokeAckHandler_Channel_Nack(t *testing.T) {
 	// an ack up to the reap time, if we get one.
 	require.True(t, ackHandlerExists(t, m, 0), "handler should not be reaped")
 
-	ack := ackResp{0, []byte{0, 0, 0}}
+	ack := ackResp{SeqNo: 0, Payload: []byte{0, 0, 0}}
 	m.invokeAckHandler(ack, time.Now())
 
 	select {
@@ -1767,6 +1805,47 @@ func TestMemberList_SuspectNode_NoNode(t *testing.T) {
 	}
 }
 
+func TestMemberList_SuspectNode_RemoteHealth(t *testing.T) {
+	m := GetMemberlist(t, func(c *Config) {
+		c.ProbeInterval = 10 * time.Millisecond
+		c.SuspicionMult = 1
+	})
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	a := alive{Node: "test", Addr: []byte{127, 0, 0, 1}, Incarnation: 1, Vsn: m.config.BuildVsnArray()}
+	m.aliveNode(&a, nil, false)
+
+	// The node reports it's struggling in its last ack.
+	m.probeSucceeded("test", m.config.AwarenessMaxMultiplier-1)
+
+	m.nodeLock.RLock()
+	health := m.nodeMap["test"].health
+	m.nodeLock.RUnlock()
+	require.Equal(t, m.config.AwarenessMaxMultiplier-1, health)
+
+	s := suspect{Node: "test", Incarnation: 1}
+	m.suspectNode(&s)
+	require.Equal(t, StateSuspect, m.getNodeState("test"))
+
+	// An unloaded node would already be dead by now.
+	time.Sleep(30 * time.Millisecond)
+	require.Equal(t, StateSuspect, m.getNodeState("test"))
+
+	iretry.Run(t, func(r *iretry.R) {
+		if m.getNodeState("test") != StateDead {
+			r.Fatal("expected node to be dead")
+		}
+	})
+}
+
+func TestScaleByHealth(t *testing.T) {
+	require.Equal(t, time.Second, scaleByHealth(time.Second, 0, 8))
+	require.Equal(t, 3*time.Second, scaleByHealth(time.Second, 2, 8))
+	require.Equal(t, 8*time.Second, scaleByHealth(time.Second, 100, 8))
+}
+
 func TestMemberList_SuspectNode(t *testing.T) {
 	m := GetMemberlist(t, func(c *Config) {
 		c.ProbeInterval = time.Millisecond
@@ -2719,3 +2798,375 @@ func verifySampleExists(t *testing.T, name string, sink *metrics.InmemSink) {
 		t.Fatalf("%s sample not emmited", name)
 	}
 }
+
+func TestNode_Addresses(t *testing.T) {
+	n := &Node{
+		Name:  "test",
+		Addr:  net.ParseIP("127.0.0.1"),
+		Port:  7946,
+		Addrs: []string{"127.0.0.1:7946", "[::1]:7946"},
+	}
+	require.Equal(t, []string{"127.0.0.1:7946", "[::1]:7946"}, n.Addresses())
+	require.Equal(t, "127.0.0.1:7946", n.Address())
+
+	n.activeAddr = "[::1]:7946"
+	require.Equal(t, "[::1]:7946", n.Address())
+	require.Equal(t, Address{Addr: "[::1]:7946", Name: "test"}, n.FullAddress())
+}
+
+func TestMemberList_DisableProbes(t *testing.T) {
+	m := GetMemberlist(t, func(c *Config) {
+		c.DisableProbes = true
+		c.GossipInterval = 0
+		c.ReconnectInterval = 0
+	})
+	defer m.Shutdown()
+
+	m.schedule()
+	defer m.deschedule()
+
+	m.tickerLock.Lock()
+	defer m.tickerLock.Unlock()
+	require.Empty(t, m.tickers)
+}
+
+func TestMemberList_SetNodeState(t *testing.T) {
+	m := GetMemberlist(t, func(c *Config) {
+		c.DisableProbes = true
+	})
+	defer m.Shutdown()
+
+	a := alive{Node: "test", Addr: []byte{127, 0, 0, 1}, Incarnation: 1, Vsn: m.config.BuildVsnArray()}
+	m.aliveNode(&a, nil, false)
+
+	require.Error(t, m.SetNodeState("nope", StateDead, 1))
+	require.Error(t, m.SetNodeState(m.config.Name, StateDead, 1))
+	require.Error(t, m.SetNodeState("test", StateLeft, 1))
+
+	require.NoError(t, m.SetNodeState("test", StateSuspect, 1))
+	require.Equal(t, StateSuspect, m.getNodeState("test"))
+
+	// A stale verdict is ignored.
+	require.NoError(t, m.SetNodeState("test", StateAlive, 1))
+	require.Equal(t, StateSuspect, m.getNodeState("test"))
+
+	require.NoError(t, m.SetNodeState("test", StateAlive, 2))
+	require.Equal(t, StateAlive, m.getNodeState("test"))
+
+	require.NoError(t, m.SetNodeState("test", StateDead, 2))
+	require.Equal(t, StateDead, m.getNodeState("test"))
+}
+
+type testFailureDetector struct {
+	suspect    bool
+	heartbeats int
+	suspects   int
+}
+
+func (d *testFailureDetector) Heartbeat(node string, now time.Time) { d.heartbeats++ }
+
+func (d *testFailureDetector) Suspect(node string, now time.Time) bool {
+	d.suspects++
+	return d.suspect
+}
+
+func (d *testFailureDetector) Remove(node string) {}
+
+func TestMemberList_ProbeNode_FailureDetector(t *testing.T) {
+	addr1 := getBindAddr()
+	addr2 := getBindAddr()
+	addr3 := getBindAddr()
+	ip1 := []byte(addr1)
+	ip2 := []byte(addr2)
+	ip3 := []byte(addr3)
+
+	fd := &testFailureDetector{}
+	m1 := HostMemberlist(addr1.String(), t, func(c *Config) {
+		c.ProbeTimeout = time.Millisecond
+		c.ProbeInterval = 10 * time.Millisecond
+		c.FailureDetector = fd
+	})
+	defer m1.Shutdown()
+
+	bindPort := m1.config.BindPort
+
+	m2 := HostMemberlist(addr2.String(), t, func(c *Config) {
+		c.BindPort = bindPort
+	})
+	defer m2.Shutdown()
+
+	a1 := alive{Node: addr1.String(), Addr: ip1, Port: uint16(bindPort), Incarnation: 1, Vsn: m1.config.BuildVsnArray()}
+	m1.aliveNode(&a1, nil, true)
+	a2 := alive{Node: addr2.String(), Addr: ip2, Port: uint16(bindPort), Incarnation: 1, Vsn: m1.config.BuildVsnArray()}
+	m1.aliveNode(&a2, nil, false)
+	a3 := alive{Node: addr3.String(), Addr: ip3, Port: uint16(bindPort), Incarnation: 1, Vsn: m1.config.BuildVsnArray()}
+	m1.aliveNode(&a3, nil, false)
+
+	// A successful probe counts as a heartbeat.
+	m1.probeNode(m1.nodeMap[addr2.String()])
+	require.Equal(t, 1, fd.heartbeats)
+
+	// The detector can hold off on suspicion of a node that doesn't answer.
+	n := m1.nodeMap[addr3.String()]
+	m1.probeNode(n)
+	require.Equal(t, 1, fd.suspects)
+	require.Equal(t, StateAlive, n.State)
+
+	fd.suspect = true
+	m1.probeNode(n)
+	require.Equal(t, 2, fd.suspects)
+	require.Equal(t, StateSuspect, n.State)
+}
+
+func TestMemberList_Probe_SkipsObservers(t *testing.T) {
+	m := GetMemberlist(t, func(c *Config) {
+		c.ProbeInterval = time.Hour
+	})
+	defer m.Shutdown()
+
+	a1 := alive{Node: m.config.Name, Addr: []byte{127, 0, 0, 1}, Incarnation: 1, Vsn: m.config.BuildVsnArray()}
+	m.aliveNode(&a1, nil, true)
+	a2 := alive{Node: "observer", Addr: []byte{127, 0, 0, 2}, Incarnation: 1, Vsn: m.config.BuildVsnArray(), Role: Observer}
+	m.aliveNode(&a2, nil, false)
+	require.Equal(t, Observer, m.nodeMap["observer"].Role)
+
+	m.probe()
+	require.Equal(t, uint32(0), atomic.LoadUint32(&m.sequenceNum))
+	require.Equal(t, StateAlive, m.getNodeState("observer"))
+}
+
+func TestMemberList_CheckObserver(t *testing.T) {
+	m := GetMemberlist(t, func(c *Config) {
+		c.ProbeInterval = time.Millisecond
+	})
+	defer m.Shutdown()
+
+	a1 := alive{Node: m.config.Name, Addr: []byte{127, 0, 0, 1}, Incarnation: 1, Vsn: m.config.BuildVsnArray()}
+	m.aliveNode(&a1, nil, true)
+	a2 := alive{Node: "observer", Addr: []byte{127, 0, 0, 2}, Incarnation: 1, Vsn: m.config.BuildVsnArray(), Role: Observer}
+	m.aliveNode(&a2, nil, false)
+	m.changeNode("observer", func(state *nodeState) {
+		state.StateChange = state.StateChange.Add(-time.Hour)
+	})
+
+	// An observer that probed us recently is left alone.
+	m.observerHeard("observer")
+	m.checkObserver(m.nodeMap["observer"])
+	require.Equal(t, StateAlive, m.getNodeState("observer"))
+
+	// One that has gone quiet gets suspected.
+	m.observerLock.Lock()
+	m.observerSeen["observer"] = time.Now().Add(-time.Hour)
+	m.observerLock.Unlock()
+	m.checkObserver(m.nodeMap["observer"])
+	require.Equal(t, StateSuspect, m.getNodeState("observer"))
+}
+
+func TestMemberList_ProbeNode_ObserverDoesNotSuspect(t *testing.T) {
+	addr1 := getBindAddr()
+	addr2 := getBindAddr()
+	ip1 := []byte(addr1)
+	ip2 := []byte(addr2)
+
+	m1 := HostMemberlist(addr1.String(), t, func(c *Config) {
+		c.ProbeTimeout = time.Millisecond
+		c.ProbeInterval = 10 * time.Millisecond
+		c.Role = Observer
+	})
+	defer m1.Shutdown()
+
+	bindPort := m1.config.BindPort
+	a1 := alive{Node: addr1.String(), Addr: ip1, Port: uint16(bindPort), Incarnation: 1, Vsn: m1.config.BuildVsnArray()}
+	m1.aliveNode(&a1, nil, true)
+	a2 := alive{Node: addr2.String(), Addr: ip2, Port: uint16(bindPort), Incarnation: 1, Vsn: m1.config.BuildVsnArray()}
+	m1.aliveNode(&a2, nil, false)
+
+	n := m1.nodeMap[addr2.String()]
+	m1.probeNode(n)
+	require.Equal(t, StateAlive, n.State)
+}
+
+func TestMemberList_KRandomZoneNodes(t *testing.T) {
+	m := GetMemberlist(t, func(c *Config) {
+		c.Zone = "a"
+		c.CrossZoneFraction = 0.5
+	})
+	defer m.Shutdown()
+
+	for i := 0; i < 8; i++ {
+		zone := "a"
+		if i%2 == 1 {
+			zone = "b"
+		}
+		a := alive{Node: fmt.Sprintf("node-%d", i), Addr: []byte{127, 0, 0, byte(i + 1)}, Incarnation: 1, Vsn: m.config.BuildVsnArray(), Zone: zone}
+		m.aliveNode(&a, nil, false)
+	}
+	require.Equal(t, "b", m.nodeMap["node-1"].Zone)
+
+	none := func(*nodeState) bool { return false }
+	for i := 0; i < 50; i++ {
+		m.nodeLock.RLock()
+		nodes := m.kRandomZoneNodes(3, none)
+		m.nodeLock.RUnlock()
+		require.Len(t, nodes, 3)
+
+		cross := 0
+		for _, n := range nodes {
+			if n.Zone == "b" {
+				cross++
+			}
+		}
+		require.GreaterOrEqual(t, cross, 2)
+	}
+
+	// When there aren't enough nodes in other zones, the rest are filled in
+	// from our own.
+	onlyOneB := func(n *nodeState) bool { return n.Zone == "b" && n.Name != "node-1" }
+	m.nodeLock.RLock()
+	nodes := m.kRandomZoneNodes(3, onlyOneB)
+	m.nodeLock.RUnlock()
+	require.Len(t, nodes, 3)
+	names := make(map[string]struct{})
+	for _, n := range nodes {
+		names[n.Name] = struct{}{}
+	}
+	require.Len(t, names, 3)
+	require.Contains(t, names, "node-1")
+}
+
+func TestMemberList_AliveNode_NodeID(t *testing.T) {
+	mock := &MockConflict{}
+	m := GetMemberlist(t, func(c *Config) {
+		c.Conflict = mock
+		c.CIDRsAllowed, _ = ParseCIDRs([]string{"127.0.0.0/8"})
+	})
+	defer m.Shutdown()
+
+	const id = "5c8c3f0e-0f5e-4c1a-9a36-2f3e7bb4e3a1"
+	a := alive{Node: "test", Addr: []byte{127, 0, 0, 1}, Port: 8000, Incarnation: 1, Vsn: m.config.BuildVsnArray(), ID: id}
+	m.aliveNode(&a, nil, false)
+	require.Equal(t, id, m.nodeMap["test"].ID)
+
+	// Another node taking over the name is a conflict, even at the same
+	// address.
+	other := alive{Node: "test", Addr: []byte{127, 0, 0, 1}, Port: 8000, Incarnation: 2, Vsn: m.config.BuildVsnArray(), ID: "0e7b8b4c-7c56-4b43-8c0c-6a1f3d6e2b90"}
+	m.aliveNode(&other, nil, false)
+	require.Equal(t, id, m.nodeMap["test"].ID)
+	require.Equal(t, uint32(1), m.nodeMap["test"].Incarnation)
+	require.NotNil(t, mock.other)
+	require.Equal(t, other.ID, mock.other.ID)
+
+	// Anyone could claim the ID, so it doesn't let a live node be moved.
+	mock.other = nil
+	moved := alive{Node: "test", Addr: []byte{127, 0, 0, 2}, Port: 9000, Incarnation: 2, Vsn: m.config.BuildVsnArray(), ID: id}
+	m.aliveNode(&moved, nil, false)
+	state := m.nodeMap["test"]
+	require.Equal(t, net.IP([]byte{127, 0, 0, 1}), state.Addr)
+	require.Equal(t, uint32(1), state.Incarnation)
+	require.NotNil(t, mock.other)
+
+	// Once it's dead, the node with the same ID can come back at a new
+	// address straight away, but only an allowed one.
+	m.deadNode(&dead{Node: "test", Incarnation: 1, From: m.config.Name})
+	outside := alive{Node: "test", Addr: []byte{10, 0, 0, 2}, Port: 9000, Incarnation: 2, Vsn: m.config.BuildVsnArray(), ID: id}
+	m.aliveNode(&outside, nil, false)
+	require.Equal(t, StateDead, state.State)
+	m.aliveNode(&moved, nil, false)
+	require.Equal(t, StateAlive, state.State)
+	require.Equal(t, net.IP([]byte{127, 0, 0, 2}), state.Addr)
+	require.Equal(t, uint16(9000), state.Port)
+	require.Equal(t, uint32(2), state.Incarnation)
+
+	// An update without the ID doesn't forget it.
+	noID := alive{Node: "test", Addr: []byte{127, 0, 0, 2}, Port: 9000, Incarnation: 3, Vsn: m.config.BuildVsnArray()}
+	m.aliveNode(&noID, nil, false)
+	require.Equal(t, uint32(3), state.Incarnation)
+	require.Equal(t, id, state.ID)
+}
+
+func TestMemberList_AliveNode_KeepsFieldsOlderMembersDrop(t *testing.T) {
+	m := GetMemberlist(t, nil)
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	vsn := m.config.BuildVsnArray()
+	m.aliveNode(decodedAlive(t, alive{
+		Node:        "test",
+		Addr:        []byte{127, 0, 0, 1},
+		Port:        7946,
+		Incarnation: 1,
+		Vsn:         vsn,
+		Addrs:       []string{"10.0.0.1:7946"},
+		Role:        Observer,
+		Zone:        "us-east-1a",
+		StreamPort:  7947,
+		Weight:      10,
+		Draining:    true,
+		Complete:    true,
+	}), nil, false)
+
+	check := func(zone string, weight uint16, draining bool) {
+		t.Helper()
+		m.nodeLock.RLock()
+		defer m.nodeLock.RUnlock()
+		state := m.nodeMap["test"]
+		require.Equal(t, zone, state.Zone)
+		require.Equal(t, weight, state.Weight)
+		require.Equal(t, draining, state.Draining)
+		if zone != "" {
+			require.Equal(t, []string{"10.0.0.1:7946"}, state.Addrs)
+			require.Equal(t, Observer, state.Role)
+			require.Equal(t, uint16(7947), state.StreamPort)
+		}
+	}
+
+	// Relayed by an older member, which drops the fields it doesn't know
+	m.aliveNode(decodedAlive(t, alive{Node: "test", Addr: []byte{127, 0, 0, 1}, Port: 7946, Incarnation: 2, Vsn: vsn}), nil, false)
+	check("us-east-1a", 10, true)
+
+	// Pushed by an older member
+	m.mergeState([]pushNodeState{{Name: "test", Addr: []byte{127, 0, 0, 1}, Port: 7946, Incarnation: 3, State: StateAlive, Vsn: vsn}})
+	check("us-east-1a", 10, true)
+
+	// From a member that knows the fields, zero values are meant
+	m.aliveNode(decodedAlive(t, alive{Node: "test", Addr: []byte{127, 0, 0, 1}, Port: 7946, Incarnation: 4, Vsn: vsn, Complete: true}), nil, false)
+	check("", 0, false)
+}
+
+func TestProbeJitter(t *testing.T) {
+	require.Equal(t, time.Duration(0), probeJitter(time.Second, 0))
+	require.Equal(t, time.Duration(0), probeJitter(0, 50))
+
+	for i := 0; i < 100; i++ {
+		d := probeJitter(time.Second, 20)
+		require.GreaterOrEqual(t, d, time.Duration(0))
+		require.Less(t, d, 200*time.Millisecond)
+
+		// Capped so the probe still fits in its interval.
+		d = probeJitter(time.Second, 150)
+		require.Less(t, d, 900*time.Millisecond)
+	}
+}
+
+func TestMemberList_ProbeJitter(t *testing.T) {
+	m := GetMemberlist(t, func(c *Config) {
+		c.ProbeInterval = 10 * time.Millisecond
+		c.ProbeJitterPercent = 50
+	})
+	defer m.Shutdown()
+
+	a1 := alive{Node: m.config.Name, Addr: []byte{127, 0, 0, 1}, Incarnation: 1, Vsn: m.config.BuildVsnArray()}
+	m.aliveNode(&a1, nil, true)
+	a2 := alive{Node: "test", Addr: []byte{127, 0, 0, 2}, Incarnation: 1, Vsn: m.config.BuildVsnArray()}
+	m.aliveNode(&a2, nil, false)
+
+	// Probes still happen with jitter turned on.
+	m.schedule()
+	defer m.deschedule()
+	iretry.Run(t, func(r *iretry.R) {
+		if atomic.LoadUint32(&m.sequenceNum) == 0 {
+			r.Fatalf("expected a probe")
+		}
+	})
+}
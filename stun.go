@@ -0,0 +1,334 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+/*
+STUN (RFC 5389) lets a node behind NAT find out what its packets look like
+from outside: it sends a binding request to a STUN server, which answers with
+the address and port the request came from. The request has to go out of the
+same socket as our other packets so that it gets the same NAT mapping, so the
+transport sends it and picks the answer out of the packets it receives.
+*/
+
+const (
+	stunMagicCookie          = 0x2112A442
+	stunHeaderSize           = 20
+	stunBindingRequest       = 0x0001
+	stunBindingSuccess       = 0x0101
+	stunAttrMappedAddress    = 0x0001
+	stunAttrXORMappedAddress = 0x0020
+	stunDefaultPort          = "3478"
+
+	// stunTimeout is how long we wait for a STUN server to answer, and
+	// stunRetransmit how often the request is sent again meanwhile.
+	stunTimeout    = 3 * time.Second
+	stunRetransmit = 500 * time.Millisecond
+)
+
+// errSTUNUnsupported is returned when the transport can't do STUN.
+var errSTUNUnsupported = errors.New("transport doesn't support STUN")
+
+// STUNTransport is an optional interface for a Transport that can ask a STUN
+// server where its packets appear to come from, see Config.STUNServers.
+type STUNTransport interface {
+	// PublicAddr sends a STUN binding request to the server, from the
+	// same socket packets are sent from, and returns the address and port
+	// the server saw it come from.
+	PublicAddr(server string, timeout time.Duration) (net.IP, int, error)
+}
+
+// stunTxID identifies a STUN transaction.
+type stunTxID [12]byte
+
+// newSTUNRequest returns a binding request and its transaction ID.
+func newSTUNRequest() (stunTxID, []byte, error) {
+	var id stunTxID
+	if _, err := rand.Read(id[:]); err != nil {
+		return id, nil, err
+	}
+	buf := make([]byte, stunHeaderSize)
+	binary.BigEndian.PutUint16(buf[0:], stunBindingRequest)
+	binary.BigEndian.PutUint32(buf[4:], stunMagicCookie)
+	copy(buf[8:], id[:])
+	return id, buf, nil
+}
+
+// stunTxIDOf returns the transaction ID of a packet if it looks like a STUN
+// message.
+func stunTxIDOf(buf []byte) (stunTxID, bool) {
+	var id stunTxID
+	if len(buf) < stunHeaderSize || buf[0]&0xc0 != 0 ||
+		binary.BigEndian.Uint32(buf[4:]) != stunMagicCookie ||
+		int(binary.BigEndian.Uint16(buf[2:]))+stunHeaderSize != len(buf) {
+		return id, false
+	}
+	copy(id[:], buf[8:stunHeaderSize])
+	return id, true
+}
+
+// parseSTUNResponse returns the mapped address from a binding response.
+func parseSTUNResponse(buf []byte) (*net.UDPAddr, error) {
+	if _, ok := stunTxIDOf(buf); !ok {
+		return nil, fmt.Errorf("not a STUN message")
+	}
+	if t := binary.BigEndian.Uint16(buf); t != stunBindingSuccess {
+		return nil, fmt.Errorf("STUN request failed with message type %#04x", t)
+	}
+
+	var mapped *net.UDPAddr
+	attrs := buf[stunHeaderSize:]
+	for len(attrs) >= 4 {
+		typ := binary.BigEndian.Uint16(attrs)
+		size := int(binary.BigEndian.Uint16(attrs[2:]))
+		if len(attrs) < 4+size {
+			return nil, fmt.Errorf("truncated STUN attribute %#04x", typ)
+		}
+		value := attrs[4 : 4+size]
+		switch typ {
+		case stunAttrXORMappedAddress:
+			addr, err := parseSTUNAddress(value, buf[4:stunHeaderSize])
+			if err != nil {
+				return nil, err
+			}
+			return addr, nil
+		case stunAttrMappedAddress:
+			addr, err := parseSTUNAddress(value, nil)
+			if err != nil {
+				return nil, err
+			}
+			mapped = addr
+		}
+		// Attributes are padded to four bytes.
+		attrs = attrs[min(len(attrs), 4+(size+3)&^3):]
+	}
+	if mapped == nil {
+		return nil, fmt.Errorf("STUN response has no mapped address")
+	}
+	return mapped, nil
+}
+
+// parseSTUNAddress decodes an address attribute, XORed with the magic
+// cookie and transaction ID if they're given.
+func parseSTUNAddress(value, xor []byte) (*net.UDPAddr, error) {
+	if len(value) < 4 {
+		return nil, fmt.Errorf("STUN address is too short")
+	}
+	var ip net.IP
+	switch value[1] {
+	case 0x01:
+		ip = make(net.IP, net.IPv4len)
+	case 0x02:
+		ip = make(net.IP, net.IPv6len)
+	default:
+		return nil, fmt.Errorf("unknown STUN address family %d", value[1])
+	}
+	if len(value) != 4+len(ip) {
+		return nil, fmt.Errorf("STUN address has the wrong length")
+	}
+	port := binary.BigEndian.Uint16(value[2:])
+	copy(ip, value[4:])
+	if xor != nil {
+		port ^= uint16(stunMagicCookie >> 16)
+		for i := range ip {
+			ip[i] ^= xor[i]
+		}
+	}
+	return &net.UDPAddr{IP: ip, Port: int(port)}, nil
+}
+
+// PublicAddr sends a STUN binding request from the first UDP listener. See
+// STUNTransport.
+func (t *NetTransport) PublicAddr(server string, timeout time.Duration) (net.IP, int, error) {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, stunDefaultPort)
+	}
+	addr, err := net.ResolveUDPAddr("udp", server)
+	if err != nil {
+		return nil, 0, err
+	}
+	id, req, err := newSTUNRequest()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ch := make(chan []byte, 1)
+	t.stunLock.Lock()
+	if t.stunWaiters == nil {
+		t.stunWaiters = make(map[stunTxID]chan []byte)
+	}
+	t.stunWaiters[id] = ch
+	t.stunLock.Unlock()
+	defer func() {
+		t.stunLock.Lock()
+		delete(t.stunWaiters, id)
+		t.stunLock.Unlock()
+	}()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		if _, err := t.udpListeners[0].WriteTo(req, addr); err != nil {
+			return nil, 0, err
+		}
+		wait := min(stunRetransmit, time.Until(deadline))
+		select {
+		case resp := <-ch:
+			mapped, err := parseSTUNResponse(resp)
+			if err != nil {
+				return nil, 0, err
+			}
+			return mapped.IP, mapped.Port, nil
+		case <-time.After(wait):
+		}
+		if !time.Now().Before(deadline) {
+			return nil, 0, fmt.Errorf("no answer from STUN server %s", addr)
+		}
+	}
+}
+
+// stunResponse hands a packet to a PublicAddr waiting for it, and returns
+// true if there was one.
+func (t *NetTransport) stunResponse(buf []byte) bool {
+	id, ok := stunTxIDOf(buf)
+	if !ok {
+		return false
+	}
+	t.stunLock.Lock()
+	defer t.stunLock.Unlock()
+	ch, ok := t.stunWaiters[id]
+	if !ok {
+		return false
+	}
+	delete(t.stunWaiters, id)
+	ch <- buf
+	return true
+}
+
+// See STUNTransport.
+func (t *shimNodeAwareTransport) PublicAddr(server string, timeout time.Duration) (net.IP, int, error) {
+	if s, ok := t.Transport.(STUNTransport); ok {
+		return s.PublicAddr(server, timeout)
+	}
+	return nil, 0, errSTUNUnsupported
+}
+
+// See STUNTransport.
+func (t *labelWrappedTransport) PublicAddr(server string, timeout time.Duration) (net.IP, int, error) {
+	if s, ok := t.NodeAwareTransport.(STUNTransport); ok {
+		return s.PublicAddr(server, timeout)
+	}
+	return nil, 0, errSTUNUnsupported
+}
+
+// discoverPublicAddr asks the STUN servers in turn for our public address,
+// returning the first answer.
+func (m *Memberlist) discoverPublicAddr() (net.IP, int, error) {
+	st, ok := m.transport.(STUNTransport)
+	if !ok {
+		return nil, 0, errSTUNUnsupported
+	}
+	var errs error
+	for _, server := range m.config.STUNServers {
+		ip, port, err := st.PublicAddr(server, stunTimeout)
+		if err == nil {
+			return ip, port, nil
+		}
+		errs = multierror.Append(errs, fmt.Errorf("%s: %v", server, err))
+	}
+	return nil, 0, errs
+}
+
+// useSTUN returns true if we take our advertise address from STUN.
+func (m *Memberlist) useSTUN() bool {
+	return len(m.config.STUNServers) > 0 && m.config.AdvertiseAddr == ""
+}
+
+// stunAdvertise returns the public address found with STUN, if any.
+func (m *Memberlist) stunAdvertise() (net.IP, int, bool) {
+	m.stunLock.Lock()
+	defer m.stunLock.Unlock()
+	return m.stunIP, m.stunPort, m.stunIP != nil
+}
+
+// initPublicAddr looks up our public address at startup. If no STUN server
+// answers we go on with the address the transport picks.
+func (m *Memberlist) initPublicAddr() {
+	if !m.useSTUN() {
+		return
+	}
+	ip, port, err := m.discoverPublicAddr()
+	if err != nil {
+		m.logger.Printf("[WARN] memberlist: Failed to discover public address with STUN: %v", err)
+		return
+	}
+	m.logger.Printf("[INFO] memberlist: Discovered public address %s with STUN", joinHostPort(ip.String(), uint16(port)))
+	m.stunLock.Lock()
+	m.stunIP, m.stunPort = ip, port
+	m.stunLock.Unlock()
+}
+
+// checkPublicAddr looks up our public address again, and if it changed,
+// advertises the new one with a new alive message. Members only take a new
+// address early from a node with the same ID, see Config.STUNInterval, so
+// without a NodeID we keep the old one.
+func (m *Memberlist) checkPublicAddr() {
+	ip, port, err := m.discoverPublicAddr()
+	if err != nil {
+		m.logger.Printf("[WARN] memberlist: Failed to check public address with STUN: %v", err)
+		return
+	}
+	if oldIP, oldPort, ok := m.stunAdvertise(); ok && oldIP.Equal(ip) && oldPort == port {
+		return
+	}
+	addr := joinHostPort(ip.String(), uint16(port))
+
+	m.nodeLock.RLock()
+	state, ok := m.nodeMap[m.config.Name]
+	var a alive
+	if ok {
+		a = alive{
+			Node:        state.Name,
+			Addr:        ip,
+			Port:        uint16(port),
+			Meta:        state.Meta,
+			Vsn:         m.config.BuildVsnArray(),
+			Addrs:       state.Addrs,
+			Role:        state.Role,
+			Zone:        state.Zone,
+			ID:          state.ID,
+			StreamPort:  state.StreamPort,
+			Maintenance: state.maintenanceLeft(),
+			Weight:      state.Weight,
+			Draining:    state.Draining,
+			Complete:    true,
+		}
+	}
+	m.nodeLock.RUnlock()
+	if !ok {
+		return
+	}
+	if a.ID == "" {
+		m.logger.Printf("[WARN] memberlist: Public address changed to %s, but it can't be advertised without a NodeID", addr)
+		return
+	}
+
+	m.logger.Printf("[INFO] memberlist: Public address changed to %s", addr)
+	m.stunLock.Lock()
+	m.stunIP, m.stunPort = ip, port
+	m.stunLock.Unlock()
+	m.setAdvertise(ip, port)
+
+	a.Incarnation = m.nextIncarnation()
+	m.aliveNode(&a, nil, true)
+}
@@ -0,0 +1,115 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"encoding/binary"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// stunResponse builds a binding response mapping to the given address.
+func stunResponse(req []byte, addr *net.UDPAddr) []byte {
+	ip := addr.IP.To4()
+	attr := make([]byte, 12)
+	binary.BigEndian.PutUint16(attr[0:], stunAttrXORMappedAddress)
+	binary.BigEndian.PutUint16(attr[2:], 8)
+	attr[5] = 0x01
+	binary.BigEndian.PutUint16(attr[6:], uint16(addr.Port)^uint16(stunMagicCookie>>16))
+	for i := range ip {
+		attr[8+i] = ip[i] ^ req[4+i]
+	}
+
+	resp := make([]byte, stunHeaderSize, stunHeaderSize+len(attr))
+	copy(resp, req[:stunHeaderSize])
+	binary.BigEndian.PutUint16(resp[0:], stunBindingSuccess)
+	binary.BigEndian.PutUint16(resp[2:], uint16(len(attr)))
+	return append(resp, attr...)
+}
+
+// fakeSTUNServer answers binding requests with a settable mapping.
+type fakeSTUNServer struct {
+	conn *net.UDPConn
+
+	lock   sync.Mutex
+	mapped *net.UDPAddr
+}
+
+func newFakeSTUNServer(t *testing.T, mapped *net.UDPAddr) *fakeSTUNServer {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	s := &fakeSTUNServer{conn: conn, mapped: mapped}
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if _, ok := stunTxIDOf(buf[:n]); !ok {
+				continue
+			}
+			s.lock.Lock()
+			resp := stunResponse(buf[:n], s.mapped)
+			s.lock.Unlock()
+			_, _ = conn.WriteTo(resp, from)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSTUNServer) setMapped(addr *net.UDPAddr) {
+	s.lock.Lock()
+	s.mapped = addr
+	s.lock.Unlock()
+}
+
+func TestParseSTUNResponse(t *testing.T) {
+	id, req, err := newSTUNRequest()
+	require.NoError(t, err)
+	got, ok := stunTxIDOf(req)
+	require.True(t, ok)
+	require.Equal(t, id, got)
+
+	want := &net.UDPAddr{IP: net.IPv4(203, 0, 113, 7).To4(), Port: 40000}
+	addr, err := parseSTUNResponse(stunResponse(req, want))
+	require.NoError(t, err)
+	require.Equal(t, want, addr)
+
+	_, err = parseSTUNResponse(req)
+	require.Error(t, err)
+	_, err = parseSTUNResponse([]byte("not a stun message at all"))
+	require.Error(t, err)
+}
+
+func TestMemberlist_STUN(t *testing.T) {
+	server := newFakeSTUNServer(t, &net.UDPAddr{IP: net.IPv4(203, 0, 113, 7), Port: 40000})
+	defer server.conn.Close()
+
+	m := GetMemberlist(t, func(c *Config) {
+		c.STUNServers = []string{server.conn.LocalAddr().String()}
+		c.NodeID = "8f0c5c1e-3f6e-4b8b-9a3c-0c1f0e2d3a4b"
+	})
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+	require.NoError(t, m.setAlive())
+
+	local := m.LocalNode()
+	require.Equal(t, "203.0.113.7", local.Addr.String())
+	require.Equal(t, uint16(40000), local.Port)
+
+	// The NAT gave us a new mapping.
+	server.setMapped(&net.UDPAddr{IP: net.IPv4(203, 0, 113, 8), Port: 40001})
+	m.checkPublicAddr()
+	local = m.LocalNode()
+	require.Equal(t, "203.0.113.8", local.Addr.String())
+	require.Equal(t, uint16(40001), local.Port)
+	addr, port := m.getAdvertise()
+	require.Equal(t, "203.0.113.8", addr.String())
+	require.Equal(t, uint16(40001), port)
+}
@@ -34,7 +34,7 @@ type suspicion struct {
 	start time.Time
 
 	// timer is the underlying timer that implements the timeout.
-	timer *time.Timer
+	timer stoppableTimer
 
 	// f is the function to call when the timer expires. We hold on to this
 	// because there are cases where we call it directly.
@@ -49,8 +49,9 @@ type suspicion struct {
 // to the min time after seeing k or more confirmations. The from node will be
 // excluded from confirmations since we might get our own suspicion message
 // gossiped back to us. The minimum time will be used if no confirmations are
-// called for (k <= 0).
-func newSuspicion(from string, k int, min time.Duration, max time.Duration, fn func(int)) *suspicion {
+// called for (k <= 0). The timer is scheduled on the given wheel, which may be
+// nil to use a runtime timer.
+func newSuspicion(wheel *timerWheel, from string, k int, min time.Duration, max time.Duration, fn func(int)) *suspicion {
 	s := &suspicion{
 		k:             int32(k),
 		min:           min,
@@ -73,7 +74,7 @@ func newSuspicion(from string, k int, min time.Duration, max time.Duration, fn f
 	if k < 1 {
 		timeout = min
 	}
-	s.timer = time.AfterFunc(timeout, s.timeoutFn)
+	s.timer = wheel.AfterFunc(timeout, s.timeoutFn)
 
 	// Capture the start time right after starting the timer above so
 	// we should always err on the side of a little longer timeout if
@@ -0,0 +1,247 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+// Package tags defines conventions for the string tags a node advertises in
+// its metadata, so applications built on memberlist can read each other's
+// nodes without agreeing on a format of their own.
+//
+// Tags are stored in a node's metadata in the format of a
+// memberlist.TTLMeta, which the local node's Delegate keeps them in:
+//
+//	meta := memberlist.NewTTLMeta()
+//	if err := tags.Set(meta, tags.Region, "us-east-1", 0); err != nil {
+//	    return err
+//	}
+//
+// and any node's tags can be read back with Parse, or one at a time with the
+// typed accessors such as ZoneOf and VersionOf.
+//
+// The well-known keys have their values validated. Other keys are allowed
+// too, as long as they're well formed; applications should prefix their own
+// with a domain they control, as in "example.com/shard".
+package tags
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/memberlist"
+)
+
+// The well-known keys.
+const (
+	// Zone is the availability zone the node is in, such as "us-east-1a".
+	// It falls back to Node.Zone when it's not tagged.
+	Zone = "zone"
+
+	// Region is the region the node is in, such as "us-east-1".
+	Region = "region"
+
+	// Role is what the node does for the application, such as "web". It
+	// has nothing to do with Node.Role, which is about how the node takes
+	// part in the cluster.
+	Role = "role"
+
+	// Version is the application's version on the node, in semantic
+	// versioning form, such as "1.4.2" or "v2.0.0-rc.1".
+	Version = "version"
+)
+
+var (
+	keyRe     = regexp.MustCompile(`^[a-z0-9]([a-z0-9._/-]{0,62}[a-z0-9])?$`)
+	labelRe   = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+	versionRe = regexp.MustCompile(`^v?(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)(?:-([0-9A-Za-z.-]+))?(?:\+([0-9A-Za-z.-]+))?$`)
+)
+
+// MaxValueLen is the longest a tag value can be.
+const MaxValueLen = 255
+
+// Tags are a node's tags by key.
+type Tags map[string]string
+
+// Validate checks that a key is well formed and that its value is valid
+// for it.
+func Validate(key, value string) error {
+	if !keyRe.MatchString(key) {
+		return fmt.Errorf("tags: invalid key %q", key)
+	}
+	if len(value) > MaxValueLen {
+		return fmt.Errorf("tags: value of %q is longer than %d bytes", key, MaxValueLen)
+	}
+	switch key {
+	case Zone, Region, Role:
+		if !labelRe.MatchString(value) {
+			return fmt.Errorf("tags: invalid %s %q, must be lower case letters, digits and dashes", key, value)
+		}
+	case Version:
+		if _, err := ParseVersion(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks every tag, see Validate.
+func (t Tags) Validate() error {
+	var errs error
+	for key, value := range t {
+		if err := Validate(key, value); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Set validates a tag and sets it in the local node's metadata, to be
+// withdrawn after the TTL unless it's set again, or kept if the TTL is zero.
+// Call UpdateNode to gossip the change.
+func Set(meta *memberlist.TTLMeta, key, value string, ttl time.Duration) error {
+	if err := Validate(key, value); err != nil {
+		return err
+	}
+	meta.Set(key, []byte(value), ttl)
+	return nil
+}
+
+// Parse reads a node's tags from its metadata. Tags that don't validate are
+// left out, and reported in the error alongside the ones that did.
+func Parse(n *memberlist.Node) (Tags, error) {
+	entries, err := memberlist.DecodeTTLMeta(n.Meta)
+	if err != nil {
+		return nil, fmt.Errorf("tags: %v", err)
+	}
+	t := make(Tags, len(entries))
+	var errs error
+	for key, value := range entries {
+		if err := Validate(key, string(value)); err != nil {
+			errs = multierror.Append(errs, err)
+			continue
+		}
+		t[key] = string(value)
+	}
+	return t, errs
+}
+
+// Get returns a node's tag, if it has a valid one.
+func Get(n *memberlist.Node, key string) (string, bool) {
+	entries, err := memberlist.DecodeTTLMeta(n.Meta)
+	if err != nil {
+		return "", false
+	}
+	value, ok := entries[key]
+	if !ok || Validate(key, string(value)) != nil {
+		return "", false
+	}
+	return string(value), true
+}
+
+// ZoneOf returns the node's zone tag, or Node.Zone if it doesn't have one.
+func ZoneOf(n *memberlist.Node) string {
+	if zone, ok := Get(n, Zone); ok {
+		return zone
+	}
+	return n.Zone
+}
+
+// RegionOf returns the node's region tag, if it has one.
+func RegionOf(n *memberlist.Node) (string, bool) {
+	return Get(n, Region)
+}
+
+// RoleOf returns the node's role tag, if it has one.
+func RoleOf(n *memberlist.Node) (string, bool) {
+	return Get(n, Role)
+}
+
+// VersionOf returns the node's version tag, if it has one.
+func VersionOf(n *memberlist.Node) (SemVer, bool) {
+	value, ok := Get(n, Version)
+	if !ok {
+		return SemVer{}, false
+	}
+	v, err := ParseVersion(value)
+	return v, err == nil
+}
+
+// SemVer is a semantic version.
+type SemVer struct {
+	Major, Minor, Patch int
+	Pre                 string // Pre-release, such as "rc.1"
+	Build               string // Build metadata, ignored when comparing
+}
+
+// ParseVersion parses a semantic version, with or without a leading "v".
+func ParseVersion(s string) (SemVer, error) {
+	parts := versionRe.FindStringSubmatch(s)
+	if parts == nil {
+		return SemVer{}, fmt.Errorf("tags: invalid version %q", s)
+	}
+	var v SemVer
+	for i, dst := range []*int{&v.Major, &v.Minor, &v.Patch} {
+		n, err := strconv.Atoi(parts[i+1])
+		if err != nil {
+			return SemVer{}, fmt.Errorf("tags: invalid version %q: %v", s, err)
+		}
+		*dst = n
+	}
+	v.Pre, v.Build = parts[4], parts[5]
+	return v, nil
+}
+
+func (v SemVer) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 as v is older than, the same as or newer than
+// w, following the precedence rules of semantic versioning.
+func (v SemVer) Compare(w SemVer) int {
+	if c := cmp.Or(cmp.Compare(v.Major, w.Major), cmp.Compare(v.Minor, w.Minor), cmp.Compare(v.Patch, w.Patch)); c != 0 {
+		return c
+	}
+	switch {
+	case v.Pre == w.Pre:
+		return 0
+	case v.Pre == "":
+		return 1
+	case w.Pre == "":
+		return -1
+	}
+	return comparePre(v.Pre, w.Pre)
+}
+
+// comparePre compares pre-release versions field by field, numeric fields
+// numerically and the rest as strings, numeric ones coming first.
+func comparePre(a, b string) int {
+	af, bf := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(af) && i < len(bf); i++ {
+		an, aerr := strconv.Atoi(af[i])
+		bn, berr := strconv.Atoi(bf[i])
+		switch {
+		case aerr == nil && berr == nil:
+			if c := cmp.Compare(an, bn); c != 0 {
+				return c
+			}
+		case aerr == nil:
+			return -1
+		case berr == nil:
+			return 1
+		default:
+			if c := strings.Compare(af[i], bf[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return cmp.Compare(len(af), len(bf))
+}
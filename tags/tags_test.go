@@ -0,0 +1,78 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package tags
+
+import (
+	"testing"
+
+	"github.com/hashicorp/memberlist"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, Validate(Zone, "us-east-1a"))
+	require.NoError(t, Validate(Version, "v1.4.2-rc.1+abc"))
+	require.NoError(t, Validate("example.com/shard", "Anything at all"))
+
+	require.Error(t, Validate(Zone, "US East"))
+	require.Error(t, Validate(Role, ""))
+	require.Error(t, Validate(Version, "1.4"))
+	require.Error(t, Validate("Bad Key", "x"))
+	require.Error(t, Validate("k", string(make([]byte, MaxValueLen+1))))
+
+	err := Tags{Zone: "a", Region: "B", Version: "x"}.Validate()
+	require.ErrorContains(t, err, "region")
+	require.ErrorContains(t, err, "version")
+}
+
+func TestParse(t *testing.T) {
+	meta := memberlist.NewTTLMeta()
+	require.NoError(t, Set(meta, Region, "us-east-1", 0))
+	require.NoError(t, Set(meta, Role, "web", 0))
+	require.NoError(t, Set(meta, Version, "2.0.1", 0))
+	require.Error(t, Set(meta, Zone, "Not Valid", 0))
+	meta.Set(Zone, []byte("Not Valid"), 0)
+
+	n := &memberlist.Node{Zone: "us-east-1b", Meta: meta.NodeMeta(memberlist.MetaMaxSize)}
+	tags, err := Parse(n)
+	require.ErrorContains(t, err, "zone")
+	require.Equal(t, Tags{Region: "us-east-1", Role: "web", Version: "2.0.1"}, tags)
+
+	// Falls back to the node's own zone when the tag isn't valid.
+	require.Equal(t, "us-east-1b", ZoneOf(n))
+	region, ok := RegionOf(n)
+	require.True(t, ok)
+	require.Equal(t, "us-east-1", region)
+	role, ok := RoleOf(n)
+	require.True(t, ok)
+	require.Equal(t, "web", role)
+	v, ok := VersionOf(n)
+	require.True(t, ok)
+	require.Equal(t, SemVer{Major: 2, Patch: 1}, v)
+
+	_, ok = RegionOf(&memberlist.Node{Meta: []byte("opaque")})
+	require.False(t, ok)
+}
+
+func TestSemVer_Compare(t *testing.T) {
+	ordered := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.2.0", "v2.0.0",
+	}
+	for i := range ordered {
+		a, err := ParseVersion(ordered[i])
+		require.NoError(t, err)
+		require.Zero(t, a.Compare(a))
+		for _, s := range ordered[i+1:] {
+			b, err := ParseVersion(s)
+			require.NoError(t, err)
+			require.Equal(t, -1, a.Compare(b), "%s < %s", a, b)
+			require.Equal(t, 1, b.Compare(a), "%s > %s", b, a)
+		}
+	}
+
+	v, err := ParseVersion("1.2.3-rc.1+build.5")
+	require.NoError(t, err)
+	require.Equal(t, "1.2.3-rc.1+build.5", v.String())
+}
@@ -0,0 +1,191 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+const (
+	// timerWheelTick is the resolution of the timer wheel. Timers will fire
+	// at most one tick after their requested deadline.
+	timerWheelTick = 10 * time.Millisecond
+
+	// timerWheelSlots is the number of slots in the wheel. Timers further out
+	// than timerWheelSlots*timerWheelTick wrap around and wait extra rounds.
+	timerWheelSlots = 512
+
+	// timerWheelMinTicks is the shortest duration, in ticks, that gets
+	// scheduled on the wheel.
+	timerWheelMinTicks = 5
+)
+
+// stoppableTimer is the subset of *time.Timer that we use, so that timers
+// backed by the wheel and by the runtime can be used interchangeably.
+type stoppableTimer interface {
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// timerWheel is a hashed timer wheel used for the short-lived timers that
+// memberlist creates on every probe (ack reapers and suspicion timeouts).
+// Scheduling and cancelling are O(1) and don't touch the runtime timer heap,
+// which keeps timer churn low when probing thousands of nodes per minute.
+type timerWheel struct {
+	tick time.Duration
+
+	mu       sync.Mutex
+	slots    []*list.List
+	cursor   int
+	lastTick time.Time
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// wheelTimer is a single timer scheduled on a timerWheel.
+type wheelTimer struct {
+	w      *timerWheel
+	fn     func()
+	rounds int
+	slot   int
+	elem   *list.Element
+}
+
+// newTimerWheel returns a running timer wheel with the given tick resolution
+// and number of slots. It must be stopped with Stop when no longer needed.
+func newTimerWheel(tick time.Duration, numSlots int) *timerWheel {
+	w := &timerWheel{
+		tick:     tick,
+		slots:    make([]*list.List, numSlots),
+		lastTick: time.Now(),
+		stopCh:   make(chan struct{}),
+	}
+	for i := range w.slots {
+		w.slots[i] = list.New()
+	}
+	go w.run()
+	return w
+}
+
+// AfterFunc waits for the duration to elapse and then calls f in its own
+// goroutine, like time.AfterFunc. It's safe to call this on a nil wheel, in
+// which case a runtime timer is used instead. Runtime timers are also used
+// for durations of only a few ticks, where the wheel's resolution would be
+// too coarse.
+func (w *timerWheel) AfterFunc(d time.Duration, f func()) stoppableTimer {
+	if w == nil || d < timerWheelMinTicks*w.tick {
+		return time.AfterFunc(d, f)
+	}
+
+	t := &wheelTimer{w: w, fn: f}
+	w.mu.Lock()
+	w.scheduleLocked(t, d)
+	w.mu.Unlock()
+	return t
+}
+
+// Stop halts the wheel. Any timers that have not fired yet will never fire.
+func (w *timerWheel) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stopCh)
+	})
+}
+
+// scheduleLocked places the timer in the right slot. You must hold the lock.
+func (w *timerWheel) scheduleLocked(t *wheelTimer, d time.Duration) {
+	// Measure from the last tick so a timer never fires before its
+	// deadline, no matter where between two ticks it was scheduled.
+	d += time.Since(w.lastTick)
+	ticks := int((d + w.tick - 1) / w.tick)
+	if ticks < 1 {
+		ticks = 1
+	}
+
+	n := len(w.slots)
+	t.slot = (w.cursor + ticks) % n
+	t.rounds = (ticks - 1) / n
+	t.elem = w.slots[t.slot].PushBack(t)
+}
+
+// run advances the wheel until it is stopped.
+func (w *timerWheel) run() {
+	ticker := time.NewTicker(w.tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			for _, fn := range w.advance(now) {
+				go fn()
+			}
+		case <-w.stopCh:
+			return
+		}
+	}
+}
+
+// advance moves the cursor forward by however many ticks have elapsed since
+// the last call and returns the callbacks of all the timers that expired.
+func (w *timerWheel) advance(now time.Time) []func() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// The ticker drops ticks if we fall behind, so catch up based on the
+	// actual elapsed time.
+	elapsed := int(now.Sub(w.lastTick) / w.tick)
+	if elapsed < 1 {
+		return nil
+	}
+	w.lastTick = w.lastTick.Add(time.Duration(elapsed) * w.tick)
+
+	var expired []func()
+	for i := 0; i < elapsed; i++ {
+		w.cursor = (w.cursor + 1) % len(w.slots)
+		slot := w.slots[w.cursor]
+		for e := slot.Front(); e != nil; {
+			next := e.Next()
+			t := e.Value.(*wheelTimer)
+			if t.rounds > 0 {
+				t.rounds--
+			} else {
+				slot.Remove(e)
+				t.elem = nil
+				expired = append(expired, t.fn)
+			}
+			e = next
+		}
+	}
+	return expired
+}
+
+// Stop prevents the timer from firing. It returns true if the call stops the
+// timer, false if the timer has already expired or been stopped.
+func (t *wheelTimer) Stop() bool {
+	t.w.mu.Lock()
+	defer t.w.mu.Unlock()
+	return t.stopLocked()
+}
+
+// Reset changes the timer to expire after duration d. It returns true if the
+// timer had been active, false if the timer had expired or been stopped.
+func (t *wheelTimer) Reset(d time.Duration) bool {
+	t.w.mu.Lock()
+	defer t.w.mu.Unlock()
+	active := t.stopLocked()
+	t.w.scheduleLocked(t, d)
+	return active
+}
+
+// stopLocked removes the timer from the wheel. You must hold the wheel lock.
+func (t *wheelTimer) stopLocked() bool {
+	if t.elem == nil {
+		return false
+	}
+	t.w.slots[t.slot].Remove(t.elem)
+	t.elem = nil
+	return true
+}
@@ -0,0 +1,114 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimerWheel_Fires(t *testing.T) {
+	w := newTimerWheel(time.Millisecond, 8)
+	defer w.Stop()
+
+	// Use delays that wrap around the wheel several times.
+	cases := []time.Duration{0, 7 * time.Millisecond, 50 * time.Millisecond, 120 * time.Millisecond}
+	for i, d := range cases {
+		ch := make(chan time.Duration, 1)
+		start := time.Now()
+		w.AfterFunc(d, func() {
+			ch <- time.Since(start)
+		})
+
+		select {
+		case elapsed := <-ch:
+			if elapsed < d {
+				t.Fatalf("case %d: fired early after %v, wanted %v", i, elapsed, d)
+			}
+		case <-time.After(d + time.Second):
+			t.Fatalf("case %d: should have fired", i)
+		}
+	}
+}
+
+func TestTimerWheel_Stop(t *testing.T) {
+	w := newTimerWheel(time.Millisecond, 8)
+	defer w.Stop()
+
+	ch := make(chan struct{}, 1)
+	timer := w.AfterFunc(20*time.Millisecond, func() {
+		ch <- struct{}{}
+	})
+	if !timer.Stop() {
+		t.Fatalf("expected timer to be active")
+	}
+	if timer.Stop() {
+		t.Fatalf("expected timer to be stopped")
+	}
+
+	select {
+	case <-ch:
+		t.Fatalf("should not have fired")
+	case <-time.After(60 * time.Millisecond):
+	}
+}
+
+func TestTimerWheel_Reset(t *testing.T) {
+	w := newTimerWheel(time.Millisecond, 8)
+	defer w.Stop()
+
+	ch := make(chan time.Duration, 1)
+	start := time.Now()
+	timer := w.AfterFunc(time.Second, func() {
+		ch <- time.Since(start)
+	})
+	if !timer.Reset(20 * time.Millisecond) {
+		t.Fatalf("expected timer to be active")
+	}
+
+	select {
+	case elapsed := <-ch:
+		if elapsed >= time.Second {
+			t.Fatalf("reset was not applied: %v", elapsed)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatalf("should have fired")
+	}
+
+	// A fired timer can be rearmed.
+	if timer.Reset(10 * time.Millisecond) {
+		t.Fatalf("expected timer to have expired")
+	}
+	select {
+	case <-ch:
+	case <-time.After(500 * time.Millisecond):
+		t.Fatalf("should have fired again")
+	}
+}
+
+func TestTimerWheel_Nil(t *testing.T) {
+	var w *timerWheel
+	ch := make(chan struct{}, 1)
+	w.AfterFunc(time.Millisecond, func() {
+		ch <- struct{}{}
+	})
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatalf("should have fired")
+	}
+}
+
+func TestTimerWheel_ShortTimers(t *testing.T) {
+	w := newTimerWheel(10*time.Millisecond, 8)
+	defer w.Stop()
+
+	// Timers of just a few ticks skip the wheel.
+	if _, ok := w.AfterFunc(time.Millisecond, func() {}).(*time.Timer); !ok {
+		t.Fatalf("expected a runtime timer")
+	}
+	if _, ok := w.AfterFunc(time.Second, func() {}).(*wheelTimer); !ok {
+		t.Fatalf("expected a wheel timer")
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"time"
+)
+
+// maxTombstones bounds how many reaped nodes we remember.
+const maxTombstones = 1024
+
+// tombstone remembers a dead or left node after it's been reaped from the
+// node map, so a stale alive message for it can't bring it back.
+type tombstone struct {
+	state pushNodeState
+	at    time.Time
+}
+
+// bury adds a tombstone for a node, unless we already have a newer one. The
+// age is how long ago the node was reaped, as far as we know. It must be
+// called with nodeLock held for writing.
+func (m *Memberlist) bury(s pushNodeState, age time.Duration) {
+	timeout := m.config.TombstoneTimeout
+	if timeout <= 0 || age >= timeout {
+		return
+	}
+	if t, ok := m.tombstones[s.Name]; ok && !incarnationAfter(s.Incarnation, t.state.Incarnation) {
+		return
+	}
+	if m.tombstones == nil {
+		m.tombstones = make(map[string]tombstone)
+	}
+
+	// Make room by dropping the oldest tombstone
+	m.pruneTombstones()
+	if _, ok := m.tombstones[s.Name]; !ok && len(m.tombstones) >= maxTombstones {
+		var oldest string
+		for name, t := range m.tombstones {
+			if oldest == "" || t.at.Before(m.tombstones[oldest].at) {
+				oldest = name
+			}
+		}
+		delete(m.tombstones, oldest)
+	}
+
+	s.TombstoneAge = 0
+	s.Entries = nil
+	m.tombstones[s.Name] = tombstone{state: s, at: time.Now().Add(-age)}
+}
+
+// buried returns true if there's a tombstone for the node at the given
+// incarnation or later. A later incarnation means the node really came back,
+// so its tombstone is removed. It must be called with nodeLock held for
+// writing.
+func (m *Memberlist) buried(name string, incarnation uint32) bool {
+	t, ok := m.tombstones[name]
+	if !ok {
+		return false
+	}
+	if time.Since(t.at) < m.config.TombstoneTimeout && !incarnationAfter(incarnation, t.state.Incarnation) {
+		return true
+	}
+	delete(m.tombstones, name)
+	return false
+}
+
+// pruneTombstones forgets the tombstones that have expired. It must be called
+// with nodeLock held for writing.
+func (m *Memberlist) pruneTombstones() {
+	for name, t := range m.tombstones {
+		if time.Since(t.at) >= m.config.TombstoneTimeout {
+			delete(m.tombstones, name)
+		}
+	}
+}
+
+// tombstoneStates returns the tombstones in the form used for push/pull. It
+// must be called with nodeLock held.
+func (m *Memberlist) tombstoneStates() []pushNodeState {
+	states := make([]pushNodeState, 0, len(m.tombstones))
+	for _, t := range m.tombstones {
+		age := time.Since(t.at)
+		if age >= m.config.TombstoneTimeout {
+			continue
+		}
+		s := t.state
+		s.TombstoneAge = age
+		states = append(states, s)
+	}
+	return states
+}
+
+// buryRemote records the dead and left nodes from a push/pull that we don't
+// know about, which the remote side has either just reaped or still holds.
+func (m *Memberlist) buryRemote(remote []pushNodeState) {
+	m.nodeLock.Lock()
+	defer m.nodeLock.Unlock()
+
+	for _, r := range remote {
+		if r.State != StateDead && r.State != StateLeft {
+			continue
+		}
+		if _, ok := m.nodeMap[r.Name]; ok {
+			continue
+		}
+		m.bury(r, r.TombstoneAge)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"testing"
+	"time"
+
+	iretry "github.com/hashicorp/memberlist/internal/retry"
+	"github.com/stretchr/testify/require"
+)
+
+// reapNode adds a node, kills it and reaps it from the member list.
+func reapNode(t *testing.T, m *Memberlist, name string) {
+	a := alive{Node: name, Addr: []byte{127, 0, 0, 1}, Port: 1, Incarnation: 1, Vsn: m.config.BuildVsnArray()}
+	m.aliveNode(&a, nil, false)
+	m.deadNode(&dead{Node: name, Incarnation: 1, From: name})
+	m.changeNode(name, func(state *nodeState) {
+		state.StateChange = state.StateChange.Add(-time.Hour)
+	})
+	m.resetNodes()
+
+	m.nodeLock.RLock()
+	defer m.nodeLock.RUnlock()
+	require.NotContains(t, m.nodeMap, name)
+	require.Contains(t, m.tombstones, name)
+}
+
+func TestMemberList_Tombstones(t *testing.T) {
+	m := GetMemberlist(t, nil)
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+	reapNode(t, m, "test")
+
+	// A stale alive message doesn't bring it back
+	a := alive{Node: "test", Addr: []byte{127, 0, 0, 1}, Port: 1, Incarnation: 1, Vsn: m.config.BuildVsnArray()}
+	m.aliveNode(&a, nil, false)
+	require.False(t, m.knownNode("test"))
+
+	// A newer one does
+	a.Incarnation = 2
+	m.aliveNode(&a, nil, false)
+	require.Equal(t, StateAlive, m.getNodeState("test"))
+	m.nodeLock.RLock()
+	require.NotContains(t, m.tombstones, "test")
+	m.nodeLock.RUnlock()
+}
+
+func TestMemberList_Tombstones_Expire(t *testing.T) {
+	m := GetMemberlist(t, func(c *Config) {
+		c.TombstoneTimeout = time.Minute
+	})
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	m.nodeLock.Lock()
+	defer m.nodeLock.Unlock()
+
+	// Tombstones heard about after they expired elsewhere are ignored
+	m.bury(pushNodeState{Name: "old", Incarnation: 1, State: StateLeft}, time.Minute)
+	require.NotContains(t, m.tombstones, "old")
+
+	// And others expire in time
+	m.bury(pushNodeState{Name: "test", Incarnation: 1, State: StateLeft}, 59*time.Second)
+	require.True(t, m.buried("test", 1))
+	require.Len(t, m.tombstoneStates(), 1)
+	require.GreaterOrEqual(t, m.tombstoneStates()[0].TombstoneAge, 59*time.Second)
+	m.tombstones["test"] = tombstone{state: m.tombstones["test"].state, at: time.Now().Add(-time.Minute)}
+	require.Empty(t, m.tombstoneStates())
+	require.False(t, m.buried("test", 1))
+}
+
+func TestMemberlist_Tombstones_PushPull(t *testing.T) {
+	c1 := testConfig(t)
+	m1, err := Create(c1)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m1.Shutdown())
+	}()
+	reapNode(t, m1, "gone")
+
+	// A node that joins afterwards learns about the departure
+	c2 := testConfig(t)
+	c2.BindPort = m1.config.BindPort
+	m2, err := Create(c2)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m2.Shutdown())
+	}()
+	_, err = m2.Join([]string{m1.config.Name + "/" + m1.config.BindAddr})
+	require.NoError(t, err)
+
+	iretry.Run(t, func(r *iretry.R) {
+		m2.nodeLock.RLock()
+		defer m2.nodeLock.RUnlock()
+		if _, ok := m2.tombstones["gone"]; !ok {
+			r.Fatal("expected a tombstone")
+		}
+	})
+	require.False(t, m2.knownNode("gone"))
+
+	// So a stale peer can't bring it back
+	m2.mergeState([]pushNodeState{{Name: "gone", Addr: []byte{127, 0, 0, 1}, Port: 1, Incarnation: 1, State: StateAlive, Vsn: m2.config.BuildVsnArray()}})
+	require.False(t, m2.knownNode("gone"))
+}
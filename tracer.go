@@ -0,0 +1,135 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"time"
+)
+
+// Tracer is used to observe the messages memberlist exchanges with other
+// members, for example to turn them into OpenTelemetry spans. Each method
+// is invoked once an operation is complete, with its start time and
+// duration, on the goroutine that did the work, so it must be fast and
+// must not block.
+type Tracer interface {
+	// TracePacket is invoked for each packet sent or received.
+	TracePacket(t PacketTrace)
+
+	// TraceProbe is invoked once a probe of another member is finished,
+	// however it ended.
+	TraceProbe(t ProbeTrace)
+
+	// TracePushPull is invoked once a push/pull state exchange is
+	// finished, whether we started it or the other member did.
+	TracePushPull(t PushPullTrace)
+}
+
+// PacketTrace describes a packet sent to or received from a peer.
+type PacketTrace struct {
+	// Inbound is true for packets we received.
+	Inbound bool
+
+	// Addr is the peer's address, and Node its name if known.
+	Addr string
+	Node string
+
+	// Type is the type of the outermost message, such as "ping" or
+	// "compound".
+	Type string
+
+	// Size is the size of the packet on the wire.
+	Size int
+
+	// Time is when the packet was sent or received.
+	Time time.Time
+
+	// Err is set if sending failed.
+	Err error
+}
+
+// ProbeTrace describes a probe of another member.
+type ProbeTrace struct {
+	// Node is the member probed, and Addr its address.
+	Node string
+	Addr string
+
+	// Start is when the probe started, and Duration how long it took.
+	Start    time.Time
+	Duration time.Duration
+
+	// Acked is true if the member answered.
+	Acked bool
+
+	// RTT is the round trip time of the direct ping, if the member
+	// answered it.
+	RTT time.Duration
+
+	// Indirect is the number of other members asked to probe it after a
+	// direct ping went unanswered.
+	Indirect int
+
+	// TCPFallback is true if the member only answered over TCP.
+	TCPFallback bool
+}
+
+// PushPullTrace describes a push/pull state exchange with a peer.
+type PushPullTrace struct {
+	// Inbound is true if the peer started the exchange.
+	Inbound bool
+
+	// Addr is the peer's address, and Node its name if known.
+	Addr string
+	Node string
+
+	// Join is true for the exchange done when joining, and Delta for a
+	// delta exchange rather than a full one.
+	Join  bool
+	Delta bool
+
+	// Start is when the exchange started, and Duration how long it took.
+	Start    time.Time
+	Duration time.Duration
+
+	// Err is set if the exchange failed.
+	Err error
+}
+
+// tracePacket reports a packet to the tracer, if there is one.
+func (m *Memberlist) tracePacket(inbound bool, addr, node string, msgType messageType, size int, err error) {
+	tr := m.config.Tracer
+	if tr == nil {
+		return
+	}
+	t := PacketTrace{
+		Inbound: inbound,
+		Addr:    addr,
+		Node:    node,
+		Type:    msgType.String(),
+		Size:    size,
+		Time:    time.Now(),
+		Err:     err,
+	}
+	m.guard("TracePacket", func() { tr.TracePacket(t) })
+}
+
+// traceProbe reports a finished probe to the tracer, if there is one.
+func (m *Memberlist) traceProbe(t ProbeTrace) {
+	tr := m.config.Tracer
+	if tr == nil {
+		return
+	}
+	t.Duration = time.Since(t.Start)
+	m.guard("TraceProbe", func() { tr.TraceProbe(t) })
+}
+
+// tracePushPull reports a finished push/pull to the tracer, if there is one.
+func (m *Memberlist) tracePushPull(t PushPullTrace, err error) {
+	tr := m.config.Tracer
+	if tr == nil {
+		return
+	}
+	t.Duration = time.Since(t.Start)
+	t.Err = err
+	m.guard("TracePushPull", func() { tr.TracePushPull(t) })
+}
@@ -0,0 +1,109 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	iretry "github.com/hashicorp/memberlist/internal/retry"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingTracer struct {
+	lock      sync.Mutex
+	packets   []PacketTrace
+	probes    []ProbeTrace
+	pushPulls []PushPullTrace
+}
+
+func (t *recordingTracer) TracePacket(p PacketTrace) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.packets = append(t.packets, p)
+}
+
+func (t *recordingTracer) TraceProbe(p ProbeTrace) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.probes = append(t.probes, p)
+}
+
+func (t *recordingTracer) TracePushPull(p PushPullTrace) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.pushPulls = append(t.pushPulls, p)
+}
+
+func TestMemberlist_Tracer(t *testing.T) {
+	tr1, tr2 := &recordingTracer{}, &recordingTracer{}
+
+	c1 := testConfig(t)
+	c1.Tracer = tr1
+	m1, err := Create(c1)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m1.Shutdown())
+	}()
+
+	c2 := testConfig(t)
+	c2.BindPort = m1.config.BindPort
+	c2.Tracer = tr2
+	m2, err := Create(c2)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m2.Shutdown())
+	}()
+
+	_, err = m2.Join([]string{m1.config.Name + "/" + m1.config.BindAddr})
+	require.NoError(t, err)
+
+	iretry.Run(t, func(r *iretry.R) {
+		tr1.lock.Lock()
+		defer tr1.lock.Unlock()
+		tr2.lock.Lock()
+		defer tr2.lock.Unlock()
+
+		// The join shows up on both sides.
+		if len(tr2.pushPulls) == 0 || len(tr1.pushPulls) == 0 {
+			r.Fatal("no push/pull traced")
+		}
+		out, in := tr2.pushPulls[0], tr1.pushPulls[0]
+		require.True(t, out.Join)
+		require.False(t, out.Inbound)
+		require.Equal(t, m1.config.Name, out.Node)
+		require.NoError(t, out.Err)
+		require.True(t, in.Join)
+		require.True(t, in.Inbound)
+		require.NoError(t, in.Err)
+
+		// Probes go out as pings, and come back as acks.
+		var ping, ack bool
+		for _, p := range tr1.packets {
+			ping = ping || (!p.Inbound && p.Type == "ping" && p.Node == m2.config.Name)
+			ack = ack || (p.Inbound && p.Type == "ack" && p.Size > 0)
+		}
+		if !ping || !ack {
+			r.Fatal("no ping and ack traced")
+		}
+		var acked bool
+		for _, p := range tr1.probes {
+			if p.Node == m2.config.Name && p.Acked {
+				acked = true
+				require.Zero(t, p.Indirect)
+				require.Greater(t, p.Duration, time.Duration(0))
+			}
+		}
+		if !acked {
+			r.Fatal("no probe traced")
+		}
+	})
+}
+
+func TestMessageType_String(t *testing.T) {
+	require.Equal(t, "ping", pingMsg.String())
+	require.Equal(t, "push-pull-digest", pushPullDigestMsg.String())
+	require.Equal(t, "unknown(200)", messageType(200).String())
+}
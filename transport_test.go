@@ -4,10 +4,13 @@
 package memberlist
 
 import (
+	"errors"
 	"log"
 	"net"
 	"strings"
+	"sync"
 	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
 
@@ -232,3 +235,57 @@ func TestTransport_TcpListenBackoff(t *testing.T) {
 	// no connections should have been accepted and sent to the channel
 	require.Equal(t, len(transport.streamCh), 0)
 }
+
+func TestTransport_SocketControl(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		networks []string
+	)
+	c := testConfig(t)
+	c.SocketControl = func(network, address string, rc syscall.RawConn) error {
+		mu.Lock()
+		defer mu.Unlock()
+		networks = append(networks, network)
+		return rc.Control(func(fd uintptr) {})
+	}
+	m, err := Create(c)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	mu.Lock()
+	require.ElementsMatch(t, []string{"tcp4", "udp4"}, networks)
+	mu.Unlock()
+
+	// An error from the hook fails the bind.
+	_, err = NewNetTransport(&NetTransportConfig{
+		BindAddrs: []string{c.BindAddr},
+		SocketControl: func(string, string, syscall.RawConn) error {
+			return errors.New("nope")
+		},
+	})
+	require.ErrorContains(t, err, "nope")
+}
+
+func TestTransport_UDPRecvBuf(t *testing.T) {
+	c := testConfig(t)
+	nt, err := NewNetTransport(&NetTransportConfig{
+		BindAddrs: []string{c.BindAddr},
+		Logger:    c.Logger,
+	})
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, nt.Shutdown())
+	}()
+
+	// Whatever the platform settled on, it's a real size that's no
+	// bigger than what we asked for.
+	size, err := udpRecvBuf(nt.udpListeners[0])
+	require.NoError(t, err)
+	require.Greater(t, size, 0)
+	require.LessOrEqual(t, size, udpRecvBufSize)
+
+	// Nothing that comes out of a healthy listener is ignorable.
+	require.False(t, udpReadErrIgnorable(errors.New("boom")))
+}
@@ -0,0 +1,177 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ExpiringMetaDelegate can optionally be implemented by a Delegate whose
+// NodeMeta changes on its own once some time has passed. Whenever memberlist
+// gets the local node's metadata, it asks for NodeMetaExpiry too, and at
+// that time it gets the metadata again and gossips it the same way as an
+// UpdateNode, without waiting for it to go out. TTLMeta implements this.
+type ExpiringMetaDelegate interface {
+	// NodeMetaExpiry returns when the metadata last returned by NodeMeta
+	// next changes by itself, or the zero time if it won't.
+	NodeMetaExpiry() time.Time
+}
+
+// TTLMeta is a set of keyed metadata entries, each of which can be given a
+// TTL. An entry that isn't set again before its TTL runs out is withdrawn
+// from the metadata, so a transient fact like "currently draining" doesn't
+// outlive the process that keeps it fresh. Its NodeMeta and NodeMetaExpiry
+// methods can be used by embedding it in a Delegate, and DecodeTTLMeta
+// reads the metadata of any node back. It's safe for concurrent use.
+//
+// Changes aren't gossiped until UpdateNode is called, except for expired
+// entries, which memberlist withdraws by itself.
+type TTLMeta struct {
+	lock    sync.Mutex
+	entries map[string]ttlMetaEntry
+}
+
+type ttlMetaEntry struct {
+	value   []byte
+	expires time.Time // Zero if it doesn't
+}
+
+// NewTTLMeta returns an empty TTLMeta.
+func NewTTLMeta() *TTLMeta {
+	return &TTLMeta{entries: make(map[string]ttlMetaEntry)}
+}
+
+// Set sets the value of a key, which is withdrawn after the given TTL
+// unless set again first. A TTL of zero keeps it until it's deleted.
+func (t *TTLMeta) Set(key string, value []byte, ttl time.Duration) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	e := ttlMetaEntry{value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		e.expires = time.Now().Add(ttl)
+	}
+	t.entries[key] = e
+}
+
+// Delete removes a key.
+func (t *TTLMeta) Delete(key string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.entries, key)
+}
+
+// Get returns the value of a key, if it's set and hasn't expired.
+func (t *TTLMeta) Get(key string) ([]byte, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	e, ok := t.entries[key]
+	if !ok || e.expired(time.Now()) {
+		return nil, false
+	}
+	return e.value, true
+}
+
+// NodeMeta encodes the entries that haven't expired, in key order. Entries
+// that would take it over the limit are left out.
+func (t *TTLMeta) NodeMeta(limit int) []byte {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := time.Now()
+	keys := make([]string, 0, len(t.entries))
+	for key, e := range t.entries {
+		if e.expired(now) {
+			delete(t.entries, key)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var buf []byte
+	for _, key := range keys {
+		value := t.entries[key].value
+		next := binary.AppendUvarint(buf, uint64(len(key)))
+		next = append(next, key...)
+		next = binary.AppendUvarint(next, uint64(len(value)))
+		next = append(next, value...)
+		if len(next) > limit {
+			// Leave buf alone, and see if a smaller entry still fits.
+			continue
+		}
+		buf = next
+	}
+	return buf
+}
+
+// NodeMetaExpiry returns when the first of the entries expires. See
+// ExpiringMetaDelegate.
+func (t *TTLMeta) NodeMetaExpiry() time.Time {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var first time.Time
+	for _, e := range t.entries {
+		if !e.expires.IsZero() && (first.IsZero() || e.expires.Before(first)) {
+			first = e.expires
+		}
+	}
+	return first
+}
+
+func (e ttlMetaEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+// DecodeTTLMeta decodes a node's metadata as encoded by TTLMeta.NodeMeta.
+func DecodeTTLMeta(meta []byte) (map[string][]byte, error) {
+	out := make(map[string][]byte)
+	for len(meta) > 0 {
+		var parts [2][]byte
+		for i := range parts {
+			l, size := binary.Uvarint(meta)
+			if size <= 0 || l > uint64(len(meta)-size) {
+				return nil, errors.New("memberlist: truncated TTL metadata")
+			}
+			meta = meta[size:]
+			parts[i] = meta[:l]
+			meta = meta[l:]
+		}
+		out[string(parts[0])] = parts[1]
+	}
+	return out, nil
+}
+
+// armMetaExpiry arranges for the local node's metadata to be gossiped again
+// when the delegate says it next expires, replacing any earlier arrangement.
+func (m *Memberlist) armMetaExpiry(d Delegate) {
+	ed, ok := d.(ExpiringMetaDelegate)
+	if !ok {
+		return
+	}
+	var expires time.Time
+	m.guard("NodeMetaExpiry", func() { expires = ed.NodeMetaExpiry() })
+
+	m.metaLock.Lock()
+	defer m.metaLock.Unlock()
+	if m.metaTimer != nil {
+		m.metaTimer.Stop()
+		m.metaTimer = nil
+	}
+	if expires.IsZero() || m.hasShutdown() {
+		return
+	}
+	m.metaTimer = m.timers.AfterFunc(time.Until(expires), func() {
+		if m.hasShutdown() || m.hasLeft() {
+			return
+		}
+		m.updateNode(nil)
+	})
+}
@@ -0,0 +1,95 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTTLMeta(t *testing.T) {
+	meta := NewTTLMeta()
+	meta.Set("zone", []byte("a"), 0)
+	meta.Set("draining", []byte("yes"), 50*time.Millisecond)
+	meta.Set("big", make([]byte, 100), 0)
+
+	got, err := DecodeTTLMeta(meta.NodeMeta(MetaMaxSize))
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+	require.Equal(t, []byte("yes"), got["draining"])
+	require.False(t, meta.NodeMetaExpiry().IsZero())
+
+	// Entries that don't fit are left out.
+	got, err = DecodeTTLMeta(meta.NodeMeta(20))
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{"draining": []byte("yes"), "zone": []byte("a")}, got)
+
+	time.Sleep(60 * time.Millisecond)
+	_, ok := meta.Get("draining")
+	require.False(t, ok)
+	got, err = DecodeTTLMeta(meta.NodeMeta(MetaMaxSize))
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	require.True(t, meta.NodeMetaExpiry().IsZero())
+
+	_, err = DecodeTTLMeta([]byte{5, 'a'})
+	require.Error(t, err)
+}
+
+// ttlMetaDelegate keeps its metadata in a TTLMeta.
+type ttlMetaDelegate struct {
+	MockDelegate
+	*TTLMeta
+}
+
+func (d *ttlMetaDelegate) NodeMeta(limit int) []byte {
+	return d.TTLMeta.NodeMeta(limit)
+}
+
+func TestMemberlist_TTLMetaWithdrawn(t *testing.T) {
+	d := &ttlMetaDelegate{TTLMeta: NewTTLMeta()}
+	d.Set("role", []byte("web"), 0)
+	d.Set("draining", []byte("yes"), 500*time.Millisecond)
+
+	m1 := GetMemberlist(t, func(c *Config) {
+		c.Delegate = d
+	})
+	defer func() {
+		require.NoError(t, m1.Shutdown())
+	}()
+	require.NoError(t, m1.setAlive())
+	m1.schedule()
+
+	m2 := GetMemberlist(t, nil)
+	defer func() {
+		require.NoError(t, m2.Shutdown())
+	}()
+	require.NoError(t, m2.setAlive())
+	m2.schedule()
+	_, err := m2.Join([]string{m1.config.Name + "/" + m1.LocalNode().Address()})
+	require.NoError(t, err)
+
+	metaOf := func(m *Memberlist) map[string][]byte {
+		m.nodeLock.RLock()
+		defer m.nodeLock.RUnlock()
+		n, ok := m.nodeMap[m1.config.Name]
+		if !ok {
+			return nil
+		}
+		got, err := DecodeTTLMeta(n.Meta)
+		require.NoError(t, err)
+		return got
+	}
+	require.Contains(t, metaOf(m2), "draining")
+
+	// Nobody refreshes the entry, so it's withdrawn here and over there.
+	want := map[string][]byte{"role": []byte("web")}
+	require.Eventually(t, func() bool {
+		return len(metaOf(m1)) == 1 && len(metaOf(m2)) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, want, metaOf(m1))
+	require.Equal(t, want, metaOf(m2))
+}
@@ -0,0 +1,98 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"fmt"
+	"time"
+)
+
+// Tuning holds the intervals that can be changed while memberlist is
+// running. They have the same meaning as the fields of Config with the same
+// names, and zero turns the corresponding activity off.
+type Tuning struct {
+	GossipInterval   time.Duration
+	ProbeInterval    time.Duration
+	PushPullInterval time.Duration
+}
+
+// Tuning returns the intervals currently in use.
+func (m *Memberlist) Tuning() Tuning {
+	m.tuningLock.RLock()
+	defer m.tuningLock.RUnlock()
+	return Tuning{
+		GossipInterval:   m.config.GossipInterval,
+		ProbeInterval:    m.config.ProbeInterval,
+		PushPullInterval: m.config.PushPullInterval,
+	}
+}
+
+// SetTuning changes the gossip, probe and push/pull intervals, restarting
+// the periodic tasks so the new intervals take effect right away. This can
+// be used to throttle gossip during an incident without a restart. Probes
+// already in flight finish with the old interval.
+func (m *Memberlist) SetTuning(t Tuning) (err error) {
+	defer func() {
+		m.audit(AuditSetTuning, fmt.Sprintf("gossip %v, probe %v, push/pull %v",
+			t.GossipInterval, t.ProbeInterval, t.PushPullInterval), err)
+	}()
+	if t.GossipInterval < 0 || t.ProbeInterval < 0 || t.PushPullInterval < 0 {
+		return fmt.Errorf("intervals must not be negative")
+	}
+
+	// Hold off Shutdown so we don't reschedule after it has descheduled.
+	m.shutdownLock.Lock()
+	defer m.shutdownLock.Unlock()
+	if m.hasShutdown() {
+		return ErrAlreadyShutdown
+	}
+
+	m.tuningLock.Lock()
+	m.config.GossipInterval = t.GossipInterval
+	m.config.ProbeInterval = t.ProbeInterval
+	m.config.PushPullInterval = t.PushPullInterval
+	m.tuningLock.Unlock()
+
+	m.tickerLock.Lock()
+	scheduled := m.stopTick != nil
+	m.tickerLock.Unlock()
+	if scheduled {
+		m.deschedule()
+		m.schedule()
+	}
+	m.logger.Printf("[INFO] memberlist: Tuning changed: gossip %v, probe %v, push/pull %v",
+		t.GossipInterval, t.ProbeInterval, t.PushPullInterval)
+	return nil
+}
+
+// Pause stops the periodic probes, gossip and push/pulls, without closing
+// any sockets or forgetting any state. We still answer probes and handle
+// messages from other members, so they won't see us fail, but we stop
+// checking on them and passing on broadcasts until Resume is called.
+// Pausing an already paused memberlist does nothing.
+func (m *Memberlist) Pause() error {
+	m.shutdownLock.Lock()
+	defer m.shutdownLock.Unlock()
+	if m.hasShutdown() {
+		return ErrAlreadyShutdown
+	}
+
+	m.deschedule()
+	m.logger.Printf("[INFO] memberlist: Paused")
+	return nil
+}
+
+// Resume restarts the periodic tasks stopped by Pause, using the current
+// Tuning. Resuming a memberlist that isn't paused does nothing.
+func (m *Memberlist) Resume() error {
+	m.shutdownLock.Lock()
+	defer m.shutdownLock.Unlock()
+	if m.hasShutdown() {
+		return ErrAlreadyShutdown
+	}
+
+	m.schedule()
+	m.logger.Printf("[INFO] memberlist: Resumed")
+	return nil
+}
@@ -0,0 +1,80 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberlist_SetTuning(t *testing.T) {
+	c := testConfig(t)
+	c.ReconnectInterval = 0
+	m, err := Create(c)
+	require.NoError(t, err)
+
+	numTickers := func() int {
+		m.tickerLock.Lock()
+		defer m.tickerLock.Unlock()
+		return len(m.tickers)
+	}
+	require.Equal(t, 2, numTickers())
+
+	// Turn gossip off and slow down probes.
+	tuning := Tuning{ProbeInterval: 5 * time.Second, PushPullInterval: time.Minute}
+	require.NoError(t, m.SetTuning(tuning))
+	require.Equal(t, tuning, m.Tuning())
+	require.Equal(t, 1, numTickers())
+
+	// Only push/pull left is still scheduled, and can be descheduled.
+	require.NoError(t, m.SetTuning(Tuning{PushPullInterval: time.Minute}))
+	require.Equal(t, 0, numTickers())
+	m.tickerLock.Lock()
+	require.NotNil(t, m.stopTick)
+	m.tickerLock.Unlock()
+
+	require.Error(t, m.SetTuning(Tuning{ProbeInterval: -time.Second}))
+
+	require.NoError(t, m.Shutdown())
+	m.tickerLock.Lock()
+	require.Nil(t, m.stopTick)
+	m.tickerLock.Unlock()
+	require.Error(t, m.SetTuning(tuning))
+}
+
+func TestMemberlist_PauseResume(t *testing.T) {
+	c := testConfig(t)
+	c.ReconnectInterval = 0
+	m, err := Create(c)
+	require.NoError(t, err)
+
+	scheduled := func() bool {
+		m.tickerLock.Lock()
+		defer m.tickerLock.Unlock()
+		return m.stopTick != nil
+	}
+	require.True(t, scheduled())
+
+	require.NoError(t, m.Pause())
+	require.False(t, scheduled())
+	require.NoError(t, m.Pause())
+
+	// Tuning changes while paused are picked up on resume.
+	require.NoError(t, m.SetTuning(Tuning{ProbeInterval: time.Second}))
+	require.False(t, scheduled())
+
+	require.NoError(t, m.Resume())
+	require.True(t, scheduled())
+	m.tickerLock.Lock()
+	require.Len(t, m.tickers, 1)
+	m.tickerLock.Unlock()
+	require.NoError(t, m.Resume())
+
+	require.NoError(t, m.Shutdown())
+	require.Error(t, m.Pause())
+	require.Error(t, m.Resume())
+	require.False(t, scheduled())
+}
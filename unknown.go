@@ -0,0 +1,112 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"net"
+	"time"
+
+	metrics "github.com/hashicorp/go-metrics/compat"
+)
+
+const (
+	maxUnknownNodes   = 128 // Most unknown nodes we'll hold messages for
+	maxUnknownPerNode = 4   // Most messages we'll hold for each of them
+)
+
+// unknownMsg is a suspect or dead message about a node we didn't know
+// about yet, held in case its alive message turns up shortly.
+type unknownMsg struct {
+	at    time.Time
+	apply func()
+}
+
+// knownNode returns true if we know about the named node.
+func (m *Memberlist) knownNode(name string) bool {
+	m.nodeLock.RLock()
+	defer m.nodeLock.RUnlock()
+	_, ok := m.nodeMap[name]
+	return ok
+}
+
+// holdUnknown holds a message about a node we don't know yet for up to
+// Config.UnknownNodeHoldTime, so it isn't lost when it overtakes the
+// node's alive message during a join. The first time we hear about the node
+// we also do a push/pull with the sender, which knows about it. Returns
+// false if holding is turned off, in which case the message should be
+// handled as usual.
+func (m *Memberlist) holdUnknown(name string, from net.Addr, apply func()) bool {
+	hold := m.config.UnknownNodeHoldTime
+	if hold <= 0 {
+		return false
+	}
+
+	now := time.Now()
+	m.unknownLock.Lock()
+	if m.unknown == nil {
+		m.unknown = make(map[string][]unknownMsg)
+	}
+	msgs, ok := m.unknown[name]
+	if !ok {
+		for n, held := range m.unknown {
+			if now.Sub(held[len(held)-1].at) > hold {
+				delete(m.unknown, n)
+			}
+		}
+	}
+	if (!ok && len(m.unknown) >= maxUnknownNodes) || len(msgs) >= maxUnknownPerNode {
+		m.unknownLock.Unlock()
+		metrics.IncrCounterWithLabels([]string{"memberlist", "unknown", "dropped"}, 1, m.metricLabels)
+		return true
+	}
+	m.unknown[name] = append(msgs, unknownMsg{at: now, apply: apply})
+	m.unknownLock.Unlock()
+	metrics.IncrCounterWithLabels([]string{"memberlist", "unknown", "held"}, 1, m.metricLabels)
+
+	if !ok {
+		m.logger.Printf("[DEBUG] memberlist: Got a message about unknown node %s, syncing with %s", name, LogAddress(from))
+		go func() {
+			a := Address{Addr: from.String(), Name: m.nodeNameAt(from.String())}
+			if err := m.pushPullNode(a, false); err != nil {
+				m.logger.Printf("[DEBUG] memberlist: Failed to sync with %s: %s", LogAddress(from), err)
+			}
+		}()
+	}
+	return true
+}
+
+// nodeNameAt returns the name of the node at the given address, or an empty
+// string if we don't know of one.
+func (m *Memberlist) nodeNameAt(addr string) string {
+	m.nodeLock.RLock()
+	defer m.nodeLock.RUnlock()
+	for _, n := range m.nodes {
+		if n.Address() == addr {
+			return n.Name
+		}
+	}
+	return ""
+}
+
+// releaseUnknown applies any messages held for a node we've just learned
+// about. They're applied on another goroutine since the caller holds the
+// node lock, so they take effect once it's released.
+func (m *Memberlist) releaseUnknown(name string) {
+	m.unknownLock.Lock()
+	msgs, ok := m.unknown[name]
+	delete(m.unknown, name)
+	m.unknownLock.Unlock()
+	if !ok {
+		return
+	}
+
+	go func() {
+		hold := m.config.UnknownNodeHoldTime
+		for _, msg := range msgs {
+			if time.Since(msg.at) <= hold {
+				msg.apply()
+			}
+		}
+	}()
+}
@@ -0,0 +1,86 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	iretry "github.com/hashicorp/memberlist/internal/retry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberlist_HoldUnknown(t *testing.T) {
+	m := GetMemberlist(t, nil)
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+	from := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1}
+
+	// The dead message overtakes the alive one.
+	buf, err := encode(deadMsg, &dead{Node: "test", Incarnation: 1, From: "other"}, false)
+	require.NoError(t, err)
+	m.handleDead(buf.Bytes()[1:], from)
+	m.unknownLock.Lock()
+	require.Len(t, m.unknown["test"], 1)
+	m.unknownLock.Unlock()
+
+	a := alive{Node: "test", Addr: []byte{127, 0, 0, 1}, Incarnation: 1, Vsn: m.config.BuildVsnArray()}
+	m.aliveNode(&a, nil, false)
+
+	iretry.Run(t, func(r *iretry.R) {
+		m.nodeLock.RLock()
+		defer m.nodeLock.RUnlock()
+		if state := m.nodeMap["test"].State; state != StateDead {
+			r.Fatalf("bad state: %v", state)
+		}
+	})
+	m.unknownLock.Lock()
+	require.Empty(t, m.unknown)
+	m.unknownLock.Unlock()
+
+	// There's a limit on how much we hold per node.
+	for i := 0; i < maxUnknownPerNode+2; i++ {
+		require.True(t, m.holdUnknown("other", from, func() {}))
+	}
+	m.unknownLock.Lock()
+	require.Len(t, m.unknown["other"], maxUnknownPerNode)
+	m.unknownLock.Unlock()
+}
+
+func TestMemberlist_HoldUnknown_Expired(t *testing.T) {
+	m := GetMemberlist(t, func(c *Config) {
+		c.UnknownNodeHoldTime = 10 * time.Millisecond
+	})
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+	from := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1}
+
+	s := suspect{Node: "test", Incarnation: 1, From: "other"}
+	require.True(t, m.holdUnknown("test", from, func() { m.suspectNode(&s) }))
+	time.Sleep(20 * time.Millisecond)
+
+	a := alive{Node: "test", Addr: []byte{127, 0, 0, 1}, Incarnation: 1, Vsn: m.config.BuildVsnArray()}
+	m.aliveNode(&a, nil, false)
+	time.Sleep(20 * time.Millisecond)
+
+	m.nodeLock.RLock()
+	require.Equal(t, StateAlive, m.nodeMap["test"].State)
+	m.nodeLock.RUnlock()
+}
+
+func TestMemberlist_HoldUnknown_Disabled(t *testing.T) {
+	m := GetMemberlist(t, func(c *Config) {
+		c.UnknownNodeHoldTime = 0
+	})
+	defer func() {
+		require.NoError(t, m.Shutdown())
+	}()
+
+	from := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1}
+	require.False(t, m.holdUnknown("test", from, func() {}))
+	require.Nil(t, m.unknown)
+}
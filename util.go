@@ -130,30 +130,36 @@ func moveDeadNodes(nodes []*nodeState, gossipToTheDeadTime time.Duration) int {
 func kRandomNodes(k int, nodes []*nodeState, exclude func(*nodeState) bool) []Node {
 	n := len(nodes)
 	kNodes := make([]Node, 0, k)
-OUTER:
-	// Probe up to 3*n times, with large n this is not necessary
-	// since k << n, but with small n we want search to be
-	// exhaustive
-	for i := 0; i < 3*n && len(kNodes) < k; i++ {
-		// Get random nodeState
-		idx := randomOffset(n)
-		state := nodes[idx]
-
+	add := func(state *nodeState) {
 		// Give the filter a shot at it.
 		if exclude != nil && exclude(state) {
-			continue OUTER
+			return
 		}
 
 		// Check if we have this node already
 		for j := 0; j < len(kNodes); j++ {
 			if state.Name == kNodes[j].Name {
-				continue OUTER
+				return
 			}
 		}
 
 		// Append the node
 		kNodes = append(kNodes, state.Node)
 	}
+
+	// Probe up to 3*n times, with large n this is not necessary
+	// since k << n, but with small n we want search to be
+	// exhaustive
+	for i := 0; i < 3*n && len(kNodes) < k; i++ {
+		add(nodes[randomOffset(n)])
+	}
+
+	// Random draws can still miss the few nodes that pass the filter, so
+	// finish with a walk from a random starting point.
+	start := randomOffset(n)
+	for i := 0; i < n && len(kNodes) < k; i++ {
+		add(nodes[(start+i)%n])
+	}
 	return kNodes
 }
 
@@ -298,6 +304,30 @@ func decompressBuffer(c *compress) ([]byte, error) {
 	return b.Bytes(), nil
 }
 
+// containsString returns true if the slice holds the given string.
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// equalStrings returns true if both slices hold the same strings in the same
+// order.
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 // joinHostPort returns the host:port form of an address, for use with a
 // transport.
 func joinHostPort(host string, port uint16) string {
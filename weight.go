@@ -0,0 +1,102 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"math/rand"
+	"sort"
+	"strconv"
+)
+
+// DefaultWeight is the weight of a node that doesn't advertise one.
+const DefaultWeight = 100
+
+// WeightDelegate can optionally be implemented by a Delegate to supply the
+// local node's weight, see Config.Weight. It's asked whenever the local
+// node is advertised, including by UpdateNode, and takes precedence over
+// SetWeight.
+type WeightDelegate interface {
+	// NodeWeight returns the local node's weight. Zero means
+	// DefaultWeight.
+	NodeWeight() uint16
+}
+
+// WeightOrDefault returns the node's weight, or DefaultWeight if it doesn't
+// advertise one.
+func (n *Node) WeightOrDefault() uint16 {
+	if n.Weight == 0 {
+		return DefaultWeight
+	}
+	return n.Weight
+}
+
+// SetWeight changes the local node's weight and gossips it with a new alive
+// message, the same way as an UpdateNode, without waiting for it to go out.
+// A WeightDelegate overrides it.
+func (m *Memberlist) SetWeight(w uint16) (err error) {
+	defer func() { m.audit(AuditSetWeight, strconv.Itoa(int(w)), err) }()
+	if m.hasShutdown() {
+		return ErrAlreadyShutdown
+	}
+	m.weight.Store(uint32(w))
+
+	m.nodeLock.RLock()
+	_, ok := m.nodeMap[m.config.Name]
+	m.nodeLock.RUnlock()
+	if !ok {
+		return nil
+	}
+	m.updateNode(nil)
+	return nil
+}
+
+// localWeight returns the weight to advertise for the local node.
+func (m *Memberlist) localWeight() uint16 {
+	if wd, ok := m.delegate().(WeightDelegate); ok {
+		var w uint16
+		m.guard("NodeWeight", func() { w = wd.NodeWeight() })
+		return w
+	}
+	return uint16(m.weight.Load())
+}
+
+// kRandomNodes picks up to k random nodes that aren't excluded, biased by
+// their weight if Config.WeightedSelection is set. You must hold the node
+// lock.
+func (m *Memberlist) kRandomNodes(k int, exclude func(*nodeState) bool) []Node {
+	if !m.config.WeightedSelection {
+		return kRandomNodes(k, m.nodes, exclude)
+	}
+	return kRandomWeightedNodes(k, m.nodes, exclude)
+}
+
+// kRandomWeightedNodes picks up to k nodes that aren't excluded, each with a
+// chance in proportion to its weight. This is the A-ES algorithm from
+// Efraimidis and Spirakis: every node gets a random key that's
+// exponentially distributed with its weight as the rate, and the k nodes
+// with the smallest keys win.
+func kRandomWeightedNodes(k int, nodes []*nodeState, exclude func(*nodeState) bool) []Node {
+	type keyed struct {
+		key  float64
+		node *nodeState
+	}
+	if k <= 0 {
+		return nil
+	}
+	candidates := make([]keyed, 0, len(nodes))
+	for _, n := range nodes {
+		if exclude != nil && exclude(n) {
+			continue
+		}
+		key := rand.ExpFloat64() / float64(n.WeightOrDefault())
+		candidates = append(candidates, keyed{key: key, node: n})
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].key < candidates[j].key })
+
+	kNodes := make([]Node, 0, min(k, len(candidates)))
+	for _, c := range candidates[:cap(kNodes)] {
+		kNodes = append(kNodes, c.node.Node)
+	}
+	return kNodes
+}
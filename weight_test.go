@@ -0,0 +1,86 @@
+// Copyright IBM Corp. 2013, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package memberlist
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// weightDelegate supplies a fixed weight.
+type weightDelegate struct {
+	MockDelegate
+	weight uint16
+}
+
+func (d *weightDelegate) NodeWeight() uint16 { return d.weight }
+
+func TestMemberlist_Weight(t *testing.T) {
+	m1 := GetMemberlist(t, func(c *Config) {
+		c.Weight = 50
+	})
+	defer func() {
+		require.NoError(t, m1.Shutdown())
+	}()
+	require.NoError(t, m1.setAlive())
+	m1.schedule()
+
+	m2 := GetMemberlist(t, nil)
+	defer func() {
+		require.NoError(t, m2.Shutdown())
+	}()
+	require.NoError(t, m2.setAlive())
+	m2.schedule()
+	_, err := m2.Join([]string{m1.config.Name + "/" + m1.LocalNode().Address()})
+	require.NoError(t, err)
+
+	weightOf := func(m *Memberlist, name string) uint16 {
+		m.nodeLock.RLock()
+		defer m.nodeLock.RUnlock()
+		return m.nodeMap[name].Weight
+	}
+	require.Equal(t, uint16(50), weightOf(m2, m1.config.Name))
+	require.Equal(t, uint16(DefaultWeight), m2.LocalNode().WeightOrDefault())
+
+	require.NoError(t, m1.SetWeight(200))
+	require.Eventually(t, func() bool {
+		return weightOf(m2, m1.config.Name) == 200
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// A delegate takes precedence.
+	m1.SetDelegate(&weightDelegate{weight: 7})
+	require.NoError(t, m1.UpdateNode(time.Second))
+	require.Eventually(t, func() bool {
+		return weightOf(m2, m1.config.Name) == 7
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, m1.SetWeight(300))
+	require.Equal(t, uint16(7), m1.LocalNode().Weight)
+}
+
+func TestKRandomWeightedNodes(t *testing.T) {
+	nodes := []*nodeState{
+		{Node: Node{Name: "weak", Weight: 1}},
+		{Node: Node{Name: "strong", Weight: 1000}},
+		{Node: Node{Name: "default"}},
+		{Node: Node{Name: "excluded", Weight: 1000}},
+	}
+	exclude := func(n *nodeState) bool { return n.Name == "excluded" }
+
+	picks := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		kNodes := kRandomWeightedNodes(1, nodes, exclude)
+		require.Len(t, kNodes, 1)
+		picks[kNodes[0].Name]++
+	}
+	require.Zero(t, picks["excluded"])
+	require.Greater(t, picks["strong"], 800)
+	require.Less(t, picks["weak"], 20)
+	require.Greater(t, picks["default"], picks["weak"])
+
+	kNodes := kRandomWeightedNodes(10, nodes, exclude)
+	require.Len(t, kNodes, 3)
+	require.Empty(t, kRandomWeightedNodes(0, nodes, exclude))
+}